@@ -8,49 +8,82 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-playground/validator/v10"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pressly/goose/v3"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
 	"github.com/vasujain275/reforge/internal/admin"
 	"github.com/vasujain275/reforge/internal/attempts"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/calendar"
+	"github.com/vasujain275/reforge/internal/companies"
 	"github.com/vasujain275/reforge/internal/dashboard"
+	"github.com/vasujain275/reforge/internal/digest"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/goals"
 	dataimport "github.com/vasujain275/reforge/internal/import"
+	"github.com/vasujain275/reforge/internal/jobs"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/metrics"
 	"github.com/vasujain275/reforge/internal/onboarding"
 	"github.com/vasujain275/reforge/internal/patterns"
+	"github.com/vasujain275/reforge/internal/preferences"
 	"github.com/vasujain275/reforge/internal/problems"
 	"github.com/vasujain275/reforge/internal/scoring"
 	"github.com/vasujain275/reforge/internal/sessions"
 	"github.com/vasujain275/reforge/internal/settings"
 	"github.com/vasujain275/reforge/internal/users"
 	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/vacations"
+	"github.com/vasujain275/reforge/internal/webhooks"
 )
 
-func (app *application) mount() http.Handler {
+// mount builds the router; shutdown is cancelled when the server starts
+// shutting down, which ends any streaming responses
+func (app *application) mount(shutdown context.Context) http.Handler {
 	r := chi.NewRouter()
 
+	metricsRegistry := metrics.NewRegistry()
+	metricsRegistry.ObservePool(app.pool)
+
 	r.Use(middleware.RequestID)
+	r.Use(logging.Middleware)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(metricsRegistry.Middleware)
 	r.Use(app.CORSMiddleware)
+	r.Use(app.BodyLimitMiddleware)
+	r.Use(CompressMiddleware(5))
 
-	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(TimeoutMiddleware(60 * time.Second))
 
 	repoInstance := repo.New(app.pool)
+	transactor := postgres.NewTransactor(app.pool)
 
 	// Determine production status from config
 	isProd := app.config.env == "prod"
 
 	// Services
 	scoringService := scoring.NewService(repoInstance)
-	userService := users.NewService(repoInstance)
-	authService := auth.NewService(repoInstance, app.config.auth.secret)
-	problemService := problems.NewService(repoInstance, scoringService)
-	patternService := patterns.NewService(repoInstance)
-	sessionService := sessions.NewService(repoInstance, scoringService)
-	attemptService := attempts.NewService(repoInstance, scoringService)
-	dashboardService := dashboard.NewService(repoInstance)
+	userService := users.NewService(repoInstance, transactor)
+	authService := auth.NewService(repoInstance, app.config.auth.secret, auth.NewMemoryAttemptStore(), auth.TokenLifetimes{
+		Session:    app.config.auth.sessionTTL,
+		RememberMe: app.config.auth.rememberMeTTL,
+	})
+	webhookService := webhooks.NewService(repoInstance)
+	problemService := problems.NewService(repoInstance, transactor, scoringService, problems.NewMetadataFetcher(nil), webhookService)
+	patternService := patterns.NewService(repoInstance, transactor)
+	companyService := companies.NewService(repoInstance, transactor)
+	timerHub := events.NewHub()
+	sessionService := sessions.NewService(repoInstance, scoringService, metricsRegistry, timerHub, webhookService)
+	attemptService := attempts.NewService(repoInstance, transactor, scoringService, metricsRegistry, timerHub, webhookService)
+	goalService := goals.NewService(repoInstance)
+	preferencesService := preferences.NewService(repoInstance)
+	dashboardService := dashboard.NewService(repoInstance, goalService)
+	digestService := digest.NewService(repoInstance, problemService, dashboardService)
+	calendarService := calendar.NewService(repoInstance, dashboardService, preferencesService)
+	vacationService := vacations.NewService(repoInstance, transactor, preferencesService)
 
 	// Create default weights from config
 	defaultWeights := &settings.ScoringWeightsResponse{
@@ -62,56 +95,95 @@ func (app *application) mount() http.Handler {
 		WFailed:     app.config.defaultWeights.wFailed,
 		WPattern:    app.config.defaultWeights.wPattern,
 	}
-	settingsService := settings.NewService(repoInstance, defaultWeights)
-	adminService := admin.NewService(repoInstance)
+	settingsService := settings.NewService(repoInstance, scoringService, defaultWeights)
+	adminService := admin.NewService(repoInstance, transactor)
 	onboardingService := onboarding.NewService(repoInstance)
-	importService := dataimport.NewService(repoInstance, app.pool, app.config.datasetPath)
+	importService := dataimport.NewService(repoInstance, transactor, app.config.datasetPath, app.config.maxImportRows, metricsRegistry)
 
 	// Handlers
 	userHandler := users.NewHandler(userService, adminService)
 	authHandler := auth.NewHandler(authService, isProd)
-	problemHandler := problems.NewHandler(problemService)
+	problemHandler := problems.NewHandler(problemService, preferencesService)
 	patternHandler := patterns.NewHandler(patternService)
+	companyHandler := companies.NewHandler(companyService)
 	sessionHandler := sessions.NewHandler(sessionService)
-	attemptHandler := attempts.NewHandler(attemptService)
-	dashboardHandler := dashboard.NewHandler(dashboardService)
+	attemptHandler := attempts.NewHandler(attemptService, timerHub)
+	dashboardHandler := dashboard.NewHandler(dashboardService, preferencesService)
+	goalHandler := goals.NewHandler(goalService, preferencesService)
+	preferencesHandler := preferences.NewHandler(preferencesService)
+	digestHandler := digest.NewHandler(digestService, preferencesService)
+	webhookHandler := webhooks.NewHandler(webhookService)
+	vacationHandler := vacations.NewHandler(vacationService)
+	calendarHandler := calendar.NewHandler(calendarService)
 	settingsHandler := settings.NewHandler(settingsService)
 	adminHandler := admin.NewHandler(adminService)
 	onboardingHandler := onboarding.NewHandler(onboardingService)
 	importHandler := dataimport.NewHandler(importService)
+	jobsHandler := jobs.NewHandler(app.jobs)
+
+	endOnShutdown := EndOnShutdownMiddleware(shutdown)
 
 	r.Route("/api/v1", func(r chi.Router) {
-		r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-			utils.Write(w, http.StatusOK, healthResponse{Status: "ok"})
-		})
+		r.Use(app.CSRFMiddleware)
+
+		// Liveness and readiness; the bare path is kept as liveness for older healthchecks
+		r.Get("/health", app.HealthLive)
+		r.Get("/health/live", app.HealthLive)
+		r.Get("/health/ready", app.HealthReady)
 
 		// Onboarding Endpoints (Public - for first-time setup)
 		r.Route("/onboarding", func(r chi.Router) {
 			r.Get("/status", onboardingHandler.GetInitStatus)
 			r.Post("/setup", onboardingHandler.CreateFirstAdmin)
-			// Import endpoints for onboarding (no auth required during setup)
-			r.Get("/import/datasets", importHandler.GetBundledDatasets)
-			r.Post("/import/parse", importHandler.ParseBundledDataset)
-			r.Get("/import/execute", importHandler.ExecuteImport)
+			// Import endpoints for onboarding (no auth, so only before the first user exists)
+			r.Group(func(r chi.Router) {
+				r.Use(app.RequireUninitializedMiddleware)
+				r.Get("/import/datasets", importHandler.GetBundledDatasets)
+				r.Post("/import/parse", importHandler.ParseBundledDataset)
+				r.With(endOnShutdown, app.DataVersionMiddleware).Get("/import/execute", importHandler.ExecuteImport)
+			})
 		})
 
+		// Calendar feed (Public - authorized by the token in the URL)
+		r.Get("/calendar.ics", calendarHandler.GetFeed)
+
 		// Auth Endpoints
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/login", authHandler.Login)
 			r.Post("/logout", authHandler.Logout)
 			r.Post("/refresh", authHandler.Refresh)
+			r.Post("/reset-password", userHandler.ResetPassword) // Public Password Reset
 		})
 
 		// User Routes
 		r.Route("/users", func(r chi.Router) {
-			r.Post("/", userHandler.CreateUser)                  // Public Registration
-			r.Post("/reset-password", userHandler.ResetPassword) // Public Password Reset
+			r.Post("/", userHandler.CreateUser) // Public Registration
 
 			r.Group(func(r chi.Router) {
 				r.Use(app.AuthTokenMiddleware)
 				r.Get("/me", userHandler.GetCurrentUser)
+				r.Put("/me", userHandler.UpdateProfile)
 				r.Put("/me/password", userHandler.ChangePassword)
 				r.Delete("/me", userHandler.DeleteOwnAccount)
+				r.Get("/me/api-keys", userHandler.ListAPIKeys)
+				r.Post("/me/api-keys", userHandler.CreateAPIKey)
+				r.Delete("/me/api-keys/{id}", userHandler.RevokeAPIKey)
+				r.Get("/me/sessions", userHandler.ListSessions)
+				r.Post("/me/sessions/revoke-all", userHandler.RevokeAllSessions)
+				r.Get("/me/preferences", preferencesHandler.GetPreferences)
+				r.Put("/me/preferences", preferencesHandler.UpdatePreferences)
+				r.Get("/me/webhooks", webhookHandler.ListWebhooks)
+				r.Post("/me/webhooks", webhookHandler.CreateWebhook)
+				r.Delete("/me/webhooks/{id}", webhookHandler.DeleteWebhook)
+				r.Get("/me/webhooks/{id}/deliveries", webhookHandler.ListDeliveries)
+				r.Post("/me/webhooks/{id}/test", webhookHandler.TestWebhook)
+				r.Get("/me/vacation", vacationHandler.ListVacations)
+				r.Post("/me/vacation", vacationHandler.CreateVacation)
+				r.Delete("/me/vacation/{id}", vacationHandler.CancelVacation)
+				r.Get("/me/calendar-feed", calendarHandler.GetFeedToken)
+				r.Post("/me/calendar-feed", calendarHandler.CreateFeedToken)
+				r.Delete("/me/calendar-feed", calendarHandler.RevokeFeedToken)
+				r.Delete("/me/sessions/{id}", userHandler.RevokeSession)
 			})
 		})
 
@@ -121,33 +193,86 @@ func (app *application) mount() http.Handler {
 		// Protected Routes (require authentication)
 		r.Group(func(r chi.Router) {
 			r.Use(app.AuthTokenMiddleware)
+			r.Use(app.DataVersionMiddleware)
 
 			// Dashboard
 			r.Get("/dashboard/stats", dashboardHandler.GetDashboardStats)
+			r.Get("/dashboard/forecast", dashboardHandler.GetReviewForecast)
+			r.Get("/dashboard/heatmap", dashboardHandler.GetActivityHeatmap)
+			r.Get("/dashboard/weekly-report", dashboardHandler.GetWeeklyReport)
+			r.Get("/dashboard/time-spent", dashboardHandler.GetTimeSpent)
+			r.Get("/dashboard/confidence-trend", dashboardHandler.GetConfidenceTrend)
+			r.Get("/dashboard/activity", dashboardHandler.GetActivityFeed)
+			r.Get("/dashboard/breakdown", dashboardHandler.GetBreakdown)
+
+			// Morning summary for scripts and widgets
+			r.Get("/digest", digestHandler.GetDigest)
+
+			// Practice goals
+			r.Route("/goals", func(r chi.Router) {
+				r.Get("/", goalHandler.ListGoals)
+				r.Post("/", goalHandler.CreateGoal)
+				r.Get("/progress", goalHandler.GetGoalProgress)
+				r.Put("/{id}", goalHandler.UpdateGoal)
+				r.Delete("/{id}", goalHandler.DeleteGoal)
+			})
 
 			// Problems
 			r.Route("/problems", func(r chi.Router) {
-				r.Get("/", problemHandler.ListProblemsForUser)
+				r.With(app.ETagMiddleware).Get("/", problemHandler.ListProblemsForUser)
 				r.Post("/", problemHandler.CreateProblem)
+				r.Post("/bulk-delete", problemHandler.BulkDeleteProblems)
+				r.Post("/bulk-update", problemHandler.BulkUpdateProblems)
+				r.Post("/bulk-link-pattern", problemHandler.BulkLinkPattern)
+				r.Post("/preview-url", problemHandler.PreviewProblemURL)
+				r.Get("/export", problemHandler.ExportProblems)
+				r.Get("/random", problemHandler.GetRandomProblem)
+				r.Get("/breakdown", problemHandler.GetProblemBreakdown)
 				r.Get("/urgent", problemHandler.GetUrgentProblems)
+				r.Get("/due", problemHandler.GetDueProblems)
+				r.Get("/leeches", problemHandler.GetLeechProblems)
+				r.Get("/unpatterned", problemHandler.GetUnpatternedProblems)
 				r.Get("/{id}", problemHandler.GetProblem)
 				r.Put("/{id}", problemHandler.UpdateProblem)
 				r.Delete("/{id}", problemHandler.DeleteProblem)
 				r.Get("/{id}/attempts", attemptHandler.ListAttemptsForProblem)
+				r.Get("/{id}/score", problemHandler.GetProblemScore)
+				r.Put("/{id}/notes", problemHandler.UpdateProblemNotes)
+				r.Post("/{id}/star", problemHandler.StarProblem)
+				r.Delete("/{id}/star", problemHandler.UnstarProblem)
+				r.Get("/{id}/related", problemHandler.GetRelatedProblems)
+				r.Post("/{id}/status", problemHandler.UpdateProblemStatus)
+				r.Post("/{id}/snooze", problemHandler.SnoozeProblem)
+				r.Delete("/{id}/snooze", problemHandler.CancelSnooze)
 			})
 
 			// Patterns
 			r.Route("/patterns", func(r chi.Router) {
-				r.Get("/", patternHandler.ListPatternsWithStats)
+				r.With(app.ETagMiddleware).Get("/", patternHandler.ListPatternsWithStats)
 				r.Post("/", patternHandler.CreatePattern)
+				r.Get("/weakest", patternHandler.GetWeakestPatterns)
 				r.Get("/{id}", patternHandler.GetPattern)
 				r.Put("/{id}", patternHandler.UpdatePattern)
 				r.Delete("/{id}", patternHandler.DeletePattern)
+				r.Get("/{id}/problems", patternHandler.GetPatternProblems)
+				r.Post("/{id}/problems", patternHandler.LinkProblems)
+				r.Delete("/{id}/problems", patternHandler.UnlinkProblems)
+				r.Get("/{id}/history", patternHandler.GetPatternHistory)
+				r.Post("/{id}/merge", patternHandler.MergePatterns)
+			})
+
+			// Companies
+			r.Route("/companies", func(r chi.Router) {
+				r.With(app.ETagMiddleware).Get("/", companyHandler.ListCompanies)
+				r.Post("/", companyHandler.CreateCompany)
+				r.Delete("/{id}", companyHandler.DeleteCompany)
+				r.Post("/{id}/problems", companyHandler.LinkProblems)
+				r.Delete("/{id}/problems", companyHandler.UnlinkProblems)
 			})
 
 			// Sessions
 			r.Route("/sessions", func(r chi.Router) {
-				r.Get("/", sessionHandler.ListSessionsForUser)
+				r.With(app.ETagMiddleware).Get("/", sessionHandler.ListSessionsForUser)
 				r.Post("/", sessionHandler.CreateSession)
 				r.Post("/generate", sessionHandler.GenerateSession)
 				r.Post("/generate/custom", sessionHandler.GenerateCustomSession)
@@ -166,17 +291,29 @@ func (app *application) mount() http.Handler {
 				// Timer-based attempt endpoints
 				r.Post("/start", attemptHandler.StartAttempt)
 				r.Get("/in-progress", attemptHandler.GetInProgressAttempt)
+				r.With(endOnShutdown).Get("/stream", attemptHandler.Stream) // SSE endpoint
 				r.Get("/{id}", attemptHandler.GetAttemptByID)
 				r.Put("/{id}/timer", attemptHandler.UpdateAttemptTimer)
 				r.Put("/{id}/complete", attemptHandler.CompleteAttempt)
 				r.Delete("/{id}", attemptHandler.AbandonAttempt)
 			})
 
-			// Settings
+			// Account backup
+			r.Get("/export/backup", importHandler.ExportBackup)
+			r.With(endOnShutdown).Post("/import/restore", importHandler.RestoreBackup)
+
+			// Anki deck of due problems
+			r.Get("/export/anki", problemHandler.ExportAnkiDeck)
+
+			// Settings; weights are global and changing them drops every
+			// user's cached scores, so only admins may change or preview them
 			r.Route("/settings", func(r chi.Router) {
 				r.Get("/weights", settingsHandler.GetScoringWeights)
 				r.Get("/weights/defaults", settingsHandler.GetDefaultWeights)
-				r.Put("/weights", settingsHandler.UpdateScoringWeights)
+				r.With(app.RequireAdminMiddleware).Put("/weights", settingsHandler.UpdateScoringWeights)
+				r.With(app.RequireAdminMiddleware).Post("/weights/preview", settingsHandler.PreviewScoringWeights)
+				r.Get("/weights/presets", settingsHandler.ListPresets)
+				r.With(app.RequireAdminMiddleware).Post("/weights/apply-preset", settingsHandler.ApplyPreset)
 			})
 
 			// Admin Routes (require admin role)
@@ -186,17 +323,32 @@ func (app *application) mount() http.Handler {
 				// User Management
 				r.Route("/users", func(r chi.Router) {
 					r.Get("/", adminHandler.ListUsers)
+					r.Get("/{id}", adminHandler.GetUser)
 					r.Post("/{id}/role", adminHandler.UpdateUserRole)
 					r.Post("/{id}/deactivate", adminHandler.DeactivateUser)
 					r.Post("/{id}/reactivate", adminHandler.ReactivateUser)
 					r.Delete("/{id}", adminHandler.DeleteUser)
+					r.Post("/{id}/purge", adminHandler.PurgeUser)
 					r.Post("/{id}/reset-password", adminHandler.InitiatePasswordReset)
 				})
 
+				// Instance-wide aggregates
+				r.Get("/stats", adminHandler.GetInstanceStats)
+
+				// Maintenance
+				r.Post("/maintenance/cleanup-tokens", adminHandler.CleanupTokens)
+
+				// Background Jobs
+				r.Route("/jobs", func(r chi.Router) {
+					r.Get("/", jobsHandler.ListJobs)
+					r.Post("/{name}/run", jobsHandler.RunJob)
+				})
+
 				// Invite Codes
 				r.Route("/invites", func(r chi.Router) {
 					r.Get("/", adminHandler.ListInviteCodes)
 					r.Post("/", adminHandler.CreateInviteCode)
+					r.Get("/{id}/uses", adminHandler.ListInviteCodeUses)
 					r.Delete("/{id}", adminHandler.DeleteInviteCode)
 				})
 
@@ -207,14 +359,23 @@ func (app *application) mount() http.Handler {
 					r.Put("/signup/invites", adminHandler.UpdateInviteCodesEnabled)
 				})
 
+				// Pattern taxonomy transfer between instances
+				r.Route("/patterns", func(r chi.Router) {
+					r.Get("/export", patternHandler.ExportPatterns)
+					r.Post("/import", patternHandler.ImportPatterns)
+				})
+
 				// Data Import (under /admin/data)
 				r.Route("/data", func(r chi.Router) {
 					r.Route("/import", func(r chi.Router) {
 						r.Get("/datasets", importHandler.GetBundledDatasets)
 						r.Post("/parse", importHandler.ParseBundledDataset)
 						r.Post("/parse-upload", importHandler.ParseUploadedCSV)
-						r.Get("/execute", importHandler.ExecuteImport)               // SSE endpoint
-						r.Post("/execute-upload", importHandler.ExecuteUploadImport) // SSE endpoint
+						r.With(endOnShutdown).Get("/execute", importHandler.ExecuteImport)               // SSE endpoint
+						r.With(endOnShutdown).Post("/execute-upload", importHandler.ExecuteUploadImport) // SSE endpoint
+						r.Get("/jobs", importHandler.ListImportJobs)
+						r.Get("/jobs/{id}", importHandler.GetImportJob)
+						r.Get("/jobs/{id}/errors.csv", importHandler.GetImportJobErrors)
 					})
 				})
 			})
@@ -222,6 +383,9 @@ func (app *application) mount() http.Handler {
 
 	})
 
+	// Prometheus scrape endpoint, outside /api/v1 so it skips CSRF and auth
+	r.Method(http.MethodGet, "/metrics", metricsRegistry.Handler(app.config.metricsToken))
+
 	// Health check endpoint for API
 	r.Get("/", func(w http.ResponseWriter, req *http.Request) {
 		utils.Write(w, http.StatusOK, map[string]string{
@@ -243,34 +407,46 @@ func (app *application) run(ctx context.Context, h http.Handler) error {
 	}
 
 	// Start server in goroutine
+	serverErr := make(chan error, 1)
 	go func() {
 		slog.Info("Server has started", "addr", app.config.addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("Server error", "error", err)
+			serverErr <- err
 		}
 	}()
 
-	// Wait for shutdown signal
-	<-ctx.Done()
-	slog.Info("Shutting down server gracefully...")
+	// Wait for shutdown signal, or the listener failing
+	select {
+	case <-ctx.Done():
+	case err := <-serverErr:
+		return err
+	}
+	slog.Info("Shutdown signal received, draining connections", "grace_period", app.config.shutdownTimeout)
 
 	// Graceful shutdown with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.config.shutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		slog.Error("Server forced to shutdown", "error", err)
-		return err
+		// Whatever is still running is cut off; that's the end of the grace period, not a failure
+		slog.Error("Grace period expired, closing remaining connections", "error", err)
+		return srv.Close()
 	}
 
-	slog.Info("Server exited gracefully")
+	slog.Info("Server drained")
 	return nil
 }
 
 type application struct {
-	config   config
-	pool     *pgxpool.Pool
-	validate *validator.Validate
+	config config
+	pool   *pgxpool.Pool
+	// migrationVersion is the schema version reached at startup, which the
+	// readiness check expects the database to stay at
+	migrationVersion int64
+	// schema reads the applied migration version for the readiness check
+	schema *goose.Provider
+	// jobs runs the periodic background work admins can inspect and trigger
+	jobs *jobs.Runner
 }
 
 type config struct {
@@ -280,14 +456,34 @@ type config struct {
 	auth           authConfig
 	defaultWeights scoringWeightsConfig
 	datasetPath    string
+	maxImportRows  int
+	// shutdownTimeout is how long in-flight requests get to finish on shutdown
+	shutdownTimeout time.Duration
+	// metricsToken, if set, must be sent as a bearer token to read /metrics
+	metricsToken string
+	// corsAllowedOrigins are the origins (or https://*.domain patterns) that
+	// may make credentialed cross-origin requests in prod
+	corsAllowedOrigins []string
+	// maxBodyBytes caps request bodies; maxUploadBytes replaces it on uploadPaths
+	maxBodyBytes   int64
+	maxUploadBytes int64
 }
 
 type dbConfig struct {
 	dsn string
+	// autoMigrate applies pending migrations at startup; without it a schema
+	// behind the build is an error
+	autoMigrate bool
+	// connectAttempts and connectBackoff bound the startup wait for the
+	// database; the backoff doubles after each failed attempt
+	connectAttempts int
+	connectBackoff  time.Duration
 }
 
 type authConfig struct {
-	secret string
+	secret        string
+	sessionTTL    time.Duration // Refresh token lifetime without "remember me"
+	rememberMeTTL time.Duration
 }
 
 type scoringWeightsConfig struct {
@@ -299,7 +495,3 @@ type scoringWeightsConfig struct {
 	wFailed     float64
 	wPattern    float64
 }
-
-type healthResponse struct {
-	Status string `json:"status"`
-}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// Scoring weights are global, so a plain user can't change them for everyone
+// or wipe everyone's cached scores, nor preview a change they can't make
+func TestSettingsWeightWritesRequireAdmin(t *testing.T) {
+	// The role claim is refused before any query, so the pool never connects
+	pool, err := pgxpool.New(context.Background(), "postgres://reforge@127.0.0.1:1/reforge")
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+	app := &application{
+		config: config{auth: authConfig{secret: testJWTSecret}, maxBodyBytes: 1 << 20},
+		pool:   pool,
+	}
+	handler := app.mount(context.Background())
+	token := accessToken(t, uuid.New(), "user")
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/api/v1/settings/weights", `{"w_conf":1}`},
+		{http.MethodPost, "/api/v1/settings/weights/apply-preset", `{"preset":"maintenance"}`},
+		{http.MethodPost, "/api/v1/settings/weights/preview", `{"w_conf":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(auth.CSRFHeader, "csrf")
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+			req.AddCookie(&http.Cookie{Name: auth.CSRFTokenCookie, Value: "csrf"})
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body.String())
+			}
+			if code := errorCode(t, rec); code != utils.ErrCodeForbidden {
+				t.Errorf("error code = %q, want %q", code, utils.ErrCodeForbidden)
+			}
+		})
+	}
+}
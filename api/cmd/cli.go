@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	dataimport "github.com/vasujain275/reforge/internal/import"
+	"github.com/vasujain275/reforge/internal/metrics"
+)
+
+// Exit codes, so scripts can tell a bad invocation from a failed operation
+const (
+	exitOK           = 0
+	exitRuntimeError = 1
+	exitConfigError  = 2
+)
+
+// configError marks failures caused by flags or environment rather than by
+// the operation itself; they exit with exitConfigError
+type configError struct {
+	err error
+}
+
+func (e configError) Error() string { return e.err.Error() }
+func (e configError) Unwrap() error { return e.err }
+
+func configErrorf(format string, args ...any) error {
+	return configError{err: fmt.Errorf(format, args...)}
+}
+
+// command is one subcommand of the binary
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, cfg config, args []string) error
+}
+
+// commands lists the subcommands in help order; serve is the default
+var commands = []command{
+	{"serve", "Run the HTTP API (default)", serve},
+	{"migrate", "Apply migrations (up) or show their status (status)", runMigrate},
+	{"import", "Import problems from a CSV file or bundled dataset", runImport},
+	{"export", "Write a user's account backup as JSON", runExport},
+	{"create-admin", "Create an admin account", runCreateAdmin},
+	{"seed-demo", "Seed an empty database with a demo user and practice history", runSeedDemo},
+}
+
+// runCLI dispatches to a subcommand and maps its error to an exit code
+func runCLI(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return exitConfigError
+	}
+
+	// Only the server logs to stdout; other commands keep it for their output,
+	// e.g. export writing a backup there
+	cfg := loadConfig()
+	logOut := os.Stderr
+	if cmd.name == "serve" {
+		logOut = os.Stdout
+	}
+	setupLogger(cfg, logOut)
+
+	ctx, stop := signalContext()
+	defer stop()
+
+	err := cmd.run(ctx, cfg, args)
+	var cfgErr configError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &cfgErr):
+		slog.Error("Invalid configuration", "command", name, "error", err)
+		return exitConfigError
+	default:
+		slog.Error("Command failed", "command", name, "error", err)
+		return exitRuntimeError
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: reforge-api [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'reforge-api <command> -h' for a command's flags.")
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
+
+// parseFlags parses args and rejects stray positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return configError{err: err}
+	}
+	if fs.NArg() > 0 {
+		return configErrorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// runMigrate - migrate [up|status]
+func runMigrate(ctx context.Context, cfg config, args []string) error {
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := newFlagSet("migrate")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		_, err := migrateDatabase(ctx, cfg)
+		return err
+	case "status":
+		db, err := openMigrationDB(cfg)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return goose.StatusContext(ctx, db, ".")
+	default:
+		return configErrorf("unknown migrate action %q, want up or status", action)
+	}
+}
+
+// runImport - import --file problems.csv | --dataset leetcode
+func runImport(ctx context.Context, cfg config, args []string) error {
+	fs := newFlagSet("import")
+	file := fs.String("file", "", "CSV file to import")
+	dataset := fs.String("dataset", "", "bundled dataset ID to import instead of a file")
+	onDuplicate := fs.String("on-duplicate", dataimport.DuplicateSkip, "what to do with existing problems: skip, update or fail")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if (*file == "") == (*dataset == "") {
+		return configErrorf("exactly one of --file or --dataset is required")
+	}
+	if _, err := dataimport.NormalizeDuplicateMode(*onDuplicate); err != nil {
+		return configError{err: err}
+	}
+
+	pool, _, err := openMigratedDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	importService := dataimport.NewService(repo.New(pool), postgres.NewTransactor(pool), cfg.datasetPath, cfg.maxImportRows, metrics.Noop{})
+	progress := newConsoleProgress(os.Stderr)
+
+	var result *dataimport.ImportResult
+	if *dataset != "" {
+		result, err = importService.ExecuteImport(ctx, dataimport.ImportOptions{
+			UseBundled:  true,
+			DatasetID:   *dataset,
+			OnDuplicate: *onDuplicate,
+			TagDataset:  true,
+		}, progress.report)
+	} else {
+		f, openErr := os.Open(*file)
+		if openErr != nil {
+			return configError{err: openErr}
+		}
+		defer f.Close()
+		result, err = importService.ExecuteImportFromReader(ctx, f, dataimport.ImportOptions{
+			OnDuplicate: *onDuplicate,
+		}, progress.report)
+	}
+	progress.done()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "Imported %d problems (%d updated, %d duplicates skipped, %d patterns created, %d errors) in %s\n",
+		result.ProblemsCreated, result.ProblemsUpdated, result.DuplicatesSkipped, result.PatternsCreated, len(result.Errors), result.Duration)
+	for _, e := range result.Errors {
+		fmt.Fprintf(os.Stderr, "  row %d (%s): %s\n", e.RowNumber, e.Title, e.Error)
+	}
+	if result.Cancelled {
+		return errors.New("import cancelled; counts are partial")
+	}
+	return nil
+}
+
+// runExport - export --email user@example.com --out backup.json
+func runExport(ctx context.Context, cfg config, args []string) error {
+	fs := newFlagSet("export")
+	email := fs.String("email", "", "email of the user whose backup to write")
+	out := fs.String("out", "-", "file to write, or - for stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return configErrorf("--email is required")
+	}
+
+	pool, _, err := openMigratedDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	queries := repo.New(pool)
+	user, err := queries.GetUserByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("failed to find user %s: %w", *email, err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return configError{err: err}
+		}
+		defer f.Close()
+		w = f
+	}
+
+	bw := bufio.NewWriter(w)
+	importService := dataimport.NewService(queries, postgres.NewTransactor(pool), cfg.datasetPath, cfg.maxImportRows, metrics.Noop{})
+	if err := importService.ExportBackup(ctx, user.ID, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// runCreateAdmin - create-admin --email admin@example.com [--name ...]
+// The password is read from stdin unless --password is given, so it stays out
+// of shell history and the process list.
+func runCreateAdmin(ctx context.Context, cfg config, args []string) error {
+	fs := newFlagSet("create-admin")
+	email := fs.String("email", "", "admin email (used for login)")
+	name := fs.String("name", "System Administrator", "admin display name")
+	password := fs.String("password", "", "admin password; read from stdin when empty")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return configErrorf("--email is required")
+	}
+
+	if *password == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return configErrorf("failed to read password: %v", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	if len(*password) < 8 {
+		return configErrorf("password must be at least 8 characters")
+	}
+
+	pool, _, err := openMigratedDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	user, err := createAdmin(ctx, repo.New(pool), *email, *name, *password)
+	if err != nil {
+		return err
+	}
+	slog.Info("Admin user created", "id", user.ID, "email", user.Email)
+	return nil
+}
+
+// runSeedDemo - seed-demo [--seed N] [--force]
+func runSeedDemo(ctx context.Context, cfg config, args []string) error {
+	fs := newFlagSet("seed-demo")
+	seed := fs.Uint64("seed", 1, "random seed; the same seed yields the same history")
+	force := fs.Bool("force", false, "seed even when the database already has data")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	pool, _, err := openMigratedDatabase(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	return seedDemoData(ctx, cfg, pool, demoSeedOptions{seed: *seed, force: *force})
+}
+
+// consoleProgress prints import progress where the HTTP API would send SSE
+// events, rewriting one line per phase
+type consoleProgress struct {
+	w     io.Writer
+	phase string
+}
+
+func newConsoleProgress(w io.Writer) *consoleProgress {
+	return &consoleProgress{w: w}
+}
+
+func (p *consoleProgress) report(progress dataimport.ImportProgress) {
+	if progress.Phase != p.phase && p.phase != "" {
+		fmt.Fprintln(p.w)
+	}
+	p.phase = progress.Phase
+
+	if progress.Error != "" {
+		fmt.Fprintf(p.w, "\r%s: %s", progress.Phase, progress.Error)
+		return
+	}
+	fmt.Fprintf(p.w, "\r%-9s %5.1f%% (%d/%d) created=%d updated=%d skipped=%d",
+		progress.Phase, progress.Percentage, progress.CurrentIndex+1, progress.TotalItems,
+		progress.ProblemsCreated, progress.ProblemsUpdated, progress.DuplicatesSkipped)
+}
+
+// done ends the progress line so later output starts on a fresh one
+func (p *consoleProgress) done() {
+	if p.phase != "" {
+		fmt.Fprintln(p.w)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/problems"
+)
+
+// catalogScope is the data version of the problem, pattern and company
+// library every user shares; user data versions are scoped by user ID
+const catalogScope = "catalog"
+
+// catalogWritePrefixes are the paths whose writes can change the shared
+// library, on top of the caller's own data
+var catalogWritePrefixes = []string{
+	"/api/v1/problems",
+	"/api/v1/patterns",
+	"/api/v1/companies",
+	"/api/v1/import/restore",
+	"/api/v1/admin/data/import/execute",
+	"/api/v1/onboarding/import/execute",
+}
+
+// userOnlyProblemSuffixes are problem writes that only touch the caller's
+// own view of a problem
+var userOnlyProblemSuffixes = []string{"/star", "/notes", "/status", "/snooze"}
+
+// catalogWrite reports whether a write to path can change the shared library
+func catalogWrite(path string) bool {
+	for _, suffix := range userOnlyProblemSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return false
+		}
+	}
+	for _, prefix := range catalogWritePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWrite reports whether r changes data. The import endpoints that stream
+// progress over a GET are writes too.
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return strings.HasSuffix(r.URL.Path, "/import/execute")
+	}
+	return true
+}
+
+// DataVersionMiddleware bumps the caller's data version, and the catalog's
+// when the library may have changed, after every successful write. ETags on
+// list endpoints are built from these versions, so a write here is what makes
+// clients refetch. Unauthenticated writes (onboarding) only bump the catalog.
+// Writes made outside this group or outside any request (vacations, the
+// background jobs) bump the versions from their services instead.
+func (app *application) DataVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWrite(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if status := ww.Status(); status >= http.StatusBadRequest {
+			return
+		}
+
+		// The request context may be cancelled by now, e.g. after a stream
+		ctx := context.WithoutCancel(r.Context())
+		queries := repo.New(app.pool)
+		if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+			if err := queries.BumpDataVersion(ctx, userID.String()); err != nil {
+				logging.FromContext(ctx).Warn("Failed to bump user data version", "error", err)
+			}
+		}
+		if catalogWrite(r.URL.Path) {
+			if err := queries.BumpDataVersion(ctx, catalogScope); err != nil {
+				logging.FromContext(ctx).Warn("Failed to bump catalog data version", "error", err)
+			}
+		}
+	})
+}
+
+// ETagMiddleware answers conditional GETs on list endpoints from the data
+// versions alone, returning 304 when neither the catalog nor the caller's data
+// changed, and none of the caller's snoozes ended, since the client's copy.
+// The ETag is weak because compression changes the bytes but not the content.
+func (app *application) ETagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Scores grow with the time since each problem was last attempted,
+		// which no data version tracks, so score-sorted lists go untagged
+		if r.URL.Query().Get("sort_by") == problems.SortScoreDesc {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, _ := auth.UserIDFromContext(r.Context())
+		etag, err := app.dataETag(r, userID)
+		if err != nil {
+			// Serve the list uncached rather than fail it
+			logging.FromContext(r.Context()).Warn("Failed to read data versions", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Clients must revalidate, and shared caches must not keep per-user lists
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// dataETag is read before the handler loads its data, so a write landing in
+// between leaves the client with an older tag and it refetches next time.
+// Snoozed problems show as snoozed only until their snooze ends, so the
+// earliest active snooze end is part of the tag.
+func (app *application) dataETag(r *http.Request, userID uuid.UUID) (string, error) {
+	versions, err := repo.New(app.pool).GetDataVersions(r.Context(), userID.String())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`W/"%d.%d.%d"`, versions.CatalogVersion, versions.UserVersion, versions.NextSnoozeEnd.UnixMicro()), nil
+}
+
+// etagMatches implements If-None-Match's weak comparison over a list of tags
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/preferences"
+	"github.com/vasujain275/reforge/internal/problems"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// A revalidation with an unchanged ETag costs an empty 304 instead of the
+// gzipped list, until a write bumps the caller's data version; run with -v
+// for the sizes
+func TestETagMiddlewareListSizes(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := db.CreateUser(t, "etag@example.com")
+	app := &application{pool: db.Pool}
+
+	list := problemList(2000)
+	served := 0
+	etagged := app.ETagMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		utils.WriteSuccess(w, http.StatusOK, list)
+	}))
+	handler := CompressMiddleware(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etagged.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user.ID, "user")))
+	}))
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/problems", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first fetch = %d with ETag %q, want 200 and an ETag", first.Code, etag)
+	}
+
+	revalidated := get(etag)
+	if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Fatalf("revalidation = %d with %d bytes, want an empty 304", revalidated.Code, revalidated.Body.Len())
+	}
+	if served != 1 {
+		t.Errorf("list served %d times, want 1", served)
+	}
+	t.Logf("2000 problems: %d bytes gzipped, %d bytes on revalidation", first.Body.Len(), revalidated.Body.Len())
+
+	if err := db.Queries.BumpDataVersion(context.Background(), user.ID.String()); err != nil {
+		t.Fatalf("BumpDataVersion: %v", err)
+	}
+	changed := get(etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("after a write = %d with ETag %q, want 200 and a new ETag", changed.Code, changed.Header().Get("ETag"))
+	}
+}
+
+// A snooze ends with the clock, not a write, yet the list stops showing it
+// then; a client revalidating after that must get the new list, not a 304
+func TestETagMiddlewareSnoozeExpiry(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "snooze@example.com")
+	problem := db.CreateProblem(t, "Two Sum", "easy")
+	app := &application{pool: db.Pool}
+
+	snoozedUntil := time.Now().Add(time.Second)
+	if _, err := db.Pool.Exec(ctx,
+		"INSERT INTO user_problem_stats (user_id, problem_id, snoozed_until) VALUES ($1, $2, $3)",
+		user.ID, problem.ID, snoozedUntil,
+	); err != nil {
+		t.Fatalf("insert snoozed stats: %v", err)
+	}
+
+	service := problems.NewService(db.Queries, db.Transactor, scoring.NewService(db.Queries), nil, webhooks.Noop{})
+	handler := app.ETagMiddleware(http.HandlerFunc(problems.NewHandler(service, preferences.NewService(db.Queries)).ListProblemsForUser))
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/problems", nil)
+		req = req.WithContext(auth.WithUser(req.Context(), user.ID, "user"))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	snoozed := func(rec *httptest.ResponseRecorder) bool {
+		var body struct {
+			Data []problems.ProblemWithStats `json:"data"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode list: %v", err)
+		}
+		if len(body.Data) != 1 || body.Data[0].Stats == nil {
+			t.Fatalf("list = %+v, want the one problem with stats", body.Data)
+		}
+		return body.Data[0].Stats.SnoozedUntil != nil
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !snoozed(first) {
+		t.Fatalf("while snoozed = %d, want 200 with the snooze shown", first.Code)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("revalidating while snoozed = %d, want %d", rec.Code, http.StatusNotModified)
+	}
+
+	time.Sleep(time.Until(snoozedUntil) + 10*time.Millisecond)
+
+	expired := get(etag)
+	if expired.Code != http.StatusOK {
+		t.Fatalf("revalidating after the snooze ended = %d, want %d", expired.Code, http.StatusOK)
+	}
+	if expired.Header().Get("ETag") == etag {
+		t.Errorf("ETag %q unchanged after the snooze ended", etag)
+	}
+	if snoozed(expired) {
+		t.Errorf("list still shows the snooze after it ended")
+	}
+}
+
+// Score-sorted lists change with the clock alone, so they are always served
+// in full and never tagged; no data versions are read for them
+func TestETagMiddlewareSkipsScoreSortedLists(t *testing.T) {
+	app := &application{} // no pool: reading data versions would panic
+	served := 0
+	handler := app.ETagMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/problems?sort_by="+problems.SortScoreDesc, nil)
+	req.Header.Set("If-None-Match", "*")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || served != 1 {
+		t.Errorf("status = %d with %d handler calls, want 200 and 1", rec.Code, served)
+	}
+	if etag := rec.Header().Get("ETag"); etag != "" {
+		t.Errorf("ETag = %q, want none", etag)
+	}
+}
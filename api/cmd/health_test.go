@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+func ready(t *testing.T, app *application) (*httptest.ResponseRecorder, readinessResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	app.HealthReady(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health/ready", nil))
+
+	var resp readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode readiness body %q: %v", rec.Body.String(), err)
+	}
+	return rec, resp
+}
+
+// An unreachable database fails readiness without the driver's error, which
+// names the host, in the unauthenticated body
+func TestHealthReadyHidesDriverErrors(t *testing.T) {
+	pool, err := pgxpool.New(context.Background(), "postgres://reforge@127.0.0.1:1/reforge?connect_timeout=1")
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	defer pool.Close()
+	schema, err := loadMigrations(stdlib.OpenDBFromPool(pool))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{pool: pool, schema: schema, migrationVersion: 1}
+
+	rec, resp := ready(t, app)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	for _, name := range []string{"database", "migrations"} {
+		component := resp.Components[name]
+		if component.Status != "error" || component.Error != errUnavailable {
+			t.Errorf("%s = %+v, want error %q", name, component, errUnavailable)
+		}
+	}
+	if body := rec.Body.String(); strings.Contains(body, "127.0.0.1") || strings.Contains(body, "reforge@") {
+		t.Errorf("body leaks connection details: %s", body)
+	}
+}
+
+// A fully migrated database is ready at the version goose reports
+func TestHealthReadyMigrated(t *testing.T) {
+	db := testutil.NewDB(t)
+	schema, err := loadMigrations(stdlib.OpenDBFromPool(db.Pool))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := schema.ListSources()
+	latest := sources[len(sources)-1].Version
+	app := &application{pool: db.Pool, schema: schema, migrationVersion: latest}
+
+	rec, resp := ready(t, app)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	migrations := resp.Components["migrations"]
+	if migrations.Status != "ok" || migrations.Version == nil || *migrations.Version != latest {
+		t.Errorf("migrations = %+v, want ok at version %d", migrations, latest)
+	}
+}
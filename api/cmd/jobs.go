@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/admin"
+	"github.com/vasujain275/reforge/internal/attempts"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/jobs"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/preferences"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/vacations"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+const (
+	// tokenCleanupInterval is how often expired auth tokens are swept
+	tokenCleanupInterval = 6 * time.Hour
+
+	// staleAttemptInterval is how often abandoned timers are swept, and
+	// staleAttemptIdle how long an in-progress attempt may go without a timer
+	// sync before it counts as abandoned
+	staleAttemptInterval = time.Hour
+	staleAttemptIdle     = 24 * time.Hour
+
+	// webhookDeliveryInterval is how often queued webhook deliveries are sent
+	webhookDeliveryInterval = 15 * time.Second
+
+	// vacationShiftInterval is how often vacations that have started get
+	// their schedule shift
+	vacationShiftInterval = 15 * time.Minute
+)
+
+// newJobRunner registers the background jobs. Start the runner once the rest
+// of the application is set up; the jobs stop when its context is cancelled.
+func (app *application) newJobRunner() *jobs.Runner {
+	queries := repo.New(app.pool)
+	transactor := postgres.NewTransactor(app.pool)
+	adminService := admin.NewService(queries, transactor)
+	// Sweeping stale attempts needs neither metrics, timer events nor webhooks
+	attemptService := attempts.NewService(queries, transactor, scoring.NewService(queries), metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+	vacationService := vacations.NewService(queries, transactor, preferences.NewService(queries))
+
+	runner := jobs.NewRunner(queries)
+
+	runner.Register("token-cleanup", tokenCleanupInterval, func(ctx context.Context) error {
+		result, err := adminService.CleanupTokens(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Info("Cleaned up expired tokens",
+			"refresh_tokens", result.RefreshTokensDeleted,
+			"password_reset_tokens", result.PasswordResetTokensDeleted,
+		)
+		return nil
+	})
+
+	runner.Register("stale-attempt-sweep", staleAttemptInterval, func(ctx context.Context) error {
+		abandoned, err := attemptService.AbandonStaleAttempts(ctx, staleAttemptIdle)
+		if err != nil {
+			return err
+		}
+		if abandoned > 0 {
+			slog.Info("Abandoned stale attempts", "count", abandoned)
+		}
+		return nil
+	})
+
+	webhookDispatcher := webhooks.NewDispatcher(queries)
+	runner.Register("webhook-delivery", webhookDeliveryInterval, webhookDispatcher.DeliverDue)
+
+	runner.Register("vacation-shift", vacationShiftInterval, func(ctx context.Context) error {
+		shifted, err := vacationService.ApplyStartedVacations(ctx)
+		if err != nil {
+			return err
+		}
+		if shifted > 0 {
+			slog.Info("Shifted review schedules for started vacations", "count", shifted)
+		}
+		return nil
+	})
+
+	return runner
+}
@@ -1,45 +1,45 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/security"
+	"github.com/vasujain275/reforge/internal/users"
+	"github.com/vasujain275/reforge/internal/utils"
 )
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
+// CORSMiddleware handles Cross-Origin Resource Sharing. In dev any origin is
+// echoed back; in prod only origins matching config.corsAllowedOrigins are.
 func (app *application) CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get allowed origins from environment
-		allowedOriginsEnv := os.Getenv("CORS_ALLOWED_ORIGINS")
-
-		var allowedOrigins []string
-		if allowedOriginsEnv != "" {
-			allowedOrigins = strings.Split(allowedOriginsEnv, ",")
-			// Trim whitespace from each origin
-			for i := range allowedOrigins {
-				allowedOrigins[i] = strings.TrimSpace(allowedOrigins[i])
-			}
-		}
+		// The response depends on Origin, so shared caches must key on it
+		w.Header().Add("Vary", "Origin")
 
 		origin := r.Header.Get("Origin")
-
-		// In development mode, allow all origins for easier testing
-		if app.config.env == "dev" {
+		if origin != "" && (app.config.env == "dev" || originAllowed(origin, app.config.corsAllowedOrigins)) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
-		} else {
-			// In production, only allow specified origins
-			if origin != "" && contains(allowedOrigins, origin) {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			}
 		}
 
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-CSRF-Token, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "3600")
 
 		// Handle preflight requests
-		if r.Method == "OPTIONS" {
+		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
@@ -48,12 +48,330 @@ func (app *application) CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// contains checks if a string slice contains a specific string
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
+// originAllowed matches origin against the allowed list. Entries are exact
+// origins ("https://reforge.example.com") or wildcard subdomain patterns
+// ("https://*.example.com"), which match any subdomain depth but not the
+// bare domain itself.
+func originAllowed(origin string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if pattern == origin {
+			return true
+		}
+
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+		rest, ok := strings.CutPrefix(origin, scheme+"://")
+		if !ok {
+			continue
+		}
+		sub, ok := strings.CutSuffix(rest, "."+host)
+		if ok && sub != "" && !strings.ContainsAny(sub, "/:@") {
 			return true
 		}
 	}
 	return false
 }
+
+// streamPaths are long-lived SSE endpoints that the request timeout would cut
+// off; they end when the client disconnects or the server shuts down instead
+var streamPaths = map[string]bool{
+	"/api/v1/attempts/stream": true,
+}
+
+// TimeoutMiddleware applies chi's request timeout to everything but streamPaths
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limited := middleware.Timeout(timeout)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if streamPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CompressMiddleware gzips JSON and CSV responses for clients that accept it.
+// SSE streams are left alone so every event reaches the client as soon as it
+// is flushed.
+func CompressMiddleware(level int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		compressed := middleware.Compress(level, "application/json", "text/csv")(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if streamPaths[r.URL.Path] || r.Header.Get("Accept") == "text/event-stream" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			compressed.ServeHTTP(w, r)
+		})
+	}
+}
+
+// EndOnShutdownMiddleware cancels the request context when shutdown starts.
+// srv.Shutdown waits for handlers but never cancels them, so long-lived SSE
+// streams use this to wind down and send their final event within the grace
+// period instead of being cut off.
+func EndOnShutdownMiddleware(shutdown context.Context) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithCancel(r.Context())
+			defer cancel()
+			stop := context.AfterFunc(shutdown, cancel)
+			defer stop()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (app *application) AuthTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Scripts authenticate with a personal API key instead of cookies
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && strings.HasPrefix(bearer, users.APIKeyPrefix) {
+			app.authenticateAPIKey(w, r, next, bearer)
+			return
+		}
+
+		// 1. Get Acess Token from cookie
+		cookie, err := r.Cookie("access_token")
+		if err != nil {
+			utils.Unauthorized(w, "Authentication Required!")
+			return
+		}
+
+		tokenString := cookie.Value
+
+		// 2. Parse and Validate the JWT
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			// Ensure the signing method is HMAC
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			// Return the secret key from your app config
+			return []byte(app.config.auth.secret), nil
+		})
+		// 3. Check Validity
+		if err != nil || !token.Valid {
+			utils.Unauthorized(w, "Invalid or expired token")
+			return
+		}
+
+		// 4. Extract Claims (User ID and Role)
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			utils.Unauthorized(w, "Invalid token claims")
+			return
+		}
+
+		// Extract sub as string (UUID)
+		userIDStr, ok := claims["sub"].(string)
+		if !ok {
+			utils.Unauthorized(w, "Invalid user ID in token")
+			return
+		}
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			utils.Unauthorized(w, "Invalid user ID format in token")
+			return
+		}
+
+		// Extract role (default to "user" if not present)
+		role, _ := claims["role"].(string)
+		if role == "" {
+			role = "user"
+		}
+
+		// 5. Add User ID and Role to Context
+		ctx := auth.WithUser(r.Context(), userID, role)
+		ctx = logging.With(ctx, "user_id", userID)
+
+		// 6. Serve the next handler with the new context
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// uploadPaths are the endpoints that accept file uploads or backups and get
+// config.maxUploadBytes instead of config.maxBodyBytes
+var uploadPaths = map[string]bool{
+	"/api/v1/import/restore":                   true,
+	"/api/v1/admin/data/import/parse-upload":   true,
+	"/api/v1/admin/data/import/execute-upload": true,
+}
+
+// BodyLimitMiddleware caps how much of a request body handlers can read.
+// Reads past the cap fail with *http.MaxBytesError, which handlers turn into
+// a 413; requests that declare an oversize Content-Length are refused upfront.
+func (app *application) BodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := app.config.maxBodyBytes
+		if uploadPaths[r.URL.Path] {
+			limit = app.config.maxUploadBytes
+		}
+
+		if r.ContentLength > limit {
+			utils.PayloadTooLarge(w, "Request body is too large")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// csrfExemptPaths are the state-changing endpoints that run before a CSRF
+// token exists (login) or that issue a new one (refresh)
+var csrfExemptPaths = map[string]bool{
+	"/api/v1/auth/login":   true,
+	"/api/v1/auth/refresh": true,
+}
+
+// CSRFMiddleware requires a matching CSRF header on state-changing requests
+// that carry auth cookies. API key requests and cookie-less requests have no
+// ambient credentials to abuse and pass through.
+func (app *application) CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if csrfExemptPaths[r.URL.Path] || strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "+users.APIKeyPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		_, accessErr := r.Cookie("access_token")
+		_, refreshErr := r.Cookie(auth.RefreshTokenCookie)
+		if accessErr != nil && refreshErr != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !auth.ValidCSRF(r) {
+			utils.Forbidden(w, "CSRF token missing or invalid")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateAPIKey resolves a personal API key to its owner. Read-only keys
+// are limited to safe methods.
+func (app *application) authenticateAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, rawKey string) {
+	queries := repo.New(app.pool)
+
+	key, err := queries.GetAPIKeyByHash(r.Context(), security.HashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			utils.Unauthorized(w, "Invalid API key")
+			return
+		}
+		utils.InternalServerError(w, "Failed to verify API key")
+		return
+	}
+
+	if key.ExpiresAt.Valid && time.Now().After(key.ExpiresAt.Time) {
+		utils.Unauthorized(w, "API key has expired")
+		return
+	}
+	if key.IsActive.Valid && !key.IsActive.Bool {
+		utils.Unauthorized(w, "Account is deactivated")
+		return
+	}
+	if key.ReadOnly && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		utils.Forbidden(w, "This API key is read-only")
+		return
+	}
+
+	if err := queries.TouchAPIKey(r.Context(), key.ID); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to update API key last use", "key_id", key.ID, "error", err)
+	}
+
+	role := "user"
+	if key.Role.Valid && key.Role.String != "" {
+		role = key.Role.String
+	}
+
+	ctx := auth.WithUser(r.Context(), key.UserID, role)
+	ctx = logging.With(ctx, "user_id", key.UserID, "api_key_id", key.ID)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireAdminMiddleware ensures the user has admin role. The JWT claim is
+// checked first, then the role is re-read from the database so a demotion or
+// deactivation takes effect before the access token expires.
+func (app *application) RequireAdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.RoleFromContext(r.Context()) != "admin" {
+			utils.Forbidden(w, "Admin access required")
+			return
+		}
+
+		userID, ok := auth.UserIDFromContext(r.Context())
+		if !ok {
+			utils.Forbidden(w, "Admin access required")
+			return
+		}
+
+		user, err := repo.New(app.pool).GetUserByID(r.Context(), userID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				utils.Forbidden(w, "Admin access required")
+				return
+			}
+			utils.InternalServerError(w, "Failed to verify admin access")
+			return
+		}
+		if user.Role.String != "admin" || (user.IsActive.Valid && !user.IsActive.Bool) {
+			utils.Forbidden(w, "Admin access required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireUninitializedMiddleware only lets requests through while the system
+// has no users, for setup endpoints that run before anyone can log in
+func (app *application) RequireUninitializedMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, err := repo.New(app.pool).CountAllUsers(r.Context())
+		if err != nil {
+			utils.InternalServerError(w, "Failed to check system status")
+			return
+		}
+		if count > 0 {
+			utils.Forbidden(w, "System is already initialized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// PreventLastAdminDeletionMiddleware prevents deleting/demoting the last admin
+// This should be used on user deletion and role change endpoints
+func (app *application) PreventLastAdminDeletionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Create queries instance
+		queries := repo.New(app.pool)
+
+		// Check admin count
+		adminCount, err := queries.CountAdmins(r.Context())
+		if err != nil {
+			utils.InternalServerError(w, "Failed to check admin count")
+			return
+		}
+
+		if adminCount <= 1 {
+			utils.BadRequest(w, "Cannot delete or demote the last admin", nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/problems"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+const testJWTSecret = "test-secret"
+
+// accessToken signs an access token the way the auth service does, with the
+// role claim as it stood when the token was issued
+func accessToken(t *testing.T, userID uuid.UUID, role string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  userID.String(),
+		"role": role,
+		"exp":  time.Now().Add(30 * time.Minute).Unix(),
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// errorCode decodes the standard error envelope's code
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body utils.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Success || body.Error == nil {
+		t.Fatalf("response = %+v, want an error envelope", body)
+	}
+	return body.Error.Code
+}
+
+// The role claim gets a request past the first check, but the database has
+// the final say
+func TestRequireAdminMiddleware(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	app := &application{
+		config: config{auth: authConfig{secret: testJWTSecret}},
+		pool:   db.Pool,
+	}
+	handler := app.AuthTokenMiddleware(app.RequireAdminMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	newUser := func(email, role string) uuid.UUID {
+		t.Helper()
+		user := db.CreateUser(t, email)
+		if err := db.Queries.UpdateUserRole(ctx, repo.UpdateUserRoleParams{ID: user.ID, Role: pgtype.Text{String: role, Valid: true}}); err != nil {
+			t.Fatalf("UpdateUserRole: %v", err)
+		}
+		return user.ID
+	}
+
+	admin := newUser("admin@example.com", "admin")
+	user := newUser("user@example.com", "user")
+
+	demoted := newUser("demoted@example.com", "admin")
+	demotedToken := accessToken(t, demoted, "admin")
+	if err := db.Queries.UpdateUserRole(ctx, repo.UpdateUserRoleParams{ID: demoted, Role: pgtype.Text{String: "user", Valid: true}}); err != nil {
+		t.Fatalf("UpdateUserRole: %v", err)
+	}
+
+	deactivated := newUser("deactivated@example.com", "admin")
+	if _, err := db.Queries.UpdateUserActiveStatus(ctx, repo.UpdateUserActiveStatusParams{ID: deactivated, IsActive: pgtype.Bool{Bool: false, Valid: true}}); err != nil {
+		t.Fatalf("UpdateUserActiveStatus: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"active admin", accessToken(t, admin, "admin"), http.StatusNoContent},
+		{"plain user", accessToken(t, user, "user"), http.StatusForbidden},
+		{"token without a role claim", accessToken(t, admin, ""), http.StatusForbidden},
+		{"admin claim for a plain user", accessToken(t, user, "admin"), http.StatusForbidden},
+		{"demoted after the token was issued", demotedToken, http.StatusForbidden},
+		{"deactivated admin", accessToken(t, deactivated, "admin"), http.StatusForbidden},
+		{"deleted admin", accessToken(t, uuid.New(), "admin"), http.StatusForbidden},
+		{"no token", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil)
+			if tt.token != "" {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: tt.token})
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusForbidden {
+				if code := errorCode(t, rec); code != utils.ErrCodeForbidden {
+					t.Errorf("error code = %q, want %q", code, utils.ErrCodeForbidden)
+				}
+			}
+		})
+	}
+}
+
+// In prod only listed origins are echoed back; wildcard entries match
+// subdomains but not the bare domain or a host that merely ends the same way
+func TestCORSPreflight(t *testing.T) {
+	app := &application{config: config{
+		env:                "prod",
+		corsAllowedOrigins: []string{"https://reforge.example.com", "https://*.domain.com"},
+	}}
+	handler := app.CORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("preflight reached the handler")
+	}))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://reforge.example.com", true},
+		{"https://app.domain.com", true},
+		{"https://eu.app.domain.com", true},
+		{"https://domain.com", false},
+		{"https://evil-domain.com", false},
+		{"https://evildomain.com", false},
+		{"https://domain.com.evil.com", false},
+		{"https://app.domain.com.evil.com", false},
+		{"http://app.domain.com", false},
+		{"https://app.domain.com:8443", false},
+		{"https://user@app.domain.com", false},
+		{"https://reforge.example.com.evil.com", false},
+		{"http://reforge.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/problems", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Origin" {
+				t.Errorf("Vary = %q, want Origin", vary)
+			}
+			got := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.origin)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+			}
+		})
+	}
+
+	t.Run("no origin", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/problems", nil))
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+		}
+	})
+}
+
+// Bodies over the cap are refused with a 413 whether or not they declare their
+// length, and upload paths get the larger cap
+func TestBodyLimitMiddleware(t *testing.T) {
+	app := &application{config: config{maxBodyBytes: 64, maxUploadBytes: 1024}}
+	handler := app.BodyLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Name string `json:"name"`
+			Data string `json:"data"`
+		}
+		if err := utils.Read(r, &body); err != nil {
+			utils.InvalidBody(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// payload is a valid body of exactly size bytes
+	payload := func(size int) string {
+		const frame = `{"name":"x","data":""}`
+		return `{"name":"x","data":"` + strings.Repeat("a", size-len(frame)) + `"}`
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+		code    string
+	}{
+		{"at the cap", "/api/v1/problems", payload(64), false, http.StatusNoContent, ""},
+		{"over the cap", "/api/v1/problems", payload(65), false, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+		{"over the cap without a length", "/api/v1/problems", payload(65), true, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+		{"unknown field", "/api/v1/problems", `{"name":"x","admin":true}`, false, http.StatusBadRequest, utils.ErrCodeBadRequest},
+		{"trailing data", "/api/v1/problems", `{"name":"x"}{"name":"y"}`, false, http.StatusBadRequest, utils.ErrCodeBadRequest},
+		{"trailing data past the cap", "/api/v1/problems", `{"name":"x"}` + strings.Repeat(" ", 64), true, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+		{"upload under its cap", "/api/v1/import/restore", payload(1024), false, http.StatusNoContent, ""},
+		{"upload under its cap without a length", "/api/v1/admin/data/import/execute-upload", payload(1024), true, http.StatusNoContent, ""},
+		{"upload over its cap", "/api/v1/import/restore", payload(1025), false, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+		{"upload over its cap without a length", "/api/v1/admin/data/import/parse-upload", payload(1025), true, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+		{"upload cap only on upload paths", "/api/v1/import/restore/", payload(1024), false, http.StatusRequestEntityTooLarge, utils.ErrCodePayloadTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				// Hide the length so only the read limit can catch it
+				req.Body = io.NopCloser(strings.NewReader(tt.body))
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, rec); code != tt.code {
+					t.Errorf("error code = %q, want %q", code, tt.code)
+				}
+			}
+		})
+	}
+}
+
+// problemList is a /v1/problems response for a library of n problems, about
+// half of them attempted, with realistic titles, links, patterns and stats
+func problemList(n int) []problems.ProblemWithStats {
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := strings.Fields("two sum longest substring without repeating characters median sorted arrays valid parentheses merge intervals " +
+		"binary tree level order traversal word search course schedule kth largest element trapping rain water")
+	patterns := make([]problems.Pattern, 20)
+	for i := range patterns {
+		description := fmt.Sprintf("Problems solved with technique %d and its common variations", i)
+		patterns[i] = problems.Pattern{ID: uuid.NewString(), Title: fmt.Sprintf("Pattern %d", i), Description: &description}
+	}
+	companies := make([]problems.Company, 30)
+	for i := range companies {
+		companies[i] = problems.Company{ID: uuid.NewString(), Name: fmt.Sprintf("Company %d", i)}
+	}
+	str := func(s string) *string { return &s }
+
+	list := make([]problems.ProblemWithStats, n)
+	for i := range list {
+		title := make([]string, 2+rng.IntN(4))
+		for j := range title {
+			title[j] = words[rng.IntN(len(words))]
+		}
+		id := uuid.NewString()
+		p := problems.ProblemWithStats{
+			ID:         id,
+			Title:      fmt.Sprintf("%s %d", strings.Join(title, " "), i),
+			Source:     str("LeetCode"),
+			URL:        str(fmt.Sprintf("https://leetcode.com/problems/%s-%d/", strings.Join(title, "-"), i)),
+			Difficulty: []string{"easy", "medium", "hard"}[rng.IntN(3)],
+			CreatedAt:  "2026-01-15T10:04:05Z",
+			Patterns:   []problems.Pattern{patterns[rng.IntN(len(patterns))], patterns[rng.IntN(len(patterns))]},
+			Companies:  []problems.Company{companies[rng.IntN(len(companies))]},
+		}
+		if i%2 == 0 {
+			p.Stats = &problems.Stats{
+				ID:            uuid.NewString(),
+				UserID:        "6f1c2d0e-8a4b-4c3d-9e2f-1a2b3c4d5e6f",
+				ProblemID:     id,
+				Status:        "learning",
+				Confidence:    int32(rng.IntN(101)),
+				AvgConfidence: int32(rng.IntN(101)),
+				LastAttemptAt: str("2026-09-30T21:15:00Z"),
+				TotalAttempts: int32(1 + rng.IntN(8)),
+				LastOutcome:   str("passed"),
+				UpdatedAt:     "2026-09-30T21:15:00Z",
+			}
+		}
+		list[i] = p
+	}
+	return list
+}
+
+// A 2k-problem list shrinks several times over when the client accepts gzip;
+// run with -v for the sizes
+func TestCompressMiddlewareListSizes(t *testing.T) {
+	list := problemList(2000)
+	handler := CompressMiddleware(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteSuccess(w, http.StatusOK, list)
+	}))
+
+	get := func(acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/problems", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	plain := get("")
+	if enc := plain.Header().Get("Content-Encoding"); enc != "" {
+		t.Fatalf("Content-Encoding = %q without Accept-Encoding, want none", enc)
+	}
+	compressed := get("gzip, deflate, br")
+	if enc := compressed.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", enc)
+	}
+
+	plainSize, compressedSize := plain.Body.Len(), compressed.Body.Len()
+	zr, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	inflated, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to inflate response: %v", err)
+	}
+	if !bytes.Equal(inflated, plain.Body.Bytes()) {
+		t.Fatalf("inflated body differs from the uncompressed one")
+	}
+
+	t.Logf("2000 problems: %d bytes plain, %d bytes gzipped (%.1f%%)",
+		plainSize, compressedSize, float64(compressedSize)/float64(plainSize)*100)
+	if compressedSize*4 > plainSize {
+		t.Errorf("gzipped size %d is more than a quarter of %d", compressedSize, plainSize)
+	}
+}
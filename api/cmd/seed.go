@@ -2,64 +2,42 @@ package main
 
 import (
 	"context"
-	"log/slog"
+	"errors"
+	"fmt"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
-	"github.com/vasujain275/reforge/internal/env"
 	"github.com/vasujain275/reforge/internal/security"
 )
 
-// seedAdminIfNeeded checks if any admin exists in the database.
-// If no admin exists, it creates one using credentials from environment variables.
-// This ensures there's always at least one admin user to manage the system.
-func seedAdminIfNeeded(ctx context.Context, pool *pgxpool.Pool) error {
-	queries := repo.New(pool)
+// errUserExists is returned by createAdmin when the email is already registered
+var errUserExists = errors.New("a user with this email already exists")
 
-	// Check if any admin exists
-	adminCount, err := queries.CountAdmins(ctx)
-	if err != nil {
-		return err
-	}
-
-	// Admin already exists, no need to seed
-	if adminCount > 0 {
-		slog.Info("Admin user already exists, skipping seed")
-		return nil
+// createAdmin creates an admin account. Unlike onboarding it works whether or
+// not other users exist, so it can recover an instance locked out of its admins.
+func createAdmin(ctx context.Context, queries repo.Querier, email, name, password string) (repo.CreateUserRow, error) {
+	_, err := queries.GetUserByEmail(ctx, email)
+	if err == nil {
+		return repo.CreateUserRow{}, errUserExists
 	}
-
-	// Get seed credentials from environment
-	email := env.GetString("SEED_ADMIN_EMAIL", "")
-	name := env.GetString("SEED_ADMIN_NAME", "System Administrator")
-	password := env.GetString("SEED_ADMIN_PASSWORD", "")
-
-	if email == "" || password == "" {
-		slog.Warn("SEED_ADMIN_EMAIL and SEED_ADMIN_PASSWORD not set, skipping admin seed")
-		slog.Warn("You will need to create an admin user manually in the database")
-		return nil
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return repo.CreateUserRow{}, fmt.Errorf("failed to look up user: %w", err)
 	}
 
-	// Hash password
 	passwordHash, err := security.HashPassword(password)
 	if err != nil {
-		return err
+		return repo.CreateUserRow{}, err
 	}
 
-	// Create admin user
-	_, err = queries.CreateUser(ctx, repo.CreateUserParams{
+	user, err := queries.CreateUser(ctx, repo.CreateUserParams{
 		Email:        email,
 		PasswordHash: passwordHash,
 		Name:         name,
 		Role:         pgtype.Text{String: "admin", Valid: true},
 	})
-
 	if err != nil {
-		return err
+		return repo.CreateUserRow{}, fmt.Errorf("failed to create admin: %w", err)
 	}
-
-	slog.Info("Admin user seeded successfully", "email", email)
-	slog.Warn("IMPORTANT: Change the admin password immediately after first login!")
-
-	return nil
+	return user, nil
 }
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/attempts"
+	"github.com/vasujain275/reforge/internal/events"
+	dataimport "github.com/vasujain275/reforge/internal/import"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/onboarding"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/sessions"
+	"github.com/vasujain275/reforge/internal/users"
+	"github.com/vasujain275/reforge/internal/webhooks"
+	sampledatasets "github.com/vasujain275/reforge/sample-datasets"
+)
+
+const (
+	demoEmail    = "demo@reforge.local"
+	demoName     = "Demo User"
+	demoPassword = "reforge-demo"
+
+	demoDataset      = "leetcode"
+	demoProblemCount = 100
+	demoHistoryDays  = 21
+)
+
+// errDatabaseNotEmpty stops seed-demo from mixing fabricated history into real data
+var errDatabaseNotEmpty = errors.New("database already has users or problems; pass --force to seed anyway")
+
+// demoSeedOptions controls a seed-demo run
+type demoSeedOptions struct {
+	seed  uint64 // Same seed, same history
+	force bool   // Seed even when the database is not empty
+}
+
+// seedDemoData creates a demo user, imports the first problems of the bundled
+// dataset and plays a few weeks of practice sessions through the real services,
+// so stats, SM-2 state and pattern stats come out the way real usage would
+// leave them.
+func seedDemoData(ctx context.Context, cfg config, pool *pgxpool.Pool, opts demoSeedOptions) error {
+	queries := repo.New(pool)
+
+	empty, err := databaseIsEmpty(ctx, queries)
+	if err != nil {
+		return err
+	}
+	if !empty && !opts.force {
+		return errDatabaseNotEmpty
+	}
+
+	scoringService := scoring.NewService(queries)
+	transactor := postgres.NewTransactor(pool)
+	attemptService := attempts.NewService(queries, transactor, scoringService, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+	sessionService := sessions.NewService(queries, scoringService, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+	importService := dataimport.NewService(queries, transactor, cfg.datasetPath, cfg.maxImportRows, metrics.Noop{})
+
+	userID, err := createDemoUser(ctx, queries, transactor)
+	if err != nil {
+		return err
+	}
+
+	csvData, err := demoDatasetHead(demoProblemCount)
+	if err != nil {
+		return err
+	}
+	result, err := importService.ExecuteImportFromReader(ctx, bytes.NewReader(csvData), dataimport.ImportOptions{
+		UseBundled: true,
+		DatasetID:  demoDataset,
+		TagDataset: true,
+		UserID:     &userID,
+	}, func(dataimport.ImportProgress) {})
+	if err != nil {
+		return fmt.Errorf("failed to import demo problems: %w", err)
+	}
+	slog.Info("Imported demo problems", "created", result.ProblemsCreated, "patterns", result.PatternsCreated)
+
+	problems, err := queries.ListAllProblems(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list problems: %w", err)
+	}
+	// Insertion order ties on created_at, so sort for a repeatable history
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Title < problems[j].Title })
+	if len(problems) > demoProblemCount {
+		problems = problems[:demoProblemCount]
+	}
+
+	sim := &demoSimulation{
+		queries:  queries,
+		attempts: attemptService,
+		sessions: sessionService,
+		rng:      rand.New(rand.NewPCG(opts.seed, opts.seed)),
+		userID:   userID,
+		problems: problems,
+		passes:   make(map[uuid.UUID]int),
+	}
+	sessionCount, attemptCount, err := sim.run(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Demo data seeded",
+		"email", demoEmail,
+		"password", demoPassword,
+		"sessions", sessionCount,
+		"attempts", attemptCount,
+	)
+	return nil
+}
+
+// databaseIsEmpty reports whether there are no users and no problems yet
+func databaseIsEmpty(ctx context.Context, queries repo.Querier) (bool, error) {
+	userCount, err := queries.CountAllUsers(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to count users: %w", err)
+	}
+	problems, err := queries.ListProblems(ctx, repo.ListProblemsParams{Limit: 1, Offset: 0})
+	if err != nil {
+		return false, fmt.Errorf("failed to list problems: %w", err)
+	}
+	return userCount == 0 && len(problems) == 0, nil
+}
+
+// createDemoUser makes the demo account the first admin on a fresh install,
+// and a regular user when forced onto an instance that already has one
+func createDemoUser(ctx context.Context, queries repo.Querier, transactor postgres.Transactor) (uuid.UUID, error) {
+	err := onboarding.NewService(queries).CreateFirstAdmin(ctx, demoEmail, demoPassword, demoName)
+	if errors.Is(err, onboarding.ErrSystemAlreadyInitialized) {
+		_, err = users.NewService(queries, transactor).CreateUser(ctx, users.CreateUserBody{
+			Name:     demoName,
+			Email:    demoEmail,
+			Password: demoPassword,
+		}, false)
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create demo user: %w", err)
+	}
+
+	user, err := queries.GetUserByEmail(ctx, demoEmail)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to load demo user: %w", err)
+	}
+	return user.ID, nil
+}
+
+// demoDatasetHead returns the header and first n rows of the bundled demo
+// dataset. Rows go through encoding/csv so quoted newlines stay intact.
+func demoDatasetHead(n int) ([]byte, error) {
+	file, err := sampledatasets.EmbeddedDatasets.Open(demoDataset + ".csv")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundled dataset: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for i := 0; i <= n; i++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundled dataset: %w", err)
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// demoSimulation plays back practice history day by day
+type demoSimulation struct {
+	queries  repo.Querier
+	attempts attempts.Service
+	sessions sessions.Service
+	rng      *rand.Rand
+	userID   uuid.UUID
+	problems []repo.Problem
+	next     int               // Index of the next problem never attempted
+	passes   map[uuid.UUID]int // Passed attempts per problem, to make repeats easier
+	seen     []repo.Problem    // Problems attempted at least once
+}
+
+// run simulates demoHistoryDays days ending yesterday and returns how many
+// sessions and attempts it recorded
+func (d *demoSimulation) run(ctx context.Context, now time.Time) (int, int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	sessionCount, attemptCount := 0, 0
+
+	for day := demoHistoryDays; day >= 1; day-- {
+		// Roughly two practice days out of three
+		if d.rng.Float64() < 0.3 {
+			continue
+		}
+
+		start := today.AddDate(0, 0, -day).Add(time.Duration(17*60+d.rng.IntN(240)) * time.Minute)
+		picked := d.pickProblems(3 + d.rng.IntN(3))
+		if len(picked) == 0 {
+			break
+		}
+
+		n, err := d.playSession(ctx, start, picked)
+		if err != nil {
+			return sessionCount, attemptCount, err
+		}
+		sessionCount++
+		attemptCount += n
+	}
+
+	return sessionCount, attemptCount, nil
+}
+
+// pickProblems mixes revisits of earlier problems with new ones, leaning on
+// new problems while little has been seen
+func (d *demoSimulation) pickProblems(count int) []repo.Problem {
+	picked := make([]repo.Problem, 0, count)
+	chosen := make(map[uuid.UUID]bool, count)
+
+	for len(picked) < count {
+		revisit := len(d.seen) > 0 && (d.next >= len(d.problems) || d.rng.Float64() < 0.4)
+		var problem repo.Problem
+		if revisit {
+			problem = d.seen[d.rng.IntN(len(d.seen))]
+			if chosen[problem.ID] {
+				// A small pool can keep landing on the same problem
+				if len(chosen) >= len(d.seen) && d.next >= len(d.problems) {
+					break
+				}
+				continue
+			}
+		} else {
+			if d.next >= len(d.problems) {
+				break
+			}
+			problem = d.problems[d.next]
+			d.next++
+			d.seen = append(d.seen, problem)
+		}
+		chosen[problem.ID] = true
+		picked = append(picked, problem)
+	}
+	return picked
+}
+
+// playSession records one session of attempts starting at start and
+// backdates the session to match
+func (d *demoSimulation) playSession(ctx context.Context, start time.Time, problems []repo.Problem) (int, error) {
+	problemIDs := make([]string, len(problems))
+	for i, problem := range problems {
+		problemIDs[i] = problem.ID.String()
+	}
+
+	session, err := d.sessions.CreateSession(ctx, d.userID, sessions.CreateSessionBody{
+		TemplateKey:        "daily_mixed_grind",
+		PlannedDurationMin: 90,
+		ProblemIDs:         problemIDs,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create demo session: %w", err)
+	}
+
+	performedAt := start
+	for _, problem := range problems {
+		difficulty := problem.Difficulty.String
+		duration := int64(float64(scoring.ExpectedSolveSeconds(difficulty)) * (0.6 + d.rng.Float64()))
+		performedAt = performedAt.Add(time.Duration(duration) * time.Second)
+
+		outcome, confidence := d.rollOutcome(problem.ID, difficulty)
+		_, err := d.attempts.CreateAttempt(ctx, d.userID, attempts.CreateAttemptBody{
+			ProblemID:       problem.ID.String(),
+			SessionID:       &session.ID,
+			ConfidenceScore: confidence,
+			DurationSeconds: &duration,
+			Outcome:         outcome,
+			PerformedAt:     strPtr(performedAt.Format(time.RFC3339)),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to record demo attempt: %w", err)
+		}
+	}
+
+	sessionID, err := uuid.Parse(session.ID)
+	if err != nil {
+		return 0, err
+	}
+	err = d.queries.BackdateSession(ctx, repo.BackdateSessionParams{
+		ID:          sessionID,
+		UserID:      d.userID,
+		CreatedAt:   pgtype.Timestamptz{Time: start, Valid: true},
+		CompletedAt: pgtype.Timestamptz{Time: performedAt, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to backdate demo session: %w", err)
+	}
+
+	return len(problems), nil
+}
+
+// rollOutcome decides how an attempt went. Harder problems fail more often and
+// every earlier pass makes the next one likelier, as practice would.
+func (d *demoSimulation) rollOutcome(problemID uuid.UUID, difficulty string) (string, int64) {
+	passChance := 0.65
+	switch difficulty {
+	case "easy":
+		passChance = 0.85
+	case "hard":
+		passChance = 0.45
+	}
+	passChance = min(passChance+0.1*float64(d.passes[problemID]), 0.95)
+
+	if d.rng.Float64() < passChance {
+		d.passes[problemID]++
+		return "passed", int64(60 + d.rng.IntN(36))
+	}
+	return "failed", int64(20 + d.rng.IntN(31))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,6 @@
+package postgres
+
+// The repo package under ./sqlc is generated from the migrations and queries
+// and is not checked in; run `go generate ./...` (or `task sqlc:generate`)
+// with sqlc on the PATH before building.
+//go:generate sqlc generate -f ../../../sqlc.yaml
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ============================================================================
+-- Cached problem scores
+-- ============================================================================
+
+-- Stores the last computed score per user/problem so list endpoints and
+-- session generation don't have to rescore the whole library on every request.
+-- features_json holds the raw FeatureBreakdown (weight independent), score is
+-- computed with the standard weights at computed_at time.
+CREATE TABLE problem_scores (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    problem_id UUID NOT NULL REFERENCES problems(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    features_json TEXT NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, problem_id)
+);
+
+CREATE INDEX idx_problem_scores_user_score ON problem_scores(user_id, score DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_problem_scores_user_score;
+DROP TABLE IF EXISTS problem_scores;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Track failures in a row so repeatedly failed problems ("leeches") can be flagged
+ALTER TABLE user_problem_stats ADD COLUMN consecutive_failures INTEGER DEFAULT 0;
+
+-- Number of consecutive failures before a problem is considered a leech
+INSERT INTO system_settings (key, value, description) VALUES
+('leech_threshold', '4', 'Consecutive failures before a problem is flagged as a leech')
+ON CONFLICT (key) DO NOTHING;
+
+-- Backfill from existing stats: a failed last outcome counts as at least one failure
+UPDATE user_problem_stats
+SET consecutive_failures = 1
+WHERE last_outcome = 'failed';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM system_settings WHERE key = 'leech_threshold';
+ALTER TABLE user_problem_stats DROP COLUMN IF EXISTS consecutive_failures;
+
+-- +goose StatementEnd
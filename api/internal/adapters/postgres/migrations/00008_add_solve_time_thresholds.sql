@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Solve time relative to the per-difficulty expectation nudges the SM-2 quality rating
+INSERT INTO system_settings (key, value, description) VALUES
+('sr_slow_multiplier', '1.5', 'Solve time above this multiple of the expected time lowers review quality'),
+('sr_fast_multiplier', '0.5', 'Solve time below this multiple of the expected time raises review quality')
+ON CONFLICT (key) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM system_settings WHERE key IN ('sr_slow_multiplier', 'sr_fast_multiplier');
+
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Durable per-user note on a problem, separate from per-attempt notes
+ALTER TABLE user_problem_stats ADD COLUMN notes TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE user_problem_stats DROP COLUMN IF EXISTS notes;
+
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Users can now retire a problem as mastered, alongside abandoning it
+ALTER TABLE user_problem_stats DROP CONSTRAINT IF EXISTS user_problem_stats_status_check;
+ALTER TABLE user_problem_stats ADD CONSTRAINT user_problem_stats_status_check
+    CHECK (status IN ('unsolved','solved','mastered','abandoned'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE user_problem_stats SET status = 'solved' WHERE status = 'mastered';
+ALTER TABLE user_problem_stats DROP CONSTRAINT IF EXISTS user_problem_stats_status_check;
+ALTER TABLE user_problem_stats ADD CONSTRAINT user_problem_stats_status_check
+    CHECK (status IN ('unsolved','solved','abandoned'));
+
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Per-user bookmarks on problems
+CREATE TABLE user_problem_stars (
+    user_id UUID NOT NULL,
+    problem_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, problem_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+);
+
+-- Score multiplier applied to starred problems when a session prefers them
+INSERT INTO system_settings (key, value, description) VALUES
+('starred_boost', '1.5', 'Score multiplier for starred problems in sessions that prefer them')
+ON CONFLICT (key) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM system_settings WHERE key = 'starred_boost';
+DROP TABLE IF EXISTS user_problem_stars;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Daily snapshots of user_pattern_stats so confidence trends can be charted.
+-- At most one row per user/pattern/day; later changes that day overwrite it.
+CREATE TABLE user_pattern_stats_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    pattern_id UUID NOT NULL,
+    avg_confidence INTEGER NOT NULL,
+    times_revised INTEGER NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    captured_on DATE NOT NULL DEFAULT CURRENT_DATE,
+
+    UNIQUE(user_id, pattern_id, captured_on),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (pattern_id) REFERENCES patterns(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_pattern_stats_history;
+
+-- +goose StatementEnd
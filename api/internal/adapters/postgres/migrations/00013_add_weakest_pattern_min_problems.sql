@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Patterns with fewer problems are left out of weakest-pattern recommendations
+INSERT INTO system_settings (key, value, description) VALUES
+('weakest_pattern_min_problems', '3', 'Minimum problems a pattern needs to be recommended as a weakest pattern')
+ON CONFLICT (key) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM system_settings WHERE key = 'weakest_pattern_min_problems';
+
+-- +goose StatementEnd
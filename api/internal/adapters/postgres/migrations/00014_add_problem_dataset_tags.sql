@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Bundled datasets (e.g. 'blind75') a problem was imported from, so curated
+-- lists can be told apart once they are merged into the problem pool
+CREATE TABLE problem_dataset_tags (
+    problem_id UUID NOT NULL,
+    dataset_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (problem_id, dataset_id),
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_problem_dataset_tags_dataset ON problem_dataset_tags(dataset_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS problem_dataset_tags;
+
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Durable record of each problem import; the SSE stream only mirrors it
+CREATE TABLE import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID,                         -- NULL for imports during onboarding
+    source TEXT NOT NULL CHECK (source IN ('bundled','upload')),
+    dataset_id TEXT,
+    file_hash TEXT NOT NULL,              -- SHA-256 of the CSV, to recognise re-runs
+    options JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running','complete','failed','cancelled')),
+
+    -- Totals and per-phase counters, updated as batches commit
+    total_rows INT NOT NULL DEFAULT 0,
+    total_patterns INT NOT NULL DEFAULT 0,
+    patterns_created INT NOT NULL DEFAULT 0,
+    problems_processed INT NOT NULL DEFAULT 0,
+    problems_created INT NOT NULL DEFAULT 0,
+    problems_updated INT NOT NULL DEFAULT 0,
+    duplicates_skipped INT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+
+    -- Latest earlier job for the same file, when this one is a re-run
+    prior_job_id UUID,
+    error_message TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (prior_job_id) REFERENCES import_jobs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_import_jobs_started_at ON import_jobs(started_at DESC);
+CREATE INDEX idx_import_jobs_file_hash ON import_jobs(file_hash, started_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS import_jobs;
+
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Row errors of an import job, kept for the downloadable error report
+CREATE TABLE import_job_errors (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL,
+    row_number INT NOT NULL,
+    title TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL,
+
+    FOREIGN KEY (job_id) REFERENCES import_jobs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_import_job_errors_job ON import_job_errors(job_id, row_number);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS import_job_errors;
+
+-- +goose StatementEnd
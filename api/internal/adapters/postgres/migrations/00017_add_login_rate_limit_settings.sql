@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Failed logins per email or IP allowed within a window before further attempts are refused
+INSERT INTO system_settings (key, value, description) VALUES
+('login_max_failures', '5', 'Failed logins allowed per email or IP within the lockout window'),
+('login_lockout_window_minutes', '15', 'Window in minutes for counting failed logins and for the resulting lockout')
+ON CONFLICT (key) DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM system_settings WHERE key IN ('login_max_failures', 'login_lockout_window_minutes');
+
+-- +goose StatementEnd
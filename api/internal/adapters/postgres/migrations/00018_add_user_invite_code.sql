@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The invite code a user registered with (NULL for open signups and admins)
+ALTER TABLE users ADD COLUMN invite_code_id UUID REFERENCES admin_invite_codes(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users DROP COLUMN invite_code_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One row per registration made with an invite code
+CREATE TABLE invite_code_uses (
+    code_id UUID NOT NULL REFERENCES admin_invite_codes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (code_id, user_id)
+);
+
+CREATE INDEX idx_invite_code_uses_user ON invite_code_uses(user_id);
+
+-- Carry over registrations recorded on the user row, which this table replaces
+INSERT INTO invite_code_uses (code_id, user_id, used_at)
+SELECT invite_code_id, id, COALESCE(created_at, NOW())
+FROM users
+WHERE invite_code_id IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN invite_code_id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users ADD COLUMN invite_code_id UUID REFERENCES admin_invite_codes(id) ON DELETE SET NULL;
+
+UPDATE users u
+SET invite_code_id = icu.code_id
+FROM invite_code_uses icu
+WHERE icu.user_id = u.id;
+
+DROP TABLE IF EXISTS invite_code_uses;
+
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Refresh tokens are rotated on every use. Tokens from one login share a
+-- family_id; parent_id points at the token that was exchanged for this one
+-- (NULL for the token issued at login) and has no foreign key so cleaning up
+-- an expired ancestor doesn't make its descendants look like fresh logins.
+-- Rotated tokens are kept with revoked_at set so a replay can be detected.
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id UUID,
+    ADD COLUMN parent_id UUID,
+    ADD COLUMN revoked_at TIMESTAMPTZ;
+
+UPDATE refresh_tokens SET family_id = id;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens(family_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM refresh_tokens WHERE revoked_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN revoked_at,
+    DROP COLUMN parent_id,
+    DROP COLUMN family_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Lifetime class of a login, carried through every rotation of its family.
+-- Tokens issued before this column existed were all 30-day tokens.
+ALTER TABLE refresh_tokens
+    ADD COLUMN remember_me BOOLEAN NOT NULL DEFAULT true;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS remember_me;
+
+-- +goose StatementEnd
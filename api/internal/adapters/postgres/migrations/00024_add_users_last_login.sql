@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users
+    ADD COLUMN last_login_at TIMESTAMPTZ;
+
+-- Best effort backfill from the first token of each surviving login
+UPDATE users u
+SET last_login_at = t.last_login
+FROM (
+    SELECT user_id, MAX(created_at) AS last_login
+    FROM refresh_tokens
+    WHERE parent_id IS NULL
+    GROUP BY user_id
+) t
+WHERE t.user_id = u.id;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Practice targets such as "5 sessions a week"; progress is computed from
+-- attempts and sessions, nothing is stored per period
+CREATE TABLE user_goals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    goal_type TEXT NOT NULL CHECK (goal_type IN ('sessions','minutes','problems')),
+    target_value INTEGER NOT NULL CHECK (target_value > 0),
+    period TEXT NOT NULL DEFAULT 'week' CHECK (period IN ('day','week','month')),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE(user_id, goal_type, period),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_goals;
+
+-- +goose StatementEnd
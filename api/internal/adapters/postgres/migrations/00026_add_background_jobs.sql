@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Outcome of each background job's most recent run, one row per job name.
+-- Jobs are registered in code; a row appears after a job first runs.
+CREATE TABLE background_jobs (
+    name TEXT PRIMARY KEY,
+    last_trigger TEXT NOT NULL CHECK (last_trigger IN ('schedule','manual')),
+    last_started_at TIMESTAMPTZ NOT NULL,
+    last_finished_at TIMESTAMPTZ NOT NULL,
+    last_duration_ms BIGINT NOT NULL,
+    last_error TEXT,                  -- NULL when the last run succeeded
+    run_count INTEGER NOT NULL DEFAULT 0,
+    failure_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS background_jobs;
+
+-- +goose StatementEnd
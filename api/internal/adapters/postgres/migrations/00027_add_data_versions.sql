@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Counters bumped on every write, so list endpoints can answer conditional
+-- GETs without loading anything. The scope is 'catalog' for the problem and
+-- pattern library all users share, or a user ID for that user's own data.
+CREATE TABLE data_versions (
+    scope TEXT PRIMARY KEY,
+    version BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS data_versions;
+
+-- +goose StatementEnd
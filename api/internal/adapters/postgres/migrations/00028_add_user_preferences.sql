@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Per-user display and practice preferences. NULL columns are unset and fall
+-- back to the defaults in code, so new defaults reach users who never chose.
+CREATE TABLE user_preferences (
+    user_id UUID PRIMARY KEY,
+    timezone TEXT,                              -- IANA name, e.g. Europe/Berlin
+    week_start TEXT CHECK (week_start IN ('monday','sunday')),
+    default_session_duration_min INTEGER CHECK (default_session_duration_min > 0),
+    scoring_emphasis TEXT,                      -- Scoring weight preset key
+    auto_complete_session BOOLEAN,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS user_preferences;
+
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- One calendar feed token per user. Calendar apps fetch the feed without a
+-- session, so the token in the URL is the only credential; like API keys only
+-- its hash is stored.
+CREATE TABLE calendar_feed_tokens (
+    user_id UUID PRIMARY KEY,
+    token_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS calendar_feed_tokens;
+
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- A snooze pushes next_review_at out without touching the SM-2 interval or
+-- ease. The due date it replaced is kept so cancelling can put it back.
+ALTER TABLE user_problem_stats ADD COLUMN snoozed_until TIMESTAMPTZ;
+ALTER TABLE user_problem_stats ADD COLUMN snoozed_from_review_at TIMESTAMPTZ;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE user_problem_stats DROP COLUMN IF EXISTS snoozed_from_review_at;
+ALTER TABLE user_problem_stats DROP COLUMN IF EXISTS snoozed_until;
+
+-- +goose StatementEnd
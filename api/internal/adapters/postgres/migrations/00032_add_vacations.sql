@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Vacations pause the review schedule. Dates are the user's local calendar
+-- days, both inclusive; starts_at is the first one's midnight in their
+-- timezone, when the schedule shift is applied.
+CREATE TABLE user_vacations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    shift_days INTEGER NOT NULL,       -- end_date - start_date + 1
+    shifted_at TIMESTAMPTZ,            -- NULL until the schedule has been shifted
+    shifted_count INTEGER NOT NULL DEFAULT 0,
+    cancelled_at TIMESTAMPTZ,          -- Cancelled vacations are kept for the audit trail
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CHECK (end_date >= start_date),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_vacations_user ON user_vacations(user_id, start_date);
+CREATE INDEX idx_user_vacations_pending ON user_vacations(starts_at) WHERE shifted_at IS NULL AND cancelled_at IS NULL;
+
+-- Each due date a vacation moved, so cancelling can move it back
+CREATE TABLE vacation_review_shifts (
+    vacation_id UUID NOT NULL,
+    problem_id UUID NOT NULL,
+    previous_review_at TIMESTAMPTZ NOT NULL,
+    shifted_review_at TIMESTAMPTZ NOT NULL,
+
+    PRIMARY KEY (vacation_id, problem_id),
+    FOREIGN KEY (vacation_id) REFERENCES user_vacations(id) ON DELETE CASCADE,
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS vacation_review_shifts;
+DROP TABLE IF EXISTS user_vacations;
+
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Companies tag problems the way patterns do, e.g. the ones asked in a
+-- company's interviews. Names are unique ignoring case.
+CREATE TABLE companies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_companies_name ON companies(LOWER(name));
+
+CREATE TABLE problem_companies (
+    problem_id UUID NOT NULL,
+    company_id UUID NOT NULL,
+
+    PRIMARY KEY (problem_id, company_id),
+    FOREIGN KEY (problem_id) REFERENCES problems(id) ON DELETE CASCADE,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_problem_companies_company ON problem_companies(company_id, problem_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS problem_companies;
+DROP TABLE IF EXISTS companies;
+
+-- +goose StatementEnd
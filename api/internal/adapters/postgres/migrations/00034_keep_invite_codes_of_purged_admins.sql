@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Deleting an admin used to cascade to their invite codes and, through those,
+-- to other users' registration records. Used codes now outlive their creator
+-- with no creator recorded; purges delete only the unused ones.
+ALTER TABLE admin_invite_codes ALTER COLUMN created_by_admin_id DROP NOT NULL;
+ALTER TABLE admin_invite_codes DROP CONSTRAINT admin_invite_codes_created_by_admin_id_fkey;
+ALTER TABLE admin_invite_codes ADD CONSTRAINT admin_invite_codes_created_by_admin_id_fkey
+    FOREIGN KEY (created_by_admin_id) REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM admin_invite_codes WHERE created_by_admin_id IS NULL;
+ALTER TABLE admin_invite_codes DROP CONSTRAINT admin_invite_codes_created_by_admin_id_fkey;
+ALTER TABLE admin_invite_codes ADD CONSTRAINT admin_invite_codes_created_by_admin_id_fkey
+    FOREIGN KEY (created_by_admin_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE admin_invite_codes ALTER COLUMN created_by_admin_id SET NOT NULL;
+
+-- +goose StatementEnd
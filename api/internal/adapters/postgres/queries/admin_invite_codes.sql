@@ -3,6 +3,15 @@ INSERT INTO admin_invite_codes (code, created_by_admin_id, max_uses, expires_at)
 VALUES ($1, $2, $3, $4)
 RETURNING id, code, created_by_admin_id, max_uses, current_uses, expires_at, created_at;
 
+-- name: CreateInviteCodes :many
+-- Bulk insert; codes that collide with an existing one are skipped and
+-- simply missing from the result
+INSERT INTO admin_invite_codes (code, created_by_admin_id, max_uses, expires_at)
+SELECT code, sqlc.arg(created_by_admin_id), sqlc.arg(max_uses), sqlc.narg(expires_at)
+FROM unnest(sqlc.arg(codes)::text[]) AS code
+ON CONFLICT (code) DO NOTHING
+RETURNING id, code, created_by_admin_id, max_uses, current_uses, expires_at, created_at;
+
 -- name: GetInviteCodeByCode :one
 SELECT id, code, created_by_admin_id, max_uses, current_uses, expires_at, created_at
 FROM admin_invite_codes
@@ -17,7 +26,8 @@ LIMIT 1;
 
 -- name: ListInviteCodes :many
 -- Admin: List all invite codes with pagination
-SELECT id, code, created_by_admin_id, max_uses, current_uses, expires_at, created_at
+SELECT id, code, created_by_admin_id, max_uses, current_uses, expires_at, created_at,
+    (SELECT COUNT(*) FROM invite_code_uses icu WHERE icu.code_id = admin_invite_codes.id) AS users_registered
 FROM admin_invite_codes
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
@@ -30,7 +40,30 @@ UPDATE admin_invite_codes
 SET current_uses = current_uses + 1
 WHERE id = $1;
 
--- name: DeleteInviteCode :exec
+-- name: ConsumeInviteCode :one
+-- Validates and uses a code in one statement so concurrent signups can't
+-- exceed max_uses; no row means the code is unknown, expired or used up
+UPDATE admin_invite_codes
+SET current_uses = current_uses + 1
+WHERE code = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND current_uses < max_uses
+RETURNING id;
+
+-- name: RecordInviteCodeUse :exec
+INSERT INTO invite_code_uses (code_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (code_id, user_id) DO NOTHING;
+
+-- name: ListInviteCodeUses :many
+-- Admin: Who registered with a code, newest first
+SELECT icu.user_id, u.email, u.name, icu.used_at
+FROM invite_code_uses icu
+JOIN users u ON u.id = icu.user_id
+WHERE icu.code_id = $1
+ORDER BY icu.used_at DESC;
+
+-- name: DeleteInviteCode :execrows
 DELETE FROM admin_invite_codes
 WHERE id = $1;
 
@@ -0,0 +1,26 @@
+-- name: GetInstanceUserStats :one
+-- Admin: User totals for the instance stats endpoint
+SELECT
+    COUNT(*) AS total_users,
+    COUNT(*) FILTER (WHERE is_active IS NOT FALSE) AS active_users,
+    COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '7 days') AS new_users_7d,
+    COUNT(*) FILTER (WHERE created_at >= NOW() - INTERVAL '30 days') AS new_users_30d
+FROM users;
+
+-- name: CountAllProblems :one
+SELECT COUNT(*) FROM problems;
+
+-- name: CountAllPatterns :one
+SELECT COUNT(*) FROM patterns;
+
+-- name: CountAttemptsSince :one
+SELECT COUNT(*) FROM attempts
+WHERE performed_at >= $1;
+
+-- name: CountSessionsCompletedSince :one
+SELECT COUNT(*) FROM revision_sessions
+WHERE completed_at IS NOT NULL
+  AND completed_at >= $1;
+
+-- name: GetDatabaseSize :one
+SELECT pg_database_size(current_database())::bigint AS size_bytes;
@@ -0,0 +1,30 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, prefix, key_hash, read_only, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, name, prefix, read_only, expires_at, last_used_at, created_at;
+
+-- name: ListUserAPIKeys :many
+SELECT id, name, prefix, read_only, expires_at, last_used_at, created_at
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteUserAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetAPIKeyByHash :one
+-- Auth middleware: resolve a key to its owner, with the fields needed to authorize
+SELECT k.id, k.user_id, k.read_only, k.expires_at, u.role, u.is_active
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.key_hash = $1
+LIMIT 1;
+
+-- name: TouchAPIKey :exec
+-- Throttled to one write a minute per key so busy scripts don't rewrite the row on every request
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
@@ -68,7 +68,7 @@ JOIN problems p ON a.problem_id = p.id
 WHERE a.id = $1 AND a.user_id = $2
 LIMIT 1;
 
--- name: UpdateAttemptTimer :exec
+-- name: UpdateAttemptTimer :execrows
 UPDATE attempts
 SET elapsed_time_seconds = $1,
     timer_state = $2,
@@ -87,13 +87,173 @@ SET status = 'completed',
 WHERE id = $5 AND user_id = $6 AND status = 'in_progress'
 RETURNING *;
 
--- name: AbandonAttempt :exec
+-- name: AbandonAttempt :execrows
 UPDATE attempts
 SET status = 'abandoned',
     timer_state = 'idle',
     timer_last_updated_at = NOW()
 WHERE id = $1 AND user_id = $2 AND status = 'in_progress';
 
+-- name: AbandonStaleAttempts :many
+-- In-progress attempts whose timer hasn't synced since the cutoff were left
+-- open by a closed tab or a lost device. Returns the owner of each one.
+UPDATE attempts
+SET status = 'abandoned',
+    timer_state = 'idle',
+    timer_last_updated_at = NOW()
+WHERE status = 'in_progress'
+  AND COALESCE(timer_last_updated_at, started_at, performed_at) < sqlc.arg(cutoff)::timestamptz
+RETURNING user_id;
+
 -- name: DeleteAttempt :exec
 DELETE FROM attempts
 WHERE id = $1 AND user_id = $2;
+
+-- name: GetUserAttemptDates :many
+-- Dashboard streak: distinct local calendar days with a completed attempt, newest first
+SELECT DISTINCT timezone(sqlc.arg(tz)::text, performed_at)::date AS attempt_date
+FROM attempts
+WHERE user_id = sqlc.arg(user_id)
+  AND status = 'completed'
+  AND performed_at >= sqlc.arg(since)
+ORDER BY attempt_date DESC;
+
+-- name: GetUserActivityHeatmap :many
+-- Dashboard heatmap: per local calendar day with activity, completed attempts,
+-- seconds practiced and distinct problems passed
+SELECT timezone(sqlc.arg(tz)::text, performed_at)::date AS day,
+       COUNT(*) AS attempt_count,
+       COALESCE(SUM(duration_seconds), 0)::bigint AS total_seconds,
+       COUNT(DISTINCT problem_id) FILTER (WHERE outcome = 'passed') AS problems_passed
+FROM attempts
+WHERE user_id = sqlc.arg(user_id)
+  AND status = 'completed'
+  AND performed_at >= sqlc.arg(since)
+GROUP BY day
+ORDER BY day;
+
+-- name: GetWeeklyAttemptSummary :one
+-- Weekly report: completed attempts in [from, to) plus the problems first
+-- attempted in that range
+SELECT COUNT(*) AS attempt_count,
+       COUNT(*) FILTER (WHERE outcome = 'passed') AS passed_count,
+       COUNT(*) FILTER (WHERE outcome IS NOT NULL) AS graded_count,
+       COALESCE(SUM(duration_seconds), 0)::bigint AS total_seconds,
+       (SELECT COUNT(*) FROM (
+            SELECT a2.problem_id
+            FROM attempts a2
+            WHERE a2.user_id = sqlc.arg(user_id)
+              AND a2.status = 'completed'
+            GROUP BY a2.problem_id
+            HAVING MIN(a2.performed_at) >= sqlc.arg(from_time)
+               AND MIN(a2.performed_at) < sqlc.arg(to_time)
+        ) first_attempts) AS new_problems
+FROM attempts
+WHERE user_id = sqlc.arg(user_id)
+  AND status = 'completed'
+  AND performed_at >= sqlc.arg(from_time)
+  AND performed_at < sqlc.arg(to_time);
+
+-- name: GetTimeSpentByDifficultyAndSource :many
+-- Time-spent analytics: completed attempt seconds in [from, to) per problem
+-- difficulty and source; the service folds these into each grouping
+SELECT COALESCE(p.difficulty, 'medium')::text AS difficulty,
+       COALESCE(p.source, '')::text AS source,
+       COALESCE(SUM(a.duration_seconds), 0)::bigint AS total_seconds
+FROM attempts a
+JOIN problems p ON p.id = a.problem_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.status = 'completed'
+  AND a.performed_at >= sqlc.arg(from_time)
+  AND a.performed_at < sqlc.arg(to_time)
+GROUP BY 1, 2;
+
+-- name: GetTimeSpentByProblemPattern :many
+-- Time-spent analytics: completed attempt seconds in [from, to) per problem,
+-- repeated for each of the problem's patterns so the service can split it
+WITH problem_time AS (
+    SELECT problem_id, COALESCE(SUM(duration_seconds), 0)::bigint AS total_seconds
+    FROM attempts
+    WHERE user_id = sqlc.arg(user_id)
+      AND status = 'completed'
+      AND performed_at >= sqlc.arg(from_time)
+      AND performed_at < sqlc.arg(to_time)
+    GROUP BY problem_id
+)
+SELECT pt.problem_id, pt.total_seconds, pp.pattern_id, pat.title AS pattern_title
+FROM problem_time pt
+JOIN problem_patterns pp ON pp.problem_id = pt.problem_id
+JOIN patterns pat ON pat.id = pp.pattern_id
+WHERE pt.total_seconds > 0
+ORDER BY pt.problem_id, pp.pattern_id;
+
+-- name: GetConfidenceTrendOverall :many
+-- Confidence trend: average attempt confidence per local ISO week (Monday start)
+SELECT date_trunc('week', timezone(sqlc.arg(tz)::text, performed_at))::date AS week_start,
+       AVG(confidence_score)::float8 AS avg_confidence,
+       COUNT(*) AS attempt_count
+FROM attempts
+WHERE user_id = sqlc.arg(user_id)
+  AND status = 'completed'
+  AND confidence_score IS NOT NULL
+  AND performed_at >= sqlc.arg(since)
+GROUP BY week_start
+ORDER BY week_start;
+
+-- name: GetConfidenceTrendByDifficulty :many
+-- Confidence trend: as GetConfidenceTrendOverall, per problem difficulty
+SELECT date_trunc('week', timezone(sqlc.arg(tz)::text, a.performed_at))::date AS week_start,
+       COALESCE(p.difficulty, 'medium')::text AS difficulty,
+       AVG(a.confidence_score)::float8 AS avg_confidence,
+       COUNT(*) AS attempt_count
+FROM attempts a
+JOIN problems p ON p.id = a.problem_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.status = 'completed'
+  AND a.confidence_score IS NOT NULL
+  AND a.performed_at >= sqlc.arg(since)
+GROUP BY week_start, 2
+ORDER BY week_start;
+
+-- name: GetConfidenceTrendByPattern :many
+-- Confidence trend: as GetConfidenceTrendOverall, per pattern; an attempt on a
+-- problem with several patterns counts towards each
+SELECT date_trunc('week', timezone(sqlc.arg(tz)::text, a.performed_at))::date AS week_start,
+       pp.pattern_id,
+       pat.title AS pattern_title,
+       AVG(a.confidence_score)::float8 AS avg_confidence,
+       COUNT(*) AS attempt_count
+FROM attempts a
+JOIN problem_patterns pp ON pp.problem_id = a.problem_id
+JOIN patterns pat ON pat.id = pp.pattern_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.status = 'completed'
+  AND a.confidence_score IS NOT NULL
+  AND a.performed_at >= sqlc.arg(since)
+GROUP BY week_start, pp.pattern_id, pat.title
+ORDER BY week_start;
+
+-- name: ListActivityAttempts :many
+-- Activity feed: the user's latest completed attempts before a cursor
+SELECT a.id, a.performed_at, a.outcome, a.confidence_score, a.duration_seconds,
+       p.id AS problem_id, p.title AS problem_title
+FROM attempts a
+JOIN problems p ON p.id = a.problem_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.status = 'completed'
+  AND a.performed_at < sqlc.arg(before)
+ORDER BY a.performed_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetAttemptOutcomesByDifficulty :many
+-- Dashboard breakdown: completed attempt outcomes per problem difficulty since a time
+SELECT COALESCE(p.difficulty, 'medium')::text AS difficulty,
+       COUNT(*) AS attempt_count,
+       COUNT(*) FILTER (WHERE a.outcome = 'passed') AS passed_count,
+       COUNT(*) FILTER (WHERE a.outcome = 'failed') AS failed_count
+FROM attempts a
+JOIN problems p ON p.id = a.problem_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.status = 'completed'
+  AND a.performed_at >= sqlc.arg(since)
+GROUP BY 1;
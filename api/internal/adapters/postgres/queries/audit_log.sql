@@ -0,0 +1,3 @@
+-- name: InsertAuditLog :exec
+INSERT INTO audit_log (actor_id, action, target_id, details)
+VALUES ($1, $2, $3, $4);
@@ -0,0 +1,22 @@
+-- name: RecordBackgroundJobRun :exec
+INSERT INTO background_jobs (
+    name, last_trigger, last_started_at, last_finished_at, last_duration_ms,
+    last_error, run_count, failure_count
+)
+VALUES (
+    sqlc.arg(name), sqlc.arg(last_trigger), sqlc.arg(last_started_at), sqlc.arg(last_finished_at),
+    sqlc.arg(last_duration_ms), sqlc.narg(last_error), 1,
+    CASE WHEN sqlc.narg(last_error)::text IS NULL THEN 0 ELSE 1 END
+)
+ON CONFLICT (name) DO UPDATE
+SET last_trigger = EXCLUDED.last_trigger,
+    last_started_at = EXCLUDED.last_started_at,
+    last_finished_at = EXCLUDED.last_finished_at,
+    last_duration_ms = EXCLUDED.last_duration_ms,
+    last_error = EXCLUDED.last_error,
+    run_count = background_jobs.run_count + 1,
+    failure_count = background_jobs.failure_count + EXCLUDED.failure_count;
+
+-- name: ListBackgroundJobs :many
+SELECT * FROM background_jobs
+ORDER BY name;
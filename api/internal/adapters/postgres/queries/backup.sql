@@ -0,0 +1,76 @@
+-- Account backup export and restore
+
+-- name: ListAllProblemPatternLinks :many
+SELECT problem_id, pattern_id FROM problem_patterns
+ORDER BY problem_id, pattern_id;
+
+-- name: ExportSessionsForUser :many
+SELECT id, template_key, session_name, is_custom, custom_config_json,
+       planned_duration_min, items_ordered, elapsed_time_seconds, created_at, completed_at
+FROM revision_sessions
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ExportAttemptsForUser :many
+-- In-progress attempts are transient timer state and aren't backed up
+SELECT id, problem_id, session_id, confidence_score, duration_seconds, outcome,
+       notes, status, started_at, performed_at
+FROM attempts
+WHERE user_id = $1 AND COALESCE(status, 'completed') <> 'in_progress'
+ORDER BY performed_at;
+
+-- name: ExportProblemStatsForUser :many
+SELECT problem_id, status, confidence, avg_confidence, last_attempt_at, total_attempts,
+       avg_time_seconds, last_outcome, next_review_at, interval_days, ease_factor,
+       review_count, consecutive_failures, notes
+FROM user_problem_stats
+WHERE user_id = $1;
+
+-- name: FindSessionByCreatedAt :one
+SELECT id FROM revision_sessions
+WHERE user_id = $1 AND created_at = $2
+LIMIT 1;
+
+-- name: RestoreSession :one
+INSERT INTO revision_sessions (
+    user_id, template_key, session_name, is_custom, custom_config_json,
+    planned_duration_min, items_ordered, elapsed_time_seconds, created_at, completed_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id;
+
+-- name: AttemptExistsAt :one
+SELECT EXISTS (
+    SELECT 1 FROM attempts
+    WHERE user_id = $1 AND problem_id = $2 AND performed_at = $3
+)::boolean as exists;
+
+-- name: RestoreAttempt :exec
+INSERT INTO attempts (
+    user_id, problem_id, session_id, confidence_score, duration_seconds,
+    outcome, notes, status, started_at, performed_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: RestoreUserProblemStats :exec
+INSERT INTO user_problem_stats (
+    user_id, problem_id, status, confidence, avg_confidence, last_attempt_at,
+    total_attempts, avg_time_seconds, last_outcome, next_review_at, interval_days,
+    ease_factor, review_count, consecutive_failures, notes, updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW())
+ON CONFLICT(user_id, problem_id) DO UPDATE SET
+    status = excluded.status,
+    confidence = excluded.confidence,
+    avg_confidence = excluded.avg_confidence,
+    last_attempt_at = excluded.last_attempt_at,
+    total_attempts = excluded.total_attempts,
+    avg_time_seconds = excluded.avg_time_seconds,
+    last_outcome = excluded.last_outcome,
+    next_review_at = excluded.next_review_at,
+    interval_days = excluded.interval_days,
+    ease_factor = excluded.ease_factor,
+    review_count = excluded.review_count,
+    consecutive_failures = excluded.consecutive_failures,
+    notes = excluded.notes,
+    updated_at = NOW();
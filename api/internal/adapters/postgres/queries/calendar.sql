@@ -0,0 +1,42 @@
+-- name: UpsertCalendarFeedToken :one
+-- Creating a token replaces any previous one, so old feed URLs stop working
+INSERT INTO calendar_feed_tokens (user_id, token_hash)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET
+    token_hash = excluded.token_hash,
+    last_used_at = NULL,
+    created_at = NOW()
+RETURNING *;
+
+-- name: GetCalendarFeedToken :one
+SELECT * FROM calendar_feed_tokens
+WHERE user_id = $1;
+
+-- name: DeleteCalendarFeedToken :execrows
+DELETE FROM calendar_feed_tokens
+WHERE user_id = $1;
+
+-- name: GetCalendarFeedTokenByHash :one
+-- Feed requests: resolve a token to its owner
+SELECT t.user_id, u.is_active
+FROM calendar_feed_tokens t
+JOIN users u ON u.id = t.user_id
+WHERE t.token_hash = $1
+LIMIT 1;
+
+-- name: TouchCalendarFeedToken :exec
+-- Calendar apps poll, so this is throttled to one write an hour
+UPDATE calendar_feed_tokens
+SET last_used_at = NOW()
+WHERE user_id = $1
+  AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 hour');
+
+-- name: ListCalendarSessions :many
+-- Sessions not yet completed that were started inside the feed window
+SELECT id, session_name, template_key, planned_duration_min, items_ordered, created_at
+FROM revision_sessions
+WHERE user_id = sqlc.arg(user_id)
+  AND completed_at IS NULL
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY created_at;
@@ -0,0 +1,64 @@
+-- name: CreateCompany :one
+INSERT INTO companies (name)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetCompany :one
+SELECT * FROM companies
+WHERE id = $1 LIMIT 1;
+
+-- name: UpsertCompanyByName :one
+-- Import: returns the company with this name ignoring case, creating it if needed
+INSERT INTO companies (name)
+VALUES ($1)
+ON CONFLICT ((LOWER(name))) DO UPDATE SET name = companies.name
+RETURNING *;
+
+-- name: ListCompaniesWithCounts :many
+SELECT c.id, c.name, c.created_at, COUNT(pc.problem_id) AS problem_count
+FROM companies c
+LEFT JOIN problem_companies pc ON pc.company_id = c.id
+GROUP BY c.id
+ORDER BY c.name;
+
+-- name: DeleteCompany :execrows
+DELETE FROM companies
+WHERE id = $1;
+
+-- name: LinkProblemToCompany :exec
+-- Idempotent company linking (ignore if already linked)
+INSERT INTO problem_companies (problem_id, company_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlinkProblemsFromCompany :execrows
+DELETE FROM problem_companies
+WHERE company_id = sqlc.arg(company_id)
+  AND problem_id = ANY(sqlc.arg(problem_ids)::uuid[]);
+
+-- name: DeleteProblemCompanies :exec
+DELETE FROM problem_companies
+WHERE problem_id = $1;
+
+-- name: GetCompaniesForProblem :many
+SELECT c.id, c.name
+FROM companies c
+JOIN problem_companies pc ON c.id = pc.company_id
+WHERE pc.problem_id = $1
+ORDER BY c.name;
+
+-- name: GetCompaniesForProblems :many
+SELECT pc.problem_id, c.id, c.name
+FROM companies c
+JOIN problem_companies pc ON c.id = pc.company_id
+WHERE pc.problem_id = ANY(sqlc.arg(problem_ids)::uuid[])
+ORDER BY pc.problem_id, c.name;
+
+-- name: GetLinkedProblemIDsForCompany :many
+SELECT problem_id FROM problem_companies
+WHERE company_id = sqlc.arg(company_id) AND problem_id = ANY(sqlc.arg(problem_ids)::uuid[]);
+
+-- name: ListProblemIDsForCompany :many
+-- Session generation: candidates for a company-focused session
+SELECT problem_id FROM problem_companies
+WHERE company_id = $1;
@@ -0,0 +1,33 @@
+-- name: BumpDataVersion :exec
+INSERT INTO data_versions (scope, version)
+VALUES ($1, 1)
+ON CONFLICT (scope) DO UPDATE
+SET version = data_versions.version + 1,
+    updated_at = NOW();
+
+-- name: BumpUserDataVersions :exec
+-- Bumps each listed user's data version once, for writes made outside a
+-- request such as background jobs. User scopes are the user ID as text.
+INSERT INTO data_versions (scope, version)
+SELECT DISTINCT user_id::TEXT, 1
+FROM UNNEST(sqlc.arg(user_ids)::UUID[]) AS user_id
+ON CONFLICT (scope) DO UPDATE
+SET version = data_versions.version + 1,
+    updated_at = NOW();
+
+-- name: GetDataVersions :one
+-- A scope that was never bumped reads as version 0. Snoozes end with the
+-- clock rather than a write, so the end of the user's earliest active snooze
+-- (the epoch when there is none) is read too: it changes, and so does the
+-- ETag, the moment that snooze runs out.
+SELECT
+    COALESCE(MAX(version) FILTER (WHERE scope = 'catalog'), 0)::BIGINT AS catalog_version,
+    COALESCE(MAX(version) FILTER (WHERE scope = sqlc.arg(user_scope)::TEXT), 0)::BIGINT AS user_version,
+    COALESCE((
+        SELECT MIN(snoozed_until)
+        FROM user_problem_stats
+        WHERE user_id = sqlc.arg(user_scope)::TEXT::UUID
+          AND snoozed_until > NOW()
+    ), 'epoch')::TIMESTAMPTZ AS next_snooze_end
+FROM data_versions
+WHERE scope IN ('catalog', sqlc.arg(user_scope)::TEXT);
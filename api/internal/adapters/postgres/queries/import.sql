@@ -31,3 +31,32 @@ SELECT COUNT(*) as count FROM patterns;
 INSERT INTO problem_patterns (problem_id, pattern_id)
 VALUES ($1, $2)
 ON CONFLICT DO NOTHING;
+
+-- name: UpdateProblemFromImport :exec
+-- Overwrites difficulty and, when the CSV has one, the URL
+UPDATE problems
+SET difficulty = sqlc.arg(difficulty),
+    url = COALESCE(sqlc.narg(url), url)
+WHERE id = sqlc.arg(id);
+
+-- name: TagProblemWithDataset :exec
+-- Idempotent dataset tagging (ignore if already tagged)
+INSERT INTO problem_dataset_tags (problem_id, dataset_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: FindProblemsByTitlesAndSources :many
+-- Batched GetProblemByTitleAndSource: pairs are matched position by position
+SELECT p.id, p.title, p.source
+FROM problems p
+JOIN (
+    SELECT unnest(sqlc.arg(titles)::text[]) AS title,
+           unnest(sqlc.arg(sources)::text[]) AS source
+) k ON p.title = k.title AND p.source = k.source;
+
+-- name: FindProblemsByNormalizedURLs :many
+-- Batched FindProblemByNormalizedURL, with the same normalization
+SELECT id, rtrim(regexp_replace(lower(split_part(split_part(trim(url), '#', 1), '?', 1)), '^(https?://)?(www\.)?', ''), '/')::text AS normalized_url
+FROM problems
+WHERE url IS NOT NULL
+  AND rtrim(regexp_replace(lower(split_part(split_part(trim(url), '#', 1), '?', 1)), '^(https?://)?(www\.)?', ''), '/') = ANY(sqlc.arg(normalized_urls)::text[]);
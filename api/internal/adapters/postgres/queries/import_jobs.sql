@@ -0,0 +1,65 @@
+-- name: CreateImportJob :one
+INSERT INTO import_jobs (
+    user_id, source, dataset_id, file_hash, options,
+    total_rows, total_patterns, prior_job_id
+)
+VALUES (
+    sqlc.narg(user_id), sqlc.arg(source), sqlc.narg(dataset_id), sqlc.arg(file_hash), sqlc.arg(options),
+    sqlc.arg(total_rows), sqlc.arg(total_patterns), sqlc.narg(prior_job_id)
+)
+RETURNING *;
+
+-- name: UpdateImportJobProgress :exec
+UPDATE import_jobs
+SET patterns_created = sqlc.arg(patterns_created),
+    problems_processed = sqlc.arg(problems_processed),
+    problems_created = sqlc.arg(problems_created),
+    problems_updated = sqlc.arg(problems_updated),
+    duplicates_skipped = sqlc.arg(duplicates_skipped),
+    error_count = sqlc.arg(error_count)
+WHERE id = sqlc.arg(id);
+
+-- name: FinishImportJob :exec
+UPDATE import_jobs
+SET status = sqlc.arg(status),
+    patterns_created = sqlc.arg(patterns_created),
+    problems_processed = sqlc.arg(problems_processed),
+    problems_created = sqlc.arg(problems_created),
+    problems_updated = sqlc.arg(problems_updated),
+    duplicates_skipped = sqlc.arg(duplicates_skipped),
+    error_count = sqlc.arg(error_count),
+    error_message = sqlc.narg(error_message),
+    finished_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: GetImportJob :one
+SELECT * FROM import_jobs
+WHERE id = $1;
+
+-- name: GetLatestImportJobByHash :one
+-- Most recent earlier import of the same file, for resume reporting
+SELECT * FROM import_jobs
+WHERE file_hash = $1
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: ListImportJobs :many
+SELECT * FROM import_jobs
+ORDER BY started_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountImportJobs :one
+SELECT COUNT(*) FROM import_jobs;
+
+-- name: InsertImportJobErrors :exec
+-- Bulk insert; the arrays are matched position by position
+INSERT INTO import_job_errors (job_id, row_number, title, error)
+SELECT sqlc.arg(job_id)::uuid,
+       unnest(sqlc.arg(row_numbers)::int[]),
+       unnest(sqlc.arg(titles)::text[]),
+       unnest(sqlc.arg(errors)::text[]);
+
+-- name: ListImportJobErrors :many
+SELECT row_number, title, error FROM import_job_errors
+WHERE job_id = $1
+ORDER BY row_number, id;
@@ -14,16 +14,22 @@ UPDATE password_reset_tokens
 SET used_at = NOW()
 WHERE id = $1;
 
+-- name: ConsumePasswordResetToken :one
+-- Marks an unused, unexpired token as used in one statement so it can't be
+-- redeemed twice; no row means the token is unknown, expired or already used
+UPDATE password_reset_tokens
+SET used_at = NOW()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING user_id;
+
 -- name: DeletePasswordResetToken :exec
 DELETE FROM password_reset_tokens
 WHERE id = $1;
 
--- name: DeleteExpiredPasswordResetTokens :exec
--- Cleanup job: Remove expired tokens
-DELETE FROM password_reset_tokens
-WHERE expires_at < NOW();
-
--- name: DeleteUsedPasswordResetTokens :exec
--- Cleanup job: Remove tokens that were already used
+-- name: DeletePasswordResetTokensCreatedBefore :execrows
+-- Cleanup job: reset tokens expire within hours, so anything older than the
+-- cutoff is used or expired and only kept as short-lived history
 DELETE FROM password_reset_tokens
-WHERE used_at IS NOT NULL;
+WHERE created_at < $1;
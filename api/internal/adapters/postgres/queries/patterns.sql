@@ -54,7 +54,12 @@ SELECT
     COALESCE(ups.times_revised, 0) as times_revised,
     COALESCE(ups.avg_confidence, 0) as avg_confidence,
     ups.last_revised_at,
-    COALESCE(pc.problem_count, 0) as problem_count
+    COALESCE(pc.problem_count, 0) as problem_count,
+    CASE WHEN sqlc.arg(problem_query)::text = '' THEN 0::bigint ELSE (
+        SELECT COUNT(*) FROM problem_patterns mpp
+        JOIN problems mp ON mp.id = mpp.problem_id
+        WHERE mpp.pattern_id = p.id AND mp.title ILIKE '%' || sqlc.arg(problem_query)::text || '%'
+    ) END::bigint as matching_problem_count
 FROM patterns p
 LEFT JOIN user_pattern_stats ups ON p.id = ups.pattern_id AND ups.user_id = sqlc.arg(user_id)
 LEFT JOIN (
@@ -63,15 +68,86 @@ LEFT JOIN (
     GROUP BY pattern_id
 ) pc ON p.id = pc.pattern_id
 WHERE (sqlc.arg(search_query) = '' OR p.title LIKE '%' || sqlc.arg(search_query) || '%' OR p.description LIKE '%' || sqlc.arg(search_query) || '%')
-ORDER BY p.title ASC
+  AND (sqlc.arg(problem_query)::text = '' OR EXISTS (
+    SELECT 1 FROM problem_patterns epp
+    JOIN problems ep ON ep.id = epp.problem_id
+    WHERE epp.pattern_id = p.id AND ep.title ILIKE '%' || sqlc.arg(problem_query)::text || '%'
+  ))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by)::text = 'confidence_asc' THEN COALESCE(ups.avg_confidence, 0) END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'confidence_desc' THEN COALESCE(ups.avg_confidence, 0) END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'times_revised_asc' THEN COALESCE(ups.times_revised, 0) END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'times_revised_desc' THEN COALESCE(ups.times_revised, 0) END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'problem_count_asc' THEN COALESCE(pc.problem_count, 0) END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'problem_count_desc' THEN COALESCE(pc.problem_count, 0) END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'title_desc' THEN p.title END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'last_revised_asc' THEN ups.last_revised_at END ASC NULLS FIRST,
+  CASE WHEN sqlc.arg(sort_by)::text = 'last_revised_desc' THEN ups.last_revised_at END DESC NULLS LAST,
+  p.title ASC,
+  p.id ASC
 LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
 
 -- name: CountSearchPatternsWithStats :one
 SELECT COUNT(DISTINCT p.id) as count
 FROM patterns p
-WHERE (sqlc.arg(search_query) = '' OR p.title LIKE '%' || sqlc.arg(search_query) || '%' OR p.description LIKE '%' || sqlc.arg(search_query) || '%');
+WHERE (sqlc.arg(search_query) = '' OR p.title LIKE '%' || sqlc.arg(search_query) || '%' OR p.description LIKE '%' || sqlc.arg(search_query) || '%')
+  AND (sqlc.arg(problem_query)::text = '' OR EXISTS (
+    SELECT 1 FROM problem_patterns epp
+    JOIN problems ep ON ep.id = epp.problem_id
+    WHERE epp.pattern_id = p.id AND ep.title ILIKE '%' || sqlc.arg(problem_query)::text || '%'
+  ));
 
 -- name: GetUniqueProblemCount :one
 -- Returns the count of unique problems across all patterns (no double-counting)
 SELECT COUNT(DISTINCT pp.problem_id) as count
 FROM problem_patterns pp;
+
+-- name: MovePatternLinks :exec
+-- Re-point links from the source patterns to the target, skipping problems already linked to it
+INSERT INTO problem_patterns (problem_id, pattern_id)
+SELECT DISTINCT problem_id, sqlc.arg(target_id)::uuid
+FROM problem_patterns
+WHERE pattern_id = ANY(sqlc.arg(source_ids)::uuid[])
+ON CONFLICT (problem_id, pattern_id) DO NOTHING;
+
+-- name: DeletePatternsByIDs :exec
+DELETE FROM patterns
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetPatternProblemsWithStats :many
+-- Score comes from the problem_scores cache and is NULL until a problem has been scored
+SELECT p.id, p.title, p.difficulty, p.source, p.url,
+       ups.status, ups.confidence, ups.last_attempt_at, ups.total_attempts,
+       ps.score
+FROM problem_patterns pp
+JOIN problems p ON p.id = pp.problem_id
+LEFT JOIN user_problem_stats ups ON ups.problem_id = p.id AND ups.user_id = sqlc.arg(user_id)
+LEFT JOIN problem_scores ps ON ps.problem_id = p.id AND ps.user_id = sqlc.arg(user_id)
+WHERE pp.pattern_id = sqlc.arg(pattern_id)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'confidence_asc' THEN ups.confidence END ASC NULLS FIRST,
+  CASE WHEN sqlc.arg(sort_by) = 'confidence_desc' THEN ups.confidence END DESC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by) = 'score_desc' THEN ps.score END DESC NULLS LAST,
+  CASE WHEN sqlc.arg(sort_by) = 'last_attempt_asc' THEN ups.last_attempt_at END ASC NULLS FIRST,
+  CASE WHEN sqlc.arg(sort_by) = 'last_attempt_desc' THEN ups.last_attempt_at END DESC NULLS LAST,
+  p.title ASC
+LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
+
+-- name: GetExistingProblemIDs :many
+SELECT id FROM problems
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetLinkedProblemIDsForPattern :many
+SELECT problem_id FROM problem_patterns
+WHERE pattern_id = sqlc.arg(pattern_id) AND problem_id = ANY(sqlc.arg(problem_ids)::uuid[]);
+
+-- name: DeleteOrphanedUserPatternStats :exec
+-- Drops stats for users who no longer have attempted problems in the pattern
+DELETE FROM user_pattern_stats
+WHERE user_pattern_stats.pattern_id = $1
+  AND NOT EXISTS (
+    SELECT 1 FROM problem_patterns pp
+    JOIN user_problem_stats ups ON ups.problem_id = pp.problem_id
+    WHERE pp.pattern_id = user_pattern_stats.pattern_id
+      AND ups.user_id = user_pattern_stats.user_id
+  );
@@ -0,0 +1,47 @@
+-- name: GetProblemScore :one
+SELECT * FROM problem_scores
+WHERE user_id = $1 AND problem_id = $2
+LIMIT 1;
+
+-- name: ListProblemScoresForUser :many
+SELECT * FROM problem_scores
+WHERE user_id = $1
+ORDER BY score DESC;
+
+-- name: UpsertProblemScore :exec
+INSERT INTO problem_scores (user_id, problem_id, score, features_json, computed_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT(user_id, problem_id) DO UPDATE SET
+    score = excluded.score,
+    features_json = excluded.features_json,
+    computed_at = excluded.computed_at;
+
+-- name: DeleteProblemScore :exec
+DELETE FROM problem_scores
+WHERE user_id = $1 AND problem_id = $2;
+
+-- name: DeleteProblemScoresForUser :exec
+DELETE FROM problem_scores
+WHERE user_id = $1;
+
+-- name: DeleteProblemScoresForProblem :exec
+DELETE FROM problem_scores
+WHERE problem_id = $1;
+
+-- name: DeleteAllProblemScores :exec
+DELETE FROM problem_scores;
+
+-- name: DeleteProblemScoresForPattern :exec
+DELETE FROM problem_scores
+WHERE problem_id IN (
+    SELECT problem_id FROM problem_patterns WHERE pattern_id = $1
+);
+
+-- name: DeleteUserProblemScoresForPattern :exec
+-- Drops one user's cached scores for a pattern's problems, whose f_pattern
+-- reads that user's pattern stats
+DELETE FROM problem_scores
+WHERE user_id = sqlc.arg(user_id)::uuid
+  AND problem_id IN (
+    SELECT problem_id FROM problem_patterns WHERE pattern_id = sqlc.arg(pattern_id)::uuid
+);
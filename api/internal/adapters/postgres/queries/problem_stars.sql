@@ -0,0 +1,18 @@
+-- name: StarProblem :exec
+INSERT INTO user_problem_stars (user_id, problem_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, problem_id) DO NOTHING;
+
+-- name: UnstarProblem :exec
+DELETE FROM user_problem_stars
+WHERE user_id = $1 AND problem_id = $2;
+
+-- name: IsProblemStarred :one
+SELECT EXISTS (
+    SELECT 1 FROM user_problem_stars
+    WHERE user_id = $1 AND problem_id = $2
+) AS starred;
+
+-- name: ListStarredProblemIDs :many
+SELECT problem_id FROM user_problem_stars
+WHERE user_id = $1;
@@ -22,7 +22,7 @@ SET title = $1, source = $2, url = $3, difficulty = $4
 WHERE id = $5
 RETURNING id, title, source, url, difficulty, created_at;
 
--- name: DeleteProblem :exec
+-- name: DeleteProblem :execrows
 DELETE FROM problems
 WHERE id = $1;
 
@@ -36,32 +36,154 @@ FROM patterns p
 JOIN problem_patterns pp ON p.id = pp.pattern_id
 WHERE pp.problem_id = $1;
 
+-- name: GetPatternsForProblems :many
+SELECT pp.problem_id, p.id, p.title, p.description
+FROM patterns p
+JOIN problem_patterns pp ON p.id = pp.pattern_id
+WHERE pp.problem_id = ANY(sqlc.arg(problem_ids)::uuid[])
+ORDER BY pp.problem_id;
+
 -- name: DeleteProblemPatterns :exec
 DELETE FROM problem_patterns
 WHERE problem_id = $1;
 
 -- name: GetProblemsForUser :many
 SELECT p.*, ups.status, ups.confidence, ups.avg_confidence, 
-       ups.last_attempt_at, ups.total_attempts, ups.last_outcome, ups.updated_at
+       ups.last_attempt_at, ups.total_attempts, ups.last_outcome, ups.updated_at,
+       ups.consecutive_failures, ups.notes, ups.snoozed_until,
+       (st.problem_id IS NOT NULL)::boolean AS is_starred
 FROM problems p
 LEFT JOIN user_problem_stats ups ON p.id = ups.problem_id AND ups.user_id = $1
+LEFT JOIN user_problem_stars st ON p.id = st.problem_id AND st.user_id = $1
 ORDER BY p.created_at DESC;
 
 -- name: SearchProblemsForUser :many
 SELECT p.*, ups.status, ups.confidence, ups.avg_confidence, 
-       ups.last_attempt_at, ups.total_attempts, ups.last_outcome, ups.updated_at
+       ups.last_attempt_at, ups.total_attempts, ups.last_outcome, ups.updated_at,
+       ups.consecutive_failures, ups.notes, ups.snoozed_until,
+       (st.problem_id IS NOT NULL)::boolean AS is_starred
 FROM problems p
 LEFT JOIN user_problem_stats ups ON p.id = ups.problem_id AND ups.user_id = sqlc.arg(user_id)
+LEFT JOIN user_problem_stars st ON p.id = st.problem_id AND st.user_id = sqlc.arg(user_id)
 WHERE (sqlc.arg(search_query) = '' OR p.title LIKE '%' || sqlc.arg(search_query) || '%' OR p.source LIKE '%' || sqlc.arg(search_query) || '%')
   AND (sqlc.arg(difficulty) = '' OR p.difficulty = sqlc.arg(difficulty))
+  AND (sqlc.arg(source) = '' OR p.source ILIKE sqlc.arg(source))
   AND (sqlc.arg(status) = '' OR ups.status = sqlc.arg(status) OR (ups.status IS NULL AND sqlc.arg(status) = 'unsolved'))
-ORDER BY p.created_at DESC
+  AND (sqlc.arg(notes_contains) = '' OR ups.notes ILIKE '%' || sqlc.arg(notes_contains) || '%')
+  AND (NOT sqlc.arg(starred_only)::boolean OR st.problem_id IS NOT NULL)
+  AND (COALESCE(cardinality(sqlc.arg(pattern_ids)::uuid[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM problem_patterns pp
+      WHERE pp.problem_id = p.id AND pp.pattern_id = ANY(sqlc.arg(pattern_ids)::uuid[])
+  ))
+  AND (sqlc.narg(company_id)::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM problem_companies pc
+      WHERE pc.problem_id = p.id AND pc.company_id = sqlc.narg(company_id)::uuid
+  ))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'last_attempt_asc' THEN ups.last_attempt_at END ASC NULLS FIRST,
+  CASE WHEN sqlc.arg(sort_by) = 'confidence_asc' THEN ups.confidence END ASC NULLS FIRST,
+  CASE WHEN sqlc.arg(sort_by) = 'title_asc' THEN p.title END ASC,
+  p.created_at DESC
 LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
 
 -- name: CountProblemsForUser :one
 SELECT COUNT(DISTINCT p.id) as count
 FROM problems p
 LEFT JOIN user_problem_stats ups ON p.id = ups.problem_id AND ups.user_id = sqlc.arg(user_id)
+LEFT JOIN user_problem_stars st ON p.id = st.problem_id AND st.user_id = sqlc.arg(user_id)
 WHERE (sqlc.arg(search_query) = '' OR p.title LIKE '%' || sqlc.arg(search_query) || '%' OR p.source LIKE '%' || sqlc.arg(search_query) || '%')
   AND (sqlc.arg(difficulty) = '' OR p.difficulty = sqlc.arg(difficulty))
-  AND (sqlc.arg(status) = '' OR ups.status = sqlc.arg(status) OR (ups.status IS NULL AND sqlc.arg(status) = 'unsolved'));
+  AND (sqlc.arg(source) = '' OR p.source ILIKE sqlc.arg(source))
+  AND (sqlc.arg(status) = '' OR ups.status = sqlc.arg(status) OR (ups.status IS NULL AND sqlc.arg(status) = 'unsolved'))
+  AND (sqlc.arg(notes_contains) = '' OR ups.notes ILIKE '%' || sqlc.arg(notes_contains) || '%')
+  AND (NOT sqlc.arg(starred_only)::boolean OR st.problem_id IS NOT NULL)
+  AND (COALESCE(cardinality(sqlc.arg(pattern_ids)::uuid[]), 0) = 0 OR EXISTS (
+      SELECT 1 FROM problem_patterns pp
+      WHERE pp.problem_id = p.id AND pp.pattern_id = ANY(sqlc.arg(pattern_ids)::uuid[])
+  ))
+  AND (sqlc.narg(company_id)::uuid IS NULL OR EXISTS (
+      SELECT 1 FROM problem_companies pc
+      WHERE pc.problem_id = p.id AND pc.company_id = sqlc.narg(company_id)::uuid
+  ));
+
+-- name: DeleteProblemsByIDs :many
+DELETE FROM problems
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+RETURNING id;
+
+-- name: UpdateProblemFields :one
+-- Partial update: NULL arguments leave the column unchanged
+UPDATE problems
+SET difficulty = COALESCE(sqlc.narg(difficulty), difficulty),
+    source = COALESCE(sqlc.narg(source), source)
+WHERE id = sqlc.arg(id)
+RETURNING id, title, source, url, difficulty, created_at;
+
+-- name: UnlinkProblemFromPattern :exec
+DELETE FROM problem_patterns
+WHERE problem_id = $1 AND pattern_id = $2;
+
+-- name: GetRelatedProblems :many
+-- Problems sharing at least one pattern with the given problem, excluding ones the user has retired
+SELECT p.id, p.title, p.difficulty, ups.confidence,
+       array_agg(pat.title ORDER BY pat.title)::text[] AS shared_patterns,
+       COUNT(*) AS shared_count
+FROM problem_patterns src
+JOIN problem_patterns pp ON pp.pattern_id = src.pattern_id AND pp.problem_id != src.problem_id
+JOIN problems p ON p.id = pp.problem_id
+JOIN patterns pat ON pat.id = pp.pattern_id
+LEFT JOIN user_problem_stats ups ON ups.problem_id = p.id AND ups.user_id = sqlc.arg(user_id)
+WHERE src.problem_id = sqlc.arg(problem_id)
+  AND (ups.status IS NULL OR ups.status NOT IN ('mastered', 'abandoned'))
+GROUP BY p.id, p.title, p.difficulty, ups.confidence
+ORDER BY shared_count DESC, p.title;
+
+-- name: GetUnpatternedProblems :many
+SELECT p.* FROM problems p
+WHERE NOT EXISTS (SELECT 1 FROM problem_patterns pp WHERE pp.problem_id = p.id)
+ORDER BY p.created_at DESC
+LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
+
+-- name: CountUnpatternedProblems :one
+SELECT COUNT(*) as count FROM problems p
+WHERE NOT EXISTS (SELECT 1 FROM problem_patterns pp WHERE pp.problem_id = p.id);
+
+-- name: GetProblemBreakdown :many
+-- Counts per difficulty x status x source; problems without stats count as unsolved
+SELECT COALESCE(p.difficulty, 'medium')::text AS difficulty,
+       COALESCE(ups.status, 'unsolved')::text AS status,
+       COALESCE(NULLIF(p.source, ''), 'Unknown')::text AS source,
+       COUNT(*) AS count
+FROM problems p
+LEFT JOIN user_problem_stats ups ON p.id = ups.problem_id AND ups.user_id = $1
+GROUP BY 1, 2, 3
+ORDER BY 3, 1, 2;
+
+-- name: FindProblemByNormalizedURL :one
+-- Mirrors utils.NormalizeProblemURL: drop fragment and query, lowercase, drop the
+-- http(s) scheme and a leading www., strip trailing slashes
+SELECT id, title FROM problems
+WHERE url IS NOT NULL
+  AND rtrim(regexp_replace(lower(split_part(split_part(trim(url), '#', 1), '?', 1)), '^(https?://)?(www\.)?', ''), '/') = sqlc.arg(normalized_url)::text
+LIMIT 1;
+
+-- name: ListActivityProblemsAdded :many
+-- Activity feed: problems added to the library before a cursor
+SELECT id, title, difficulty, created_at
+FROM problems
+WHERE created_at < sqlc.arg(before)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetSourceStatsForUser :many
+-- Dashboard breakdown: per source, problem count plus the user's solved count
+-- and average confidence over the problems they have stats for
+SELECT COALESCE(NULLIF(p.source, ''), 'Unknown')::text AS source,
+       COUNT(*) AS problem_count,
+       COUNT(*) FILTER (WHERE ups.status IN ('solved', 'mastered')) AS solved_count,
+       COUNT(ups.id) AS tracked_count,
+       COALESCE(AVG(ups.confidence), 0)::float8 AS avg_confidence
+FROM problems p
+LEFT JOIN user_problem_stats ups ON p.id = ups.problem_id AND ups.user_id = $1
+GROUP BY 1
+ORDER BY problem_count DESC, 1;
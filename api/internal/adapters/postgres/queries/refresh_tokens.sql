@@ -1,17 +1,73 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (user_id, token_hash, expires_at, user_agent, ip_address)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO refresh_tokens (user_id, token_hash, expires_at, user_agent, ip_address, family_id, parent_id, remember_me)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING id, user_id, token_hash, expires_at, created_at;
 
 -- name: GetRefreshTokenByHash :one
-SELECT id, user_id, token_hash, expires_at
+SELECT id, user_id, token_hash, expires_at, family_id, revoked_at, remember_me
 FROM refresh_tokens
 WHERE token_hash = $1 LIMIT 1;
 
+-- name: MarkRefreshTokenRotated :execrows
+-- Zero rows means another request already rotated this token
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE id = $1
+  AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+-- Reuse detected: every token descended from the same login stops working
+UPDATE refresh_tokens
+SET revoked_at = NOW()
+WHERE family_id = $1
+  AND revoked_at IS NULL;
+
 -- name: RevokeRefreshToken :exec
 DELETE FROM refresh_tokens
 WHERE token_hash = $1;
 
--- name: DeleteExpiredTokens :exec
+-- name: RevokeUserRefreshTokens :exec
+-- Logs a user out everywhere (e.g. after a password reset)
+DELETE FROM refresh_tokens
+WHERE user_id = $1;
+
+-- name: ListUserSessions :many
+-- One row per login: the live token of each family, with the family's
+-- earliest surviving token standing in for when the session started
+SELECT
+    rt.id,
+    rt.family_id,
+    rt.token_hash,
+    rt.user_agent,
+    rt.ip_address,
+    rt.expires_at,
+    rt.created_at AS last_used_at,
+    (SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = rt.family_id)::timestamptz AS started_at
+FROM refresh_tokens rt
+WHERE rt.user_id = $1
+  AND rt.revoked_at IS NULL
+  AND rt.expires_at > NOW()
+ORDER BY rt.created_at DESC;
+
+-- name: DeleteUserSession :execrows
+-- Deletes every token in a family rather than marking them revoked, so a
+-- later refresh with one of them is simply invalid instead of looking like reuse
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+  AND user_id = $2;
+
+-- name: DeleteOtherUserSessions :exec
+-- Signs a user out everywhere except the session holding the given token hash
+-- (everywhere, if the hash is unknown)
+DELETE FROM refresh_tokens rt
+WHERE rt.user_id = $1
+  AND rt.family_id NOT IN (
+      SELECT cur.family_id FROM refresh_tokens cur
+      WHERE cur.token_hash = $2 AND cur.user_id = $1
+  );
+
+-- name: DeleteExpiredRefreshTokens :execrows
+-- Cleanup job: the cutoff trails NOW() by a grace window so a token that just
+-- expired still reads as expired rather than unknown
 DELETE FROM refresh_tokens
-WHERE expires_at < NOW();
+WHERE expires_at < $1;
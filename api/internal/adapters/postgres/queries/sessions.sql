@@ -39,7 +39,12 @@ UPDATE revision_sessions
 SET completed_at = $1
 WHERE id = $2 AND user_id = $3;
 
--- name: DeleteSession :exec
+-- name: BackdateSession :exec
+UPDATE revision_sessions
+SET created_at = $3, completed_at = $4
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteSession :execrows
 DELETE FROM revision_sessions
 WHERE id = $1 AND user_id = $2;
 
@@ -54,3 +59,30 @@ WHERE id = $4 AND user_id = $5;
 UPDATE revision_sessions
 SET items_ordered = $1
 WHERE id = $2 AND user_id = $3;
+
+-- name: GetActiveSessionsContainingProblems :many
+SELECT * FROM revision_sessions
+WHERE completed_at IS NULL
+  AND items_ordered IS NOT NULL
+  AND items_ordered <> ''
+  AND jsonb_exists_any(items_ordered::jsonb, sqlc.arg(problem_ids)::text[]);
+
+-- name: CountUserSessionsCompletedBetween :one
+SELECT COUNT(*) FROM revision_sessions
+WHERE user_id = sqlc.arg(user_id)
+  AND completed_at >= sqlc.arg(from_time)
+  AND completed_at < sqlc.arg(to_time);
+
+-- name: ListActivitySessionEvents :many
+-- Activity feed: session created and completed events before a cursor
+SELECT c.id, c.session_name, c.template_key, 'session_created'::text AS event, c.created_at AS occurred_at
+FROM revision_sessions c
+WHERE c.user_id = sqlc.arg(user_id)
+  AND c.created_at < sqlc.arg(before)
+UNION ALL
+SELECT d.id, d.session_name, d.template_key, 'session_completed'::text AS event, d.completed_at AS occurred_at
+FROM revision_sessions d
+WHERE d.user_id = sqlc.arg(user_id)
+  AND d.completed_at < sqlc.arg(before)
+ORDER BY occurred_at DESC
+LIMIT sqlc.arg(row_limit);
@@ -28,3 +28,26 @@ WHERE key IN ('w_conf', 'w_days', 'w_attempts', 'w_time', 'w_difficulty', 'w_fai
 -- name: GetSignupSettings :many
 SELECT key, value FROM system_settings
 WHERE key IN ('signup_enabled', 'invite_codes_enabled');
+
+-- name: GetLeechThreshold :one
+SELECT value FROM system_settings
+WHERE key = 'leech_threshold'
+LIMIT 1;
+
+-- name: GetSolveTimeSettings :many
+SELECT key, value FROM system_settings
+WHERE key IN ('sr_slow_multiplier', 'sr_fast_multiplier');
+
+-- name: GetStarredBoost :one
+SELECT value FROM system_settings
+WHERE key = 'starred_boost'
+LIMIT 1;
+
+-- name: GetWeakestPatternMinProblems :one
+SELECT value FROM system_settings
+WHERE key = 'weakest_pattern_min_problems'
+LIMIT 1;
+
+-- name: GetLoginRateLimitSettings :many
+SELECT key, value FROM system_settings
+WHERE key IN ('login_max_failures', 'login_lockout_window_minutes');
@@ -0,0 +1,51 @@
+-- name: CreateUserGoal :one
+INSERT INTO user_goals (user_id, goal_type, target_value, period)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListUserGoals :many
+SELECT * FROM user_goals
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: ListActiveUserGoals :many
+SELECT * FROM user_goals
+WHERE user_id = $1
+  AND is_active = true
+ORDER BY created_at;
+
+-- name: UpdateUserGoal :one
+UPDATE user_goals
+SET target_value = $3,
+    is_active = $4,
+    updated_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: GetUserGoal :one
+SELECT * FROM user_goals
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: DeleteUserGoal :execrows
+DELETE FROM user_goals
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetGoalActivityBetween :one
+-- Goal progress: everything a goal type can count, in [from, to)
+SELECT (SELECT COUNT(*) FROM revision_sessions rs
+        WHERE rs.user_id = sqlc.arg(user_id)
+          AND rs.completed_at >= sqlc.arg(from_time)
+          AND rs.completed_at < sqlc.arg(to_time))::bigint AS sessions_completed,
+       (SELECT COALESCE(SUM(a.duration_seconds), 0) FROM attempts a
+        WHERE a.user_id = sqlc.arg(user_id)
+          AND a.status = 'completed'
+          AND a.performed_at >= sqlc.arg(from_time)
+          AND a.performed_at < sqlc.arg(to_time))::bigint AS total_seconds,
+       (SELECT COUNT(DISTINCT a.problem_id) FROM attempts a
+        WHERE a.user_id = sqlc.arg(user_id)
+          AND a.status = 'completed'
+          AND a.performed_at >= sqlc.arg(from_time)
+          AND a.performed_at < sqlc.arg(to_time))::bigint AS problems_practiced;
@@ -26,7 +26,114 @@ ORDER BY ups.avg_confidence ASC
 LIMIT 1;
 
 -- name: GetPatternsWithStats :many
-SELECT p.*, ups.times_revised, ups.avg_confidence, ups.last_revised_at
+SELECT p.*, ups.times_revised, ups.avg_confidence, ups.last_revised_at,
+       COALESCE(pc.problem_count, 0)::bigint as problem_count
 FROM patterns p
 LEFT JOIN user_pattern_stats ups ON p.id = ups.pattern_id AND ups.user_id = $1
+LEFT JOIN (
+    SELECT pattern_id, COUNT(*) as problem_count
+    FROM problem_patterns
+    GROUP BY pattern_id
+) pc ON p.id = pc.pattern_id
 ORDER BY p.title;
+
+-- name: RecomputeUserPatternStatsForPattern :exec
+-- Rebuilds every user's stats for one pattern from their problem stats
+INSERT INTO user_pattern_stats (user_id, pattern_id, times_revised, avg_confidence, last_revised_at)
+SELECT ups.user_id, pp.pattern_id,
+       COALESCE(SUM(ups.total_attempts), 0)::int,
+       COALESCE(FLOOR(AVG(ups.avg_confidence)), 0)::int,
+       MAX(ups.last_attempt_at)
+FROM problem_patterns pp
+JOIN user_problem_stats ups ON ups.problem_id = pp.problem_id
+WHERE pp.pattern_id = $1
+GROUP BY ups.user_id, pp.pattern_id
+ON CONFLICT(user_id, pattern_id) DO UPDATE SET
+    times_revised = excluded.times_revised,
+    avg_confidence = excluded.avg_confidence,
+    last_revised_at = excluded.last_revised_at;
+
+-- name: UpsertPatternStatsSnapshot :exec
+INSERT INTO user_pattern_stats_history (user_id, pattern_id, avg_confidence, times_revised)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT(user_id, pattern_id, captured_on) DO UPDATE SET
+    avg_confidence = excluded.avg_confidence,
+    times_revised = excluded.times_revised,
+    captured_at = NOW();
+
+-- name: GetPatternStatsHistory :many
+SELECT avg_confidence, times_revised, captured_at
+FROM user_pattern_stats_history
+WHERE user_id = sqlc.arg(user_id)
+  AND pattern_id = sqlc.arg(pattern_id)
+  AND captured_at >= sqlc.arg(from_time)
+  AND captured_at < sqlc.arg(to_time)
+ORDER BY captured_at ASC;
+
+-- name: GetPatternProblemStatusCounts :many
+-- Per-pattern problem totals plus how many are unsolved or past their review date for the user
+SELECT pp.pattern_id,
+       COUNT(*)::bigint as problem_count,
+       COUNT(*) FILTER (WHERE ups.status IS NULL OR ups.status = 'unsolved')::bigint as unsolved_count,
+       COUNT(*) FILTER (
+           WHERE ups.next_review_at < NOW() AND ups.status NOT IN ('mastered', 'abandoned')
+       )::bigint as overdue_count
+FROM problem_patterns pp
+LEFT JOIN user_problem_stats ups ON ups.problem_id = pp.problem_id AND ups.user_id = $1
+GROUP BY pp.pattern_id;
+
+-- name: GetPatternConfidenceChanges :many
+-- Weekly report: patterns with a snapshot in [from_date, to_date), comparing the
+-- last snapshot in range against the last one before it (or the first in range
+-- when the pattern has no earlier history)
+WITH in_range AS (
+    SELECT pattern_id, avg_confidence, captured_on
+    FROM user_pattern_stats_history
+    WHERE user_id = sqlc.arg(user_id)
+      AND captured_on >= sqlc.arg(from_date)::date
+      AND captured_on < sqlc.arg(to_date)::date
+),
+latest AS (
+    SELECT DISTINCT ON (pattern_id) pattern_id, avg_confidence
+    FROM in_range
+    ORDER BY pattern_id, captured_on DESC
+),
+earliest AS (
+    SELECT DISTINCT ON (pattern_id) pattern_id, avg_confidence
+    FROM in_range
+    ORDER BY pattern_id, captured_on ASC
+)
+SELECT l.pattern_id,
+       p.title,
+       COALESCE((
+           SELECT b.avg_confidence
+           FROM user_pattern_stats_history b
+           WHERE b.user_id = sqlc.arg(user_id)
+             AND b.pattern_id = l.pattern_id
+             AND b.captured_on < sqlc.arg(from_date)::date
+           ORDER BY b.captured_on DESC
+           LIMIT 1
+       ), e.avg_confidence)::int AS start_confidence,
+       l.avg_confidence AS end_confidence
+FROM latest l
+JOIN earliest e ON e.pattern_id = l.pattern_id
+JOIN patterns p ON p.id = l.pattern_id
+ORDER BY p.title;
+
+-- name: ListActivityPatternGraduations :many
+-- Activity feed: daily snapshots where a pattern's confidence first reached the
+-- threshold after being below it (a first snapshot counts as coming from 0)
+WITH snapshots AS (
+    SELECT pattern_id, avg_confidence, captured_at,
+           LAG(avg_confidence) OVER (PARTITION BY pattern_id ORDER BY captured_on) AS previous_confidence
+    FROM user_pattern_stats_history
+    WHERE user_id = sqlc.arg(user_id)
+)
+SELECT s.pattern_id, p.title AS pattern_title, s.avg_confidence, s.captured_at
+FROM snapshots s
+JOIN patterns p ON p.id = s.pattern_id
+WHERE s.avg_confidence >= sqlc.arg(threshold)::int
+  AND COALESCE(s.previous_confidence, 0) < sqlc.arg(threshold)::int
+  AND s.captured_at < sqlc.arg(before)::timestamptz
+ORDER BY s.captured_at DESC
+LIMIT sqlc.arg(row_limit);
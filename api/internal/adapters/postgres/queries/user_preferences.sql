@@ -0,0 +1,23 @@
+-- name: GetUserPreferences :one
+SELECT * FROM user_preferences
+WHERE user_id = $1;
+
+-- name: UpsertUserPreferences :one
+-- NULL arguments keep the stored value
+INSERT INTO user_preferences (
+    user_id, timezone, week_start, default_session_duration_min,
+    scoring_emphasis, auto_complete_session
+)
+VALUES (
+    sqlc.arg(user_id), sqlc.narg(timezone), sqlc.narg(week_start),
+    sqlc.narg(default_session_duration_min), sqlc.narg(scoring_emphasis),
+    sqlc.narg(auto_complete_session)
+)
+ON CONFLICT (user_id) DO UPDATE
+SET timezone = COALESCE(EXCLUDED.timezone, user_preferences.timezone),
+    week_start = COALESCE(EXCLUDED.week_start, user_preferences.week_start),
+    default_session_duration_min = COALESCE(EXCLUDED.default_session_duration_min, user_preferences.default_session_duration_min),
+    scoring_emphasis = COALESCE(EXCLUDED.scoring_emphasis, user_preferences.scoring_emphasis),
+    auto_complete_session = COALESCE(EXCLUDED.auto_complete_session, user_preferences.auto_complete_session),
+    updated_at = NOW()
+RETURNING *;
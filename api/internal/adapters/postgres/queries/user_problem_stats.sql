@@ -24,9 +24,9 @@ RETURNING *;
 INSERT INTO user_problem_stats (
     user_id, problem_id, status, confidence, avg_confidence,
     last_attempt_at, total_attempts, avg_time_seconds, last_outcome, recent_history_json,
-    next_review_at, interval_days, ease_factor, review_count
+    next_review_at, interval_days, ease_factor, review_count, consecutive_failures
 )
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 ON CONFLICT(user_id, problem_id) DO UPDATE SET
     status = excluded.status,
     confidence = excluded.confidence,
@@ -39,7 +39,11 @@ ON CONFLICT(user_id, problem_id) DO UPDATE SET
     next_review_at = excluded.next_review_at,
     interval_days = excluded.interval_days,
     ease_factor = excluded.ease_factor,
-    review_count = excluded.review_count
+    review_count = excluded.review_count,
+    consecutive_failures = excluded.consecutive_failures,
+    -- Practising a snoozed problem ends the snooze; SM-2 has set a new due date
+    snoozed_until = NULL,
+    snoozed_from_review_at = NULL
 RETURNING *;
 
 -- name: UpdateSpacedRepetition :exec
@@ -101,9 +105,126 @@ WHERE user_id = $1;
 -- name: GetMasteredProblemsForUser :one
 SELECT COUNT(*) as count
 FROM user_problem_stats
-WHERE user_id = $1 AND status = 'solved' AND confidence >= 80;
+WHERE user_id = $1
+  AND (status = 'mastered' OR (status = 'solved' AND confidence >= 80));
 
 -- name: GetAverageConfidenceForUser :one
-SELECT COALESCE(AVG(confidence), 0) as avg_confidence
+SELECT COALESCE(AVG(confidence), 0)::float8 as avg_confidence
 FROM user_problem_stats
 WHERE user_id = $1 AND status != 'abandoned';
+
+-- name: GetDueProblems :many
+SELECT ups.problem_id, ups.next_review_at, ups.interval_days, ups.confidence, ups.status,
+       p.title, p.source, p.url, p.difficulty
+FROM user_problem_stats ups
+JOIN problems p ON ups.problem_id = p.id
+WHERE ups.user_id = sqlc.arg(user_id)
+  AND ups.status != 'abandoned'
+  AND ups.next_review_at IS NOT NULL
+  AND ups.next_review_at < sqlc.arg(due_before)
+ORDER BY ups.next_review_at ASC
+LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
+
+-- name: CountDueProblems :one
+SELECT COUNT(*) as count
+FROM user_problem_stats
+WHERE user_id = sqlc.arg(user_id)
+  AND status != 'abandoned'
+  AND next_review_at IS NOT NULL
+  AND next_review_at < sqlc.arg(due_before);
+
+-- name: GetReviewForecast :many
+SELECT timezone(sqlc.arg(tz)::text, ups.next_review_at)::date AS due_date,
+       COALESCE(p.difficulty, 'medium')::text AS difficulty,
+       COUNT(*) AS count
+FROM user_problem_stats ups
+JOIN problems p ON ups.problem_id = p.id
+WHERE ups.user_id = sqlc.arg(user_id)
+  AND ups.status != 'abandoned'
+  AND ups.next_review_at IS NOT NULL
+  AND ups.next_review_at < sqlc.arg(due_before)
+GROUP BY due_date, p.difficulty
+ORDER BY due_date;
+
+-- name: GetLeechProblems :many
+SELECT ups.problem_id, ups.consecutive_failures, ups.confidence, ups.last_attempt_at,
+       ups.next_review_at, ups.total_attempts,
+       p.title, p.source, p.url, p.difficulty
+FROM user_problem_stats ups
+JOIN problems p ON ups.problem_id = p.id
+WHERE ups.user_id = sqlc.arg(user_id)
+  AND ups.status != 'abandoned'
+  AND ups.consecutive_failures >= sqlc.arg(threshold)
+ORDER BY ups.consecutive_failures DESC, ups.last_attempt_at DESC;
+
+-- name: SetUserProblemNotes :one
+INSERT INTO user_problem_stats (user_id, problem_id, notes)
+VALUES ($1, $2, $3)
+ON CONFLICT(user_id, problem_id) DO UPDATE SET
+    notes = excluded.notes
+RETURNING *;
+
+-- name: SnoozeUserProblem :one
+-- Pushes the due date to at least snoozed_until. Snoozing again while snoozed
+-- keeps the original due date so a cancel still restores it.
+INSERT INTO user_problem_stats (user_id, problem_id, snoozed_until, next_review_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(problem_id), sqlc.arg(snoozed_until), sqlc.arg(snoozed_until))
+ON CONFLICT(user_id, problem_id) DO UPDATE SET
+    snoozed_from_review_at = CASE
+        WHEN user_problem_stats.snoozed_until > NOW() THEN user_problem_stats.snoozed_from_review_at
+        ELSE user_problem_stats.next_review_at
+    END,
+    snoozed_until = excluded.snoozed_until,
+    next_review_at = GREATEST(user_problem_stats.next_review_at, excluded.snoozed_until),
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CancelUserProblemSnooze :one
+UPDATE user_problem_stats
+SET next_review_at = snoozed_from_review_at,
+    snoozed_until = NULL,
+    snoozed_from_review_at = NULL,
+    updated_at = NOW()
+WHERE user_id = $1
+  AND problem_id = $2
+  AND snoozed_until > NOW()
+RETURNING *;
+
+-- name: SetUserProblemStatus :one
+INSERT INTO user_problem_stats (user_id, problem_id, status)
+VALUES ($1, $2, $3)
+ON CONFLICT(user_id, problem_id) DO UPDATE SET
+    status = excluded.status,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetAnkiDeckProblems :many
+-- Problems for an Anki export with the user's notes and latest attempt.
+-- A NULL due_before exports every tracked problem.
+SELECT p.id, p.title, p.url, p.difficulty, ups.notes, ups.next_review_at,
+       la.performed_at AS last_performed_at, la.outcome AS last_outcome,
+       la.confidence_score AS last_confidence, la.duration_seconds AS last_duration_seconds,
+       la.notes AS last_attempt_notes
+FROM user_problem_stats ups
+JOIN problems p ON ups.problem_id = p.id
+LEFT JOIN LATERAL (
+    SELECT a.performed_at, a.outcome, a.confidence_score, a.duration_seconds, a.notes
+    FROM attempts a
+    WHERE a.user_id = ups.user_id AND a.problem_id = ups.problem_id
+    ORDER BY a.performed_at DESC
+    LIMIT 1
+) la ON TRUE
+WHERE ups.user_id = sqlc.arg(user_id)
+  AND ups.status != 'abandoned'
+  AND (sqlc.narg(due_before)::timestamptz IS NULL
+       OR (ups.next_review_at IS NOT NULL AND ups.next_review_at < sqlc.narg(due_before)::timestamptz))
+ORDER BY ups.next_review_at ASC NULLS LAST, p.title, p.id
+LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
+
+-- name: CountAnkiDeckProblems :one
+SELECT COUNT(*) as count
+FROM user_problem_stats
+WHERE user_id = sqlc.arg(user_id)
+  AND status != 'abandoned'
+  AND (sqlc.narg(due_before)::timestamptz IS NULL
+       OR (next_review_at IS NOT NULL AND next_review_at < sqlc.narg(due_before)::timestamptz));
@@ -0,0 +1,97 @@
+-- Per-table deletes for a user data purge; each returns the rows removed so
+-- the caller can report a manifest. The user row is deleted last and would
+-- cascade to anything missed here. Rows other users depend on are detached
+-- from the user instead of deleted.
+
+-- name: PurgeUserAttempts :execrows
+DELETE FROM attempts WHERE user_id = $1;
+
+-- name: PurgeUserSessions :execrows
+DELETE FROM revision_sessions WHERE user_id = $1;
+
+-- name: PurgeUserProblemStats :execrows
+DELETE FROM user_problem_stats WHERE user_id = $1;
+
+-- name: PurgeUserPatternStats :execrows
+DELETE FROM user_pattern_stats WHERE user_id = $1;
+
+-- name: PurgeUserPatternStatsHistory :execrows
+DELETE FROM user_pattern_stats_history WHERE user_id = $1;
+
+-- name: PurgeUserPatternMilestones :execrows
+DELETE FROM user_pattern_milestones WHERE user_id = $1;
+
+-- name: PurgeUserProblemScores :execrows
+DELETE FROM problem_scores WHERE user_id = $1;
+
+-- name: PurgeUserProblemStars :execrows
+DELETE FROM user_problem_stars WHERE user_id = $1;
+
+-- name: PurgeUserSessionTemplates :execrows
+DELETE FROM user_session_templates WHERE user_id = $1;
+
+-- name: PurgeUserRefreshTokens :execrows
+DELETE FROM refresh_tokens WHERE user_id = $1;
+
+-- name: PurgeUserPasswordResetTokens :execrows
+DELETE FROM password_reset_tokens WHERE user_id = $1;
+
+-- name: PurgeUserInviteCodeUses :execrows
+DELETE FROM invite_code_uses WHERE user_id = $1;
+
+-- name: PurgeUserInviteCodes :execrows
+-- An admin's invite codes that nobody registered with; used ones are kept
+-- for the other users' registration records and detached below
+DELETE FROM admin_invite_codes aic
+WHERE aic.created_by_admin_id = sqlc.arg(user_id)::uuid
+  AND NOT EXISTS (SELECT 1 FROM invite_code_uses icu WHERE icu.code_id = aic.id);
+
+-- name: DetachUserInviteCodes :execrows
+UPDATE admin_invite_codes
+SET created_by_admin_id = NULL
+WHERE created_by_admin_id = sqlc.arg(user_id)::uuid;
+
+-- name: DetachUserIssuedResetTokens :execrows
+-- Reset tokens an admin issued for other users stay valid, without an issuer
+UPDATE password_reset_tokens
+SET created_by_admin_id = NULL
+WHERE created_by_admin_id = sqlc.arg(user_id)::uuid;
+
+-- name: DetachUserImportJobs :execrows
+-- Import jobs record library-wide imports and stay in the history
+UPDATE import_jobs
+SET user_id = NULL
+WHERE user_id = sqlc.arg(user_id)::uuid;
+
+-- name: PurgeUserAPIKeys :execrows
+DELETE FROM api_keys WHERE user_id = $1;
+
+-- name: PurgeUserGoals :execrows
+DELETE FROM user_goals WHERE user_id = $1;
+
+-- name: PurgeUserPreferences :execrows
+DELETE FROM user_preferences WHERE user_id = $1;
+
+-- name: PurgeUserWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE webhook_id IN (SELECT id FROM webhooks WHERE user_id = $1);
+
+-- name: PurgeUserWebhooks :execrows
+DELETE FROM webhooks WHERE user_id = $1;
+
+-- name: PurgeUserCalendarFeedTokens :execrows
+DELETE FROM calendar_feed_tokens WHERE user_id = $1;
+
+-- name: PurgeUserVacationReviewShifts :execrows
+DELETE FROM vacation_review_shifts
+WHERE vacation_id IN (SELECT id FROM user_vacations WHERE user_id = $1);
+
+-- name: PurgeUserVacations :execrows
+DELETE FROM user_vacations WHERE user_id = $1;
+
+-- name: PurgeUserDataVersion :execrows
+-- Not a foreign key, so nothing would cascade to it
+DELETE FROM data_versions WHERE scope = sqlc.arg(user_id)::uuid::text;
+
+-- name: PurgeUserRow :execrows
+DELETE FROM users WHERE id = $1;
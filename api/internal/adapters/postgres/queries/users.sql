@@ -11,7 +11,7 @@ WHERE email = $1 LIMIT 1;
 
 -- name: GetUserByID :one
 -- Used for Session/Context: Fetch user details without the sensitive hash
-SELECT id, email, name, role, is_active, created_at
+SELECT id, email, name, role, is_active, created_at, last_login_at
 FROM users
 WHERE id = $1 LIMIT 1;
 
@@ -21,12 +21,30 @@ SELECT id, email, password_hash, name, role, is_active, created_at
 FROM users
 WHERE id = $1 LIMIT 1;
 
--- name: GetAllUsers :many
--- Admin: List all users (supports pagination)
-SELECT id, email, name, role, is_active, created_at
+-- name: SearchUsers :many
+-- Admin: List users filtered by email/name, role, active flag and inactivity (supports pagination).
+-- Users who never logged in count as inactive since they were created.
+SELECT id, email, name, role, is_active, created_at, last_login_at
 FROM users
-ORDER BY created_at DESC
-LIMIT $1 OFFSET $2;
+WHERE (sqlc.arg(search_query)::text = '' OR email ILIKE '%' || sqlc.arg(search_query)::text || '%' OR name ILIKE '%' || sqlc.arg(search_query)::text || '%')
+  AND (sqlc.arg(role)::text = '' OR role = sqlc.arg(role)::text)
+  AND (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active)::boolean)
+  AND (sqlc.narg(inactive_since)::timestamptz IS NULL OR COALESCE(last_login_at, created_at) < sqlc.narg(inactive_since)::timestamptz)
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by)::text = 'email_asc' THEN email END ASC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'email_desc' THEN email END DESC,
+  CASE WHEN sqlc.arg(sort_by)::text = 'created_at_asc' THEN created_at END ASC,
+  created_at DESC,
+  id
+LIMIT sqlc.arg(limit_val) OFFSET sqlc.arg(offset_val);
+
+-- name: CountSearchUsers :one
+-- Admin: Total for SearchUsers with the same filters
+SELECT COUNT(*) FROM users
+WHERE (sqlc.arg(search_query)::text = '' OR email ILIKE '%' || sqlc.arg(search_query)::text || '%' OR name ILIKE '%' || sqlc.arg(search_query)::text || '%')
+  AND (sqlc.arg(role)::text = '' OR role = sqlc.arg(role)::text)
+  AND (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active)::boolean)
+  AND (sqlc.narg(inactive_since)::timestamptz IS NULL OR COALESCE(last_login_at, created_at) < sqlc.narg(inactive_since)::timestamptz);
 
 -- name: CountAllUsers :one
 -- Used for pagination and checking if admin exists
@@ -41,6 +59,12 @@ UPDATE users
 SET password_hash = $1
 WHERE id = $2;
 
+-- name: UpdateUserProfile :one
+UPDATE users
+SET name = $1, email = $2
+WHERE id = $3
+RETURNING id, email, name, role, is_active, created_at;
+
 -- name: UpdateUserEmail :exec
 UPDATE users
 SET email = $1
@@ -52,12 +76,27 @@ UPDATE users
 SET role = $1
 WHERE id = $2;
 
--- name: UpdateUserActiveStatus :exec
+-- name: UpdateUserActiveStatus :execrows
 -- Admin: Soft activate/deactivate users
 UPDATE users
 SET is_active = $1
 WHERE id = $2;
 
+-- name: UpdateUserLastLogin :exec
+UPDATE users
+SET last_login_at = NOW()
+WHERE id = $1;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
+
+-- name: GetUserActivityStats :one
+-- Admin: Activity aggregates for the user detail view
+SELECT
+    (SELECT COUNT(*) FROM user_problem_stats ups WHERE ups.user_id = $1) AS problem_count,
+    (SELECT COUNT(*) FROM attempts a WHERE a.user_id = $1) AS attempt_count,
+    (SELECT MAX(a.performed_at) FROM attempts a WHERE a.user_id = $1) AS last_attempt_at,
+    (SELECT COUNT(*) FROM revision_sessions rs WHERE rs.user_id = $1) AS session_count,
+    (SELECT COUNT(*) FROM refresh_tokens rt
+     WHERE rt.user_id = $1 AND rt.revoked_at IS NULL AND rt.expires_at > NOW()) AS active_token_count;
@@ -0,0 +1,105 @@
+-- name: LockUserVacations :exec
+-- Serializes vacation changes for one user, so two overlapping requests can't
+-- both pass the overlap check
+SELECT pg_advisory_xact_lock(hashtextextended('user_vacations:' || sqlc.arg(user_id)::uuid::text, 0));
+
+-- name: CountOverlappingVacations :one
+SELECT COUNT(*) FROM user_vacations
+WHERE user_id = sqlc.arg(user_id)
+  AND cancelled_at IS NULL
+  AND start_date <= sqlc.arg(end_date)
+  AND end_date >= sqlc.arg(start_date);
+
+-- name: CreateVacation :one
+INSERT INTO user_vacations (user_id, start_date, end_date, starts_at, shift_days)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListUserVacations :many
+SELECT * FROM user_vacations
+WHERE user_id = $1
+ORDER BY start_date DESC;
+
+-- name: GetUserVacation :one
+SELECT * FROM user_vacations
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: ListVacationDaysBetween :many
+-- Streaks: vacations that overlap [from_date, to_date]
+SELECT start_date, end_date FROM user_vacations
+WHERE user_id = sqlc.arg(user_id)
+  AND cancelled_at IS NULL
+  AND start_date <= sqlc.arg(to_date)
+  AND end_date >= sqlc.arg(from_date);
+
+-- name: GetActiveVacation :one
+SELECT * FROM user_vacations
+WHERE user_id = sqlc.arg(user_id)
+  AND cancelled_at IS NULL
+  AND start_date <= sqlc.arg(on_date)
+  AND end_date >= sqlc.arg(on_date)
+LIMIT 1;
+
+-- name: ListPendingVacationShifts :many
+-- Vacations that have started but whose schedule shift hasn't run yet
+SELECT id, user_id FROM user_vacations
+WHERE shifted_at IS NULL
+  AND cancelled_at IS NULL
+  AND starts_at <= NOW()
+ORDER BY starts_at
+LIMIT sqlc.arg(batch_size);
+
+-- name: ClaimVacationShift :one
+-- Marks the shift as applied; no row means another caller already applied it
+UPDATE user_vacations
+SET shifted_at = NOW()
+WHERE id = $1
+  AND shifted_at IS NULL
+  AND cancelled_at IS NULL
+RETURNING *;
+
+-- name: ShiftUserReviews :execrows
+-- Moves every due date of the user by the vacation length and records each move.
+-- A snoozed problem's pre-snooze due date moves too, so cancelling the snooze
+-- later doesn't restore a date from before the vacation.
+WITH shifted AS (
+    UPDATE user_problem_stats ups
+    SET next_review_at = ups.next_review_at + make_interval(days => v.shift_days),
+        snoozed_from_review_at = ups.snoozed_from_review_at + make_interval(days => v.shift_days),
+        updated_at = NOW()
+    FROM user_vacations v
+    WHERE v.id = sqlc.arg(vacation_id)
+      AND ups.user_id = v.user_id
+      AND ups.next_review_at IS NOT NULL
+    RETURNING ups.problem_id, ups.next_review_at - make_interval(days => v.shift_days) AS previous_review_at, ups.next_review_at
+)
+INSERT INTO vacation_review_shifts (vacation_id, problem_id, previous_review_at, shifted_review_at)
+SELECT sqlc.arg(vacation_id), problem_id, previous_review_at, next_review_at
+FROM shifted;
+
+-- name: SetVacationShiftedCount :exec
+UPDATE user_vacations
+SET shifted_count = $1
+WHERE id = $2;
+
+-- name: UndoVacationShift :execrows
+-- Moves due dates back, skipping problems reviewed since the shift
+UPDATE user_problem_stats ups
+SET next_review_at = s.previous_review_at,
+    snoozed_from_review_at = ups.snoozed_from_review_at - make_interval(days => v.shift_days),
+    updated_at = NOW()
+FROM vacation_review_shifts s
+JOIN user_vacations v ON v.id = s.vacation_id
+WHERE s.vacation_id = sqlc.arg(vacation_id)
+  AND ups.user_id = v.user_id
+  AND ups.problem_id = s.problem_id
+  AND ups.next_review_at = s.shifted_review_at;
+
+-- name: CancelVacation :one
+UPDATE user_vacations
+SET cancelled_at = NOW()
+WHERE id = $1
+  AND user_id = $2
+  AND cancelled_at IS NULL
+RETURNING *;
@@ -0,0 +1,78 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, event_types)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListUserWebhooks :many
+SELECT * FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetUserWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: CountUserWebhooks :one
+SELECT COUNT(*) FROM webhooks
+WHERE user_id = $1;
+
+-- name: DeleteUserWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: EnqueueWebhookEvent :execrows
+-- One pending delivery for each of the user's active webhooks subscribed to the event
+INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+SELECT w.id, sqlc.arg(event_type)::text, sqlc.arg(payload)::jsonb
+FROM webhooks w
+WHERE w.user_id = sqlc.arg(user_id)
+  AND w.is_active
+  AND sqlc.arg(event_type)::text = ANY(w.event_types);
+
+-- name: EnqueueWebhookDelivery :one
+-- Test fires: queue an event for one webhook regardless of its subscriptions
+INSERT INTO webhook_deliveries (webhook_id, event_type, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases due deliveries by pushing next_attempt_at out, so a delivery that
+-- crashes mid-send is retried later and concurrent workers skip claimed rows
+UPDATE webhook_deliveries d
+SET next_attempt_at = NOW() + sqlc.arg(lease)::interval
+FROM webhooks w
+WHERE w.id = d.webhook_id
+  AND d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending'
+      AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING d.id, d.webhook_id, d.event_type, d.payload, d.attempt_count, d.created_at, w.url, w.secret;
+
+-- name: RecordWebhookDeliveryAttempt :exec
+UPDATE webhook_deliveries
+SET status = sqlc.arg(status),
+    attempt_count = attempt_count + 1,
+    last_status_code = sqlc.narg(last_status_code),
+    last_error = sqlc.narg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    delivered_at = CASE WHEN sqlc.arg(status) = 'succeeded' THEN NOW() END
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event_type, status, attempt_count, next_attempt_at,
+       last_status_code, last_error, created_at, delivered_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: DeleteOldWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE status <> 'pending'
+  AND created_at < sqlc.arg(before);
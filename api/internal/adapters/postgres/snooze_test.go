@@ -0,0 +1,102 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+func TestSnoozeAndCancel(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "snooze@example.com")
+	problem := db.CreateProblem(t, "Two Sum", "easy")
+
+	// Postgres keeps microseconds
+	now := time.Now().Truncate(time.Microsecond)
+	dueAt := now.AddDate(0, 0, -3)
+	if _, err := db.Queries.UpsertUserProblemStats(ctx, repo.UpsertUserProblemStatsParams{
+		UserID:       user.ID,
+		ProblemID:    problem.ID,
+		Status:       pgtype.Text{String: "solved", Valid: true},
+		NextReviewAt: testutil.Timestamp(dueAt),
+		IntervalDays: pgtype.Int4{Int32: 6, Valid: true},
+		EaseFactor:   pgtype.Float4{Float32: 2.5, Valid: true},
+		ReviewCount:  pgtype.Int4{Int32: 2, Valid: true},
+	}); err != nil {
+		t.Fatalf("UpsertUserProblemStats: %v", err)
+	}
+
+	isDue := func() bool {
+		t.Helper()
+		rows, err := db.Queries.GetDueProblems(ctx, repo.GetDueProblemsParams{
+			UserID:    user.ID,
+			DueBefore: testutil.Timestamp(time.Now()),
+			LimitVal:  10,
+		})
+		if err != nil {
+			t.Fatalf("GetDueProblems: %v", err)
+		}
+		return len(rows) == 1
+	}
+	snooze := func(until time.Time) repo.UserProblemStat {
+		t.Helper()
+		stats, err := db.Queries.SnoozeUserProblem(ctx, repo.SnoozeUserProblemParams{
+			UserID:       user.ID,
+			ProblemID:    problem.ID,
+			SnoozedUntil: testutil.Timestamp(until),
+		})
+		if err != nil {
+			t.Fatalf("SnoozeUserProblem: %v", err)
+		}
+		return stats
+	}
+	cancel := func() (repo.UserProblemStat, error) {
+		return db.Queries.CancelUserProblemSnooze(ctx, repo.CancelUserProblemSnoozeParams{
+			UserID:    user.ID,
+			ProblemID: problem.ID,
+		})
+	}
+
+	if !isDue() {
+		t.Fatal("overdue problem missing from the due list")
+	}
+
+	week := now.AddDate(0, 0, 7)
+	stats := snooze(week)
+	if !stats.NextReviewAt.Time.Equal(week) || !stats.SnoozedFromReviewAt.Time.Equal(dueAt) {
+		t.Errorf("after snoozing: next review %v, snoozed from %v; want %v and %v",
+			stats.NextReviewAt.Time, stats.SnoozedFromReviewAt.Time, week, dueAt)
+	}
+	if isDue() {
+		t.Error("snoozed problem is in the due list")
+	}
+
+	// Snoozing again keeps the original due date for the cancel
+	stats = snooze(now.AddDate(0, 0, 14))
+	if !stats.SnoozedFromReviewAt.Time.Equal(dueAt) {
+		t.Errorf("after snoozing again: snoozed from %v, want %v", stats.SnoozedFromReviewAt.Time, dueAt)
+	}
+
+	stats, err := cancel()
+	if err != nil {
+		t.Fatalf("CancelUserProblemSnooze: %v", err)
+	}
+	if !stats.NextReviewAt.Time.Equal(dueAt) || stats.SnoozedUntil.Valid || stats.SnoozedFromReviewAt.Valid {
+		t.Errorf("after cancelling: next review %v, snoozed until %v, snoozed from %v; want %v and no snooze",
+			stats.NextReviewAt.Time, stats.SnoozedUntil, stats.SnoozedFromReviewAt, dueAt)
+	}
+	if !isDue() {
+		t.Error("problem missing from the due list after cancelling its snooze")
+	}
+
+	if _, err := cancel(); !errors.Is(err, pgx.ErrNoRows) {
+		t.Errorf("cancelling without a snooze: err = %v, want no rows", err)
+	}
+}
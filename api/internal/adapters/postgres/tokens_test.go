@@ -0,0 +1,93 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// Both cleanup queries delete strictly before the cutoff: a token exactly at
+// it is kept
+func TestTokenCleanupBoundaries(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "tokens@example.com")
+
+	// Postgres keeps microseconds, so the cutoff must too for "exactly at" to hold
+	cutoff := time.Now().Truncate(time.Microsecond)
+	offsets := map[string]time.Duration{
+		"well before": -time.Hour,
+		"just before": -time.Microsecond,
+		"at":          0,
+		"just after":  time.Microsecond,
+		"well after":  time.Hour,
+	}
+	kept := map[string]bool{"at": true, "just after": true, "well after": true}
+
+	t.Run("refresh tokens by expiry", func(t *testing.T) {
+		ids := map[string]uuid.UUID{}
+		for name, offset := range offsets {
+			token, err := db.Queries.CreateRefreshToken(ctx, repo.CreateRefreshTokenParams{
+				UserID:    user.ID,
+				TokenHash: "refresh " + name,
+				ExpiresAt: cutoff.Add(offset),
+				FamilyID:  uuid.New(),
+			})
+			if err != nil {
+				t.Fatalf("CreateRefreshToken: %v", err)
+			}
+			ids[name] = token.ID
+		}
+
+		deleted, err := db.Queries.DeleteExpiredRefreshTokens(ctx, cutoff)
+		if err != nil {
+			t.Fatalf("DeleteExpiredRefreshTokens: %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("deleted %d refresh tokens, want 2", deleted)
+		}
+		for name, id := range ids {
+			if got := db.Count(t, "refresh_tokens", "id = $1", id) == 1; got != kept[name] {
+				t.Errorf("token expiring %s the cutoff kept = %v, want %v", name, got, kept[name])
+			}
+		}
+	})
+
+	t.Run("password reset tokens by creation", func(t *testing.T) {
+		ids := map[string]uuid.UUID{}
+		for name, offset := range offsets {
+			token, err := db.Queries.CreatePasswordResetToken(ctx, repo.CreatePasswordResetTokenParams{
+				UserID:    user.ID,
+				TokenHash: fmt.Sprintf("reset %s", name),
+				ExpiresAt: cutoff.Add(offset + time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("CreatePasswordResetToken: %v", err)
+			}
+			// created_at defaults to NOW(); backdate it to the case's offset
+			if _, err := db.Pool.Exec(ctx, "UPDATE password_reset_tokens SET created_at = $1 WHERE id = $2", cutoff.Add(offset), token.ID); err != nil {
+				t.Fatalf("backdate reset token: %v", err)
+			}
+			ids[name] = token.ID
+		}
+
+		deleted, err := db.Queries.DeletePasswordResetTokensCreatedBefore(ctx, pgtype.Timestamptz{Time: cutoff, Valid: true})
+		if err != nil {
+			t.Fatalf("DeletePasswordResetTokensCreatedBefore: %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("deleted %d reset tokens, want 2", deleted)
+		}
+		for name, id := range ids {
+			if got := db.Count(t, "password_reset_tokens", "id = $1", id) == 1; got != kept[name] {
+				t.Errorf("token created %s the cutoff kept = %v, want %v", name, got, kept[name])
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Transactor runs a group of queries atomically. Services that need
+// multi-statement writes depend on this instead of holding the pool.
+type Transactor interface {
+	// WithTx runs fn inside a transaction, committing if it returns nil and
+	// rolling back otherwise. fn must use the context and Querier it is given;
+	// a WithTx call made with that context joins the same transaction instead
+	// of opening a second one, which could block on the first one's locks.
+	// A joined call runs in a savepoint, so when it fails only its own writes
+	// are rolled back and the caller may carry on.
+	WithTx(ctx context.Context, fn func(ctx context.Context, q repo.Querier) error) error
+}
+
+type txKey struct{}
+
+type poolTransactor struct {
+	pool *pgxpool.Pool
+}
+
+func NewTransactor(pool *pgxpool.Pool) Transactor {
+	return &poolTransactor{pool: pool}
+}
+
+func (t *poolTransactor) WithTx(ctx context.Context, fn func(ctx context.Context, q repo.Querier) error) error {
+	var (
+		tx  pgx.Tx
+		err error
+	)
+	if outer, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		tx, err = outer.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create savepoint: %w", err)
+		}
+	} else {
+		tx, err = t.pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx), repo.New(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
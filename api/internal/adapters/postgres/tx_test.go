@@ -0,0 +1,104 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+var errAbort = errors.New("abort")
+
+func createPattern(ctx context.Context, q repo.Querier, title string) error {
+	_, err := q.CreatePattern(ctx, repo.CreatePatternParams{Title: title})
+	return err
+}
+
+func patternTitles(t *testing.T, q repo.Querier) map[string]bool {
+	t.Helper()
+	patterns, err := q.ListPatterns(context.Background())
+	if err != nil {
+		t.Fatalf("ListPatterns: %v", err)
+	}
+	titles := make(map[string]bool, len(patterns))
+	for _, p := range patterns {
+		titles[p.Title] = true
+	}
+	return titles
+}
+
+func TestWithTx(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, db *testutil.DB, q repo.Querier) error
+		want map[string]bool
+	}{
+		{
+			name: "commits when fn succeeds",
+			fn: func(ctx context.Context, db *testutil.DB, q repo.Querier) error {
+				return createPattern(ctx, q, "outer")
+			},
+			want: map[string]bool{"outer": true},
+		},
+		{
+			name: "rolls back when fn fails",
+			fn: func(ctx context.Context, db *testutil.DB, q repo.Querier) error {
+				if err := createPattern(ctx, q, "outer"); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			want: map[string]bool{"outer": false},
+		},
+		{
+			name: "keeps the outer writes when a nested call fails",
+			fn: func(ctx context.Context, db *testutil.DB, q repo.Querier) error {
+				if err := createPattern(ctx, q, "outer"); err != nil {
+					return err
+				}
+				err := db.Transactor.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+					if err := createPattern(ctx, q, "inner"); err != nil {
+						return err
+					}
+					return errAbort
+				})
+				if !errors.Is(err, errAbort) {
+					return err
+				}
+				return nil
+			},
+			want: map[string]bool{"outer": true, "inner": false},
+		},
+		{
+			name: "rolls back a committed nested call with the outer one",
+			fn: func(ctx context.Context, db *testutil.DB, q repo.Querier) error {
+				if err := db.Transactor.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+					return createPattern(ctx, q, "inner")
+				}); err != nil {
+					return err
+				}
+				return errAbort
+			},
+			want: map[string]bool{"inner": false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.NewDB(t)
+			ctx := context.Background()
+
+			_ = db.Transactor.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+				return tt.fn(ctx, db, q)
+			})
+
+			got := patternTitles(t, db.Queries)
+			for title, want := range tt.want {
+				if got[title] != want {
+					t.Errorf("pattern %q present = %v, want %v", title, got[title], want)
+				}
+			}
+		})
+	}
+}
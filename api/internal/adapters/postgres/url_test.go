@@ -0,0 +1,64 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// The normalized URL queries must agree with utils.NormalizeProblemURL
+func TestFindProblemByNormalizedURL(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+
+	stored := []string{
+		"http://www.LeetCode.com/problems/two-sum/?envType=study-plan#description",
+		"https://leetcode.com/problems/three-sum",
+	}
+	ids := make(map[string]string, len(stored))
+	for _, url := range stored {
+		problem, err := db.Queries.CreateProblem(ctx, repo.CreateProblemParams{
+			Title:      url,
+			Url:        pgtype.Text{String: url, Valid: true},
+			Difficulty: pgtype.Text{String: "easy", Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("CreateProblem: %v", err)
+		}
+		ids[url] = problem.ID.String()
+	}
+
+	tests := []struct {
+		lookup string
+		want   string
+	}{
+		{"https://leetcode.com/problems/two-sum", stored[0]},
+		{"leetcode.com/problems/two-sum/", stored[0]},
+		{"http://www.leetcode.com/problems/three-sum/?x=1", stored[1]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lookup, func(t *testing.T) {
+			normalized := utils.NormalizeProblemURL(tt.lookup)
+
+			found, err := db.Queries.FindProblemByNormalizedURL(ctx, normalized)
+			if err != nil {
+				t.Fatalf("FindProblemByNormalizedURL(%q): %v", normalized, err)
+			}
+			if found.ID.String() != ids[tt.want] {
+				t.Errorf("found %s, want %s", found.Title, tt.want)
+			}
+
+			rows, err := db.Queries.FindProblemsByNormalizedURLs(ctx, []string{normalized})
+			if err != nil {
+				t.Fatalf("FindProblemsByNormalizedURLs: %v", err)
+			}
+			if len(rows) != 1 || rows[0].NormalizedUrl != normalized {
+				t.Errorf("FindProblemsByNormalizedURLs = %+v, want one row normalized to %q", rows, normalized)
+			}
+		})
+	}
+}
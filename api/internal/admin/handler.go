@@ -1,13 +1,17 @@
 package admin
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -33,9 +37,44 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		limit = 20
 	}
 
-	users, err := h.service.ListUsers(r.Context(), page, limit)
+	filter := UserFilter{
+		Query:  strings.TrimSpace(r.URL.Query().Get("q")),
+		Role:   r.URL.Query().Get("role"),
+		SortBy: r.URL.Query().Get("sort_by"),
+	}
+	if filter.Role != "" && filter.Role != "user" && filter.Role != "admin" {
+		utils.BadRequest(w, "Invalid role, must be one of: user, admin", nil)
+		return
+	}
+	if activeStr := r.URL.Query().Get("is_active"); activeStr != "" {
+		isActive, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid is_active, must be true or false", nil)
+			return
+		}
+		filter.IsActive = &isActive
+	}
+	if sinceStr := r.URL.Query().Get("inactive_since"); sinceStr != "" {
+		since, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			since, err = time.Parse(time.RFC3339, sinceStr)
+		}
+		if err != nil {
+			utils.BadRequest(w, "Invalid inactive_since, expected YYYY-MM-DD or RFC3339", nil)
+			return
+		}
+		filter.InactiveSince = &since
+	}
+	switch filter.SortBy {
+	case "", "created_at_desc", "created_at_asc", "email_asc", "email_desc":
+	default:
+		utils.BadRequest(w, "Invalid sort_by, must be one of: created_at_desc, created_at_asc, email_asc, email_desc", nil)
+		return
+	}
+
+	users, err := h.service.ListUsers(r.Context(), page, limit, filter)
 	if err != nil {
-		slog.Error("Failed to list users", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list users", "error", err)
 		utils.InternalServerError(w, "Failed to list users")
 		return
 	}
@@ -43,6 +82,29 @@ func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, users)
 }
 
+// GetUser - GET /api/v1/admin/users/:id
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userIDStr := chi.URLParam(r, "id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid user ID format", nil)
+		return
+	}
+
+	detail, err := h.service.GetUserDetail(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get user", "error", err)
+		utils.InternalServerError(w, "Failed to get user")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, detail)
+}
+
 // UpdateUserRole - POST /api/v1/admin/users/:id/role
 func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "id")
@@ -52,24 +114,28 @@ func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	var req UpdateRoleRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	if err := h.service.UpdateUserRole(r.Context(), adminID, targetUserID, req.Role); err != nil {
-		if err == ErrSelfRoleChange {
+		if errors.Is(err, ErrSelfRoleChange) {
 			utils.BadRequest(w, "Cannot change your own role", nil)
 			return
 		}
-		if err == ErrLastAdmin {
+		if errors.Is(err, ErrLastAdmin) {
 			utils.BadRequest(w, "Cannot demote the last admin", nil)
 			return
 		}
-		slog.Error("Failed to update user role", "error", err)
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update user role", "error", err)
 		utils.InternalServerError(w, "Failed to update user role")
 		return
 	}
@@ -86,14 +152,18 @@ func (h *Handler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	if err := h.service.DeactivateUser(r.Context(), adminID, targetUserID); err != nil {
-		if err == ErrSelfDeactivation {
+		if errors.Is(err, ErrSelfDeactivation) {
 			utils.BadRequest(w, "Cannot deactivate your own account", nil)
 			return
 		}
-		slog.Error("Failed to deactivate user", "error", err)
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to deactivate user", "error", err)
 		utils.InternalServerError(w, "Failed to deactivate user")
 		return
 	}
@@ -110,10 +180,14 @@ func (h *Handler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	if err := h.service.ReactivateUser(r.Context(), adminID, targetUserID); err != nil {
-		slog.Error("Failed to reactivate user", "error", err)
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to reactivate user", "error", err)
 		utils.InternalServerError(w, "Failed to reactivate user")
 		return
 	}
@@ -130,18 +204,22 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	if err := h.service.DeleteUser(r.Context(), adminID, targetUserID); err != nil {
-		if err == ErrSelfDeactivation {
+		if errors.Is(err, ErrSelfDeactivation) {
 			utils.BadRequest(w, "Cannot delete your own account", nil)
 			return
 		}
-		if err == ErrLastAdmin {
+		if errors.Is(err, ErrLastAdmin) {
 			utils.BadRequest(w, "Cannot delete the last admin", nil)
 			return
 		}
-		slog.Error("Failed to delete user", "error", err)
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete user", "error", err)
 		utils.InternalServerError(w, "Failed to delete user")
 		return
 	}
@@ -149,6 +227,47 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
+// PurgeUser - POST /api/v1/admin/users/:id/purge
+func (h *Handler) PurgeUser(w http.ResponseWriter, r *http.Request) {
+	userIDStr := chi.URLParam(r, "id")
+	targetUserID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid user ID format", nil)
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	if adminID == targetUserID {
+		utils.BadRequest(w, "Use account deletion to purge your own data", nil)
+		return
+	}
+
+	var req PurgeUserRequest
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	response, err := h.service.PurgeUser(r.Context(), adminID, targetUserID, req.ConfirmEmail)
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			utils.NotFound(w, "User not found")
+		case ErrPurgeConfirmation:
+			utils.BadRequest(w, "confirm_email does not match the user's email", nil)
+		case ErrLastAdmin:
+			utils.BadRequest(w, "Cannot delete the last admin", nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to purge user", "error", err)
+			utils.InternalServerError(w, "Failed to purge user")
+		}
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("User purged", "admin_id", adminID, "user_id", targetUserID)
+	utils.WriteSuccess(w, http.StatusOK, response)
+}
+
 // InitiatePasswordReset - POST /api/v1/admin/users/:id/reset-password
 func (h *Handler) InitiatePasswordReset(w http.ResponseWriter, r *http.Request) {
 	userIDStr := chi.URLParam(r, "id")
@@ -158,11 +277,15 @@ func (h *Handler) InitiatePasswordReset(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	response, err := h.service.InitiatePasswordReset(r.Context(), adminID, targetUserID)
 	if err != nil {
-		slog.Error("Failed to initiate password reset", "error", err)
+		if errors.Is(err, ErrUserNotFound) {
+			utils.NotFound(w, "User not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to initiate password reset", "error", err)
 		utils.InternalServerError(w, "Failed to initiate password reset")
 		return
 	}
@@ -172,17 +295,21 @@ func (h *Handler) InitiatePasswordReset(w http.ResponseWriter, r *http.Request)
 
 // CreateInviteCode - POST /api/v1/admin/invites
 func (h *Handler) CreateInviteCode(w http.ResponseWriter, r *http.Request) {
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	var req CreateInviteCodeRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
-	response, err := h.service.CreateInviteCode(r.Context(), adminID, req.MaxUses, req.ExpiresIn)
+	response, err := h.service.CreateInviteCode(r.Context(), adminID, req.Count, req.MaxUses, req.ExpiresIn)
 	if err != nil {
-		slog.Error("Failed to create invite code", "error", err)
+		if errors.Is(err, ErrInviteCountRange) {
+			utils.BadRequest(w, fmt.Sprintf("count must be between 1 and %d", MaxInviteCodeBatch), nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to create invite code", "error", err)
 		utils.InternalServerError(w, "Failed to create invite code")
 		return
 	}
@@ -194,7 +321,7 @@ func (h *Handler) CreateInviteCode(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) ListInviteCodes(w http.ResponseWriter, r *http.Request) {
 	response, err := h.service.ListInviteCodes(r.Context())
 	if err != nil {
-		slog.Error("Failed to list invite codes", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list invite codes", "error", err)
 		utils.InternalServerError(w, "Failed to list invite codes")
 		return
 	}
@@ -202,6 +329,29 @@ func (h *Handler) ListInviteCodes(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, response)
 }
 
+// ListInviteCodeUses - GET /api/v1/admin/invites/:id/uses
+func (h *Handler) ListInviteCodeUses(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	codeID, err := uuid.Parse(idStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid invite code ID format", nil)
+		return
+	}
+
+	response, err := h.service.ListInviteCodeUses(r.Context(), codeID)
+	if err != nil {
+		if errors.Is(err, ErrInviteCodeNotFound) {
+			utils.NotFound(w, "Invite code not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to list invite code uses", "error", err)
+		utils.InternalServerError(w, "Failed to list invite code uses")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, response)
+}
+
 // DeleteInviteCode - DELETE /api/v1/admin/invites/:id
 func (h *Handler) DeleteInviteCode(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
@@ -212,7 +362,11 @@ func (h *Handler) DeleteInviteCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.DeleteInviteCode(r.Context(), codeID); err != nil {
-		slog.Error("Failed to delete invite code", "error", err)
+		if errors.Is(err, ErrInviteCodeNotFound) {
+			utils.NotFound(w, "Invite code not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete invite code", "error", err)
 		utils.InternalServerError(w, "Failed to delete invite code")
 		return
 	}
@@ -220,11 +374,43 @@ func (h *Handler) DeleteInviteCode(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Invite code deleted successfully"})
 }
 
+// GetInstanceStats - GET /api/v1/admin/stats
+func (h *Handler) GetInstanceStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetInstanceStats(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get instance stats", "error", err)
+		utils.InternalServerError(w, "Failed to get instance stats")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, stats)
+}
+
+// CleanupTokens - POST /api/v1/admin/maintenance/cleanup-tokens
+func (h *Handler) CleanupTokens(w http.ResponseWriter, r *http.Request) {
+	adminID, _ := auth.UserIDFromContext(r.Context())
+
+	result, err := h.service.CleanupTokens(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to clean up tokens", "error", err)
+		utils.InternalServerError(w, "Failed to clean up tokens")
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Token cleanup triggered by admin",
+		"admin_id", adminID,
+		"refresh_tokens", result.RefreshTokensDeleted,
+		"password_reset_tokens", result.PasswordResetTokensDeleted,
+	)
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
 // GetSignupSettings - GET /api/v1/admin/settings/signup
 func (h *Handler) GetSignupSettings(w http.ResponseWriter, r *http.Request) {
 	settings, err := h.service.GetSignupSettings(r.Context())
 	if err != nil {
-		slog.Error("Failed to get signup settings", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get signup settings", "error", err)
 		utils.InternalServerError(w, "Failed to get signup settings")
 		return
 	}
@@ -234,16 +420,16 @@ func (h *Handler) GetSignupSettings(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSignupEnabled - PUT /api/v1/admin/settings/signup/enabled
 func (h *Handler) UpdateSignupEnabled(w http.ResponseWriter, r *http.Request) {
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	var req UpdateSignupEnabledRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	if err := h.service.UpdateSignupEnabled(r.Context(), adminID, req.Enabled); err != nil {
-		slog.Error("Failed to update signup enabled setting", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to update signup enabled setting", "error", err)
 		utils.InternalServerError(w, "Failed to update setting")
 		return
 	}
@@ -253,16 +439,16 @@ func (h *Handler) UpdateSignupEnabled(w http.ResponseWriter, r *http.Request) {
 
 // UpdateInviteCodesEnabled - PUT /api/v1/admin/settings/signup/invites
 func (h *Handler) UpdateInviteCodesEnabled(w http.ResponseWriter, r *http.Request) {
-	adminID := r.Context().Value(auth.UserKey).(uuid.UUID)
+	adminID, _ := auth.UserIDFromContext(r.Context())
 
 	var req UpdateSignupEnabledRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	if err := h.service.UpdateInviteCodesEnabled(r.Context(), adminID, req.Enabled); err != nil {
-		slog.Error("Failed to update invite codes enabled setting", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to update invite codes enabled setting", "error", err)
 		utils.InternalServerError(w, "Failed to update setting")
 		return
 	}
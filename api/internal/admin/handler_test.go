@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// serveID runs one handler method for a request to /{id}, as an admin
+func serveID(h http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	req = req.WithContext(auth.WithUser(ctx, uuid.New(), "admin"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// lookupRepo fails the user lookup with err and reports updated rows for the
+// active status change
+type lookupRepo struct {
+	*testutil.Querier
+	err     error
+	updated int64
+}
+
+func (f *lookupRepo) GetUserByID(ctx context.Context, id uuid.UUID) (repo.GetUserByIDRow, error) {
+	return repo.GetUserByIDRow{}, f.err
+}
+
+func (f *lookupRepo) UpdateUserActiveStatus(ctx context.Context, arg repo.UpdateUserActiveStatusParams) (int64, error) {
+	return f.updated, f.err
+}
+
+// A missing user is a 404; any other repo failure stays a 500
+func TestUserNotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(*Handler) http.HandlerFunc
+		method  string
+		id      string
+		repo    lookupRepo
+		status  int
+		code    string
+	}{
+		{
+			name:    "get missing",
+			handler: func(h *Handler) http.HandlerFunc { return h.GetUser },
+			method:  http.MethodGet,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{err: pgx.ErrNoRows},
+			status:  http.StatusNotFound,
+			code:    utils.ErrCodeNotFound,
+		},
+		{
+			name:    "get database failure",
+			handler: func(h *Handler) http.HandlerFunc { return h.GetUser },
+			method:  http.MethodGet,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{err: errors.New("connection refused")},
+			status:  http.StatusInternalServerError,
+			code:    utils.ErrCodeInternalServer,
+		},
+		{
+			name:    "get malformed id",
+			handler: func(h *Handler) http.HandlerFunc { return h.GetUser },
+			method:  http.MethodGet,
+			id:      "42",
+			status:  http.StatusBadRequest,
+			code:    utils.ErrCodeBadRequest,
+		},
+		{
+			name:    "deactivate missing",
+			handler: func(h *Handler) http.HandlerFunc { return h.DeactivateUser },
+			method:  http.MethodPost,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{updated: 0},
+			status:  http.StatusNotFound,
+			code:    utils.ErrCodeNotFound,
+		},
+		{
+			name:    "deactivate database failure",
+			handler: func(h *Handler) http.HandlerFunc { return h.DeactivateUser },
+			method:  http.MethodPost,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{err: errors.New("connection refused")},
+			status:  http.StatusInternalServerError,
+			code:    utils.ErrCodeInternalServer,
+		},
+		{
+			name:    "reactivate missing",
+			handler: func(h *Handler) http.HandlerFunc { return h.ReactivateUser },
+			method:  http.MethodPost,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{updated: 0},
+			status:  http.StatusNotFound,
+			code:    utils.ErrCodeNotFound,
+		},
+		{
+			name:    "reactivate database failure",
+			handler: func(h *Handler) http.HandlerFunc { return h.ReactivateUser },
+			method:  http.MethodPost,
+			id:      uuid.NewString(),
+			repo:    lookupRepo{err: errors.New("connection refused")},
+			status:  http.StatusInternalServerError,
+			code:    utils.ErrCodeInternalServer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &tt.repo
+			f.Querier = testutil.NewQuerier()
+			rec := serveID(tt.handler(NewHandler(NewService(f, testutil.Transactor{Q: f}))), tt.method, tt.id)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if code := testutil.ErrorResponse(t, rec).Code; code != tt.code {
+				t.Errorf("error code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
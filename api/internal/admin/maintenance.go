@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// RefreshTokenCleanupGrace keeps expired refresh tokens around briefly, so
+	// a client refreshing just after expiry is told its token expired
+	RefreshTokenCleanupGrace = 24 * time.Hour
+	// PasswordResetTokenRetention is how long reset tokens are kept after creation
+	PasswordResetTokenRetention = 7 * 24 * time.Hour
+)
+
+// CleanupTokens deletes refresh tokens past expiry plus the grace window and
+// password reset tokens older than the retention period
+func (s *adminService) CleanupTokens(ctx context.Context) (TokenCleanupResponse, error) {
+	now := time.Now()
+
+	refreshDeleted, err := s.repo.DeleteExpiredRefreshTokens(ctx, now.Add(-RefreshTokenCleanupGrace))
+	if err != nil {
+		return TokenCleanupResponse{}, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	resetDeleted, err := s.repo.DeletePasswordResetTokensCreatedBefore(ctx, pgtype.Timestamptz{
+		Time:  now.Add(-PasswordResetTokenRetention),
+		Valid: true,
+	})
+	if err != nil {
+		return TokenCleanupResponse{}, fmt.Errorf("failed to delete old password reset tokens: %w", err)
+	}
+
+	return TokenCleanupResponse{
+		RefreshTokensDeleted:       refreshDeleted,
+		PasswordResetTokensDeleted: resetDeleted,
+	}, nil
+}
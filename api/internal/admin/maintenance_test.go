@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// cleanupRepo records the cutoffs the cleanup queries run with
+type cleanupRepo struct {
+	*testutil.Querier
+}
+
+func (f *cleanupRepo) DeleteExpiredRefreshTokens(ctx context.Context, expiresAt time.Time) (int64, error) {
+	f.Record("DeleteExpiredRefreshTokens", expiresAt)
+	return 3, nil
+}
+
+func (f *cleanupRepo) DeletePasswordResetTokensCreatedBefore(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	f.Record("DeletePasswordResetTokensCreatedBefore", createdAt.Time)
+	return 2, nil
+}
+
+func TestCleanupTokensCutoffs(t *testing.T) {
+	f := &cleanupRepo{Querier: testutil.NewQuerier()}
+
+	before := time.Now()
+	result, err := NewService(f, testutil.Transactor{Q: f}).CleanupTokens(context.Background())
+	after := time.Now()
+	if err != nil {
+		t.Fatalf("CleanupTokens: %v", err)
+	}
+	if result.RefreshTokensDeleted != 3 || result.PasswordResetTokensDeleted != 2 {
+		t.Errorf("result = %+v, want 3 refresh and 2 reset tokens deleted", result)
+	}
+
+	tests := []struct {
+		method string
+		age    time.Duration
+	}{
+		{"DeleteExpiredRefreshTokens", RefreshTokenCleanupGrace},
+		{"DeletePasswordResetTokensCreatedBefore", PasswordResetTokenRetention},
+	}
+	for _, tt := range tests {
+		calls := f.CallsTo(tt.method)
+		if len(calls) != 1 {
+			t.Fatalf("%s called %d times, want 1", tt.method, len(calls))
+		}
+		cutoff := calls[0].(time.Time)
+		if cutoff.Before(before.Add(-tt.age)) || cutoff.After(after.Add(-tt.age)) {
+			t.Errorf("%s cutoff = %v, want %v before now", tt.method, cutoff, tt.age)
+		}
+	}
+}
+
+// Just inside the grace and retention windows is kept, just outside is deleted
+func TestCleanupTokensAgainstDatabase(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "cleanup@example.com")
+	now := time.Now()
+
+	refresh := map[string]time.Time{
+		"live":               now.Add(time.Hour),
+		"just expired":       now.Add(-time.Minute),
+		"inside the grace":   now.Add(-RefreshTokenCleanupGrace + time.Minute),
+		"outside the grace":  now.Add(-RefreshTokenCleanupGrace - time.Minute),
+		"long since expired": now.Add(-30 * 24 * time.Hour),
+	}
+	for name, expiresAt := range refresh {
+		if _, err := db.Pool.Exec(ctx,
+			"INSERT INTO refresh_tokens (user_id, token_hash, expires_at, family_id) VALUES ($1, $2, $3, gen_random_uuid())",
+			user.ID, name, expiresAt,
+		); err != nil {
+			t.Fatalf("insert refresh token: %v", err)
+		}
+	}
+	reset := map[string]time.Time{
+		"new":                   now,
+		"inside the retention":  now.Add(-PasswordResetTokenRetention + time.Minute),
+		"outside the retention": now.Add(-PasswordResetTokenRetention - time.Minute),
+	}
+	for name, createdAt := range reset {
+		if _, err := db.Pool.Exec(ctx,
+			"INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $3)",
+			user.ID, name, createdAt,
+		); err != nil {
+			t.Fatalf("insert reset token: %v", err)
+		}
+	}
+
+	result, err := NewService(db.Queries, db.Transactor).CleanupTokens(ctx)
+	if err != nil {
+		t.Fatalf("CleanupTokens: %v", err)
+	}
+	if result.RefreshTokensDeleted != 2 || result.PasswordResetTokensDeleted != 1 {
+		t.Errorf("result = %+v, want 2 refresh and 1 reset tokens deleted", result)
+	}
+
+	for name, wantKept := range map[string]bool{
+		"live": true, "just expired": true, "inside the grace": true,
+		"outside the grace": false, "long since expired": false,
+	} {
+		if kept := db.Count(t, "refresh_tokens", "token_hash = $1", name) == 1; kept != wantKept {
+			t.Errorf("refresh token %s kept = %v, want %v", name, kept, wantKept)
+		}
+	}
+	for name, wantKept := range map[string]bool{"new": true, "inside the retention": true, "outside the retention": false} {
+		if kept := db.Count(t, "password_reset_tokens", "token_hash = $1", name) == 1; kept != wantKept {
+			t.Errorf("reset token %s kept = %v, want %v", name, kept, wantKept)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Audit log actions written by PurgeUser
+const (
+	AuditActionUserPurge     = "user.purge"
+	AuditActionUserSelfPurge = "user.self_purge"
+)
+
+// PurgeUser deletes every row belonging to a user, then the user, in one
+// transaction. confirmEmail must match the user's email. When actorID equals
+// the target the purge is self-service; the audit entry records only the user
+// ID and row counts. Rows other users still need are kept but no longer
+// point at the purged user; they are counted under detached.
+func (s *adminService) PurgeUser(ctx context.Context, actorID, targetUserID uuid.UUID, confirmEmail string) (PurgeUserResponse, error) {
+	var deleted, detached map[string]int64
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		target, err := q.GetUserByID(ctx, targetUserID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+
+		if !strings.EqualFold(strings.TrimSpace(confirmEmail), target.Email) {
+			return ErrPurgeConfirmation
+		}
+
+		if target.Role.String == "admin" {
+			adminCount, err := q.CountAdmins(ctx)
+			if err != nil {
+				return err
+			}
+			if adminCount <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		// Rows that other users depend on are detached rather than deleted: the
+		// invite codes others registered with, which would otherwise take their
+		// registration records with them, the reset tokens this user issued as
+		// an admin for others, and the import history of the shared library
+		detach := []struct {
+			table  string
+			detach func(context.Context, uuid.UUID) (int64, error)
+		}{
+			{"password_reset_tokens", q.DetachUserIssuedResetTokens},
+			{"admin_invite_codes", q.DetachUserInviteCodes},
+			{"import_jobs", q.DetachUserImportJobs},
+		}
+
+		// Dependent tables first so the counts reflect what this purge removed
+		// rather than what the final cascade picked up. Every table holding a
+		// user's data must be listed here, children before parents: the user row's
+		// cascade would still delete a missing one, but it wouldn't appear in the
+		// manifest the purge reports.
+		steps := []struct {
+			table string
+			purge func(context.Context, uuid.UUID) (int64, error)
+		}{
+			{"attempts", q.PurgeUserAttempts},
+			{"revision_sessions", q.PurgeUserSessions},
+			{"user_problem_stats", q.PurgeUserProblemStats},
+			{"user_pattern_stats", q.PurgeUserPatternStats},
+			{"user_pattern_stats_history", q.PurgeUserPatternStatsHistory},
+			{"user_pattern_milestones", q.PurgeUserPatternMilestones},
+			{"problem_scores", q.PurgeUserProblemScores},
+			{"user_problem_stars", q.PurgeUserProblemStars},
+			{"user_session_templates", q.PurgeUserSessionTemplates},
+			{"refresh_tokens", q.PurgeUserRefreshTokens},
+			{"password_reset_tokens", q.PurgeUserPasswordResetTokens},
+			{"invite_code_uses", q.PurgeUserInviteCodeUses},
+			{"admin_invite_codes", q.PurgeUserInviteCodes},
+			{"api_keys", q.PurgeUserAPIKeys},
+			{"user_goals", q.PurgeUserGoals},
+			{"user_preferences", q.PurgeUserPreferences},
+			{"webhook_deliveries", q.PurgeUserWebhookDeliveries},
+			{"webhooks", q.PurgeUserWebhooks},
+			{"calendar_feed_tokens", q.PurgeUserCalendarFeedTokens},
+			{"vacation_review_shifts", q.PurgeUserVacationReviewShifts},
+			{"user_vacations", q.PurgeUserVacations},
+			{"data_versions", q.PurgeUserDataVersion},
+		}
+
+		deleted = make(map[string]int64, len(steps)+1)
+		for _, step := range steps {
+			count, err := step.purge(ctx, targetUserID)
+			if err != nil {
+				return fmt.Errorf("failed to purge %s: %w", step.table, err)
+			}
+			deleted[step.table] = count
+		}
+
+		detached = make(map[string]int64, len(detach))
+		for _, step := range detach {
+			count, err := step.detach(ctx, targetUserID)
+			if err != nil {
+				return fmt.Errorf("failed to detach %s: %w", step.table, err)
+			}
+			detached[step.table] = count
+		}
+
+		action := AuditActionUserPurge
+		if actorID == targetUserID {
+			action = AuditActionUserSelfPurge
+		}
+		details, err := json.Marshal(map[string]any{"deleted": deleted, "detached": detached})
+		if err != nil {
+			return fmt.Errorf("failed to encode audit details: %w", err)
+		}
+
+		// Written before the user row goes so the actor foreign key still
+		// resolves; a self-purge's actor_id is then nulled by the cascade
+		if err := q.InsertAuditLog(ctx, repo.InsertAuditLogParams{
+			ActorID:  pgtype.UUID{Bytes: actorID, Valid: true},
+			Action:   action,
+			TargetID: pgtype.UUID{Bytes: targetUserID, Valid: true},
+			Details:  details,
+		}); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		count, err := q.PurgeUserRow(ctx, targetUserID)
+		if err != nil {
+			return fmt.Errorf("failed to purge users: %w", err)
+		}
+		deleted["users"] = count
+		return nil
+	})
+	if err != nil {
+		return PurgeUserResponse{}, err
+	}
+
+	return PurgeUserResponse{
+		UserID:   targetUserID.String(),
+		Deleted:  deleted,
+		Detached: detached,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// Purging an admin deletes their unused invite codes but keeps the ones other
+// users registered with, along with those registrations and the reset tokens
+// the admin issued, with the admin cleared from each
+func TestPurgeAdminKeepsOtherUsersRecords(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+
+	purged := db.CreateUser(t, "purged-admin@example.com")
+	actor := db.CreateUser(t, "actor-admin@example.com")
+	member := db.CreateUser(t, "member@example.com")
+	if _, err := db.Pool.Exec(ctx, "UPDATE users SET role = 'admin' WHERE id IN ($1, $2)", purged.ID, actor.ID); err != nil {
+		t.Fatalf("promote admins: %v", err)
+	}
+
+	createdBy := pgtype.UUID{Bytes: purged.ID, Valid: true}
+	used, err := db.Queries.CreateInviteCode(ctx, repo.CreateInviteCodeParams{Code: "used", CreatedByAdminID: createdBy})
+	if err != nil {
+		t.Fatalf("CreateInviteCode: %v", err)
+	}
+	if _, err := db.Queries.CreateInviteCode(ctx, repo.CreateInviteCodeParams{Code: "unused", CreatedByAdminID: createdBy}); err != nil {
+		t.Fatalf("CreateInviteCode: %v", err)
+	}
+	if err := db.Queries.RecordInviteCodeUse(ctx, repo.RecordInviteCodeUseParams{CodeID: used.ID, UserID: member.ID}); err != nil {
+		t.Fatalf("RecordInviteCodeUse: %v", err)
+	}
+	if _, err := db.Queries.CreatePasswordResetToken(ctx, repo.CreatePasswordResetTokenParams{
+		UserID:           member.ID,
+		TokenHash:        "issued for member",
+		CreatedByAdminID: createdBy,
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("CreatePasswordResetToken: %v", err)
+	}
+
+	result, err := NewService(db.Queries, db.Transactor).PurgeUser(ctx, actor.ID, purged.ID, purged.Email)
+	if err != nil {
+		t.Fatalf("PurgeUser: %v", err)
+	}
+
+	if got := result.Deleted["admin_invite_codes"]; got != 1 {
+		t.Errorf("deleted admin_invite_codes = %d, want 1", got)
+	}
+	for table, want := range map[string]int64{"admin_invite_codes": 1, "password_reset_tokens": 1} {
+		if got := result.Detached[table]; got != want {
+			t.Errorf("detached %s = %d, want %d", table, got, want)
+		}
+	}
+
+	if n := db.Count(t, "admin_invite_codes", "code = 'unused'"); n != 0 {
+		t.Errorf("unused invite code kept")
+	}
+	if n := db.Count(t, "admin_invite_codes", "code = 'used' AND created_by_admin_id IS NULL"); n != 1 {
+		t.Errorf("used invite code not kept with its creator cleared")
+	}
+	if n := db.Count(t, "invite_code_uses", "user_id = $1", member.ID); n != 1 {
+		t.Errorf("member's registration record lost")
+	}
+	if n := db.Count(t, "password_reset_tokens", "user_id = $1 AND created_by_admin_id IS NULL", member.ID); n != 1 {
+		t.Errorf("reset token issued for the member not kept with its issuer cleared")
+	}
+}
+
+// Every table that references users must be either purged or detached, so
+// nothing is removed by the final cascade without appearing in the manifest
+func TestPurgeManifestCoversEveryUserReference(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	purged := db.CreateUser(t, "purged@example.com")
+	actor := db.CreateUser(t, "actor@example.com")
+
+	result, err := NewService(db.Queries, db.Transactor).PurgeUser(ctx, actor.ID, purged.ID, purged.Email)
+	if err != nil {
+		t.Fatalf("PurgeUser: %v", err)
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT tc.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+		  ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY'
+		  AND tc.table_schema = current_schema()
+		  AND ccu.table_name = 'users'
+		  AND tc.table_name <> 'users'`)
+	if err != nil {
+		t.Fatalf("list user references: %v", err)
+	}
+	defer rows.Close()
+
+	// The audit log keeps its entries about a purged user on purpose
+	kept := map[string]bool{"audit_log": true}
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			t.Fatalf("scan table name: %v", err)
+		}
+		_, deleted := result.Deleted[table]
+		_, detached := result.Detached[table]
+		if !deleted && !detached && !kept[table] {
+			t.Errorf("%s references users but is neither purged nor detached", table)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("list user references: %v", err)
+	}
+}
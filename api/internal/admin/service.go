@@ -2,32 +2,44 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
 	"github.com/vasujain275/reforge/internal/security"
 )
 
 type Service interface {
 	// User Management
-	ListUsers(ctx context.Context, page, limit int) (UserListResponse, error)
+	ListUsers(ctx context.Context, page, limit int, filter UserFilter) (UserListResponse, error)
+	GetUserDetail(ctx context.Context, userID uuid.UUID) (UserDetailResponse, error)
 	UpdateUserRole(ctx context.Context, adminID, targetUserID uuid.UUID, newRole string) error
 	DeactivateUser(ctx context.Context, adminID, targetUserID uuid.UUID) error
 	ReactivateUser(ctx context.Context, adminID, targetUserID uuid.UUID) error
 	DeleteUser(ctx context.Context, adminID, targetUserID uuid.UUID) error
+	PurgeUser(ctx context.Context, actorID, targetUserID uuid.UUID, confirmEmail string) (PurgeUserResponse, error)
 
 	// Password Reset
 	InitiatePasswordReset(ctx context.Context, adminID, targetUserID uuid.UUID) (InitiatePasswordResetResponse, error)
 
 	// Invite System
-	CreateInviteCode(ctx context.Context, adminID uuid.UUID, maxUses int, expiresIn *int) (InviteCodeResponse, error)
+	CreateInviteCode(ctx context.Context, adminID uuid.UUID, count, maxUses int, expiresIn *int) (InviteCodeBatchResponse, error)
 	ListInviteCodes(ctx context.Context) (InviteCodeListResponse, error)
+	ListInviteCodeUses(ctx context.Context, codeID uuid.UUID) (InviteCodeUsesResponse, error)
 	DeleteInviteCode(ctx context.Context, codeID uuid.UUID) error
 	ValidateInviteCode(ctx context.Context, code string) error
-	UseInviteCode(ctx context.Context, code string) error
+	UseInviteCode(ctx context.Context, code string, userID uuid.UUID) error
+
+	// Instance Stats
+	GetInstanceStats(ctx context.Context) (InstanceStatsResponse, error)
+
+	// Maintenance
+	CleanupTokens(ctx context.Context) (TokenCleanupResponse, error)
 
 	// Settings Management
 	GetSignupSettings(ctx context.Context) (SignupSettingsResponse, error)
@@ -37,27 +49,49 @@ type Service interface {
 
 type adminService struct {
 	repo repo.Querier
+	tx   postgres.Transactor
 }
 
-func NewService(repo repo.Querier) Service {
+func NewService(repo repo.Querier, tx postgres.Transactor) Service {
 	return &adminService{
 		repo: repo,
+		tx:   tx,
 	}
 }
 
-// ListUsers returns paginated list of all users
-func (s *adminService) ListUsers(ctx context.Context, page, limit int) (UserListResponse, error) {
+// ListUsers returns a paginated, filtered list of users
+func (s *adminService) ListUsers(ctx context.Context, page, limit int, filter UserFilter) (UserListResponse, error) {
 	offset := (page - 1) * limit
 
-	users, err := s.repo.GetAllUsers(ctx, repo.GetAllUsersParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+	var isActive pgtype.Bool
+	if filter.IsActive != nil {
+		isActive = pgtype.Bool{Bool: *filter.IsActive, Valid: true}
+	}
+
+	var inactiveSince pgtype.Timestamptz
+	if filter.InactiveSince != nil {
+		inactiveSince = pgtype.Timestamptz{Time: *filter.InactiveSince, Valid: true}
+	}
+
+	users, err := s.repo.SearchUsers(ctx, repo.SearchUsersParams{
+		SearchQuery:   filter.Query,
+		Role:          filter.Role,
+		IsActive:      isActive,
+		InactiveSince: inactiveSince,
+		SortBy:        filter.SortBy,
+		LimitVal:      int32(limit),
+		OffsetVal:     int32(offset),
 	})
 	if err != nil {
 		return UserListResponse{}, err
 	}
 
-	total, err := s.repo.CountAllUsers(ctx)
+	total, err := s.repo.CountSearchUsers(ctx, repo.CountSearchUsersParams{
+		SearchQuery:   filter.Query,
+		Role:          filter.Role,
+		IsActive:      isActive,
+		InactiveSince: inactiveSince,
+	})
 	if err != nil {
 		return UserListResponse{}, err
 	}
@@ -65,12 +99,13 @@ func (s *adminService) ListUsers(ctx context.Context, page, limit int) (UserList
 	userInfos := make([]UserInfo, len(users))
 	for i, u := range users {
 		userInfos[i] = UserInfo{
-			ID:        u.ID.String(),
-			Email:     u.Email,
-			Name:      u.Name,
-			Role:      u.Role.String,
-			IsActive:  u.IsActive.Bool,
-			CreatedAt: u.CreatedAt.Time.Format(time.RFC3339),
+			ID:          u.ID.String(),
+			Email:       u.Email,
+			Name:        u.Name,
+			Role:        u.Role.String,
+			IsActive:    u.IsActive.Bool,
+			CreatedAt:   u.CreatedAt.Time.Format(time.RFC3339),
+			LastLoginAt: toTimestampPtr(u.LastLoginAt),
 		}
 	}
 
@@ -82,6 +117,39 @@ func (s *adminService) ListUsers(ctx context.Context, page, limit int) (UserList
 	}, nil
 }
 
+// GetUserDetail returns a user's profile with activity aggregates
+func (s *adminService) GetUserDetail(ctx context.Context, userID uuid.UUID) (UserDetailResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return UserDetailResponse{}, ErrUserNotFound
+		}
+		return UserDetailResponse{}, err
+	}
+
+	stats, err := s.repo.GetUserActivityStats(ctx, userID)
+	if err != nil {
+		return UserDetailResponse{}, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	return UserDetailResponse{
+		UserInfo: UserInfo{
+			ID:          user.ID.String(),
+			Email:       user.Email,
+			Name:        user.Name,
+			Role:        user.Role.String,
+			IsActive:    user.IsActive.Bool,
+			CreatedAt:   user.CreatedAt.Time.Format(time.RFC3339),
+			LastLoginAt: toTimestampPtr(user.LastLoginAt),
+		},
+		ProblemCount:     stats.ProblemCount,
+		AttemptCount:     stats.AttemptCount,
+		LastAttemptAt:    anyTimeToPtr(stats.LastAttemptAt),
+		SessionCount:     stats.SessionCount,
+		ActiveTokenCount: stats.ActiveTokenCount,
+	}, nil
+}
+
 // UpdateUserRole changes a user's role (admin cannot change own role)
 func (s *adminService) UpdateUserRole(ctx context.Context, adminID, targetUserID uuid.UUID, newRole string) error {
 	if adminID == targetUserID {
@@ -89,9 +157,9 @@ func (s *adminService) UpdateUserRole(ctx context.Context, adminID, targetUserID
 	}
 
 	// If demoting an admin, check we won't leave zero admins
-	targetUser, err := s.repo.GetUserByID(ctx, targetUserID)
+	targetUser, err := s.getUser(ctx, targetUserID)
 	if err != nil {
-		return ErrUserNotFound
+		return err
 	}
 
 	if targetUser.Role.String == "admin" && newRole == "user" {
@@ -110,24 +178,44 @@ func (s *adminService) UpdateUserRole(ctx context.Context, adminID, targetUserID
 	})
 }
 
-// DeactivateUser soft-deletes a user account
+// DeactivateUser soft-deletes a user account and signs it out everywhere
 func (s *adminService) DeactivateUser(ctx context.Context, adminID, targetUserID uuid.UUID) error {
 	if adminID == targetUserID {
 		return ErrSelfDeactivation
 	}
 
-	return s.repo.UpdateUserActiveStatus(ctx, repo.UpdateUserActiveStatusParams{
-		IsActive: pgtype.Bool{Bool: false, Valid: true},
-		ID:       targetUserID,
+	return s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		updated, err := q.UpdateUserActiveStatus(ctx, repo.UpdateUserActiveStatusParams{
+			IsActive: pgtype.Bool{Bool: false, Valid: true},
+			ID:       targetUserID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to deactivate user: %w", err)
+		}
+		if updated == 0 {
+			return ErrUserNotFound
+		}
+
+		if err := q.RevokeUserRefreshTokens(ctx, targetUserID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+		return nil
 	})
 }
 
 // ReactivateUser reactivates a deactivated user
 func (s *adminService) ReactivateUser(ctx context.Context, adminID, targetUserID uuid.UUID) error {
-	return s.repo.UpdateUserActiveStatus(ctx, repo.UpdateUserActiveStatusParams{
+	updated, err := s.repo.UpdateUserActiveStatus(ctx, repo.UpdateUserActiveStatusParams{
 		IsActive: pgtype.Bool{Bool: true, Valid: true},
 		ID:       targetUserID,
 	})
+	if err != nil {
+		return fmt.Errorf("failed to reactivate user: %w", err)
+	}
+	if updated == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
 
 // DeleteUser permanently deletes a user
@@ -137,9 +225,9 @@ func (s *adminService) DeleteUser(ctx context.Context, adminID, targetUserID uui
 	}
 
 	// Check if target is admin
-	targetUser, err := s.repo.GetUserByID(ctx, targetUserID)
+	targetUser, err := s.getUser(ctx, targetUserID)
 	if err != nil {
-		return ErrUserNotFound
+		return err
 	}
 
 	if targetUser.Role.String == "admin" {
@@ -155,8 +243,24 @@ func (s *adminService) DeleteUser(ctx context.Context, adminID, targetUserID uui
 	return s.repo.DeleteUser(ctx, targetUserID)
 }
 
+// getUser looks up a user, reporting a missing row as ErrUserNotFound
+func (s *adminService) getUser(ctx context.Context, userID uuid.UUID) (repo.GetUserByIDRow, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repo.GetUserByIDRow{}, ErrUserNotFound
+		}
+		return repo.GetUserByIDRow{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
 // InitiatePasswordReset creates a password reset token for a user
 func (s *adminService) InitiatePasswordReset(ctx context.Context, adminID, targetUserID uuid.UUID) (InitiatePasswordResetResponse, error) {
+	if _, err := s.getUser(ctx, targetUserID); err != nil {
+		return InitiatePasswordResetResponse{}, err
+	}
+
 	// Generate secure random token
 	rawToken, err := security.GenerateSecureToken(32)
 	if err != nil {
@@ -189,10 +293,18 @@ func (s *adminService) InitiatePasswordReset(ctx context.Context, adminID, targe
 	}, nil
 }
 
-// CreateInviteCode generates a new invite code
-func (s *adminService) CreateInviteCode(ctx context.Context, adminID uuid.UUID, maxUses int, expiresIn *int) (InviteCodeResponse, error) {
-	// Generate UUID as invite code
-	code := uuid.New().String()
+// inviteCodeInsertAttempts bounds how often CreateInviteCode regenerates codes
+// that collided with existing ones
+const inviteCodeInsertAttempts = 3
+
+// CreateInviteCode generates count invite codes that share the same limits
+func (s *adminService) CreateInviteCode(ctx context.Context, adminID uuid.UUID, count, maxUses int, expiresIn *int) (InviteCodeBatchResponse, error) {
+	if count == 0 {
+		count = 1
+	}
+	if count < 1 || count > MaxInviteCodeBatch {
+		return InviteCodeBatchResponse{}, ErrInviteCountRange
+	}
 
 	var expiresAt pgtype.Timestamptz
 	if expiresIn != nil {
@@ -203,25 +315,43 @@ func (s *adminService) CreateInviteCode(ctx context.Context, adminID uuid.UUID,
 		}
 	}
 
-	inviteCode, err := s.repo.CreateInviteCode(ctx, repo.CreateInviteCodeParams{
-		Code:             code,
-		CreatedByAdminID: adminID,
-		MaxUses:          pgtype.Int4{Int32: int32(maxUses), Valid: true},
-		ExpiresAt:        expiresAt,
-	})
-	if err != nil {
-		return InviteCodeResponse{}, err
+	responses := make([]InviteCodeResponse, 0, count)
+	for attempt := 0; attempt < inviteCodeInsertAttempts && len(responses) < count; attempt++ {
+		// Generate UUIDs as invite codes; any that already exist are skipped
+		// by the insert and regenerated on the next round
+		codes := make([]string, count-len(responses))
+		for i := range codes {
+			codes[i] = uuid.New().String()
+		}
+
+		created, err := s.repo.CreateInviteCodes(ctx, repo.CreateInviteCodesParams{
+			CreatedByAdminID: pgtype.UUID{Bytes: adminID, Valid: true},
+			MaxUses:          pgtype.Int4{Int32: int32(maxUses), Valid: true},
+			ExpiresAt:        expiresAt,
+			Codes:            codes,
+		})
+		if err != nil {
+			return InviteCodeBatchResponse{}, err
+		}
+
+		for _, inviteCode := range created {
+			responses = append(responses, InviteCodeResponse{
+				ID:               inviteCode.ID.String(),
+				Code:             inviteCode.Code,
+				CreatedByAdminID: toUUIDPtr(inviteCode.CreatedByAdminID),
+				MaxUses:          int(inviteCode.MaxUses.Int32),
+				CurrentUses:      int(inviteCode.CurrentUses.Int32),
+				ExpiresAt:        toTimestampPtr(inviteCode.ExpiresAt),
+				CreatedAt:        inviteCode.CreatedAt.Time.Format(time.RFC3339),
+			})
+		}
 	}
 
-	return InviteCodeResponse{
-		ID:               inviteCode.ID.String(),
-		Code:             inviteCode.Code,
-		CreatedByAdminID: inviteCode.CreatedByAdminID.String(),
-		MaxUses:          int(inviteCode.MaxUses.Int32),
-		CurrentUses:      int(inviteCode.CurrentUses.Int32),
-		ExpiresAt:        toTimestampPtr(inviteCode.ExpiresAt),
-		CreatedAt:        inviteCode.CreatedAt.Time.Format(time.RFC3339),
-	}, nil
+	if len(responses) < count {
+		return InviteCodeBatchResponse{}, fmt.Errorf("generated %d of %d invite codes after repeated collisions", len(responses), count)
+	}
+
+	return InviteCodeBatchResponse{InviteCodes: responses}, nil
 }
 
 // ListInviteCodes returns all invite codes
@@ -244,11 +374,12 @@ func (s *adminService) ListInviteCodes(ctx context.Context) (InviteCodeListRespo
 		responses[i] = InviteCodeResponse{
 			ID:               code.ID.String(),
 			Code:             code.Code,
-			CreatedByAdminID: code.CreatedByAdminID.String(),
+			CreatedByAdminID: toUUIDPtr(code.CreatedByAdminID),
 			MaxUses:          int(code.MaxUses.Int32),
 			CurrentUses:      int(code.CurrentUses.Int32),
 			ExpiresAt:        toTimestampPtr(code.ExpiresAt),
 			CreatedAt:        code.CreatedAt.Time.Format(time.RFC3339),
+			UsersRegistered:  code.UsersRegistered,
 		}
 	}
 
@@ -258,9 +389,47 @@ func (s *adminService) ListInviteCodes(ctx context.Context) (InviteCodeListRespo
 	}, nil
 }
 
+// ListInviteCodeUses returns the users who registered with an invite code
+func (s *adminService) ListInviteCodeUses(ctx context.Context, codeID uuid.UUID) (InviteCodeUsesResponse, error) {
+	if _, err := s.repo.GetInviteCodeByID(ctx, codeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return InviteCodeUsesResponse{}, ErrInviteCodeNotFound
+		}
+		return InviteCodeUsesResponse{}, err
+	}
+
+	rows, err := s.repo.ListInviteCodeUses(ctx, codeID)
+	if err != nil {
+		return InviteCodeUsesResponse{}, err
+	}
+
+	uses := make([]InviteCodeUse, len(rows))
+	for i, row := range rows {
+		uses[i] = InviteCodeUse{
+			UserID: row.UserID.String(),
+			Email:  row.Email,
+			Name:   row.Name,
+			UsedAt: row.UsedAt.Format(time.RFC3339),
+		}
+	}
+
+	return InviteCodeUsesResponse{
+		CodeID: codeID.String(),
+		Uses:   uses,
+		Total:  len(uses),
+	}, nil
+}
+
 // DeleteInviteCode removes an invite code
 func (s *adminService) DeleteInviteCode(ctx context.Context, codeID uuid.UUID) error {
-	return s.repo.DeleteInviteCode(ctx, codeID)
+	deleted, err := s.repo.DeleteInviteCode(ctx, codeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invite code: %w", err)
+	}
+	if deleted == 0 {
+		return ErrInviteCodeNotFound
+	}
+	return nil
 }
 
 // ValidateInviteCode checks if an invite code is valid and not expired
@@ -285,21 +454,21 @@ func (s *adminService) ValidateInviteCode(ctx context.Context, code string) erro
 	return nil
 }
 
-// UseInviteCode increments the usage count of an invite code
-func (s *adminService) UseInviteCode(ctx context.Context, code string) error {
-	// Validate first
-	if err := s.ValidateInviteCode(ctx, code); err != nil {
-		return err
-	}
-
-	// Get the code ID
-	inviteCode, err := s.repo.GetInviteCodeByCode(ctx, code)
+// UseInviteCode validates and increments the usage count of an invite code
+// in a single statement, then records that userID registered with it
+func (s *adminService) UseInviteCode(ctx context.Context, code string, userID uuid.UUID) error {
+	codeID, err := s.repo.ConsumeInviteCode(ctx, code)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInviteCodeInvalid
+		}
 		return err
 	}
 
-	// Increment usage
-	return s.repo.IncrementInviteCodeUses(ctx, inviteCode.ID)
+	return s.repo.RecordInviteCodeUse(ctx, repo.RecordInviteCodeUseParams{
+		CodeID: codeID,
+		UserID: userID,
+	})
 }
 
 // GetSignupSettings retrieves current signup settings
@@ -365,3 +534,22 @@ func toTimestampPtr(ts pgtype.Timestamptz) *string {
 	s := ts.Time.Format(time.RFC3339)
 	return &s
 }
+
+func toUUIDPtr(id pgtype.UUID) *string {
+	if !id.Valid {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// anyTimeToPtr formats a MAX() over a timestamp column, which sqlc types as
+// interface{} and pgx scans as time.Time or nil
+func anyTimeToPtr(v interface{}) *string {
+	t, ok := v.(time.Time)
+	if !ok {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
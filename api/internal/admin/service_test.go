@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/security"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// Deactivating a user ends every session at its next refresh and blocks
+// login; reactivating lets them log in again but doesn't revive old sessions
+func TestDeactivateAndReactivateUser(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	authService := auth.NewService(db.Queries, "secret", auth.NewMemoryAttemptStore(), auth.TokenLifetimes{})
+	adminService := NewService(db.Queries, db.Transactor)
+
+	const email, password = "member@example.com", "correct horse battery"
+	hash, err := security.HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	user, err := db.Queries.CreateUser(ctx, repo.CreateUserParams{
+		Email:        email,
+		PasswordHash: hash,
+		Name:         "Member",
+		Role:         pgtype.Text{String: "user", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	admin := db.CreateUser(t, "admin@example.com")
+
+	login := func() (auth.IssuedRefreshToken, error) {
+		_, refresh, _, err := authService.Login(ctx, email, password, false, "test", "127.0.0.1")
+		return refresh, err
+	}
+	refresh := func(token string) (auth.IssuedRefreshToken, error) {
+		_, next, err := authService.Refresh(ctx, token, "test", "127.0.0.1")
+		return next, err
+	}
+
+	// Two sessions, one already rotated once
+	laptop, err := login()
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	phone, err := login()
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if laptop, err = refresh(laptop.Token); err != nil {
+		t.Fatalf("Refresh before deactivation: %v", err)
+	}
+
+	if err := adminService.DeactivateUser(ctx, admin.ID, user.ID); err != nil {
+		t.Fatalf("DeactivateUser: %v", err)
+	}
+	if n := db.Count(t, "refresh_tokens", "user_id = $1 AND revoked_at IS NULL", user.ID); n != 0 {
+		t.Errorf("%d live refresh tokens after deactivation, want 0", n)
+	}
+	for name, session := range map[string]auth.IssuedRefreshToken{"laptop": laptop, "phone": phone} {
+		if _, err := refresh(session.Token); err == nil {
+			t.Errorf("%s refreshed after deactivation", name)
+		}
+	}
+	if _, err := login(); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("Login after deactivation: err = %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+
+	if err := adminService.ReactivateUser(ctx, admin.ID, user.ID); err != nil {
+		t.Fatalf("ReactivateUser: %v", err)
+	}
+	if _, err := refresh(phone.Token); err == nil {
+		t.Errorf("a session from before deactivation refreshed after reactivation")
+	}
+	session, err := login()
+	if err != nil {
+		t.Fatalf("Login after reactivation: %v", err)
+	}
+	if _, err := refresh(session.Token); err != nil {
+		t.Errorf("Refresh after reactivation: %v", err)
+	}
+}
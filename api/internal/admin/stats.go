@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/logging"
+	"golang.org/x/sync/errgroup"
+)
+
+// GetInstanceStats runs the instance aggregates concurrently. A failing query
+// doesn't fail the response; its fields are left null and reported in Errors.
+func (s *adminService) GetInstanceStats(ctx context.Context) (InstanceStatsResponse, error) {
+	var (
+		stats InstanceStatsResponse
+		mu    sync.Mutex
+	)
+	weekAgo := time.Now().AddDate(0, 0, -7)
+
+	fail := func(err error, fields ...string) {
+		logging.FromContext(ctx).Error("Failed to load instance stat", "fields", fields, "error", err)
+		mu.Lock()
+		defer mu.Unlock()
+		if stats.Errors == nil {
+			stats.Errors = make(map[string]string)
+		}
+		for _, field := range fields {
+			stats.Errors[field] = "query failed"
+		}
+	}
+
+	// Each goroutine writes only its own fields, and none return an error so
+	// one failure doesn't cancel the others
+	var g errgroup.Group
+
+	g.Go(func() error {
+		row, err := s.repo.GetInstanceUserStats(ctx)
+		if err != nil {
+			fail(err, "total_users", "active_users", "new_users_7d", "new_users_30d")
+			return nil
+		}
+		stats.TotalUsers = &row.TotalUsers
+		stats.ActiveUsers = &row.ActiveUsers
+		stats.NewUsers7d = &row.NewUsers7d
+		stats.NewUsers30d = &row.NewUsers30d
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.CountAllProblems(ctx)
+		if err != nil {
+			fail(err, "total_problems")
+			return nil
+		}
+		stats.TotalProblems = &count
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.CountAllPatterns(ctx)
+		if err != nil {
+			fail(err, "total_patterns")
+			return nil
+		}
+		stats.TotalPatterns = &count
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.CountAttemptsSince(ctx, pgtype.Timestamptz{Time: weekAgo, Valid: true})
+		if err != nil {
+			fail(err, "attempts_7d")
+			return nil
+		}
+		stats.Attempts7d = &count
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.CountSessionsCompletedSince(ctx, pgtype.Timestamptz{Time: weekAgo, Valid: true})
+		if err != nil {
+			fail(err, "sessions_completed_7d")
+			return nil
+		}
+		stats.SessionsCompleted7d = &count
+		return nil
+	})
+
+	g.Go(func() error {
+		// pg_database_size needs CONNECT on the database, which managed
+		// hosts occasionally withhold
+		size, err := s.repo.GetDatabaseSize(ctx)
+		if err != nil {
+			fail(err, "database_size_bytes")
+			return nil
+		}
+		stats.DatabaseSizeBytes = &size
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return InstanceStatsResponse{}, err
+	}
+
+	return stats, nil
+}
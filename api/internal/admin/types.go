@@ -2,19 +2,36 @@ package admin
 
 import (
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/vasujain275/reforge/internal/utils"
 )
 
 var (
-	ErrLastAdmin         = errors.New("cannot delete or demote the last admin")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrInviteCodeInvalid = errors.New("invite code is invalid or expired")
-	ErrSelfRoleChange    = errors.New("cannot change your own role")
-	ErrSelfDeactivation  = errors.New("cannot deactivate your own account")
+	ErrLastAdmin          = errors.New("cannot delete or demote the last admin")
+	ErrUserNotFound       = fmt.Errorf("user %w", utils.ErrNotFound)
+	ErrInviteCodeInvalid  = errors.New("invite code is invalid or expired")
+	ErrInviteCodeNotFound = fmt.Errorf("invite code %w", utils.ErrNotFound)
+	ErrInviteCountRange   = errors.New("invite code count out of range")
+	ErrSelfRoleChange     = errors.New("cannot change your own role")
+	ErrSelfDeactivation   = errors.New("cannot deactivate your own account")
+	ErrPurgeConfirmation  = errors.New("confirmation email does not match the user")
 )
 
 // User Management Types
 
+// UserFilter narrows the admin user list; zero values don't filter
+type UserFilter struct {
+	Query    string // Case-insensitive match on email or name
+	Role     string
+	IsActive *bool
+	// InactiveSince keeps users whose last login (or signup, if they never
+	// logged in) is before this time
+	InactiveSince *time.Time
+	SortBy        string // created_at_desc (default), created_at_asc, email_asc or email_desc
+}
+
 type UserListResponse struct {
 	Users []UserInfo `json:"users"`
 	Total int64      `json:"total"`
@@ -23,12 +40,34 @@ type UserListResponse struct {
 }
 
 type UserInfo struct {
-	ID        string `json:"id"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	Role      string `json:"role"`
-	IsActive  bool   `json:"is_active"`
-	CreatedAt string `json:"created_at"`
+	ID          string  `json:"id"`
+	Email       string  `json:"email"`
+	Name        string  `json:"name"`
+	Role        string  `json:"role"`
+	IsActive    bool    `json:"is_active"`
+	CreatedAt   string  `json:"created_at"`
+	LastLoginAt *string `json:"last_login_at"`
+}
+
+// UserDetailResponse is a single user with activity aggregates for the admin panel
+type UserDetailResponse struct {
+	UserInfo
+	ProblemCount     int64   `json:"problem_count"`
+	AttemptCount     int64   `json:"attempt_count"`
+	LastAttemptAt    *string `json:"last_attempt_at"`
+	SessionCount     int64   `json:"session_count"`
+	ActiveTokenCount int64   `json:"active_token_count"`
+}
+
+type PurgeUserRequest struct {
+	ConfirmEmail string `json:"confirm_email" validate:"required"`
+}
+
+// PurgeUserResponse is the manifest of rows deleted per table
+type PurgeUserResponse struct {
+	UserID   string           `json:"user_id"`
+	Deleted  map[string]int64 `json:"deleted"`
+	Detached map[string]int64 `json:"detached"` // Kept for other users, creator cleared
 }
 
 type UpdateRoleRequest struct {
@@ -37,19 +76,28 @@ type UpdateRoleRequest struct {
 
 // Invite Code Types
 
+// MaxInviteCodeBatch caps how many codes one create request may generate
+const MaxInviteCodeBatch = 100
+
 type CreateInviteCodeRequest struct {
 	MaxUses   int  `json:"max_uses" validate:"required,min=1"`
 	ExpiresIn *int `json:"expires_in"` // Hours until expiration (nil = never expires)
+	Count     int  `json:"count"`      // Codes to generate (0 = 1)
 }
 
 type InviteCodeResponse struct {
 	ID               string  `json:"id"`
 	Code             string  `json:"code"`
-	CreatedByAdminID string  `json:"created_by_admin_id"`
+	CreatedByAdminID *string `json:"created_by_admin_id"` // nil once the creator is purged
 	MaxUses          int     `json:"max_uses"`
 	CurrentUses      int     `json:"current_uses"`
 	ExpiresAt        *string `json:"expires_at"`
 	CreatedAt        string  `json:"created_at"`
+	UsersRegistered  int64   `json:"users_registered"`
+}
+
+type InviteCodeBatchResponse struct {
+	InviteCodes []InviteCodeResponse `json:"invite_codes"`
 }
 
 type InviteCodeListResponse struct {
@@ -57,6 +105,20 @@ type InviteCodeListResponse struct {
 	Total       int64                `json:"total"`
 }
 
+// InviteCodeUse is a registration made with an invite code
+type InviteCodeUse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+	UsedAt string `json:"used_at"`
+}
+
+type InviteCodeUsesResponse struct {
+	CodeID string          `json:"code_id"`
+	Uses   []InviteCodeUse `json:"uses"`
+	Total  int             `json:"total"`
+}
+
 // Password Reset Types
 
 type InitiatePasswordResetResponse struct {
@@ -65,6 +127,23 @@ type InitiatePasswordResetResponse struct {
 	ResetLink  string    `json:"reset_link"` // For admin to copy and send
 }
 
+// Instance Stats Types
+
+// InstanceStatsResponse holds instance-wide aggregates. A field whose query
+// failed is null and its JSON name is listed in Errors.
+type InstanceStatsResponse struct {
+	TotalUsers          *int64            `json:"total_users"`
+	ActiveUsers         *int64            `json:"active_users"`
+	NewUsers7d          *int64            `json:"new_users_7d"`
+	NewUsers30d         *int64            `json:"new_users_30d"`
+	TotalProblems       *int64            `json:"total_problems"`
+	TotalPatterns       *int64            `json:"total_patterns"`
+	Attempts7d          *int64            `json:"attempts_7d"`
+	SessionsCompleted7d *int64            `json:"sessions_completed_7d"`
+	DatabaseSizeBytes   *int64            `json:"database_size_bytes"`
+	Errors              map[string]string `json:"errors,omitempty"`
+}
+
 // Settings Types
 
 type SignupSettingsResponse struct {
@@ -75,3 +154,10 @@ type SignupSettingsResponse struct {
 type UpdateSignupEnabledRequest struct {
 	Enabled bool `json:"enabled"`
 }
+
+// Maintenance Types
+
+type TokenCleanupResponse struct {
+	RefreshTokensDeleted       int64 `json:"refresh_tokens_deleted"`
+	PasswordResetTokensDeleted int64 `json:"password_reset_tokens_deleted"`
+}
@@ -0,0 +1,116 @@
+package attempts
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+var errUpsertFailed = errors.New("upsert failed")
+
+// failingStatsUpsert fails every problem stats upsert
+type failingStatsUpsert struct {
+	repo.Querier
+}
+
+func (f failingStatsUpsert) UpsertUserProblemStats(ctx context.Context, arg repo.UpsertUserProblemStatsParams) (repo.UserProblemStat, error) {
+	return repo.UserProblemStat{}, errUpsertFailed
+}
+
+func TestCreateAttemptRollsBackWhenStatsFail(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := db.CreateUser(t, "attempt@example.com")
+	problem := db.CreateProblem(t, "Two Sum", "easy")
+
+	tx := testutil.WrapTransactor{
+		Inner: db.Transactor,
+		Wrap:  func(q repo.Querier) repo.Querier { return failingStatsUpsert{q} },
+	}
+	s := NewService(db.Queries, tx, scoring.NewService(db.Queries), metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+
+	_, err := s.CreateAttempt(context.Background(), user.ID, CreateAttemptBody{
+		ProblemID:       problem.ID.String(),
+		ConfidenceScore: 80,
+		Outcome:         "passed",
+	})
+	if !errors.Is(err, errUpsertFailed) {
+		t.Fatalf("CreateAttempt err = %v, want the upsert failure", err)
+	}
+
+	if n := db.Count(t, "attempts", "user_id = $1", user.ID); n != 0 {
+		t.Errorf("%d attempt rows left after the failed stats update, want 0", n)
+	}
+	if n := db.Count(t, "user_problem_stats", "user_id = $1", user.ID); n != 0 {
+		t.Errorf("%d stats rows left after the failed stats update, want 0", n)
+	}
+}
+
+// An attempt at one problem moves its pattern's stats, so a problem sharing
+// the pattern must not keep the f_pattern it cached before
+func TestCreateAttemptRefreshesPatternSiblings(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "siblings@example.com")
+	first := db.CreateProblem(t, "Two Sum", "easy")
+	sibling := db.CreateProblem(t, "Three Sum", "medium")
+
+	pattern, err := db.Queries.CreatePattern(ctx, repo.CreatePatternParams{Title: "Two Pointers"})
+	if err != nil {
+		t.Fatalf("CreatePattern: %v", err)
+	}
+	for _, problem := range []repo.Problem{first, sibling} {
+		if err := db.Queries.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{
+			ProblemID: problem.ID,
+			PatternID: pattern.ID,
+		}); err != nil {
+			t.Fatalf("LinkProblemToPattern: %v", err)
+		}
+	}
+
+	scorer := scoring.NewService(db.Queries)
+	s := NewService(db.Queries, db.Transactor, scorer, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+	attempt := func(problem repo.Problem, confidence int64, outcome string) {
+		t.Helper()
+		if _, err := s.CreateAttempt(ctx, user.ID, CreateAttemptBody{
+			ProblemID:       problem.ID.String(),
+			ConfidenceScore: confidence,
+			Outcome:         outcome,
+		}); err != nil {
+			t.Fatalf("CreateAttempt: %v", err)
+		}
+	}
+	siblingPattern := func() float64 {
+		t.Helper()
+		scores, err := scorer.ComputeScoresForUser(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("ComputeScoresForUser: %v", err)
+		}
+		for _, score := range scores {
+			if score.ProblemID == sibling.ID {
+				return score.Features.FPattern
+			}
+		}
+		t.Fatalf("no score for the sibling problem")
+		return 0
+	}
+
+	// The sibling is cached with the pattern at 90% confidence
+	attempt(sibling, 90, "passed")
+	if got := siblingPattern(); math.Abs(got-0.1) > 1e-9 {
+		t.Fatalf("sibling f_pattern = %v, want 0.1", got)
+	}
+
+	// and the pattern averages 50% once the first problem is attempted at 10%
+	attempt(first, 10, "failed")
+	if got := siblingPattern(); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("sibling f_pattern = %v after attempting the other problem, want 0.5", got)
+	}
+}
@@ -1,23 +1,33 @@
 package attempts
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
+// streamKeepAlive is how often an idle timer stream sends a comment, so
+// proxies don't close it for inactivity
+const streamKeepAlive = 30 * time.Second
+
 type handler struct {
 	service Service
+	hub     *events.Hub
 }
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, hub *events.Hub) *handler {
 	return &handler{
 		service: service,
+		hub:     hub,
 	}
 }
 
@@ -25,22 +35,22 @@ func (h *handler) CreateAttempt(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body CreateAttemptBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	attempt, err := h.service.CreateAttempt(r.Context(), userID, body)
 	if err != nil {
-		slog.Error("Failed to create attempt", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to create attempt", "error", err)
 		utils.InternalServerError(w, "Failed to create attempt")
 		return
 	}
@@ -50,7 +60,7 @@ func (h *handler) CreateAttempt(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) ListAttemptsForUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -74,7 +84,7 @@ func (h *handler) ListAttemptsForUser(w http.ResponseWriter, r *http.Request) {
 
 	attempts, err := h.service.ListAttemptsForUser(r.Context(), userID, int32(limit), int32(offset))
 	if err != nil {
-		slog.Error("Failed to list attempts", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list attempts", "error", err)
 		utils.InternalServerError(w, "Failed to list attempts")
 		return
 	}
@@ -84,7 +94,7 @@ func (h *handler) ListAttemptsForUser(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) ListAttemptsForProblem(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -99,7 +109,7 @@ func (h *handler) ListAttemptsForProblem(w http.ResponseWriter, r *http.Request)
 
 	attempts, err := h.service.ListAttemptsForProblem(r.Context(), userID, problemID)
 	if err != nil {
-		slog.Error("Failed to list attempts for problem", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list attempts for problem", "error", err)
 		utils.InternalServerError(w, "Failed to list attempts for problem")
 		return
 	}
@@ -115,22 +125,22 @@ func (h *handler) ListAttemptsForProblem(w http.ResponseWriter, r *http.Request)
 func (h *handler) StartAttempt(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body StartAttemptBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	attempt, err := h.service.StartAttempt(r.Context(), userID, body)
 	if err != nil {
-		slog.Error("Failed to start attempt", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to start attempt", "error", err)
 		utils.InternalServerError(w, "Failed to start attempt")
 		return
 	}
@@ -140,7 +150,7 @@ func (h *handler) StartAttempt(w http.ResponseWriter, r *http.Request) {
 
 // GetInProgressAttempt retrieves an existing in-progress attempt for a problem
 func (h *handler) GetInProgressAttempt(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -160,7 +170,7 @@ func (h *handler) GetInProgressAttempt(w http.ResponseWriter, r *http.Request) {
 
 	attempt, err := h.service.GetInProgressAttempt(r.Context(), userID, problemID)
 	if err != nil {
-		slog.Error("Failed to get in-progress attempt", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get in-progress attempt", "error", err)
 		utils.InternalServerError(w, "Failed to get in-progress attempt")
 		return
 	}
@@ -175,7 +185,7 @@ func (h *handler) GetInProgressAttempt(w http.ResponseWriter, r *http.Request) {
 
 // GetAttemptByID retrieves an attempt by its ID
 func (h *handler) GetAttemptByID(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -190,8 +200,12 @@ func (h *handler) GetAttemptByID(w http.ResponseWriter, r *http.Request) {
 
 	attempt, err := h.service.GetAttemptByID(r.Context(), userID, attemptID)
 	if err != nil {
-		slog.Error("Failed to get attempt", "error", err)
-		utils.NotFound(w, "Attempt not found")
+		if errors.Is(err, ErrAttemptNotFound) {
+			utils.NotFound(w, "Attempt not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get attempt", "error", err)
+		utils.InternalServerError(w, "Failed to get attempt")
 		return
 	}
 
@@ -202,7 +216,7 @@ func (h *handler) GetAttemptByID(w http.ResponseWriter, r *http.Request) {
 func (h *handler) UpdateAttemptTimer(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -216,14 +230,18 @@ func (h *handler) UpdateAttemptTimer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body UpdateAttemptTimerBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	if err := h.service.UpdateAttemptTimer(r.Context(), userID, attemptID, body); err != nil {
-		slog.Error("Failed to update attempt timer", "error", err)
+		if errors.Is(err, ErrAttemptNotFound) {
+			utils.NotFound(w, "In-progress attempt not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update attempt timer", "error", err)
 		utils.InternalServerError(w, "Failed to update attempt timer")
 		return
 	}
@@ -237,7 +255,7 @@ func (h *handler) UpdateAttemptTimer(w http.ResponseWriter, r *http.Request) {
 func (h *handler) CompleteAttempt(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -251,15 +269,19 @@ func (h *handler) CompleteAttempt(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body CompleteAttemptBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	attempt, err := h.service.CompleteAttempt(r.Context(), userID, attemptID, body)
 	if err != nil {
-		slog.Error("Failed to complete attempt", "error", err)
+		if errors.Is(err, ErrAttemptNotFound) {
+			utils.NotFound(w, "In-progress attempt not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to complete attempt", "error", err)
 		utils.InternalServerError(w, "Failed to complete attempt")
 		return
 	}
@@ -269,7 +291,7 @@ func (h *handler) CompleteAttempt(w http.ResponseWriter, r *http.Request) {
 
 // AbandonAttempt marks an in-progress attempt as abandoned
 func (h *handler) AbandonAttempt(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -283,7 +305,11 @@ func (h *handler) AbandonAttempt(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.AbandonAttempt(r.Context(), userID, attemptID); err != nil {
-		slog.Error("Failed to abandon attempt", "error", err)
+		if errors.Is(err, ErrAttemptNotFound) {
+			utils.NotFound(w, "In-progress attempt not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to abandon attempt", "error", err)
 		utils.InternalServerError(w, "Failed to abandon attempt")
 		return
 	}
@@ -292,3 +318,52 @@ func (h *handler) AbandonAttempt(w http.ResponseWriter, r *http.Request) {
 		"message": "Attempt abandoned successfully",
 	})
 }
+
+// Stream - GET /api/v1/attempts/stream (SSE endpoint)
+// Pushes the user's attempt and session timer changes, so a timer started on
+// one device shows up on the others without polling
+func (h *handler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Get flusher for streaming
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.InternalServerError(w, "Streaming not supported")
+		return
+	}
+
+	// The stream stays open far longer than the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(r.Context()).Warn("Failed to clear write deadline for timer stream", "error", err)
+	}
+
+	stream, unsubscribe := h.hub.Subscribe(userID)
+	defer unsubscribe()
+
+	// Set SSE headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	utils.SendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Client went away, or the server is shutting down
+			return
+		case event := <-stream:
+			utils.SendSSEEvent(w, flusher, event.Type, event.Data)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
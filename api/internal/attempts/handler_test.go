@@ -0,0 +1,161 @@
+package attempts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// handlerService answers the handler's service calls with a fixed result and
+// counts them
+type handlerService struct {
+	Service
+	created int
+}
+
+func (f *handlerService) CreateAttempt(ctx context.Context, userID uuid.UUID, body CreateAttemptBody) (*AttemptResponse, error) {
+	f.created++
+	return &AttemptResponse{ProblemID: body.ProblemID}, nil
+}
+
+// serve runs one handler method as an authenticated user
+func serve(h http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(auth.WithUser(req.Context(), uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// serveID runs one handler method for a request to /{id}, as an authenticated
+// user
+func serveID(h http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	req = req.WithContext(auth.WithUser(ctx, uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestCreateAttemptValidation(t *testing.T) {
+	problemID := uuid.NewString()
+
+	tests := []struct {
+		name   string
+		body   string
+		fields []utils.FieldError
+	}{
+		{
+			name: "confidence out of range and no outcome",
+			body: `{"problem_id": "` + problemID + `", "confidence_score": 900}`,
+			fields: []utils.FieldError{
+				{Field: "confidence_score", Rule: "lte", Param: "100", Value: float64(900)},
+				{Field: "outcome", Rule: "required", Value: ""},
+			},
+		},
+		{
+			name: "unknown outcome",
+			body: `{"problem_id": "` + problemID + `", "confidence_score": 50, "outcome": "maybe"}`,
+			fields: []utils.FieldError{
+				{Field: "outcome", Rule: "oneof", Param: "passed failed", Value: "maybe"},
+			},
+		},
+		{
+			name: "malformed ids and a negative duration",
+			body: `{"problem_id": "two-sum", "session_id": "abc", "confidence_score": 50, "outcome": "passed", "duration_seconds": -5}`,
+			fields: []utils.FieldError{
+				{Field: "problem_id", Rule: "uuid", Value: "two-sum"},
+				{Field: "session_id", Rule: "uuid", Value: "abc"},
+				{Field: "duration_seconds", Rule: "gte", Param: "0", Value: float64(-5)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &handlerService{}
+			rec := serve(NewHandler(f, nil).CreateAttempt, http.MethodPost, "/api/v1/attempts", tt.body)
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+			}
+			apiErr := testutil.ErrorResponse(t, rec)
+			if apiErr.Code != utils.ErrCodeValidation {
+				t.Errorf("error code = %q, want %q", apiErr.Code, utils.ErrCodeValidation)
+			}
+			var fields []utils.FieldError
+			testutil.DecodeDetails(t, apiErr.Details, &fields)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("details = %+v, want %+v", fields, tt.fields)
+			}
+			if f.created != 0 {
+				t.Errorf("service called with an invalid body")
+			}
+		})
+	}
+
+	t.Run("valid body", func(t *testing.T) {
+		f := &handlerService{}
+		rec := serve(NewHandler(f, nil).CreateAttempt, http.MethodPost, "/api/v1/attempts",
+			`{"problem_id": "`+problemID+`", "confidence_score": 100, "outcome": "passed", "duration_seconds": 0}`)
+		if rec.Code != http.StatusCreated || f.created != 1 {
+			t.Errorf("status = %d with %d service calls, want %d and 1", rec.Code, f.created, http.StatusCreated)
+		}
+	})
+}
+
+// lookupRepo fails the attempt lookup with err
+type lookupRepo struct {
+	*testutil.Querier
+	err error
+}
+
+func (f *lookupRepo) GetAttemptById(ctx context.Context, arg repo.GetAttemptByIdParams) (repo.GetAttemptByIdRow, error) {
+	return repo.GetAttemptByIdRow{}, f.err
+}
+
+// A missing row is a 404; any other repo failure stays a 500
+func TestGetAttemptNotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		repoErr error
+		status  int
+		code    string
+	}{
+		{"missing", uuid.NewString(), pgx.ErrNoRows, http.StatusNotFound, utils.ErrCodeNotFound},
+		{"database failure", uuid.NewString(), errors.New("connection refused"), http.StatusInternalServerError, utils.ErrCodeInternalServer},
+		{"malformed id", "42", nil, http.StatusBadRequest, utils.ErrCodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &lookupRepo{Querier: testutil.NewQuerier(), err: tt.repoErr}
+			s := NewService(f, testutil.Transactor{Q: f}, nil, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+			rec := serveID(NewHandler(s, nil).GetAttemptByID, http.MethodGet, tt.id)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if code := testutil.ErrorResponse(t, rec).Code; code != tt.code {
+				t.Errorf("error code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
@@ -10,10 +10,20 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/metrics"
 	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
 )
 
+// ErrAttemptNotFound covers both unknown attempts and, for the timer
+// endpoints, attempts that are no longer in progress
+var ErrAttemptNotFound = fmt.Errorf("attempt %w", utils.ErrNotFound)
+
 type Service interface {
 	CreateAttempt(ctx context.Context, userID uuid.UUID, body CreateAttemptBody) (*AttemptResponse, error)
 	ListAttemptsForUser(ctx context.Context, userID uuid.UUID, limit, offset int32) ([]AttemptResponse, error)
@@ -26,17 +36,28 @@ type Service interface {
 	UpdateAttemptTimer(ctx context.Context, userID uuid.UUID, attemptID uuid.UUID, body UpdateAttemptTimerBody) error
 	CompleteAttempt(ctx context.Context, userID uuid.UUID, attemptID uuid.UUID, body CompleteAttemptBody) (*AttemptResponse, error)
 	AbandonAttempt(ctx context.Context, userID uuid.UUID, attemptID uuid.UUID) error
+
+	// Maintenance
+	AbandonStaleAttempts(ctx context.Context, idleFor time.Duration) (int64, error)
 }
 
 type attemptService struct {
 	repo           repo.Querier
+	tx             postgres.Transactor // Attempts and the stats derived from them are written together
 	scoringService scoring.Service
+	metrics        metrics.Recorder
+	events         events.Publisher // Timer changes, for the user's other open clients
+	webhooks       webhooks.Notifier
 }
 
-func NewService(repo repo.Querier, scoringService scoring.Service) Service {
+func NewService(repo repo.Querier, tx postgres.Transactor, scoringService scoring.Service, recorder metrics.Recorder, publisher events.Publisher, notifier webhooks.Notifier) Service {
 	return &attemptService{
 		repo:           repo,
+		tx:             tx,
 		scoringService: scoringService,
+		metrics:        recorder,
+		events:         publisher,
+		webhooks:       notifier,
 	}
 }
 
@@ -63,33 +84,35 @@ func (s *attemptService) CreateAttempt(ctx context.Context, userID uuid.UUID, bo
 		performedAtVal = *body.PerformedAt
 	}
 
-	attempt, err := s.repo.CreateAttempt(ctx, repo.CreateAttemptParams{
-		UserID:          userID,
-		ProblemID:       problemID,
-		SessionID:       sessionID,
-		ConfidenceScore: toPgInt4(&body.ConfidenceScore),
-		DurationSeconds: toPgInt4FromPtr(body.DurationSeconds),
-		Outcome:         toPgText(&body.Outcome),
-		Notes:           toPgTextFromPtr(body.Notes),
-		Column8:         performedAtVal,
+	var attempt repo.Attempt
+	err = s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		attempt, err = q.CreateAttempt(ctx, repo.CreateAttemptParams{
+			UserID:          userID,
+			ProblemID:       problemID,
+			SessionID:       sessionID,
+			ConfidenceScore: toPgInt4(&body.ConfidenceScore),
+			DurationSeconds: toPgInt4FromPtr(body.DurationSeconds),
+			Outcome:         toPgText(&body.Outcome),
+			Notes:           toPgTextFromPtr(body.Notes),
+			Column8:         performedAtVal,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create attempt: %w", err)
+		}
+		return s.updateStats(ctx, q, userID, problemID)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create attempt: %w", err)
+		return nil, err
 	}
+	s.metrics.AttemptCreated()
 
-	// Update user problem stats
-	if err := s.updateUserProblemStats(ctx, userID, problemID); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: failed to update user problem stats: %v\n", err)
+	// Refresh the cached score now that stats have changed
+	if err := s.scoringService.RefreshScore(ctx, userID, problemID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to refresh cached score", "error", err)
 	}
 
-	// Update user pattern stats
-	if err := s.updateUserPatternStats(ctx, userID, problemID); err != nil {
-		// Log error but don't fail the request
-		fmt.Printf("Warning: failed to update user pattern stats: %v\n", err)
-	}
-
-	return &AttemptResponse{
+	response := &AttemptResponse{
 		ID:              attempt.ID.String(),
 		UserID:          attempt.UserID.String(),
 		ProblemID:       attempt.ProblemID.String(),
@@ -99,7 +122,9 @@ func (s *attemptService) CreateAttempt(ctx context.Context, userID uuid.UUID, bo
 		Outcome:         pgTextToStr(attempt.Outcome, ""),
 		Notes:           pgTextToPtr(attempt.Notes),
 		PerformedAt:     pgTimestamptzToStr(attempt.PerformedAt, ""),
-	}, nil
+	}
+	s.webhooks.Notify(ctx, userID, webhooks.EventAttemptCompleted, response)
+	return response, nil
 }
 
 func (s *attemptService) ListAttemptsForUser(ctx context.Context, userID uuid.UUID, limit, offset int32) ([]AttemptResponse, error) {
@@ -159,10 +184,22 @@ func (s *attemptService) ListAttemptsForProblem(ctx context.Context, userID uuid
 	return attempts, nil
 }
 
+// updateStats recomputes the problem and pattern stats an attempt feeds into.
+// It runs in the attempt's transaction so a failure leaves no half-counted attempt.
+func (s *attemptService) updateStats(ctx context.Context, q repo.Querier, userID uuid.UUID, problemID uuid.UUID) error {
+	if err := s.updateUserProblemStats(ctx, q, userID, problemID); err != nil {
+		return fmt.Errorf("failed to update user problem stats: %w", err)
+	}
+	if err := s.updateUserPatternStats(ctx, q, userID, problemID); err != nil {
+		return fmt.Errorf("failed to update user pattern stats: %w", err)
+	}
+	return nil
+}
+
 // updateUserProblemStats aggregates data from all attempts and updates stats
-func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) error {
+func (s *attemptService) updateUserProblemStats(ctx context.Context, q repo.Querier, userID uuid.UUID, problemID uuid.UUID) error {
 	// Get all attempts for this problem
-	attempts, err := s.repo.ListAttemptsForProblem(ctx, repo.ListAttemptsForProblemParams{
+	attempts, err := q.ListAttemptsForProblem(ctx, repo.ListAttemptsForProblemParams{
 		UserID:    userID,
 		ProblemID: problemID,
 	})
@@ -202,6 +239,8 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 		avgTimeSeconds = &avg
 	}
 
+	consecutiveFailures := countConsecutiveFailures(attempts)
+
 	// Determine status
 	status := "unsolved"
 	if passedCount > 0 {
@@ -220,7 +259,7 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 	recentHistoryJSON, _ := json.Marshal(recentHistory)
 
 	// Get existing stats for spaced repetition data
-	existingStats, err := s.repo.GetUserProblemStats(ctx, repo.GetUserProblemStatsParams{
+	existingStats, err := q.GetUserProblemStats(ctx, repo.GetUserProblemStatsParams{
 		UserID:    userID,
 		ProblemID: problemID,
 	})
@@ -231,6 +270,11 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 	var reviewCount int
 
 	if err == nil {
+		// A status the user set by hand survives further attempts
+		if existingStats.Status.String == "mastered" || existingStats.Status.String == "abandoned" {
+			status = existingStats.Status.String
+		}
+
 		// Use existing values
 		currentInterval = int(existingStats.IntervalDays.Int32)
 		easeFactor = float64(existingStats.EaseFactor.Float32)
@@ -242,6 +286,15 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 		reviewCount = 0
 	}
 
+	// Slow or fast solves relative to the difficulty's expected time nudge the interval
+	timing := scoring.SolveTiming{
+		DurationSeconds: int(pgInt4ToInt64(attempts[0].DurationSeconds, 0)),
+	}
+	if problem, err := q.GetProblem(ctx, problemID); err == nil {
+		timing.ExpectedSeconds = scoring.ExpectedSolveSeconds(pgTextToStr(problem.Difficulty, "medium"))
+		timing.SlowMultiplier, timing.FastMultiplier = s.scoringService.GetSolveTimeThresholds(ctx)
+	}
+
 	// Calculate next review using SM-2 algorithm
 	newInterval, newEaseFactor, nextReviewDate := s.scoringService.CalculateNextReview(
 		lastOutcome,
@@ -249,6 +302,7 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 		currentInterval,
 		easeFactor,
 		reviewCount,
+		timing,
 	)
 
 	nextReviewTimestamp := pgtype.Timestamptz{Time: nextReviewDate, Valid: true}
@@ -258,30 +312,56 @@ func (s *attemptService) updateUserProblemStats(ctx context.Context, userID uuid
 	}
 
 	// Upsert stats with spaced repetition data
-	_, err = s.repo.UpsertUserProblemStats(ctx, repo.UpsertUserProblemStatsParams{
-		UserID:            userID,
-		ProblemID:         problemID,
-		Status:            toPgText(&status),
-		Confidence:        toPgInt4(&latestConfidence),
-		AvgConfidence:     toPgInt4(&avgConfidence),
-		LastAttemptAt:     lastAttemptTimestamp,
-		TotalAttempts:     pgtype.Int4{Int32: int32(len(attempts)), Valid: true},
-		AvgTimeSeconds:    toPgInt4FromPtr(avgTimeSeconds),
-		LastOutcome:       toPgText(&lastOutcome),
-		RecentHistoryJson: toPgText(strPtr(string(recentHistoryJSON))),
-		NextReviewAt:      nextReviewTimestamp,
-		IntervalDays:      pgtype.Int4{Int32: int32(newInterval), Valid: true},
-		EaseFactor:        pgtype.Float4{Float32: float32(newEaseFactor), Valid: true},
-		ReviewCount:       pgtype.Int4{Int32: int32(reviewCount + 1), Valid: true},
+	_, err = q.UpsertUserProblemStats(ctx, repo.UpsertUserProblemStatsParams{
+		UserID:              userID,
+		ProblemID:           problemID,
+		Status:              toPgText(&status),
+		Confidence:          toPgInt4(&latestConfidence),
+		AvgConfidence:       toPgInt4(&avgConfidence),
+		LastAttemptAt:       lastAttemptTimestamp,
+		TotalAttempts:       pgtype.Int4{Int32: int32(len(attempts)), Valid: true},
+		AvgTimeSeconds:      toPgInt4FromPtr(avgTimeSeconds),
+		LastOutcome:         toPgText(&lastOutcome),
+		RecentHistoryJson:   toPgText(strPtr(string(recentHistoryJSON))),
+		NextReviewAt:        nextReviewTimestamp,
+		IntervalDays:        pgtype.Int4{Int32: int32(newInterval), Valid: true},
+		EaseFactor:          pgtype.Float4{Float32: float32(newEaseFactor), Valid: true},
+		ReviewCount:         pgtype.Int4{Int32: int32(reviewCount + 1), Valid: true},
+		ConsecutiveFailures: pgtype.Int4{Int32: int32(consecutiveFailures), Valid: true},
 	})
 
 	return err
 }
 
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// countConsecutiveFailures counts failed outcomes in a row starting from the
+// most recent attempt. A pass resets the streak, so this is 0 unless the
+// latest attempt failed. Attempts must be ordered newest first.
+func countConsecutiveFailures(attempts []repo.Attempt) int {
+	count := 0
+	for _, attempt := range attempts {
+		// Timer attempts that were never completed carry no outcome
+		if attempt.Status.Valid && (attempt.Status.String == "in_progress" || attempt.Status.String == "abandoned") {
+			continue
+		}
+		if !attempt.Outcome.Valid || attempt.Outcome.String != "failed" {
+			break
+		}
+		count++
+	}
+	return count
+}
+
 // updateUserPatternStats updates pattern-level statistics for all patterns linked to the problem
-func (s *attemptService) updateUserPatternStats(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) error {
+func (s *attemptService) updateUserPatternStats(ctx context.Context, q repo.Querier, userID uuid.UUID, problemID uuid.UUID) error {
 	// Get all patterns linked to this problem
-	patterns, err := s.repo.GetPatternsForProblem(ctx, problemID)
+	patterns, err := q.GetPatternsForProblem(ctx, problemID)
 	if err != nil {
 		return fmt.Errorf("failed to get patterns: %w", err)
 	}
@@ -289,7 +369,7 @@ func (s *attemptService) updateUserPatternStats(ctx context.Context, userID uuid
 	// For each pattern, get all problems with that pattern and calculate stats
 	for _, pattern := range patterns {
 		// Get all problems with this pattern
-		problems, err := s.repo.GetProblemsForPattern(ctx, pattern.ID)
+		problems, err := q.GetProblemsForPattern(ctx, pattern.ID)
 		if err != nil {
 			continue
 		}
@@ -301,7 +381,7 @@ func (s *attemptService) updateUserPatternStats(ctx context.Context, userID uuid
 
 		for _, problem := range problems {
 			// Get user problem stats for this problem
-			stats, err := s.repo.GetUserProblemStats(ctx, repo.GetUserProblemStatsParams{
+			stats, err := q.GetUserProblemStats(ctx, repo.GetUserProblemStatsParams{
 				UserID:    userID,
 				ProblemID: problem.ID,
 			})
@@ -325,15 +405,41 @@ func (s *attemptService) updateUserPatternStats(ctx context.Context, userID uuid
 			avgConfidence = totalConfidence / problemCount
 		}
 
+		previous, prevErr := q.GetUserPatternStats(ctx, repo.GetUserPatternStatsParams{
+			UserID:    userID,
+			PatternID: pattern.ID,
+		})
+
 		// Upsert pattern stats
-		_, err = s.repo.UpsertUserPatternStats(ctx, repo.UpsertUserPatternStatsParams{
+		_, err = q.UpsertUserPatternStats(ctx, repo.UpsertUserPatternStatsParams{
 			UserID:        userID,
 			PatternID:     pattern.ID,
 			AvgConfidence: toPgInt4(&avgConfidence),
 			TimesRevised:  toPgInt4(&totalRevisions),
 		})
 		if err != nil {
-			fmt.Printf("Warning: failed to update pattern stats for pattern %s: %v\n", pattern.ID.String(), err)
+			logging.FromContext(ctx).Warn("Failed to update pattern stats", "pattern_id", pattern.ID, "error", err)
+			continue
+		}
+
+		// The pattern's other problems cached an f_pattern from the old stats
+		if err := q.DeleteUserProblemScoresForPattern(ctx, repo.DeleteUserProblemScoresForPatternParams{
+			UserID:    userID,
+			PatternID: pattern.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to invalidate pattern scores: %w", err)
+		}
+
+		// Record a history point when the average moved by more than a point
+		if prevErr != nil || abs(avgConfidence-int64(previous.AvgConfidence.Int32)) > 1 {
+			if err := q.UpsertPatternStatsSnapshot(ctx, repo.UpsertPatternStatsSnapshotParams{
+				UserID:        userID,
+				PatternID:     pattern.ID,
+				AvgConfidence: int32(avgConfidence),
+				TimesRevised:  int32(totalRevisions),
+			}); err != nil {
+				logging.FromContext(ctx).Warn("Failed to snapshot pattern stats", "pattern_id", pattern.ID, "error", err)
+			}
 		}
 	}
 
@@ -466,6 +572,16 @@ func (s *attemptService) StartAttempt(ctx context.Context, userID uuid.UUID, bod
 	if err != nil {
 		return nil, fmt.Errorf("failed to create in-progress attempt: %w", err)
 	}
+	s.metrics.AttemptCreated()
+	s.events.Publish(userID, events.Event{Type: events.AttemptStarted, Data: TimerEvent{
+		AttemptID:          attempt.ID.String(),
+		ProblemID:          attempt.ProblemID.String(),
+		SessionID:          pgUUIDToPtr(attempt.SessionID),
+		Status:             pgTextToStr(attempt.Status, "in_progress"),
+		ElapsedTimeSeconds: pgInt4ToInt64(attempt.ElapsedTimeSeconds, 0),
+		TimerState:         pgTextToStr(attempt.TimerState, "idle"),
+		UpdatedAt:          pgTimestamptzToStr(attempt.StartedAt, ""),
+	}})
 
 	// Get problem details for the response
 	problem, err := s.repo.GetProblem(ctx, problemID)
@@ -535,7 +651,7 @@ func (s *attemptService) GetAttemptByID(ctx context.Context, userID uuid.UUID, a
 	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("attempt not found")
+			return nil, ErrAttemptNotFound
 		}
 		return nil, fmt.Errorf("failed to get attempt: %w", err)
 	}
@@ -559,7 +675,7 @@ func (s *attemptService) GetAttemptByID(ctx context.Context, userID uuid.UUID, a
 func (s *attemptService) UpdateAttemptTimer(ctx context.Context, userID uuid.UUID, attemptID uuid.UUID, body UpdateAttemptTimerBody) error {
 	now := pgtype.Timestamptz{Time: time.Now().UTC(), Valid: true}
 
-	err := s.repo.UpdateAttemptTimer(ctx, repo.UpdateAttemptTimerParams{
+	updated, err := s.repo.UpdateAttemptTimer(ctx, repo.UpdateAttemptTimerParams{
 		ElapsedTimeSeconds: pgtype.Int4{Int32: int32(body.ElapsedTimeSeconds), Valid: true},
 		TimerState:         pgtype.Text{String: body.TimerState, Valid: true},
 		TimerLastUpdatedAt: now,
@@ -569,7 +685,17 @@ func (s *attemptService) UpdateAttemptTimer(ctx context.Context, userID uuid.UUI
 	if err != nil {
 		return fmt.Errorf("failed to update attempt timer: %w", err)
 	}
+	if updated == 0 {
+		return ErrAttemptNotFound
+	}
 
+	s.events.Publish(userID, events.Event{Type: events.AttemptTimer, Data: TimerEvent{
+		AttemptID:          attemptID.String(),
+		Status:             "in_progress",
+		ElapsedTimeSeconds: body.ElapsedTimeSeconds,
+		TimerState:         body.TimerState,
+		UpdatedAt:          now.Time.Format(time.RFC3339),
+	}})
 	return nil
 }
 
@@ -581,6 +707,9 @@ func (s *attemptService) CompleteAttempt(ctx context.Context, userID uuid.UUID,
 		UserID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrAttemptNotFound
+		}
 		return nil, fmt.Errorf("failed to get attempt: %w", err)
 	}
 
@@ -592,29 +721,45 @@ func (s *attemptService) CompleteAttempt(ctx context.Context, userID uuid.UUID,
 		durationSeconds = pgInt4ToInt64(existingAttempt.ElapsedTimeSeconds, 0)
 	}
 
-	attempt, err := s.repo.CompleteAttempt(ctx, repo.CompleteAttemptParams{
-		ConfidenceScore: pgtype.Int4{Int32: int32(body.ConfidenceScore), Valid: true},
-		DurationSeconds: pgtype.Int4{Int32: int32(durationSeconds), Valid: true},
-		Outcome:         pgtype.Text{String: body.Outcome, Valid: true},
-		Notes:           toPgTextFromPtr(body.Notes),
-		ID:              attemptID,
-		UserID:          userID,
+	var attempt repo.Attempt
+	err = s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		attempt, err = q.CompleteAttempt(ctx, repo.CompleteAttemptParams{
+			ConfidenceScore: pgtype.Int4{Int32: int32(body.ConfidenceScore), Valid: true},
+			DurationSeconds: pgtype.Int4{Int32: int32(durationSeconds), Valid: true},
+			Outcome:         pgtype.Text{String: body.Outcome, Valid: true},
+			Notes:           toPgTextFromPtr(body.Notes),
+			ID:              attemptID,
+			UserID:          userID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrAttemptNotFound
+			}
+			return fmt.Errorf("failed to complete attempt: %w", err)
+		}
+		return s.updateStats(ctx, q, userID, attempt.ProblemID)
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to complete attempt: %w", err)
+		return nil, err
 	}
 
-	// Update user problem stats
-	if err := s.updateUserProblemStats(ctx, userID, attempt.ProblemID); err != nil {
-		fmt.Printf("Warning: failed to update user problem stats: %v\n", err)
-	}
+	s.events.Publish(userID, events.Event{Type: events.AttemptCompleted, Data: TimerEvent{
+		AttemptID:          attempt.ID.String(),
+		ProblemID:          attempt.ProblemID.String(),
+		SessionID:          pgUUIDToPtr(attempt.SessionID),
+		Status:             "completed",
+		ElapsedTimeSeconds: durationSeconds,
+		TimerState:         "idle",
+		UpdatedAt:          time.Now().UTC().Format(time.RFC3339),
+	}})
 
-	// Update user pattern stats
-	if err := s.updateUserPatternStats(ctx, userID, attempt.ProblemID); err != nil {
-		fmt.Printf("Warning: failed to update user pattern stats: %v\n", err)
+	// Refresh the cached score now that stats have changed
+	if err := s.scoringService.RefreshScore(ctx, userID, attempt.ProblemID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to refresh cached score", "error", err)
 	}
 
-	return &AttemptResponse{
+	response := &AttemptResponse{
 		ID:              attempt.ID.String(),
 		UserID:          attempt.UserID.String(),
 		ProblemID:       attempt.ProblemID.String(),
@@ -624,18 +769,54 @@ func (s *attemptService) CompleteAttempt(ctx context.Context, userID uuid.UUID,
 		Outcome:         pgTextToStr(attempt.Outcome, ""),
 		Notes:           pgTextToPtr(attempt.Notes),
 		PerformedAt:     pgTimestamptzToStr(attempt.PerformedAt, ""),
-	}, nil
+	}
+	s.webhooks.Notify(ctx, userID, webhooks.EventAttemptCompleted, response)
+	return response, nil
 }
 
 // AbandonAttempt marks an in-progress attempt as abandoned
 func (s *attemptService) AbandonAttempt(ctx context.Context, userID uuid.UUID, attemptID uuid.UUID) error {
-	err := s.repo.AbandonAttempt(ctx, repo.AbandonAttemptParams{
+	abandoned, err := s.repo.AbandonAttempt(ctx, repo.AbandonAttemptParams{
 		ID:     attemptID,
 		UserID: userID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to abandon attempt: %w", err)
 	}
+	if abandoned == 0 {
+		return ErrAttemptNotFound
+	}
 
+	s.events.Publish(userID, events.Event{Type: events.AttemptAbandoned, Data: TimerEvent{
+		AttemptID:  attemptID.String(),
+		Status:     "abandoned",
+		TimerState: "idle",
+		UpdatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}})
 	return nil
 }
+
+// AbandonStaleAttempts abandons every user's in-progress attempts whose timer
+// hasn't synced for idleFor. Their clients are long gone, so no events are
+// sent, but the owners' data versions are bumped so cached lists refetch.
+func (s *attemptService) AbandonStaleAttempts(ctx context.Context, idleFor time.Duration) (int64, error) {
+	var abandoned int64
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		userIDs, err := q.AbandonStaleAttempts(ctx, time.Now().Add(-idleFor))
+		if err != nil {
+			return fmt.Errorf("failed to abandon stale attempts: %w", err)
+		}
+		abandoned = int64(len(userIDs))
+		if abandoned == 0 {
+			return nil
+		}
+		if err := q.BumpUserDataVersions(ctx, userIDs); err != nil {
+			return fmt.Errorf("failed to bump data versions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return abandoned, nil
+}
@@ -0,0 +1,264 @@
+package attempts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// statsRepo serves one problem's attempts and existing stats, and records the
+// stats written back
+type statsRepo struct {
+	*testutil.Querier
+	problem  repo.Problem
+	attempts []repo.Attempt
+	existing *repo.UserProblemStat
+}
+
+func (f *statsRepo) ListAttemptsForProblem(ctx context.Context, arg repo.ListAttemptsForProblemParams) ([]repo.Attempt, error) {
+	f.Record("ListAttemptsForProblem", arg)
+	return f.attempts, nil
+}
+
+func (f *statsRepo) GetUserProblemStats(ctx context.Context, arg repo.GetUserProblemStatsParams) (repo.UserProblemStat, error) {
+	f.Record("GetUserProblemStats", arg)
+	if f.existing == nil {
+		return repo.UserProblemStat{}, errors.New("no rows")
+	}
+	return *f.existing, nil
+}
+
+func (f *statsRepo) GetProblem(ctx context.Context, id uuid.UUID) (repo.Problem, error) {
+	f.Record("GetProblem", id)
+	return f.problem, nil
+}
+
+// GetSolveTimeSettings fails so scoring falls back to its default multipliers
+func (f *statsRepo) GetSolveTimeSettings(ctx context.Context) ([]repo.GetSolveTimeSettingsRow, error) {
+	return nil, errors.New("no settings")
+}
+
+func (f *statsRepo) UpsertUserProblemStats(ctx context.Context, arg repo.UpsertUserProblemStatsParams) (repo.UserProblemStat, error) {
+	f.Record("UpsertUserProblemStats", arg)
+	return repo.UserProblemStat{}, nil
+}
+
+func newStatsService(f *statsRepo) *attemptService {
+	return NewService(f, testutil.Transactor{Q: f}, scoring.NewService(f), metrics.Noop{}, events.Noop{}, webhooks.Noop{}).(*attemptService)
+}
+
+func TestUpdateUserProblemStats(t *testing.T) {
+	userID := uuid.New()
+	problem := testutil.Problem("Two Sum", "easy")
+
+	mastered := testutil.ProblemStats(userID, problem.ID, 90, 3)
+	mastered.Status = pgtype.Text{String: "mastered", Valid: true}
+	mastered.ReviewCount = pgtype.Int4{Int32: 4, Valid: true}
+	mastered.IntervalDays = pgtype.Int4{Int32: 10, Valid: true}
+
+	tests := []struct {
+		name     string
+		outcomes []string
+		existing *repo.UserProblemStat
+
+		status        string
+		totalAttempts int32
+		confidence    int32
+		avgConfidence int32
+		failures      int32
+		reviewCount   int32
+		lastOutcome   string
+	}{
+		{
+			name:          "first pass solves the problem",
+			outcomes:      []string{"passed"},
+			status:        "solved",
+			totalAttempts: 1,
+			confidence:    80,
+			avgConfidence: 80,
+			reviewCount:   1,
+			lastOutcome:   "passed",
+		},
+		{
+			name:          "failures only leave it unsolved and count the streak",
+			outcomes:      []string{"failed", "failed"},
+			status:        "unsolved",
+			totalAttempts: 2,
+			confidence:    30,
+			avgConfidence: 30,
+			failures:      2,
+			reviewCount:   1,
+			lastOutcome:   "failed",
+		},
+		{
+			name:          "a pass anywhere in the history solves it",
+			outcomes:      []string{"passed", "failed"},
+			status:        "solved",
+			totalAttempts: 2,
+			confidence:    30,
+			avgConfidence: 55,
+			failures:      1,
+			reviewCount:   1,
+			lastOutcome:   "failed",
+		},
+		{
+			name:          "a manual status survives and the review count carries on",
+			outcomes:      []string{"failed", "passed"},
+			existing:      &mastered,
+			status:        "mastered",
+			totalAttempts: 2,
+			confidence:    80,
+			avgConfidence: 55,
+			reviewCount:   5,
+			lastOutcome:   "passed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &statsRepo{
+				Querier:  testutil.NewQuerier(),
+				problem:  problem,
+				attempts: testutil.Attempts(userID, problem.ID, tt.outcomes...),
+				existing: tt.existing,
+			}
+			if err := newStatsService(f).updateUserProblemStats(context.Background(), f, userID, problem.ID); err != nil {
+				t.Fatalf("updateUserProblemStats: %v", err)
+			}
+
+			upserts := f.CallsTo("UpsertUserProblemStats")
+			if len(upserts) != 1 {
+				t.Fatalf("UpsertUserProblemStats called %d times, want 1", len(upserts))
+			}
+			got := upserts[0].(repo.UpsertUserProblemStatsParams)
+
+			if got.Status.String != tt.status {
+				t.Errorf("status = %q, want %q", got.Status.String, tt.status)
+			}
+			if got.TotalAttempts.Int32 != tt.totalAttempts {
+				t.Errorf("total attempts = %d, want %d", got.TotalAttempts.Int32, tt.totalAttempts)
+			}
+			if got.Confidence.Int32 != tt.confidence {
+				t.Errorf("confidence = %d, want %d", got.Confidence.Int32, tt.confidence)
+			}
+			if got.AvgConfidence.Int32 != tt.avgConfidence {
+				t.Errorf("avg confidence = %d, want %d", got.AvgConfidence.Int32, tt.avgConfidence)
+			}
+			if got.ConsecutiveFailures.Int32 != tt.failures {
+				t.Errorf("consecutive failures = %d, want %d", got.ConsecutiveFailures.Int32, tt.failures)
+			}
+			if got.ReviewCount.Int32 != tt.reviewCount {
+				t.Errorf("review count = %d, want %d", got.ReviewCount.Int32, tt.reviewCount)
+			}
+			if got.LastOutcome.String != tt.lastOutcome {
+				t.Errorf("last outcome = %q, want %q", got.LastOutcome.String, tt.lastOutcome)
+			}
+			if got.LastAttemptAt != f.attempts[0].PerformedAt {
+				t.Errorf("last attempt at = %v, want the newest attempt's %v", got.LastAttemptAt.Time, f.attempts[0].PerformedAt.Time)
+			}
+			if got.AvgTimeSeconds.Int32 != 20*60 {
+				t.Errorf("avg time = %d, want %d", got.AvgTimeSeconds.Int32, 20*60)
+			}
+			if !got.NextReviewAt.Valid || !got.NextReviewAt.Time.After(testutil.Now) {
+				t.Errorf("next review at = %v, want a date in the future", got.NextReviewAt)
+			}
+		})
+	}
+}
+
+func TestUpdateUserProblemStatsWithoutAttempts(t *testing.T) {
+	f := &statsRepo{Querier: testutil.NewQuerier()}
+	if err := newStatsService(f).updateUserProblemStats(context.Background(), f, uuid.New(), uuid.New()); err != nil {
+		t.Fatalf("updateUserProblemStats: %v", err)
+	}
+	if n := len(f.CallsTo("UpsertUserProblemStats")); n != 0 {
+		t.Errorf("UpsertUserProblemStats called %d times, want 0", n)
+	}
+}
+
+func TestUpdateUserProblemStatsIntervalGrowsWithPasses(t *testing.T) {
+	userID := uuid.New()
+	problem := testutil.Problem("Two Sum", "medium")
+
+	intervalAfter := func(outcome string) int32 {
+		existing := testutil.ProblemStats(userID, problem.ID, 80, 6)
+		existing.IntervalDays = pgtype.Int4{Int32: 6, Valid: true}
+		existing.ReviewCount = pgtype.Int4{Int32: 2, Valid: true}
+		f := &statsRepo{
+			Querier:  testutil.NewQuerier(),
+			problem:  problem,
+			attempts: testutil.Attempts(userID, problem.ID, outcome),
+			existing: &existing,
+		}
+		if err := newStatsService(f).updateUserProblemStats(context.Background(), f, userID, problem.ID); err != nil {
+			t.Fatalf("updateUserProblemStats: %v", err)
+		}
+		return f.CallsTo("UpsertUserProblemStats")[0].(repo.UpsertUserProblemStatsParams).IntervalDays.Int32
+	}
+
+	passed, failed := intervalAfter("passed"), intervalAfter("failed")
+	if passed <= 6 {
+		t.Errorf("interval after a pass = %d, want more than the previous 6", passed)
+	}
+	if failed >= 6 {
+		t.Errorf("interval after a failure = %d, want less than the previous 6", failed)
+	}
+}
+
+func TestCountConsecutiveFailures(t *testing.T) {
+	userID, problemID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		outcomes []string // oldest first
+		want     int
+	}{
+		{"no attempts", nil, 0},
+		{"one failure", []string{"failed"}, 1},
+		{"failures in a row", []string{"failed", "failed", "failed"}, 3},
+		{"a pass resets the count", []string{"failed", "failed", "passed"}, 0},
+		{"failures after a pass", []string{"failed", "passed", "failed", "failed"}, 2},
+		{"only passes", []string{"passed", "passed"}, 0},
+		{"a pass long ago", []string{"passed", "failed", "failed", "failed", "failed"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := testutil.Attempts(userID, problemID, tt.outcomes...)
+			if got := countConsecutiveFailures(attempts); got != tt.want {
+				t.Errorf("countConsecutiveFailures(%v) = %d, want %d", tt.outcomes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountConsecutiveFailuresSkipsUnfinishedAttempts(t *testing.T) {
+	userID, problemID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		status string
+	}{
+		{"in progress", "in_progress"},
+		{"abandoned", "abandoned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Newest first: an unfinished timer attempt, then two failures
+			attempts := testutil.Attempts(userID, problemID, "passed", "failed", "failed", "")
+			attempts[0].Outcome = pgtype.Text{}
+			attempts[0].Status = pgtype.Text{String: tt.status, Valid: true}
+
+			if got := countConsecutiveFailures(attempts); got != 2 {
+				t.Errorf("countConsecutiveFailures = %d, want 2", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package attempts
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// staleIdle is how long an attempt's timer may go unsynced in these tests
+const staleIdle = 24 * time.Hour
+
+// sweepRepo abandons one stale attempt per entry in owners
+type sweepRepo struct {
+	*testutil.Querier
+	owners []uuid.UUID
+}
+
+func (f *sweepRepo) AbandonStaleAttempts(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	return f.owners, nil
+}
+
+func (f *sweepRepo) BumpUserDataVersions(ctx context.Context, userIDs []uuid.UUID) error {
+	f.Record("BumpUserDataVersions", userIDs)
+	return nil
+}
+
+// The sweep runs outside any request, so it bumps the owners' data versions
+// itself for their cached lists to refetch
+func TestAbandonStaleAttemptsBumpsDataVersions(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name   string
+		owners []uuid.UUID
+		bumped [][]uuid.UUID
+	}{
+		{"nothing stale", nil, nil},
+		{"several owners", []uuid.UUID{alice, bob, alice}, [][]uuid.UUID{{alice, bob, alice}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &sweepRepo{Querier: testutil.NewQuerier(), owners: tt.owners}
+			s := NewService(f, testutil.Transactor{Q: f}, nil, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+
+			abandoned, err := s.AbandonStaleAttempts(context.Background(), staleIdle)
+			if err != nil {
+				t.Fatalf("AbandonStaleAttempts: %v", err)
+			}
+			if abandoned != int64(len(tt.owners)) {
+				t.Errorf("abandoned = %d, want %d", abandoned, len(tt.owners))
+			}
+			var bumped [][]uuid.UUID
+			for _, call := range f.CallsTo("BumpUserDataVersions") {
+				bumped = append(bumped, call.([]uuid.UUID))
+			}
+			if !reflect.DeepEqual(bumped, tt.bumped) {
+				t.Errorf("bumped %v, want %v", bumped, tt.bumped)
+			}
+		})
+	}
+}
+
+// Each owner of a stale attempt is bumped once however many they had; users
+// whose attempts are still live are left alone
+func TestAbandonStaleAttemptsAgainstDatabase(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	problem := db.CreateProblem(t, "Two Sum", "easy")
+	stale := db.CreateUser(t, "stale@example.com")
+	live := db.CreateUser(t, "live@example.com")
+
+	for _, attempt := range []struct {
+		userID   uuid.UUID
+		lastSync time.Time
+	}{
+		{stale.ID, time.Now().Add(-2 * staleIdle)},
+		{stale.ID, time.Now().Add(-3 * staleIdle)},
+		{live.ID, time.Now().Add(-time.Minute)},
+	} {
+		if _, err := db.Pool.Exec(ctx,
+			"INSERT INTO attempts (user_id, problem_id, status, timer_state, started_at, timer_last_updated_at) VALUES ($1, $2, 'in_progress', 'running', $3, $3)",
+			attempt.userID, problem.ID, attempt.lastSync,
+		); err != nil {
+			t.Fatalf("insert attempt: %v", err)
+		}
+	}
+
+	s := NewService(db.Queries, db.Transactor, nil, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+	abandoned, err := s.AbandonStaleAttempts(ctx, staleIdle)
+	if err != nil {
+		t.Fatalf("AbandonStaleAttempts: %v", err)
+	}
+	if abandoned != 2 {
+		t.Errorf("abandoned = %d, want 2", abandoned)
+	}
+
+	for userID, want := range map[uuid.UUID]int64{stale.ID: 1, live.ID: 0} {
+		versions, err := db.Queries.GetDataVersions(ctx, userID.String())
+		if err != nil {
+			t.Fatalf("GetDataVersions: %v", err)
+		}
+		if versions.UserVersion != want {
+			t.Errorf("user %s data version = %d, want %d", userID, versions.UserVersion, want)
+		}
+	}
+}
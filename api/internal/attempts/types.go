@@ -4,7 +4,7 @@ package attempts
 type CreateAttemptBody struct {
 	ProblemID       string  `json:"problem_id"       validate:"required,uuid"`
 	SessionID       *string `json:"session_id"       validate:"omitempty,uuid"`
-	ConfidenceScore int64   `json:"confidence_score" validate:"required,gte=0,lte=100"`
+	ConfidenceScore int64   `json:"confidence_score" validate:"gte=0,lte=100"`
 	DurationSeconds *int64  `json:"duration_seconds" validate:"omitempty,gte=0"`
 	Outcome         string  `json:"outcome"          validate:"required,oneof=passed failed"`
 	Notes           *string `json:"notes"            validate:"omitempty"`
@@ -38,13 +38,13 @@ type StartAttemptBody struct {
 
 // UpdateAttemptTimerBody is the request body for updating attempt timer state
 type UpdateAttemptTimerBody struct {
-	ElapsedTimeSeconds int64  `json:"elapsed_time_seconds" validate:"required,gte=0"`
+	ElapsedTimeSeconds int64  `json:"elapsed_time_seconds" validate:"gte=0"`
 	TimerState         string `json:"timer_state"          validate:"required,oneof=idle running paused"`
 }
 
 // CompleteAttemptBody is the request body for completing an in-progress attempt
 type CompleteAttemptBody struct {
-	ConfidenceScore int64   `json:"confidence_score" validate:"required,gte=0,lte=100"`
+	ConfidenceScore int64   `json:"confidence_score" validate:"gte=0,lte=100"`
 	Outcome         string  `json:"outcome"          validate:"required,oneof=passed failed"`
 	Notes           *string `json:"notes"            validate:"omitempty"`
 	DurationSeconds *int64  `json:"duration_seconds" validate:"omitempty,gte=0"` // Optional: override elapsed time
@@ -64,3 +64,15 @@ type InProgressAttemptResponse struct {
 	ProblemTitle       *string `json:"problem_title,omitempty"`
 	ProblemDifficulty  *string `json:"problem_difficulty,omitempty"`
 }
+
+// TimerEvent is the payload of attempt.* events on the timer stream. Fields
+// the change didn't touch, like problem_id on a timer tick, are left out.
+type TimerEvent struct {
+	AttemptID          string  `json:"attempt_id"`
+	ProblemID          string  `json:"problem_id,omitempty"`
+	SessionID          *string `json:"session_id,omitempty"`
+	Status             string  `json:"status"` // in_progress, completed or abandoned
+	ElapsedTimeSeconds int64   `json:"elapsed_time_seconds"`
+	TimerState         string  `json:"timer_state"`
+	UpdatedAt          string  `json:"updated_at"`
+}
@@ -1,8 +1,35 @@
 package auth
 
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
 type contextKey string
 
+// The keys are unexported so handlers go through the helpers below and can't
+// assert the stored values to the wrong type
 const (
-	UserKey contextKey = "userID"
-	RoleKey contextKey = "role"
+	userKey contextKey = "userID"
+	roleKey contextKey = "role"
 )
+
+// WithUser stores the authenticated user's ID and role in ctx
+func WithUser(ctx context.Context, userID uuid.UUID, role string) context.Context {
+	ctx = context.WithValue(ctx, userKey, userID)
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// UserIDFromContext returns the authenticated user's ID; ok is false on
+// routes that don't run AuthTokenMiddleware
+func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(userKey).(uuid.UUID)
+	return userID, ok
+}
+
+// RoleFromContext returns the authenticated user's role, or "" if unset
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
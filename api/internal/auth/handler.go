@@ -1,12 +1,20 @@
 package auth
 
 import (
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
+// RefreshTokenCookie is the name of the cookie holding the raw refresh token
+const RefreshTokenCookie = "refresh_token"
+
 type Handler struct {
 	service Service
 	// Cookie settings based on if prod envirnment or not
@@ -21,29 +29,42 @@ func NewHandler(service Service, isProd bool) *Handler {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	RememberMe bool   `json:"remember_me"`
 }
 
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 	var req LoginRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invaild Request Body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	userAgent := r.UserAgent()
-	ip := r.RemoteAddr
+	ip := clientIP(r)
 
-	accessToken, refreshToken, userData, err := h.service.Login(r.Context(), req.Email, req.Password, userAgent, ip)
+	accessToken, refreshToken, userData, err := h.service.Login(r.Context(), req.Email, req.Password, req.RememberMe, userAgent, ip)
 	if err != nil {
+		var lockout *LockoutError
+		if errors.As(err, &lockout) {
+			retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+			logging.FromContext(r.Context()).Warn("Login locked out", "scope", lockout.Scope, "ip", ip, "email", req.Email, "retry_after_seconds", retryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			utils.TooManyRequests(w, "Too many failed login attempts, try again later")
+			return
+		}
 		utils.Unauthorized(w, "Invalid Credentials")
 		return
 	}
 
 	// Set Cookies
-	h.setTokenCookies(w, accessToken, refreshToken)
+	if err := h.setTokenCookies(w, accessToken, refreshToken); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to issue CSRF token", "error", err)
+		utils.InternalServerError(w, "Failed to start session")
+		return
+	}
 
 	utils.WriteSuccess(w, http.StatusOK, map[string]interface{}{
 		"message": "Login Successful",
@@ -53,30 +74,38 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	// Get refresh token from cookie
-	cookie, err := r.Cookie("refresh_token")
+	cookie, err := r.Cookie(RefreshTokenCookie)
 	if err != nil {
 		utils.Unauthorized(w, "Missing Refresh Token")
 		return
 	}
 
 	// Call service
-	newAccessToken, err := h.service.Refresh(r.Context(), cookie.Value)
+	ip := clientIP(r)
+	newAccessToken, newRefreshToken, err := h.service.Refresh(r.Context(), cookie.Value, r.UserAgent(), ip)
 	if err != nil {
+		if errors.Is(err, ErrTokenReused) {
+			logging.FromContext(r.Context()).Warn("Refresh token reuse detected, token family revoked", "ip", ip)
+		}
 		// If refresh fails, clear cookies so the client knows they are logged out
 		h.clearCookies(w)
 		utils.Unauthorized(w, "Invalid or expired token")
 		return
 	}
 
-	// Set new access token cookie
-	h.setAccessTokenCookie(w, newAccessToken)
+	// The presented refresh token is now spent; replace the cookies
+	if err := h.setTokenCookies(w, newAccessToken, newRefreshToken); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to issue CSRF token", "error", err)
+		utils.InternalServerError(w, "Failed to refresh session")
+		return
+	}
 
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Token refreshed"})
 }
 
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	// Attempt to revoke from DB if cookie exists
-	if cookie, err := r.Cookie("refresh_token"); err == nil {
+	if cookie, err := r.Cookie(RefreshTokenCookie); err == nil {
 		_ = h.service.Logout(r.Context(), cookie.Value)
 	}
 
@@ -85,22 +114,38 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Logged out"})
 }
 
+// clientIP returns the request's IP without the port. RealIP middleware has
+// already replaced RemoteAddr with the forwarded address when one is present.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
 // --- Cookie Helpers ---
 
-func (h *Handler) setTokenCookies(w http.ResponseWriter, access, refresh string) {
+// setTokenCookies sets the access, refresh and CSRF cookies for a session
+func (h *Handler) setTokenCookies(w http.ResponseWriter, access string, refresh IssuedRefreshToken) error {
 	h.setAccessTokenCookie(w, access)
 
-	// Refresh Token: Long lived (30 days)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    refresh,
+	// Refresh Token: the cookie expires with the stored token, or with the
+	// browser session when "remember me" wasn't chosen
+	cookie := &http.Cookie{
+		Name:     RefreshTokenCookie,
+		Value:    refresh.Token,
 		Path:     "/",
-		Expires:  time.Now().Add(30 * 24 * time.Hour),
-		MaxAge:   30 * 24 * 60 * 60,
 		HttpOnly: true,
 		Secure:   h.isProd, // true in production (HTTPS)
 		SameSite: http.SameSiteStrictMode,
-	})
+	}
+	if refresh.Persistent {
+		cookie.Expires = refresh.ExpiresAt
+		cookie.MaxAge = int(time.Until(refresh.ExpiresAt).Seconds())
+	}
+	http.SetCookie(w, cookie)
+
+	return h.setCSRFCookie(w, refresh)
 }
 
 func (h *Handler) setAccessTokenCookie(w http.ResponseWriter, token string) {
@@ -118,6 +163,11 @@ func (h *Handler) setAccessTokenCookie(w http.ResponseWriter, token string) {
 }
 
 func (h *Handler) clearCookies(w http.ResponseWriter) {
+	ClearTokenCookies(w)
+}
+
+// ClearTokenCookies expires the auth and CSRF cookies, logging the browser out
+func ClearTokenCookies(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "access_token",
 		Value:    "",
@@ -128,11 +178,19 @@ func (h *Handler) clearCookies(w http.ResponseWriter) {
 	})
 
 	http.SetCookie(w, &http.Cookie{
-		Name:     "refresh_token",
+		Name:     RefreshTokenCookie,
 		Value:    "",
 		Path:     "/",
 		Expires:  time.Unix(0, 0),
 		MaxAge:   -1,
 		HttpOnly: true,
 	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    CSRFTokenCookie,
+		Value:   "",
+		Path:    "/",
+		Expires: time.Unix(0, 0),
+		MaxAge:  -1,
+	})
 }
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Used when the login rate limit settings are missing or invalid
+const (
+	DefaultLoginMaxFailures    = 5
+	DefaultLoginLockoutWindow  = 15 * time.Minute
+	loginAttemptSweepThreshold = 1024
+)
+
+// LockoutError is returned by Login when too many attempts have failed for the
+// email or the client IP; no password check is made while it applies
+type LockoutError struct {
+	Scope      string // "email" or "ip"
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("too many failed logins for this %s, retry in %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// LoginAttemptStore counts failed logins per key over a fixed window. The
+// in-memory store only suits a single instance; a shared store can implement
+// the same interface when the API is scaled out.
+type LoginAttemptStore interface {
+	// Failures returns the failures recorded for key in its current window and
+	// when that window ends
+	Failures(key string, now time.Time) (int, time.Time)
+	// RecordFailure adds a failure, opening a new window if the last one ended
+	RecordFailure(key string, now time.Time, window time.Duration)
+	// Reset forgets every failure for key
+	Reset(key string)
+}
+
+type loginAttempt struct {
+	count   int
+	resetAt time.Time
+}
+
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]loginAttempt
+}
+
+func NewMemoryAttemptStore() LoginAttemptStore {
+	return &memoryAttemptStore{
+		attempts: make(map[string]loginAttempt),
+	}
+}
+
+func (m *memoryAttemptStore) Failures(key string, now time.Time) (int, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempt, ok := m.attempts[key]
+	if !ok || !now.Before(attempt.resetAt) {
+		return 0, time.Time{}
+	}
+	return attempt.count, attempt.resetAt
+}
+
+func (m *memoryAttemptStore) RecordFailure(key string, now time.Time, window time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Drop expired entries once the map grows, so random emails can't pile up
+	if len(m.attempts) >= loginAttemptSweepThreshold {
+		for k, attempt := range m.attempts {
+			if !now.Before(attempt.resetAt) {
+				delete(m.attempts, k)
+			}
+		}
+	}
+
+	attempt, ok := m.attempts[key]
+	if !ok || !now.Before(attempt.resetAt) {
+		attempt = loginAttempt{resetAt: now.Add(window)}
+	}
+	attempt.count++
+	m.attempts[key] = attempt
+}
+
+func (m *memoryAttemptStore) Reset(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+}
+
+// loginLimits reads the failure threshold and window from system settings
+func (s *authService) loginLimits(ctx context.Context) (int, time.Duration) {
+	maxFailures, window := DefaultLoginMaxFailures, DefaultLoginLockoutWindow
+
+	rows, err := s.repo.GetLoginRateLimitSettings(ctx)
+	if err != nil {
+		return maxFailures, window
+	}
+
+	for _, row := range rows {
+		val, err := strconv.Atoi(row.Value)
+		if err != nil || val < 1 {
+			continue
+		}
+		switch row.Key {
+		case "login_max_failures":
+			maxFailures = val
+		case "login_lockout_window_minutes":
+			window = time.Duration(val) * time.Minute
+		}
+	}
+
+	return maxFailures, window
+}
+
+type loginAttemptKey struct {
+	scope string
+	key   string
+}
+
+// loginAttemptKeys returns the email and IP keys failures are counted under
+func loginAttemptKeys(email, ip string) []loginAttemptKey {
+	keys := []loginAttemptKey{{scope: "email", key: "email:" + strings.ToLower(strings.TrimSpace(email))}}
+	if ip != "" {
+		keys = append(keys, loginAttemptKey{scope: "ip", key: "ip:" + ip})
+	}
+	return keys
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/security"
 )
 
@@ -16,43 +17,103 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrTokenExpired       = errors.New("refresh token expired")
 	ErrInvalidToken       = errors.New("invalid refresh token")
+	ErrTokenReused        = errors.New("refresh token reused")
 )
 
+// Used when the configured refresh token lifetimes are missing or invalid
+const (
+	DefaultSessionTTL    = 12 * time.Hour
+	DefaultRememberMeTTL = 30 * 24 * time.Hour
+)
+
+// TokenLifetimes are the refresh token lifetimes for a login without and with
+// "remember me". Each rotation issues a token with a fresh lifetime of the
+// same class as the login that started the family.
+type TokenLifetimes struct {
+	Session    time.Duration
+	RememberMe time.Duration
+}
+
+func (l TokenLifetimes) ttl(rememberMe bool) time.Duration {
+	if rememberMe {
+		return l.RememberMe
+	}
+	return l.Session
+}
+
 type Service interface {
-	Login(ctx context.Context, email, password, userAgent, ip string) (string, string, UserResponse, error)
-	Refresh(ctx context.Context, rawRefreshToken string) (string, error)
+	Login(ctx context.Context, email, password string, rememberMe bool, userAgent, ip string) (string, IssuedRefreshToken, UserResponse, error)
+	Refresh(ctx context.Context, rawRefreshToken, userAgent, ip string) (string, IssuedRefreshToken, error)
 	Logout(ctx context.Context, rawRefreshToken string) error
 }
 
 type authService struct {
 	repo      repo.Querier
 	jwtSecret []byte
+	attempts  LoginAttemptStore
+	lifetimes TokenLifetimes
 }
 
-func NewService(repo repo.Querier, jwtSecret string) Service {
+func NewService(repo repo.Querier, jwtSecret string, attempts LoginAttemptStore, lifetimes TokenLifetimes) Service {
+	if lifetimes.Session <= 0 {
+		lifetimes.Session = DefaultSessionTTL
+	}
+	if lifetimes.RememberMe <= 0 {
+		lifetimes.RememberMe = DefaultRememberMeTTL
+	}
+
 	return &authService{
 		repo:      repo,
 		jwtSecret: []byte(jwtSecret),
+		attempts:  attempts,
+		lifetimes: lifetimes,
 	}
 }
 
 // Login validates user, returns (AccessToken, RefreshToken, UserData, error)
-func (s *authService) Login(ctx context.Context, email, password, userAgent, ip string) (string, string, UserResponse, error) {
+func (s *authService) Login(ctx context.Context, email, password string, rememberMe bool, userAgent, ip string) (string, IssuedRefreshToken, UserResponse, error) {
+
+	// Refuse locked-out emails and IPs before any password comparison
+	now := time.Now()
+	maxFailures, window := s.loginLimits(ctx)
+	keys := loginAttemptKeys(email, ip)
+	for _, k := range keys {
+		if count, resetAt := s.attempts.Failures(k.key, now); count >= maxFailures {
+			return "", IssuedRefreshToken{}, UserResponse{}, &LockoutError{Scope: k.scope, RetryAfter: resetAt.Sub(now)}
+		}
+	}
+
+	recordFailure := func() {
+		for _, k := range keys {
+			s.attempts.RecordFailure(k.key, now, window)
+		}
+	}
 
 	// Fetch user
 	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", "", UserResponse{}, ErrInvalidCredentials
+		recordFailure()
+		return "", IssuedRefreshToken{}, UserResponse{}, ErrInvalidCredentials
 	}
 
-	// Check if user is active
+	// Verify Password
+	if !security.CheckPasswordHash(password, user.PasswordHash) {
+		recordFailure()
+		return "", IssuedRefreshToken{}, UserResponse{}, ErrInvalidCredentials
+	}
+
+	// Deactivated accounts get the same answer as a wrong password
 	if user.IsActive.Valid && !user.IsActive.Bool {
-		return "", "", UserResponse{}, errors.New("account is deactivated")
+		return "", IssuedRefreshToken{}, UserResponse{}, ErrInvalidCredentials
 	}
 
-	// Verify Password
-	if !security.CheckPasswordHash(password, user.PasswordHash) {
-		return "", "", UserResponse{}, ErrInvalidCredentials
+	for _, k := range keys {
+		s.attempts.Reset(k.key)
+	}
+
+	// Bookkeeping only; a failed write shouldn't fail the login
+	if err := s.repo.UpdateUserLastLogin(ctx, user.ID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record last login", "user_id", user.ID, "error", err)
 	}
 
 	// Extract role (default to 'user' if not set)
@@ -64,66 +125,81 @@ func (s *authService) Login(ctx context.Context, email, password, userAgent, ip
 	// Generate Access Token (JWT) - includes role
 	accessToken, err := s.generateJWT(user.ID, user.Email, role)
 	if err != nil {
-		return "", "", UserResponse{}, err
+		return "", IssuedRefreshToken{}, UserResponse{}, err
 	}
 
-	// Generate Refresh Token (Random String)
-	rawRefreshToken, err := security.GenerateSecureToken(32)
+	// A login starts a new token family
+	refreshToken, err := s.issueRefreshToken(ctx, user.ID, uuid.New(), pgtype.UUID{}, rememberMe, userAgent, ip)
 	if err != nil {
-		return "", "", UserResponse{}, err
-	}
-
-	// Hash Refresh Token for DB Storage
-	tokenHash := security.HashToken(rawRefreshToken)
-
-	// Store in DB
-	expiresAt := time.Now().Add(30 * 24 * time.Hour)
-
-	params := repo.CreateRefreshTokenParams{
-		UserID:    user.ID,
-		TokenHash: tokenHash,
-		ExpiresAt: expiresAt,
-		UserAgent: toPgText(userAgent),
-		IpAddress: toPgText(ip),
-	}
-
-	_, err = s.repo.CreateRefreshToken(ctx, params)
-	if err != nil {
-		return "", "", UserResponse{}, err
+		return "", IssuedRefreshToken{}, UserResponse{}, err
 	}
 
 	// Fetch user data (without password hash)
 	userData, err := s.repo.GetUserByID(ctx, user.ID)
 	if err != nil {
-		return "", "", UserResponse{}, err
+		return "", IssuedRefreshToken{}, UserResponse{}, err
 	}
 
 	// Convert to UserResponse
 	userResponse := toUserResponse(userData.ID, userData.Email, userData.Name, userData.Role, userData.IsActive, userData.CreatedAt)
 
-	return accessToken, rawRefreshToken, userResponse, nil
+	return accessToken, refreshToken, userResponse, nil
 }
 
-// Refresh validates the raw token and issues a new Access Token
-func (s *authService) Refresh(ctx context.Context, rawRefreshToken string) (string, error) {
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token, revoking the presented one. Presenting a token that was already
+// rotated means it leaked, so its whole family is revoked.
+func (s *authService) Refresh(ctx context.Context, rawRefreshToken, userAgent, ip string) (string, IssuedRefreshToken, error) {
 
 	tokenHash := security.HashToken(rawRefreshToken)
 
 	storedToken, err := s.repo.GetRefreshTokenByHash(ctx, tokenHash)
 	if err != nil {
-		return "", ErrInvalidToken
+		return "", IssuedRefreshToken{}, ErrInvalidToken
+	}
+
+	if storedToken.RevokedAt.Valid {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+			return "", IssuedRefreshToken{}, err
+		}
+		return "", IssuedRefreshToken{}, ErrTokenReused
 	}
 
 	// Check expiry - ExpiresAt is time.Time in PostgreSQL
 	if time.Now().After(storedToken.ExpiresAt) {
 		_ = s.repo.RevokeRefreshToken(ctx, storedToken.TokenHash) // Cleanup
-		return "", ErrTokenExpired
+		return "", IssuedRefreshToken{}, ErrTokenExpired
 	}
 
 	// Fetch User to Ensure they still exist and get their role
 	user, err := s.repo.GetUserByID(ctx, storedToken.UserID)
 	if err != nil {
-		return "", ErrInvalidToken
+		return "", IssuedRefreshToken{}, ErrInvalidToken
+	}
+
+	// A deactivated user's sessions end on their next refresh at the latest
+	if user.IsActive.Valid && !user.IsActive.Bool {
+		if err := s.repo.RevokeUserRefreshTokens(ctx, user.ID); err != nil {
+			return "", IssuedRefreshToken{}, err
+		}
+		return "", IssuedRefreshToken{}, ErrInvalidToken
+	}
+
+	// Claim the token; losing the race to a concurrent refresh is reuse too
+	rotated, err := s.repo.MarkRefreshTokenRotated(ctx, storedToken.ID)
+	if err != nil {
+		return "", IssuedRefreshToken{}, err
+	}
+	if rotated == 0 {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, storedToken.FamilyID); err != nil {
+			return "", IssuedRefreshToken{}, err
+		}
+		return "", IssuedRefreshToken{}, ErrTokenReused
+	}
+
+	newToken, err := s.issueRefreshToken(ctx, user.ID, storedToken.FamilyID, pgtype.UUID{Bytes: storedToken.ID, Valid: true}, storedToken.RememberMe, userAgent, ip)
+	if err != nil {
+		return "", IssuedRefreshToken{}, err
 	}
 
 	// Extract role (default to 'user' if not set)
@@ -132,7 +208,12 @@ func (s *authService) Refresh(ctx context.Context, rawRefreshToken string) (stri
 		role = user.Role.String
 	}
 
-	return s.generateJWT(user.ID, user.Email, role)
+	accessToken, err := s.generateJWT(user.ID, user.Email, role)
+	if err != nil {
+		return "", IssuedRefreshToken{}, err
+	}
+
+	return accessToken, newToken, nil
 }
 
 func (s *authService) Logout(ctx context.Context, rawRefreshToken string) error {
@@ -142,6 +223,37 @@ func (s *authService) Logout(ctx context.Context, rawRefreshToken string) error
 
 // --- Helpers ---
 
+// issueRefreshToken stores the hash of a new refresh token and returns the raw
+// token with the expiry the cookie must match
+func (s *authService) issueRefreshToken(ctx context.Context, userID, familyID uuid.UUID, parentID pgtype.UUID, rememberMe bool, userAgent, ip string) (IssuedRefreshToken, error) {
+	rawToken, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return IssuedRefreshToken{}, err
+	}
+
+	expiresAt := time.Now().Add(s.lifetimes.ttl(rememberMe))
+
+	_, err = s.repo.CreateRefreshToken(ctx, repo.CreateRefreshTokenParams{
+		UserID:     userID,
+		TokenHash:  security.HashToken(rawToken),
+		ExpiresAt:  expiresAt,
+		UserAgent:  toPgText(userAgent),
+		IpAddress:  toPgText(ip),
+		FamilyID:   familyID,
+		ParentID:   parentID,
+		RememberMe: rememberMe,
+	})
+	if err != nil {
+		return IssuedRefreshToken{}, err
+	}
+
+	return IssuedRefreshToken{
+		Token:      rawToken,
+		ExpiresAt:  expiresAt,
+		Persistent: rememberMe,
+	}, nil
+}
+
 func (s *authService) generateJWT(userID uuid.UUID, email, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":   userID.String(),
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/security"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// refreshRepo holds one live refresh token for one user
+type refreshRepo struct {
+	*testutil.Querier
+	token repo.GetRefreshTokenByHashRow
+	user  repo.GetUserByIDRow
+}
+
+func (f *refreshRepo) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (repo.GetRefreshTokenByHashRow, error) {
+	return f.token, nil
+}
+
+func (f *refreshRepo) GetUserByID(ctx context.Context, id uuid.UUID) (repo.GetUserByIDRow, error) {
+	return f.user, nil
+}
+
+func (f *refreshRepo) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	f.Record("RevokeUserRefreshTokens", userID)
+	return nil
+}
+
+func (f *refreshRepo) MarkRefreshTokenRotated(ctx context.Context, id uuid.UUID) (int64, error) {
+	f.Record("MarkRefreshTokenRotated", id)
+	return 1, nil
+}
+
+func (f *refreshRepo) CreateRefreshToken(ctx context.Context, arg repo.CreateRefreshTokenParams) (repo.CreateRefreshTokenRow, error) {
+	f.Record("CreateRefreshToken", arg)
+	return repo.CreateRefreshTokenRow{}, nil
+}
+
+// A token that was live when the user was deactivated is refused and takes
+// the user's other sessions with it
+func TestRefreshDeactivatedUser(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name     string
+		isActive pgtype.Bool
+		wantErr  error
+	}{
+		{"active", pgtype.Bool{Bool: true, Valid: true}, nil},
+		{"active by default", pgtype.Bool{}, nil},
+		{"deactivated", pgtype.Bool{Bool: false, Valid: true}, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &refreshRepo{
+				Querier: testutil.NewQuerier(),
+				token: repo.GetRefreshTokenByHashRow{
+					ID:        uuid.New(),
+					UserID:    userID,
+					TokenHash: security.HashToken("raw"),
+					ExpiresAt: time.Now().Add(time.Hour),
+					FamilyID:  uuid.New(),
+				},
+				user: repo.GetUserByIDRow{ID: userID, Email: "user@example.com", IsActive: tt.isActive},
+			}
+			s := NewService(f, "secret", NewMemoryAttemptStore(), TokenLifetimes{})
+
+			access, refresh, err := s.Refresh(context.Background(), "raw", "test", "127.0.0.1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refresh: err = %v, want %v", err, tt.wantErr)
+			}
+
+			revoked := len(f.CallsTo("RevokeUserRefreshTokens"))
+			issued := len(f.CallsTo("CreateRefreshToken"))
+			if tt.wantErr != nil {
+				if access != "" || refresh.Token != "" {
+					t.Errorf("tokens issued for a deactivated user")
+				}
+				if revoked != 1 || issued != 0 {
+					t.Errorf("%d revocations and %d new tokens, want 1 and 0", revoked, issued)
+				}
+				return
+			}
+			if access == "" || refresh.Token == "" || revoked != 0 || issued != 1 {
+				t.Errorf("access %q, refresh %q, %d revocations, %d new tokens; want new tokens and no revocation",
+					access, refresh.Token, revoked, issued)
+			}
+		})
+	}
+}
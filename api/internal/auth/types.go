@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -15,6 +17,14 @@ type UserResponse struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// IssuedRefreshToken is a new raw refresh token and the expiry stored with it.
+// Persistent tokens get a cookie that outlives the browser session.
+type IssuedRefreshToken struct {
+	Token      string
+	ExpiresAt  time.Time
+	Persistent bool
+}
+
 // toUserResponse converts DB row to UserResponse
 func toUserResponse(id uuid.UUID, email, name string, role pgtype.Text, isActive pgtype.Bool, createdAt pgtype.Timestamptz) UserResponse {
 	// Default values for nullable fields
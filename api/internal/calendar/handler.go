@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+// GetFeedToken - GET /api/v1/users/me/calendar-feed
+func (h *handler) GetFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	token, err := h.service.GetFeedToken(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get calendar feed token", "error", err)
+		utils.InternalServerError(w, "Failed to get calendar feed token")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, token)
+}
+
+// CreateFeedToken - POST /api/v1/users/me/calendar-feed
+// Replaces any existing token, so it also serves to rotate a leaked feed URL.
+func (h *handler) CreateFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	token, err := h.service.CreateFeedToken(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to create calendar feed token", "error", err)
+		utils.InternalServerError(w, "Failed to create calendar feed token")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, token)
+}
+
+// RevokeFeedToken - DELETE /api/v1/users/me/calendar-feed
+func (h *handler) RevokeFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	if err := h.service.RevokeFeedToken(r.Context(), userID); err != nil {
+		if errors.Is(err, ErrFeedTokenNotFound) {
+			utils.NotFound(w, "Calendar feed is not enabled")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to revoke calendar feed token", "error", err)
+		utils.InternalServerError(w, "Failed to revoke calendar feed token")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Calendar feed disabled"})
+}
+
+// GetFeed - GET /api/v1/calendar.ics?token=...
+// Public route: the token is the credential, as calendar apps can't send cookies.
+func (h *handler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.service.UserForToken(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidFeedToken) {
+			utils.Unauthorized(w, "Invalid calendar feed token")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to verify calendar feed token", "error", err)
+		utils.InternalServerError(w, "Failed to load calendar feed")
+		return
+	}
+
+	feed, err := h.service.BuildFeed(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to build calendar feed", "error", err)
+		utils.InternalServerError(w, "Failed to load calendar feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="reforge.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(feed); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to write calendar feed", "error", err)
+	}
+}
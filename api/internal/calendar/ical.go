@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the RFC 5545 limit on a content line, CRLF excluded
+const maxLineOctets = 75
+
+// icalDate and icalDateTime are the DATE and UTC DATE-TIME value formats
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405Z"
+)
+
+// icalWriter builds an iCalendar document with CRLF line endings and long
+// lines folded
+type icalWriter struct {
+	buf bytes.Buffer
+}
+
+// line writes one content line, "NAME:value" or "NAME;PARAM=x:value", folding
+// it at maxLineOctets without splitting a UTF-8 sequence
+func (w *icalWriter) line(name, value string) {
+	content := name + ":" + value
+	width := 0
+	for len(content) > 0 {
+		_, size := utf8.DecodeRuneInString(content)
+		if width+size > maxLineOctets {
+			// A continuation line starts with a space, which counts toward its length
+			w.buf.WriteString("\r\n ")
+			width = 1
+		}
+		w.buf.WriteString(content[:size])
+		width += size
+		content = content[size:]
+	}
+	w.buf.WriteString("\r\n")
+}
+
+// text writes a property with a TEXT value, escaped
+func (w *icalWriter) text(name, value string) {
+	w.line(name, escapeText(value))
+}
+
+func (w *icalWriter) date(name string, t time.Time) {
+	w.line(name+";VALUE=DATE", t.Format(icalDate))
+}
+
+func (w *icalWriter) dateTime(name string, t time.Time) {
+	w.line(name, t.UTC().Format(icalDateTime))
+}
+
+func (w *icalWriter) bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// textEscaper escapes TEXT values per RFC 5545 section 3.3.11. Backslashes go
+// first so the escapes added for the other characters aren't doubled.
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,246 @@
+// Package calendar serves an iCalendar feed of upcoming reviews and unfinished
+// sessions. Calendar apps can't log in, so the feed is authorized by a
+// per-user token carried in its URL.
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/dashboard"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
+	"github.com/vasujain275/reforge/internal/security"
+	"github.com/vasujain275/reforge/internal/sessions"
+)
+
+// defaultSessionMinutes is the event length for sessions without a planned duration
+const defaultSessionMinutes = 60
+
+type Service interface {
+	GetFeedToken(ctx context.Context, userID uuid.UUID) (*FeedTokenResponse, error)
+	CreateFeedToken(ctx context.Context, userID uuid.UUID) (*CreateFeedTokenResponse, error)
+	RevokeFeedToken(ctx context.Context, userID uuid.UUID) error
+	UserForToken(ctx context.Context, token string) (uuid.UUID, error)
+	BuildFeed(ctx context.Context, userID uuid.UUID) ([]byte, error)
+}
+
+type calendarService struct {
+	repo        repo.Querier
+	dashboard   dashboard.Service   // Due review counts per day
+	preferences preferences.Service // Timezone the review days are counted in
+}
+
+func NewService(repo repo.Querier, dashboardService dashboard.Service, preferencesService preferences.Service) Service {
+	return &calendarService{
+		repo:        repo,
+		dashboard:   dashboardService,
+		preferences: preferencesService,
+	}
+}
+
+func (s *calendarService) GetFeedToken(ctx context.Context, userID uuid.UUID) (*FeedTokenResponse, error) {
+	token, err := s.repo.GetCalendarFeedToken(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &FeedTokenResponse{Enabled: false}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar feed token: %w", err)
+	}
+	return toFeedTokenResponse(token), nil
+}
+
+// CreateFeedToken issues a new token, replacing the previous one. The raw token
+// is returned once; only its hash is stored.
+func (s *calendarService) CreateFeedToken(ctx context.Context, userID uuid.UUID) (*CreateFeedTokenResponse, error) {
+	raw, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	raw = strings.TrimRight(raw, "=") // Padding would need escaping in the URL
+
+	token, err := s.repo.UpsertCalendarFeedToken(ctx, repo.UpsertCalendarFeedTokenParams{
+		UserID:    userID,
+		TokenHash: security.HashToken(raw),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed token: %w", err)
+	}
+
+	return &CreateFeedTokenResponse{
+		FeedTokenResponse: *toFeedTokenResponse(token),
+		Token:             raw,
+		FeedPath:          FeedPath + "?token=" + url.QueryEscape(raw),
+	}, nil
+}
+
+func (s *calendarService) RevokeFeedToken(ctx context.Context, userID uuid.UUID) error {
+	deleted, err := s.repo.DeleteCalendarFeedToken(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke calendar feed token: %w", err)
+	}
+	if deleted == 0 {
+		return ErrFeedTokenNotFound
+	}
+	return nil
+}
+
+// UserForToken resolves a feed token to its owner
+func (s *calendarService) UserForToken(ctx context.Context, token string) (uuid.UUID, error) {
+	if token == "" {
+		return uuid.Nil, ErrInvalidFeedToken
+	}
+
+	owner, err := s.repo.GetCalendarFeedTokenByHash(ctx, security.HashToken(token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ErrInvalidFeedToken
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to verify calendar feed token: %w", err)
+	}
+	if owner.IsActive.Valid && !owner.IsActive.Bool {
+		return uuid.Nil, ErrInvalidFeedToken
+	}
+
+	if err := s.repo.TouchCalendarFeedToken(ctx, owner.UserID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record calendar feed use", "error", err)
+	}
+	return owner.UserID, nil
+}
+
+// BuildFeed renders the user's calendar: an all-day event for every day in the
+// window with reviews due, overdue ones counted on today, and a timed event
+// for every session started in the window and not yet completed. UIDs are
+// derived from the day or session, so a refreshed feed updates events in place.
+func (s *calendarService) BuildFeed(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	locale, err := s.preferences.GetLocale(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := locale.Location
+
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	forecast, err := s.dashboard.GetReviewForecast(ctx, userID, futureDays, loc, false)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionRows, err := s.repo.ListCalendarSessions(ctx, repo.ListCalendarSessionsParams{
+		UserID:   userID,
+		FromTime: pgtype.Timestamptz{Time: today.AddDate(0, 0, -pastDays), Valid: true},
+		ToTime:   pgtype.Timestamptz{Time: today.AddDate(0, 0, futureDays), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	w := &icalWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Reforge//Review Calendar//EN")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.text("X-WR-CALNAME", "Reforge")
+	w.text("X-WR-TIMEZONE", loc.String())
+	w.line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	w.line("X-PUBLISHED-TTL", "PT1H")
+
+	for i, day := range forecast.Forecast {
+		if day.Count == 0 {
+			continue
+		}
+		date := today.AddDate(0, 0, i)
+
+		summary := pluralize(day.Count, "problem") + " due"
+		if i == 0 && forecast.OverdueCount > 0 {
+			summary += fmt.Sprintf(", %d overdue", forecast.OverdueCount)
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", fmt.Sprintf("reviews-%s-%s@reforge", date.Format(icalDate), userID))
+		w.dateTime("DTSTAMP", now)
+		w.date("DTSTART", date)
+		w.date("DTEND", date.AddDate(0, 0, 1))
+		w.text("SUMMARY", summary)
+		w.line("TRANSP", "TRANSPARENT") // Reviews shouldn't block the whole day
+		w.line("END", "VEVENT")
+	}
+
+	for _, session := range sessionRows {
+		if !session.CreatedAt.Valid {
+			continue
+		}
+		start := session.CreatedAt.Time
+		minutes := int64(defaultSessionMinutes)
+		if session.PlannedDurationMin.Valid && session.PlannedDurationMin.Int32 > 0 {
+			minutes = int64(session.PlannedDurationMin.Int32)
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", fmt.Sprintf("session-%s@reforge", session.ID))
+		w.dateTime("DTSTAMP", now)
+		w.dateTime("DTSTART", start)
+		w.dateTime("DTEND", start.Add(time.Duration(minutes)*time.Minute))
+		w.text("SUMMARY", sessionTitle(session))
+		w.text("DESCRIPTION", sessionDescription(session))
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return w.bytes(), nil
+}
+
+// sessionTitle prefers the session's own name, then its template's
+func sessionTitle(session repo.ListCalendarSessionsRow) string {
+	if session.SessionName.Valid && session.SessionName.String != "" {
+		return session.SessionName.String
+	}
+	if session.TemplateKey.Valid {
+		if template, ok := sessions.GetTemplate(session.TemplateKey.String); ok {
+			return template.DisplayName
+		}
+	}
+	return "Practice session"
+}
+
+func sessionDescription(session repo.ListCalendarSessionsRow) string {
+	var problemIDs []string
+	if session.ItemsOrdered.Valid && session.ItemsOrdered.String != "" {
+		_ = json.Unmarshal([]byte(session.ItemsOrdered.String), &problemIDs)
+	}
+	if len(problemIDs) == 0 {
+		return "Not completed yet."
+	}
+	return pluralize(int64(len(problemIDs)), "problem") + " planned; not completed yet."
+}
+
+func pluralize(n int64, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func toFeedTokenResponse(token repo.CalendarFeedToken) *FeedTokenResponse {
+	createdAt := token.CreatedAt.Format(time.RFC3339)
+	resp := &FeedTokenResponse{
+		Enabled:   true,
+		CreatedAt: &createdAt,
+	}
+	if token.LastUsedAt.Valid {
+		lastUsed := token.LastUsedAt.Time.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsed
+	}
+	return resp
+}
@@ -0,0 +1,34 @@
+package calendar
+
+import (
+	"errors"
+)
+
+var ErrFeedTokenNotFound = errors.New("calendar feed token not found")
+
+// ErrInvalidFeedToken covers unknown tokens and tokens of deactivated users
+var ErrInvalidFeedToken = errors.New("invalid calendar feed token")
+
+// FeedPath is where calendar apps fetch the feed, relative to the API host
+const FeedPath = "/api/v1/calendar.ics"
+
+// The feed covers pastDays before today through futureDays after it
+const (
+	pastDays   = 7
+	futureDays = 60
+)
+
+// Response types
+
+type FeedTokenResponse struct {
+	Enabled    bool    `json:"enabled"`
+	LastUsedAt *string `json:"last_used_at"`
+	CreatedAt  *string `json:"created_at"`
+}
+
+// CreateFeedTokenResponse is the only time the token is returned
+type CreateFeedTokenResponse struct {
+	FeedTokenResponse
+	Token    string `json:"token"`
+	FeedPath string `json:"feed_path"` // FeedPath with the token, ready to append to the API origin
+}
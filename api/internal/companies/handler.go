@@ -0,0 +1,156 @@
+package companies
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+// ListCompanies - GET /api/v1/companies
+func (h *handler) ListCompanies(w http.ResponseWriter, r *http.Request) {
+	companies, err := h.service.ListCompanies(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list companies", "error", err)
+		utils.InternalServerError(w, "Failed to list companies")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, companies)
+}
+
+// CreateCompany - POST /api/v1/companies
+func (h *handler) CreateCompany(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body CreateCompanyBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	company, err := h.service.CreateCompany(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, ErrCompanyExists) {
+			utils.Conflict(w, "A company with this name already exists", nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to create company", "error", err)
+		utils.InternalServerError(w, "Failed to create company")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, company)
+}
+
+// DeleteCompany - DELETE /api/v1/companies/:id
+func (h *handler) DeleteCompany(w http.ResponseWriter, r *http.Request) {
+	companyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid company ID format", nil)
+		return
+	}
+
+	if err := h.service.DeleteCompany(r.Context(), companyID); err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			utils.NotFound(w, "Company not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete company", "error", err)
+		utils.InternalServerError(w, "Failed to delete company")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Company deleted successfully"})
+}
+
+// LinkProblems - POST /api/v1/companies/:id/problems
+func (h *handler) LinkProblems(w http.ResponseWriter, r *http.Request) {
+	companyID, problemIDs, ok := parseCompanyProblemsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.LinkProblems(r.Context(), companyID, problemIDs)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			utils.NotFound(w, "Company not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to link problems to company", "error", err)
+		utils.InternalServerError(w, "Failed to link problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// UnlinkProblems - DELETE /api/v1/companies/:id/problems
+func (h *handler) UnlinkProblems(w http.ResponseWriter, r *http.Request) {
+	companyID, problemIDs, ok := parseCompanyProblemsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UnlinkProblems(r.Context(), companyID, problemIDs)
+	if err != nil {
+		if errors.Is(err, ErrCompanyNotFound) {
+			utils.NotFound(w, "Company not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to unlink problems from company", "error", err)
+		utils.InternalServerError(w, "Failed to unlink problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// parseCompanyProblemsRequest reads the company ID and problem ID batch shared by
+// the link and unlink endpoints, writing a 400 and returning false when invalid
+func parseCompanyProblemsRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, []uuid.UUID, bool) {
+	defer r.Body.Close()
+
+	companyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid company ID format", nil)
+		return uuid.Nil, nil, false
+	}
+
+	var body CompanyProblemsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return uuid.Nil, nil, false
+	}
+	if len(body.ProblemIDs) > MaxCompanyProblemsBatch {
+		utils.BadRequest(w, fmt.Sprintf("At most %d problems can be changed at once", MaxCompanyProblemsBatch), nil)
+		return uuid.Nil, nil, false
+	}
+
+	problemIDs := make([]uuid.UUID, 0, len(body.ProblemIDs))
+	for _, idStr := range body.ProblemIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid problem ID format", nil)
+			return uuid.Nil, nil, false
+		}
+		problemIDs = append(problemIDs, id)
+	}
+	return companyID, problemIDs, true
+}
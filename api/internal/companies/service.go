@@ -0,0 +1,169 @@
+// Package companies tags problems with the companies known to ask them, so
+// practice can be narrowed to one company's list before its interviews.
+package companies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrCompanyNotFound = fmt.Errorf("company %w", utils.ErrNotFound)
+	ErrCompanyExists   = errors.New("a company with this name already exists")
+)
+
+type Service interface {
+	ListCompanies(ctx context.Context) ([]Company, error)
+	CreateCompany(ctx context.Context, body CreateCompanyBody) (*Company, error)
+	DeleteCompany(ctx context.Context, companyID uuid.UUID) error
+	LinkProblems(ctx context.Context, companyID uuid.UUID, problemIDs []uuid.UUID) (*LinkProblemsResult, error)
+	UnlinkProblems(ctx context.Context, companyID uuid.UUID, problemIDs []uuid.UUID) (*UnlinkProblemsResult, error)
+}
+
+type companyService struct {
+	repo repo.Querier
+	tx   postgres.Transactor
+}
+
+func NewService(repo repo.Querier, tx postgres.Transactor) Service {
+	return &companyService{
+		repo: repo,
+		tx:   tx,
+	}
+}
+
+func (s *companyService) ListCompanies(ctx context.Context) ([]Company, error) {
+	rows, err := s.repo.ListCompaniesWithCounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list companies: %w", err)
+	}
+
+	companies := make([]Company, len(rows))
+	for i, row := range rows {
+		companies[i] = Company{
+			ID:           row.ID.String(),
+			Name:         row.Name,
+			ProblemCount: row.ProblemCount,
+			CreatedAt:    row.CreatedAt.Format(time.RFC3339),
+		}
+	}
+	return companies, nil
+}
+
+func (s *companyService) CreateCompany(ctx context.Context, body CreateCompanyBody) (*Company, error) {
+	company, err := s.repo.CreateCompany(ctx, strings.TrimSpace(body.Name))
+	if err != nil {
+		// Names are unique ignoring case (23505 is unique_violation)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrCompanyExists
+		}
+		return nil, fmt.Errorf("failed to create company: %w", err)
+	}
+
+	return &Company{
+		ID:        company.ID.String(),
+		Name:      company.Name,
+		CreatedAt: company.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+// DeleteCompany removes a company and its problem links; the problems stay
+func (s *companyService) DeleteCompany(ctx context.Context, companyID uuid.UUID) error {
+	deleted, err := s.repo.DeleteCompany(ctx, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete company: %w", err)
+	}
+	if deleted == 0 {
+		return ErrCompanyNotFound
+	}
+	return nil
+}
+
+// LinkProblems tags problems with a company in one transaction, counting the
+// ones already tagged and the IDs that match no problem
+func (s *companyService) LinkProblems(ctx context.Context, companyID uuid.UUID, problemIDs []uuid.UUID) (*LinkProblemsResult, error) {
+	result := &LinkProblemsResult{NotFoundIDs: []string{}}
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		if _, err := q.GetCompany(ctx, companyID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrCompanyNotFound
+			}
+			return fmt.Errorf("failed to get company: %w", err)
+		}
+
+		existingIDs, err := q.GetExistingProblemIDs(ctx, problemIDs)
+		if err != nil {
+			return fmt.Errorf("failed to look up problems: %w", err)
+		}
+		linkedIDs, err := q.GetLinkedProblemIDsForCompany(ctx, repo.GetLinkedProblemIDsForCompanyParams{
+			CompanyID:  companyID,
+			ProblemIds: problemIDs,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up company links: %w", err)
+		}
+
+		existing := make(map[uuid.UUID]bool, len(existingIDs))
+		for _, id := range existingIDs {
+			existing[id] = true
+		}
+		linked := make(map[uuid.UUID]bool, len(linkedIDs))
+		for _, id := range linkedIDs {
+			linked[id] = true
+		}
+
+		for _, problemID := range problemIDs {
+			switch {
+			case !existing[problemID]:
+				result.NotFound++
+				result.NotFoundIDs = append(result.NotFoundIDs, problemID.String())
+			case linked[problemID]:
+				result.AlreadyLinked++
+			default:
+				if err := q.LinkProblemToCompany(ctx, repo.LinkProblemToCompanyParams{
+					ProblemID: problemID,
+					CompanyID: companyID,
+				}); err != nil {
+					return fmt.Errorf("failed to link problem: %w", err)
+				}
+				// A repeated ID counts once
+				linked[problemID] = true
+				result.Linked++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *companyService) UnlinkProblems(ctx context.Context, companyID uuid.UUID, problemIDs []uuid.UUID) (*UnlinkProblemsResult, error) {
+	if _, err := s.repo.GetCompany(ctx, companyID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCompanyNotFound
+		}
+		return nil, fmt.Errorf("failed to get company: %w", err)
+	}
+
+	unlinked, err := s.repo.UnlinkProblemsFromCompany(ctx, repo.UnlinkProblemsFromCompanyParams{
+		CompanyID:  companyID,
+		ProblemIds: problemIDs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlink problems: %w", err)
+	}
+	return &UnlinkProblemsResult{Unlinked: unlinked}, nil
+}
@@ -0,0 +1,30 @@
+package companies
+
+type CreateCompanyBody struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// MaxCompanyProblemsBatch caps how many problems one link/unlink request may touch
+const MaxCompanyProblemsBatch = 500
+
+type CompanyProblemsBody struct {
+	ProblemIDs []string `json:"problem_ids" validate:"required,min=1,max=500,dive,uuid"`
+}
+
+type Company struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	ProblemCount int64  `json:"problem_count"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type LinkProblemsResult struct {
+	Linked        int      `json:"linked"`
+	AlreadyLinked int      `json:"already_linked"`
+	NotFound      int      `json:"not_found"`
+	NotFoundIDs   []string `json:"not_found_ids"`
+}
+
+type UnlinkProblemsResult struct {
+	Unlinked int64 `json:"unlinked"`
+}
@@ -0,0 +1,152 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Activity feed item types
+const (
+	ActivityAttempt          = "attempt"
+	ActivitySessionCreated   = "session_created"
+	ActivitySessionCompleted = "session_completed"
+	ActivityProblemAdded     = "problem_added"
+	ActivityPatternGraduated = "pattern_graduated"
+)
+
+// graduationConfidence is the pattern confidence that counts as graduating,
+// the same bar a problem needs to count as mastered
+const graduationConfidence = 80
+
+// feedEvent is an ActivityItem before its timestamp is formatted
+type feedEvent struct {
+	at   time.Time
+	kind string
+	data any
+}
+
+// GetActivityFeed merges the newest events from each source that happened
+// strictly before before. Each source is read up to limit rows, so the merged
+// page is exact.
+func (s *dashboardService) GetActivityFeed(ctx context.Context, userID uuid.UUID, before time.Time, limit int) (*ActivityFeed, error) {
+	beforeTs := pgtype.Timestamptz{Time: before, Valid: true}
+	rowLimit := int32(limit)
+	var events []feedEvent
+
+	attempts, err := s.repo.ListActivityAttempts(ctx, repo.ListActivityAttemptsParams{
+		UserID:   userID,
+		Before:   beforeTs,
+		RowLimit: rowLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempt activity: %w", err)
+	}
+	for _, a := range attempts {
+		if !a.PerformedAt.Valid {
+			continue
+		}
+		events = append(events, feedEvent{a.PerformedAt.Time, ActivityAttempt, AttemptActivity{
+			AttemptID:       a.ID.String(),
+			ProblemID:       a.ProblemID.String(),
+			ProblemTitle:    a.ProblemTitle,
+			Outcome:         textToPtr(a.Outcome),
+			ConfidenceScore: int4ToPtr(a.ConfidenceScore),
+			DurationSeconds: int4ToPtr(a.DurationSeconds),
+		}})
+	}
+
+	sessions, err := s.repo.ListActivitySessionEvents(ctx, repo.ListActivitySessionEventsParams{
+		UserID:   userID,
+		Before:   beforeTs,
+		RowLimit: rowLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session activity: %w", err)
+	}
+	for _, sess := range sessions {
+		if !sess.OccurredAt.Valid {
+			continue
+		}
+		events = append(events, feedEvent{sess.OccurredAt.Time, sess.Event, SessionActivity{
+			SessionID:   sess.ID.String(),
+			SessionName: textToPtr(sess.SessionName),
+			TemplateKey: textToPtr(sess.TemplateKey),
+		}})
+	}
+
+	problems, err := s.repo.ListActivityProblemsAdded(ctx, repo.ListActivityProblemsAddedParams{
+		Before:   beforeTs,
+		RowLimit: rowLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list problem activity: %w", err)
+	}
+	for _, p := range problems {
+		if !p.CreatedAt.Valid {
+			continue
+		}
+		events = append(events, feedEvent{p.CreatedAt.Time, ActivityProblemAdded, ProblemAddedActivity{
+			ProblemID:  p.ID.String(),
+			Title:      p.Title,
+			Difficulty: textToPtr(p.Difficulty),
+		}})
+	}
+
+	graduations, err := s.repo.ListActivityPatternGraduations(ctx, repo.ListActivityPatternGraduationsParams{
+		UserID:    userID,
+		Threshold: graduationConfidence,
+		Before:    before,
+		RowLimit:  rowLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pattern activity: %w", err)
+	}
+	for _, g := range graduations {
+		events = append(events, feedEvent{g.CapturedAt, ActivityPatternGraduated, PatternGraduatedActivity{
+			PatternID:  g.PatternID.String(),
+			Title:      g.PatternTitle,
+			Confidence: g.AvgConfidence,
+		}})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.After(events[j].at)
+	})
+
+	feed := &ActivityFeed{Items: make([]ActivityItem, 0, limit)}
+	// A full page may have more behind it; an empty next page is cheap
+	if len(events) >= limit {
+		events = events[:limit]
+		next := events[limit-1].at.Format(time.RFC3339Nano)
+		feed.NextBefore = &next
+	}
+	for _, e := range events {
+		feed.Items = append(feed.Items, ActivityItem{
+			Type:      e.kind,
+			Timestamp: e.at.Format(time.RFC3339Nano),
+			Data:      e.data,
+		})
+	}
+
+	return feed, nil
+}
+
+func textToPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func int4ToPtr(i pgtype.Int4) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int32
+}
@@ -0,0 +1,77 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// GetBreakdown returns per-source stats (all time) and attempt outcomes by
+// difficulty over the last windowDays days
+func (s *dashboardService) GetBreakdown(ctx context.Context, userID uuid.UUID, windowDays int) (*Breakdown, error) {
+	since := time.Now().AddDate(0, 0, -windowDays)
+
+	sources, err := s.repo.GetSourceStatsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source stats: %w", err)
+	}
+
+	outcomes, err := s.repo.GetAttemptOutcomesByDifficulty(ctx, repo.GetAttemptOutcomesByDifficultyParams{
+		UserID: userID,
+		Since:  pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt outcomes: %w", err)
+	}
+
+	breakdown := &Breakdown{
+		WindowDays:          windowDays,
+		Since:               since.Format(time.RFC3339),
+		Sources:             make([]SourceStats, len(sources)),
+		SuccessByDifficulty: make([]DifficultyOutcomes, 0, 3),
+	}
+
+	for i, row := range sources {
+		breakdown.Sources[i] = SourceStats{
+			Source:        row.Source,
+			ProblemCount:  row.ProblemCount,
+			SolvedCount:   row.SolvedCount,
+			TrackedCount:  row.TrackedCount,
+			AvgConfidence: math.Round(row.AvgConfidence*10) / 10,
+		}
+	}
+
+	byDifficulty := make(map[string]repo.GetAttemptOutcomesByDifficultyRow, len(outcomes))
+	for _, row := range outcomes {
+		byDifficulty[row.Difficulty] = row
+	}
+	for _, difficulty := range []string{"easy", "medium", "hard"} {
+		row := byDifficulty[difficulty]
+		d := DifficultyOutcomes{
+			Difficulty: difficulty,
+			Attempts:   row.AttemptCount,
+			Passed:     row.PassedCount,
+			Failed:     row.FailedCount,
+			Ungraded:   row.AttemptCount - row.PassedCount - row.FailedCount,
+		}
+		if d.Attempts > 0 {
+			d.PassedPercent = percent(d.Passed, d.Attempts)
+			d.FailedPercent = percent(d.Failed, d.Attempts)
+			d.UngradedPercent = percent(d.Ungraded, d.Attempts)
+		}
+		breakdown.TotalAttempts += d.Attempts
+		breakdown.SuccessByDifficulty = append(breakdown.SuccessByDifficulty, d)
+	}
+
+	return breakdown, nil
+}
+
+// percent is part of total as a percentage to one decimal place
+func percent(part, total int64) float64 {
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
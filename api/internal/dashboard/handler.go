@@ -1,38 +1,341 @@
 package dashboard
 
 import (
-	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
 type handler struct {
-	service Service
+	service     Service
+	preferences preferences.Service // Timezone and week start when the request doesn't give them
 }
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, preferences preferences.Service) *handler {
 	return &handler{
-		service: service,
+		service:     service,
+		preferences: preferences,
 	}
 }
 
 func (h *handler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
-	stats, err := h.service.GetDashboardStats(r.Context(), userID)
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetDashboardStats(r.Context(), userID, locale.Location, locale.WeekStart)
 	if err != nil {
-		slog.Error("Failed to get dashboard stats", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get dashboard stats", "error", err)
 		utils.InternalServerError(w, "Failed to get dashboard stats")
 		return
 	}
 
 	utils.WriteSuccess(w, http.StatusOK, stats)
 }
+
+func (h *handler) GetReviewForecast(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Default to a 30 day window, capped at 90
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		if parsedDays, err := strconv.Atoi(daysStr); err == nil && parsedDays > 0 && parsedDays <= 90 {
+			days = parsedDays
+		}
+	}
+
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+	loc := locale.Location
+
+	byDifficulty := r.URL.Query().Get("by_difficulty") == "true"
+
+	forecast, err := h.service.GetReviewForecast(r.Context(), userID, days, loc, byDifficulty)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get review forecast", "error", err)
+		utils.InternalServerError(w, "Failed to get review forecast")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, forecast)
+}
+
+func (h *handler) GetActivityHeatmap(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Default to a year, capped at two
+	days := 365
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays < 1 || parsedDays > 730 {
+			utils.BadRequest(w, "Invalid days, must be between 1 and 730", nil)
+			return
+		}
+		days = parsedDays
+	}
+
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+	loc := locale.Location
+
+	heatmap, err := h.service.GetActivityHeatmap(r.Context(), userID, days, loc)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get activity heatmap", "error", err)
+		utils.InternalServerError(w, "Failed to get activity heatmap")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, heatmap)
+}
+
+func (h *handler) GetWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+	loc := locale.Location
+
+	// Zero means the current week
+	var weekStart time.Time
+	if week := r.URL.Query().Get("week"); week != "" {
+		var err error
+		weekStart, err = ParseISOWeek(week, loc)
+		if err != nil {
+			utils.BadRequest(w, "Invalid week, expected an ISO week like 2024-W45", nil)
+			return
+		}
+	}
+
+	report, err := h.service.GetWeeklyReport(r.Context(), userID, weekStart, loc)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get weekly report", "error", err)
+		utils.InternalServerError(w, "Failed to get weekly report")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, report)
+}
+
+func (h *handler) GetTimeSpent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+	loc := locale.Location
+
+	// Default to the last 30 days, today included
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, dateOnly, err := parseRangeTime(toStr, loc)
+		if err != nil {
+			utils.BadRequest(w, "Invalid to, expected YYYY-MM-DD or RFC3339", nil)
+			return
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, _, err := parseRangeTime(fromStr, loc)
+		if err != nil {
+			utils.BadRequest(w, "Invalid from, expected YYYY-MM-DD or RFC3339", nil)
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		utils.BadRequest(w, "from must be before to", nil)
+		return
+	}
+
+	timeSpent, err := h.service.GetTimeSpent(r.Context(), userID, from, to, loc)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get time spent", "error", err)
+		utils.InternalServerError(w, "Failed to get time spent")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, timeSpent)
+}
+
+func (h *handler) GetConfidenceTrend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Default to 12 weeks, capped at a year
+	weeks := 12
+	if weeksStr := r.URL.Query().Get("weeks"); weeksStr != "" {
+		parsedWeeks, err := strconv.Atoi(weeksStr)
+		if err != nil || parsedWeeks < 1 || parsedWeeks > 52 {
+			utils.BadRequest(w, "Invalid weeks, must be between 1 and 52", nil)
+			return
+		}
+		weeks = parsedWeeks
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = TrendGroupOverall
+	case TrendGroupOverall, TrendGroupDifficulty, TrendGroupPattern:
+	default:
+		utils.BadRequest(w, ErrInvalidTrendGroup.Error(), nil)
+		return
+	}
+
+	locale, ok := h.locale(w, r, userID)
+	if !ok {
+		return
+	}
+	loc := locale.Location
+
+	trend, err := h.service.GetConfidenceTrend(r.Context(), userID, weeks, groupBy, loc)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get confidence trend", "error", err)
+		utils.InternalServerError(w, "Failed to get confidence trend")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, trend)
+}
+
+func (h *handler) GetActivityFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Default to 20 items, capped at 100
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 || parsedLimit > 100 {
+			utils.BadRequest(w, "Invalid limit, must be between 1 and 100", nil)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	// The cursor is the previous page's next_before; no cursor is the newest page
+	before := time.Now()
+	if beforeStr := r.URL.Query().Get("before"); beforeStr != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, beforeStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid before, expected an RFC3339 timestamp", nil)
+			return
+		}
+		before = parsed
+	}
+
+	feed, err := h.service.GetActivityFeed(r.Context(), userID, before, limit)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get activity feed", "error", err)
+		utils.InternalServerError(w, "Failed to get activity feed")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, feed)
+}
+
+func (h *handler) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Default to a 90 day window, capped at a year
+	days := 90
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays < 1 || parsedDays > 365 {
+			utils.BadRequest(w, "Invalid days, must be between 1 and 365", nil)
+			return
+		}
+		days = parsedDays
+	}
+
+	breakdown, err := h.service.GetBreakdown(r.Context(), userID, days)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get dashboard breakdown", "error", err)
+		utils.InternalServerError(w, "Failed to get dashboard breakdown")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, breakdown)
+}
+
+// parseRangeTime accepts a date (YYYY-MM-DD, midnight in loc) or an RFC3339 timestamp
+func parseRangeTime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// locale resolves the caller's timezone and week start from the tz and
+// week_start parameters or their preferences, writing the error response when
+// that fails
+func (h *handler) locale(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (preferences.Locale, bool) {
+	locale, err := preferences.RequestLocale(r, h.preferences, userID)
+	if err != nil {
+		if preferences.IsInvalidLocale(err) {
+			utils.BadRequest(w, err.Error(), nil)
+			return preferences.Locale{}, false
+		}
+		logging.FromContext(r.Context()).Error("Failed to load preferences", "error", err)
+		utils.InternalServerError(w, "Failed to load preferences")
+		return preferences.Locale{}, false
+	}
+	return locale, true
+}
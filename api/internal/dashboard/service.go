@@ -2,67 +2,272 @@ package dashboard
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/goals"
+	"github.com/vasujain275/reforge/internal/logging"
+	"golang.org/x/sync/errgroup"
 )
 
 type Service interface {
-	GetDashboardStats(ctx context.Context, userID uuid.UUID) (*DashboardStats, error)
+	GetDashboardStats(ctx context.Context, userID uuid.UUID, loc *time.Location, weekStart time.Weekday) (*DashboardStats, error)
+	GetReviewForecast(ctx context.Context, userID uuid.UUID, days int, loc *time.Location, byDifficulty bool) (*ReviewForecast, error)
+	GetActivityHeatmap(ctx context.Context, userID uuid.UUID, days int, loc *time.Location) (*ActivityHeatmap, error)
+	GetWeeklyReport(ctx context.Context, userID uuid.UUID, weekStart time.Time, loc *time.Location) (*WeeklyReport, error)
+	GetConfidenceTrend(ctx context.Context, userID uuid.UUID, weeks int, groupBy string, loc *time.Location) (*ConfidenceTrend, error)
+	GetActivityFeed(ctx context.Context, userID uuid.UUID, before time.Time, limit int) (*ActivityFeed, error)
+	GetBreakdown(ctx context.Context, userID uuid.UUID, windowDays int) (*Breakdown, error)
+	GetTimeSpent(ctx context.Context, userID uuid.UUID, from, to time.Time, loc *time.Location) (*TimeSpent, error)
+	GetStreak(ctx context.Context, userID uuid.UUID, loc *time.Location) (*Streak, error)
 }
 
 type dashboardService struct {
-	repo repo.Querier
+	repo        repo.Querier
+	goalService goals.Service
 }
 
-func NewService(repo repo.Querier) Service {
+func NewService(repo repo.Querier, goalService goals.Service) Service {
 	return &dashboardService{
-		repo: repo,
+		repo:        repo,
+		goalService: goalService,
 	}
 }
 
-func (s *dashboardService) GetDashboardStats(ctx context.Context, userID uuid.UUID) (*DashboardStats, error) {
-	stats := &DashboardStats{}
+// GetDashboardStats runs the dashboard queries concurrently. A failing query
+// doesn't fail the response; it is logged and reported in PartialErrors.
+func (s *dashboardService) GetDashboardStats(ctx context.Context, userID uuid.UUID, loc *time.Location, weekStart time.Weekday) (*DashboardStats, error) {
+	var (
+		stats DashboardStats
+		mu    sync.Mutex
+	)
 
-	// Get total problems
-	totalProblems, err := s.repo.GetTotalProblemsForUser(ctx, userID)
-	if err == nil {
-		stats.TotalProblems = totalProblems
+	fail := func(err error, fields ...string) {
+		logging.FromContext(ctx).Error("Failed to load dashboard stat", "fields", fields, "error", err)
+		mu.Lock()
+		defer mu.Unlock()
+		if stats.PartialErrors == nil {
+			stats.PartialErrors = make(map[string]string)
+		}
+		for _, field := range fields {
+			stats.PartialErrors[field] = "query failed"
+		}
 	}
 
-	// Get mastered problems
-	masteredProblems, err := s.repo.GetMasteredProblemsForUser(ctx, userID)
-	if err == nil {
-		stats.MasteredProblems = masteredProblems
-	}
+	// Each goroutine writes only its own fields, and none return an error so
+	// one failure doesn't cancel the others
+	var g errgroup.Group
+
+	g.Go(func() error {
+		count, err := s.repo.GetTotalProblemsForUser(ctx, userID)
+		if err != nil {
+			fail(err, "total_problems")
+			return nil
+		}
+		stats.TotalProblems = count
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.GetMasteredProblemsForUser(ctx, userID)
+		if err != nil {
+			fail(err, "mastered_problems")
+			return nil
+		}
+		stats.MasteredProblems = count
+		return nil
+	})
+
+	g.Go(func() error {
+		avg, err := s.repo.GetAverageConfidenceForUser(ctx, userID)
+		if err != nil {
+			fail(err, "avg_confidence")
+			return nil
+		}
+		stats.AvgConfidence = avg
+		return nil
+	})
+
+	g.Go(func() error {
+		count, err := s.repo.GetSessionCount(ctx, userID)
+		if err != nil {
+			fail(err, "total_sessions")
+			return nil
+		}
+		stats.TotalSessions = count
+		return nil
+	})
 
-	// Get average confidence
-	avgConfidence, err := s.repo.GetAverageConfidenceForUser(ctx, userID)
-	if err == nil {
-		if val, ok := avgConfidence.(float64); ok {
-			stats.AvgConfidence = val
-		} else if val, ok := avgConfidence.(int64); ok {
-			stats.AvgConfidence = float64(val)
+	g.Go(func() error {
+		weakest, err := s.repo.GetWeakestPattern(ctx, userID)
+		if err != nil {
+			// No pattern stats yet is not a failure
+			if !errors.Is(err, pgx.ErrNoRows) {
+				fail(err, "weakest_pattern")
+			}
+			return nil
 		}
+		stats.WeakestPattern = &WeakestPattern{
+			Name:       weakest.PatternTitle,
+			Confidence: int64(weakest.AvgConfidence.Int32),
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		streak, err := s.GetStreak(ctx, userID, loc)
+		if err != nil {
+			fail(err, "current_streak", "longest_streak", "last_active_date", "at_risk")
+			return nil
+		}
+		stats.CurrentStreak = streak.Current
+		stats.LongestStreak = streak.Longest
+		stats.StreakAtRisk = streak.AtRisk
+		stats.LastActiveDate = streak.LastActiveDate
+		return nil
+	})
+
+	g.Go(func() error {
+		now := time.Now().In(loc)
+		vacation, err := s.repo.GetActiveVacation(ctx, repo.GetActiveVacationParams{
+			UserID: userID,
+			OnDate: pgtype.Date{Time: now, Valid: true},
+		})
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				fail(err, "vacation")
+			}
+			return nil
+		}
+		stats.Vacation = &ActiveVacation{
+			ID:            vacation.ID.String(),
+			StartDate:     vacation.StartDate.Time.Format("2006-01-02"),
+			EndDate:       vacation.EndDate.Time.Format("2006-01-02"),
+			DaysRemaining: dayNumber(vacation.EndDate.Time) - dayNumber(now) + 1,
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		progress, err := s.goalService.GetGoalProgress(ctx, userID, loc, weekStart)
+		if err != nil {
+			fail(err, "goals")
+			return nil
+		}
+		stats.Goals = progress
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+func (s *dashboardService) GetReviewForecast(ctx context.Context, userID uuid.UUID, days int, loc *time.Location, byDifficulty bool) (*ReviewForecast, error) {
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	end := today.AddDate(0, 0, days)
+
+	rows, err := s.repo.GetReviewForecast(ctx, repo.GetReviewForecastParams{
+		Tz:        loc.String(),
+		UserID:    userID,
+		DueBefore: pgtype.Timestamptz{Time: end, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review forecast: %w", err)
 	}
 
-	// Get session count
-	sessionCount, err := s.repo.GetSessionCount(ctx, userID)
-	if err == nil {
-		stats.TotalSessions = sessionCount
+	forecast := &ReviewForecast{
+		Timezone: loc.String(),
+		Days:     days,
+		Forecast: make([]ForecastDay, days),
+	}
+	for i := range forecast.Forecast {
+		forecast.Forecast[i].Date = today.AddDate(0, 0, i).Format("2006-01-02")
+		if byDifficulty {
+			forecast.Forecast[i].ByDifficulty = &DifficultyBreakdown{}
+		}
 	}
 
-	// Get weakest pattern
-	weakestPattern, err := s.repo.GetWeakestPattern(ctx, userID)
-	if err == nil {
-		stats.WeakestPattern = &WeakestPattern{
-			Name:       weakestPattern.PatternTitle,
-			Confidence: int64(weakestPattern.AvgConfidence.Int32),
+	for _, row := range rows {
+		if !row.DueDate.Valid {
+			continue
+		}
+		dueDate := time.Date(row.DueDate.Time.Year(), row.DueDate.Time.Month(), row.DueDate.Time.Day(), 0, 0, 0, 0, loc)
+
+		// Anything due before today rolls into day 0
+		idx := 0
+		if dueDate.Before(today) {
+			forecast.OverdueCount += row.Count
+		} else {
+			idx = int(dueDate.Sub(today).Hours()/24 + 0.5) // round to absorb DST shifts
+		}
+		if idx >= days {
+			continue
+		}
+
+		day := &forecast.Forecast[idx]
+		day.Count += row.Count
+		forecast.TotalDue += row.Count
+
+		if day.ByDifficulty != nil {
+			switch row.Difficulty {
+			case "easy":
+				day.ByDifficulty.Easy += row.Count
+			case "hard":
+				day.ByDifficulty.Hard += row.Count
+			default:
+				day.ByDifficulty.Medium += row.Count
+			}
 		}
 	}
 
-	// TODO: Calculate current streak from attempts
-	stats.CurrentStreak = 0
+	return forecast, nil
+}
+
+// GetActivityHeatmap returns practice activity for the last days days, today included
+func (s *dashboardService) GetActivityHeatmap(ctx context.Context, userID uuid.UUID, days int, loc *time.Location) (*ActivityHeatmap, error) {
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	rows, err := s.repo.GetUserActivityHeatmap(ctx, repo.GetUserActivityHeatmapParams{
+		Tz:     loc.String(),
+		UserID: userID,
+		Since:  pgtype.Timestamptz{Time: from, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity heatmap: %w", err)
+	}
+
+	heatmap := &ActivityHeatmap{
+		Timezone: loc.String(),
+		From:     from.Format("2006-01-02"),
+		To:       today.Format("2006-01-02"),
+		Days:     make([]ActivityDay, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if !row.Day.Valid {
+			continue
+		}
+		heatmap.Days = append(heatmap.Days, ActivityDay{
+			Date:           row.Day.Time.Format("2006-01-02"),
+			AttemptCount:   row.AttemptCount,
+			Minutes:        (row.TotalSeconds + 30) / 60,
+			ProblemsPassed: row.ProblemsPassed,
+		})
+		if row.AttemptCount > heatmap.MaxCount {
+			heatmap.MaxCount = row.AttemptCount
+		}
+	}
 
-	return stats, nil
+	return heatmap, nil
 }
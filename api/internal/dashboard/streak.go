@@ -0,0 +1,145 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// streakLookbackDays bounds the attempt history read for streaks, so the
+// longest streak is the longest within roughly the last year
+const streakLookbackDays = 400
+
+// GetStreak counts practice streaks in calendar days of loc
+func (s *dashboardService) GetStreak(ctx context.Context, userID uuid.UUID, loc *time.Location) (*Streak, error) {
+	now := time.Now().In(loc)
+	dateRows, err := s.repo.GetUserAttemptDates(ctx, repo.GetUserAttemptDatesParams{
+		Tz:     loc.String(),
+		UserID: userID,
+		Since:  pgtype.Timestamptz{Time: now.AddDate(0, 0, -streakLookbackDays), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attempt dates: %w", err)
+	}
+
+	dates := make([]time.Time, 0, len(dateRows))
+	for _, d := range dateRows {
+		if d.Valid {
+			dates = append(dates, d.Time)
+		}
+	}
+
+	// Vacation days neither break a streak nor extend it
+	vacations, err := s.repo.ListVacationDaysBetween(ctx, repo.ListVacationDaysBetweenParams{
+		UserID:   userID,
+		FromDate: pgtype.Date{Time: now.AddDate(0, 0, -streakLookbackDays), Valid: true},
+		ToDate:   pgtype.Date{Time: now, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vacations: %w", err)
+	}
+	todayNum := dayNumber(now)
+	paused := make(map[int64]bool)
+	for _, v := range vacations {
+		for n := dayNumber(v.StartDate.Time); n <= min(dayNumber(v.EndDate.Time), todayNum); n++ {
+			paused[n] = true
+		}
+	}
+
+	info := computeStreaks(dates, paused, now)
+	streak := &Streak{
+		Current: info.current,
+		Longest: info.longest,
+		AtRisk:  info.atRisk,
+	}
+	if info.lastActive != nil {
+		lastActive := info.lastActive.Format("2006-01-02")
+		streak.LastActiveDate = &lastActive
+	}
+	return streak, nil
+}
+
+type streakInfo struct {
+	current    int64
+	longest    int64
+	lastActive *time.Time
+	atRisk     bool
+}
+
+// computeStreaks counts consecutive practice days from distinct calendar dates
+// (any order, time of day ignored). A streak is still current if the last
+// active day is yesterday; it is then at risk until something is done today.
+// Paused days (day numbers) without practice are skipped over as if they
+// weren't on the calendar.
+func computeStreaks(dates []time.Time, paused map[int64]bool, today time.Time) streakInfo {
+	var info streakInfo
+	if len(dates) == 0 {
+		return info
+	}
+
+	// Work on day numbers so DST transitions can't make a day 23 or 25 hours
+	active := make(map[int64]bool, len(dates))
+	var lastDay int64
+	for i, d := range dates {
+		n := dayNumber(d)
+		active[n] = true
+		if i == 0 || n > lastDay {
+			lastDay = n
+		}
+	}
+
+	skipped := make([]int64, 0, len(paused))
+	for n := range paused {
+		if !active[n] {
+			skipped = append(skipped, n)
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i] < skipped[j] })
+	// effective renumbers days with the skipped ones taken out
+	effective := func(n int64) int64 {
+		return n - int64(sort.Search(len(skipped), func(i int) bool { return skipped[i] > n }))
+	}
+
+	days := make(map[int64]bool, len(active))
+	for n := range active {
+		days[effective(n)] = true
+	}
+	last := effective(lastDay)
+
+	for n := range days {
+		// Only count from the first day of each run
+		if days[n-1] {
+			continue
+		}
+		var length int64
+		for days[n+length] {
+			length++
+		}
+		if length > info.longest {
+			info.longest = length
+		}
+	}
+
+	lastActive := time.Unix(lastDay*86400, 0).UTC()
+	info.lastActive = &lastActive
+
+	todayNum := effective(dayNumber(today))
+	if last == todayNum || last == todayNum-1 {
+		for n := last; days[n]; n-- {
+			info.current++
+		}
+		info.atRisk = last == todayNum-1
+	}
+
+	return info
+}
+
+// dayNumber is the number of days from the Unix epoch to t's calendar date
+func dayNumber(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
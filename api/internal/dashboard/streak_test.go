@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // DST rules shouldn't depend on the machine's zoneinfo
+)
+
+// Calendar days around 2026's DST changes: the US springs forward on 8 March
+// and falls back on 1 November, the UK springs forward on 29 March and
+// Sydney falls back on 5 April
+func TestComputeStreaksAcrossDST(t *testing.T) {
+	load := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatalf("LoadLocation(%s): %v", name, err)
+		}
+		return loc
+	}
+	newYork, london, sydney := load("America/New_York"), load("Europe/London"), load("Australia/Sydney")
+
+	// at is a local time on a calendar date, e.g. at(newYork, "2026-03-08 23:30")
+	at := func(loc *time.Location, value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+		if err != nil {
+			t.Fatalf("ParseInLocation(%s): %v", value, err)
+		}
+		return parsed
+	}
+	days := func(loc *time.Location, values ...string) []time.Time {
+		dates := make([]time.Time, len(values))
+		for i, v := range values {
+			dates[i] = at(loc, v)
+		}
+		return dates
+	}
+
+	tests := []struct {
+		name       string
+		dates      []time.Time
+		paused     []time.Time
+		today      time.Time
+		current    int64
+		longest    int64
+		atRisk     bool
+		lastActive string
+	}{
+		{
+			name:       "through the spring forward",
+			dates:      days(newYork, "2026-03-06 00:00", "2026-03-07 00:00", "2026-03-08 00:00", "2026-03-09 00:00", "2026-03-10 00:00"),
+			today:      at(newYork, "2026-03-10 09:00"),
+			current:    5,
+			longest:    5,
+			lastActive: "2026-03-10",
+		},
+		{
+			name:       "through the fall back",
+			dates:      days(newYork, "2026-10-30 00:00", "2026-10-31 00:00", "2026-11-01 00:00", "2026-11-02 00:00", "2026-11-03 00:00"),
+			today:      at(newYork, "2026-11-03 20:00"),
+			current:    5,
+			longest:    5,
+			lastActive: "2026-11-03",
+		},
+		{
+			name:       "late at night either side of the short day",
+			dates:      days(newYork, "2026-03-07 23:59", "2026-03-08 23:59", "2026-03-09 23:59"),
+			today:      at(newYork, "2026-03-09 23:59"),
+			current:    3,
+			longest:    3,
+			lastActive: "2026-03-09",
+		},
+		{
+			name: "the repeated hour of the long day counts once",
+			dates: []time.Time{
+				at(newYork, "2026-10-31 12:00"),
+				time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC).In(newYork), // 01:30 EDT
+				time.Date(2026, 11, 1, 6, 30, 0, 0, time.UTC).In(newYork), // 01:30 EST
+			},
+			today:      at(newYork, "2026-11-01 22:00"),
+			current:    2,
+			longest:    2,
+			lastActive: "2026-11-01",
+		},
+		{
+			name:       "at risk on the short day",
+			dates:      days(newYork, "2026-03-05 00:00", "2026-03-06 00:00", "2026-03-07 00:00"),
+			today:      at(newYork, "2026-03-08 23:00"),
+			current:    3,
+			longest:    3,
+			atRisk:     true,
+			lastActive: "2026-03-07",
+		},
+		{
+			name:       "broken by missing the short day",
+			dates:      days(newYork, "2026-03-06 00:00", "2026-03-07 00:00", "2026-03-09 00:00"),
+			today:      at(newYork, "2026-03-09 10:00"),
+			current:    1,
+			longest:    2,
+			lastActive: "2026-03-09",
+		},
+		{
+			name:       "lapsed across the long day",
+			dates:      days(newYork, "2026-10-30 00:00", "2026-10-31 00:00"),
+			today:      at(newYork, "2026-11-02 00:30"),
+			current:    0,
+			longest:    2,
+			lastActive: "2026-10-31",
+		},
+		{
+			name:       "vacation on the short day",
+			dates:      days(newYork, "2026-03-06 00:00", "2026-03-07 00:00", "2026-03-09 00:00"),
+			paused:     days(newYork, "2026-03-08 00:00"),
+			today:      at(newYork, "2026-03-09 10:00"),
+			current:    3,
+			longest:    3,
+			lastActive: "2026-03-09",
+		},
+		{
+			name:       "UK spring forward",
+			dates:      days(london, "2026-03-28 00:30", "2026-03-29 00:30", "2026-03-29 23:30", "2026-03-30 00:30"),
+			today:      at(london, "2026-03-30 18:00"),
+			current:    3,
+			longest:    3,
+			lastActive: "2026-03-30",
+		},
+		{
+			name:       "southern hemisphere fall back",
+			dates:      days(sydney, "2026-04-03 08:00", "2026-04-04 08:00", "2026-04-05 08:00", "2026-04-06 08:00"),
+			today:      at(sydney, "2026-04-07 07:00"),
+			current:    4,
+			longest:    4,
+			atRisk:     true,
+			lastActive: "2026-04-06",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paused := make(map[int64]bool, len(tt.paused))
+			for _, p := range tt.paused {
+				paused[dayNumber(p)] = true
+			}
+
+			got := computeStreaks(tt.dates, paused, tt.today)
+			if got.current != tt.current || got.longest != tt.longest || got.atRisk != tt.atRisk {
+				t.Errorf("streak = current %d, longest %d, at risk %v; want %d, %d, %v",
+					got.current, got.longest, got.atRisk, tt.current, tt.longest, tt.atRisk)
+			}
+			if got.lastActive == nil || got.lastActive.Format("2006-01-02") != tt.lastActive {
+				t.Errorf("last active = %v, want %s", got.lastActive, tt.lastActive)
+			}
+		})
+	}
+}
+
+func TestComputeStreaksWithoutAttempts(t *testing.T) {
+	got := computeStreaks(nil, nil, time.Now())
+	if got.current != 0 || got.longest != 0 || got.atRisk || got.lastActive != nil {
+		t.Errorf("streak = %+v, want zero", got)
+	}
+}
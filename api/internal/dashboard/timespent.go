@@ -0,0 +1,137 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// timeSpentTopPatterns is how many patterns are listed before the rest are
+// folded into "Other"
+const timeSpentTopPatterns = 10
+
+// GetTimeSpent groups completed attempt time in [from, to) by difficulty,
+// pattern and source. Time on a problem with several patterns is split evenly
+// between them; time on problems without a pattern counts as "Other".
+func (s *dashboardService) GetTimeSpent(ctx context.Context, userID uuid.UUID, from, to time.Time, loc *time.Location) (*TimeSpent, error) {
+	fromTs := pgtype.Timestamptz{Time: from, Valid: true}
+	toTs := pgtype.Timestamptz{Time: to, Valid: true}
+
+	groups, err := s.repo.GetTimeSpentByDifficultyAndSource(ctx, repo.GetTimeSpentByDifficultyAndSourceParams{
+		UserID:   userID,
+		FromTime: fromTs,
+		ToTime:   toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time spent by difficulty and source: %w", err)
+	}
+
+	patternRows, err := s.repo.GetTimeSpentByProblemPattern(ctx, repo.GetTimeSpentByProblemPatternParams{
+		UserID:   userID,
+		FromTime: fromTs,
+		ToTime:   toTs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time spent by pattern: %w", err)
+	}
+
+	var total int64
+	byDifficulty := map[string]int64{"easy": 0, "medium": 0, "hard": 0}
+	bySource := map[string]int64{}
+	for _, g := range groups {
+		total += g.TotalSeconds
+		byDifficulty[g.Difficulty] += g.TotalSeconds
+		bySource[g.Source] += g.TotalSeconds
+	}
+
+	result := &TimeSpent{
+		Timezone:     loc.String(),
+		From:         from.In(loc).Format("2006-01-02"),
+		To:           to.In(loc).Add(-time.Nanosecond).Format("2006-01-02"),
+		TotalSeconds: total,
+		ByDifficulty: make([]TimeSpentItem, 0, 3),
+		BySource:     make([]TimeSpentItem, 0, len(bySource)),
+	}
+
+	for _, difficulty := range []string{"easy", "medium", "hard"} {
+		result.ByDifficulty = append(result.ByDifficulty, TimeSpentItem{
+			Key:     difficulty,
+			Label:   difficulty,
+			Seconds: byDifficulty[difficulty],
+		})
+	}
+
+	for source, seconds := range bySource {
+		label := source
+		if label == "" {
+			label = "Unknown"
+		}
+		result.BySource = append(result.BySource, TimeSpentItem{Key: source, Label: label, Seconds: seconds})
+	}
+	sortTimeSpent(result.BySource)
+
+	result.ByPattern = splitPatternTime(patternRows, total)
+
+	return result, nil
+}
+
+// splitPatternTime spreads each problem's seconds evenly over its patterns
+// (rows are ordered by problem), keeps the top patterns and puts the rest,
+// including unpatterned time, under "Other" so the list sums to total
+func splitPatternTime(rows []repo.GetTimeSpentByProblemPatternRow, total int64) []TimeSpentItem {
+	seconds := map[uuid.UUID]int64{}
+	titles := map[uuid.UUID]string{}
+
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && rows[end].ProblemID == rows[start].ProblemID {
+			end++
+		}
+
+		// Whole seconds only; the remainder goes to the first patterns
+		n := int64(end - start)
+		share, remainder := rows[start].TotalSeconds/n, rows[start].TotalSeconds%n
+		for i, row := range rows[start:end] {
+			seconds[row.PatternID] += share
+			if int64(i) < remainder {
+				seconds[row.PatternID]++
+			}
+			titles[row.PatternID] = row.PatternTitle
+		}
+		start = end
+	}
+
+	items := make([]TimeSpentItem, 0, len(seconds))
+	for id, s := range seconds {
+		items = append(items, TimeSpentItem{Key: id.String(), Label: titles[id], Seconds: s})
+	}
+	sortTimeSpent(items)
+
+	if len(items) > timeSpentTopPatterns {
+		items = items[:timeSpentTopPatterns]
+	}
+	other := total
+	for _, item := range items {
+		other -= item.Seconds
+	}
+	if other > 0 {
+		items = append(items, TimeSpentItem{Key: "other", Label: "Other", Seconds: other})
+	}
+
+	return items
+}
+
+// sortTimeSpent orders by most time first, then label for a stable response
+func sortTimeSpent(items []TimeSpentItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Seconds != items[j].Seconds {
+			return items[i].Seconds > items[j].Seconds
+		}
+		return items[i].Label < items[j].Label
+	})
+}
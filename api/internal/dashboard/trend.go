@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Confidence trend groupings
+const (
+	TrendGroupOverall    = "overall"
+	TrendGroupDifficulty = "difficulty"
+	TrendGroupPattern    = "pattern"
+)
+
+var ErrInvalidTrendGroup = errors.New("group_by must be one of: overall, difficulty, pattern")
+
+// trendPoint is one week of one series, whichever query it came from
+type trendPoint struct {
+	weekStart pgtype.Date
+	key       string
+	label     string
+	avg       float64
+	count     int64
+}
+
+// GetConfidenceTrend averages attempt confidence over the last weeks ISO
+// weeks, the current week included
+func (s *dashboardService) GetConfidenceTrend(ctx context.Context, userID uuid.UUID, weeks int, groupBy string, loc *time.Location) (*ConfidenceTrend, error) {
+	firstWeek := startOfISOWeek(time.Now().In(loc)).AddDate(0, 0, -7*(weeks-1))
+
+	points, err := s.confidenceTrendPoints(ctx, repo.GetConfidenceTrendOverallParams{
+		Tz:     loc.String(),
+		UserID: userID,
+		Since:  pgtype.Timestamptz{Time: firstWeek, Valid: true},
+	}, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	trend := &ConfidenceTrend{
+		Timezone: loc.String(),
+		GroupBy:  groupBy,
+		Weeks:    make([]TrendWeek, weeks),
+		Series:   []ConfidenceSeries{},
+	}
+	index := make(map[string]int, weeks)
+	for i := range trend.Weeks {
+		start := firstWeek.AddDate(0, 0, 7*i)
+		year, week := start.ISOWeek()
+		trend.Weeks[i] = TrendWeek{
+			Week:  fmt.Sprintf("%04d-W%02d", year, week),
+			Start: start.Format("2006-01-02"),
+		}
+		index[trend.Weeks[i].Start] = i
+	}
+
+	series := make(map[string]*ConfidenceSeries)
+	for _, p := range points {
+		if !p.weekStart.Valid {
+			continue
+		}
+		i, ok := index[p.weekStart.Time.Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+
+		sr, ok := series[p.key]
+		if !ok {
+			sr = &ConfidenceSeries{
+				Key:           p.key,
+				Label:         p.label,
+				Values:        make([]*float64, weeks),
+				AttemptCounts: make([]int64, weeks),
+			}
+			series[p.key] = sr
+		}
+		avg := math.Round(p.avg*10) / 10
+		sr.Values[i] = &avg
+		sr.AttemptCounts[i] = p.count
+	}
+
+	// Overall always has a series, even with no attempts at all
+	if groupBy == TrendGroupOverall && len(series) == 0 {
+		series[TrendGroupOverall] = &ConfidenceSeries{
+			Key:           TrendGroupOverall,
+			Label:         "Overall",
+			Values:        make([]*float64, weeks),
+			AttemptCounts: make([]int64, weeks),
+		}
+	}
+
+	for _, sr := range series {
+		trend.Series = append(trend.Series, *sr)
+	}
+	sort.Slice(trend.Series, func(i, j int) bool {
+		return seriesOrder(trend.Series[i]) < seriesOrder(trend.Series[j])
+	})
+
+	return trend, nil
+}
+
+// confidenceTrendPoints runs the grouped query for groupBy
+func (s *dashboardService) confidenceTrendPoints(ctx context.Context, params repo.GetConfidenceTrendOverallParams, groupBy string) ([]trendPoint, error) {
+	var points []trendPoint
+
+	switch groupBy {
+	case TrendGroupOverall:
+		rows, err := s.repo.GetConfidenceTrendOverall(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get confidence trend: %w", err)
+		}
+		for _, row := range rows {
+			points = append(points, trendPoint{row.WeekStart, TrendGroupOverall, "Overall", row.AvgConfidence, row.AttemptCount})
+		}
+
+	case TrendGroupDifficulty:
+		rows, err := s.repo.GetConfidenceTrendByDifficulty(ctx, repo.GetConfidenceTrendByDifficultyParams(params))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get confidence trend by difficulty: %w", err)
+		}
+		for _, row := range rows {
+			points = append(points, trendPoint{row.WeekStart, row.Difficulty, row.Difficulty, row.AvgConfidence, row.AttemptCount})
+		}
+
+	case TrendGroupPattern:
+		rows, err := s.repo.GetConfidenceTrendByPattern(ctx, repo.GetConfidenceTrendByPatternParams(params))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get confidence trend by pattern: %w", err)
+		}
+		for _, row := range rows {
+			points = append(points, trendPoint{row.WeekStart, row.PatternID.String(), row.PatternTitle, row.AvgConfidence, row.AttemptCount})
+		}
+
+	default:
+		return nil, ErrInvalidTrendGroup
+	}
+
+	return points, nil
+}
+
+// seriesOrder sorts difficulties easy to hard and everything else by label
+func seriesOrder(sr ConfidenceSeries) string {
+	switch sr.Key {
+	case "easy":
+		return "0"
+	case "medium":
+		return "1"
+	case "hard":
+		return "2"
+	}
+	return "3" + sr.Label
+}
@@ -1,15 +1,231 @@
 package dashboard
 
+import "github.com/vasujain275/reforge/internal/goals"
+
+// DashboardStats is the dashboard summary. A metric whose query failed is left
+// at its zero value and its JSON name is listed in PartialErrors.
 type DashboardStats struct {
-	TotalProblems    int64           `json:"total_problems"`
-	MasteredProblems int64           `json:"mastered_problems"`
-	AvgConfidence    float64         `json:"avg_confidence"`
-	CurrentStreak    int64           `json:"current_streak"`
-	TotalSessions    int64           `json:"total_sessions"`
-	WeakestPattern   *WeakestPattern `json:"weakest_pattern,omitempty"`
+	TotalProblems    int64   `json:"total_problems"`
+	MasteredProblems int64   `json:"mastered_problems"`
+	AvgConfidence    float64 `json:"avg_confidence"`
+	CurrentStreak    int64   `json:"current_streak"`
+	LongestStreak    int64   `json:"longest_streak"`
+	LastActiveDate   *string `json:"last_active_date"`
+	// StreakAtRisk means the current streak ended yesterday and needs an attempt today
+	StreakAtRisk   bool                 `json:"at_risk"`
+	TotalSessions  int64                `json:"total_sessions"`
+	WeakestPattern *WeakestPattern      `json:"weakest_pattern,omitempty"`
+	Goals          []goals.GoalProgress `json:"goals"`              // Active goals, current period
+	Vacation       *ActiveVacation      `json:"vacation,omitempty"` // Set while a vacation is underway
+	PartialErrors  map[string]string    `json:"partial_errors,omitempty"`
+}
+
+// ActiveVacation is the vacation covering today, for the dashboard banner
+type ActiveVacation struct {
+	ID            string `json:"id"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	DaysRemaining int64  `json:"days_remaining"` // Including today
+}
+
+// Streak counts consecutive calendar days with at least one attempt
+type Streak struct {
+	Current        int64   `json:"current"`
+	Longest        int64   `json:"longest"`
+	LastActiveDate *string `json:"last_active_date"`
+	// AtRisk means the current streak ended yesterday and needs an attempt today
+	AtRisk bool `json:"at_risk"`
 }
 
 type WeakestPattern struct {
 	Name       string `json:"name"`
 	Confidence int64  `json:"confidence"`
 }
+
+// ReviewForecast is the number of reviews falling due on each upcoming day.
+// Day 0 is today and also absorbs everything already overdue.
+type ReviewForecast struct {
+	Timezone     string        `json:"timezone"`
+	Days         int           `json:"days"`
+	OverdueCount int64         `json:"overdue_count"`
+	TotalDue     int64         `json:"total_due"`
+	Forecast     []ForecastDay `json:"forecast"`
+}
+
+type ForecastDay struct {
+	Date         string               `json:"date"`
+	Count        int64                `json:"count"`
+	ByDifficulty *DifficultyBreakdown `json:"by_difficulty,omitempty"`
+}
+
+type DifficultyBreakdown struct {
+	Easy   int64 `json:"easy"`
+	Medium int64 `json:"medium"`
+	Hard   int64 `json:"hard"`
+}
+
+// ActivityHeatmap is per-day practice activity. Days without activity are
+// omitted; the client fills the gaps.
+type ActivityHeatmap struct {
+	Timezone string        `json:"timezone"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	MaxCount int64         `json:"max_count"` // Highest daily attempt count, for color scaling
+	Days     []ActivityDay `json:"days"`
+}
+
+type ActivityDay struct {
+	Date           string `json:"date"`
+	AttemptCount   int64  `json:"attempt_count"`
+	Minutes        int64  `json:"minutes"`
+	ProblemsPassed int64  `json:"problems_passed"`
+}
+
+// WeeklyReport is one ISO week of practice compared with the week before
+type WeeklyReport struct {
+	Week         string                    `json:"week"` // e.g. 2024-W45
+	Timezone     string                    `json:"timezone"`
+	From         string                    `json:"from"` // Monday
+	To           string                    `json:"to"`   // Sunday
+	Summary      WeeklySummary             `json:"summary"`
+	PreviousWeek WeeklySummary             `json:"previous_week"`
+	Change       WeeklyChange              `json:"change"`
+	Patterns     []PatternConfidenceChange `json:"patterns"`
+}
+
+type WeeklySummary struct {
+	Attempts          int64   `json:"attempts"`
+	Passed            int64   `json:"passed"`
+	PassRate          float64 `json:"pass_rate"` // 0-1, over attempts with an outcome
+	Minutes           int64   `json:"minutes"`
+	SessionsCompleted int64   `json:"sessions_completed"`
+	NewProblems       int64   `json:"new_problems"` // Problems first attempted this week
+}
+
+// WeeklyChange is this week minus the previous week
+type WeeklyChange struct {
+	Attempts          int64   `json:"attempts"`
+	PassRate          float64 `json:"pass_rate"`
+	Minutes           int64   `json:"minutes"`
+	SessionsCompleted int64   `json:"sessions_completed"`
+	NewProblems       int64   `json:"new_problems"`
+}
+
+type PatternConfidenceChange struct {
+	PatternID       string `json:"pattern_id"`
+	Title           string `json:"title"`
+	StartConfidence int32  `json:"start_confidence"`
+	EndConfidence   int32  `json:"end_confidence"`
+	Delta           int32  `json:"delta"`
+}
+
+// TimeSpent is completed attempt time in a date range, grouped three ways.
+// Each grouping sums to TotalSeconds.
+type TimeSpent struct {
+	Timezone     string          `json:"timezone"`
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	TotalSeconds int64           `json:"total_seconds"`
+	ByDifficulty []TimeSpentItem `json:"by_difficulty"`
+	ByPattern    []TimeSpentItem `json:"by_pattern"` // Top patterns, then "Other"
+	BySource     []TimeSpentItem `json:"by_source"`
+}
+
+type TimeSpentItem struct {
+	Key     string `json:"key"` // Difficulty, pattern ID, source, or "other"
+	Label   string `json:"label"`
+	Seconds int64  `json:"seconds"`
+}
+
+// ConfidenceTrend is average attempt confidence per ISO week, oldest week
+// first. Each series has one value per week; weeks without attempts are null.
+type ConfidenceTrend struct {
+	Timezone string             `json:"timezone"`
+	GroupBy  string             `json:"group_by"`
+	Weeks    []TrendWeek        `json:"weeks"`
+	Series   []ConfidenceSeries `json:"series"`
+}
+
+type TrendWeek struct {
+	Week  string `json:"week"`  // e.g. 2024-W45
+	Start string `json:"start"` // Monday
+}
+
+type ConfidenceSeries struct {
+	Key           string     `json:"key"` // "overall", a difficulty, or a pattern ID
+	Label         string     `json:"label"`
+	Values        []*float64 `json:"values"`
+	AttemptCounts []int64    `json:"attempt_counts"`
+}
+
+// ActivityFeed is recent events, newest first. Pass NextBefore as before to
+// load the next page; it is null when there is nothing older.
+type ActivityFeed struct {
+	Items      []ActivityItem `json:"items"`
+	NextBefore *string        `json:"next_before"`
+}
+
+// ActivityItem is one event; Data depends on Type
+type ActivityItem struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Data      any    `json:"data"`
+}
+
+type AttemptActivity struct {
+	AttemptID       string  `json:"attempt_id"`
+	ProblemID       string  `json:"problem_id"`
+	ProblemTitle    string  `json:"problem_title"`
+	Outcome         *string `json:"outcome"`
+	ConfidenceScore *int32  `json:"confidence_score"`
+	DurationSeconds *int32  `json:"duration_seconds"`
+}
+
+type SessionActivity struct {
+	SessionID   string  `json:"session_id"`
+	SessionName *string `json:"session_name"`
+	TemplateKey *string `json:"template_key"`
+}
+
+type ProblemAddedActivity struct {
+	ProblemID  string  `json:"problem_id"`
+	Title      string  `json:"title"`
+	Difficulty *string `json:"difficulty"`
+}
+
+type PatternGraduatedActivity struct {
+	PatternID  string `json:"pattern_id"`
+	Title      string `json:"title"`
+	Confidence int32  `json:"confidence"`
+}
+
+// Breakdown is per-source progress and, over a recent window, how attempts
+// went per difficulty. Totals are included so small samples can be flagged.
+type Breakdown struct {
+	WindowDays          int                  `json:"window_days"`
+	Since               string               `json:"since"`
+	TotalAttempts       int64                `json:"total_attempts"`
+	Sources             []SourceStats        `json:"sources"`
+	SuccessByDifficulty []DifficultyOutcomes `json:"success_by_difficulty"`
+}
+
+type SourceStats struct {
+	Source        string  `json:"source"`
+	ProblemCount  int64   `json:"problem_count"`
+	SolvedCount   int64   `json:"solved_count"`
+	TrackedCount  int64   `json:"tracked_count"`  // Problems the user has attempted
+	AvgConfidence float64 `json:"avg_confidence"` // Over tracked problems
+}
+
+// DifficultyOutcomes splits attempts by outcome. Ungraded attempts were
+// completed without a pass or fail; percentages are of Attempts.
+type DifficultyOutcomes struct {
+	Difficulty      string  `json:"difficulty"`
+	Attempts        int64   `json:"attempts"`
+	Passed          int64   `json:"passed"`
+	Failed          int64   `json:"failed"`
+	Ungraded        int64   `json:"ungraded"`
+	PassedPercent   float64 `json:"passed_percent"`
+	FailedPercent   float64 `json:"failed_percent"`
+	UngradedPercent float64 `json:"ungraded_percent"`
+}
@@ -0,0 +1,140 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+var ErrInvalidWeek = errors.New("week must be an ISO week like 2024-W45")
+
+var isoWeekPattern = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// ParseISOWeek returns the Monday starting an ISO week string such as "2024-W45"
+func ParseISOWeek(value string, loc *time.Location) (time.Time, error) {
+	m := isoWeekPattern.FindStringSubmatch(value)
+	if m == nil {
+		return time.Time{}, ErrInvalidWeek
+	}
+	year, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+
+	// Dec 28 always falls in the year's last ISO week
+	_, weeksInYear := time.Date(year, time.December, 28, 0, 0, 0, 0, loc).ISOWeek()
+	if week < 1 || week > weeksInYear {
+		return time.Time{}, ErrInvalidWeek
+	}
+
+	// Jan 4 always falls in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	offset := (int(jan4.Weekday()) + 6) % 7 // days since Monday
+	return jan4.AddDate(0, 0, -offset+(week-1)*7), nil
+}
+
+// startOfISOWeek returns the Monday of the ISO week containing t
+func startOfISOWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// GetWeeklyReport summarizes the ISO week starting at weekStart (local
+// midnight on a Monday, or the zero time for the current week) and compares
+// it with the week before
+func (s *dashboardService) GetWeeklyReport(ctx context.Context, userID uuid.UUID, weekStart time.Time, loc *time.Location) (*WeeklyReport, error) {
+	if weekStart.IsZero() {
+		weekStart = startOfISOWeek(time.Now().In(loc))
+	}
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	prevStart := weekStart.AddDate(0, 0, -7)
+
+	current, err := s.weeklySummary(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.weeklySummary(ctx, userID, prevStart, weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.GetPatternConfidenceChanges(ctx, repo.GetPatternConfidenceChangesParams{
+		UserID:   userID,
+		FromDate: pgtype.Date{Time: weekStart, Valid: true},
+		ToDate:   pgtype.Date{Time: weekEnd, Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pattern confidence changes: %w", err)
+	}
+
+	patterns := make([]PatternConfidenceChange, len(rows))
+	for i, row := range rows {
+		patterns[i] = PatternConfidenceChange{
+			PatternID:       row.PatternID.String(),
+			Title:           row.Title,
+			StartConfidence: row.StartConfidence,
+			EndConfidence:   row.EndConfidence,
+			Delta:           row.EndConfidence - row.StartConfidence,
+		}
+	}
+
+	year, week := weekStart.ISOWeek()
+	return &WeeklyReport{
+		Week:         fmt.Sprintf("%04d-W%02d", year, week),
+		Timezone:     loc.String(),
+		From:         weekStart.Format("2006-01-02"),
+		To:           weekEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		Summary:      current,
+		PreviousWeek: previous,
+		Change: WeeklyChange{
+			Attempts:          current.Attempts - previous.Attempts,
+			PassRate:          current.PassRate - previous.PassRate,
+			Minutes:           current.Minutes - previous.Minutes,
+			SessionsCompleted: current.SessionsCompleted - previous.SessionsCompleted,
+			NewProblems:       current.NewProblems - previous.NewProblems,
+		},
+		Patterns: patterns,
+	}, nil
+}
+
+// weeklySummary aggregates activity in [from, to); an empty range is all zeros
+func (s *dashboardService) weeklySummary(ctx context.Context, userID uuid.UUID, from, to time.Time) (WeeklySummary, error) {
+	fromTs := pgtype.Timestamptz{Time: from, Valid: true}
+	toTs := pgtype.Timestamptz{Time: to, Valid: true}
+
+	attempts, err := s.repo.GetWeeklyAttemptSummary(ctx, repo.GetWeeklyAttemptSummaryParams{
+		UserID:   userID,
+		FromTime: fromTs,
+		ToTime:   toTs,
+	})
+	if err != nil {
+		return WeeklySummary{}, fmt.Errorf("failed to get attempt summary: %w", err)
+	}
+
+	sessions, err := s.repo.CountUserSessionsCompletedBetween(ctx, repo.CountUserSessionsCompletedBetweenParams{
+		UserID:   userID,
+		FromTime: fromTs,
+		ToTime:   toTs,
+	})
+	if err != nil {
+		return WeeklySummary{}, fmt.Errorf("failed to count completed sessions: %w", err)
+	}
+
+	summary := WeeklySummary{
+		Attempts:          attempts.AttemptCount,
+		Passed:            attempts.PassedCount,
+		Minutes:           (attempts.TotalSeconds + 30) / 60,
+		SessionsCompleted: sessions,
+		NewProblems:       attempts.NewProblems,
+	}
+	if attempts.GradedCount > 0 {
+		summary.PassRate = float64(attempts.PassedCount) / float64(attempts.GradedCount)
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,51 @@
+package digest
+
+import (
+	"net/http"
+
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service     Service
+	preferences preferences.Service // Timezone that decides what "today" is
+}
+
+func NewHandler(service Service, preferences preferences.Service) *handler {
+	return &handler{
+		service:     service,
+		preferences: preferences,
+	}
+}
+
+// GetDigest - GET /api/v1/digest?tz=
+func (h *handler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	locale, err := preferences.RequestLocale(r, h.preferences, userID)
+	if err != nil {
+		if preferences.IsInvalidLocale(err) {
+			utils.BadRequest(w, err.Error(), nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to load preferences", "error", err)
+		utils.InternalServerError(w, "Failed to load preferences")
+		return
+	}
+
+	digest, err := h.service.GetDigest(r.Context(), userID, locale.Location)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get digest", "error", err)
+		utils.InternalServerError(w, "Failed to get digest")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, digest)
+}
@@ -0,0 +1,185 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/dashboard"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/problems"
+	"github.com/vasujain275/reforge/internal/sessions"
+	"golang.org/x/sync/errgroup"
+)
+
+// urgentLimit keeps the digest small enough for a widget
+const urgentLimit = 5
+
+type Service interface {
+	GetDigest(ctx context.Context, userID uuid.UUID, loc *time.Location) (*Digest, error)
+}
+
+type digestService struct {
+	repo             repo.Querier
+	problemService   problems.Service
+	dashboardService dashboard.Service
+}
+
+func NewService(repo repo.Querier, problemService problems.Service, dashboardService dashboard.Service) Service {
+	return &digestService{
+		repo:             repo,
+		problemService:   problemService,
+		dashboardService: dashboardService,
+	}
+}
+
+// GetDigest loads each section concurrently. A failing section doesn't fail
+// the digest; it is logged, left out and reported in PartialErrors.
+func (s *digestService) GetDigest(ctx context.Context, userID uuid.UUID, loc *time.Location) (*Digest, error) {
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	digest := Digest{
+		Timezone: loc.String(),
+		Date:     today.Format("2006-01-02"),
+	}
+	var mu sync.Mutex
+
+	fail := func(err error, section string) {
+		logging.FromContext(ctx).Error("Failed to load digest section", "section", section, "error", err)
+		mu.Lock()
+		defer mu.Unlock()
+		if digest.PartialErrors == nil {
+			digest.PartialErrors = make(map[string]string)
+		}
+		digest.PartialErrors[section] = "query failed"
+	}
+
+	// Each goroutine writes only its own section, and none return an error so
+	// one failure doesn't cancel the others
+	var g errgroup.Group
+
+	g.Go(func() error {
+		due, err := s.dueSummary(ctx, userID, loc)
+		if err != nil {
+			fail(err, "due")
+			return nil
+		}
+		digest.Due = due
+		return nil
+	})
+
+	g.Go(func() error {
+		urgent, err := s.problemService.GetUrgentProblems(ctx, userID, urgentLimit, false)
+		if err != nil {
+			fail(err, "urgent")
+			return nil
+		}
+		digest.Urgent = make([]UrgentProblem, len(urgent))
+		for i, p := range urgent {
+			digest.Urgent[i] = UrgentProblem{
+				ID:         p.ID,
+				Title:      p.Title,
+				Difficulty: p.Difficulty,
+				Score:      p.Score,
+				Confidence: p.Confidence,
+				Reason:     p.Reason,
+			}
+		}
+		return nil
+	})
+
+	g.Go(func() error {
+		streak, err := s.dashboardService.GetStreak(ctx, userID, loc)
+		if err != nil {
+			fail(err, "streak")
+			return nil
+		}
+		digest.Streak = &StreakSummary{Current: streak.Current, AtRisk: streak.AtRisk}
+		return nil
+	})
+
+	g.Go(func() error {
+		yesterday := today.AddDate(0, 0, -1)
+		activity, err := s.repo.GetGoalActivityBetween(ctx, repo.GetGoalActivityBetweenParams{
+			UserID:   userID,
+			FromTime: pgtype.Timestamptz{Time: yesterday, Valid: true},
+			ToTime:   pgtype.Timestamptz{Time: today, Valid: true},
+		})
+		if err != nil {
+			fail(err, "yesterday")
+			return nil
+		}
+		digest.Yesterday = &ActivitySummary{
+			Date:              yesterday.Format("2006-01-02"),
+			SessionsCompleted: activity.SessionsCompleted,
+			ProblemsPracticed: activity.ProblemsPracticed,
+			Minutes:           activity.TotalSeconds / 60,
+		}
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Built from the other sections, so it waits for them
+	digest.RecommendedTemplate = recommendTemplate(&digest, now)
+
+	return &digest, nil
+}
+
+// dueSummary counts reviews due today and already overdue. Only the totals
+// are needed, so a single row is fetched per window.
+func (s *digestService) dueSummary(ctx context.Context, userID uuid.UUID, loc *time.Location) (*DueSummary, error) {
+	today, err := s.problemService.GetDueProblems(ctx, userID, "today", loc, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count due problems: %w", err)
+	}
+	overdue, err := s.problemService.GetDueProblems(ctx, userID, "overdue", loc, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count overdue problems: %w", err)
+	}
+	return &DueSummary{DueToday: today.Total, Overdue: overdue.Total}, nil
+}
+
+// recommendTemplate picks a session template from the digest: an easy win to
+// save a streak or restart after a day off, the weekend review on weekends,
+// weak spots when the most urgent problems are low-confidence, and the mixed
+// daily grind otherwise. Sections that failed to load are treated as unknown.
+func recommendTemplate(d *Digest, now time.Time) *TemplateSuggestion {
+	key, reason := "daily_mixed_grind", "A balanced mix for a regular practice day"
+
+	lowConfidence := 0
+	for _, p := range d.Urgent {
+		if p.Confidence < 50 {
+			lowConfidence++
+		}
+	}
+
+	switch {
+	case d.Streak != nil && d.Streak.AtRisk:
+		key, reason = "morning_momentum", "Quick wins to keep your streak alive"
+	case d.Yesterday != nil && d.Yesterday.ProblemsPracticed == 0 && d.Streak != nil && d.Streak.Current == 0:
+		key, reason = "morning_momentum", "Quick wins to get back into practice"
+	case now.Weekday() == time.Saturday || now.Weekday() == time.Sunday:
+		key, reason = "weekend_comprehensive", "The weekend leaves time for a longer review"
+	case len(d.Urgent) > 0 && lowConfidence*2 > len(d.Urgent):
+		key, reason = "weakness_crusher", "Most of your urgent problems are low-confidence"
+	}
+
+	template, ok := sessions.AllTemplates[key]
+	if !ok {
+		return nil
+	}
+	return &TemplateSuggestion{
+		Key:         template.Key,
+		DisplayName: template.DisplayName,
+		DurationMin: template.DurationMin,
+		Reason:      reason,
+	}
+}
@@ -0,0 +1,48 @@
+package digest
+
+// Digest is a morning summary for scripts and widgets. A section that failed
+// to load is left out and named in PartialErrors.
+type Digest struct {
+	Timezone            string              `json:"timezone"`
+	Date                string              `json:"date"` // Today in Timezone
+	Due                 *DueSummary         `json:"due,omitempty"`
+	Urgent              []UrgentProblem     `json:"urgent,omitempty"`
+	Streak              *StreakSummary      `json:"streak,omitempty"`
+	Yesterday           *ActivitySummary    `json:"yesterday,omitempty"`
+	RecommendedTemplate *TemplateSuggestion `json:"recommended_template,omitempty"`
+	PartialErrors       map[string]string   `json:"partial_errors,omitempty"`
+}
+
+type DueSummary struct {
+	DueToday int64 `json:"due_today"` // Due before midnight, overdue included
+	Overdue  int64 `json:"overdue"`
+}
+
+// UrgentProblem is the short form of problems.UrgentProblem
+type UrgentProblem struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Difficulty string  `json:"difficulty"`
+	Score      float64 `json:"score"`
+	Confidence int32   `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+type StreakSummary struct {
+	Current int64 `json:"current"`
+	AtRisk  bool  `json:"at_risk"`
+}
+
+type ActivitySummary struct {
+	Date              string `json:"date"`
+	SessionsCompleted int64  `json:"sessions_completed"`
+	ProblemsPracticed int64  `json:"problems_practiced"`
+	Minutes           int64  `json:"minutes"`
+}
+
+type TemplateSuggestion struct {
+	Key         string `json:"key"`
+	DisplayName string `json:"display_name"`
+	DurationMin int64  `json:"duration_min"`
+	Reason      string `json:"reason"`
+}
@@ -0,0 +1,93 @@
+// Package events fans out per-user change notifications to open SSE streams,
+// so a timer started on one device shows up live on the others.
+package events
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event types pushed on the timer stream
+const (
+	AttemptStarted   = "attempt.started"
+	AttemptTimer     = "attempt.timer"
+	AttemptCompleted = "attempt.completed"
+	AttemptAbandoned = "attempt.abandoned"
+	SessionTimer     = "session.timer"
+	SessionCompleted = "session.completed"
+)
+
+// Event is one change for a user; Type becomes the SSE event name and Data
+// its JSON payload
+type Event struct {
+	Type string
+	Data any
+}
+
+// Publisher is what services announce changes through
+type Publisher interface {
+	Publish(userID uuid.UUID, event Event)
+}
+
+// Noop drops every event, for callers nobody subscribes to (CLI commands)
+type Noop struct{}
+
+func (Noop) Publish(uuid.UUID, Event) {}
+
+// subscriberBuffer is how far a subscriber can fall behind before it starts
+// missing events
+const subscriberBuffer = 16
+
+// Hub is an in-process pub/sub keyed by user ID. Streams only see events from
+// the instance they are connected to.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan Event]struct{}
+}
+
+var _ Publisher = (*Hub)(nil)
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel of the user's events and a function that
+// unregisters it, which the caller must call when the stream ends
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+		})
+	}
+}
+
+// Publish delivers event to every stream the user has open. It never blocks
+// the caller: a subscriber with a full buffer misses the event and has to
+// refetch state, which clients already do when they reconnect.
+func (h *Hub) Publish(userID uuid.UUID, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package goals
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service     Service
+	preferences preferences.Service // Timezone and week start for progress periods
+}
+
+func NewHandler(service Service, preferences preferences.Service) *handler {
+	return &handler{
+		service:     service,
+		preferences: preferences,
+	}
+}
+
+// CreateGoal - POST /api/v1/goals
+func (h *handler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body CreateGoalBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	goal, err := h.service.CreateGoal(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidGoalType), errors.Is(err, ErrInvalidPeriod), errors.Is(err, ErrInvalidTarget):
+			utils.BadRequest(w, err.Error(), nil)
+		case errors.Is(err, ErrGoalExists):
+			utils.Conflict(w, err.Error(), nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to create goal", "error", err)
+			utils.InternalServerError(w, "Failed to create goal")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, goal)
+}
+
+// ListGoals - GET /api/v1/goals
+func (h *handler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	goals, err := h.service.ListGoals(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list goals", "error", err)
+		utils.InternalServerError(w, "Failed to list goals")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"goals": goals})
+}
+
+// UpdateGoal - PUT /api/v1/goals/:id
+func (h *handler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	goalID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid goal ID format", nil)
+		return
+	}
+
+	var body UpdateGoalBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	goal, err := h.service.UpdateGoal(r.Context(), userID, goalID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTarget):
+			utils.BadRequest(w, err.Error(), nil)
+		case errors.Is(err, ErrGoalNotFound):
+			utils.NotFound(w, "Goal not found")
+		default:
+			logging.FromContext(r.Context()).Error("Failed to update goal", "error", err)
+			utils.InternalServerError(w, "Failed to update goal")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, goal)
+}
+
+// DeleteGoal - DELETE /api/v1/goals/:id
+func (h *handler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	goalID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid goal ID format", nil)
+		return
+	}
+
+	if err := h.service.DeleteGoal(r.Context(), userID, goalID); err != nil {
+		if errors.Is(err, ErrGoalNotFound) {
+			utils.NotFound(w, "Goal not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete goal", "error", err)
+		utils.InternalServerError(w, "Failed to delete goal")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Goal deleted"})
+}
+
+// GetGoalProgress - GET /api/v1/goals/progress?tz=&week_start=
+func (h *handler) GetGoalProgress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	// Explicit parameters win over the caller's saved preferences
+	locale, err := preferences.RequestLocale(r, h.preferences, userID)
+	if err != nil {
+		if preferences.IsInvalidLocale(err) {
+			utils.BadRequest(w, err.Error(), nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to load preferences", "error", err)
+		utils.InternalServerError(w, "Failed to load preferences")
+		return
+	}
+
+	progress, err := h.service.GetGoalProgress(r.Context(), userID, locale.Location, locale.WeekStart)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get goal progress", "error", err)
+		utils.InternalServerError(w, "Failed to get goal progress")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"goals": progress})
+}
@@ -0,0 +1,93 @@
+package goals
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// PeriodBounds returns the period containing now as [start, end), with
+// boundaries at local midnight in now's location
+func PeriodBounds(period string, now time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case PeriodDay:
+		return today, today.AddDate(0, 0, 1)
+	case PeriodMonth:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := today.AddDate(0, 0, -((int(today.Weekday()) - int(weekStart) + 7) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+}
+
+// GetGoalProgress reports the current period of each active goal. Activity is
+// read once per distinct period, not once per goal.
+func (s *goalService) GetGoalProgress(ctx context.Context, userID uuid.UUID, loc *time.Location, weekStart time.Weekday) ([]GoalProgress, error) {
+	goals, err := s.repo.ListActiveUserGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active goals: %w", err)
+	}
+
+	now := time.Now().In(loc)
+	activity := make(map[string]repo.GetGoalActivityBetweenRow)
+
+	progress := make([]GoalProgress, 0, len(goals))
+	for _, goal := range goals {
+		start, end := PeriodBounds(goal.Period, now, weekStart)
+
+		counts, ok := activity[goal.Period]
+		if !ok {
+			counts, err = s.repo.GetGoalActivityBetween(ctx, repo.GetGoalActivityBetweenParams{
+				UserID:   userID,
+				FromTime: pgtype.Timestamptz{Time: start, Valid: true},
+				ToTime:   pgtype.Timestamptz{Time: end, Valid: true},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get goal activity: %w", err)
+			}
+			activity[goal.Period] = counts
+		}
+
+		var current int64
+		switch goal.GoalType {
+		case GoalTypeSessions:
+			current = counts.SessionsCompleted
+		case GoalTypeMinutes:
+			current = (counts.TotalSeconds + 30) / 60
+		case GoalTypeProblems:
+			current = counts.ProblemsPracticed
+		}
+
+		progress = append(progress, buildProgress(goal, current, start, end, now))
+	}
+
+	return progress, nil
+}
+
+// buildProgress compares current with a linear pace through the period
+func buildProgress(goal repo.UserGoal, current int64, start, end, now time.Time) GoalProgress {
+	target := float64(goal.TargetValue)
+	elapsed := now.Sub(start).Seconds() / end.Sub(start).Seconds()
+	expected := target * math.Min(math.Max(elapsed, 0), 1)
+
+	return GoalProgress{
+		GoalID:       goal.ID.String(),
+		GoalType:     goal.GoalType,
+		Period:       goal.Period,
+		PeriodStart:  start.Format(time.RFC3339),
+		PeriodEnd:    end.Format(time.RFC3339),
+		TargetValue:  goal.TargetValue,
+		CurrentValue: current,
+		Percentage:   math.Round(math.Min(float64(current)/target, 1)*1000) / 10,
+		ExpectedNow:  math.Round(expected*10) / 10,
+		OnTrack:      float64(current) >= expected,
+		Completed:    current >= int64(goal.TargetValue),
+	}
+}
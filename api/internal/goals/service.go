@@ -0,0 +1,159 @@
+package goals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+type Service interface {
+	CreateGoal(ctx context.Context, userID uuid.UUID, body CreateGoalBody) (*GoalResponse, error)
+	ListGoals(ctx context.Context, userID uuid.UUID) ([]GoalResponse, error)
+	UpdateGoal(ctx context.Context, userID, goalID uuid.UUID, body UpdateGoalBody) (*GoalResponse, error)
+	DeleteGoal(ctx context.Context, userID, goalID uuid.UUID) error
+	GetGoalProgress(ctx context.Context, userID uuid.UUID, loc *time.Location, weekStart time.Weekday) ([]GoalProgress, error)
+}
+
+type goalService struct {
+	repo repo.Querier
+}
+
+func NewService(repo repo.Querier) Service {
+	return &goalService{
+		repo: repo,
+	}
+}
+
+func (s *goalService) CreateGoal(ctx context.Context, userID uuid.UUID, body CreateGoalBody) (*GoalResponse, error) {
+	if body.Period == "" {
+		body.Period = PeriodWeek
+	}
+	if !validGoalType(body.GoalType) {
+		return nil, ErrInvalidGoalType
+	}
+	if !validPeriod(body.Period) {
+		return nil, ErrInvalidPeriod
+	}
+	if body.TargetValue < 1 {
+		return nil, ErrInvalidTarget
+	}
+
+	goal, err := s.repo.CreateUserGoal(ctx, repo.CreateUserGoalParams{
+		UserID:      userID,
+		GoalType:    body.GoalType,
+		TargetValue: body.TargetValue,
+		Period:      body.Period,
+	})
+	if err != nil {
+		// One goal per type and period (23505 is unique_violation)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrGoalExists
+		}
+		return nil, fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	resp := toGoalResponse(goal)
+	return &resp, nil
+}
+
+func (s *goalService) ListGoals(ctx context.Context, userID uuid.UUID) ([]GoalResponse, error) {
+	rows, err := s.repo.ListUserGoals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list goals: %w", err)
+	}
+
+	goals := make([]GoalResponse, len(rows))
+	for i, row := range rows {
+		goals[i] = toGoalResponse(row)
+	}
+	return goals, nil
+}
+
+func (s *goalService) UpdateGoal(ctx context.Context, userID, goalID uuid.UUID, body UpdateGoalBody) (*GoalResponse, error) {
+	goal, err := s.repo.GetUserGoal(ctx, repo.GetUserGoalParams{
+		ID:     goalID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	params := repo.UpdateUserGoalParams{
+		ID:          goalID,
+		UserID:      userID,
+		TargetValue: goal.TargetValue,
+		IsActive:    goal.IsActive,
+	}
+	if body.TargetValue != nil {
+		if *body.TargetValue < 1 {
+			return nil, ErrInvalidTarget
+		}
+		params.TargetValue = *body.TargetValue
+	}
+	if body.IsActive != nil {
+		params.IsActive = *body.IsActive
+	}
+
+	updated, err := s.repo.UpdateUserGoal(ctx, params)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGoalNotFound
+		}
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	resp := toGoalResponse(updated)
+	return &resp, nil
+}
+
+func (s *goalService) DeleteGoal(ctx context.Context, userID, goalID uuid.UUID) error {
+	deleted, err := s.repo.DeleteUserGoal(ctx, repo.DeleteUserGoalParams{
+		ID:     goalID,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	if deleted == 0 {
+		return ErrGoalNotFound
+	}
+	return nil
+}
+
+func toGoalResponse(goal repo.UserGoal) GoalResponse {
+	return GoalResponse{
+		ID:          goal.ID.String(),
+		GoalType:    goal.GoalType,
+		TargetValue: goal.TargetValue,
+		Period:      goal.Period,
+		IsActive:    goal.IsActive,
+		CreatedAt:   goal.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   goal.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func validGoalType(goalType string) bool {
+	switch goalType {
+	case GoalTypeSessions, GoalTypeMinutes, GoalTypeProblems:
+		return true
+	}
+	return false
+}
+
+func validPeriod(period string) bool {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	}
+	return false
+}
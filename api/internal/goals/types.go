@@ -0,0 +1,72 @@
+package goals
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrGoalNotFound    = fmt.Errorf("goal %w", utils.ErrNotFound)
+	ErrGoalExists      = errors.New("a goal of this type and period already exists")
+	ErrInvalidGoalType = errors.New("goal type must be one of: sessions, minutes, problems")
+	ErrInvalidPeriod   = errors.New("period must be one of: day, week, month")
+	ErrInvalidTarget   = errors.New("target value must be positive")
+)
+
+// Goal types: what a goal counts within its period
+const (
+	GoalTypeSessions = "sessions" // Revision sessions completed
+	GoalTypeMinutes  = "minutes"  // Minutes spent on completed attempts
+	GoalTypeProblems = "problems" // Distinct problems with a completed attempt
+)
+
+// Goal periods
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Request types
+
+type CreateGoalBody struct {
+	GoalType    string `json:"goal_type"    validate:"required,oneof=sessions minutes problems"`
+	TargetValue int32  `json:"target_value" validate:"required,gte=1"`
+	Period      string `json:"period"       validate:"omitempty,oneof=day week month"` // Defaults to week
+}
+
+// UpdateGoalBody changes the target or pauses a goal; omitted fields are kept
+type UpdateGoalBody struct {
+	TargetValue *int32 `json:"target_value" validate:"omitempty,gte=1"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// Response types
+
+type GoalResponse struct {
+	ID          string `json:"id"`
+	GoalType    string `json:"goal_type"`
+	TargetValue int32  `json:"target_value"`
+	Period      string `json:"period"`
+	IsActive    bool   `json:"is_active"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// GoalProgress is how far the current period of a goal has got. OnTrack
+// compares progress with a steady pace from the start of the period.
+type GoalProgress struct {
+	GoalID       string  `json:"goal_id"`
+	GoalType     string  `json:"goal_type"`
+	Period       string  `json:"period"`
+	PeriodStart  string  `json:"period_start"`
+	PeriodEnd    string  `json:"period_end"` // Exclusive
+	TargetValue  int32   `json:"target_value"`
+	CurrentValue int64   `json:"current_value"`
+	Percentage   float64 `json:"percentage"` // Capped at 100
+	ExpectedNow  float64 `json:"expected_now"`
+	OnTrack      bool    `json:"on_track"`
+	Completed    bool    `json:"completed"`
+}
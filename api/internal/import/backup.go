@@ -0,0 +1,782 @@
+package dataimport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+const (
+	// BackupVersion is bumped whenever the backup document shape changes
+	BackupVersion = 1
+
+	// restoreProgressEvery throttles restore progress events
+	restoreProgressEvery = 25
+)
+
+// Backup section names, in document order
+const (
+	SectionPatterns        = "patterns"
+	SectionProblems        = "problems"
+	SectionProblemPatterns = "problem_patterns"
+	SectionSessions        = "sessions"
+	SectionAttempts        = "attempts"
+	SectionProblemStats    = "problem_stats"
+	SectionPatternStats    = "pattern_stats"
+	SectionScoringSettings = "scoring_settings"
+)
+
+// ExportBackup streams the user's account backup as JSON. Problems and
+// patterns are shared across accounts, so the whole catalog is included.
+// Credentials, tokens and other users' data never are.
+func (s *importService) ExportBackup(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	patterns, err := s.repo.ListPatterns(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list patterns: %w", err)
+	}
+	problems, err := s.repo.ListAllProblems(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list problems: %w", err)
+	}
+	links, err := s.repo.ListAllProblemPatternLinks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list problem patterns: %w", err)
+	}
+	sessions, err := s.repo.ExportSessionsForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	attempts, err := s.repo.ExportAttemptsForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list attempts: %w", err)
+	}
+	problemStats, err := s.repo.ExportProblemStatsForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list problem stats: %w", err)
+	}
+	patternStats, err := s.repo.ListUserPatternStats(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list pattern stats: %w", err)
+	}
+	weights, err := s.repo.GetScoringWeights(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get scoring settings: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	out := &backupWriter{w: bw, enc: json.NewEncoder(bw)}
+
+	out.raw("{")
+	out.field("version", BackupVersion)
+	out.field("exported_at", time.Now().UTC().Format(time.RFC3339))
+	out.field("counts", map[string]int{
+		SectionPatterns:        len(patterns),
+		SectionProblems:        len(problems),
+		SectionProblemPatterns: len(links),
+		SectionSessions:        len(sessions),
+		SectionAttempts:        len(attempts),
+		SectionProblemStats:    len(problemStats),
+		SectionPatternStats:    len(patternStats),
+	})
+
+	out.beginArray(SectionPatterns)
+	for _, p := range patterns {
+		out.item(BackupPattern{
+			ID:          p.ID.String(),
+			Title:       p.Title,
+			Description: textPtr(p.Description),
+		})
+	}
+	out.endArray()
+
+	out.beginArray(SectionProblems)
+	for _, p := range problems {
+		out.item(BackupProblem{
+			ID:         p.ID.String(),
+			Title:      p.Title,
+			Source:     textPtr(p.Source),
+			URL:        textPtr(p.Url),
+			Difficulty: textPtr(p.Difficulty),
+		})
+	}
+	out.endArray()
+
+	out.beginArray(SectionProblemPatterns)
+	for _, l := range links {
+		out.item(BackupLink{ProblemID: l.ProblemID.String(), PatternID: l.PatternID.String()})
+	}
+	out.endArray()
+
+	out.beginArray(SectionSessions)
+	for _, sess := range sessions {
+		out.item(BackupSession{
+			ID:                 sess.ID.String(),
+			TemplateKey:        textPtr(sess.TemplateKey),
+			SessionName:        textPtr(sess.SessionName),
+			IsCustom:           boolPtr(sess.IsCustom),
+			CustomConfigJSON:   textPtr(sess.CustomConfigJson),
+			PlannedDurationMin: int4Ptr(sess.PlannedDurationMin),
+			ItemsOrdered:       textPtr(sess.ItemsOrdered),
+			ElapsedTimeSeconds: int4Ptr(sess.ElapsedTimeSeconds),
+			CreatedAt:          timePtr(sess.CreatedAt),
+			CompletedAt:        timePtr(sess.CompletedAt),
+		})
+	}
+	out.endArray()
+
+	out.beginArray(SectionAttempts)
+	for _, a := range attempts {
+		var sessionID *string
+		if a.SessionID.Valid {
+			id := uuid.UUID(a.SessionID.Bytes).String()
+			sessionID = &id
+		}
+		out.item(BackupAttempt{
+			ID:              a.ID.String(),
+			ProblemID:       a.ProblemID.String(),
+			SessionID:       sessionID,
+			ConfidenceScore: int4Ptr(a.ConfidenceScore),
+			DurationSeconds: int4Ptr(a.DurationSeconds),
+			Outcome:         textPtr(a.Outcome),
+			Notes:           textPtr(a.Notes),
+			Status:          textPtr(a.Status),
+			StartedAt:       timePtr(a.StartedAt),
+			PerformedAt:     timePtr(a.PerformedAt),
+		})
+	}
+	out.endArray()
+
+	out.beginArray(SectionProblemStats)
+	for _, st := range problemStats {
+		var ease *float32
+		if st.EaseFactor.Valid {
+			ease = &st.EaseFactor.Float32
+		}
+		out.item(BackupProblemStats{
+			ProblemID:           st.ProblemID.String(),
+			Status:              textPtr(st.Status),
+			Confidence:          int4Ptr(st.Confidence),
+			AvgConfidence:       int4Ptr(st.AvgConfidence),
+			LastAttemptAt:       timePtr(st.LastAttemptAt),
+			TotalAttempts:       int4Ptr(st.TotalAttempts),
+			AvgTimeSeconds:      int4Ptr(st.AvgTimeSeconds),
+			LastOutcome:         textPtr(st.LastOutcome),
+			NextReviewAt:        timePtr(st.NextReviewAt),
+			IntervalDays:        int4Ptr(st.IntervalDays),
+			EaseFactor:          ease,
+			ReviewCount:         int4Ptr(st.ReviewCount),
+			ConsecutiveFailures: int4Ptr(st.ConsecutiveFailures),
+			Notes:               textPtr(st.Notes),
+		})
+	}
+	out.endArray()
+
+	out.beginArray(SectionPatternStats)
+	for _, st := range patternStats {
+		out.item(BackupPatternStats{
+			PatternID:     st.PatternID.String(),
+			TimesRevised:  int4Ptr(st.TimesRevised),
+			AvgConfidence: int4Ptr(st.AvgConfidence),
+			LastRevisedAt: timePtr(st.LastRevisedAt),
+		})
+	}
+	out.endArray()
+
+	settings := make(map[string]string, len(weights))
+	for _, row := range weights {
+		settings[row.Key] = row.Value
+	}
+	out.field(SectionScoringSettings, settings)
+	out.raw("}")
+
+	if out.err != nil {
+		return fmt.Errorf("failed to write backup: %w", out.err)
+	}
+	return bw.Flush()
+}
+
+// RestoreBackup stream-parses a backup document into the user's account.
+// Each section is restored in its own transaction; patterns and problems are
+// matched to existing ones by title (and source or URL) and every ID is remapped.
+// Scoring settings are global, so they're only applied when applySettings is set.
+func (s *importService) RestoreBackup(ctx context.Context, userID uuid.UUID, reader io.Reader, applySettings bool, progressFn ProgressCallback) (*RestoreResult, error) {
+	result, err := s.restoreBackup(ctx, userID, reader, applySettings, progressFn)
+	if err != nil {
+		s.metrics.ImportRun(metrics.ImportRestore, metrics.OutcomeError)
+	} else {
+		s.metrics.ImportRun(metrics.ImportRestore, metrics.OutcomeSuccess)
+	}
+	return result, err
+}
+
+func (s *importService) restoreBackup(ctx context.Context, userID uuid.UUID, reader io.Reader, applySettings bool, progressFn ProgressCallback) (*RestoreResult, error) {
+	startTime := time.Now()
+
+	r := &restorer{
+		service:    s,
+		userID:     userID,
+		progressFn: progressFn,
+		patternIDs: make(map[string]uuid.UUID),
+		problemIDs: make(map[string]uuid.UUID),
+		sessionIDs: make(map[string]uuid.UUID),
+		result: &RestoreResult{
+			Success:   true,
+			Sections:  make(map[string]*RestoreSectionCount),
+			Conflicts: make([]RestoreConflict, 0),
+		},
+	}
+
+	dec := json.NewDecoder(reader)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup document: %w", err)
+		}
+		key, _ := tok.(string)
+
+		switch key {
+		case "version":
+			var version int
+			if err := dec.Decode(&version); err != nil {
+				return nil, fmt.Errorf("invalid backup version: %w", err)
+			}
+			if version != BackupVersion {
+				return nil, fmt.Errorf("unsupported backup version %d", version)
+			}
+		case "counts":
+			if err := dec.Decode(&r.counts); err != nil {
+				return nil, fmt.Errorf("invalid backup counts: %w", err)
+			}
+		case SectionPatterns:
+			err = restoreSection(ctx, r, dec, key, r.restorePattern)
+		case SectionProblems:
+			err = restoreSection(ctx, r, dec, key, r.restoreProblem)
+		case SectionProblemPatterns:
+			err = restoreSection(ctx, r, dec, key, r.restoreLink)
+		case SectionSessions:
+			r.buildProblemIDReplacer()
+			err = restoreSection(ctx, r, dec, key, r.restoreSession)
+		case SectionAttempts:
+			err = restoreSection(ctx, r, dec, key, r.restoreAttempt)
+		case SectionProblemStats:
+			err = restoreSection(ctx, r, dec, key, r.restoreProblemStats)
+		case SectionPatternStats:
+			err = restoreSection(ctx, r, dec, key, r.restorePatternStats)
+		case SectionScoringSettings:
+			var settings map[string]string
+			if err := dec.Decode(&settings); err != nil {
+				return nil, fmt.Errorf("invalid scoring settings: %w", err)
+			}
+			err = r.restoreSettings(ctx, settings, applySettings)
+		default:
+			// exported_at and unknown keys carry nothing to restore
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	// Restored stats make any cached scores stale
+	if err := s.repo.DeleteProblemScoresForUser(ctx, userID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to invalidate cached scores", "error", err)
+	}
+
+	r.result.Duration = formatDuration(time.Since(startTime))
+	progressFn(ImportProgress{
+		Phase:       "complete",
+		CurrentItem: "Restore complete",
+		Percentage:  100,
+	})
+
+	return r.result, nil
+}
+
+// restorer holds the ID maps that carry references between backup sections
+type restorer struct {
+	service    *importService
+	userID     uuid.UUID
+	progressFn ProgressCallback
+	counts     map[string]int
+	result     *RestoreResult
+
+	patternIDs map[string]uuid.UUID // backup ID -> restored ID
+	problemIDs map[string]uuid.UUID
+	sessionIDs map[string]uuid.UUID
+
+	problemIDReplacer *strings.Replacer
+}
+
+// restoreSection decodes one array section item by item inside a single
+// transaction, so a failed section leaves nothing half-written
+func restoreSection[T any](ctx context.Context, r *restorer, dec *json.Decoder, section string, restoreFn func(context.Context, repo.Querier, T, *RestoreSectionCount) error) error {
+	processed := 0
+	err := r.service.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		count := &RestoreSectionCount{}
+		r.result.Sections[section] = count
+
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for dec.More() {
+			var item T
+			if err := dec.Decode(&item); err != nil {
+				return fmt.Errorf("invalid %s entry: %w", section, err)
+			}
+			if err := restoreFn(ctx, q, item, count); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", section, err)
+			}
+
+			processed++
+			if processed%restoreProgressEvery == 0 {
+				r.reportProgress(section, processed)
+			}
+		}
+		return expectDelim(dec, ']')
+	})
+	if err != nil {
+		return err
+	}
+	r.reportProgress(section, processed)
+	return nil
+}
+
+func (r *restorer) reportProgress(section string, processed int) {
+	total := r.counts[section]
+	percentage := 100.0
+	if total > 0 {
+		percentage = float64(processed) / float64(total) * 100
+	}
+	r.progressFn(ImportProgress{
+		Phase:        section,
+		CurrentItem:  section,
+		CurrentIndex: processed,
+		TotalItems:   total,
+		Percentage:   percentage,
+	})
+}
+
+func (r *restorer) conflict(section, id, reason string) {
+	r.result.Conflicts = append(r.result.Conflicts, RestoreConflict{Section: section, ID: id, Reason: reason})
+}
+
+func (r *restorer) restorePattern(ctx context.Context, q repo.Querier, item BackupPattern, count *RestoreSectionCount) error {
+	existing, err := q.GetPatternByTitle(ctx, item.Title)
+	if err == nil {
+		r.patternIDs[item.ID] = existing.ID
+		count.Matched++
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	created, err := q.CreatePattern(ctx, repo.CreatePatternParams{
+		Title:       item.Title,
+		Description: toText(item.Description),
+	})
+	if err != nil {
+		return err
+	}
+	r.patternIDs[item.ID] = created.ID
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restoreProblem(ctx context.Context, q repo.Querier, item BackupProblem, count *RestoreSectionCount) error {
+	if item.Source != nil {
+		existing, err := q.GetProblemByTitleAndSource(ctx, repo.GetProblemByTitleAndSourceParams{
+			Title:  item.Title,
+			Source: toText(item.Source),
+		})
+		if err == nil {
+			r.problemIDs[item.ID] = existing.ID
+			count.Matched++
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+	if item.URL != nil && *item.URL != "" {
+		existing, err := q.FindProblemByNormalizedURL(ctx, utils.NormalizeProblemURL(*item.URL))
+		if err == nil {
+			r.problemIDs[item.ID] = existing.ID
+			count.Matched++
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	created, err := q.CreateProblem(ctx, repo.CreateProblemParams{
+		Title:      item.Title,
+		Source:     toText(item.Source),
+		Url:        toText(item.URL),
+		Difficulty: toText(item.Difficulty),
+	})
+	if err != nil {
+		return err
+	}
+	r.problemIDs[item.ID] = created.ID
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restoreLink(ctx context.Context, q repo.Querier, item BackupLink, count *RestoreSectionCount) error {
+	problemID, okProblem := r.problemIDs[item.ProblemID]
+	patternID, okPattern := r.patternIDs[item.PatternID]
+	if !okProblem || !okPattern {
+		r.conflict(SectionProblemPatterns, item.ProblemID+":"+item.PatternID, "references a problem or pattern missing from the backup")
+		count.Skipped++
+		return nil
+	}
+
+	if err := q.LinkProblemToPatternIfNotExists(ctx, repo.LinkProblemToPatternIfNotExistsParams{
+		ProblemID: problemID,
+		PatternID: patternID,
+	}); err != nil {
+		return err
+	}
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restoreSession(ctx context.Context, q repo.Querier, item BackupSession, count *RestoreSectionCount) error {
+	// A session with the same start time means this backup was restored before
+	if item.CreatedAt != nil {
+		existingID, err := q.FindSessionByCreatedAt(ctx, repo.FindSessionByCreatedAtParams{
+			UserID:    r.userID,
+			CreatedAt: toTimestamptz(item.CreatedAt),
+		})
+		if err == nil {
+			r.sessionIDs[item.ID] = existingID
+			count.Matched++
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	items := item.ItemsOrdered
+	if items != nil && r.problemIDReplacer != nil {
+		remapped := r.problemIDReplacer.Replace(*items)
+		items = &remapped
+	}
+
+	isCustom := pgtype.Bool{}
+	if item.IsCustom != nil {
+		isCustom = pgtype.Bool{Bool: *item.IsCustom, Valid: true}
+	}
+
+	id, err := q.RestoreSession(ctx, repo.RestoreSessionParams{
+		UserID:             r.userID,
+		TemplateKey:        toText(item.TemplateKey),
+		SessionName:        toText(item.SessionName),
+		IsCustom:           isCustom,
+		CustomConfigJson:   toText(item.CustomConfigJSON),
+		PlannedDurationMin: toInt4(item.PlannedDurationMin),
+		ItemsOrdered:       toText(items),
+		ElapsedTimeSeconds: toInt4(item.ElapsedTimeSeconds),
+		CreatedAt:          toTimestamptz(item.CreatedAt),
+		CompletedAt:        toTimestamptz(item.CompletedAt),
+	})
+	if err != nil {
+		return err
+	}
+	r.sessionIDs[item.ID] = id
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restoreAttempt(ctx context.Context, q repo.Querier, item BackupAttempt, count *RestoreSectionCount) error {
+	problemID, ok := r.problemIDs[item.ProblemID]
+	if !ok {
+		r.conflict(SectionAttempts, item.ID, "references a problem missing from the backup")
+		count.Skipped++
+		return nil
+	}
+
+	if item.PerformedAt != nil {
+		exists, err := q.AttemptExistsAt(ctx, repo.AttemptExistsAtParams{
+			UserID:      r.userID,
+			ProblemID:   problemID,
+			PerformedAt: toTimestamptz(item.PerformedAt),
+		})
+		if err != nil {
+			return err
+		}
+		if exists {
+			count.Matched++
+			return nil
+		}
+	}
+
+	sessionID := pgtype.UUID{}
+	if item.SessionID != nil {
+		if id, ok := r.sessionIDs[*item.SessionID]; ok {
+			sessionID = pgtype.UUID{Bytes: id, Valid: true}
+		}
+	}
+
+	if err := q.RestoreAttempt(ctx, repo.RestoreAttemptParams{
+		UserID:          r.userID,
+		ProblemID:       problemID,
+		SessionID:       sessionID,
+		ConfidenceScore: toInt4(item.ConfidenceScore),
+		DurationSeconds: toInt4(item.DurationSeconds),
+		Outcome:         toText(item.Outcome),
+		Notes:           toText(item.Notes),
+		Status:          toText(item.Status),
+		StartedAt:       toTimestamptz(item.StartedAt),
+		PerformedAt:     toTimestamptz(item.PerformedAt),
+	}); err != nil {
+		return err
+	}
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restoreProblemStats(ctx context.Context, q repo.Querier, item BackupProblemStats, count *RestoreSectionCount) error {
+	problemID, ok := r.problemIDs[item.ProblemID]
+	if !ok {
+		r.conflict(SectionProblemStats, item.ProblemID, "references a problem missing from the backup")
+		count.Skipped++
+		return nil
+	}
+
+	ease := pgtype.Float4{}
+	if item.EaseFactor != nil {
+		ease = pgtype.Float4{Float32: *item.EaseFactor, Valid: true}
+	}
+
+	if err := q.RestoreUserProblemStats(ctx, repo.RestoreUserProblemStatsParams{
+		UserID:              r.userID,
+		ProblemID:           problemID,
+		Status:              toText(item.Status),
+		Confidence:          toInt4(item.Confidence),
+		AvgConfidence:       toInt4(item.AvgConfidence),
+		LastAttemptAt:       toTimestamptz(item.LastAttemptAt),
+		TotalAttempts:       toInt4(item.TotalAttempts),
+		AvgTimeSeconds:      toInt4(item.AvgTimeSeconds),
+		LastOutcome:         toText(item.LastOutcome),
+		NextReviewAt:        toTimestamptz(item.NextReviewAt),
+		IntervalDays:        toInt4(item.IntervalDays),
+		EaseFactor:          ease,
+		ReviewCount:         toInt4(item.ReviewCount),
+		ConsecutiveFailures: toInt4(item.ConsecutiveFailures),
+		Notes:               toText(item.Notes),
+	}); err != nil {
+		return err
+	}
+	count.Created++
+	return nil
+}
+
+func (r *restorer) restorePatternStats(ctx context.Context, q repo.Querier, item BackupPatternStats, count *RestoreSectionCount) error {
+	patternID, ok := r.patternIDs[item.PatternID]
+	if !ok {
+		r.conflict(SectionPatternStats, item.PatternID, "references a pattern missing from the backup")
+		count.Skipped++
+		return nil
+	}
+
+	if _, err := q.UpsertUserPatternStats(ctx, repo.UpsertUserPatternStatsParams{
+		UserID:        r.userID,
+		PatternID:     patternID,
+		TimesRevised:  toInt4(item.TimesRevised),
+		AvgConfidence: toInt4(item.AvgConfidence),
+		LastRevisedAt: toTimestamptz(item.LastRevisedAt),
+	}); err != nil {
+		return err
+	}
+	count.Created++
+	return nil
+}
+
+// restoreSettings writes back known scoring weights; unknown keys are ignored
+func (r *restorer) restoreSettings(ctx context.Context, settings map[string]string, apply bool) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	if !apply {
+		r.conflict(SectionScoringSettings, "", "scoring settings are global and only restored for admins")
+		return nil
+	}
+
+	current, err := r.service.repo.GetScoringWeights(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get scoring settings: %w", err)
+	}
+
+	err = r.service.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		for _, row := range current {
+			value, ok := settings[row.Key]
+			if !ok || value == row.Value {
+				continue
+			}
+			if _, err := q.UpdateSystemSetting(ctx, repo.UpdateSystemSettingParams{
+				Value: value,
+				Key:   row.Key,
+			}); err != nil {
+				return fmt.Errorf("failed to restore setting %s: %w", row.Key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.result.SettingsApplied = true
+	return nil
+}
+
+// buildProblemIDReplacer prepares rewriting of problem IDs embedded in
+// session item lists, which are stored as opaque JSON text
+func (r *restorer) buildProblemIDReplacer() {
+	pairs := make([]string, 0, len(r.problemIDs)*2)
+	for oldID, newID := range r.problemIDs {
+		if oldID != newID.String() {
+			pairs = append(pairs, oldID, newID.String())
+		}
+	}
+	if len(pairs) > 0 {
+		r.problemIDReplacer = strings.NewReplacer(pairs...)
+	}
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid backup document: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("invalid backup document: expected %q", want)
+	}
+	return nil
+}
+
+// backupWriter writes a JSON object incrementally, keeping the first error
+type backupWriter struct {
+	w         *bufio.Writer
+	enc       *json.Encoder
+	err       error
+	needComma bool // a separator is due before the next field or item
+}
+
+func (b *backupWriter) raw(s string) {
+	if b.err == nil {
+		_, b.err = b.w.WriteString(s)
+	}
+}
+
+func (b *backupWriter) key(name string) {
+	if b.needComma {
+		b.raw(",")
+	}
+	b.raw(fmt.Sprintf("%q:", name))
+}
+
+func (b *backupWriter) field(name string, value any) {
+	b.key(name)
+	b.value(value)
+	b.needComma = true
+}
+
+func (b *backupWriter) beginArray(name string) {
+	b.key(name)
+	b.raw("[")
+	b.needComma = false
+}
+
+func (b *backupWriter) item(value any) {
+	if b.needComma {
+		b.raw(",")
+	}
+	b.value(value)
+	b.needComma = true
+}
+
+func (b *backupWriter) endArray() {
+	b.raw("]")
+	b.needComma = true
+}
+
+func (b *backupWriter) value(value any) {
+	if b.err == nil {
+		b.err = b.enc.Encode(value)
+	}
+}
+
+func textPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func toText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+func int4Ptr(i pgtype.Int4) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int32
+}
+
+func toInt4(i *int32) pgtype.Int4 {
+	if i == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *i, Valid: true}
+}
+
+func boolPtr(b pgtype.Bool) *bool {
+	if !b.Valid {
+		return nil
+	}
+	return &b.Bool
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
+
+func toTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
@@ -0,0 +1,116 @@
+package dataimport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	sampledatasets "github.com/vasujain275/reforge/sample-datasets"
+)
+
+// bundledDatasetDef describes a dataset shipped inside the binary. Adding a
+// dataset is one registry entry plus its CSV in sample-datasets; counts are
+// derived from the file itself, see loadBundledDatasets.
+type bundledDatasetDef struct {
+	ID          string
+	Name        string
+	Description string // Formatted with the problem count
+	FileName    string
+}
+
+// bundledDatasetRegistry lists the bundled datasets in display order
+var bundledDatasetRegistry = []bundledDatasetDef{
+	{
+		ID:          "leetcode",
+		Name:        "LeetCode Problems",
+		Description: "%d free LeetCode problems with patterns (premium excluded)",
+		FileName:    "leetcode.csv",
+	},
+	{
+		ID:          "neetcode150",
+		Name:        "NeetCode 150",
+		Description: "The %d problems of the NeetCode 150 roadmap",
+		FileName:    "neetcode150.csv",
+	},
+	{
+		ID:          "blind75",
+		Name:        "Blind 75",
+		Description: "The %d problems of the Blind 75 list",
+		FileName:    "blind75.csv",
+	},
+}
+
+// lookupBundledDataset finds a registry entry by dataset ID
+func lookupBundledDataset(datasetID string) (bundledDatasetDef, bool) {
+	for _, def := range bundledDatasetRegistry {
+		if def.ID == datasetID {
+			return def, true
+		}
+	}
+	return bundledDatasetDef{}, false
+}
+
+// loadBundledDatasets parses every bundled dataset once and derives its
+// problem, pattern and difficulty counts
+func (s *importService) loadBundledDatasets() ([]BundledDataset, error) {
+	datasets := make([]BundledDataset, 0, len(bundledDatasetRegistry))
+
+	for _, def := range bundledDatasetRegistry {
+		reader, err := s.openDatasetFile(def.FileName)
+		if err != nil {
+			return nil, err
+		}
+
+		problems, _, err := s.parser.ParseCSV(reader)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bundled dataset %s: %w", def.FileName, err)
+		}
+
+		datasets = append(datasets, BundledDataset{
+			ID:           def.ID,
+			Name:         def.Name,
+			Description:  fmt.Sprintf(def.Description, len(problems)),
+			FileName:     def.FileName,
+			ProblemCount: len(problems),
+			PatternCount: len(s.parser.GetUniquePatterns(problems)),
+			Difficulties: s.parser.CountDifficulties(problems),
+		})
+	}
+
+	return datasets, nil
+}
+
+// getBundledDatasetReader returns a reader for a bundled dataset
+func (s *importService) getBundledDatasetReader(datasetID string) (io.ReadCloser, error) {
+	def, ok := lookupBundledDataset(datasetID)
+	if !ok {
+		return nil, fmt.Errorf("unknown dataset: %s", datasetID)
+	}
+
+	return s.openDatasetFile(def.FileName)
+}
+
+// openDatasetFile opens a dataset from the embedded files. When a dataset
+// path is configured, a file of the same name there takes precedence.
+func (s *importService) openDatasetFile(fileName string) (io.ReadCloser, error) {
+	if s.datasetPath != "" {
+		file, err := os.Open(filepath.Join(s.datasetPath, fileName))
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to open dataset override %s: %w", fileName, err)
+		}
+	}
+
+	file, err := sampledatasets.EmbeddedDatasets.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("could not find bundled dataset file: %s", fileName)
+	}
+
+	return file, nil
+}
@@ -1,11 +1,19 @@
 package dataimport
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -26,7 +34,7 @@ func NewHandler(service Service) *Handler {
 func (h *Handler) GetBundledDatasets(w http.ResponseWriter, r *http.Request) {
 	datasets, err := h.service.GetBundledDatasets(r.Context())
 	if err != nil {
-		slog.Error("Failed to get bundled datasets", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get bundled datasets", "error", err)
 		utils.InternalServerError(w, "Failed to get bundled datasets")
 		return
 	}
@@ -38,8 +46,8 @@ func (h *Handler) GetBundledDatasets(w http.ResponseWriter, r *http.Request) {
 // Parses a bundled dataset and returns analysis without importing
 func (h *Handler) ParseBundledDataset(w http.ResponseWriter, r *http.Request) {
 	var req ParseCSVRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
@@ -48,9 +56,14 @@ func (h *Handler) ParseBundledDataset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.service.ParseBundledDataset(r.Context(), req.DatasetID)
+	if _, err := NormalizeDuplicateMode(req.OnDuplicate); err != nil {
+		utils.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	result, err := h.service.ParseBundledDataset(r.Context(), req.DatasetID, req.OnDuplicate)
 	if err != nil {
-		slog.Error("Failed to parse bundled dataset", "error", err, "dataset_id", req.DatasetID)
+		logging.FromContext(r.Context()).Error("Failed to parse bundled dataset", "error", err, "dataset_id", req.DatasetID)
 		utils.InternalServerError(w, fmt.Sprintf("Failed to parse dataset: %v", err))
 		return
 	}
@@ -61,8 +74,12 @@ func (h *Handler) ParseBundledDataset(w http.ResponseWriter, r *http.Request) {
 // ParseUploadedCSV - POST /api/v1/admin/import/parse-upload
 // Parses an uploaded CSV file and returns analysis without importing
 func (h *Handler) ParseUploadedCSV(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 10MB)
+	// Parse multipart form (10MB in memory, the rest spills to disk)
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		if utils.IsBodyTooLarge(err) {
+			utils.PayloadTooLarge(w, "Uploaded file is too large")
+			return
+		}
 		utils.BadRequest(w, "Failed to parse form data", nil)
 		return
 	}
@@ -74,9 +91,24 @@ func (h *Handler) ParseUploadedCSV(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	result, err := h.service.ParseCSV(r.Context(), file)
+	onDuplicate := r.FormValue("on_duplicate")
+	if _, err := NormalizeDuplicateMode(onDuplicate); err != nil {
+		utils.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	mapping, err := parseCSVMappingForm(r)
 	if err != nil {
-		slog.Error("Failed to parse uploaded CSV", "error", err)
+		utils.BadRequest(w, err.Error(), nil)
+		return
+	}
+
+	result, err := h.service.ParseCSV(r.Context(), file, onDuplicate, mapping)
+	if err != nil {
+		if writeMissingColumns(w, err) {
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to parse uploaded CSV", "error", err)
 		utils.BadRequest(w, fmt.Sprintf("Failed to parse CSV: %v", err), nil)
 		return
 	}
@@ -90,11 +122,16 @@ func (h *Handler) ExecuteImport(w http.ResponseWriter, r *http.Request) {
 	// Get query parameters
 	useBundled := r.URL.Query().Get("use_bundled") == "true"
 	datasetID := r.URL.Query().Get("dataset_id")
+	onDuplicate := r.URL.Query().Get("on_duplicate")
 
 	if useBundled && datasetID == "" {
 		http.Error(w, "dataset_id is required when use_bundled is true", http.StatusBadRequest)
 		return
 	}
+	if _, err := NormalizeDuplicateMode(onDuplicate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -110,35 +147,45 @@ func (h *Handler) ExecuteImport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send initial connection event
-	sendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
+	utils.SendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
 
 	// Progress callback for SSE
 	progressFn := func(progress ImportProgress) {
-		sendSSEEvent(w, flusher, "progress", progress)
+		utils.SendSSEEvent(w, flusher, "progress", progress)
 	}
 
 	// Execute import
 	opts := ImportOptions{
-		UseBundled: useBundled,
-		DatasetID:  datasetID,
+		UseBundled:   useBundled,
+		DatasetID:    datasetID,
+		SkipPatterns: r.URL.Query().Get("skip_patterns") == "true",
+		OnDuplicate:  onDuplicate,
+		TagDataset:   r.URL.Query().Get("tag_dataset") == "true",
+	}
+	if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+		opts.UserID = &userID
 	}
 
 	result, err := h.service.ExecuteImport(r.Context(), opts, progressFn)
 	if err != nil {
-		slog.Error("Import failed", "error", err)
-		sendSSEEvent(w, flusher, "error", map[string]string{"error": err.Error()})
+		logging.FromContext(r.Context()).Error("Import failed", "error", err)
+		utils.SendSSEEvent(w, flusher, "error", map[string]string{"error": err.Error()})
 		return
 	}
 
 	// Send final result
-	sendSSEEvent(w, flusher, "complete", result)
+	sendImportResult(r.Context(), w, flusher, result)
 }
 
 // ExecuteUploadImport - POST /api/v1/admin/import/execute-upload (SSE endpoint)
 // Executes import from uploaded CSV with real-time progress
 func (h *Handler) ExecuteUploadImport(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 10MB)
+	// Parse multipart form (10MB in memory, the rest spills to disk)
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		if utils.IsBodyTooLarge(err) {
+			http.Error(w, "Uploaded file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "Failed to parse form data", http.StatusBadRequest)
 		return
 	}
@@ -150,6 +197,36 @@ func (h *Handler) ExecuteUploadImport(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	opts := ImportOptions{
+		SkipPatterns: r.FormValue("skip_patterns") == "true",
+		OnDuplicate:  r.FormValue("on_duplicate"),
+	}
+	if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+		opts.UserID = &userID
+	}
+	if _, err := NormalizeDuplicateMode(opts.OnDuplicate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts.Mapping, err = parseCSVMappingForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Check the header before streaming, so a bad mapping is a plain 400
+	if err := CheckCSVColumns(file, opts.Mapping); err != nil {
+		if !writeMissingColumns(w, err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, "Failed to read CSV file", http.StatusInternalServerError)
+		return
+	}
+
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -164,34 +241,202 @@ func (h *Handler) ExecuteUploadImport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Send initial connection event
-	sendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
+	utils.SendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
 
 	// Progress callback for SSE
 	progressFn := func(progress ImportProgress) {
-		sendSSEEvent(w, flusher, "progress", progress)
+		utils.SendSSEEvent(w, flusher, "progress", progress)
 	}
 
 	// Execute import
-	result, err := h.service.ExecuteImportFromReader(r.Context(), file, progressFn)
+	result, err := h.service.ExecuteImportFromReader(r.Context(), file, opts, progressFn)
 	if err != nil {
-		slog.Error("Import failed", "error", err)
-		sendSSEEvent(w, flusher, "error", map[string]string{"error": err.Error()})
+		logging.FromContext(r.Context()).Error("Import failed", "error", err)
+		utils.SendSSEEvent(w, flusher, "error", map[string]string{"error": err.Error()})
 		return
 	}
 
 	// Send final result
-	sendSSEEvent(w, flusher, "complete", result)
+	sendImportResult(r.Context(), w, flusher, result)
+}
+
+// sendImportResult sends the final import event. A cancelled import usually
+// means the client disconnected, but a proxy may still deliver the event.
+func sendImportResult(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, result *ImportResult) {
+	if result.Cancelled {
+		logging.FromContext(ctx).Info("Import cancelled", "problems_created", result.ProblemsCreated, "patterns_created", result.PatternsCreated)
+		utils.SendSSEEvent(w, flusher, "cancelled", result)
+		return
+	}
+	utils.SendSSEEvent(w, flusher, "complete", result)
+}
+
+// ExportBackup - GET /api/v1/export/backup
+// Downloads the current user's account backup as JSON
+func (h *Handler) ExportBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	filename := fmt.Sprintf("reforge-backup-%s.json", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers are already sent once streaming starts, so failures can only be logged
+	if err := h.service.ExportBackup(r.Context(), userID, w); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to export backup", "error", err)
+	}
 }
 
-// sendSSEEvent sends a Server-Sent Event
-func sendSSEEvent(w http.ResponseWriter, flusher http.Flusher, eventType string, data interface{}) {
-	jsonData, err := json.Marshal(data)
+// RestoreBackup - POST /api/v1/import/restore (SSE endpoint)
+// Restores a backup sent as the raw JSON request body with real-time progress
+func (h *Handler) RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+	role := auth.RoleFromContext(r.Context())
+
+	// The body is parsed while events are written, which HTTP/1.x needs opted into
+	_ = http.NewResponseController(w).EnableFullDuplex()
+
+	// Set SSE headers
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	// Get flusher for streaming
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Send initial connection event
+	utils.SendSSEEvent(w, flusher, "connected", map[string]string{"status": "connected"})
+
+	// Progress callback for SSE
+	progressFn := func(progress ImportProgress) {
+		utils.SendSSEEvent(w, flusher, "progress", progress)
+	}
+
+	result, err := h.service.RestoreBackup(r.Context(), userID, r.Body, role == "admin", progressFn)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Restore failed", "error", err)
+		utils.SendSSEEvent(w, flusher, "error", map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Send final result
+	utils.SendSSEEvent(w, flusher, "complete", result)
+}
+
+// ListImportJobs - GET /api/v1/admin/data/import/jobs
+// Returns import job history, newest first
+func (h *Handler) ListImportJobs(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	jobs, err := h.service.ListImportJobs(r.Context(), page, limit)
 	if err != nil {
-		slog.Error("Failed to marshal SSE data", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list import jobs", "error", err)
+		utils.InternalServerError(w, "Failed to list import jobs")
 		return
 	}
 
-	fmt.Fprintf(w, "event: %s\n", eventType)
-	fmt.Fprintf(w, "data: %s\n\n", jsonData)
-	flusher.Flush()
+	utils.WriteSuccess(w, http.StatusOK, jobs)
+}
+
+// GetImportJob - GET /api/v1/admin/data/import/jobs/{id}
+// Returns one import job, e.g. to check a run whose SSE stream dropped
+func (h *Handler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid import job ID", nil)
+		return
+	}
+
+	job, err := h.service.GetImportJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, ErrImportJobNotFound) {
+			utils.NotFound(w, "Import job not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get import job", "error", err, "job_id", jobID)
+		utils.InternalServerError(w, "Failed to get import job")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, job)
+}
+
+// GetImportJobErrors - GET /api/v1/admin/data/import/jobs/{id}/errors.csv
+// Downloads the row errors of an import job as CSV
+func (h *Handler) GetImportJobErrors(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid import job ID", nil)
+		return
+	}
+
+	// Check the job first, a 404 can't be sent once the CSV is streaming
+	if _, err := h.service.GetImportJob(r.Context(), jobID); err != nil {
+		if errors.Is(err, ErrImportJobNotFound) {
+			utils.NotFound(w, "Import job not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get import job", "error", err, "job_id", jobID)
+		utils.InternalServerError(w, "Failed to get import job")
+		return
+	}
+
+	filename := fmt.Sprintf("import-%s-errors.csv", jobID)
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if err := h.service.WriteImportJobErrorsCSV(r.Context(), jobID, w); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to write import job errors", "error", err, "job_id", jobID)
+	}
+}
+
+// parseCSVMappingForm reads the optional column_mapping and difficulty_mapping
+// form fields, each a JSON object
+func parseCSVMappingForm(r *http.Request) (CSVMapping, error) {
+	var mapping CSVMapping
+	if raw := r.FormValue("column_mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping.Columns); err != nil {
+			return CSVMapping{}, fmt.Errorf("column_mapping must be a JSON object of target to source column: %w", err)
+		}
+	}
+	if raw := r.FormValue("difficulty_mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping.DifficultyValues); err != nil {
+			return CSVMapping{}, fmt.Errorf("difficulty_mapping must be a JSON object of value to difficulty: %w", err)
+		}
+	}
+	return mapping, nil
+}
+
+// writeMissingColumns answers 400 for a CSV without its required columns,
+// listing them along with the detected headers. It reports whether it wrote.
+func writeMissingColumns(w http.ResponseWriter, err error) bool {
+	var missingErr *MissingColumnsError
+	if !errors.As(err, &missingErr) {
+		return false
+	}
+	utils.BadRequest(w, missingErr.Error(), map[string]any{
+		"missing_columns": missingErr.Missing,
+		"headers":         missingErr.Headers,
+	})
+	return true
 }
@@ -0,0 +1,237 @@
+package dataimport
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// Import job statuses
+const (
+	JobRunning   = "running"
+	JobComplete  = "complete"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ErrImportJobNotFound is returned when an import job ID doesn't exist
+var ErrImportJobNotFound = fmt.Errorf("import job %w", utils.ErrNotFound)
+
+// importJob writes the durable record of one running import. Writes use a
+// context detached from the request, so a dropped SSE connection still
+// leaves the record accurate.
+type importJob struct {
+	id uuid.UUID
+	q  repo.Querier
+}
+
+// startImportJob records a new running job. A previous job for the same file
+// hash, if any, becomes its prior job so re-runs can report what was already
+// imported.
+func (s *importService) startImportJob(ctx context.Context, opts ImportOptions, fileHash string, totalRows, totalPatterns int) (*importJob, *repo.ImportJob, error) {
+	ctx = context.WithoutCancel(ctx)
+
+	var prior *repo.ImportJob
+	previous, err := s.repo.GetLatestImportJobByHash(ctx, fileHash)
+	if err == nil {
+		prior = &previous
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, fmt.Errorf("failed to look up prior import job: %w", err)
+	}
+
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode import options: %w", err)
+	}
+
+	source := "upload"
+	if opts.UseBundled {
+		source = "bundled"
+	}
+
+	params := repo.CreateImportJobParams{
+		Source:        source,
+		DatasetID:     pgtype.Text{String: opts.DatasetID, Valid: opts.DatasetID != ""},
+		FileHash:      fileHash,
+		Options:       options,
+		TotalRows:     int32(totalRows),
+		TotalPatterns: int32(totalPatterns),
+	}
+	if opts.UserID != nil {
+		params.UserID = pgtype.UUID{Bytes: *opts.UserID, Valid: true}
+	}
+	if prior != nil {
+		params.PriorJobID = pgtype.UUID{Bytes: prior.ID, Valid: true}
+	}
+
+	job, err := s.repo.CreateImportJob(ctx, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	return &importJob{id: job.ID, q: s.repo}, prior, nil
+}
+
+// progress saves the running counters; failures only cost durability
+func (j *importJob) progress(ctx context.Context, result *ImportResult, processed int) {
+	err := j.q.UpdateImportJobProgress(context.WithoutCancel(ctx), repo.UpdateImportJobProgressParams{
+		ID:                j.id,
+		PatternsCreated:   int32(result.PatternsCreated),
+		ProblemsProcessed: int32(processed),
+		ProblemsCreated:   int32(result.ProblemsCreated),
+		ProblemsUpdated:   int32(result.ProblemsUpdated),
+		DuplicatesSkipped: int32(result.DuplicatesSkipped),
+		ErrorCount:        int32(len(result.Errors)),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to update import job", "job_id", j.id, "error", err)
+	}
+}
+
+// finish saves the final counters, status and row errors
+func (j *importJob) finish(ctx context.Context, status string, result *ImportResult, processed int, errMsg string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if len(result.Errors) > 0 {
+		params := repo.InsertImportJobErrorsParams{
+			JobID:      j.id,
+			RowNumbers: make([]int32, len(result.Errors)),
+			Titles:     make([]string, len(result.Errors)),
+			Errors:     make([]string, len(result.Errors)),
+		}
+		for i, importErr := range result.Errors {
+			params.RowNumbers[i] = int32(importErr.RowNumber)
+			params.Titles[i] = importErr.Title
+			params.Errors[i] = importErr.Error
+		}
+		if err := j.q.InsertImportJobErrors(ctx, params); err != nil {
+			logging.FromContext(ctx).Warn("Failed to save import job errors", "job_id", j.id, "error", err)
+		}
+	}
+
+	err := j.q.FinishImportJob(ctx, repo.FinishImportJobParams{
+		ID:                j.id,
+		Status:            status,
+		PatternsCreated:   int32(result.PatternsCreated),
+		ProblemsProcessed: int32(processed),
+		ProblemsCreated:   int32(result.ProblemsCreated),
+		ProblemsUpdated:   int32(result.ProblemsUpdated),
+		DuplicatesSkipped: int32(result.DuplicatesSkipped),
+		ErrorCount:        int32(len(result.Errors)),
+		ErrorMessage:      pgtype.Text{String: errMsg, Valid: errMsg != ""},
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to finish import job", "job_id", j.id, "error", err)
+	}
+}
+
+// ListImportJobs returns a page of import jobs, newest first
+func (s *importService) ListImportJobs(ctx context.Context, page, limit int) (ImportJobListResponse, error) {
+	offset := (page - 1) * limit
+
+	jobs, err := s.repo.ListImportJobs(ctx, repo.ListImportJobsParams{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		return ImportJobListResponse{}, fmt.Errorf("failed to list import jobs: %w", err)
+	}
+
+	total, err := s.repo.CountImportJobs(ctx)
+	if err != nil {
+		return ImportJobListResponse{}, fmt.Errorf("failed to count import jobs: %w", err)
+	}
+
+	items := make([]ImportJob, len(jobs))
+	for i, job := range jobs {
+		items[i] = toImportJob(job)
+	}
+
+	return ImportJobListResponse{
+		Jobs:  items,
+		Total: total,
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// GetImportJob returns one import job
+func (s *importService) GetImportJob(ctx context.Context, id uuid.UUID) (*ImportJob, error) {
+	job, err := s.repo.GetImportJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	item := toImportJob(job)
+	return &item, nil
+}
+
+// WriteImportJobErrorsCSV writes a job's row errors as CSV. A job without
+// errors produces just the header row.
+func (s *importService) WriteImportJobErrorsCSV(ctx context.Context, id uuid.UUID, w io.Writer) error {
+	rows, err := s.repo.ListImportJobErrors(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to list import job errors: %w", err)
+	}
+
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{"row_number", "title", "error"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := csvWriter.Write([]string{strconv.Itoa(int(row.RowNumber)), row.Title, row.Error}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func toImportJob(job repo.ImportJob) ImportJob {
+	item := ImportJob{
+		ID:                job.ID.String(),
+		Source:            job.Source,
+		DatasetID:         textPtr(job.DatasetID),
+		FileHash:          job.FileHash,
+		Options:           json.RawMessage(job.Options),
+		Status:            job.Status,
+		TotalRows:         job.TotalRows,
+		TotalPatterns:     job.TotalPatterns,
+		PatternsCreated:   job.PatternsCreated,
+		ProblemsProcessed: job.ProblemsProcessed,
+		ProblemsCreated:   job.ProblemsCreated,
+		ProblemsUpdated:   job.ProblemsUpdated,
+		DuplicatesSkipped: job.DuplicatesSkipped,
+		ErrorCount:        job.ErrorCount,
+		ErrorMessage:      textPtr(job.ErrorMessage),
+		StartedAt:         job.StartedAt.Format(time.RFC3339),
+	}
+	if job.UserID.Valid {
+		userID := uuid.UUID(job.UserID.Bytes).String()
+		item.UserID = &userID
+	}
+	if job.PriorJobID.Valid {
+		priorID := uuid.UUID(job.PriorJobID.Bytes).String()
+		item.PriorJobID = &priorID
+	}
+	if job.FinishedAt.Valid {
+		finishedAt := job.FinishedAt.Time.Format(time.RFC3339)
+		item.FinishedAt = &finishedAt
+	}
+	return item
+}
@@ -2,50 +2,121 @@ package dataimport
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 )
 
+// MaxCSVLineBytes bounds a single CSV line, so a file without newlines can't
+// be buffered whole by the CSV reader
+const MaxCSVLineBytes = 64 << 10
+
+// ErrLineTooLong is returned when a CSV line exceeds MaxCSVLineBytes
+var ErrLineTooLong = fmt.Errorf("CSV line exceeds %d bytes", MaxCSVLineBytes)
+
+// RowLimitError is returned when a CSV has more data rows than allowed
+type RowLimitError struct {
+	Limit int
+}
+
+func (e *RowLimitError) Error() string {
+	return fmt.Sprintf("CSV exceeds the limit of %d rows", e.Limit)
+}
+
 // Parser handles CSV parsing and validation
-type Parser struct{}
+type Parser struct {
+	maxRows int // Data rows allowed per CSV; 0 means unlimited
+}
 
 // NewParser creates a new CSV parser
-func NewParser() *Parser {
-	return &Parser{}
+func NewParser(maxRows int) *Parser {
+	return &Parser{maxRows: maxRows}
+}
+
+// expectedHeaders defines the CSV columns a row is built from
+var expectedHeaders = []string{"title", "url", "source", "difficulty", "patterns", "companies"}
+
+// requiredHeaders must resolve to a column for a CSV to be importable
+var requiredHeaders = []string{"title", "difficulty"}
+
+// defaultDifficultyValues are difficulty synonyms accepted without a mapping
+var defaultDifficultyValues = map[string]string{
+	"1": "easy",
+	"2": "medium",
+	"3": "hard",
+	"e": "easy",
+	"m": "medium",
+	"h": "hard",
 }
 
-// expectedHeaders defines the required CSV column headers
-var expectedHeaders = []string{"title", "url", "source", "difficulty", "patterns"}
+// ErrInvalidCSVMapping is returned for a column or difficulty mapping that
+// names an unknown target
+var ErrInvalidCSVMapping = errors.New("invalid CSV mapping")
+
+// MissingColumnsError reports required columns that couldn't be found in the
+// CSV header, even after applying the column mapping
+type MissingColumnsError struct {
+	Missing []string
+	Headers []string // Headers detected in the CSV, to build a mapping from
+}
+
+func (e *MissingColumnsError) Error() string {
+	return fmt.Sprintf("CSV is missing required columns: %s", strings.Join(e.Missing, ", "))
+}
 
-// ParseCSV reads and validates a CSV file, returning parsed problems
+// ParseCSV reads and validates a CSV file with the standard headers
 func (p *Parser) ParseCSV(reader io.Reader) ([]ParsedProblem, []InvalidRow, error) {
-	csvReader := csv.NewReader(reader)
+	_, problems, invalidRows, err := p.ParseCSVWithMapping(reader, CSVMapping{})
+	return problems, invalidRows, err
+}
+
+// ParseCSVWithMapping reads and validates a CSV file, resolving columns and
+// difficulty values through mapping. It also returns the detected headers.
+func (p *Parser) ParseCSVWithMapping(reader io.Reader, mapping CSVMapping) ([]string, []ParsedProblem, []InvalidRow, error) {
+	var problems []ParsedProblem
+	var invalidRows []InvalidRow
+
+	headers, err := p.StreamCSV(reader, mapping, func(prob ParsedProblem) error {
+		problems = append(problems, prob)
+		return nil
+	}, func(row InvalidRow) {
+		invalidRows = append(invalidRows, row)
+	})
+	if err != nil {
+		return headers, nil, nil, err
+	}
+
+	return headers, problems, invalidRows, nil
+}
+
+// StreamCSV reads and validates a CSV file row by row, handing each valid
+// problem to onProblem and each rejected row to onInvalid without keeping
+// the rows itself. An error from onProblem stops the stream.
+func (p *Parser) StreamCSV(reader io.Reader, mapping CSVMapping, onProblem func(ParsedProblem) error, onInvalid func(InvalidRow)) ([]string, error) {
+	csvReader := csv.NewReader(&lineLimitReader{r: reader, limit: MaxCSVLineBytes})
 	csvReader.TrimLeadingSpace = true
 	csvReader.FieldsPerRecord = -1 // Allow variable fields
+	csvReader.ReuseRecord = true
 
 	// Read header row
 	headers, err := csvReader.Read()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read CSV headers: %w", err)
+		return nil, fmt.Errorf("failed to read CSV headers: %w", err)
 	}
+	headers = slices.Clone(headers) // The reader reuses the record's backing array
 
-	// Build column index map
-	colIndex := make(map[string]int)
-	for i, h := range headers {
-		colIndex[strings.ToLower(strings.TrimSpace(h))] = i
+	colIndex, err := resolveColumns(headers, mapping)
+	if err != nil {
+		return headers, err
 	}
 
-	// Validate required columns exist
-	if _, ok := colIndex["title"]; !ok {
-		return nil, nil, fmt.Errorf("CSV must have 'title' column")
-	}
-	if _, ok := colIndex["difficulty"]; !ok {
-		return nil, nil, fmt.Errorf("CSV must have 'difficulty' column")
+	difficultyValues, err := mapping.difficultyValues()
+	if err != nil {
+		return headers, err
 	}
 
-	var problems []ParsedProblem
-	var invalidRows []InvalidRow
 	rowNum := 1 // Start at 1 (header is row 0)
 
 	for {
@@ -54,8 +125,16 @@ func (p *Parser) ParseCSV(reader io.Reader) ([]ParsedProblem, []InvalidRow, erro
 		if err == io.EOF {
 			break
 		}
+		if p.maxRows > 0 && rowNum-1 > p.maxRows {
+			return headers, &RowLimitError{Limit: p.maxRows}
+		}
 		if err != nil {
-			invalidRows = append(invalidRows, InvalidRow{
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				// Read errors are sticky, so the rest of the file is unreadable
+				return headers, fmt.Errorf("failed to read CSV row %d: %w", rowNum, err)
+			}
+			onInvalid(InvalidRow{
 				RowNumber: rowNum,
 				Error:     fmt.Sprintf("CSV parse error: %v", err),
 			})
@@ -64,10 +143,13 @@ func (p *Parser) ParseCSV(reader io.Reader) ([]ParsedProblem, []InvalidRow, erro
 
 		// Parse row into CSVRow
 		row := p.recordToCSVRow(record, colIndex)
+		if value, ok := difficultyValues[strings.ToLower(strings.TrimSpace(row.Difficulty))]; ok {
+			row.Difficulty = value
+		}
 
 		// Validate row
 		if err := p.validateRow(row, rowNum); err != nil {
-			invalidRows = append(invalidRows, InvalidRow{
+			onInvalid(InvalidRow{
 				RowNumber: rowNum,
 				Error:     err.Error(),
 				Title:     row.Title,
@@ -75,20 +157,117 @@ func (p *Parser) ParseCSV(reader io.Reader) ([]ParsedProblem, []InvalidRow, erro
 			continue
 		}
 
-		// Parse patterns
-		patterns := p.parsePatterns(row.Patterns)
+		// Parse patterns and companies, both comma-separated names
+		patterns := p.parseNames(row.Patterns)
+		companies := p.parseNames(row.Companies)
 
-		problems = append(problems, ParsedProblem{
+		if err := onProblem(ParsedProblem{
 			Title:      strings.TrimSpace(row.Title),
 			URL:        strings.TrimSpace(row.URL),
 			Source:     strings.TrimSpace(row.Source),
 			Difficulty: strings.ToLower(strings.TrimSpace(row.Difficulty)),
 			Patterns:   patterns,
+			Companies:  companies,
 			RowNumber:  rowNum,
-		})
+		}); err != nil {
+			return headers, err
+		}
 	}
 
-	return problems, invalidRows, nil
+	return headers, nil
+}
+
+// lineLimitReader fails with ErrLineTooLong once a line runs past limit bytes
+type lineLimitReader struct {
+	r       io.Reader
+	limit   int
+	current int // Bytes since the last newline
+}
+
+func (l *lineLimitReader) Read(buf []byte) (int, error) {
+	n, err := l.r.Read(buf)
+	for _, b := range buf[:n] {
+		if b == '\n' {
+			l.current = 0
+			continue
+		}
+		l.current++
+		if l.current > l.limit {
+			return 0, ErrLineTooLong
+		}
+	}
+	return n, err
+}
+
+// CheckCSVColumns reads only the header row and reports whether the mapping
+// resolves every required column, so uploads can be rejected before import
+func CheckCSVColumns(reader io.Reader, mapping CSVMapping) error {
+	headers, err := csv.NewReader(reader).Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV headers: %w", err)
+	}
+	if _, err := resolveColumns(headers, mapping); err != nil {
+		return err
+	}
+	_, err = mapping.difficultyValues()
+	return err
+}
+
+// resolveColumns maps each expected column to its index in headers. A mapped
+// column is looked up by its source header name, anything else by its own name.
+func resolveColumns(headers []string, mapping CSVMapping) (map[string]int, error) {
+	headerIndex := make(map[string]int, len(headers))
+	for i, h := range headers {
+		headerIndex[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for target := range mapping.Columns {
+		if !slices.Contains(expectedHeaders, target) {
+			return nil, fmt.Errorf("%w: unknown target column %q (expected one of: %s)",
+				ErrInvalidCSVMapping, target, strings.Join(expectedHeaders, ", "))
+		}
+	}
+
+	colIndex := make(map[string]int, len(expectedHeaders))
+	for _, target := range expectedHeaders {
+		source := target
+		if mapped, ok := mapping.Columns[target]; ok {
+			source = mapped
+		}
+		if idx, ok := headerIndex[strings.ToLower(strings.TrimSpace(source))]; ok {
+			colIndex[target] = idx
+		}
+	}
+
+	var missing []string
+	for _, target := range requiredHeaders {
+		if _, ok := colIndex[target]; !ok {
+			missing = append(missing, target)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingColumnsError{Missing: missing, Headers: headers}
+	}
+
+	return colIndex, nil
+}
+
+// difficultyValues merges the mapping's difficulty synonyms over the defaults,
+// keyed by lowercased source value
+func (m CSVMapping) difficultyValues() (map[string]string, error) {
+	values := make(map[string]string, len(defaultDifficultyValues)+len(m.DifficultyValues))
+	for source, target := range defaultDifficultyValues {
+		values[source] = target
+	}
+	for source, target := range m.DifficultyValues {
+		target = strings.ToLower(strings.TrimSpace(target))
+		if target != "easy" && target != "medium" && target != "hard" {
+			return nil, fmt.Errorf("%w: difficulty %q must map to easy, medium or hard, got %q",
+				ErrInvalidCSVMapping, source, target)
+		}
+		values[strings.ToLower(strings.TrimSpace(source))] = target
+	}
+	return values, nil
 }
 
 // recordToCSVRow converts a CSV record to a CSVRow using column indices
@@ -106,6 +285,7 @@ func (p *Parser) recordToCSVRow(record []string, colIndex map[string]int) CSVRow
 		Source:     getField("source"),
 		Difficulty: getField("difficulty"),
 		Patterns:   getField("patterns"),
+		Companies:  getField("companies"),
 	}
 }
 
@@ -125,29 +305,29 @@ func (p *Parser) validateRow(row CSVRow, rowNum int) error {
 	return nil
 }
 
-// parsePatterns splits and cleans pattern names
-func (p *Parser) parsePatterns(patternsStr string) []string {
-	if strings.TrimSpace(patternsStr) == "" {
+// parseNames splits and cleans a comma-separated list of pattern or company names
+func (p *Parser) parseNames(namesStr string) []string {
+	if strings.TrimSpace(namesStr) == "" {
 		return nil
 	}
 
 	// Split by comma
-	parts := strings.Split(patternsStr, ",")
-	var patterns []string
+	parts := strings.Split(namesStr, ",")
+	var names []string
 
 	for _, part := range parts {
-		// Clean each pattern name
-		pattern := strings.TrimSpace(part)
+		// Clean each name
+		name := strings.TrimSpace(part)
 		// Remove surrounding quotes if present
-		pattern = strings.Trim(pattern, "\"'")
-		pattern = strings.TrimSpace(pattern)
+		name = strings.Trim(name, "\"'")
+		name = strings.TrimSpace(name)
 
-		if pattern != "" {
-			patterns = append(patterns, pattern)
+		if name != "" {
+			names = append(names, name)
 		}
 	}
 
-	return patterns
+	return names
 }
 
 // GetUniquePatterns extracts all unique pattern names from parsed problems
@@ -172,6 +352,25 @@ func (p *Parser) GetUniquePatterns(problems []ParsedProblem) []string {
 	return patterns
 }
 
+// GetUniqueCompanies extracts all unique company names from parsed problems,
+// keeping the first spelling of names that differ only in case
+func (p *Parser) GetUniqueCompanies(problems []ParsedProblem) []string {
+	seen := make(map[string]bool)
+	var companies []string
+
+	for _, prob := range problems {
+		for _, company := range prob.Companies {
+			key := strings.ToLower(company)
+			if !seen[key] {
+				seen[key] = true
+				companies = append(companies, company)
+			}
+		}
+	}
+
+	return companies
+}
+
 // CountDifficulties returns a map of difficulty -> count
 func (p *Parser) CountDifficulties(problems []ParsedProblem) map[string]int {
 	counts := map[string]int{
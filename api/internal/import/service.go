@@ -2,10 +2,12 @@ package dataimport
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
@@ -13,8 +15,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/utils"
 )
 
 const (
@@ -22,8 +26,38 @@ const (
 	BatchSize = 50
 	// RecentItemsCount is the number of recent items to show in progress
 	RecentItemsCount = 8
+	// duplicateCheckChunk is how many rows one batched duplicate lookup covers
+	duplicateCheckChunk = 1000
 )
 
+// On-duplicate modes for problems that already exist
+const (
+	DuplicateSkip   = "skip"
+	DuplicateUpdate = "update"
+	DuplicateFail   = "fail"
+)
+
+// Ways a CSV row can match an existing problem
+const (
+	matchNone  = ""
+	matchTitle = "title"
+	matchURL   = "url"
+)
+
+// ErrInvalidDuplicateMode is returned for an unknown on_duplicate value
+var ErrInvalidDuplicateMode = errors.New("on_duplicate must be one of: skip, update, fail")
+
+// NormalizeDuplicateMode validates an on_duplicate value, defaulting to skip
+func NormalizeDuplicateMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return DuplicateSkip, nil
+	case DuplicateSkip, DuplicateUpdate, DuplicateFail:
+		return mode, nil
+	}
+	return "", ErrInvalidDuplicateMode
+}
+
 // ProgressCallback is called during import to report progress
 type ProgressCallback func(progress ImportProgress)
 
@@ -33,92 +67,149 @@ type Service interface {
 	GetBundledDatasets(ctx context.Context) ([]BundledDataset, error)
 
 	// ParseCSV parses a CSV and returns analysis (doesn't import)
-	ParseCSV(ctx context.Context, reader io.Reader) (*ParseResult, error)
+	ParseCSV(ctx context.Context, reader io.Reader, onDuplicate string, mapping CSVMapping) (*ParseResult, error)
 
 	// ParseBundledDataset parses a bundled dataset and returns analysis
-	ParseBundledDataset(ctx context.Context, datasetID string) (*ParseResult, error)
+	ParseBundledDataset(ctx context.Context, datasetID string, onDuplicate string) (*ParseResult, error)
 
 	// ExecuteImport runs the actual import with progress callbacks
 	ExecuteImport(ctx context.Context, opts ImportOptions, progressFn ProgressCallback) (*ImportResult, error)
 
 	// ExecuteImportFromReader imports from a custom CSV reader
-	ExecuteImportFromReader(ctx context.Context, reader io.Reader, progressFn ProgressCallback) (*ImportResult, error)
+	ExecuteImportFromReader(ctx context.Context, reader io.Reader, opts ImportOptions, progressFn ProgressCallback) (*ImportResult, error)
+
+	// ExportBackup streams the user's account backup as JSON
+	ExportBackup(ctx context.Context, userID uuid.UUID, w io.Writer) error
+
+	// ListImportJobs returns a page of import job records, newest first
+	ListImportJobs(ctx context.Context, page, limit int) (ImportJobListResponse, error)
+
+	// GetImportJob returns one import job record
+	GetImportJob(ctx context.Context, id uuid.UUID) (*ImportJob, error)
+
+	// WriteImportJobErrorsCSV streams an import job's row errors as CSV
+	WriteImportJobErrorsCSV(ctx context.Context, id uuid.UUID, w io.Writer) error
+
+	// RestoreBackup restores an account backup with progress callbacks
+	RestoreBackup(ctx context.Context, userID uuid.UUID, reader io.Reader, applySettings bool, progressFn ProgressCallback) (*RestoreResult, error)
 }
 
 type importService struct {
 	repo        repo.Querier
-	pool        *pgxpool.Pool // Need pool for transactions
+	tx          postgres.Transactor
 	parser      *Parser
-	datasetPath string // Path to sample-datasets folder
+	datasetPath string // Optional folder whose CSVs override the embedded datasets
+	metrics     metrics.Recorder
+
+	// Bundled dataset metadata, parsed once in NewService
+	datasets    []BundledDataset
+	datasetsErr error
 }
 
 // NewService creates a new import service
-func NewService(queries repo.Querier, pool *pgxpool.Pool, datasetPath string) Service {
-	return &importService{
+func NewService(queries repo.Querier, tx postgres.Transactor, datasetPath string, maxRows int, recorder metrics.Recorder) Service {
+	s := &importService{
 		repo:        queries,
-		pool:        pool,
-		parser:      NewParser(),
+		tx:          tx,
+		parser:      NewParser(maxRows),
 		datasetPath: datasetPath,
+		metrics:     recorder,
+	}
+
+	s.datasets, s.datasetsErr = s.loadBundledDatasets()
+	if s.datasetsErr != nil {
+		slog.Warn("Failed to load bundled datasets", "error", s.datasetsErr)
 	}
+
+	return s
 }
 
 // GetBundledDatasets returns available pre-packaged datasets
 func (s *importService) GetBundledDatasets(ctx context.Context) ([]BundledDataset, error) {
-	datasets := []BundledDataset{
-		{
-			ID:           "leetcode",
-			Name:         "LeetCode Problems",
-			Description:  "2,160 free LeetCode problems with patterns (premium excluded)",
-			FileName:     "leetcode.csv",
-			ProblemCount: 2160,
-			PatternCount: 72,
-			Difficulties: map[string]int{
-				"easy":   592,
-				"medium": 1105,
-				"hard":   463,
-			},
-		},
-	}
-
-	return datasets, nil
-}
-
-// ParseCSV parses a CSV and returns analysis
-func (s *importService) ParseCSV(ctx context.Context, reader io.Reader) (*ParseResult, error) {
-	problems, invalidRows, err := s.parser.ParseCSV(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	if s.datasetsErr != nil {
+		return nil, s.datasetsErr
 	}
-
-	return s.analyzeProblems(ctx, problems, invalidRows)
+	return s.datasets, nil
 }
 
-// ParseBundledDataset parses a bundled dataset
-func (s *importService) ParseBundledDataset(ctx context.Context, datasetID string) (*ParseResult, error) {
-	reader, err := s.getBundledDatasetReader(datasetID)
+// ParseCSV parses a CSV and returns analysis. Rows are streamed and counted
+// as they are read, with duplicates looked up a chunk at a time.
+func (s *importService) ParseCSV(ctx context.Context, reader io.Reader, onDuplicate string, mapping CSVMapping) (*ParseResult, error) {
+	mode, err := NormalizeDuplicateMode(onDuplicate)
 	if err != nil {
 		return nil, err
 	}
-	defer reader.Close()
-
-	return s.ParseCSV(ctx, reader)
-}
 
-// analyzeProblems checks existing patterns/problems and returns analysis
-func (s *importService) analyzeProblems(ctx context.Context, problems []ParsedProblem, invalidRows []InvalidRow) (*ParseResult, error) {
+	validRows := 0
+	patternSet := make(map[string]struct{})
+	difficulties := map[string]int{
+		"easy":   0,
+		"medium": 0,
+		"hard":   0,
+	}
 	// Ensure invalidRows is never nil (JSON serializes nil slices as null)
-	if invalidRows == nil {
-		invalidRows = make([]InvalidRow, 0)
+	invalidRows := make([]InvalidRow, 0)
+	breakdown := DuplicateBreakdown{Mode: mode}
+
+	chunk := make([]ParsedProblem, 0, duplicateCheckChunk)
+	countDuplicates := func() error {
+		matches, err := matchDuplicates(ctx, s.repo, chunk)
+		if err != nil {
+			return fmt.Errorf("failed to check duplicates: %w", err)
+		}
+		chunk = chunk[:0]
+		for _, matchedBy := range matches {
+			switch matchedBy {
+			case matchNone:
+				continue
+			case matchTitle:
+				breakdown.MatchedByTitle++
+			default:
+				breakdown.MatchedByURL++
+			}
+			switch mode {
+			case DuplicateUpdate:
+				breakdown.WouldUpdate++
+			case DuplicateFail:
+				breakdown.WouldFail++
+			default:
+				breakdown.WouldSkip++
+			}
+		}
+		return nil
 	}
 
-	// Get unique patterns from problems
-	allPatterns := s.parser.GetUniquePatterns(problems)
+	headers, err := s.parser.StreamCSV(reader, mapping, func(prob ParsedProblem) error {
+		validRows++
+		difficulties[prob.Difficulty]++
+		for _, pattern := range prob.Patterns {
+			if normalized := strings.TrimSpace(pattern); normalized != "" {
+				patternSet[normalized] = struct{}{}
+			}
+		}
+
+		chunk = append(chunk, prob)
+		if len(chunk) < duplicateCheckChunk {
+			return nil
+		}
+		return countDuplicates()
+	}, func(row InvalidRow) {
+		invalidRows = append(invalidRows, row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(chunk) > 0 {
+		if err := countDuplicates(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Check which patterns already exist
 	existingPatterns := make([]string, 0)
 	patternsToCreate := make([]string, 0)
 
-	for _, pattern := range allPatterns {
+	for pattern := range patternSet {
 		_, err := s.repo.GetPatternByTitle(ctx, strings.ToLower(pattern))
 		if err == pgx.ErrNoRows {
 			patternsToCreate = append(patternsToCreate, pattern)
@@ -131,33 +222,30 @@ func (s *importService) analyzeProblems(ctx context.Context, problems []ParsedPr
 	sort.Strings(existingPatterns)
 	sort.Strings(patternsToCreate)
 
-	// Count duplicates
-	duplicateCount := 0
-	for _, prob := range problems {
-		source := prob.Source
-		if source == "" {
-			source = "LeetCode"
-		}
-		_, err := s.repo.GetProblemByTitleAndSource(ctx, repo.GetProblemByTitleAndSourceParams{
-			Title:  prob.Title,
-			Source: pgtype.Text{String: source, Valid: true},
-		})
-		if err == nil {
-			duplicateCount++
-		}
-	}
-
 	return &ParseResult{
-		TotalRows:        len(problems) + len(invalidRows),
-		ValidRows:        len(problems),
+		Headers:          headers,
+		TotalRows:        validRows + len(invalidRows),
+		ValidRows:        validRows,
 		InvalidRows:      invalidRows,
 		PatternsToCreate: patternsToCreate,
 		ExistingPatterns: existingPatterns,
-		DuplicateCount:   duplicateCount,
-		Difficulties:     s.parser.CountDifficulties(problems),
+		DuplicateCount:   breakdown.MatchedByTitle + breakdown.MatchedByURL,
+		Duplicates:       breakdown,
+		Difficulties:     difficulties,
 	}, nil
 }
 
+// ParseBundledDataset parses a bundled dataset
+func (s *importService) ParseBundledDataset(ctx context.Context, datasetID string, onDuplicate string) (*ParseResult, error) {
+	reader, err := s.getBundledDatasetReader(datasetID)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return s.ParseCSV(ctx, reader, onDuplicate, CSVMapping{})
+}
+
 // ExecuteImport runs the import from a bundled dataset
 func (s *importService) ExecuteImport(ctx context.Context, opts ImportOptions, progressFn ProgressCallback) (*ImportResult, error) {
 	if !opts.UseBundled {
@@ -170,18 +258,65 @@ func (s *importService) ExecuteImport(ctx context.Context, opts ImportOptions, p
 	}
 	defer reader.Close()
 
-	return s.ExecuteImportFromReader(ctx, reader, progressFn)
+	return s.ExecuteImportFromReader(ctx, reader, opts, progressFn)
 }
 
 // ExecuteImportFromReader imports from a custom CSV reader
-func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.Reader, progressFn ProgressCallback) (*ImportResult, error) {
+func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.Reader, opts ImportOptions, progressFn ProgressCallback) (*ImportResult, error) {
+	result, err := s.executeImport(ctx, reader, opts, progressFn)
+
+	kind := metrics.ImportUpload
+	if opts.UseBundled {
+		kind = metrics.ImportBundled
+	}
+	switch {
+	case err != nil:
+		s.metrics.ImportRun(kind, metrics.OutcomeError)
+	case result.Cancelled:
+		s.metrics.ImportRun(kind, metrics.OutcomeCancelled)
+	default:
+		s.metrics.ImportRun(kind, metrics.OutcomeSuccess)
+	}
+
+	return result, err
+}
+
+func (s *importService) executeImport(ctx context.Context, reader io.Reader, opts ImportOptions, progressFn ProgressCallback) (*ImportResult, error) {
 	startTime := time.Now()
 
-	// Parse CSV
-	problems, invalidRows, err := s.parser.ParseCSV(reader)
+	mode, err := NormalizeDuplicateMode(opts.OnDuplicate)
+	if err != nil {
+		return nil, err
+	}
+
+	var datasetTag string
+	if opts.TagDataset && opts.UseBundled {
+		datasetTag = opts.DatasetID
+	}
+
+	// Parse CSV, hashing it on the way to recognise re-runs of the same file
+	hasher := sha256.New()
+	_, problems, invalidRows, err := s.parser.ParseCSVWithMapping(io.TeeReader(reader, hasher), opts.Mapping)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse CSV: %w", err)
 	}
+	fileHash := hex.EncodeToString(hasher.Sum(nil))
+
+	// In fail mode nothing is written if any row already exists
+	if mode == DuplicateFail {
+		for chunkStart := 0; chunkStart < len(problems); chunkStart += duplicateCheckChunk {
+			chunk := problems[chunkStart:min(chunkStart+duplicateCheckChunk, len(problems))]
+			matches, err := matchDuplicates(ctx, s.repo, chunk)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check duplicates: %w", err)
+			}
+			for i, matchedBy := range matches {
+				if matchedBy != matchNone {
+					return nil, fmt.Errorf("row %d (%q) already exists and on_duplicate is fail", chunk[i].RowNumber, chunk[i].Title)
+				}
+			}
+		}
+	}
 
 	// Report invalid rows as errors
 	importErrors := make([]ImportError, 0, len(invalidRows))
@@ -198,17 +333,52 @@ func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.R
 		Errors:  importErrors,
 	}
 
+	// With SkipPatterns no pattern is created or linked, so the pattern
+	// taxonomy is left as it is
+	var patternNames []string
+	if !opts.SkipPatterns {
+		patternNames = s.parser.GetUniquePatterns(problems)
+	}
+	totalProblems := len(problems)
+
+	job, prior, err := s.startImportJob(ctx, opts, fileHash, totalProblems+len(invalidRows), len(patternNames))
+	if err != nil {
+		return nil, err
+	}
+	result.JobID = job.id.String()
+	if prior != nil {
+		priorID := prior.ID.String()
+		result.PriorJobID = &priorID
+	}
+
+	reportProgress := progressFn
+	progressFn = func(progress ImportProgress) {
+		progress.JobID = result.JobID
+		reportProgress(progress)
+	}
+
+	processed := 0
+	cancelled := func() (*ImportResult, error) {
+		job.finish(ctx, JobCancelled, result, processed, "")
+		return cancelImport(result, startTime), nil
+	}
+
 	// Phase 1: Create patterns
-	patternNames := s.parser.GetUniquePatterns(problems)
 	patternIDMap := make(map[string]uuid.UUID) // pattern name -> ID
 
-	progressFn(ImportProgress{
-		Phase:       "patterns",
-		TotalItems:  len(patternNames),
-		CurrentItem: "Preparing patterns...",
-	})
+	if !opts.SkipPatterns {
+		progressFn(ImportProgress{
+			Phase:       "patterns",
+			TotalItems:  len(patternNames),
+			CurrentItem: "Preparing patterns...",
+		})
+	}
 
 	for i, patternName := range patternNames {
+		if ctx.Err() != nil {
+			return cancelled()
+		}
+
 		// Check if pattern exists (case-insensitive)
 		existingPattern, err := s.repo.GetPatternByTitle(ctx, strings.ToLower(patternName))
 		if err == nil {
@@ -237,91 +407,105 @@ func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.R
 		})
 	}
 
-	// Phase 2: Import problems in batches
-	totalProblems := len(problems)
+	// Companies are found or created by name; unlike patterns there's no
+	// option to skip them, a CSV without the column simply has none
+	companyIDMap := make(map[string]uuid.UUID) // lowercased company name -> ID
+	for _, companyName := range s.parser.GetUniqueCompanies(problems) {
+		if ctx.Err() != nil {
+			return cancelled()
+		}
+		company, err := s.repo.UpsertCompanyByName(ctx, companyName)
+		if err != nil {
+			err = fmt.Errorf("failed to create company %q: %w", companyName, err)
+			job.finish(ctx, JobFailed, result, processed, err.Error())
+			return nil, err
+		}
+		companyIDMap[strings.ToLower(companyName)] = company.ID
+	}
+
+	job.progress(ctx, result, processed)
+
+	// Phase 2: Import problems in batches, one transaction per batch
 	recentItems := make([]RecentItem, 0, RecentItemsCount)
+	failedBatches := 0
+	var lastBatchErr error
 
-	for i, prob := range problems {
-		// Check for duplicate
-		source := prob.Source
-		if source == "" {
-			source = "LeetCode" // Default source
+	for batchStart := 0; batchStart < totalProblems; batchStart += BatchSize {
+		if ctx.Err() != nil {
+			return cancelled()
 		}
 
-		_, err := s.repo.GetProblemByTitleAndSource(ctx, repo.GetProblemByTitleAndSourceParams{
-			Title:  prob.Title,
-			Source: pgtype.Text{String: source, Valid: true},
-		})
+		batchEnd := min(batchStart+BatchSize, totalProblems)
+		batch := problems[batchStart:batchEnd]
 
-		status := "created"
-		if err == nil {
-			// Duplicate found, skip
-			result.DuplicatesSkipped++
-			status = "skipped"
-		} else if err == pgx.ErrNoRows {
-			// Create problem
-			newProblem, err := s.repo.CreateProblem(ctx, repo.CreateProblemParams{
-				Title:      prob.Title,
-				Source:     pgtype.Text{String: source, Valid: true},
-				Url:        pgtype.Text{String: prob.URL, Valid: prob.URL != ""},
-				Difficulty: pgtype.Text{String: prob.Difficulty, Valid: true},
-			})
-			if err != nil {
+		outcome, err := s.importProblemBatch(ctx, batch, patternIDMap, companyIDMap, mode, datasetTag)
+		if err != nil {
+			if ctx.Err() != nil {
+				return cancelled()
+			}
+			failedBatches++
+			lastBatchErr = err
+			// The whole batch was rolled back, so every row in it failed
+			for _, prob := range batch {
 				result.Errors = append(result.Errors, ImportError{
 					RowNumber: prob.RowNumber,
 					Title:     prob.Title,
-					Error:     fmt.Sprintf("failed to create: %v", err),
+					Error:     fmt.Sprintf("batch rolled back: %v", err),
 				})
-				status = "error"
-			} else {
-				result.ProblemsCreated++
-
-				// Link patterns
-				for _, patternName := range prob.Patterns {
-					patternID, ok := patternIDMap[strings.ToLower(patternName)]
-					if ok {
-						_ = s.repo.LinkProblemToPatternIfNotExists(ctx, repo.LinkProblemToPatternIfNotExistsParams{
-							ProblemID: newProblem.ID,
-							PatternID: patternID,
-						})
-					}
-				}
+			}
+			outcome = &batchOutcome{statuses: make([]string, len(batch))}
+			for i := range outcome.statuses {
+				outcome.statuses[i] = "error"
 			}
 		}
 
-		// Update recent items (keep last N)
-		recentItems = append(recentItems, RecentItem{
-			Title:      prob.Title,
-			Difficulty: prob.Difficulty,
-			Status:     status,
-		})
-		if len(recentItems) > RecentItemsCount {
-			recentItems = recentItems[1:]
+		result.ProblemsCreated += outcome.created
+		result.DuplicatesSkipped += outcome.skipped
+		result.ProblemsUpdated += outcome.updated
+		if prior != nil {
+			result.AlreadyImported += outcome.skipped + outcome.updated
 		}
+		processed = batchEnd
+		job.progress(ctx, result, processed)
 
-		// Report progress
+		for i, prob := range batch {
+			// Update recent items (keep last N)
+			recentItems = append(recentItems, RecentItem{
+				Title:      prob.Title,
+				Difficulty: prob.Difficulty,
+				Status:     outcome.statuses[i],
+			})
+			if len(recentItems) > RecentItemsCount {
+				recentItems = recentItems[1:]
+			}
+		}
+
+		// Report progress once per committed batch
+		lastProb := batch[len(batch)-1]
 		progressFn(ImportProgress{
 			Phase:             "problems",
-			CurrentItem:       prob.Title,
-			CurrentIndex:      i + 1,
+			CurrentItem:       lastProb.Title,
+			CurrentIndex:      batchEnd,
 			TotalItems:        totalProblems,
 			ProblemsCreated:   result.ProblemsCreated,
 			PatternsCreated:   result.PatternsCreated,
 			DuplicatesSkipped: result.DuplicatesSkipped,
-			Percentage:        float64(i+1) / float64(totalProblems) * 100,
+			ProblemsUpdated:   result.ProblemsUpdated,
+			Percentage:        float64(batchEnd) / float64(totalProblems) * 100,
 			RecentItems:       recentItems,
 		})
-
-		// Small delay to prevent overwhelming the client with SSE events
-		// and to make the UI feel smoother
-		if i%10 == 0 {
-			time.Sleep(5 * time.Millisecond)
-		}
 	}
 
 	// Final progress
 	result.Duration = formatDuration(time.Since(startTime))
 
+	// A run where every batch rolled back wrote nothing
+	if batchCount := (totalProblems + BatchSize - 1) / BatchSize; batchCount > 0 && failedBatches == batchCount {
+		job.finish(ctx, JobFailed, result, processed, lastBatchErr.Error())
+	} else {
+		job.finish(ctx, JobComplete, result, processed, "")
+	}
+
 	progressFn(ImportProgress{
 		Phase:             "complete",
 		CurrentItem:       "Import complete",
@@ -330,6 +514,7 @@ func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.R
 		ProblemsCreated:   result.ProblemsCreated,
 		PatternsCreated:   result.PatternsCreated,
 		DuplicatesSkipped: result.DuplicatesSkipped,
+		ProblemsUpdated:   result.ProblemsUpdated,
 		Percentage:        100,
 		RecentItems:       recentItems,
 	})
@@ -337,47 +522,223 @@ func (s *importService) ExecuteImportFromReader(ctx context.Context, reader io.R
 	return result, nil
 }
 
-// getBundledDatasetReader returns a reader for a bundled dataset
-func (s *importService) getBundledDatasetReader(datasetID string) (io.ReadCloser, error) {
-	datasets, _ := s.GetBundledDatasets(context.Background())
+// batchOutcome is what one committed problem batch did
+type batchOutcome struct {
+	created  int
+	skipped  int
+	updated  int
+	statuses []string // Per row: "created", "skipped" or "updated"
+}
+
+// importProblemBatch creates a batch of problems and their pattern and company
+// links in a single transaction. Any failure rolls back the whole batch.
+func (s *importService) importProblemBatch(ctx context.Context, batch []ParsedProblem, patternIDMap, companyIDMap map[string]uuid.UUID, mode string, datasetTag string) (*batchOutcome, error) {
+	outcome := &batchOutcome{statuses: make([]string, 0, len(batch))}
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		for _, prob := range batch {
+			source := importSource(prob)
+
+			// Check for duplicate
+			existingID, matchedBy, err := findDuplicate(ctx, q, prob)
+			if err != nil {
+				return fmt.Errorf("row %d: failed to check duplicate: %w", prob.RowNumber, err)
+			}
 
-	var dataset *BundledDataset
-	for _, d := range datasets {
-		if d.ID == datasetID {
-			dataset = &d
-			break
+			var problemID uuid.UUID
+			switch {
+			case matchedBy != matchNone && mode != DuplicateUpdate:
+				// Skipped rows still get the dataset tag and their companies, so
+				// a curated list imported over the full dump is fully tagged
+				if err := tagProblem(ctx, q, existingID, datasetTag); err != nil {
+					return fmt.Errorf("row %d: %w", prob.RowNumber, err)
+				}
+				if err := linkCompanies(ctx, q, existingID, prob.Companies, companyIDMap); err != nil {
+					return fmt.Errorf("row %d: %w", prob.RowNumber, err)
+				}
+				outcome.skipped++
+				outcome.statuses = append(outcome.statuses, "skipped")
+				continue
+			case matchedBy != matchNone:
+				// Refresh difficulty/url; pattern links are merged below
+				if err := q.UpdateProblemFromImport(ctx, repo.UpdateProblemFromImportParams{
+					ID:         existingID,
+					Difficulty: pgtype.Text{String: prob.Difficulty, Valid: true},
+					Url:        pgtype.Text{String: prob.URL, Valid: prob.URL != ""},
+				}); err != nil {
+					return fmt.Errorf("row %d: failed to update: %w", prob.RowNumber, err)
+				}
+				problemID = existingID
+				outcome.updated++
+				outcome.statuses = append(outcome.statuses, "updated")
+			default:
+				newProblem, err := q.CreateProblem(ctx, repo.CreateProblemParams{
+					Title:      prob.Title,
+					Source:     pgtype.Text{String: source, Valid: true},
+					Url:        pgtype.Text{String: prob.URL, Valid: prob.URL != ""},
+					Difficulty: pgtype.Text{String: prob.Difficulty, Valid: true},
+				})
+				if err != nil {
+					return fmt.Errorf("row %d: failed to create: %w", prob.RowNumber, err)
+				}
+				problemID = newProblem.ID
+				outcome.created++
+				outcome.statuses = append(outcome.statuses, "created")
+			}
+
+			if err := tagProblem(ctx, q, problemID, datasetTag); err != nil {
+				return fmt.Errorf("row %d: %w", prob.RowNumber, err)
+			}
+
+			// Link patterns; the map is empty when SkipPatterns is set
+			for _, patternName := range prob.Patterns {
+				patternID, ok := patternIDMap[strings.ToLower(patternName)]
+				if !ok {
+					continue
+				}
+				if err := q.LinkProblemToPatternIfNotExists(ctx, repo.LinkProblemToPatternIfNotExistsParams{
+					ProblemID: problemID,
+					PatternID: patternID,
+				}); err != nil {
+					return fmt.Errorf("row %d: failed to link pattern %q: %w", prob.RowNumber, patternName, err)
+				}
+			}
+
+			if err := linkCompanies(ctx, q, problemID, prob.Companies, companyIDMap); err != nil {
+				return fmt.Errorf("row %d: %w", prob.RowNumber, err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return outcome, nil
+}
 
-	if dataset == nil {
-		return nil, fmt.Errorf("unknown dataset: %s", datasetID)
+// tagProblem records the dataset a problem was imported from; a no-op without a tag
+func tagProblem(ctx context.Context, q repo.Querier, problemID uuid.UUID, datasetTag string) error {
+	if datasetTag == "" {
+		return nil
+	}
+	if err := q.TagProblemWithDataset(ctx, repo.TagProblemWithDatasetParams{
+		ProblemID: problemID,
+		DatasetID: datasetTag,
+	}); err != nil {
+		return fmt.Errorf("failed to tag with dataset %q: %w", datasetTag, err)
 	}
+	return nil
+}
 
-	// Try configured path first
-	if s.datasetPath != "" {
-		path := filepath.Join(s.datasetPath, dataset.FileName)
-		file, err := os.Open(path)
-		if err == nil {
-			return file, nil
+// linkCompanies tags a problem with the row's companies, keeping existing tags
+func linkCompanies(ctx context.Context, q repo.Querier, problemID uuid.UUID, companyNames []string, companyIDMap map[string]uuid.UUID) error {
+	for _, companyName := range companyNames {
+		companyID, ok := companyIDMap[strings.ToLower(companyName)]
+		if !ok {
+			continue
+		}
+		if err := q.LinkProblemToCompany(ctx, repo.LinkProblemToCompanyParams{
+			ProblemID: problemID,
+			CompanyID: companyID,
+		}); err != nil {
+			return fmt.Errorf("failed to link company %q: %w", companyName, err)
 		}
 	}
+	return nil
+}
 
-	// Fallback to filesystem search (for development)
-	cwd, _ := os.Getwd()
-	possiblePaths := []string{
-		filepath.Join(cwd, "sample-datasets", dataset.FileName),
-		filepath.Join(cwd, "api", "sample-datasets", dataset.FileName),
-		filepath.Join(cwd, "..", "sample-datasets", dataset.FileName),
+// findDuplicate looks for an existing problem matching a CSV row, first by
+// title and source, then by normalized URL so edited titles still match
+func findDuplicate(ctx context.Context, q repo.Querier, prob ParsedProblem) (uuid.UUID, string, error) {
+	existing, err := q.GetProblemByTitleAndSource(ctx, repo.GetProblemByTitleAndSourceParams{
+		Title:  prob.Title,
+		Source: pgtype.Text{String: importSource(prob), Valid: true},
+	})
+	if err == nil {
+		return existing.ID, matchTitle, nil
+	}
+	if err != pgx.ErrNoRows {
+		return uuid.Nil, matchNone, err
 	}
 
-	for _, path := range possiblePaths {
-		file, err := os.Open(path)
-		if err == nil {
-			return file, nil
+	if prob.URL == "" {
+		return uuid.Nil, matchNone, nil
+	}
+	byURL, err := q.FindProblemByNormalizedURL(ctx, utils.NormalizeProblemURL(prob.URL))
+	if err == nil {
+		return byURL.ID, matchURL, nil
+	}
+	if err != pgx.ErrNoRows {
+		return uuid.Nil, matchNone, err
+	}
+	return uuid.Nil, matchNone, nil
+}
+
+// matchDuplicates is findDuplicate for many rows at once: one query by title
+// and source, one by normalized URL. It returns how each row matched, in order.
+func matchDuplicates(ctx context.Context, q repo.Querier, probs []ParsedProblem) ([]string, error) {
+	titles := make([]string, len(probs))
+	sources := make([]string, len(probs))
+	urls := make([]string, 0, len(probs))
+	for i, prob := range probs {
+		titles[i] = prob.Title
+		sources[i] = importSource(prob)
+		if prob.URL != "" {
+			urls = append(urls, utils.NormalizeProblemURL(prob.URL))
+		}
+	}
+
+	byTitle, err := q.FindProblemsByTitlesAndSources(ctx, repo.FindProblemsByTitlesAndSourcesParams{
+		Titles:  titles,
+		Sources: sources,
+	})
+	if err != nil {
+		return nil, err
+	}
+	existingTitles := make(map[[2]string]bool, len(byTitle))
+	for _, row := range byTitle {
+		existingTitles[[2]string{row.Title, row.Source.String}] = true
+	}
+
+	existingURLs := make(map[string]bool)
+	if len(urls) > 0 {
+		byURL, err := q.FindProblemsByNormalizedURLs(ctx, urls)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range byURL {
+			existingURLs[row.NormalizedUrl] = true
 		}
 	}
 
-	return nil, fmt.Errorf("could not find bundled dataset file: %s", dataset.FileName)
+	matches := make([]string, len(probs))
+	for i, prob := range probs {
+		switch {
+		case existingTitles[[2]string{titles[i], sources[i]}]:
+			matches[i] = matchTitle
+		case prob.URL != "" && existingURLs[utils.NormalizeProblemURL(prob.URL)]:
+			matches[i] = matchURL
+		default:
+			matches[i] = matchNone
+		}
+	}
+	return matches, nil
+}
+
+// importSource is the source stored for a row, defaulting to LeetCode
+func importSource(prob ParsedProblem) string {
+	if prob.Source == "" {
+		return "LeetCode"
+	}
+	return prob.Source
+}
+
+// cancelImport marks a partial result as cancelled. Rows already written stay
+// in place, and the counts reflect them.
+func cancelImport(result *ImportResult, startTime time.Time) *ImportResult {
+	result.Success = false
+	result.Cancelled = true
+	result.Duration = formatDuration(time.Since(startTime))
+	return result
 }
 
 // formatDuration formats a duration as a human-readable string
@@ -0,0 +1,367 @@
+package dataimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// importRepo is a library holding only the titles and normalized URLs it is
+// given: every pattern and every other problem in the CSV is new. It records
+// the duplicate lookups, the rows created and the job records written.
+type importRepo struct {
+	*testutil.Querier
+	titles map[string]bool
+	urls   map[string]bool
+}
+
+func newImportRepo() *importRepo {
+	return &importRepo{Querier: testutil.NewQuerier()}
+}
+
+func (f *importRepo) GetLatestImportJobByHash(ctx context.Context, fileHash string) (repo.ImportJob, error) {
+	return repo.ImportJob{}, pgx.ErrNoRows
+}
+
+func (f *importRepo) CreateImportJob(ctx context.Context, arg repo.CreateImportJobParams) (repo.ImportJob, error) {
+	return repo.ImportJob{ID: uuid.New()}, nil
+}
+
+func (f *importRepo) UpdateImportJobProgress(ctx context.Context, arg repo.UpdateImportJobProgressParams) error {
+	f.Record("UpdateImportJobProgress", arg)
+	return nil
+}
+
+func (f *importRepo) FinishImportJob(ctx context.Context, arg repo.FinishImportJobParams) error {
+	f.Record("FinishImportJob", arg)
+	return nil
+}
+
+func (f *importRepo) GetPatternByTitle(ctx context.Context, lower string) (repo.Pattern, error) {
+	return repo.Pattern{}, pgx.ErrNoRows
+}
+
+func (f *importRepo) CreatePattern(ctx context.Context, arg repo.CreatePatternParams) (repo.Pattern, error) {
+	f.Record("CreatePattern", arg)
+	return repo.Pattern{ID: uuid.New(), Title: arg.Title}, nil
+}
+
+func (f *importRepo) FindProblemsByTitlesAndSources(ctx context.Context, arg repo.FindProblemsByTitlesAndSourcesParams) ([]repo.FindProblemsByTitlesAndSourcesRow, error) {
+	f.Record("FindProblemsByTitlesAndSources", len(arg.Titles))
+	var rows []repo.FindProblemsByTitlesAndSourcesRow
+	for i, title := range arg.Titles {
+		if f.titles[title] {
+			rows = append(rows, repo.FindProblemsByTitlesAndSourcesRow{ID: uuid.New(), Title: title, Source: pgtype.Text{String: arg.Sources[i], Valid: true}})
+		}
+	}
+	return rows, nil
+}
+
+func (f *importRepo) FindProblemsByNormalizedURLs(ctx context.Context, normalizedUrls []string) ([]repo.FindProblemsByNormalizedURLsRow, error) {
+	f.Record("FindProblemsByNormalizedURLs", len(normalizedUrls))
+	var rows []repo.FindProblemsByNormalizedURLsRow
+	for _, url := range normalizedUrls {
+		if f.urls[url] {
+			rows = append(rows, repo.FindProblemsByNormalizedURLsRow{ID: uuid.New(), NormalizedUrl: url})
+		}
+	}
+	return rows, nil
+}
+
+func (f *importRepo) GetProblemByTitleAndSource(ctx context.Context, arg repo.GetProblemByTitleAndSourceParams) (repo.Problem, error) {
+	return repo.Problem{}, pgx.ErrNoRows
+}
+
+func (f *importRepo) CreateProblem(ctx context.Context, arg repo.CreateProblemParams) (repo.Problem, error) {
+	f.Record("CreateProblem", arg)
+	return repo.Problem{ID: uuid.New(), Title: arg.Title}, nil
+}
+
+func (f *importRepo) LinkProblemToPatternIfNotExists(ctx context.Context, arg repo.LinkProblemToPatternIfNotExistsParams) error {
+	return nil
+}
+
+func newImportService(f *importRepo) Service {
+	return NewService(f, testutil.Transactor{Q: f}, "", 0, metrics.Noop{})
+}
+
+// problemsCSV builds an upload of n problems spread over the given number of
+// patterns
+func problemsCSV(n, patterns int) string {
+	var b strings.Builder
+	b.WriteString("title,difficulty,patterns\n")
+	for i := range n {
+		fmt.Fprintf(&b, "Problem %05d,medium,Pattern %d\n", i, i%patterns)
+	}
+	return b.String()
+}
+
+// Cancelling mid-import stops at the next pattern or batch and records the
+// job as cancelled with the counts so far
+func TestExecuteImportCancelledAfterProgress(t *testing.T) {
+	// 5 patterns then 120 problems: progress callback 1 announces the pattern
+	// phase, 2-6 report each pattern and 7-9 each batch of up to 50 problems
+	tests := []struct {
+		name            string
+		cancelOn        int // Cancel on this progress callback; 0 never cancels
+		patternsCreated int
+		problemsCreated int
+	}{
+		{"during the pattern phase", 3, 2, 0},
+		{"after the last pattern", 6, 5, 0},
+		{"after the first batch", 7, 5, BatchSize},
+		{"after the second batch", 8, 5, 2 * BatchSize},
+		{"not cancelled", 0, 5, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newImportRepo()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var phases []string
+			progress := func(p ImportProgress) {
+				phases = append(phases, p.Phase)
+				if len(phases) == tt.cancelOn {
+					cancel()
+				}
+			}
+			result, err := newImportService(f).ExecuteImportFromReader(ctx, strings.NewReader(problemsCSV(120, 5)), ImportOptions{}, progress)
+			if err != nil {
+				t.Fatalf("ExecuteImportFromReader: %v", err)
+			}
+
+			cancelled := tt.cancelOn > 0
+			if result.Cancelled != cancelled || result.Success == cancelled {
+				t.Errorf("cancelled = %v, success = %v; want cancelled %v", result.Cancelled, result.Success, cancelled)
+			}
+			if cancelled && len(phases) != tt.cancelOn {
+				t.Errorf("%d progress callbacks, want none after the cancel at %d", len(phases), tt.cancelOn)
+			}
+			if result.PatternsCreated != tt.patternsCreated {
+				t.Errorf("patterns created = %d, want %d", result.PatternsCreated, tt.patternsCreated)
+			}
+			if result.ProblemsCreated != tt.problemsCreated {
+				t.Errorf("problems created = %d, want %d", result.ProblemsCreated, tt.problemsCreated)
+			}
+			if n := len(f.CallsTo("CreateProblem")); n != tt.problemsCreated {
+				t.Errorf("%d problems written, want %d", n, tt.problemsCreated)
+			}
+
+			finishes := f.CallsTo("FinishImportJob")
+			if len(finishes) != 1 {
+				t.Fatalf("FinishImportJob called %d times, want 1", len(finishes))
+			}
+			job := finishes[0].(repo.FinishImportJobParams)
+			wantStatus := JobComplete
+			if cancelled {
+				wantStatus = JobCancelled
+			}
+			if job.Status != wantStatus {
+				t.Errorf("job status = %q, want %q", job.Status, wantStatus)
+			}
+			if job.ID.String() != result.JobID {
+				t.Errorf("finished job %s, result reports %s", job.ID, result.JobID)
+			}
+			if int(job.ProblemsProcessed) != tt.problemsCreated || int(job.ProblemsCreated) != tt.problemsCreated ||
+				int(job.PatternsCreated) != tt.patternsCreated {
+				t.Errorf("job counts = %d processed, %d problems, %d patterns; want %d, %d, %d",
+					job.ProblemsProcessed, job.ProblemsCreated, job.PatternsCreated,
+					tt.problemsCreated, tt.problemsCreated, tt.patternsCreated)
+			}
+		})
+	}
+}
+
+// A 20k-row preview is streamed and checked for duplicates a chunk at a time,
+// and a row cap rejects anything longer
+func TestParseCSVLargeFile(t *testing.T) {
+	const rows = 20000
+
+	// Every 10th title already exists, as does the URL of every 10th row
+	// offset by 5
+	f := newImportRepo()
+	f.titles, f.urls = map[string]bool{}, map[string]bool{}
+	var b strings.Builder
+	b.WriteString("title,url,difficulty,patterns\n")
+	for i := range rows {
+		title := fmt.Sprintf("Problem %05d", i)
+		url := fmt.Sprintf("https://leetcode.com/problems/problem-%05d/", i)
+		fmt.Fprintf(&b, "%s,%s,%s,Pattern %d\n", title, url, []string{"easy", "medium", "hard"}[i%3], i%40)
+		switch i % 10 {
+		case 0:
+			f.titles[title] = true
+		case 5:
+			f.urls[utils.NormalizeProblemURL(url)] = true
+		}
+	}
+	csv := b.String()
+
+	result, err := NewService(f, testutil.Transactor{Q: f}, "", rows, metrics.Noop{}).
+		ParseCSV(context.Background(), strings.NewReader(csv), DuplicateSkip, CSVMapping{})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if result.TotalRows != rows || result.ValidRows != rows || len(result.InvalidRows) != 0 {
+		t.Errorf("rows = %d total, %d valid, %d invalid; want %d valid", result.TotalRows, result.ValidRows, len(result.InvalidRows), rows)
+	}
+	if d := result.Difficulties; d["easy"]+d["medium"]+d["hard"] != rows {
+		t.Errorf("difficulties = %v, want %d rows in all", d, rows)
+	}
+	if len(result.PatternsToCreate) != 40 {
+		t.Errorf("%d patterns to create, want 40", len(result.PatternsToCreate))
+	}
+	want := DuplicateBreakdown{Mode: DuplicateSkip, MatchedByTitle: rows / 10, MatchedByURL: rows / 10, WouldSkip: rows / 5}
+	if result.Duplicates != want || result.DuplicateCount != rows/5 {
+		t.Errorf("duplicates = %+v (count %d), want %+v", result.Duplicates, result.DuplicateCount, want)
+	}
+
+	// One lookup of each kind per chunk, never one per row
+	chunks := rows / duplicateCheckChunk
+	for _, method := range []string{"FindProblemsByTitlesAndSources", "FindProblemsByNormalizedURLs"} {
+		calls := f.CallsTo(method)
+		if len(calls) != chunks {
+			t.Errorf("%s called %d times, want %d", method, len(calls), chunks)
+		}
+		for _, n := range calls {
+			if n != duplicateCheckChunk {
+				t.Errorf("%s looked up %v rows at once, want %d", method, n, duplicateCheckChunk)
+			}
+		}
+	}
+
+	_, err = NewService(newImportRepo(), nil, "", rows-1, metrics.Noop{}).
+		ParseCSV(context.Background(), strings.NewReader(csv), DuplicateSkip, CSVMapping{})
+	var limitErr *RowLimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != rows-1 {
+		t.Errorf("ParseCSV over the cap: err = %v, want RowLimitError for %d rows", err, rows-1)
+	}
+}
+
+// commitLatency stands in for one durable commit, i.e. a WAL flush to disk
+const commitLatency = time.Millisecond
+
+// commitRepo charges commitLatency for every write that commits: each one on
+// its own outside a transaction, once per transaction inside one
+type commitRepo struct {
+	*importRepo
+	commits *int
+	inTx    bool
+}
+
+func (f commitRepo) write() {
+	if !f.inTx {
+		*f.commits++
+		time.Sleep(commitLatency)
+	}
+}
+
+func (f commitRepo) CreateImportJob(ctx context.Context, arg repo.CreateImportJobParams) (repo.ImportJob, error) {
+	f.write()
+	return f.importRepo.CreateImportJob(ctx, arg)
+}
+
+func (f commitRepo) UpdateImportJobProgress(ctx context.Context, arg repo.UpdateImportJobProgressParams) error {
+	f.write()
+	return f.importRepo.UpdateImportJobProgress(ctx, arg)
+}
+
+func (f commitRepo) FinishImportJob(ctx context.Context, arg repo.FinishImportJobParams) error {
+	f.write()
+	return f.importRepo.FinishImportJob(ctx, arg)
+}
+
+func (f commitRepo) CreatePattern(ctx context.Context, arg repo.CreatePatternParams) (repo.Pattern, error) {
+	f.write()
+	return f.importRepo.CreatePattern(ctx, arg)
+}
+
+func (f commitRepo) CreateProblem(ctx context.Context, arg repo.CreateProblemParams) (repo.Problem, error) {
+	f.write()
+	return f.importRepo.CreateProblem(ctx, arg)
+}
+
+func (f commitRepo) LinkProblemToPatternIfNotExists(ctx context.Context, arg repo.LinkProblemToPatternIfNotExistsParams) error {
+	f.write()
+	return f.importRepo.LinkProblemToPatternIfNotExists(ctx, arg)
+}
+
+// commitTransactor commits each batch once, or with autocommit set runs the
+// batch's statements as separate commits the way imports did before batching
+type commitTransactor struct {
+	repo       commitRepo
+	autocommit bool
+}
+
+func (t commitTransactor) WithTx(ctx context.Context, fn func(ctx context.Context, q repo.Querier) error) error {
+	if t.autocommit {
+		return fn(ctx, t.repo)
+	}
+	inTx := t.repo
+	inTx.inTx = true
+	if err := fn(ctx, inTx); err != nil {
+		return err
+	}
+	inTx.inTx = false
+	inTx.write()
+	return nil
+}
+
+// A 2k-row import with commitLatency per commit, batched versus one commit
+// per statement. Run with -benchtime=1x; the autocommit case takes seconds.
+func BenchmarkExecuteImport(b *testing.B) {
+	upload := problemsCSV(2000, 20)
+
+	for _, autocommit := range []bool{false, true} {
+		name := "batched"
+		if autocommit {
+			name = "autocommit"
+		}
+		b.Run(name, func(b *testing.B) {
+			commits := 0
+			for b.Loop() {
+				f := commitRepo{importRepo: newImportRepo(), commits: &commits}
+				s := NewService(f, commitTransactor{repo: f, autocommit: autocommit}, "", 0, metrics.Noop{})
+				result, err := s.ExecuteImportFromReader(context.Background(), strings.NewReader(upload), ImportOptions{}, func(ImportProgress) {})
+				if err != nil || result.ProblemsCreated != 2000 {
+					b.Fatalf("ExecuteImportFromReader = %+v, %v; want 2000 problems created", result, err)
+				}
+			}
+			b.ReportMetric(float64(commits)/float64(b.N), "commits/op")
+		})
+	}
+}
+
+// The same 2k-row import against a real database, for numbers that include
+// the server's own commit cost
+func BenchmarkExecuteImportAgainstDatabase(b *testing.B) {
+	db := testutil.NewDB(b)
+	s := NewService(db.Queries, db.Transactor, "", 0, metrics.Noop{})
+
+	run := 0
+	for b.Loop() {
+		// Fresh titles each run, or every row after the first would be skipped
+		run++
+		var upload strings.Builder
+		upload.WriteString("title,difficulty,patterns\n")
+		for i := range 2000 {
+			fmt.Fprintf(&upload, "Run %d problem %05d,medium,Pattern %d\n", run, i, i%20)
+		}
+
+		result, err := s.ExecuteImportFromReader(context.Background(), strings.NewReader(upload.String()), ImportOptions{}, func(ImportProgress) {})
+		if err != nil || result.ProblemsCreated != 2000 {
+			b.Fatalf("ExecuteImportFromReader = %+v, %v; want 2000 problems created", result, err)
+		}
+	}
+}
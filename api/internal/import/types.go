@@ -1,12 +1,26 @@
 package dataimport
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // CSVRow represents a single row from the import CSV
 type CSVRow struct {
 	Title      string `json:"title"`
 	URL        string `json:"url"`
 	Source     string `json:"source"`
 	Difficulty string `json:"difficulty"`
-	Patterns   string `json:"patterns"` // Comma-separated pattern names
+	Patterns   string `json:"patterns"`  // Comma-separated pattern names
+	Companies  string `json:"companies"` // Comma-separated company names, optional
+}
+
+// CSVMapping adapts a CSV with its own headers and difficulty labels
+type CSVMapping struct {
+	Columns          map[string]string `json:"column_mapping,omitempty"`     // Target column -> CSV header
+	DifficultyValues map[string]string `json:"difficulty_mapping,omitempty"` // CSV value -> easy/medium/hard
 }
 
 // ParsedProblem is a validated problem ready for import
@@ -16,6 +30,7 @@ type ParsedProblem struct {
 	Source     string   `json:"source"`
 	Difficulty string   `json:"difficulty"`
 	Patterns   []string `json:"patterns"`
+	Companies  []string `json:"companies"`
 	RowNumber  int      `json:"row_number"`
 }
 
@@ -39,24 +54,42 @@ type BundledDataset struct {
 
 // ParseResult is returned after parsing a CSV file
 type ParseResult struct {
-	TotalRows        int            `json:"total_rows"`
-	ValidRows        int            `json:"valid_rows"`
-	InvalidRows      []InvalidRow   `json:"invalid_rows"`
-	PatternsToCreate []string       `json:"patterns_to_create"` // New patterns that will be created
-	ExistingPatterns []string       `json:"existing_patterns"`  // Patterns already in DB
-	DuplicateCount   int            `json:"duplicate_count"`    // Problems that already exist
-	Difficulties     map[string]int `json:"difficulties"`       // easy/medium/hard counts
+	Headers          []string           `json:"headers"` // Header row as found in the CSV
+	TotalRows        int                `json:"total_rows"`
+	ValidRows        int                `json:"valid_rows"`
+	InvalidRows      []InvalidRow       `json:"invalid_rows"`
+	PatternsToCreate []string           `json:"patterns_to_create"` // New patterns that will be created
+	ExistingPatterns []string           `json:"existing_patterns"`  // Patterns already in DB
+	DuplicateCount   int                `json:"duplicate_count"`    // Problems that already exist
+	Duplicates       DuplicateBreakdown `json:"duplicates"`         // How duplicates would be handled
+	Difficulties     map[string]int     `json:"difficulties"`       // easy/medium/hard counts
+}
+
+// DuplicateBreakdown splits the duplicate count by how each was matched and
+// what the chosen on_duplicate mode would do with it
+type DuplicateBreakdown struct {
+	Mode           string `json:"mode"`
+	MatchedByTitle int    `json:"matched_by_title"` // Same title and source
+	MatchedByURL   int    `json:"matched_by_url"`   // Same normalized URL
+	WouldSkip      int    `json:"would_skip"`
+	WouldUpdate    int    `json:"would_update"`
+	WouldFail      int    `json:"would_fail"`
 }
 
 // ImportOptions configures the import execution
 type ImportOptions struct {
-	UseBundled   bool   `json:"use_bundled"`
-	DatasetID    string `json:"dataset_id,omitempty"`    // If using bundled dataset
-	SkipPatterns bool   `json:"skip_patterns,omitempty"` // Don't create/link patterns
+	UseBundled   bool       `json:"use_bundled"`
+	DatasetID    string     `json:"dataset_id,omitempty"`    // If using bundled dataset
+	SkipPatterns bool       `json:"skip_patterns,omitempty"` // Don't create/link patterns
+	OnDuplicate  string     `json:"on_duplicate,omitempty"`  // "skip" (default), "update" or "fail"
+	TagDataset   bool       `json:"tag_dataset,omitempty"`   // Tag every row's problem with the bundled dataset ID
+	Mapping      CSVMapping `json:"mapping"`                 // Column/difficulty mapping for uploaded CSVs
+	UserID       *uuid.UUID `json:"-"`                       // Admin running the import; nil during onboarding
 }
 
 // ImportProgress is sent via SSE during import
 type ImportProgress struct {
+	JobID             string  `json:"job_id"`             // Import job recording this run
 	Phase             string  `json:"phase"`              // "patterns", "problems", "complete", "error"
 	CurrentItem       string  `json:"current_item"`       // Current problem/pattern name
 	CurrentIndex      int     `json:"current_index"`      // 0-based index
@@ -64,6 +97,7 @@ type ImportProgress struct {
 	ProblemsCreated   int     `json:"problems_created"`   // Running count
 	PatternsCreated   int     `json:"patterns_created"`   // Running count
 	DuplicatesSkipped int     `json:"duplicates_skipped"` // Running count
+	ProblemsUpdated   int     `json:"problems_updated"`   // Running count
 	Percentage        float64 `json:"percentage"`         // 0-100
 	Error             string  `json:"error,omitempty"`    // Error message if phase is "error"
 
@@ -75,7 +109,7 @@ type ImportProgress struct {
 type RecentItem struct {
 	Title      string `json:"title"`
 	Difficulty string `json:"difficulty"`
-	Status     string `json:"status"` // "created", "skipped", "error"
+	Status     string `json:"status"` // "created", "skipped", "updated", "error"
 }
 
 // ImportResult is the final result after import completes
@@ -84,8 +118,47 @@ type ImportResult struct {
 	ProblemsCreated   int           `json:"problems_created"`
 	PatternsCreated   int           `json:"patterns_created"`
 	DuplicatesSkipped int           `json:"duplicates_skipped"`
+	ProblemsUpdated   int           `json:"problems_updated"`
 	Errors            []ImportError `json:"errors,omitempty"`
-	Duration          string        `json:"duration"` // Human-readable duration
+	Duration          string        `json:"duration"`            // Human-readable duration
+	Cancelled         bool          `json:"cancelled,omitempty"` // Stopped early; counts are partial
+
+	// JobID is the durable record of this run. When the same file was
+	// imported before, PriorJobID names that job and AlreadyImported counts
+	// the rows that were found to exist already.
+	JobID           string  `json:"job_id"`
+	PriorJobID      *string `json:"prior_job_id,omitempty"`
+	AlreadyImported int     `json:"already_imported"`
+}
+
+// ImportJob is the stored record of an import run
+type ImportJob struct {
+	ID                string          `json:"id"`
+	UserID            *string         `json:"user_id"`
+	Source            string          `json:"source"` // "bundled" or "upload"
+	DatasetID         *string         `json:"dataset_id"`
+	FileHash          string          `json:"file_hash"`
+	Options           json.RawMessage `json:"options"`
+	Status            string          `json:"status"` // "running", "complete", "failed" or "cancelled"
+	TotalRows         int32           `json:"total_rows"`
+	TotalPatterns     int32           `json:"total_patterns"`
+	PatternsCreated   int32           `json:"patterns_created"`
+	ProblemsProcessed int32           `json:"problems_processed"`
+	ProblemsCreated   int32           `json:"problems_created"`
+	ProblemsUpdated   int32           `json:"problems_updated"`
+	DuplicatesSkipped int32           `json:"duplicates_skipped"`
+	ErrorCount        int32           `json:"error_count"`
+	PriorJobID        *string         `json:"prior_job_id"`
+	ErrorMessage      *string         `json:"error_message"`
+	StartedAt         string          `json:"started_at"`
+	FinishedAt        *string         `json:"finished_at"`
+}
+
+type ImportJobListResponse struct {
+	Jobs  []ImportJob `json:"jobs"`
+	Total int64       `json:"total"`
+	Page  int         `json:"page"`
+	Limit int         `json:"limit"`
 }
 
 // ImportError represents an error during import
@@ -99,8 +172,9 @@ type ImportError struct {
 
 // ParseCSVRequest is the request body for parsing CSV
 type ParseCSVRequest struct {
-	UseBundled bool   `json:"use_bundled"`
-	DatasetID  string `json:"dataset_id,omitempty"`
+	UseBundled  bool   `json:"use_bundled"`
+	DatasetID   string `json:"dataset_id,omitempty"`
+	OnDuplicate string `json:"on_duplicate,omitempty"`
 }
 
 // ExecuteImportRequest starts the import process
@@ -108,3 +182,114 @@ type ExecuteImportRequest struct {
 	UseBundled bool   `json:"use_bundled"`
 	DatasetID  string `json:"dataset_id,omitempty"`
 }
+
+// --- Account backup types ---
+
+// Backup is the account backup document. Sections are written, and must be
+// restored, in this field order because later sections reference earlier IDs.
+type Backup struct {
+	Version         int                  `json:"version"`
+	ExportedAt      string               `json:"exported_at"`
+	Counts          map[string]int       `json:"counts"` // Items per section, for restore progress
+	Patterns        []BackupPattern      `json:"patterns"`
+	Problems        []BackupProblem      `json:"problems"`
+	ProblemPatterns []BackupLink         `json:"problem_patterns"`
+	Sessions        []BackupSession      `json:"sessions"`
+	Attempts        []BackupAttempt      `json:"attempts"`
+	ProblemStats    []BackupProblemStats `json:"problem_stats"`
+	PatternStats    []BackupPatternStats `json:"pattern_stats"`
+	ScoringSettings map[string]string    `json:"scoring_settings"`
+}
+
+type BackupPattern struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+}
+
+type BackupProblem struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	Source     *string `json:"source"`
+	URL        *string `json:"url"`
+	Difficulty *string `json:"difficulty"`
+}
+
+type BackupLink struct {
+	ProblemID string `json:"problem_id"`
+	PatternID string `json:"pattern_id"`
+}
+
+type BackupSession struct {
+	ID                 string     `json:"id"`
+	TemplateKey        *string    `json:"template_key"`
+	SessionName        *string    `json:"session_name"`
+	IsCustom           *bool      `json:"is_custom"`
+	CustomConfigJSON   *string    `json:"custom_config_json"`
+	PlannedDurationMin *int32     `json:"planned_duration_min"`
+	ItemsOrdered       *string    `json:"items_ordered"` // JSON array referencing problem IDs
+	ElapsedTimeSeconds *int32     `json:"elapsed_time_seconds"`
+	CreatedAt          *time.Time `json:"created_at"`
+	CompletedAt        *time.Time `json:"completed_at"`
+}
+
+type BackupAttempt struct {
+	ID              string     `json:"id"`
+	ProblemID       string     `json:"problem_id"`
+	SessionID       *string    `json:"session_id"`
+	ConfidenceScore *int32     `json:"confidence_score"`
+	DurationSeconds *int32     `json:"duration_seconds"`
+	Outcome         *string    `json:"outcome"`
+	Notes           *string    `json:"notes"`
+	Status          *string    `json:"status"`
+	StartedAt       *time.Time `json:"started_at"`
+	PerformedAt     *time.Time `json:"performed_at"`
+}
+
+type BackupProblemStats struct {
+	ProblemID           string     `json:"problem_id"`
+	Status              *string    `json:"status"`
+	Confidence          *int32     `json:"confidence"`
+	AvgConfidence       *int32     `json:"avg_confidence"`
+	LastAttemptAt       *time.Time `json:"last_attempt_at"`
+	TotalAttempts       *int32     `json:"total_attempts"`
+	AvgTimeSeconds      *int32     `json:"avg_time_seconds"`
+	LastOutcome         *string    `json:"last_outcome"`
+	NextReviewAt        *time.Time `json:"next_review_at"`
+	IntervalDays        *int32     `json:"interval_days"`
+	EaseFactor          *float32   `json:"ease_factor"`
+	ReviewCount         *int32     `json:"review_count"`
+	ConsecutiveFailures *int32     `json:"consecutive_failures"`
+	Notes               *string    `json:"notes"`
+}
+
+type BackupPatternStats struct {
+	PatternID     string     `json:"pattern_id"`
+	TimesRevised  *int32     `json:"times_revised"`
+	AvgConfidence *int32     `json:"avg_confidence"`
+	LastRevisedAt *time.Time `json:"last_revised_at"`
+}
+
+// RestoreResult is the final result after a backup restore completes
+type RestoreResult struct {
+	Success         bool                            `json:"success"`
+	Sections        map[string]*RestoreSectionCount `json:"sections"`
+	Conflicts       []RestoreConflict               `json:"conflicts"`
+	SettingsApplied bool                            `json:"settings_applied"`
+	Duration        string                          `json:"duration"`
+}
+
+// RestoreSectionCount tallies one backup section. Matched items already
+// existed (by natural key) and were mapped rather than created.
+type RestoreSectionCount struct {
+	Created int `json:"created"`
+	Matched int `json:"matched"`
+	Skipped int `json:"skipped"`
+}
+
+// RestoreConflict is a backup item that couldn't be restored
+type RestoreConflict struct {
+	Section string `json:"section"`
+	ID      string `json:"id"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	runner *Runner
+}
+
+func NewHandler(runner *Runner) *handler {
+	return &handler{
+		runner: runner,
+	}
+}
+
+// ListJobs - GET /api/v1/admin/jobs
+// Returns every background job with the outcome of its last run
+func (h *handler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.runner.List(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list background jobs", "error", err)
+		utils.InternalServerError(w, "Failed to list background jobs")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, jobs)
+}
+
+// RunJob - POST /api/v1/admin/jobs/{name}/run
+// Starts a job right away; the run finishes in the background, so poll
+// ListJobs for its outcome
+func (h *handler) RunJob(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	adminID, _ := auth.UserIDFromContext(r.Context())
+
+	err := h.runner.Trigger(name)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		utils.NotFound(w, "Job not found")
+		return
+	case errors.Is(err, ErrJobRunning):
+		utils.Conflict(w, "Job is already running", nil)
+		return
+	case errors.Is(err, ErrNotStarted):
+		utils.ServiceUnavailable(w, "Background jobs are not running")
+		return
+	case err != nil:
+		logging.FromContext(r.Context()).Error("Failed to start background job", "job", name, "error", err)
+		utils.InternalServerError(w, "Failed to start job")
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Background job triggered by admin", "admin_id", adminID, "job", name)
+	utils.WriteSuccess(w, http.StatusAccepted, map[string]string{"name": name, "status": "started"})
+}
@@ -0,0 +1,205 @@
+// Package jobs runs periodic background work such as token cleanup. Each job
+// runs at most once at a time, and the outcome of its last run is stored so
+// admins can see it and trigger runs by hand.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrJobNotFound = fmt.Errorf("job %w", utils.ErrNotFound)
+	ErrJobRunning  = errors.New("job is already running")
+	ErrNotStarted  = errors.New("job runner is not running")
+)
+
+// Triggers recorded for a run
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Func is the work a job does; it must return promptly once ctx is done
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       Func
+
+	mu      sync.Mutex  // Held for the duration of a run
+	running atomic.Bool // Mirrors mu for List, which must not block on it
+}
+
+// Runner owns the registered jobs. Register them all before Start.
+// Overlap protection is per process, so with several API instances each one
+// runs its own schedule.
+type Runner struct {
+	repo repo.Querier
+	jobs map[string]*job
+
+	// Set by Start: the context runs use, and the group Start's wait blocks on
+	ctx context.Context
+	wg  sync.WaitGroup
+}
+
+func NewRunner(repo repo.Querier) *Runner {
+	return &Runner{
+		repo: repo,
+		jobs: make(map[string]*job),
+	}
+}
+
+// Register adds a job that runs every interval, starting as soon as the
+// runner starts. Names must be unique.
+func (r *Runner) Register(name string, interval time.Duration, fn Func) {
+	if _, exists := r.jobs[name]; exists {
+		panic(fmt.Sprintf("jobs: %q registered twice", name))
+	}
+	r.jobs[name] = &job{name: name, interval: interval, fn: fn}
+}
+
+// Start launches every job's schedule; they stop when ctx is cancelled. The
+// returned wait blocks until scheduled and manual runs have all returned.
+func (r *Runner) Start(ctx context.Context) (wait func()) {
+	r.ctx = ctx
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.schedule(ctx, j)
+		}()
+	}
+	return r.wg.Wait
+}
+
+// Trigger starts a run of the named job in the background. It fails with
+// ErrJobRunning instead of queueing when a run is already underway.
+func (r *Runner) Trigger(name string) error {
+	j, ok := r.jobs[name]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return ErrNotStarted
+	}
+	if !j.mu.TryLock() {
+		return ErrJobRunning
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer j.mu.Unlock()
+		r.run(r.ctx, j, TriggerManual)
+	}()
+	return nil
+}
+
+// List returns every registered job with its last recorded run
+func (r *Runner) List(ctx context.Context) ([]JobStatus, error) {
+	rows, err := r.repo.ListBackgroundJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	lastRuns := make(map[string]repo.BackgroundJob, len(rows))
+	for _, row := range rows {
+		lastRuns[row.Name] = row
+	}
+
+	statuses := make([]JobStatus, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		status := JobStatus{
+			Name:            j.name,
+			IntervalSeconds: int64(j.interval / time.Second),
+			Running:         j.running.Load(),
+		}
+		if row, ok := lastRuns[j.name]; ok {
+			status.LastRun = &JobRun{
+				Trigger:    row.LastTrigger,
+				StartedAt:  row.LastStartedAt.Format(time.RFC3339),
+				FinishedAt: row.LastFinishedAt.Format(time.RFC3339),
+				DurationMs: row.LastDurationMs,
+				Error:      pgTextToPtr(row.LastError),
+			}
+			status.RunCount = int64(row.RunCount)
+			status.FailureCount = int64(row.FailureCount)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, k int) bool { return statuses[i].Name < statuses[k].Name })
+	return statuses, nil
+}
+
+// schedule runs j right away and then every interval until ctx is done. A tick
+// that lands while a manual run is underway is skipped.
+func (r *Runner) schedule(ctx context.Context, j *job) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if j.mu.TryLock() {
+			r.run(ctx, j, TriggerSchedule)
+			j.mu.Unlock()
+		} else {
+			slog.Debug("Skipping scheduled run, job is already running", "job", j.name)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run executes j and records the outcome; the caller holds j.mu
+func (r *Runner) run(ctx context.Context, j *job, trigger string) {
+	j.running.Store(true)
+	defer j.running.Store(false)
+
+	started := time.Now()
+	err := j.fn(ctx)
+	finished := time.Now()
+
+	// A run cut short by shutdown isn't a failure worth recording
+	if ctx.Err() != nil {
+		return
+	}
+
+	var lastError pgtype.Text
+	if err != nil {
+		slog.Error("Background job failed", "job", j.name, "trigger", trigger, "error", err)
+		lastError = pgtype.Text{String: err.Error(), Valid: true}
+	}
+
+	recordErr := r.repo.RecordBackgroundJobRun(ctx, repo.RecordBackgroundJobRunParams{
+		Name:           j.name,
+		LastTrigger:    trigger,
+		LastStartedAt:  started,
+		LastFinishedAt: finished,
+		LastDurationMs: finished.Sub(started).Milliseconds(),
+		LastError:      lastError,
+	})
+	if recordErr != nil {
+		slog.Warn("Failed to record background job run", "job", j.name, "error", recordErr)
+	}
+}
+
+func pgTextToPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
@@ -0,0 +1,20 @@
+package jobs
+
+// JobStatus describes a registered job for the admin jobs view
+type JobStatus struct {
+	Name            string  `json:"name"`
+	IntervalSeconds int64   `json:"interval_seconds"`
+	Running         bool    `json:"running"`
+	RunCount        int64   `json:"run_count"`
+	FailureCount    int64   `json:"failure_count"`
+	LastRun         *JobRun `json:"last_run"` // nil until the job has run once
+}
+
+// JobRun is the outcome of a job's most recent run
+type JobRun struct {
+	Trigger    string  `json:"trigger"` // "schedule" or "manual"
+	StartedAt  string  `json:"started_at"`
+	FinishedAt string  `json:"finished_at"`
+	DurationMs int64   `json:"duration_ms"`
+	Error      *string `json:"error"` // nil when the run succeeded
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Handler serves the metrics. With a token, scrapers must send it as a
+// bearer token; an empty token leaves the endpoint open.
+func (m *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			want := "Bearer " + token
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		m.write(bw)
+		bw.Flush()
+	})
+}
+
+func (m *Registry) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header(w, "reforge_http_requests_total", "counter", "HTTP requests by method, route template and status class.")
+	for _, key := range sortedKeys(m.requests) {
+		fmt.Fprintf(w, "reforge_http_requests_total%s %d\n", key, m.requests[key])
+	}
+
+	header(w, "reforge_http_request_duration_seconds", "histogram", "HTTP request latency by method and route template.")
+	for _, key := range sortedKeys(m.durations) {
+		h := m.durations[key]
+		var cumulative uint64
+		for i, bound := range h.bounds {
+			cumulative += h.counts[i]
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(w, "reforge_http_request_duration_seconds_bucket%s %d\n", key.with("le", le), cumulative)
+		}
+		fmt.Fprintf(w, "reforge_http_request_duration_seconds_bucket%s %d\n", key.with("le", "+Inf"), h.count)
+		fmt.Fprintf(w, "reforge_http_request_duration_seconds_sum%s %g\n", key, h.sum)
+		fmt.Fprintf(w, "reforge_http_request_duration_seconds_count%s %d\n", key, h.count)
+	}
+
+	header(w, "reforge_attempts_created_total", "counter", "Attempts logged or started.")
+	fmt.Fprintf(w, "reforge_attempts_created_total %d\n", m.attempts)
+
+	header(w, "reforge_sessions_generated_total", "counter", "Sessions generated, by template.")
+	for _, key := range sortedKeys(m.sessions) {
+		fmt.Fprintf(w, "reforge_sessions_generated_total%s %d\n", key, m.sessions[key])
+	}
+
+	header(w, "reforge_imports_total", "counter", "Problem imports and backup restores, by kind and outcome.")
+	for _, key := range sortedKeys(m.imports) {
+		fmt.Fprintf(w, "reforge_imports_total%s %d\n", key, m.imports[key])
+	}
+
+	if m.pool != nil {
+		stat := m.pool.Stat()
+		header(w, "reforge_db_pool_acquired_connections", "gauge", "Database connections currently in use.")
+		fmt.Fprintf(w, "reforge_db_pool_acquired_connections %d\n", stat.AcquiredConns())
+		header(w, "reforge_db_pool_idle_connections", "gauge", "Idle database connections.")
+		fmt.Fprintf(w, "reforge_db_pool_idle_connections %d\n", stat.IdleConns())
+		header(w, "reforge_db_pool_total_connections", "gauge", "Open database connections.")
+		fmt.Fprintf(w, "reforge_db_pool_total_connections %d\n", stat.TotalConns())
+		header(w, "reforge_db_pool_max_connections", "gauge", "Maximum database connections.")
+		fmt.Fprintf(w, "reforge_db_pool_max_connections %d\n", stat.MaxConns())
+		header(w, "reforge_db_pool_acquires_total", "counter", "Connections acquired from the pool.")
+		fmt.Fprintf(w, "reforge_db_pool_acquires_total %d\n", stat.AcquireCount())
+	}
+}
+
+func header(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
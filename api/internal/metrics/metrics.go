@@ -0,0 +1,213 @@
+// Package metrics keeps in-process counters, gauges and histograms and serves
+// them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Recorder counts domain events. Services take one so tests and tools can
+// pass Noop.
+type Recorder interface {
+	AttemptCreated()
+	SessionGenerated(templateKey string)
+	ImportRun(kind, outcome string)
+}
+
+// Noop is a Recorder that records nothing
+type Noop struct{}
+
+func (Noop) AttemptCreated()          {}
+func (Noop) SessionGenerated(string)  {}
+func (Noop) ImportRun(string, string) {}
+
+// Import kinds and outcomes for ImportRun
+const (
+	ImportBundled = "bundled"
+	ImportUpload  = "upload"
+	ImportRestore = "restore"
+
+	OutcomeSuccess   = "success"
+	OutcomeCancelled = "cancelled"
+	OutcomeError     = "error"
+)
+
+// durationBuckets are the request latency histogram bounds, in seconds
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds every metric the API exports. The zero value is not usable;
+// create one with NewRegistry.
+type Registry struct {
+	mu        sync.Mutex
+	requests  map[labels]uint64 // method, route, status_class
+	durations map[labels]*histogram
+	attempts  uint64
+	sessions  map[labels]uint64 // template
+	imports   map[labels]uint64 // kind, outcome
+	pool      *pgxpool.Pool
+}
+
+var _ Recorder = (*Registry)(nil)
+
+func NewRegistry() *Registry {
+	return &Registry{
+		requests:  make(map[labels]uint64),
+		durations: make(map[labels]*histogram),
+		sessions:  make(map[labels]uint64),
+		imports:   make(map[labels]uint64),
+	}
+}
+
+// ObservePool exports the pool's connection stats, read at scrape time
+func (m *Registry) ObservePool(pool *pgxpool.Pool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pool = pool
+}
+
+func (m *Registry) AttemptCreated() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+}
+
+func (m *Registry) SessionGenerated(templateKey string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[labels{"template", templateKey}]++
+}
+
+func (m *Registry) ImportRun(kind, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.imports[labels{"kind", kind, "outcome", outcome}]++
+}
+
+// Middleware records a count and duration for every request, labelled with
+// the matched route template (e.g. /api/v1/problems/{id}) rather than the raw
+// path so label cardinality stays bounded
+func (m *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		// The route context is filled in while routing, so read it afterwards
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.observeRequest(r.Method, route, statusClass(status), time.Since(start))
+	})
+}
+
+func (m *Registry) observeRequest(method, route, class string, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[labels{"method", method, "route", route, "status_class", class}]++
+
+	key := labels{"method", method, "route", route}
+	h, ok := m.durations[key]
+	if !ok {
+		h = newHistogram(durationBuckets)
+		m.durations[key] = h
+	}
+	h.observe(elapsed.Seconds())
+}
+
+func statusClass(status int) string {
+	switch {
+	case status >= 500:
+		return "5xx"
+	case status >= 400:
+		return "4xx"
+	case status >= 300:
+		return "3xx"
+	case status >= 200:
+		return "2xx"
+	}
+	return "1xx"
+}
+
+// labels is a flattened list of name/value pairs, usable as a map key
+type labels [6]string
+
+func (l labels) String() string {
+	var parts []string
+	for i := 0; i+1 < len(l); i += 2 {
+		if l[i] == "" {
+			break
+		}
+		parts = append(parts, l[i]+`="`+escapeLabel(l[i+1])+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// with appends one more pair, for histogram le labels
+func (l labels) with(name, value string) string {
+	s := l.String()
+	pair := name + `="` + value + `"`
+	if s == "" {
+		return "{" + pair + "}"
+	}
+	return s[:len(s)-1] + "," + pair + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+// sortedKeys orders label sets so the output is stable between scrapes
+func sortedKeys[V any](m map[labels]V) []labels {
+	keys := make([]labels, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64 // Per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.sum += v
+	h.count++
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
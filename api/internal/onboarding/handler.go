@@ -43,8 +43,8 @@ func (h *Handler) GetInitStatus(w http.ResponseWriter, r *http.Request) {
 // This endpoint is only accessible when no users exist
 func (h *Handler) CreateFirstAdmin(w http.ResponseWriter, r *http.Request) {
 	var req CreateAdminRequest
-	if err := utils.Read(r, &req); err != nil {
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &req); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
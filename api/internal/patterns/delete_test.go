@@ -0,0 +1,240 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// deleteRepo knows which patterns exist and how many problems link to each,
+// and records the writes a delete makes
+type deleteRepo struct {
+	*testutil.Querier
+	links map[uuid.UUID]int64
+}
+
+func (f *deleteRepo) GetPattern(ctx context.Context, id uuid.UUID) (repo.Pattern, error) {
+	if _, ok := f.links[id]; !ok {
+		return repo.Pattern{}, pgx.ErrNoRows
+	}
+	return repo.Pattern{ID: id}, nil
+}
+
+func (f *deleteRepo) GetPatternProblemCount(ctx context.Context, id uuid.UUID) (int64, error) {
+	return f.links[id], nil
+}
+
+func (f *deleteRepo) MovePatternLinks(ctx context.Context, arg repo.MovePatternLinksParams) error {
+	f.Record("MovePatternLinks", arg)
+	return nil
+}
+
+func (f *deleteRepo) DeleteProblemScoresForPattern(ctx context.Context, id uuid.UUID) error {
+	f.Record("DeleteProblemScoresForPattern", id)
+	return nil
+}
+
+func (f *deleteRepo) DeletePattern(ctx context.Context, id uuid.UUID) error {
+	f.Record("DeletePattern", id)
+	return nil
+}
+
+func (f *deleteRepo) RecomputeUserPatternStatsForPattern(ctx context.Context, id uuid.UUID) error {
+	f.Record("RecomputeUserPatternStatsForPattern", id)
+	return nil
+}
+
+func TestDeletePattern(t *testing.T) {
+	linked, unlinked, target, missing := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		pattern uuid.UUID
+		opts    DeletePatternOptions
+		wantErr error
+		inUse   int64
+		writes  []testutil.Call
+	}{
+		{
+			name:    "unlinked pattern needs no options",
+			pattern: unlinked,
+			writes:  []testutil.Call{{Method: "DeletePattern", Arg: unlinked}},
+		},
+		{
+			name:    "linked pattern is refused",
+			pattern: linked,
+			inUse:   3,
+		},
+		{
+			name:    "force drops the links and their cached scores",
+			pattern: linked,
+			opts:    DeletePatternOptions{Force: true},
+			writes: []testutil.Call{
+				{Method: "DeleteProblemScoresForPattern", Arg: linked},
+				{Method: "DeletePattern", Arg: linked},
+			},
+		},
+		{
+			name:    "reassign moves the links and refreshes the target",
+			pattern: linked,
+			opts:    DeletePatternOptions{ReassignTo: &target},
+			writes: []testutil.Call{
+				{Method: "MovePatternLinks", Arg: repo.MovePatternLinksParams{TargetID: target, SourceIds: []uuid.UUID{linked}}},
+				{Method: "DeletePattern", Arg: linked},
+				{Method: "RecomputeUserPatternStatsForPattern", Arg: target},
+				{Method: "DeleteProblemScoresForPattern", Arg: target},
+			},
+		},
+		{
+			name:    "reassign wins over force",
+			pattern: linked,
+			opts:    DeletePatternOptions{Force: true, ReassignTo: &target},
+			writes: []testutil.Call{
+				{Method: "MovePatternLinks", Arg: repo.MovePatternLinksParams{TargetID: target, SourceIds: []uuid.UUID{linked}}},
+				{Method: "DeletePattern", Arg: linked},
+				{Method: "RecomputeUserPatternStatsForPattern", Arg: target},
+				{Method: "DeleteProblemScoresForPattern", Arg: target},
+			},
+		},
+		{
+			name:    "reassign on an unlinked pattern just deletes it",
+			pattern: unlinked,
+			opts:    DeletePatternOptions{ReassignTo: &target},
+			writes:  []testutil.Call{{Method: "DeletePattern", Arg: unlinked}},
+		},
+		{
+			name:    "reassign to itself",
+			pattern: linked,
+			opts:    DeletePatternOptions{ReassignTo: &linked},
+			wantErr: ErrReassignToSelf,
+		},
+		{
+			name:    "reassign to a missing pattern",
+			pattern: linked,
+			opts:    DeletePatternOptions{ReassignTo: &missing},
+			wantErr: ErrPatternNotFound,
+		},
+		{
+			name:    "missing pattern",
+			pattern: missing,
+			opts:    DeletePatternOptions{Force: true},
+			wantErr: ErrPatternNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &deleteRepo{
+				Querier: testutil.NewQuerier(),
+				links:   map[uuid.UUID]int64{linked: 3, unlinked: 0, target: 1},
+			}
+			err := NewService(f, testutil.Transactor{Q: f}).DeletePattern(context.Background(), tt.pattern, tt.opts)
+
+			var inUse *PatternInUseError
+			switch {
+			case tt.inUse > 0:
+				if !errors.As(err, &inUse) || inUse.ProblemCount != tt.inUse {
+					t.Fatalf("err = %v, want PatternInUseError for %d problems", err, tt.inUse)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("DeletePattern: %v", err)
+			}
+
+			if got := f.Calls(); !reflect.DeepEqual(got, tt.writes) && (len(got) > 0 || len(tt.writes) > 0) {
+				t.Errorf("writes = %+v, want %+v", got, tt.writes)
+			}
+		})
+	}
+}
+
+// Problems linked to both patterns keep a single link to the target, and the
+// target's stats pick up the moved problems
+func TestDeletePatternAgainstDatabase(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	s := NewService(db.Queries, db.Transactor)
+	user := db.CreateUser(t, "delete@example.com")
+
+	newPattern := func(title string, problems ...repo.Problem) repo.Pattern {
+		t.Helper()
+		pattern, err := db.Queries.CreatePattern(ctx, repo.CreatePatternParams{Title: title})
+		if err != nil {
+			t.Fatalf("CreatePattern: %v", err)
+		}
+		for _, problem := range problems {
+			if err := db.Queries.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{ProblemID: problem.ID, PatternID: pattern.ID}); err != nil {
+				t.Fatalf("LinkProblemToPattern: %v", err)
+			}
+		}
+		return pattern
+	}
+	var problems []repo.Problem
+	for i := range 4 {
+		problems = append(problems, db.CreateProblem(t, fmt.Sprintf("delete problem %d", i), "medium"))
+	}
+	if _, err := db.Pool.Exec(ctx,
+		`INSERT INTO user_problem_stats (user_id, problem_id, total_attempts, avg_confidence) VALUES ($1, $2, 4, 60)`,
+		user.ID, problems[0].ID,
+	); err != nil {
+		t.Fatalf("insert problem stats: %v", err)
+	}
+
+	t.Run("refused while linked", func(t *testing.T) {
+		pattern := newPattern("delete refused", problems[0], problems[1])
+		var inUse *PatternInUseError
+		if err := s.DeletePattern(ctx, pattern.ID, DeletePatternOptions{}); !errors.As(err, &inUse) || inUse.ProblemCount != 2 {
+			t.Fatalf("err = %v, want PatternInUseError for 2 problems", err)
+		}
+		if n := db.Count(t, "patterns", "id = $1", pattern.ID); n != 1 {
+			t.Errorf("pattern deleted after a refusal")
+		}
+		if n := db.Count(t, "problem_patterns", "pattern_id = $1", pattern.ID); n != 2 {
+			t.Errorf("%d links left after a refusal, want 2", n)
+		}
+	})
+
+	t.Run("force", func(t *testing.T) {
+		pattern := newPattern("delete forced", problems[0], problems[1])
+		if err := s.DeletePattern(ctx, pattern.ID, DeletePatternOptions{Force: true}); err != nil {
+			t.Fatalf("DeletePattern: %v", err)
+		}
+		if n := db.Count(t, "patterns", "id = $1", pattern.ID); n != 0 {
+			t.Errorf("pattern still exists")
+		}
+		if n := db.Count(t, "problem_patterns", "pattern_id = $1", pattern.ID); n != 0 {
+			t.Errorf("%d links left, want 0", n)
+		}
+		if n := db.Count(t, "problems", "id = ANY($1)", []uuid.UUID{problems[0].ID, problems[1].ID}); n != 2 {
+			t.Errorf("%d problems left, want 2", n)
+		}
+	})
+
+	t.Run("reassign", func(t *testing.T) {
+		source := newPattern("delete source", problems[0], problems[1], problems[2])
+		target := newPattern("delete target", problems[1], problems[3])
+		if err := s.DeletePattern(ctx, source.ID, DeletePatternOptions{ReassignTo: &target.ID}); err != nil {
+			t.Fatalf("DeletePattern: %v", err)
+		}
+		if n := db.Count(t, "patterns", "id = $1", source.ID); n != 0 {
+			t.Errorf("source pattern still exists")
+		}
+		for _, problem := range problems {
+			if n := db.Count(t, "problem_patterns", "pattern_id = $1 AND problem_id = $2", target.ID, problem.ID); n != 1 {
+				t.Errorf("%s has %d links to the target, want 1", problem.Title, n)
+			}
+		}
+		if n := db.Count(t, "user_pattern_stats", "user_id = $1 AND pattern_id = $2 AND times_revised = 4", user.ID, target.ID); n != 1 {
+			t.Errorf("target stats not recomputed from the moved problem")
+		}
+	})
+}
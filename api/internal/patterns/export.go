@@ -0,0 +1,165 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// PatternExportVersion is bumped whenever the export document shape changes
+const PatternExportVersion = 1
+
+// ExportPatterns returns every pattern as a portable document, without user stats
+func (s *patternService) ExportPatterns(ctx context.Context) (*PatternExport, error) {
+	patterns, err := s.repo.ListPatterns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list patterns: %w", err)
+	}
+
+	items := make([]PatternExportItem, 0, len(patterns))
+	for _, pattern := range patterns {
+		items = append(items, PatternExportItem{
+			Title:       pattern.Title,
+			Description: textToPtr(pattern.Description),
+		})
+	}
+
+	return &PatternExport{
+		Version:    PatternExportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Patterns:   items,
+	}, nil
+}
+
+// ImportPatterns upserts patterns by normalized title inside one transaction.
+// Aliases aren't stored; they are only checked against existing and imported
+// titles so collisions can be reported. With DryRun nothing is written.
+func (s *patternService) ImportPatterns(ctx context.Context, body PatternImportBody) (*PatternImportResult, error) {
+	var result *PatternImportResult
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		existing, err := q.ListPatterns(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list patterns: %w", err)
+		}
+		existingByTitle := make(map[string][]repo.Pattern, len(existing))
+		for _, pattern := range existing {
+			key := normalizePatternTitle(pattern.Title)
+			existingByTitle[key] = append(existingByTitle[key], pattern)
+		}
+
+		importedTitles := make(map[string]bool, len(body.Patterns))
+		for _, item := range body.Patterns {
+			importedTitles[normalizePatternTitle(item.Title)] = true
+		}
+
+		result = &PatternImportResult{
+			DryRun:          body.DryRun,
+			Created:         []string{},
+			Updated:         []string{},
+			Conflicts:       []PatternImportConflict{},
+			AliasCollisions: []PatternAliasCollision{},
+		}
+
+		seen := make(map[string]bool, len(body.Patterns))
+		for _, item := range body.Patterns {
+			key := normalizePatternTitle(item.Title)
+			if key == "" {
+				result.Conflicts = append(result.Conflicts, PatternImportConflict{
+					Title:  item.Title,
+					Reason: "title is empty",
+				})
+				continue
+			}
+			if seen[key] {
+				result.Conflicts = append(result.Conflicts, PatternImportConflict{
+					Title:  item.Title,
+					Reason: "duplicate title in import document",
+				})
+				continue
+			}
+			seen[key] = true
+
+			for _, alias := range item.Aliases {
+				aliasKey := normalizePatternTitle(alias)
+				if aliasKey == "" || aliasKey == key {
+					continue
+				}
+				if matches := existingByTitle[aliasKey]; len(matches) > 0 {
+					result.AliasCollisions = append(result.AliasCollisions, PatternAliasCollision{
+						Title:        item.Title,
+						Alias:        alias,
+						CollidesWith: matches[0].Title,
+						Source:       "existing",
+					})
+				} else if importedTitles[aliasKey] {
+					result.AliasCollisions = append(result.AliasCollisions, PatternAliasCollision{
+						Title:        item.Title,
+						Alias:        alias,
+						CollidesWith: alias,
+						Source:       "import",
+					})
+				}
+			}
+
+			matches := existingByTitle[key]
+			switch len(matches) {
+			case 0:
+				if !body.DryRun {
+					if _, err := q.CreatePattern(ctx, repo.CreatePatternParams{
+						Title:       strings.TrimSpace(item.Title),
+						Description: pgtypeText(item.Description),
+					}); err != nil {
+						return fmt.Errorf("failed to create pattern %q: %w", item.Title, err)
+					}
+				}
+				result.Created = append(result.Created, item.Title)
+			case 1:
+				current := matches[0]
+				title := strings.TrimSpace(item.Title)
+				if current.Title == title && equalDescriptions(textToPtr(current.Description), item.Description) {
+					result.Unchanged++
+					continue
+				}
+				if !body.DryRun {
+					if _, err := q.UpdatePattern(ctx, repo.UpdatePatternParams{
+						ID:          current.ID,
+						Title:       title,
+						Description: pgtypeText(item.Description),
+					}); err != nil {
+						return fmt.Errorf("failed to update pattern %q: %w", item.Title, err)
+					}
+				}
+				result.Updated = append(result.Updated, item.Title)
+			default:
+				// Existing patterns that differ only in case can't be matched safely
+				result.Conflicts = append(result.Conflicts, PatternImportConflict{
+					Title:  item.Title,
+					Reason: fmt.Sprintf("matches %d existing patterns that differ only in case", len(matches)),
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// normalizePatternTitle lowercases a title and collapses its whitespace, so
+// "Sliding  Window" and "sliding window" are the same pattern
+func normalizePatternTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+func equalDescriptions(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
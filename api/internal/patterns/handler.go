@@ -1,13 +1,16 @@
 package patterns
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -25,15 +28,15 @@ func (h *handler) CreatePattern(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	var body CreatePatternBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	pattern, err := h.service.CreatePattern(r.Context(), body)
 	if err != nil {
-		slog.Error("Failed to create pattern", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to create pattern", "error", err)
 		utils.InternalServerError(w, "Failed to create pattern")
 		return
 	}
@@ -51,8 +54,12 @@ func (h *handler) GetPattern(w http.ResponseWriter, r *http.Request) {
 
 	pattern, err := h.service.GetPattern(r.Context(), patternID)
 	if err != nil {
-		slog.Error("Failed to get pattern", "error", err)
-		utils.NotFound(w, "Pattern not found")
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get pattern", "error", err)
+		utils.InternalServerError(w, "Failed to get pattern")
 		return
 	}
 
@@ -70,15 +77,19 @@ func (h *handler) UpdatePattern(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body UpdatePatternBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	pattern, err := h.service.UpdatePattern(r.Context(), patternID, body)
 	if err != nil {
-		slog.Error("Failed to update pattern", "error", err)
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update pattern", "error", err)
 		utils.InternalServerError(w, "Failed to update pattern")
 		return
 	}
@@ -94,18 +105,376 @@ func (h *handler) DeletePattern(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeletePattern(r.Context(), patternID); err != nil {
-		slog.Error("Failed to delete pattern", "error", err)
-		utils.InternalServerError(w, "Failed to delete pattern")
+	opts := DeletePatternOptions{Force: r.URL.Query().Get("force") == "true"}
+	if reassignStr := r.URL.Query().Get("reassign_to"); reassignStr != "" {
+		reassignTo, err := uuid.Parse(reassignStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid reassign_to pattern ID format", nil)
+			return
+		}
+		opts.ReassignTo = &reassignTo
+	}
+	if opts.Force && opts.ReassignTo != nil {
+		utils.BadRequest(w, "Use either force or reassign_to, not both", nil)
+		return
+	}
+
+	if err := h.service.DeletePattern(r.Context(), patternID, opts); err != nil {
+		var inUse *PatternInUseError
+		switch {
+		case errors.As(err, &inUse):
+			utils.Conflict(w, "Pattern is linked to problems, retry with force=true or reassign_to=<pattern_id>", map[string]int64{
+				"problem_count": inUse.ProblemCount,
+			})
+		case errors.Is(err, ErrReassignToSelf):
+			utils.BadRequest(w, "A pattern cannot be reassigned to itself", nil)
+		case errors.Is(err, ErrPatternNotFound):
+			utils.NotFound(w, "Pattern not found")
+		default:
+			logging.FromContext(r.Context()).Error("Failed to delete pattern", "error", err)
+			utils.InternalServerError(w, "Failed to delete pattern")
+		}
 		return
 	}
 
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Pattern deleted successfully"})
 }
 
+func (h *handler) GetPatternProblems(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	patternIDStr := chi.URLParam(r, "id")
+	patternID, err := uuid.Parse(patternIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return
+	}
+
+	sortBy := r.URL.Query().Get("sort_by")
+	switch sortBy {
+	case "", "confidence_asc", "confidence_desc", "score_desc", "last_attempt_asc", "last_attempt_desc":
+	default:
+		utils.BadRequest(w, "Invalid sort_by, must be one of: confidence_asc, confidence_desc, score_desc, last_attempt_asc, last_attempt_desc", nil)
+		return
+	}
+
+	// Parse pagination params
+	page := int64(1)
+	pageSize := int64(20)
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if parsedPage, err := strconv.ParseInt(pageStr, 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
+		if parsedSize, err := strconv.ParseInt(pageSizeStr, 10, 64); err == nil && parsedSize > 0 && parsedSize <= 100 {
+			pageSize = parsedSize
+		}
+	}
+
+	result, err := h.service.GetPatternProblems(r.Context(), userID, patternID, PatternProblemsParams{
+		SortBy: sortBy,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get pattern problems", "error", err)
+		utils.InternalServerError(w, "Failed to get pattern problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// defaultHistoryDays is the window returned when no from date is given
+const defaultHistoryDays = 30
+
+func (h *handler) GetPatternHistory(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	patternIDStr := chi.URLParam(r, "id")
+	patternID, err := uuid.Parse(patternIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return
+	}
+
+	to := time.Now()
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		parsed, dateOnly, err := parseHistoryTime(toStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid to, expected YYYY-MM-DD or RFC3339", nil)
+			return
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultHistoryDays)
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		parsed, _, err := parseHistoryTime(fromStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid from, expected YYYY-MM-DD or RFC3339", nil)
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		utils.BadRequest(w, "from must be before to", nil)
+		return
+	}
+
+	history, err := h.service.GetPatternHistory(r.Context(), userID, patternID, from, to)
+	if err != nil {
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get pattern history", "error", err)
+		utils.InternalServerError(w, "Failed to get pattern history")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, history)
+}
+
+// parseHistoryTime accepts a date (YYYY-MM-DD, UTC midnight) or an RFC3339 timestamp
+func parseHistoryTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func (h *handler) MergePatterns(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	patternIDStr := chi.URLParam(r, "id")
+	patternID, err := uuid.Parse(patternIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return
+	}
+
+	var body MergePatternsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	if len(body.SourceIDs) == 0 {
+		utils.BadRequest(w, "source_ids must not be empty", nil)
+		return
+	}
+	sourceIDs := make([]uuid.UUID, 0, len(body.SourceIDs))
+	for _, idStr := range body.SourceIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid pattern ID format", nil)
+			return
+		}
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	result, err := h.service.MergePatterns(r.Context(), patternID, sourceIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMergeIntoSelf):
+			utils.BadRequest(w, "A pattern cannot be merged into itself", nil)
+		case errors.Is(err, ErrPatternNotFound):
+			utils.NotFound(w, "Pattern not found")
+		default:
+			logging.FromContext(r.Context()).Error("Failed to merge patterns", "error", err)
+			utils.InternalServerError(w, "Failed to merge patterns")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) ExportPatterns(w http.ResponseWriter, r *http.Request) {
+	export, err := h.service.ExportPatterns(r.Context())
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to export patterns", "error", err)
+		utils.InternalServerError(w, "Failed to export patterns")
+		return
+	}
+
+	filename := fmt.Sprintf("reforge-patterns-%s.json", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	utils.WriteSuccess(w, http.StatusOK, export)
+}
+
+func (h *handler) ImportPatterns(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body PatternImportBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	if len(body.Patterns) == 0 {
+		utils.BadRequest(w, "patterns must not be empty", nil)
+		return
+	}
+
+	result, err := h.service.ImportPatterns(r.Context(), body)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to import patterns", "error", err)
+		utils.InternalServerError(w, "Failed to import patterns")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) GetWeakestPatterns(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	limit := 5
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 50 {
+			utils.BadRequest(w, "Invalid limit, must be between 1 and 50", nil)
+			return
+		}
+		limit = parsed
+	}
+
+	var minProblems *int
+	if minStr := r.URL.Query().Get("min_problems"); minStr != "" {
+		parsed, err := strconv.Atoi(minStr)
+		if err != nil || parsed < 0 {
+			utils.BadRequest(w, "Invalid min_problems, must be a non-negative integer", nil)
+			return
+		}
+		minProblems = &parsed
+	}
+
+	weakest, err := h.service.GetWeakestPatterns(r.Context(), userID, limit, minProblems)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get weakest patterns", "error", err)
+		utils.InternalServerError(w, "Failed to get weakest patterns")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, weakest)
+}
+
+func (h *handler) LinkProblems(w http.ResponseWriter, r *http.Request) {
+	patternID, problemIDs, ok := parsePatternProblemsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.LinkProblems(r.Context(), patternID, problemIDs)
+	if err != nil {
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to link problems to pattern", "error", err)
+		utils.InternalServerError(w, "Failed to link problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) UnlinkProblems(w http.ResponseWriter, r *http.Request) {
+	patternID, problemIDs, ok := parsePatternProblemsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.UnlinkProblems(r.Context(), patternID, problemIDs)
+	if err != nil {
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to unlink problems from pattern", "error", err)
+		utils.InternalServerError(w, "Failed to unlink problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+// parsePatternProblemsRequest reads the pattern ID and problem ID batch shared by
+// the link and unlink endpoints, writing a 400 and returning false when invalid
+func parsePatternProblemsRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, []uuid.UUID, bool) {
+	defer r.Body.Close()
+
+	patternIDStr := chi.URLParam(r, "id")
+	patternID, err := uuid.Parse(patternIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return uuid.Nil, nil, false
+	}
+
+	var body PatternProblemsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return uuid.Nil, nil, false
+	}
+
+	if len(body.ProblemIDs) == 0 {
+		utils.BadRequest(w, "problem_ids must not be empty", nil)
+		return uuid.Nil, nil, false
+	}
+	if len(body.ProblemIDs) > MaxPatternProblemsBatch {
+		utils.BadRequest(w, fmt.Sprintf("At most %d problems can be changed at once", MaxPatternProblemsBatch), nil)
+		return uuid.Nil, nil, false
+	}
+
+	problemIDs := make([]uuid.UUID, 0, len(body.ProblemIDs))
+	for _, idStr := range body.ProblemIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			utils.BadRequest(w, "Invalid problem ID format", nil)
+			return uuid.Nil, nil, false
+		}
+		problemIDs = append(problemIDs, id)
+	}
+
+	return patternID, problemIDs, true
+}
+
 func (h *handler) ListPatternsWithStats(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -113,19 +482,20 @@ func (h *handler) ListPatternsWithStats(w http.ResponseWriter, r *http.Request)
 
 	// Check if we should use search/pagination
 	query := r.URL.Query().Get("q")
+	problemQuery := r.URL.Query().Get("problem_q")
 	pageStr := r.URL.Query().Get("page")
 	pageSizeStr := r.URL.Query().Get("page_size")
 	sortBy := r.URL.Query().Get("sort_by")
 
 	// If any search/pagination params are present, use the search endpoint
-	if query != "" || pageStr != "" || pageSizeStr != "" || sortBy != "" {
-		h.searchPatternsWithStats(w, r, userID, query, pageStr, pageSizeStr, sortBy)
+	if query != "" || problemQuery != "" || pageStr != "" || pageSizeStr != "" || sortBy != "" {
+		h.searchPatternsWithStats(w, r, userID, query, problemQuery, pageStr, pageSizeStr, sortBy)
 		return
 	}
 
 	patterns, err := h.service.ListPatternsWithStats(r.Context(), userID)
 	if err != nil {
-		slog.Error("Failed to list patterns", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list patterns", "error", err)
 		utils.InternalServerError(w, "Failed to list patterns")
 		return
 	}
@@ -133,7 +503,7 @@ func (h *handler) ListPatternsWithStats(w http.ResponseWriter, r *http.Request)
 	utils.WriteSuccess(w, http.StatusOK, patterns)
 }
 
-func (h *handler) searchPatternsWithStats(w http.ResponseWriter, r *http.Request, userID uuid.UUID, query, pageStr, pageSizeStr, sortBy string) {
+func (h *handler) searchPatternsWithStats(w http.ResponseWriter, r *http.Request, userID uuid.UUID, query, problemQuery, pageStr, pageSizeStr, sortBy string) {
 	// Parse pagination params
 	page := int64(1)
 	pageSize := int64(20)
@@ -153,15 +523,16 @@ func (h *handler) searchPatternsWithStats(w http.ResponseWriter, r *http.Request
 	offset := (page - 1) * pageSize
 
 	params := SearchPatternsParams{
-		Query:  query,
-		SortBy: sortBy,
-		Limit:  pageSize,
-		Offset: offset,
+		Query:        query,
+		ProblemQuery: problemQuery,
+		SortBy:       sortBy,
+		Limit:        pageSize,
+		Offset:       offset,
 	}
 
 	result, err := h.service.SearchPatternsWithStats(r.Context(), userID, params)
 	if err != nil {
-		slog.Error("Failed to search patterns", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to search patterns", "error", err)
 		utils.InternalServerError(w, "Failed to search patterns")
 		return
 	}
@@ -0,0 +1,71 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// serveID runs one handler method for a request to /{id}
+func serveID(h http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// lookupRepo fails the pattern lookup with err
+type lookupRepo struct {
+	*testutil.Querier
+	err error
+}
+
+func (f *lookupRepo) GetPattern(ctx context.Context, id uuid.UUID) (repo.Pattern, error) {
+	return repo.Pattern{}, f.err
+}
+
+// A missing row is a 404; any other repo failure stays a 500
+func TestPatternNotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		repoErr error
+		status  int
+		code    string
+	}{
+		{"missing", uuid.NewString(), pgx.ErrNoRows, http.StatusNotFound, utils.ErrCodeNotFound},
+		{"database failure", uuid.NewString(), errors.New("connection refused"), http.StatusInternalServerError, utils.ErrCodeInternalServer},
+		{"malformed id", "42", nil, http.StatusBadRequest, utils.ErrCodeBadRequest},
+	}
+	handlers := map[string]func(*handler) http.HandlerFunc{
+		http.MethodGet:    func(h *handler) http.HandlerFunc { return h.GetPattern },
+		http.MethodDelete: func(h *handler) http.HandlerFunc { return h.DeletePattern },
+	}
+	for method, handle := range handlers {
+		for _, tt := range tests {
+			t.Run(method+" "+tt.name, func(t *testing.T) {
+				f := &lookupRepo{Querier: testutil.NewQuerier(), err: tt.repoErr}
+				rec := serveID(handle(NewHandler(NewService(f, testutil.Transactor{Q: f}))), method, tt.id)
+
+				if rec.Code != tt.status {
+					t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+				}
+				if code := testutil.ErrorResponse(t, rec).Code; code != tt.code {
+					t.Errorf("error code = %q, want %q", code, tt.code)
+				}
+			})
+		}
+	}
+}
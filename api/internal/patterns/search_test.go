@@ -0,0 +1,130 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// Sorting happens in SQL before LIMIT/OFFSET, so walking the pages of any sort
+// must give the same order as one big page, with nothing repeated or skipped
+func TestSearchPatternsWithStatsSortedPages(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "patterns@example.com")
+
+	// Ties on confidence, revisions and problem count make the tiebreakers
+	// decide page boundaries
+	specs := []struct {
+		confidence int32
+		revised    int32
+		problems   int
+	}{
+		{40, 3, 2}, {10, 1, 0}, {40, 3, 2}, {70, 5, 1},
+		{10, 2, 3}, {90, 8, 1}, {40, 1, 0}, {0, 0, 2},
+	}
+	for i, spec := range specs {
+		pattern, err := db.Queries.CreatePattern(ctx, repo.CreatePatternParams{Title: fmt.Sprintf("zz sorted %d", i)})
+		if err != nil {
+			t.Fatalf("CreatePattern: %v", err)
+		}
+		if spec.revised > 0 {
+			if _, err := db.Queries.UpsertUserPatternStats(ctx, repo.UpsertUserPatternStatsParams{
+				UserID:        user.ID,
+				PatternID:     pattern.ID,
+				AvgConfidence: pgtype.Int4{Int32: spec.confidence, Valid: true},
+				TimesRevised:  pgtype.Int4{Int32: spec.revised, Valid: true},
+			}); err != nil {
+				t.Fatalf("UpsertUserPatternStats: %v", err)
+			}
+		}
+		for j := range spec.problems {
+			problem := db.CreateProblem(t, fmt.Sprintf("zz problem %d-%d", i, j), "easy")
+			if err := db.Queries.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{
+				ProblemID: problem.ID,
+				PatternID: pattern.ID,
+			}); err != nil {
+				t.Fatalf("LinkProblemToPattern: %v", err)
+			}
+		}
+	}
+
+	s := NewService(db.Queries, db.Transactor)
+
+	tests := []struct {
+		sortBy string
+		key    func(PatternWithStats) int64 // Must not decrease down the list
+	}{
+		{"confidence_asc", func(p PatternWithStats) int64 { return avgConfidence(p) }},
+		{"confidence_desc", func(p PatternWithStats) int64 { return -avgConfidence(p) }},
+		{"times_revised_asc", func(p PatternWithStats) int64 { return timesRevised(p) }},
+		{"times_revised_desc", func(p PatternWithStats) int64 { return -timesRevised(p) }},
+		{"problem_count_asc", func(p PatternWithStats) int64 { return p.ProblemCount }},
+		{"problem_count_desc", func(p PatternWithStats) int64 { return -p.ProblemCount }},
+		{"title_desc", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			search := func(limit, offset int64) *PaginatedPatterns {
+				t.Helper()
+				page, err := s.SearchPatternsWithStats(ctx, user.ID, SearchPatternsParams{
+					Query:  "zz sorted",
+					SortBy: tt.sortBy,
+					Limit:  limit,
+					Offset: offset,
+				})
+				if err != nil {
+					t.Fatalf("SearchPatternsWithStats: %v", err)
+				}
+				return page
+			}
+
+			all := search(100, 0).Data
+			if len(all) != len(specs) {
+				t.Fatalf("got %d patterns, want %d", len(all), len(specs))
+			}
+			for i := 1; i < len(all) && tt.key != nil; i++ {
+				if tt.key(all[i]) < tt.key(all[i-1]) {
+					t.Errorf("%s sorted before %s", all[i-1].Title, all[i].Title)
+				}
+			}
+
+			var paged []PatternWithStats
+			for offset := int64(0); offset < int64(len(specs)); offset += 3 {
+				paged = append(paged, search(3, offset).Data...)
+			}
+			seen := map[string]bool{}
+			for i, p := range paged {
+				if seen[p.ID] {
+					t.Errorf("%s appears on more than one page", p.Title)
+				}
+				seen[p.ID] = true
+				if i < len(all) && p.ID != all[i].ID {
+					t.Errorf("position %d is %s when paged, %s in one page", i, p.Title, all[i].Title)
+				}
+			}
+			if len(paged) != len(all) {
+				t.Errorf("pages hold %d patterns, want %d", len(paged), len(all))
+			}
+		})
+	}
+}
+
+func avgConfidence(p PatternWithStats) int64 {
+	if p.Stats == nil {
+		return 0
+	}
+	return p.Stats.AvgConfidence
+}
+
+func timesRevised(p PatternWithStats) int64 {
+	if p.Stats == nil {
+		return 0
+	}
+	return p.Stats.TimesRevised
+}
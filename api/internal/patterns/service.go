@@ -2,32 +2,71 @@ package patterns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/utils"
 )
 
+var (
+	ErrPatternNotFound = fmt.Errorf("pattern %w", utils.ErrNotFound)
+	ErrMergeIntoSelf   = errors.New("a pattern cannot be merged into itself")
+	ErrReassignToSelf  = errors.New("a pattern cannot be reassigned to itself")
+)
+
+const (
+	// DefaultWeakestMinProblems is used when the weakest_pattern_min_problems setting is missing
+	DefaultWeakestMinProblems = 3
+
+	// weakPatternTemplateKey is the session template suggested for a weak pattern
+	weakPatternTemplateKey = "pattern_deep_dive"
+)
+
+// PatternInUseError is returned when deleting a pattern that problems still
+// link to, without force or a reassignment target
+type PatternInUseError struct {
+	ProblemCount int64
+}
+
+func (e *PatternInUseError) Error() string {
+	return fmt.Sprintf("pattern is linked to %d problem(s)", e.ProblemCount)
+}
+
 type Service interface {
 	CreatePattern(ctx context.Context, body CreatePatternBody) (*repo.Pattern, error)
 	GetPattern(ctx context.Context, patternID uuid.UUID) (*repo.Pattern, error)
 	UpdatePattern(ctx context.Context, patternID uuid.UUID, body UpdatePatternBody) (*repo.Pattern, error)
-	DeletePattern(ctx context.Context, patternID uuid.UUID) error
+	DeletePattern(ctx context.Context, patternID uuid.UUID, opts DeletePatternOptions) error
 	ListPatternsWithStats(ctx context.Context, userID uuid.UUID) ([]PatternWithStats, error)
 	SearchPatternsWithStats(ctx context.Context, userID uuid.UUID, params SearchPatternsParams) (*PaginatedPatterns, error)
 	ListPatterns(ctx context.Context) ([]repo.Pattern, error)
+	GetPatternProblems(ctx context.Context, userID uuid.UUID, patternID uuid.UUID, params PatternProblemsParams) (*PatternProblemsResponse, error)
+	GetPatternHistory(ctx context.Context, userID uuid.UUID, patternID uuid.UUID, from, to time.Time) (*PatternHistoryResponse, error)
+	MergePatterns(ctx context.Context, targetID uuid.UUID, sourceIDs []uuid.UUID) (*MergePatternsResult, error)
+	ExportPatterns(ctx context.Context) (*PatternExport, error)
+	ImportPatterns(ctx context.Context, body PatternImportBody) (*PatternImportResult, error)
+	GetWeakestPatterns(ctx context.Context, userID uuid.UUID, limit int, minProblems *int) ([]WeakPattern, error)
+	LinkProblems(ctx context.Context, patternID uuid.UUID, problemIDs []uuid.UUID) (*LinkProblemsResult, error)
+	UnlinkProblems(ctx context.Context, patternID uuid.UUID, problemIDs []uuid.UUID) (*UnlinkProblemsResult, error)
 }
 
 type patternService struct {
 	repo repo.Querier
+	tx   postgres.Transactor
 }
 
-func NewService(repo repo.Querier) Service {
+func NewService(repo repo.Querier, tx postgres.Transactor) Service {
 	return &patternService{
 		repo: repo,
+		tx:   tx,
 	}
 }
 
@@ -45,6 +84,9 @@ func (s *patternService) CreatePattern(ctx context.Context, body CreatePatternBo
 func (s *patternService) GetPattern(ctx context.Context, patternID uuid.UUID) (*repo.Pattern, error) {
 	pattern, err := s.repo.GetPattern(ctx, patternID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPatternNotFound
+		}
 		return nil, fmt.Errorf("failed to get pattern: %w", err)
 	}
 	return &pattern, nil
@@ -57,13 +99,71 @@ func (s *patternService) UpdatePattern(ctx context.Context, patternID uuid.UUID,
 		Description: pgtypeText(body.Description),
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPatternNotFound
+		}
 		return nil, fmt.Errorf("failed to update pattern: %w", err)
 	}
 	return &pattern, nil
 }
 
-func (s *patternService) DeletePattern(ctx context.Context, patternID uuid.UUID) error {
-	return s.repo.DeletePattern(ctx, patternID)
+// DeletePattern removes a pattern. Patterns with linked problems are only
+// deleted with opts.Force (links dropped) or opts.ReassignTo (links moved).
+func (s *patternService) DeletePattern(ctx context.Context, patternID uuid.UUID, opts DeletePatternOptions) error {
+	if opts.ReassignTo != nil && *opts.ReassignTo == patternID {
+		return ErrReassignToSelf
+	}
+
+	return s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		if _, err := q.GetPattern(ctx, patternID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPatternNotFound
+			}
+			return fmt.Errorf("failed to get pattern: %w", err)
+		}
+
+		problemCount, err := q.GetPatternProblemCount(ctx, patternID)
+		if err != nil {
+			return fmt.Errorf("failed to count problems: %w", err)
+		}
+
+		if problemCount > 0 {
+			switch {
+			case opts.ReassignTo != nil:
+				if _, err := q.GetPattern(ctx, *opts.ReassignTo); err != nil {
+					if errors.Is(err, pgx.ErrNoRows) {
+						return ErrPatternNotFound
+					}
+					return fmt.Errorf("failed to get reassignment pattern: %w", err)
+				}
+				if err := q.MovePatternLinks(ctx, repo.MovePatternLinksParams{
+					TargetID:  *opts.ReassignTo,
+					SourceIds: []uuid.UUID{patternID},
+				}); err != nil {
+					return fmt.Errorf("failed to move problem links: %w", err)
+				}
+			case opts.Force:
+				// Dropped links change scoring for the problems that had them
+				if err := q.DeleteProblemScoresForPattern(ctx, patternID); err != nil {
+					return fmt.Errorf("failed to invalidate cached scores: %w", err)
+				}
+			default:
+				return &PatternInUseError{ProblemCount: problemCount}
+			}
+		}
+
+		// Cascades the remaining links and user stats for this pattern
+		if err := q.DeletePattern(ctx, patternID); err != nil {
+			return fmt.Errorf("failed to delete pattern: %w", err)
+		}
+
+		if problemCount > 0 && opts.ReassignTo != nil {
+			if err := refreshPatternStats(ctx, q, *opts.ReassignTo); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (s *patternService) ListPatternsWithStats(ctx context.Context, userID uuid.UUID) ([]PatternWithStats, error) {
@@ -74,17 +174,11 @@ func (s *patternService) ListPatternsWithStats(ctx context.Context, userID uuid.
 
 	patterns := make([]PatternWithStats, 0, len(rows))
 	for _, row := range rows {
-		// Get problem count for this pattern
-		problemCount, err := s.repo.GetPatternProblemCount(ctx, row.ID)
-		if err != nil {
-			problemCount = 0
-		}
-
 		pattern := PatternWithStats{
 			ID:           row.ID.String(),
 			Title:        row.Title,
 			Description:  textToPtr(row.Description),
-			ProblemCount: problemCount,
+			ProblemCount: row.ProblemCount,
 		}
 
 		// Add stats if they exist
@@ -106,7 +200,10 @@ func (s *patternService) ListPatternsWithStats(ctx context.Context, userID uuid.
 
 func (s *patternService) SearchPatternsWithStats(ctx context.Context, userID uuid.UUID, params SearchPatternsParams) (*PaginatedPatterns, error) {
 	// Get total count
-	countRow, err := s.repo.CountSearchPatternsWithStats(ctx, params.Query)
+	countRow, err := s.repo.CountSearchPatternsWithStats(ctx, repo.CountSearchPatternsWithStatsParams{
+		SearchQuery:  params.Query,
+		ProblemQuery: params.ProblemQuery,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to count patterns: %w", err)
 	}
@@ -118,12 +215,20 @@ func (s *patternService) SearchPatternsWithStats(ctx context.Context, userID uui
 		uniqueProblemCount = 0
 	}
 
+	unpatternedProblemCount, err := s.repo.CountUnpatternedProblems(ctx)
+	if err != nil {
+		// Non-fatal - just set to 0 if query fails
+		unpatternedProblemCount = 0
+	}
+
 	// Get paginated results with stats
 	rows, err := s.repo.SearchPatternsWithStats(ctx, repo.SearchPatternsWithStatsParams{
-		UserID:      userID,
-		SearchQuery: params.Query,
-		LimitVal:    int32(params.Limit),
-		OffsetVal:   int32(params.Offset),
+		UserID:       userID,
+		SearchQuery:  params.Query,
+		ProblemQuery: params.ProblemQuery,
+		SortBy:       params.SortBy,
+		LimitVal:     int32(params.Limit),
+		OffsetVal:    int32(params.Offset),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search patterns: %w", err)
@@ -137,6 +242,10 @@ func (s *patternService) SearchPatternsWithStats(ctx context.Context, userID uui
 			Description:  textToPtr(row.Description),
 			ProblemCount: row.ProblemCount,
 		}
+		if params.ProblemQuery != "" {
+			matching := row.MatchingProblemCount
+			pattern.MatchingProblemCount = &matching
+		}
 
 		// Add stats if they exist (times_revised > 0 indicates stats exist)
 		if row.TimesRevised > 0 || row.AvgConfidence > 0 {
@@ -152,9 +261,6 @@ func (s *patternService) SearchPatternsWithStats(ctx context.Context, userID uui
 		results = append(results, pattern)
 	}
 
-	// Sort results based on params.SortBy
-	sortPatterns(results, params.SortBy)
-
 	// Calculate pagination info
 	page := params.Offset/params.Limit + 1
 	if params.Offset == 0 {
@@ -163,15 +269,427 @@ func (s *patternService) SearchPatternsWithStats(ctx context.Context, userID uui
 	totalPages := (countRow + params.Limit - 1) / params.Limit
 
 	return &PaginatedPatterns{
-		Data:               results,
-		Total:              countRow,
-		Page:               page,
-		PageSize:           params.Limit,
-		TotalPages:         totalPages,
-		UniqueProblemCount: uniqueProblemCount,
+		Data:                    results,
+		Total:                   countRow,
+		Page:                    page,
+		PageSize:                params.Limit,
+		TotalPages:              totalPages,
+		UniqueProblemCount:      uniqueProblemCount,
+		UnpatternedProblemCount: unpatternedProblemCount,
+	}, nil
+}
+
+func (s *patternService) GetPatternProblems(ctx context.Context, userID uuid.UUID, patternID uuid.UUID, params PatternProblemsParams) (*PatternProblemsResponse, error) {
+	pattern, err := s.repo.GetPattern(ctx, patternID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPatternNotFound
+		}
+		return nil, fmt.Errorf("failed to get pattern: %w", err)
+	}
+
+	problemCount, err := s.repo.GetPatternProblemCount(ctx, patternID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count problems: %w", err)
+	}
+
+	header := PatternHeader{
+		ID:           pattern.ID.String(),
+		Title:        pattern.Title,
+		Description:  textToPtr(pattern.Description),
+		ProblemCount: problemCount,
+	}
+	if stats, err := s.repo.GetUserPatternStats(ctx, repo.GetUserPatternStatsParams{
+		UserID:    userID,
+		PatternID: patternID,
+	}); err == nil {
+		if stats.AvgConfidence.Valid {
+			avg := int64(stats.AvgConfidence.Int32)
+			header.AvgConfidence = &avg
+		}
+		header.TimesRevised = int64(stats.TimesRevised.Int32)
+		header.LastRevisedAt = timestamptzToPtr(stats.LastRevisedAt)
+	}
+
+	rows, err := s.repo.GetPatternProblemsWithStats(ctx, repo.GetPatternProblemsWithStatsParams{
+		UserID:    userID,
+		PatternID: patternID,
+		SortBy:    params.SortBy,
+		LimitVal:  int32(params.Limit),
+		OffsetVal: int32(params.Offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pattern problems: %w", err)
+	}
+
+	problems := make([]PatternProblem, 0, len(rows))
+	for _, row := range rows {
+		problem := PatternProblem{
+			ID:            row.ID.String(),
+			Title:         row.Title,
+			Difficulty:    row.Difficulty.String,
+			Source:        textToPtr(row.Source),
+			URL:           textToPtr(row.Url),
+			Status:        "unsolved",
+			LastAttemptAt: timestamptzToPtr(row.LastAttemptAt),
+			TotalAttempts: row.TotalAttempts.Int32,
+		}
+		if row.Status.Valid {
+			problem.Status = row.Status.String
+		}
+		if row.Confidence.Valid {
+			problem.Confidence = &row.Confidence.Int32
+		}
+		if row.Score.Valid {
+			problem.Score = &row.Score.Float64
+		}
+		problems = append(problems, problem)
+	}
+
+	page := params.Offset/params.Limit + 1
+	totalPages := (problemCount + params.Limit - 1) / params.Limit
+
+	return &PatternProblemsResponse{
+		Pattern:    header,
+		Data:       problems,
+		Total:      problemCount,
+		Page:       page,
+		PageSize:   params.Limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetPatternHistory returns the user's confidence snapshots for a pattern in [from, to)
+func (s *patternService) GetPatternHistory(ctx context.Context, userID uuid.UUID, patternID uuid.UUID, from, to time.Time) (*PatternHistoryResponse, error) {
+	if _, err := s.repo.GetPattern(ctx, patternID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPatternNotFound
+		}
+		return nil, fmt.Errorf("failed to get pattern: %w", err)
+	}
+
+	rows, err := s.repo.GetPatternStatsHistory(ctx, repo.GetPatternStatsHistoryParams{
+		UserID:    userID,
+		PatternID: patternID,
+		FromTime:  from,
+		ToTime:    to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pattern history: %w", err)
+	}
+
+	points := make([]PatternHistoryPoint, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, PatternHistoryPoint{
+			AvgConfidence: row.AvgConfidence,
+			TimesRevised:  row.TimesRevised,
+			CapturedAt:    row.CapturedAt.Format(time.RFC3339),
+		})
+	}
+
+	return &PatternHistoryResponse{
+		PatternID: patternID.String(),
+		From:      from.Format(time.RFC3339),
+		To:        to.Format(time.RFC3339),
+		Points:    points,
 	}, nil
 }
 
+// MergePatterns folds the source patterns into the target in one transaction:
+// problem links move over, the target's user stats are rebuilt for every user,
+// and the sources are deleted
+func (s *patternService) MergePatterns(ctx context.Context, targetID uuid.UUID, sourceIDs []uuid.UUID) (*MergePatternsResult, error) {
+	// Dedupe sources and validate everything before writing
+	seen := make(map[uuid.UUID]bool, len(sourceIDs))
+	uniqueIDs := make([]uuid.UUID, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, ErrMergeIntoSelf
+		}
+		if !seen[id] {
+			seen[id] = true
+			uniqueIDs = append(uniqueIDs, id)
+		}
+	}
+
+	var (
+		target       repo.Pattern
+		problemCount int64
+	)
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		target, err = q.GetPattern(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrPatternNotFound
+			}
+			return fmt.Errorf("failed to get pattern: %w", err)
+		}
+
+		sources, err := q.GetPatternsByIDs(ctx, uniqueIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get source patterns: %w", err)
+		}
+		if len(sources) != len(uniqueIDs) {
+			return ErrPatternNotFound
+		}
+
+		if err := q.MovePatternLinks(ctx, repo.MovePatternLinksParams{
+			TargetID:  targetID,
+			SourceIds: uniqueIDs,
+		}); err != nil {
+			return fmt.Errorf("failed to move problem links: %w", err)
+		}
+
+		// Deleting the sources cascades their remaining links and user stats
+		if err := q.DeletePatternsByIDs(ctx, uniqueIDs); err != nil {
+			return fmt.Errorf("failed to delete source patterns: %w", err)
+		}
+
+		if err := q.RecomputeUserPatternStatsForPattern(ctx, targetID); err != nil {
+			return fmt.Errorf("failed to recompute pattern stats: %w", err)
+		}
+
+		// Pattern weakness feeds into scoring for every problem in the merged pattern
+		if err := q.DeleteProblemScoresForPattern(ctx, targetID); err != nil {
+			return fmt.Errorf("failed to invalidate cached scores: %w", err)
+		}
+
+		problemCount, err = q.GetPatternProblemCount(ctx, targetID)
+		if err != nil {
+			return fmt.Errorf("failed to count problems: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MergePatternsResult{
+		ID:           target.ID.String(),
+		Title:        target.Title,
+		Description:  textToPtr(target.Description),
+		ProblemCount: problemCount,
+		MergedCount:  len(uniqueIDs),
+	}, nil
+}
+
+// GetWeakestPatterns returns the user's lowest-confidence revised patterns,
+// skipping patterns with fewer than minProblems problems (the system setting
+// when nil)
+func (s *patternService) GetWeakestPatterns(ctx context.Context, userID uuid.UUID, limit int, minProblems *int) ([]WeakPattern, error) {
+	threshold := DefaultWeakestMinProblems
+	if minProblems != nil {
+		threshold = *minProblems
+	} else if value, err := s.repo.GetWeakestPatternMinProblems(ctx); err == nil {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			threshold = parsed
+		}
+	}
+
+	rows, err := s.repo.GetPatternsWithStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list patterns with stats: %w", err)
+	}
+
+	counts, err := s.repo.GetPatternProblemStatusCounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count pattern problems: %w", err)
+	}
+	countsByPattern := make(map[uuid.UUID]repo.GetPatternProblemStatusCountsRow, len(counts))
+	for _, count := range counts {
+		countsByPattern[count.PatternID] = count
+	}
+
+	weak := make([]WeakPattern, 0)
+	for _, row := range rows {
+		// Only patterns the user has revised have a confidence to rank by
+		if !row.TimesRevised.Valid || row.ProblemCount < int64(threshold) {
+			continue
+		}
+		count := countsByPattern[row.ID]
+		weak = append(weak, WeakPattern{
+			ID:            row.ID.String(),
+			Title:         row.Title,
+			AvgConfidence: int64(row.AvgConfidence.Int32),
+			TimesRevised:  int64(row.TimesRevised.Int32),
+			ProblemCount:  row.ProblemCount,
+			UnsolvedCount: count.UnsolvedCount,
+			OverdueCount:  count.OverdueCount,
+			SuggestedSession: SuggestedSession{
+				TemplateKey: weakPatternTemplateKey,
+				PatternID:   row.ID.String(),
+			},
+		})
+	}
+
+	// Lowest confidence first; among equals, the pattern with more outstanding work
+	sort.SliceStable(weak, func(i, j int) bool {
+		if weak[i].AvgConfidence != weak[j].AvgConfidence {
+			return weak[i].AvgConfidence < weak[j].AvgConfidence
+		}
+		return weak[i].UnsolvedCount+weak[i].OverdueCount > weak[j].UnsolvedCount+weak[j].OverdueCount
+	})
+
+	if len(weak) > limit {
+		weak = weak[:limit]
+	}
+	return weak, nil
+}
+
+// LinkProblems attaches problems to a pattern in one transaction, counting
+// problems that were already linked or don't exist
+func (s *patternService) LinkProblems(ctx context.Context, patternID uuid.UUID, problemIDs []uuid.UUID) (*LinkProblemsResult, error) {
+	var result *LinkProblemsResult
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		existing, linked, err := resolvePatternProblems(ctx, q, patternID, problemIDs)
+		if err != nil {
+			return err
+		}
+
+		result = &LinkProblemsResult{NotFoundIDs: []string{}}
+		for _, problemID := range dedupeUUIDs(problemIDs) {
+			switch {
+			case !existing[problemID]:
+				result.NotFound++
+				result.NotFoundIDs = append(result.NotFoundIDs, problemID.String())
+			case linked[problemID]:
+				result.AlreadyLinked++
+			default:
+				if err := q.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{
+					ProblemID: problemID,
+					PatternID: patternID,
+				}); err != nil {
+					return fmt.Errorf("failed to link problem: %w", err)
+				}
+				result.Linked++
+			}
+		}
+
+		if result.Linked > 0 {
+			if err := refreshPatternStats(ctx, q, patternID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UnlinkProblems detaches problems from a pattern in one transaction and
+// refreshes the pattern stats of affected users
+func (s *patternService) UnlinkProblems(ctx context.Context, patternID uuid.UUID, problemIDs []uuid.UUID) (*UnlinkProblemsResult, error) {
+	var result *UnlinkProblemsResult
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		existing, linked, err := resolvePatternProblems(ctx, q, patternID, problemIDs)
+		if err != nil {
+			return err
+		}
+
+		// Cached scores must go while the links still identify the affected problems
+		if len(linked) > 0 {
+			if err := q.DeleteProblemScoresForPattern(ctx, patternID); err != nil {
+				return fmt.Errorf("failed to invalidate cached scores: %w", err)
+			}
+		}
+
+		result = &UnlinkProblemsResult{NotFoundIDs: []string{}}
+		for _, problemID := range dedupeUUIDs(problemIDs) {
+			switch {
+			case !existing[problemID]:
+				result.NotFound++
+				result.NotFoundIDs = append(result.NotFoundIDs, problemID.String())
+			case !linked[problemID]:
+				result.NotLinked++
+			default:
+				if err := q.UnlinkProblemFromPattern(ctx, repo.UnlinkProblemFromPatternParams{
+					ProblemID: problemID,
+					PatternID: patternID,
+				}); err != nil {
+					return fmt.Errorf("failed to unlink problem: %w", err)
+				}
+				result.Unlinked++
+			}
+		}
+
+		if result.Unlinked > 0 {
+			if err := q.RecomputeUserPatternStatsForPattern(ctx, patternID); err != nil {
+				return fmt.Errorf("failed to recompute pattern stats: %w", err)
+			}
+			if err := q.DeleteOrphanedUserPatternStats(ctx, patternID); err != nil {
+				return fmt.Errorf("failed to clear stale pattern stats: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// resolvePatternProblems checks the pattern exists and reports which of the
+// given problems exist and which are already linked to it
+func resolvePatternProblems(ctx context.Context, q repo.Querier, patternID uuid.UUID, problemIDs []uuid.UUID) (existing, linked map[uuid.UUID]bool, err error) {
+	if _, err := q.GetPattern(ctx, patternID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrPatternNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get pattern: %w", err)
+	}
+
+	existingIDs, err := q.GetExistingProblemIDs(ctx, problemIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up problems: %w", err)
+	}
+	linkedIDs, err := q.GetLinkedProblemIDsForPattern(ctx, repo.GetLinkedProblemIDsForPatternParams{
+		PatternID:  patternID,
+		ProblemIds: problemIDs,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to look up pattern links: %w", err)
+	}
+
+	existing = make(map[uuid.UUID]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+	linked = make(map[uuid.UUID]bool, len(linkedIDs))
+	for _, id := range linkedIDs {
+		linked[id] = true
+	}
+	return existing, linked, nil
+}
+
+// refreshPatternStats rebuilds user stats for a pattern and drops cached
+// scores for its problems, since pattern weakness feeds into scoring
+func refreshPatternStats(ctx context.Context, q repo.Querier, patternID uuid.UUID) error {
+	if err := q.RecomputeUserPatternStatsForPattern(ctx, patternID); err != nil {
+		return fmt.Errorf("failed to recompute pattern stats: %w", err)
+	}
+	if err := q.DeleteProblemScoresForPattern(ctx, patternID); err != nil {
+		return fmt.Errorf("failed to invalidate cached scores: %w", err)
+	}
+	return nil
+}
+
+func dedupeUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
 func (s *patternService) ListPatterns(ctx context.Context) ([]repo.Pattern, error) {
 	patterns, err := s.repo.ListPatterns(ctx)
 	if err != nil {
@@ -203,7 +721,8 @@ func timestamptzToPtr(ts pgtype.Timestamptz) *string {
 	return &s
 }
 
-// sortPatterns sorts patterns based on the provided sort_by parameter
+// sortPatterns orders a full in-memory pattern list. Paginated searches sort in
+// SQL instead, since sorting a page after LIMIT/OFFSET gives wrong results.
 func sortPatterns(patterns []PatternWithStats, sortBy string) {
 	switch sortBy {
 	case "confidence_asc":
@@ -1,5 +1,7 @@
 package patterns
 
+import "github.com/google/uuid"
+
 type CreatePatternBody struct {
 	Title       string  `json:"title"       validate:"required"`
 	Description *string `json:"description" validate:"omitempty"`
@@ -10,12 +12,54 @@ type UpdatePatternBody struct {
 	Description *string `json:"description" validate:"omitempty"`
 }
 
+// DeletePatternOptions controls what happens to a pattern's problem links on delete
+type DeletePatternOptions struct {
+	Force      bool
+	ReassignTo *uuid.UUID
+}
+
+type MergePatternsBody struct {
+	SourceIDs []string `json:"source_ids" validate:"required,min=1,dive,uuid"`
+}
+
+// MaxPatternProblemsBatch caps how many problems one link/unlink request may touch
+const MaxPatternProblemsBatch = 500
+
+type PatternProblemsBody struct {
+	ProblemIDs []string `json:"problem_ids" validate:"required,min=1,max=500,dive,uuid"`
+}
+
+type LinkProblemsResult struct {
+	Linked        int      `json:"linked"`
+	AlreadyLinked int      `json:"already_linked"`
+	NotFound      int      `json:"not_found"`
+	NotFoundIDs   []string `json:"not_found_ids"`
+}
+
+type UnlinkProblemsResult struct {
+	Unlinked    int      `json:"unlinked"`
+	NotLinked   int      `json:"not_linked"`
+	NotFound    int      `json:"not_found"`
+	NotFoundIDs []string `json:"not_found_ids"`
+}
+
+type MergePatternsResult struct {
+	ID           string  `json:"id"`
+	Title        string  `json:"title"`
+	Description  *string `json:"description"`
+	ProblemCount int64   `json:"problemCount"`
+	MergedCount  int     `json:"merged_count"`
+}
+
 type PatternWithStats struct {
 	ID           string            `json:"id"`
 	Title        string            `json:"title"`
 	Description  *string           `json:"description"`
 	ProblemCount int64             `json:"problemCount"`
 	Stats        *PatternUserStats `json:"stats"`
+
+	// MatchingProblemCount is set when searching by problem title
+	MatchingProblemCount *int64 `json:"matching_problem_count,omitempty"`
 }
 
 type PatternUserStats struct {
@@ -28,17 +72,131 @@ type PatternUserStats struct {
 }
 
 type SearchPatternsParams struct {
-	Query  string
+	Query        string
+	ProblemQuery string // Matches titles of problems linked to the pattern
+	SortBy       string
+	Limit        int64
+	Offset       int64
+}
+
+type PaginatedPatterns struct {
+	Data                    []PatternWithStats `json:"data"`
+	Total                   int64              `json:"total"`
+	Page                    int64              `json:"page"`
+	PageSize                int64              `json:"page_size"`
+	TotalPages              int64              `json:"total_pages"`
+	UniqueProblemCount      int64              `json:"unique_problem_count"`
+	UnpatternedProblemCount int64              `json:"unpatterned_problem_count"`
+}
+
+// PatternProblemsResponse is the pattern page: a stats header plus a page of its problems
+type PatternProblemsResponse struct {
+	Pattern    PatternHeader    `json:"pattern"`
+	Data       []PatternProblem `json:"data"`
+	Total      int64            `json:"total"`
+	Page       int64            `json:"page"`
+	PageSize   int64            `json:"page_size"`
+	TotalPages int64            `json:"total_pages"`
+}
+
+type PatternHeader struct {
+	ID            string  `json:"id"`
+	Title         string  `json:"title"`
+	Description   *string `json:"description"`
+	ProblemCount  int64   `json:"problemCount"`
+	AvgConfidence *int64  `json:"avg_confidence"`
+	TimesRevised  int64   `json:"times_revised"`
+	LastRevisedAt *string `json:"last_revised_at"`
+}
+
+type PatternProblem struct {
+	ID            string   `json:"id"`
+	Title         string   `json:"title"`
+	Difficulty    string   `json:"difficulty"`
+	Source        *string  `json:"source"`
+	URL           *string  `json:"url"`
+	Status        string   `json:"status"`
+	Confidence    *int32   `json:"confidence"`
+	LastAttemptAt *string  `json:"last_attempt_at"`
+	TotalAttempts int32    `json:"total_attempts"`
+	Score         *float64 `json:"score"`
+}
+
+type PatternProblemsParams struct {
 	SortBy string
 	Limit  int64
 	Offset int64
 }
 
-type PaginatedPatterns struct {
-	Data               []PatternWithStats `json:"data"`
-	Total              int64              `json:"total"`
-	Page               int64              `json:"page"`
-	PageSize           int64              `json:"page_size"`
-	TotalPages         int64              `json:"total_pages"`
-	UniqueProblemCount int64              `json:"unique_problem_count"`
+type PatternHistoryPoint struct {
+	AvgConfidence int32  `json:"avg_confidence"`
+	TimesRevised  int32  `json:"times_revised"`
+	CapturedAt    string `json:"captured_at"`
+}
+
+type PatternHistoryResponse struct {
+	PatternID string                `json:"pattern_id"`
+	From      string                `json:"from"`
+	To        string                `json:"to"`
+	Points    []PatternHistoryPoint `json:"points"`
+}
+
+// WeakPattern is a low-confidence pattern recommended for study
+type WeakPattern struct {
+	ID               string           `json:"id"`
+	Title            string           `json:"title"`
+	AvgConfidence    int64            `json:"avg_confidence"`
+	TimesRevised     int64            `json:"times_revised"`
+	ProblemCount     int64            `json:"problemCount"`
+	UnsolvedCount    int64            `json:"unsolved_count"`
+	OverdueCount     int64            `json:"overdue_count"`
+	SuggestedSession SuggestedSession `json:"suggested_session"`
+}
+
+// SuggestedSession is the generate-session request that would study a pattern
+type SuggestedSession struct {
+	TemplateKey string `json:"template_key"`
+	PatternID   string `json:"pattern_id"`
+}
+
+// PatternExport is the portable pattern taxonomy document
+type PatternExport struct {
+	Version    int                 `json:"version"`
+	ExportedAt string              `json:"exported_at"`
+	Patterns   []PatternExportItem `json:"patterns"`
+}
+
+type PatternExportItem struct {
+	Title       string   `json:"title"             validate:"required"`
+	Description *string  `json:"description"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+type PatternImportBody struct {
+	Patterns []PatternExportItem `json:"patterns" validate:"required,min=1,dive"`
+	DryRun   bool                `json:"dry_run"`
+}
+
+type PatternImportResult struct {
+	DryRun          bool                    `json:"dry_run"`
+	Created         []string                `json:"created"`
+	Updated         []string                `json:"updated"`
+	Unchanged       int                     `json:"unchanged"`
+	Conflicts       []PatternImportConflict `json:"conflicts"`
+	AliasCollisions []PatternAliasCollision `json:"alias_collisions"`
+}
+
+// PatternImportConflict is an import entry that was skipped
+type PatternImportConflict struct {
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// PatternAliasCollision is an alias that matches an existing pattern title
+// ("existing") or another title in the same document ("import")
+type PatternAliasCollision struct {
+	Title        string `json:"title"`
+	Alias        string `json:"alias"`
+	CollidesWith string `json:"collides_with"`
+	Source       string `json:"source"`
 }
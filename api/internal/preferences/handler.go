@@ -0,0 +1,70 @@
+package preferences
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+// GetPreferences - GET /api/v1/users/me/preferences
+func (h *handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	prefs, err := h.service.GetPreferences(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get preferences", "error", err)
+		utils.InternalServerError(w, "Failed to get preferences")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, prefs)
+}
+
+// UpdatePreferences - PUT /api/v1/users/me/preferences
+func (h *handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body UpdatePreferencesBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTimezone), errors.Is(err, ErrInvalidWeekStart), errors.Is(err, ErrInvalidScoringEmphasis):
+			utils.BadRequest(w, err.Error(), nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to update preferences", "error", err)
+			utils.InternalServerError(w, "Failed to update preferences")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, prefs)
+}
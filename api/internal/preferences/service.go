@@ -0,0 +1,189 @@
+package preferences
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/settings"
+)
+
+type Service interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*PreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, body UpdatePreferencesBody) (*PreferencesResponse, error)
+	GetLocale(ctx context.Context, userID uuid.UUID) (Locale, error)
+}
+
+type preferencesService struct {
+	repo repo.Querier
+}
+
+func NewService(repo repo.Querier) Service {
+	return &preferencesService{
+		repo: repo,
+	}
+}
+
+func (s *preferencesService) GetPreferences(ctx context.Context, userID uuid.UUID) (*PreferencesResponse, error) {
+	prefs, err := s.repo.GetUserPreferences(ctx, userID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	// No row yet means everything is at its default
+	resp := toPreferencesResponse(prefs)
+	return &resp, nil
+}
+
+func (s *preferencesService) UpdatePreferences(ctx context.Context, userID uuid.UUID, body UpdatePreferencesBody) (*PreferencesResponse, error) {
+	if body.Timezone != nil {
+		// LoadLocation also accepts "" and "Local", which mean the server's zone
+		if *body.Timezone == "" || *body.Timezone == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+		if _, err := time.LoadLocation(*body.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+	}
+	if body.WeekStart != nil {
+		if _, err := ParseWeekStart(*body.WeekStart); err != nil {
+			return nil, err
+		}
+	}
+	if body.ScoringEmphasis != nil {
+		if _, ok := settings.GetPreset(*body.ScoringEmphasis); !ok {
+			return nil, ErrInvalidScoringEmphasis
+		}
+	}
+
+	prefs, err := s.repo.UpsertUserPreferences(ctx, repo.UpsertUserPreferencesParams{
+		UserID:                    userID,
+		Timezone:                  strPtrToPgText(body.Timezone),
+		WeekStart:                 strPtrToPgText(body.WeekStart),
+		DefaultSessionDurationMin: int32PtrToPgInt4(body.DefaultSessionDurationMin),
+		ScoringEmphasis:           strPtrToPgText(body.ScoringEmphasis),
+		AutoCompleteSession:       boolPtrToPgBool(body.AutoCompleteSession),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update preferences: %w", err)
+	}
+
+	resp := toPreferencesResponse(prefs)
+	return &resp, nil
+}
+
+// GetLocale resolves the user's timezone and week start, falling back to UTC
+// and Monday
+func (s *preferencesService) GetLocale(ctx context.Context, userID uuid.UUID) (Locale, error) {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return Locale{}, err
+	}
+
+	loc, err := time.LoadLocation(prefs.Timezone)
+	if err != nil {
+		// A zone dropped from the tz database since it was saved
+		loc = time.UTC
+	}
+	weekStart, err := ParseWeekStart(prefs.WeekStart)
+	if err != nil {
+		weekStart = time.Monday
+	}
+	return Locale{Location: loc, WeekStart: weekStart}, nil
+}
+
+// ParseWeekStart converts a week start name into a weekday
+func ParseWeekStart(value string) (time.Weekday, error) {
+	switch value {
+	case "monday":
+		return time.Monday, nil
+	case "sunday":
+		return time.Sunday, nil
+	}
+	return 0, ErrInvalidWeekStart
+}
+
+func toPreferencesResponse(prefs repo.UserPreference) PreferencesResponse {
+	resp := PreferencesResponse{
+		Timezone:                  DefaultTimezone,
+		WeekStart:                 DefaultWeekStart,
+		DefaultSessionDurationMin: DefaultSessionDurationMin,
+		ScoringEmphasis:           DefaultScoringEmphasis,
+		AutoCompleteSession:       DefaultAutoCompleteSession,
+	}
+	if prefs.Timezone.Valid {
+		resp.Timezone = prefs.Timezone.String
+	}
+	if prefs.WeekStart.Valid {
+		resp.WeekStart = prefs.WeekStart.String
+	}
+	if prefs.DefaultSessionDurationMin.Valid {
+		resp.DefaultSessionDurationMin = prefs.DefaultSessionDurationMin.Int32
+	}
+	if prefs.ScoringEmphasis.Valid {
+		resp.ScoringEmphasis = prefs.ScoringEmphasis.String
+	}
+	if prefs.AutoCompleteSession.Valid {
+		resp.AutoCompleteSession = prefs.AutoCompleteSession.Bool
+	}
+	return resp
+}
+
+func strPtrToPgText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+func int32PtrToPgInt4(i *int32) pgtype.Int4 {
+	if i == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *i, Valid: true}
+}
+
+func boolPtrToPgBool(b *bool) pgtype.Bool {
+	if b == nil {
+		return pgtype.Bool{}
+	}
+	return pgtype.Bool{Bool: *b, Valid: true}
+}
+
+// RequestLocale resolves the locale for a request: the tz and week_start
+// query parameters win over the user's saved preferences. Invalid parameters
+// fail with ErrInvalidTimezone or ErrInvalidWeekStart.
+func RequestLocale(r *http.Request, service Service, userID uuid.UUID) (Locale, error) {
+	locale, err := service.GetLocale(r.Context(), userID)
+	if err != nil {
+		return Locale{}, err
+	}
+
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return Locale{}, ErrInvalidTimezone
+		}
+		locale.Location = loc
+	}
+	if weekStart := r.URL.Query().Get("week_start"); weekStart != "" {
+		day, err := ParseWeekStart(strings.ToLower(weekStart))
+		if err != nil {
+			return Locale{}, err
+		}
+		locale.WeekStart = day
+	}
+	return locale, nil
+}
+
+// IsInvalidLocale reports whether err came from a bad tz or week_start
+// parameter rather than from loading the preferences
+func IsInvalidLocale(err error) bool {
+	return errors.Is(err, ErrInvalidTimezone) || errors.Is(err, ErrInvalidWeekStart)
+}
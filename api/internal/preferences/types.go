@@ -0,0 +1,50 @@
+package preferences
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidTimezone        = errors.New("timezone must be an IANA name such as Europe/Berlin")
+	ErrInvalidWeekStart       = errors.New("week start must be monday or sunday")
+	ErrInvalidScoringEmphasis = errors.New("scoring emphasis must be a scoring preset key")
+)
+
+// Defaults for unset preferences
+const (
+	DefaultTimezone            = "UTC"
+	DefaultWeekStart           = "monday"
+	DefaultSessionDurationMin  = 90
+	DefaultScoringEmphasis     = "balanced"
+	DefaultAutoCompleteSession = false
+)
+
+// Request types
+
+// UpdatePreferencesBody changes preferences; omitted fields are kept
+type UpdatePreferencesBody struct {
+	Timezone                  *string `json:"timezone"                     validate:"omitempty,min=1"`
+	WeekStart                 *string `json:"week_start"                   validate:"omitempty,oneof=monday sunday"`
+	DefaultSessionDurationMin *int32  `json:"default_session_duration_min" validate:"omitempty,gte=1,lte=600"`
+	ScoringEmphasis           *string `json:"scoring_emphasis"             validate:"omitempty,min=1"`
+	AutoCompleteSession       *bool   `json:"auto_complete_session"`
+}
+
+// Response types
+
+// PreferencesResponse always holds the effective values, defaults included
+type PreferencesResponse struct {
+	Timezone                  string `json:"timezone"`
+	WeekStart                 string `json:"week_start"`
+	DefaultSessionDurationMin int32  `json:"default_session_duration_min"`
+	ScoringEmphasis           string `json:"scoring_emphasis"`
+	AutoCompleteSession       bool   `json:"auto_complete_session"`
+}
+
+// Locale is what date computations need from the preferences: where a day
+// starts and ends, and which day a week starts on
+type Locale struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
@@ -0,0 +1,163 @@
+package problems
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// MaxAnkiCards caps one Anki export; bigger decks are fetched page by page
+const MaxAnkiCards = 1000
+
+// ExportAnkiDeck builds Anki cards for the user's problems in a due window:
+// "overdue" (due before now), "week" (due within the next 7 days) or "all"
+// (every tracked problem)
+func (s *problemService) ExportAnkiDeck(ctx context.Context, userID uuid.UUID, window string, limit, offset int32) (*AnkiDeck, error) {
+	now := time.Now()
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+
+	var cutoff pgtype.Timestamptz
+	switch window {
+	case "overdue":
+		cutoff = pgtype.Timestamptz{Time: now, Valid: true}
+	case "week":
+		cutoff = pgtype.Timestamptz{Time: endOfToday.AddDate(0, 0, 6), Valid: true}
+	case "all":
+	default:
+		return nil, fmt.Errorf("invalid window: %s", window)
+	}
+
+	total, err := s.repo.CountAnkiDeckProblems(ctx, repo.CountAnkiDeckProblemsParams{
+		UserID:    userID,
+		DueBefore: cutoff,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count problems: %w", err)
+	}
+
+	rows, err := s.repo.GetAnkiDeckProblems(ctx, repo.GetAnkiDeckProblemsParams{
+		UserID:    userID,
+		DueBefore: cutoff,
+		LimitVal:  limit,
+		OffsetVal: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problems: %w", err)
+	}
+
+	problemIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		problemIDs = append(problemIDs, row.ID)
+	}
+	patternsByProblem := s.getPatternsForProblems(ctx, problemIDs)
+
+	cards := make([]AnkiCard, 0, len(rows))
+	for _, row := range rows {
+		patternTitles := make([]string, 0, len(patternsByProblem[row.ID]))
+		for _, pattern := range patternsByProblem[row.ID] {
+			patternTitles = append(patternTitles, pattern.Title)
+		}
+		cards = append(cards, buildAnkiCard(row, patternTitles))
+	}
+
+	return &AnkiDeck{
+		Window:   window,
+		Cards:    cards,
+		Total:    total,
+		Page:     offset/limit + 1,
+		PageSize: limit,
+	}, nil
+}
+
+// buildAnkiCard renders a problem as an HTML card. Everything user-written is
+// escaped, and newlines become <br> so each note stays on one line.
+func buildAnkiCard(row repo.GetAnkiDeckProblemsRow, patternTitles []string) AnkiCard {
+	difficulty := pgtypeTextToStr(row.Difficulty, "medium")
+
+	front := "<b>" + ankiHTML(row.Title) + "</b>"
+	if row.Url.Valid && row.Url.String != "" {
+		url := html.EscapeString(row.Url.String)
+		front += fmt.Sprintf(`<br><a href="%s">%s</a>`, url, url)
+	}
+	if len(patternTitles) > 0 {
+		front += "<br>Patterns: " + ankiHTML(strings.Join(patternTitles, ", "))
+	}
+
+	var back []string
+	if row.Notes.Valid && strings.TrimSpace(row.Notes.String) != "" {
+		back = append(back, ankiHTML(row.Notes.String))
+	}
+	if row.LastPerformedAt.Valid {
+		summary := []string{"Last attempt " + row.LastPerformedAt.Time.Format("2006-01-02")}
+		if row.LastOutcome.Valid {
+			summary = append(summary, row.LastOutcome.String)
+		}
+		if row.LastConfidence.Valid {
+			summary = append(summary, fmt.Sprintf("confidence %d", row.LastConfidence.Int32))
+		}
+		if row.LastDurationSeconds.Valid {
+			summary = append(summary, (time.Duration(row.LastDurationSeconds.Int32) * time.Second).String())
+		}
+		back = append(back, "<i>"+html.EscapeString(strings.Join(summary, " · "))+"</i>")
+		if row.LastAttemptNotes.Valid && strings.TrimSpace(row.LastAttemptNotes.String) != "" {
+			back = append(back, ankiHTML(row.LastAttemptNotes.String))
+		}
+	}
+	if len(back) == 0 {
+		back = append(back, "<i>No notes yet</i>")
+	}
+
+	tags := []string{"reforge", "difficulty::" + ankiTag(difficulty)}
+	for _, title := range patternTitles {
+		tags = append(tags, "pattern::"+ankiTag(title))
+	}
+
+	return AnkiCard{
+		Front: front,
+		Back:  strings.Join(back, "<br><br>"),
+		Tags:  tags,
+	}
+}
+
+// ankiHTML escapes text for an HTML field and flattens its line breaks
+func ankiHTML(text string) string {
+	escaped := html.EscapeString(strings.TrimSpace(text))
+	escaped = strings.ReplaceAll(escaped, "\r\n", "\n")
+	escaped = strings.ReplaceAll(escaped, "\r", "\n")
+	escaped = strings.ReplaceAll(escaped, "\t", " ")
+	return strings.ReplaceAll(escaped, "\n", "<br>")
+}
+
+// ankiTag makes a tag from a title; Anki tags are space separated
+func ankiTag(title string) string {
+	return strings.Join(strings.Fields(title), "_")
+}
+
+// writeAnkiDeck writes cards in Anki's plain text import format. The header
+// lines pin the separator and column roles so Anki doesn't guess them from
+// the content, and fields are quoted whenever they contain tabs or quotes.
+func writeAnkiDeck(w io.Writer, cards []AnkiCard) error {
+	header := "#separator:tab\n#html:true\n#columns:Front\tBack\tTags\n#tags column:3\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to write deck header: %w", err)
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+	for _, card := range cards {
+		if err := writer.Write([]string{card.Front, card.Back, strings.Join(card.Tags, " ")}); err != nil {
+			return fmt.Errorf("failed to write card: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
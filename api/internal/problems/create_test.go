@@ -0,0 +1,55 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+var errUpsertFailed = errors.New("upsert failed")
+
+// failingStatsUpsert fails every problem stats upsert
+type failingStatsUpsert struct {
+	repo.Querier
+}
+
+func (f failingStatsUpsert) UpsertUserProblemStats(ctx context.Context, arg repo.UpsertUserProblemStatsParams) (repo.UserProblemStat, error) {
+	return repo.UserProblemStat{}, errUpsertFailed
+}
+
+func TestCreateProblemRollsBackWhenStatsFail(t *testing.T) {
+	db := testutil.NewDB(t)
+	ctx := context.Background()
+	user := db.CreateUser(t, "problem@example.com")
+	pattern, err := db.Queries.CreatePattern(ctx, repo.CreatePatternParams{Title: "Arrays"})
+	if err != nil {
+		t.Fatalf("CreatePattern: %v", err)
+	}
+
+	tx := testutil.WrapTransactor{
+		Inner: db.Transactor,
+		Wrap:  func(q repo.Querier) repo.Querier { return failingStatsUpsert{q} },
+	}
+	s := NewService(db.Queries, tx, scoring.NewService(db.Queries), nil, webhooks.Noop{})
+
+	_, err = s.CreateProblem(ctx, user.ID, CreateProblemBody{
+		Title:      "Half Created",
+		Difficulty: "easy",
+		PatternIDs: []string{pattern.ID.String()},
+	})
+	if !errors.Is(err, errUpsertFailed) {
+		t.Fatalf("CreateProblem err = %v, want the upsert failure", err)
+	}
+
+	if n := db.Count(t, "problems", "title = $1", "Half Created"); n != 0 {
+		t.Errorf("%d problem rows left after the failed stats upsert, want 0", n)
+	}
+	if n := db.Count(t, "problem_patterns", "pattern_id = $1", pattern.ID); n != 0 {
+		t.Errorf("%d pattern links left after the failed stats upsert, want 0", n)
+	}
+}
@@ -0,0 +1,79 @@
+package problems
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// exportHeaders matches the columns the CSV importer expects
+var exportHeaders = []string{"title", "url", "source", "difficulty", "patterns", "companies"}
+
+// extendedExportHeaders are appended in extended mode; the importer ignores them
+var extendedExportHeaders = []string{"status", "confidence", "total_attempts"}
+
+// ExportProblemsCSV writes every problem in the importer's CSV format. Pattern
+// titles and company names are each comma-joined into a single quoted column,
+// so names that themselves contain commas won't survive a round trip.
+func (s *problemService) ExportProblemsCSV(ctx context.Context, userID uuid.UUID, w io.Writer, extended bool) error {
+	rows, err := s.repo.GetProblemsForUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list problems: %w", err)
+	}
+
+	problemIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		problemIDs = append(problemIDs, row.ID)
+	}
+	patternsByProblem := s.getPatternsForProblems(ctx, problemIDs)
+	companiesByProblem := s.getCompaniesForProblems(ctx, problemIDs)
+
+	writer := csv.NewWriter(w)
+
+	headers := exportHeaders
+	if extended {
+		headers = append(append([]string{}, exportHeaders...), extendedExportHeaders...)
+	}
+	if err := writer.Write(headers); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, row := range rows {
+		patternTitles := make([]string, 0, len(patternsByProblem[row.ID]))
+		for _, pattern := range patternsByProblem[row.ID] {
+			patternTitles = append(patternTitles, pattern.Title)
+		}
+		companyNames := make([]string, 0, len(companiesByProblem[row.ID]))
+		for _, company := range companiesByProblem[row.ID] {
+			companyNames = append(companyNames, company.Name)
+		}
+
+		record := []string{
+			row.Title,
+			row.Url.String,
+			row.Source.String,
+			pgtypeTextToStr(row.Difficulty, "medium"),
+			strings.Join(patternTitles, ","),
+			strings.Join(companyNames, ","),
+		}
+		if extended {
+			record = append(record,
+				pgtypeTextToStr(row.Status, "unsolved"),
+				strconv.Itoa(int(row.Confidence.Int32)),
+				strconv.Itoa(int(row.TotalAttempts.Int32)),
+			)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
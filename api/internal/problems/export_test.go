@@ -0,0 +1,125 @@
+package problems
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	dataimport "github.com/vasujain275/reforge/internal/import"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// exportRepo serves a library of problems with their pattern and company tags
+type exportRepo struct {
+	*testutil.Querier
+	rows      []repo.GetProblemsForUserRow
+	patterns  map[uuid.UUID][]string
+	companies map[uuid.UUID][]string
+}
+
+func (f *exportRepo) GetProblemsForUser(ctx context.Context, userID uuid.UUID) ([]repo.GetProblemsForUserRow, error) {
+	return f.rows, nil
+}
+
+func (f *exportRepo) GetPatternsForProblems(ctx context.Context, problemIDs []uuid.UUID) ([]repo.GetPatternsForProblemsRow, error) {
+	var rows []repo.GetPatternsForProblemsRow
+	for _, id := range problemIDs {
+		for _, title := range f.patterns[id] {
+			rows = append(rows, repo.GetPatternsForProblemsRow{ProblemID: id, ID: uuid.New(), Title: title})
+		}
+	}
+	return rows, nil
+}
+
+func (f *exportRepo) GetCompaniesForProblems(ctx context.Context, problemIDs []uuid.UUID) ([]repo.GetCompaniesForProblemsRow, error) {
+	var rows []repo.GetCompaniesForProblemsRow
+	for _, id := range problemIDs {
+		for _, name := range f.companies[id] {
+			rows = append(rows, repo.GetCompaniesForProblemsRow{ProblemID: id, ID: uuid.New(), Name: name})
+		}
+	}
+	return rows, nil
+}
+
+func text(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+func TestExportProblemsCSVRoundTrip(t *testing.T) {
+	want := []dataimport.ParsedProblem{
+		{
+			Title:      "Two Sum",
+			URL:        "https://leetcode.com/problems/two-sum/",
+			Source:     "LeetCode",
+			Difficulty: "easy",
+			Patterns:   []string{"Arrays", "Hash Map"},
+			Companies:  []string{"Amazon", "Google"},
+		},
+		{
+			Title:      `Merge "K" Sorted Lists, Again`,
+			URL:        "https://leetcode.com/problems/merge-k-sorted-lists/?tab=description",
+			Source:     "LeetCode",
+			Difficulty: "hard",
+			Patterns:   []string{"Heap"},
+			Companies:  []string{"Meta"},
+		},
+		{
+			Title:      "Untagged",
+			Difficulty: "medium",
+			Patterns:   []string{},
+			Companies:  []string{},
+		},
+	}
+
+	f := &exportRepo{
+		Querier:   testutil.NewQuerier(),
+		patterns:  map[uuid.UUID][]string{},
+		companies: map[uuid.UUID][]string{},
+	}
+	for _, p := range want {
+		id := uuid.New()
+		f.rows = append(f.rows, repo.GetProblemsForUserRow{
+			ID:            id,
+			Title:         p.Title,
+			Url:           text(p.URL),
+			Source:        text(p.Source),
+			Difficulty:    text(p.Difficulty),
+			Status:        text("solved"),
+			Confidence:    pgtype.Int4{Int32: 70, Valid: true},
+			TotalAttempts: pgtype.Int4{Int32: 3, Valid: true},
+		})
+		f.patterns[id] = p.Patterns
+		f.companies[id] = p.Companies
+	}
+	s := NewService(f, testutil.Transactor{Q: f}, listScoring{}, nil, webhooks.Noop{})
+
+	for _, extended := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := s.ExportProblemsCSV(context.Background(), uuid.New(), &buf, extended); err != nil {
+			t.Fatalf("ExportProblemsCSV(extended=%v): %v", extended, err)
+		}
+
+		got, invalid, err := dataimport.NewParser(0).ParseCSV(&buf)
+		if err != nil {
+			t.Fatalf("ParseCSV(extended=%v): %v", extended, err)
+		}
+		if len(invalid) > 0 {
+			t.Fatalf("ParseCSV(extended=%v) rejected rows: %+v", extended, invalid)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("extended=%v: parsed %d problems, want %d", extended, len(got), len(want))
+		}
+		for i := range want {
+			g, w := got[i], want[i]
+			if g.Title != w.Title || g.URL != w.URL || g.Source != w.Source || g.Difficulty != w.Difficulty ||
+				!slices.Equal(g.Patterns, w.Patterns) || !slices.Equal(g.Companies, w.Companies) {
+				t.Errorf("extended=%v: row %d = %+v, want %+v", extended, i, g, w)
+			}
+		}
+	}
+}
@@ -1,23 +1,30 @@
 package problems
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
 type handler struct {
-	service Service
+	service     Service
+	preferences preferences.Service // Timezone for due-date windows
 }
 
-func NewHandler(service Service) *handler {
+func NewHandler(service Service, preferences preferences.Service) *handler {
 	return &handler{
-		service: service,
+		service:     service,
+		preferences: preferences,
 	}
 }
 
@@ -25,22 +32,30 @@ func (h *handler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body CreateProblemBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	problem, err := h.service.CreateProblem(r.Context(), userID, body)
 	if err != nil {
-		slog.Error("Failed to create problem", "error", err)
+		var dup *DuplicateProblemError
+		if errors.As(err, &dup) {
+			utils.Conflict(w, "A problem with this URL already exists", map[string]string{
+				"existing_problem_id":    dup.ExistingID,
+				"existing_problem_title": dup.ExistingTitle,
+			})
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to create problem", "error", err)
 		utils.InternalServerError(w, "Failed to create problem")
 		return
 	}
@@ -49,6 +64,13 @@ func (h *handler) CreateProblem(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) GetProblem(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
 	problemIDStr := chi.URLParam(r, "id")
 	problemID, err := uuid.Parse(problemIDStr)
 	if err != nil {
@@ -56,10 +78,14 @@ func (h *handler) GetProblem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	problem, err := h.service.GetProblem(r.Context(), problemID)
+	problem, err := h.service.GetProblem(r.Context(), userID, problemID)
 	if err != nil {
-		slog.Error("Failed to get problem", "error", err)
-		utils.NotFound(w, "Problem not found")
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get problem", "error", err)
+		utils.InternalServerError(w, "Failed to get problem")
 		return
 	}
 
@@ -77,15 +103,19 @@ func (h *handler) UpdateProblem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body UpdateProblemBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	problem, err := h.service.UpdateProblem(r.Context(), problemID, body)
 	if err != nil {
-		slog.Error("Failed to update problem", "error", err)
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update problem", "error", err)
 		utils.InternalServerError(w, "Failed to update problem")
 		return
 	}
@@ -102,7 +132,11 @@ func (h *handler) DeleteProblem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.DeleteProblem(r.Context(), problemID); err != nil {
-		slog.Error("Failed to delete problem", "error", err)
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete problem", "error", err)
 		utils.InternalServerError(w, "Failed to delete problem")
 		return
 	}
@@ -110,9 +144,158 @@ func (h *handler) DeleteProblem(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Problem deleted successfully"})
 }
 
+func (h *handler) BulkDeleteProblems(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body BulkDeleteProblemsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	if len(body.ProblemIDs) == 0 {
+		utils.BadRequest(w, "problem_ids must not be empty", nil)
+		return
+	}
+	if len(body.ProblemIDs) > MaxBulkProblems {
+		utils.BadRequest(w, fmt.Sprintf("At most %d problems can be deleted at once", MaxBulkProblems), nil)
+		return
+	}
+
+	problemIDs, err := parseUUIDs(body.ProblemIDs)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+
+	result, err := h.service.BulkDeleteProblems(r.Context(), problemIDs, force)
+	if err != nil {
+		var inUse *ProblemsInUseError
+		if errors.As(err, &inUse) {
+			utils.Conflict(w, "Some problems are part of active sessions, retry with force=true to remove them", map[string]interface{}{
+				"session_ids": inUse.SessionIDs,
+			})
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to bulk delete problems", "error", err)
+		utils.InternalServerError(w, "Failed to delete problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) BulkUpdateProblems(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body BulkUpdateProblemsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	if len(body.ProblemIDs) == 0 {
+		utils.BadRequest(w, "problem_ids must not be empty", nil)
+		return
+	}
+	if len(body.ProblemIDs) > MaxBulkProblems {
+		utils.BadRequest(w, fmt.Sprintf("At most %d problems can be updated at once", MaxBulkProblems), nil)
+		return
+	}
+	for _, ids := range [][]string{body.ProblemIDs, body.AddPatternIDs, body.RemovePatternIDs} {
+		if _, err := parseUUIDs(ids); err != nil {
+			utils.BadRequest(w, "Invalid ID format", nil)
+			return
+		}
+	}
+	if body.Difficulty != nil && *body.Difficulty != "easy" && *body.Difficulty != "medium" && *body.Difficulty != "hard" {
+		utils.BadRequest(w, "Invalid difficulty, must be one of: easy, medium, hard", nil)
+		return
+	}
+
+	result, err := h.service.BulkUpdateProblems(r.Context(), body)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to bulk update problems", "error", err)
+		utils.InternalServerError(w, "Failed to update problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) BulkLinkPattern(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body BulkLinkPatternBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	if len(body.ProblemIDs) == 0 {
+		utils.BadRequest(w, "problem_ids must not be empty", nil)
+		return
+	}
+	if len(body.ProblemIDs) > MaxBulkProblems {
+		utils.BadRequest(w, fmt.Sprintf("At most %d problems can be updated at once", MaxBulkProblems), nil)
+		return
+	}
+	if _, err := parseUUIDs(append([]string{body.PatternID}, body.ProblemIDs...)); err != nil {
+		utils.BadRequest(w, "Invalid ID format", nil)
+		return
+	}
+
+	result, err := h.service.BulkLinkPattern(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, ErrPatternNotFound) {
+			utils.NotFound(w, "Pattern not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to bulk link pattern", "error", err)
+		utils.InternalServerError(w, "Failed to link pattern")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) GetUnpatternedProblems(w http.ResponseWriter, r *http.Request) {
+	// Parse pagination params
+	page := int64(1)
+	pageSize := int64(20)
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if parsedPage, err := strconv.ParseInt(pageStr, 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
+		if parsedSize, err := strconv.ParseInt(pageSizeStr, 10, 64); err == nil && parsedSize > 0 && parsedSize <= 100 {
+			pageSize = parsedSize
+		}
+	}
+
+	offset := (page - 1) * pageSize
+
+	result, err := h.service.GetUnpatternedProblems(r.Context(), int32(pageSize), int32(offset))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get unpatterned problems", "error", err)
+		utils.InternalServerError(w, "Failed to get unpatterned problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
 func (h *handler) ListProblemsForUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -121,20 +304,53 @@ func (h *handler) ListProblemsForUser(w http.ResponseWriter, r *http.Request) {
 	// Check if we should use search/pagination
 	query := r.URL.Query().Get("q")
 	difficulty := r.URL.Query().Get("difficulty")
+	source := r.URL.Query().Get("source")
 	status := r.URL.Query().Get("status")
+	notesContains := r.URL.Query().Get("notes_contains")
+	starredOnly := r.URL.Query().Get("starred") == "true"
 	pageStr := r.URL.Query().Get("page")
 	pageSizeStr := r.URL.Query().Get("page_size")
 
+	sortBy := r.URL.Query().Get("sort_by")
+	switch sortBy {
+	case "", SortScoreDesc, SortLastAttemptAsc, SortConfidenceAsc, SortCreatedDesc, SortTitleAsc:
+	default:
+		utils.BadRequest(w, "Invalid sort_by, must be one of: score_desc, last_attempt_asc, confidence_asc, created_desc, title_asc", nil)
+		return
+	}
+
+	patternIDs, err := parsePatternIDs(r.URL.Query().Get("pattern_id"), r.URL.Query().Get("pattern_ids"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return
+	}
+
+	companyID, err := parseOptionalUUID(r.URL.Query().Get("company_id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid company ID format", nil)
+		return
+	}
+
 	// If any search/pagination params are present, use the search endpoint
-	if query != "" || difficulty != "" || status != "" || pageStr != "" || pageSizeStr != "" {
-		h.searchProblemsForUser(w, r, userID, query, difficulty, status, pageStr, pageSizeStr)
+	if query != "" || difficulty != "" || source != "" || status != "" || notesContains != "" || starredOnly || len(patternIDs) > 0 || companyID != nil || sortBy != "" || pageStr != "" || pageSizeStr != "" {
+		h.searchProblemsForUser(w, r, userID, SearchProblemsParams{
+			Query:         query,
+			Difficulty:    difficulty,
+			Source:        source,
+			Status:        status,
+			NotesContains: notesContains,
+			PatternIDs:    patternIDs,
+			CompanyID:     companyID,
+			StarredOnly:   starredOnly,
+			SortBy:        sortBy,
+		}, pageStr, pageSizeStr)
 		return
 	}
 
 	// Otherwise, return all problems (backward compatibility)
 	problems, err := h.service.ListProblemsForUser(r.Context(), userID)
 	if err != nil {
-		slog.Error("Failed to list problems", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list problems", "error", err)
 		utils.InternalServerError(w, "Failed to list problems")
 		return
 	}
@@ -142,7 +358,7 @@ func (h *handler) ListProblemsForUser(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, problems)
 }
 
-func (h *handler) searchProblemsForUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, query, difficulty, status, pageStr, pageSizeStr string) {
+func (h *handler) searchProblemsForUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID, params SearchProblemsParams, pageStr, pageSizeStr string) {
 	// Parse pagination params
 	page := int64(1)
 	pageSize := int64(20)
@@ -161,17 +377,12 @@ func (h *handler) searchProblemsForUser(w http.ResponseWriter, r *http.Request,
 
 	offset := (page - 1) * pageSize
 
-	params := SearchProblemsParams{
-		Query:      query,
-		Difficulty: difficulty,
-		Status:     status,
-		Limit:      int32(pageSize),
-		Offset:     int32(offset),
-	}
+	params.Limit = int32(pageSize)
+	params.Offset = int32(offset)
 
 	result, err := h.service.SearchProblemsForUser(r.Context(), userID, params)
 	if err != nil {
-		slog.Error("Failed to search problems", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to search problems", "error", err)
 		utils.InternalServerError(w, "Failed to search problems")
 		return
 	}
@@ -179,9 +390,440 @@ func (h *handler) searchProblemsForUser(w http.ResponseWriter, r *http.Request,
 	utils.WriteSuccess(w, http.StatusOK, result)
 }
 
+func (h *handler) GetProblemBreakdown(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	breakdown, err := h.service.GetProblemBreakdown(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get problem breakdown", "error", err)
+		utils.InternalServerError(w, "Failed to get problem breakdown")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, breakdown)
+}
+
+func (h *handler) GetRandomProblem(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	patternIDs, err := parsePatternIDs(r.URL.Query().Get("pattern_id"), r.URL.Query().Get("pattern_ids"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid pattern ID format", nil)
+		return
+	}
+
+	companyID, err := parseOptionalUUID(r.URL.Query().Get("company_id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid company ID format", nil)
+		return
+	}
+
+	var seed *int64
+	if seedStr := r.URL.Query().Get("seed"); seedStr != "" {
+		parsedSeed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			utils.BadRequest(w, "Invalid seed, must be an integer", nil)
+			return
+		}
+		seed = &parsedSeed
+	}
+
+	params := SearchProblemsParams{
+		Query:       r.URL.Query().Get("q"),
+		Difficulty:  r.URL.Query().Get("difficulty"),
+		Source:      r.URL.Query().Get("source"),
+		Status:      r.URL.Query().Get("status"),
+		PatternIDs:  patternIDs,
+		CompanyID:   companyID,
+		StarredOnly: r.URL.Query().Get("starred") == "true",
+	}
+
+	problem, err := h.service.GetRandomProblem(r.Context(), userID, params, seed)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.WriteError(w, http.StatusNotFound, utils.ErrCodeNotFound, "No problems match the filters", map[string]int{"match_count": 0})
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get random problem", "error", err)
+		utils.InternalServerError(w, "Failed to get random problem")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, problem)
+}
+
+func (h *handler) GetRelatedProblems(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	// Default limit is 10, capped at 50
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 50 {
+			limit = parsedLimit
+		}
+	}
+
+	related, err := h.service.GetRelatedProblems(r.Context(), userID, problemID, limit)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get related problems", "error", err)
+		utils.InternalServerError(w, "Failed to get related problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, related)
+}
+
+func (h *handler) ExportProblems(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		utils.BadRequest(w, "Invalid format, only csv is supported", nil)
+		return
+	}
+	extended := r.URL.Query().Get("extended") == "true"
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="reforge-problems.csv"`)
+
+	if err := h.service.ExportProblemsCSV(r.Context(), userID, w, extended); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to export problems", "error", err)
+		utils.InternalServerError(w, "Failed to export problems")
+		return
+	}
+}
+
+// ExportAnkiDeck - GET /api/v1/export/anki
+// Downloads problems in a due window as an Anki import file. One request
+// returns at most MaxAnkiCards; the X-Total-Count and X-Next-Page headers tell
+// the client how to fetch the rest.
+func (h *handler) ExportAnkiDeck(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "overdue"
+	}
+	if window != "overdue" && window != "week" && window != "all" {
+		utils.BadRequest(w, "Invalid window, must be one of: overdue, week, all", nil)
+		return
+	}
+
+	page := int64(1)
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if parsedPage, err := strconv.ParseInt(pageStr, 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+	offset := (page - 1) * MaxAnkiCards
+
+	deck, err := h.service.ExportAnkiDeck(r.Context(), userID, window, MaxAnkiCards, int32(offset))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to export Anki deck", "error", err)
+		utils.InternalServerError(w, "Failed to export Anki deck")
+		return
+	}
+
+	filename := fmt.Sprintf("reforge-anki-%s-%s.txt", window, time.Now().Format("2006-01-02"))
+	if page > 1 {
+		filename = strings.TrimSuffix(filename, ".txt") + fmt.Sprintf("-page%d.txt", page)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Total-Count", strconv.FormatInt(deck.Total, 10))
+	if offset+int64(len(deck.Cards)) < deck.Total {
+		w.Header().Set("X-Next-Page", strconv.FormatInt(page+1, 10))
+	}
+
+	// Headers are already sent once writing starts, so failures can only be logged
+	if err := writeAnkiDeck(w, deck.Cards); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to write Anki deck", "error", err)
+	}
+}
+
+func (h *handler) PreviewProblemURL(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var body PreviewURLBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	preview, err := h.service.PreviewProblemURL(r.Context(), body.URL)
+	if err != nil {
+		if errors.Is(err, ErrUnsupportedURL) {
+			utils.BadRequest(w, "Unsupported URL, expected a LeetCode or Codeforces problem link", nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to preview problem URL", "error", err)
+		utils.InternalServerError(w, "Failed to preview problem URL")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, preview)
+}
+
+func (h *handler) StarProblem(w http.ResponseWriter, r *http.Request) {
+	h.setProblemStarred(w, r, true)
+}
+
+func (h *handler) UnstarProblem(w http.ResponseWriter, r *http.Request) {
+	h.setProblemStarred(w, r, false)
+}
+
+func (h *handler) setProblemStarred(w http.ResponseWriter, r *http.Request, starred bool) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	if err := h.service.SetProblemStarred(r.Context(), userID, problemID, starred); err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update problem star", "error", err)
+		utils.InternalServerError(w, "Failed to update star")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]bool{"is_starred": starred})
+}
+
+func (h *handler) UpdateProblemNotes(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	var body UpdateNotesBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	stats, err := h.service.UpdateProblemNotes(r.Context(), userID, problemID, body.Notes)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update problem notes", "error", err)
+		utils.InternalServerError(w, "Failed to update notes")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, stats)
+}
+
+// SnoozeProblem - POST /api/v1/problems/:id/snooze
+func (h *handler) SnoozeProblem(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	var body SnoozeBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	stats, err := h.service.SnoozeProblem(r.Context(), userID, problemID, body.Days)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to snooze problem", "error", err)
+		utils.InternalServerError(w, "Failed to snooze problem")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, stats)
+}
+
+// CancelSnooze - DELETE /api/v1/problems/:id/snooze
+func (h *handler) CancelSnooze(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	stats, err := h.service.CancelSnooze(r.Context(), userID, problemID)
+	if err != nil {
+		if errors.Is(err, ErrNotSnoozed) {
+			utils.NotFound(w, "Problem is not snoozed")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to cancel snooze", "error", err)
+		utils.InternalServerError(w, "Failed to cancel snooze")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, stats)
+}
+
+func (h *handler) UpdateProblemStatus(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	var body UpdateStatusBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	switch body.Status {
+	case StatusMastered, StatusAbandoned, StatusActive:
+	default:
+		utils.BadRequest(w, "Invalid status, must be one of: mastered, abandoned, active", nil)
+		return
+	}
+
+	stats, err := h.service.UpdateProblemStatus(r.Context(), userID, problemID, body.Status)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "Problem not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update problem status", "error", err)
+		utils.InternalServerError(w, "Failed to update status")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, stats)
+}
+
+// parsePatternIDs combines the single pattern_id and comma-separated pattern_ids filters
+func parsePatternIDs(patternID, patternIDs string) ([]uuid.UUID, error) {
+	raw := make([]string, 0)
+	if patternID != "" {
+		raw = append(raw, patternID)
+	}
+	if patternIDs != "" {
+		raw = append(raw, strings.Split(patternIDs, ",")...)
+	}
+
+	ids := make([]uuid.UUID, 0, len(raw))
+	for _, idStr := range raw {
+		id, err := uuid.Parse(strings.TrimSpace(idStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern ID %q: %w", idStr, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseOptionalUUID parses a UUID query parameter, nil when it is absent
+func parseOptionalUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
 func (h *handler) GetUrgentProblems(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -195,12 +837,125 @@ func (h *handler) GetUrgentProblems(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	problems, err := h.service.GetUrgentProblems(r.Context(), userID, int32(limit))
+	// force=true skips the score cache and rescores everything
+	force := r.URL.Query().Get("force") == "true"
+
+	problems, err := h.service.GetUrgentProblems(r.Context(), userID, int32(limit), force)
 	if err != nil {
-		slog.Error("Failed to get urgent problems", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get urgent problems", "error", err)
 		utils.InternalServerError(w, "Failed to get urgent problems")
 		return
 	}
 
 	utils.WriteSuccess(w, http.StatusOK, problems)
 }
+
+func (h *handler) GetProblemScore(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	problemIDStr := chi.URLParam(r, "id")
+	problemID, err := uuid.Parse(problemIDStr)
+	if err != nil {
+		utils.BadRequest(w, "Invalid problem ID format", nil)
+		return
+	}
+
+	// Optional emphasis (confidence, failure, time) mirrors session templates
+	emphasis := r.URL.Query().Get("emphasis")
+	if emphasis == "" {
+		emphasis = "standard"
+	}
+
+	explanation, err := h.service.ExplainProblemScore(r.Context(), userID, problemID, emphasis)
+	if err != nil {
+		if errors.Is(err, ErrProblemNotFound) {
+			utils.NotFound(w, "No score available for this problem")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to explain problem score", "error", err)
+		utils.InternalServerError(w, "Failed to explain problem score")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, explanation)
+}
+
+func (h *handler) GetDueProblems(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "today"
+	}
+	if window != "today" && window != "week" && window != "overdue" {
+		utils.BadRequest(w, "Invalid window, must be one of: today, week, overdue", nil)
+		return
+	}
+
+	// Parse pagination params
+	page := int64(1)
+	pageSize := int64(20)
+
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if parsedPage, err := strconv.ParseInt(pageStr, 10, 64); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+
+	if pageSizeStr := r.URL.Query().Get("page_size"); pageSizeStr != "" {
+		if parsedSize, err := strconv.ParseInt(pageSizeStr, 10, 64); err == nil && parsedSize > 0 && parsedSize <= 100 {
+			pageSize = parsedSize
+		}
+	}
+
+	offset := (page - 1) * pageSize
+
+	// "today" ends at midnight in the caller's timezone
+	locale, err := preferences.RequestLocale(r, h.preferences, userID)
+	if err != nil {
+		if preferences.IsInvalidLocale(err) {
+			utils.BadRequest(w, err.Error(), nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to load preferences", "error", err)
+		utils.InternalServerError(w, "Failed to load preferences")
+		return
+	}
+
+	result, err := h.service.GetDueProblems(r.Context(), userID, window, locale.Location, int32(pageSize), int32(offset))
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get due problems", "error", err)
+		utils.InternalServerError(w, "Failed to get due problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, result)
+}
+
+func (h *handler) GetLeechProblems(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	leeches, err := h.service.GetLeechProblems(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to get leech problems", "error", err)
+		utils.InternalServerError(w, "Failed to get leech problems")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, leeches)
+}
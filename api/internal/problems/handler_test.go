@@ -0,0 +1,146 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// handlerService answers the handler's service calls with a fixed result and
+// counts them
+type handlerService struct {
+	Service
+	created int
+}
+
+func (f *handlerService) CreateProblem(ctx context.Context, userID uuid.UUID, body CreateProblemBody) (*ProblemWithStats, error) {
+	f.created++
+	return &ProblemWithStats{}, nil
+}
+
+// serve runs one handler method as an authenticated user
+func serve(h http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req = req.WithContext(auth.WithUser(req.Context(), uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// serveID runs one handler method for a request to /{id}, as an authenticated
+// user
+func serveID(h http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	req = req.WithContext(auth.WithUser(ctx, uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+func TestCreateProblemValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []utils.FieldError
+	}{
+		{
+			name: "missing title and unknown difficulty",
+			body: `{"difficulty": "extreme"}`,
+			fields: []utils.FieldError{
+				{Field: "title", Rule: "required", Value: ""},
+				{Field: "difficulty", Rule: "oneof", Param: "easy medium hard", Value: "extreme"},
+			},
+		},
+		{
+			name: "one bad pattern id among good ones",
+			body: `{"title": "Two Sum", "difficulty": "easy", "pattern_ids": ["` + uuid.NewString() + `", "arrays"]}`,
+			fields: []utils.FieldError{
+				{Field: "pattern_ids[1]", Rule: "uuid", Value: "arrays"},
+			},
+		},
+		{
+			name: "url that isn't one",
+			body: `{"title": "Two Sum", "difficulty": "easy", "url": "two sum"}`,
+			fields: []utils.FieldError{
+				{Field: "url", Rule: "url", Value: "two sum"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &handlerService{}
+			rec := serve(NewHandler(f, nil).CreateProblem, http.MethodPost, "/api/v1/problems", tt.body)
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+			}
+			apiErr := testutil.ErrorResponse(t, rec)
+			if apiErr.Code != utils.ErrCodeValidation {
+				t.Errorf("error code = %q, want %q", apiErr.Code, utils.ErrCodeValidation)
+			}
+			var fields []utils.FieldError
+			testutil.DecodeDetails(t, apiErr.Details, &fields)
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("details = %+v, want %+v", fields, tt.fields)
+			}
+			if f.created != 0 {
+				t.Errorf("service called with an invalid body")
+			}
+		})
+	}
+}
+
+// lookupRepo fails the problem lookup with err
+type lookupRepo struct {
+	*testutil.Querier
+	err error
+}
+
+func (f *lookupRepo) GetProblem(ctx context.Context, id uuid.UUID) (repo.Problem, error) {
+	return repo.Problem{}, f.err
+}
+
+// A missing row is a 404; any other repo failure stays a 500
+func TestGetProblemNotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		repoErr error
+		status  int
+		code    string
+	}{
+		{"missing", uuid.NewString(), pgx.ErrNoRows, http.StatusNotFound, utils.ErrCodeNotFound},
+		{"database failure", uuid.NewString(), errors.New("connection refused"), http.StatusInternalServerError, utils.ErrCodeInternalServer},
+		{"malformed id", "42", nil, http.StatusBadRequest, utils.ErrCodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &lookupRepo{Querier: testutil.NewQuerier(), err: tt.repoErr}
+			s := NewService(f, testutil.Transactor{Q: f}, listScoring{}, nil, webhooks.Noop{})
+			rec := serveID(NewHandler(s, nil).GetProblem, http.MethodGet, tt.id)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if code := testutil.ErrorResponse(t, rec).Code; code != tt.code {
+				t.Errorf("error code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package problems
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// listRepo serves a user's problem library for listing and search, each
+// problem linked to one pattern
+type listRepo struct {
+	*testutil.Querier
+	problems []repo.Problem
+	patterns map[uuid.UUID]repo.Pattern
+}
+
+func newListRepo(n int) *listRepo {
+	f := &listRepo{Querier: testutil.NewQuerier(), patterns: map[uuid.UUID]repo.Pattern{}}
+	for i := range n {
+		problem := testutil.Problem(fmt.Sprintf("Problem %02d", i), "medium")
+		f.problems = append(f.problems, problem)
+		f.patterns[problem.ID] = repo.Pattern{ID: uuid.New(), Title: fmt.Sprintf("Pattern %02d", i)}
+	}
+	return f
+}
+
+func (f *listRepo) GetProblemsForUser(ctx context.Context, userID uuid.UUID) ([]repo.GetProblemsForUserRow, error) {
+	f.Record("GetProblemsForUser", userID)
+	rows := make([]repo.GetProblemsForUserRow, 0, len(f.problems))
+	for _, p := range f.problems {
+		rows = append(rows, repo.GetProblemsForUserRow{ID: p.ID, Title: p.Title, Difficulty: p.Difficulty, CreatedAt: p.CreatedAt})
+	}
+	return rows, nil
+}
+
+func (f *listRepo) CountProblemsForUser(ctx context.Context, arg repo.CountProblemsForUserParams) (int64, error) {
+	f.Record("CountProblemsForUser", arg)
+	return int64(len(f.problems)), nil
+}
+
+func (f *listRepo) SearchProblemsForUser(ctx context.Context, arg repo.SearchProblemsForUserParams) ([]repo.SearchProblemsForUserRow, error) {
+	f.Record("SearchProblemsForUser", arg)
+	start := min(int(arg.OffsetVal), len(f.problems))
+	end := min(start+int(arg.LimitVal), len(f.problems))
+	rows := make([]repo.SearchProblemsForUserRow, 0, end-start)
+	for _, p := range f.problems[start:end] {
+		rows = append(rows, repo.SearchProblemsForUserRow{ID: p.ID, Title: p.Title, Difficulty: p.Difficulty, CreatedAt: p.CreatedAt})
+	}
+	return rows, nil
+}
+
+func (f *listRepo) GetPatternsForProblems(ctx context.Context, problemIDs []uuid.UUID) ([]repo.GetPatternsForProblemsRow, error) {
+	f.Record("GetPatternsForProblems", problemIDs)
+	rows := make([]repo.GetPatternsForProblemsRow, 0, len(problemIDs))
+	for _, id := range problemIDs {
+		pattern := f.patterns[id]
+		rows = append(rows, repo.GetPatternsForProblemsRow{ProblemID: id, ID: pattern.ID, Title: pattern.Title})
+	}
+	return rows, nil
+}
+
+func (f *listRepo) GetPatternsForProblem(ctx context.Context, problemID uuid.UUID) ([]repo.Pattern, error) {
+	f.Record("GetPatternsForProblem", problemID)
+	return []repo.Pattern{f.patterns[problemID]}, nil
+}
+
+func (f *listRepo) GetCompaniesForProblems(ctx context.Context, problemIDs []uuid.UUID) ([]repo.GetCompaniesForProblemsRow, error) {
+	f.Record("GetCompaniesForProblems", problemIDs)
+	return nil, nil
+}
+
+// listScoring scores problems from a fixed map
+type listScoring struct {
+	scoring.Service
+	scores map[uuid.UUID]float64
+}
+
+func (f listScoring) GetLeechThreshold(ctx context.Context) int {
+	return scoring.DefaultLeechThreshold
+}
+
+func (f listScoring) ComputeScoresForUser(ctx context.Context, userID uuid.UUID) ([]scoring.ProblemScore, error) {
+	scores := make([]scoring.ProblemScore, 0, len(f.scores))
+	for id, score := range f.scores {
+		scores = append(scores, scoring.ProblemScore{ProblemID: id, Score: score})
+	}
+	return scores, nil
+}
+
+func newListService(f *listRepo, scores map[uuid.UUID]float64) Service {
+	return NewService(f, testutil.Transactor{Q: f}, listScoring{scores: scores}, nil, webhooks.Noop{})
+}
+
+// Each page loads its patterns and companies in one query apiece, however many
+// rows it has
+func TestListingQueryCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		list  func(Service) ([]ProblemWithStats, error)
+		calls map[string]int
+	}{
+		{
+			name: "list",
+			list: func(s Service) ([]ProblemWithStats, error) {
+				return s.ListProblemsForUser(context.Background(), uuid.New())
+			},
+			calls: map[string]int{
+				"GetProblemsForUser":      1,
+				"GetPatternsForProblems":  1,
+				"GetCompaniesForProblems": 1,
+			},
+		},
+		{
+			name: "search",
+			list: func(s Service) ([]ProblemWithStats, error) {
+				page, err := s.SearchProblemsForUser(context.Background(), uuid.New(), SearchProblemsParams{Limit: 50})
+				if err != nil {
+					return nil, err
+				}
+				return page.Data, nil
+			},
+			calls: map[string]int{
+				"CountProblemsForUser":    1,
+				"SearchProblemsForUser":   1,
+				"GetPatternsForProblems":  1,
+				"GetCompaniesForProblems": 1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newListRepo(50)
+			problems, err := tt.list(newListService(f, nil))
+			if err != nil {
+				t.Fatalf("listing: %v", err)
+			}
+			if len(problems) != 50 {
+				t.Fatalf("got %d problems, want 50", len(problems))
+			}
+
+			total := 0
+			for method, want := range tt.calls {
+				if got := len(f.CallsTo(method)); got != want {
+					t.Errorf("%s called %d times, want %d", method, got, want)
+				}
+				total += want
+			}
+			if got := len(f.Calls()); got != total {
+				t.Errorf("%d repo calls, want %d", got, total)
+			}
+
+			// The batched lookup still gives each problem its own patterns
+			for i, problem := range problems {
+				want := f.patterns[f.problems[i].ID].Title
+				if len(problem.Patterns) != 1 || problem.Patterns[0].Title != want {
+					t.Errorf("%s patterns = %+v, want [%s]", problem.Title, problem.Patterns, want)
+				}
+			}
+		})
+	}
+}
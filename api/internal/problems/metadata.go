@@ -0,0 +1,240 @@
+package problems
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedURL is returned when a URL isn't a recognized problem page
+var ErrUnsupportedURL = errors.New("unsupported problem URL")
+
+const (
+	PlatformLeetCode   = "leetcode"
+	PlatformCodeforces = "codeforces"
+
+	// metadataFetchTimeout bounds each upstream lookup so previews stay snappy
+	metadataFetchTimeout = 5 * time.Second
+)
+
+var (
+	leetCodePath   = regexp.MustCompile(`^/problems/([a-z0-9-]+)/?`)
+	codeforcesPath = regexp.MustCompile(`^/(?:problemset/problem|contest|gym)/(\d+)(?:/problem)?/([A-Za-z][0-9]?)/?$`)
+)
+
+// ParsedProblemURL identifies a problem on a known platform
+type ParsedProblemURL struct {
+	Platform  string
+	Slug      string // LeetCode title slug, or Codeforces contest+index e.g. "1520F"
+	ContestID string // Codeforces only
+	Index     string // Codeforces only
+}
+
+// ProblemMetadata is what an upstream platform tells us about a problem
+type ProblemMetadata struct {
+	Title      string
+	Difficulty string // easy, medium or hard
+	Tags       []string
+}
+
+// MetadataFetcher looks up problem metadata on LeetCode and Codeforces
+type MetadataFetcher struct {
+	client        *http.Client
+	leetCodeURL   string
+	codeforcesURL string
+}
+
+// NewMetadataFetcher creates a fetcher using the given HTTP client, or a
+// default client with a short timeout when nil
+func NewMetadataFetcher(client *http.Client) *MetadataFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: metadataFetchTimeout}
+	}
+	return &MetadataFetcher{
+		client:        client,
+		leetCodeURL:   "https://leetcode.com/graphql",
+		codeforcesURL: "https://codeforces.com/api/contest.standings",
+	}
+}
+
+// ParseProblemURL recognizes LeetCode and Codeforces problem URL shapes
+func ParseProblemURL(raw string) (*ParsedProblemURL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return nil, ErrUnsupportedURL
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	switch host {
+	case "leetcode.com", "leetcode.cn":
+		m := leetCodePath.FindStringSubmatch(u.Path)
+		if m == nil {
+			return nil, ErrUnsupportedURL
+		}
+		return &ParsedProblemURL{Platform: PlatformLeetCode, Slug: m[1]}, nil
+	case "codeforces.com":
+		m := codeforcesPath.FindStringSubmatch(u.Path)
+		if m == nil {
+			return nil, ErrUnsupportedURL
+		}
+		index := strings.ToUpper(m[2])
+		return &ParsedProblemURL{
+			Platform:  PlatformCodeforces,
+			Slug:      m[1] + index,
+			ContestID: m[1],
+			Index:     index,
+		}, nil
+	}
+
+	return nil, ErrUnsupportedURL
+}
+
+// Fetch retrieves metadata for a parsed URL from the platform's public API
+func (f *MetadataFetcher) Fetch(ctx context.Context, parsed *ParsedProblemURL) (*ProblemMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	switch parsed.Platform {
+	case PlatformLeetCode:
+		return f.fetchLeetCode(ctx, parsed.Slug)
+	case PlatformCodeforces:
+		return f.fetchCodeforces(ctx, parsed.ContestID, parsed.Index)
+	}
+	return nil, ErrUnsupportedURL
+}
+
+func (f *MetadataFetcher) fetchLeetCode(ctx context.Context, slug string) (*ProblemMetadata, error) {
+	payload, err := json.Marshal(map[string]any{
+		"query":     `query questionData($titleSlug: String!) { question(titleSlug: $titleSlug) { title difficulty topicTags { name } } }`,
+		"variables": map[string]string{"titleSlug": slug},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.leetCodeURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://leetcode.com/problems/"+slug+"/")
+
+	var body struct {
+		Data struct {
+			Question *struct {
+				Title      string `json:"title"`
+				Difficulty string `json:"difficulty"`
+				TopicTags  []struct {
+					Name string `json:"name"`
+				} `json:"topicTags"`
+			} `json:"question"`
+		} `json:"data"`
+	}
+	if err := f.doJSON(req, &body); err != nil {
+		return nil, err
+	}
+
+	question := body.Data.Question
+	if question == nil {
+		return nil, fmt.Errorf("leetcode problem %q not found", slug)
+	}
+
+	tags := make([]string, 0, len(question.TopicTags))
+	for _, tag := range question.TopicTags {
+		tags = append(tags, tag.Name)
+	}
+
+	return &ProblemMetadata{
+		Title:      question.Title,
+		Difficulty: strings.ToLower(question.Difficulty),
+		Tags:       tags,
+	}, nil
+}
+
+func (f *MetadataFetcher) fetchCodeforces(ctx context.Context, contestID, index string) (*ProblemMetadata, error) {
+	query := url.Values{}
+	query.Set("contestId", contestID)
+	query.Set("from", "1")
+	query.Set("count", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.codeforcesURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Status string `json:"status"`
+		Result struct {
+			Problems []struct {
+				Index  string   `json:"index"`
+				Name   string   `json:"name"`
+				Rating int      `json:"rating"`
+				Tags   []string `json:"tags"`
+			} `json:"problems"`
+		} `json:"result"`
+	}
+	if err := f.doJSON(req, &body); err != nil {
+		return nil, err
+	}
+	if body.Status != "OK" {
+		return nil, fmt.Errorf("codeforces returned status %q", body.Status)
+	}
+
+	for _, problem := range body.Result.Problems {
+		if problem.Index == index {
+			return &ProblemMetadata{
+				Title:      problem.Name,
+				Difficulty: codeforcesDifficulty(problem.Rating),
+				Tags:       problem.Tags,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("codeforces problem %s%s not found", contestID, index)
+}
+
+func (f *MetadataFetcher) doJSON(req *http.Request, out any) error {
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// codeforcesDifficulty buckets a Codeforces rating into easy/medium/hard;
+// unrated problems fall back to medium
+func codeforcesDifficulty(rating int) string {
+	switch {
+	case rating == 0:
+		return "medium"
+	case rating < 1400:
+		return "easy"
+	case rating < 2000:
+		return "medium"
+	default:
+		return "hard"
+	}
+}
+
+// titleFromSlug guesses a display title from a URL slug,
+// e.g. "merge-k-sorted-lists" -> "Merge K Sorted Lists"
+func titleFromSlug(slug string) string {
+	words := strings.Split(slug, "-")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
@@ -2,76 +2,169 @@ package problems
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
 )
 
+var (
+	ErrProblemNotFound = fmt.Errorf("problem %w", utils.ErrNotFound)
+	ErrPatternNotFound = fmt.Errorf("pattern %w", utils.ErrNotFound)
+	ErrNotSnoozed      = fmt.Errorf("snooze %w", utils.ErrNotFound)
+)
+
+// errDryRun rolls back a dry run's transaction; it never reaches the caller
+var errDryRun = errors.New("dry run")
+
+// DuplicateProblemError is returned when creating a problem whose URL matches
+// an existing one
+type DuplicateProblemError struct {
+	ExistingID    string
+	ExistingTitle string
+}
+
+func (e *DuplicateProblemError) Error() string {
+	return fmt.Sprintf("problem with this URL already exists: %s", e.ExistingID)
+}
+
+// ProblemsInUseError is returned when a bulk delete would remove problems that
+// are still planned in sessions that haven't been completed
+type ProblemsInUseError struct {
+	SessionIDs []string
+}
+
+func (e *ProblemsInUseError) Error() string {
+	return fmt.Sprintf("%d active session(s) contain problems scheduled for deletion", len(e.SessionIDs))
+}
+
 type Service interface {
 	CreateProblem(ctx context.Context, userID uuid.UUID, body CreateProblemBody) (*ProblemWithStats, error)
-	GetProblem(ctx context.Context, problemID uuid.UUID) (*ProblemWithStats, error)
+	GetProblem(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) (*ProblemWithStats, error)
 	UpdateProblem(ctx context.Context, problemID uuid.UUID, body UpdateProblemBody) (*ProblemWithStats, error)
 	DeleteProblem(ctx context.Context, problemID uuid.UUID) error
+	BulkDeleteProblems(ctx context.Context, problemIDs []uuid.UUID, force bool) (*BulkDeleteResult, error)
+	BulkUpdateProblems(ctx context.Context, body BulkUpdateProblemsBody) (*BulkUpdateResult, error)
 	ListProblemsForUser(ctx context.Context, userID uuid.UUID) ([]ProblemWithStats, error)
 	SearchProblemsForUser(ctx context.Context, userID uuid.UUID, params SearchProblemsParams) (*PaginatedProblems, error)
-	GetUrgentProblems(ctx context.Context, userID uuid.UUID, limit int32) ([]UrgentProblem, error)
+	GetUrgentProblems(ctx context.Context, userID uuid.UUID, limit int32, force bool) ([]UrgentProblem, error)
+	GetLeechProblems(ctx context.Context, userID uuid.UUID) (*LeechListResponse, error)
+	GetDueProblems(ctx context.Context, userID uuid.UUID, window string, loc *time.Location, limit, offset int32) (*PaginatedDueProblems, error)
+	GetUnpatternedProblems(ctx context.Context, limit, offset int32) (*PaginatedProblems, error)
+	BulkLinkPattern(ctx context.Context, body BulkLinkPatternBody) (*BulkUpdateResult, error)
+	GetProblemBreakdown(ctx context.Context, userID uuid.UUID) (*ProblemBreakdown, error)
+	GetRandomProblem(ctx context.Context, userID uuid.UUID, params SearchProblemsParams, seed *int64) (*ProblemWithStats, error)
+	GetRelatedProblems(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, limit int) ([]RelatedProblem, error)
+	ExportProblemsCSV(ctx context.Context, userID uuid.UUID, w io.Writer, extended bool) error
+	ExportAnkiDeck(ctx context.Context, userID uuid.UUID, window string, limit, offset int32) (*AnkiDeck, error)
+	PreviewProblemURL(ctx context.Context, rawURL string) (*URLPreview, error)
+	SetProblemStarred(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, starred bool) error
+	UpdateProblemNotes(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, notes *string) (*Stats, error)
+	UpdateProblemStatus(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, status string) (*Stats, error)
+	SnoozeProblem(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, days int) (*Stats, error)
+	CancelSnooze(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) (*Stats, error)
+	ExplainProblemScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ScoreExplanation, error)
 	LinkProblemToPatterns(ctx context.Context, problemID uuid.UUID, patternIDs []uuid.UUID) error
 }
 
 type problemService struct {
 	repo           repo.Querier
+	tx             postgres.Transactor
 	scoringService scoring.Service
+	fetcher        *MetadataFetcher
+	webhooks       webhooks.Notifier
 }
 
-func NewService(repo repo.Querier, scoringService scoring.Service) Service {
+func NewService(repo repo.Querier, tx postgres.Transactor, scoringService scoring.Service, fetcher *MetadataFetcher, notifier webhooks.Notifier) Service {
 	return &problemService{
 		repo:           repo,
+		tx:             tx,
 		scoringService: scoringService,
+		fetcher:        fetcher,
+		webhooks:       notifier,
 	}
 }
 
 func (s *problemService) CreateProblem(ctx context.Context, userID uuid.UUID, body CreateProblemBody) (*ProblemWithStats, error) {
-	// Create the problem
-	problem, err := s.repo.CreateProblem(ctx, repo.CreateProblemParams{
-		Title:      body.Title,
-		Source:     pgtypeText(body.Source),
-		Url:        pgtypeText(body.URL),
-		Difficulty: pgtypeText(&body.Difficulty),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create problem: %w", err)
+	if body.URL != nil && *body.URL != "" && !body.AllowDuplicate {
+		existing, err := s.repo.FindProblemByNormalizedURL(ctx, utils.NormalizeProblemURL(*body.URL))
+		if err == nil {
+			return nil, &DuplicateProblemError{ExistingID: existing.ID.String(), ExistingTitle: existing.Title}
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to check for duplicate URL: %w", err)
+		}
 	}
 
-	// Link patterns if provided
-	if len(body.PatternIDs) > 0 {
-		patternUUIDs, err := parseUUIDs(body.PatternIDs)
+	// Problem, pattern and company links and stats are created together or not at all
+	var problem repo.Problem
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		problem, err = q.CreateProblem(ctx, repo.CreateProblemParams{
+			Title:      body.Title,
+			Source:     pgtypeText(body.Source),
+			Url:        pgtypeText(body.URL),
+			Difficulty: pgtypeText(&body.Difficulty),
+		})
 		if err != nil {
-			return nil, fmt.Errorf("invalid pattern ID: %w", err)
+			return fmt.Errorf("failed to create problem: %w", err)
 		}
-		if err := s.LinkProblemToPatterns(ctx, problem.ID, patternUUIDs); err != nil {
-			return nil, fmt.Errorf("failed to link patterns: %w", err)
+
+		// Link patterns if provided
+		if len(body.PatternIDs) > 0 {
+			patternUUIDs, err := parseUUIDs(body.PatternIDs)
+			if err != nil {
+				return fmt.Errorf("invalid pattern ID: %w", err)
+			}
+			if err := linkProblemToPatterns(ctx, q, problem.ID, patternUUIDs); err != nil {
+				return fmt.Errorf("failed to link patterns: %w", err)
+			}
+		}
+
+		if len(body.CompanyIDs) > 0 {
+			companyUUIDs, err := parseUUIDs(body.CompanyIDs)
+			if err != nil {
+				return fmt.Errorf("invalid company ID: %w", err)
+			}
+			if err := linkProblemToCompanies(ctx, q, problem.ID, companyUUIDs); err != nil {
+				return fmt.Errorf("failed to link companies: %w", err)
+			}
 		}
-	}
 
-	// Initialize user stats for this problem
-	_, err = s.repo.UpsertUserProblemStats(ctx, repo.UpsertUserProblemStatsParams{
-		UserID:            userID,
-		ProblemID:         problem.ID,
-		Status:            pgtypeText(strPtr("unsolved")),
-		Confidence:        pgtype.Int4{Int32: 50, Valid: true},
-		AvgConfidence:     pgtype.Int4{Int32: 50, Valid: true},
-		LastAttemptAt:     pgtype.Timestamptz{},
-		TotalAttempts:     pgtype.Int4{Int32: 0, Valid: true},
-		AvgTimeSeconds:    pgtype.Int4{},
-		LastOutcome:       pgtype.Text{},
-		RecentHistoryJson: pgtype.Text{String: "[]", Valid: true},
+		// Initialize user stats for this problem
+		_, err = q.UpsertUserProblemStats(ctx, repo.UpsertUserProblemStatsParams{
+			UserID:            userID,
+			ProblemID:         problem.ID,
+			Status:            pgtypeText(strPtr("unsolved")),
+			Confidence:        pgtype.Int4{Int32: 50, Valid: true},
+			AvgConfidence:     pgtype.Int4{Int32: 50, Valid: true},
+			LastAttemptAt:     pgtype.Timestamptz{},
+			TotalAttempts:     pgtype.Int4{Int32: 0, Valid: true},
+			AvgTimeSeconds:    pgtype.Int4{},
+			LastOutcome:       pgtype.Text{},
+			RecentHistoryJson: pgtype.Text{String: "[]", Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize stats: %w", err)
+		}
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize stats: %w", err)
+		return nil, err
 	}
 
 	// Fetch patterns
@@ -80,7 +173,7 @@ func (s *problemService) CreateProblem(ctx context.Context, userID uuid.UUID, bo
 		patterns = []repo.Pattern{} // empty if error
 	}
 
-	return &ProblemWithStats{
+	created := &ProblemWithStats{
 		ID:         problem.ID.String(),
 		Title:      problem.Title,
 		Source:     pgtypeTextToPtr(problem.Source),
@@ -95,13 +188,22 @@ func (s *problemService) CreateProblem(ctx context.Context, userID uuid.UUID, bo
 			AvgConfidence: 50,
 			TotalAttempts: 0,
 		},
-		Patterns: convertPatternsFromRepo(patterns),
-	}, nil
+		Patterns:  convertPatternsFromRepo(patterns),
+		Companies: s.getCompaniesForProblem(ctx, problem.ID),
+	}
+	s.webhooks.Notify(ctx, userID, webhooks.EventProblemCreated, created)
+	return created, nil
 }
 
-func (s *problemService) GetProblem(ctx context.Context, problemID uuid.UUID) (*ProblemWithStats, error) {
+// recentAttemptsLimit caps the attempt history inlined on the problem detail
+const recentAttemptsLimit = 5
+
+func (s *problemService) GetProblem(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) (*ProblemWithStats, error) {
 	problem, err := s.repo.GetProblem(ctx, problemID)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProblemNotFound
+		}
 		return nil, fmt.Errorf("failed to get problem: %w", err)
 	}
 
@@ -111,42 +213,129 @@ func (s *problemService) GetProblem(ctx context.Context, problemID uuid.UUID) (*
 		patterns = []repo.Pattern{} // empty if error
 	}
 
-	return &ProblemWithStats{
-		ID:         problem.ID.String(),
-		Title:      problem.Title,
-		Source:     pgtypeTextToPtr(problem.Source),
-		URL:        pgtypeTextToPtr(problem.Url),
-		Difficulty: pgtypeTextToStr(problem.Difficulty, "medium"),
-		CreatedAt:  problem.CreatedAt.Time.Format(time.RFC3339),
-		Patterns:   convertPatternsFromRepo(patterns),
-	}, nil
-}
+	result := &ProblemWithStats{
+		ID:             problem.ID.String(),
+		Title:          problem.Title,
+		Source:         pgtypeTextToPtr(problem.Source),
+		URL:            pgtypeTextToPtr(problem.Url),
+		Difficulty:     pgtypeTextToStr(problem.Difficulty, "medium"),
+		CreatedAt:      problem.CreatedAt.Time.Format(time.RFC3339),
+		Patterns:       convertPatternsFromRepo(patterns),
+		Companies:      s.getCompaniesForProblem(ctx, problemID),
+		RecentAttempts: []RecentAttempt{},
+	}
 
-func (s *problemService) UpdateProblem(ctx context.Context, problemID uuid.UUID, body UpdateProblemBody) (*ProblemWithStats, error) {
-	problem, err := s.repo.UpdateProblem(ctx, repo.UpdateProblemParams{
-		ID:         problemID,
-		Title:      body.Title,
-		Source:     pgtypeText(body.Source),
-		Url:        pgtypeText(body.URL),
-		Difficulty: pgtypeText(&body.Difficulty),
+	if starred, err := s.repo.IsProblemStarred(ctx, repo.IsProblemStarredParams{
+		UserID:    userID,
+		ProblemID: problemID,
+	}); err == nil {
+		result.IsStarred = starred
+	}
+
+	// A problem the user hasn't attempted yet has no stats, score, or history
+	stats, err := s.repo.GetUserProblemStats(ctx, repo.GetUserProblemStatsParams{
+		UserID:    userID,
+		ProblemID: problemID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update problem: %w", err)
+		return result, nil
 	}
+	result.Stats = toStats(stats)
+	result.IsLeech = scoring.IsLeech(stats.ConsecutiveFailures, s.scoringService.GetLeechThreshold(ctx))
 
-	// Update pattern links
-	if err := s.repo.DeleteProblemPatterns(ctx, problemID); err != nil {
-		return nil, fmt.Errorf("failed to delete old patterns: %w", err)
+	if score, err := s.scoringService.ComputeScore(ctx, userID, problemID); err == nil {
+		result.Score = &score.Score
+		result.Reason = &score.Reason
+	} else {
+		logging.FromContext(ctx).Warn("Failed to compute score", "problem_id", problemID, "error", err)
 	}
 
-	if len(body.PatternIDs) > 0 {
-		patternUUIDs, err := parseUUIDs(body.PatternIDs)
+	attempts, err := s.repo.ListAttemptsForProblem(ctx, repo.ListAttemptsForProblemParams{
+		UserID:    userID,
+		ProblemID: problemID,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to list attempts", "problem_id", problemID, "error", err)
+		return result, nil
+	}
+	for _, attempt := range attempts {
+		if len(result.RecentAttempts) >= recentAttemptsLimit {
+			break
+		}
+		// Skip attempts that never finished
+		if attempt.Status.Valid && (attempt.Status.String == "in_progress" || attempt.Status.String == "abandoned") {
+			continue
+		}
+		result.RecentAttempts = append(result.RecentAttempts, RecentAttempt{
+			ID:              attempt.ID.String(),
+			SessionID:       pgtypeUUIDToPtr(attempt.SessionID),
+			Outcome:         pgtypeTextToPtr(attempt.Outcome),
+			ConfidenceScore: pgInt4ToPtr(attempt.ConfidenceScore),
+			DurationSeconds: pgInt4ToPtr(attempt.DurationSeconds),
+			Notes:           pgtypeTextToPtr(attempt.Notes),
+			PerformedAt:     pgtypeTimestamptzToPtr(attempt.PerformedAt),
+		})
+	}
+
+	return result, nil
+}
+
+func (s *problemService) UpdateProblem(ctx context.Context, problemID uuid.UUID, body UpdateProblemBody) (*ProblemWithStats, error) {
+	// Pattern links are replaced wholesale, so a failure must not leave them deleted
+	var problem repo.Problem
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		problem, err = q.UpdateProblem(ctx, repo.UpdateProblemParams{
+			ID:         problemID,
+			Title:      body.Title,
+			Source:     pgtypeText(body.Source),
+			Url:        pgtypeText(body.URL),
+			Difficulty: pgtypeText(&body.Difficulty),
+		})
 		if err != nil {
-			return nil, fmt.Errorf("invalid pattern ID: %w", err)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrProblemNotFound
+			}
+			return fmt.Errorf("failed to update problem: %w", err)
 		}
-		if err := s.LinkProblemToPatterns(ctx, problemID, patternUUIDs); err != nil {
-			return nil, fmt.Errorf("failed to link patterns: %w", err)
+
+		// Update pattern links
+		if err := q.DeleteProblemPatterns(ctx, problemID); err != nil {
+			return fmt.Errorf("failed to delete old patterns: %w", err)
+		}
+
+		if len(body.PatternIDs) > 0 {
+			patternUUIDs, err := parseUUIDs(body.PatternIDs)
+			if err != nil {
+				return fmt.Errorf("invalid pattern ID: %w", err)
+			}
+			if err := linkProblemToPatterns(ctx, q, problemID, patternUUIDs); err != nil {
+				return fmt.Errorf("failed to link patterns: %w", err)
+			}
 		}
+
+		// Company tags are only replaced when the body has them
+		if body.CompanyIDs != nil {
+			companyUUIDs, err := parseUUIDs(body.CompanyIDs)
+			if err != nil {
+				return fmt.Errorf("invalid company ID: %w", err)
+			}
+			if err := q.DeleteProblemCompanies(ctx, problemID); err != nil {
+				return fmt.Errorf("failed to delete old companies: %w", err)
+			}
+			if err := linkProblemToCompanies(ctx, q, problemID, companyUUIDs); err != nil {
+				return fmt.Errorf("failed to link companies: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Difficulty and patterns feed into scoring, so cached scores are now stale
+	if err := s.scoringService.InvalidateProblemScores(ctx, problemID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to invalidate cached scores", "error", err)
 	}
 
 	// Fetch patterns for the updated problem
@@ -163,11 +352,328 @@ func (s *problemService) UpdateProblem(ctx context.Context, problemID uuid.UUID,
 		Difficulty: pgtypeTextToStr(problem.Difficulty, "medium"),
 		CreatedAt:  problem.CreatedAt.Time.Format(time.RFC3339),
 		Patterns:   convertPatternsFromRepo(patterns),
+		Companies:  s.getCompaniesForProblem(ctx, problemID),
 	}, nil
 }
 
 func (s *problemService) DeleteProblem(ctx context.Context, problemID uuid.UUID) error {
-	return s.repo.DeleteProblem(ctx, problemID)
+	deleted, err := s.repo.DeleteProblem(ctx, problemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete problem: %w", err)
+	}
+	if deleted == 0 {
+		return ErrProblemNotFound
+	}
+	return nil
+}
+
+// BulkDeleteProblems deletes problems in one transaction. Pattern links, stats
+// and attempts go with them via ON DELETE CASCADE. Problems still planned in an
+// unfinished session block the delete unless force is set, in which case they
+// are removed from those sessions' items_ordered as well.
+func (s *problemService) BulkDeleteProblems(ctx context.Context, problemIDs []uuid.UUID, force bool) (*BulkDeleteResult, error) {
+	idStrs := make([]string, 0, len(problemIDs))
+	toDelete := make(map[string]bool, len(problemIDs))
+	for _, id := range problemIDs {
+		idStrs = append(idStrs, id.String())
+		toDelete[id.String()] = true
+	}
+
+	var (
+		sessions   []repo.RevisionSession
+		deletedIDs []uuid.UUID
+	)
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var err error
+		sessions, err = q.GetActiveSessionsContainingProblems(ctx, idStrs)
+		if err != nil {
+			return fmt.Errorf("failed to check active sessions: %w", err)
+		}
+
+		if len(sessions) > 0 && !force {
+			inUse := &ProblemsInUseError{SessionIDs: make([]string, 0, len(sessions))}
+			for _, session := range sessions {
+				inUse.SessionIDs = append(inUse.SessionIDs, session.ID.String())
+			}
+			return inUse
+		}
+
+		// Strip the doomed problems out of each affected session
+		for _, session := range sessions {
+			var items []string
+			if err := json.Unmarshal([]byte(session.ItemsOrdered.String), &items); err != nil {
+				return fmt.Errorf("failed to parse items for session %s: %w", session.ID, err)
+			}
+
+			remaining := make([]string, 0, len(items))
+			for _, item := range items {
+				if !toDelete[item] {
+					remaining = append(remaining, item)
+				}
+			}
+
+			remainingJSON, err := json.Marshal(remaining)
+			if err != nil {
+				return fmt.Errorf("failed to marshal items for session %s: %w", session.ID, err)
+			}
+
+			if err := q.UpdateSessionOrder(ctx, repo.UpdateSessionOrderParams{
+				ItemsOrdered: pgtype.Text{String: string(remainingJSON), Valid: true},
+				ID:           session.ID,
+				UserID:       session.UserID,
+			}); err != nil {
+				return fmt.Errorf("failed to update session %s: %w", session.ID, err)
+			}
+		}
+
+		deletedIDs, err = q.DeleteProblemsByIDs(ctx, problemIDs)
+		if err != nil {
+			return fmt.Errorf("failed to delete problems: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[uuid.UUID]bool, len(deletedIDs))
+	for _, id := range deletedIDs {
+		deleted[id] = true
+	}
+
+	notFound := make([]string, 0)
+	for _, id := range problemIDs {
+		if !deleted[id] {
+			notFound = append(notFound, id.String())
+		}
+	}
+
+	return &BulkDeleteResult{
+		Deleted:         len(deletedIDs),
+		NotFound:        notFound,
+		SessionsUpdated: len(sessions),
+	}, nil
+}
+
+// BulkUpdateProblems applies the same partial patch to many problems inside a
+// single transaction. Each problem runs in its own savepoint so one failure
+// doesn't roll back the rest. With DryRun nothing is written.
+func (s *problemService) BulkUpdateProblems(ctx context.Context, body BulkUpdateProblemsBody) (*BulkUpdateResult, error) {
+	problemIDs, err := parseUUIDs(body.ProblemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid problem ID: %w", err)
+	}
+	addIDs, err := parseUUIDs(body.AddPatternIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern ID: %w", err)
+	}
+	removeIDs, err := parseUUIDs(body.RemovePatternIDs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern ID: %w", err)
+	}
+
+	result := &BulkUpdateResult{
+		DryRun:  body.DryRun,
+		Results: make([]BulkUpdateItemResult, 0, len(problemIDs)),
+	}
+	changed := make([]uuid.UUID, 0, len(problemIDs))
+
+	err = s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		for _, problemID := range problemIDs {
+			item := BulkUpdateItemResult{ProblemID: problemID.String()}
+
+			// The nested transaction is a savepoint, so a failed problem only
+			// rolls back its own changes
+			var changes *ProblemChanges
+			err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+				var err error
+				changes, err = s.applyProblemPatch(ctx, q, problemID, body, addIDs, removeIDs)
+				return err
+			})
+			if err != nil {
+				msg := err.Error()
+				item.Error = &msg
+				result.Failed++
+			} else {
+				item.Success = true
+				item.Changes = changes
+				result.Succeeded++
+				changed = append(changed, problemID)
+			}
+
+			result.Results = append(result.Results, item)
+		}
+
+		if body.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Difficulty and patterns feed into scoring
+	for _, problemID := range changed {
+		if err := s.scoringService.InvalidateProblemScores(ctx, problemID); err != nil {
+			logging.FromContext(ctx).Warn("Failed to invalidate cached scores", "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+// BulkLinkPattern attaches one pattern to many problems, skipping problems that
+// already have it. It's a bulk update that only adds a pattern.
+func (s *problemService) BulkLinkPattern(ctx context.Context, body BulkLinkPatternBody) (*BulkUpdateResult, error) {
+	patternID, err := uuid.Parse(body.PatternID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern ID: %w", err)
+	}
+	if _, err := s.repo.GetPattern(ctx, patternID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrPatternNotFound
+		}
+		return nil, fmt.Errorf("failed to get pattern: %w", err)
+	}
+
+	return s.BulkUpdateProblems(ctx, BulkUpdateProblemsBody{
+		ProblemIDs:    body.ProblemIDs,
+		AddPatternIDs: []string{body.PatternID},
+	})
+}
+
+func (s *problemService) GetUnpatternedProblems(ctx context.Context, limit, offset int32) (*PaginatedProblems, error) {
+	count, err := s.repo.CountUnpatternedProblems(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count unpatterned problems: %w", err)
+	}
+
+	rows, err := s.repo.GetUnpatternedProblems(ctx, repo.GetUnpatternedProblemsParams{
+		LimitVal:  limit,
+		OffsetVal: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpatterned problems: %w", err)
+	}
+
+	problemIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		problemIDs = append(problemIDs, row.ID)
+	}
+	companiesByProblem := s.getCompaniesForProblems(ctx, problemIDs)
+
+	problems := make([]ProblemWithStats, 0, len(rows))
+	for _, row := range rows {
+		problems = append(problems, ProblemWithStats{
+			ID:         row.ID.String(),
+			Title:      row.Title,
+			Source:     pgtypeTextToPtr(row.Source),
+			URL:        pgtypeTextToPtr(row.Url),
+			Difficulty: pgtypeTextToStr(row.Difficulty, "medium"),
+			CreatedAt:  row.CreatedAt.Time.Format(time.RFC3339),
+			Patterns:   []Pattern{},
+			Companies:  companiesOrEmpty(companiesByProblem[row.ID]),
+		})
+	}
+
+	return &PaginatedProblems{
+		Data:       problems,
+		Total:      count,
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		TotalPages: (int32(count) + limit - 1) / limit,
+	}, nil
+}
+
+// applyProblemPatch works out the changes for one problem and, unless this is
+// a dry run, writes them through the given (transactional) queries
+func (s *problemService) applyProblemPatch(
+	ctx context.Context,
+	q repo.Querier,
+	problemID uuid.UUID,
+	body BulkUpdateProblemsBody,
+	addIDs, removeIDs []uuid.UUID,
+) (*ProblemChanges, error) {
+	problem, err := q.GetProblem(ctx, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("problem not found")
+	}
+
+	existing, err := q.GetPatternsForProblem(ctx, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get patterns: %w", err)
+	}
+	linked := make(map[uuid.UUID]bool, len(existing))
+	for _, pattern := range existing {
+		linked[pattern.ID] = true
+	}
+
+	changes := &ProblemChanges{
+		AddedPatternIDs:   make([]string, 0),
+		RemovedPatternIDs: make([]string, 0),
+	}
+
+	if body.Difficulty != nil && *body.Difficulty != problem.Difficulty.String {
+		changes.DifficultyFrom = pgtypeTextToPtr(problem.Difficulty)
+		changes.DifficultyTo = body.Difficulty
+	}
+	if body.Source != nil && *body.Source != problem.Source.String {
+		changes.SourceFrom = pgtypeTextToPtr(problem.Source)
+		changes.SourceTo = body.Source
+	}
+
+	// Only link patterns that aren't already linked
+	toAdd := make([]uuid.UUID, 0, len(addIDs))
+	for _, patternID := range addIDs {
+		if !linked[patternID] {
+			toAdd = append(toAdd, patternID)
+			linked[patternID] = true
+			changes.AddedPatternIDs = append(changes.AddedPatternIDs, patternID.String())
+		}
+	}
+	for _, patternID := range removeIDs {
+		if linked[patternID] {
+			changes.RemovedPatternIDs = append(changes.RemovedPatternIDs, patternID.String())
+		}
+	}
+
+	if body.DryRun {
+		return changes, nil
+	}
+
+	if changes.DifficultyTo != nil || changes.SourceTo != nil {
+		if _, err := q.UpdateProblemFields(ctx, repo.UpdateProblemFieldsParams{
+			Difficulty: pgtypeText(changes.DifficultyTo),
+			Source:     pgtypeText(changes.SourceTo),
+			ID:         problemID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update problem: %w", err)
+		}
+	}
+
+	for _, patternID := range toAdd {
+		if err := q.LinkProblemToPatternIfNotExists(ctx, repo.LinkProblemToPatternIfNotExistsParams{
+			ProblemID: problemID,
+			PatternID: patternID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to link pattern %s: %w", patternID, err)
+		}
+	}
+
+	for _, patternID := range removeIDs {
+		if err := q.UnlinkProblemFromPattern(ctx, repo.UnlinkProblemFromPatternParams{
+			ProblemID: problemID,
+			PatternID: patternID,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to unlink pattern %s: %w", patternID, err)
+		}
+	}
+
+	return changes, nil
 }
 
 func (s *problemService) ListProblemsForUser(ctx context.Context, userID uuid.UUID) ([]ProblemWithStats, error) {
@@ -176,13 +682,18 @@ func (s *problemService) ListProblemsForUser(ctx context.Context, userID uuid.UU
 		return nil, fmt.Errorf("failed to list problems: %w", err)
 	}
 
+	leechThreshold := s.scoringService.GetLeechThreshold(ctx)
+
+	problemIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		problemIDs = append(problemIDs, row.ID)
+	}
+	patternsByProblem := s.getPatternsForProblems(ctx, problemIDs)
+	companiesByProblem := s.getCompaniesForProblems(ctx, problemIDs)
+
 	problems := make([]ProblemWithStats, 0, len(rows))
 	for _, row := range rows {
-		// Fetch patterns for each problem
-		patterns, err := s.repo.GetPatternsForProblem(ctx, row.ID)
-		if err != nil {
-			patterns = []repo.Pattern{}
-		}
+		patterns := patternsByProblem[row.ID]
 
 		problem := ProblemWithStats{
 			ID:         row.ID.String(),
@@ -192,6 +703,9 @@ func (s *problemService) ListProblemsForUser(ctx context.Context, userID uuid.UU
 			Difficulty: pgtypeTextToStr(row.Difficulty, "medium"),
 			CreatedAt:  row.CreatedAt.Time.Format(time.RFC3339),
 			Patterns:   convertPatternsFromRepo(patterns),
+			Companies:  companiesOrEmpty(companiesByProblem[row.ID]),
+			IsLeech:    scoring.IsLeech(row.ConsecutiveFailures, leechThreshold),
+			IsStarred:  row.IsStarred,
 		}
 
 		// Add stats if they exist
@@ -205,6 +719,8 @@ func (s *problemService) ListProblemsForUser(ctx context.Context, userID uuid.UU
 				LastAttemptAt: pgtypeTimestamptzToPtr(row.LastAttemptAt),
 				TotalAttempts: row.TotalAttempts.Int32,
 				LastOutcome:   pgtypeTextToPtr(row.LastOutcome),
+				Notes:         pgtypeTextToPtr(row.Notes),
+				SnoozedUntil:  activeSnooze(row.SnoozedUntil),
 				UpdatedAt:     row.UpdatedAt.Time.Format(time.RFC3339),
 			}
 		}
@@ -218,36 +734,80 @@ func (s *problemService) ListProblemsForUser(ctx context.Context, userID uuid.UU
 func (s *problemService) SearchProblemsForUser(ctx context.Context, userID uuid.UUID, params SearchProblemsParams) (*PaginatedProblems, error) {
 	// Get total count
 	countRow, err := s.repo.CountProblemsForUser(ctx, repo.CountProblemsForUserParams{
-		UserID:      userID,
-		SearchQuery: params.Query,
-		Difficulty:  params.Difficulty,
-		Status:      params.Status,
+		UserID:        userID,
+		SearchQuery:   params.Query,
+		Difficulty:    params.Difficulty,
+		Source:        params.Source,
+		Status:        params.Status,
+		NotesContains: params.NotesContains,
+		PatternIds:    params.PatternIDs,
+		CompanyID:     pgtypeUUIDFromPtr(params.CompanyID),
+		StarredOnly:   params.StarredOnly,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to count problems: %w", err)
 	}
 
+	// Scores aren't stored on the rows, so score sorting fetches every match
+	// and paginates in memory after ordering
+	limit, offset := params.Limit, params.Offset
+	if params.SortBy == SortScoreDesc {
+		limit, offset = int32(countRow), 0
+	}
+
 	// Get paginated results
 	rows, err := s.repo.SearchProblemsForUser(ctx, repo.SearchProblemsForUserParams{
-		UserID:      userID,
-		SearchQuery: params.Query,
-		Difficulty:  params.Difficulty,
-		Status:      params.Status,
-		LimitVal:    params.Limit,
-		OffsetVal:   params.Offset,
+		UserID:        userID,
+		SearchQuery:   params.Query,
+		Difficulty:    params.Difficulty,
+		Source:        params.Source,
+		Status:        params.Status,
+		NotesContains: params.NotesContains,
+		PatternIds:    params.PatternIDs,
+		CompanyID:     pgtypeUUIDFromPtr(params.CompanyID),
+		StarredOnly:   params.StarredOnly,
+		SortBy:        params.SortBy,
+		LimitVal:      limit,
+		OffsetVal:     offset,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search problems: %w", err)
 	}
 
-	problems := make([]ProblemWithStats, 0, len(rows))
-	for _, row := range rows {
-		// Fetch patterns for each problem
-		patterns, err := s.repo.GetPatternsForProblem(ctx, row.ID)
+	var scoresByProblem map[uuid.UUID]scoring.ProblemScore
+	if params.SortBy == SortScoreDesc {
+		scores, err := s.scoringService.ComputeScoresForUser(ctx, userID)
 		if err != nil {
-			patterns = []repo.Pattern{}
+			return nil, fmt.Errorf("failed to compute scores: %w", err)
+		}
+		scoresByProblem = make(map[uuid.UUID]scoring.ProblemScore, len(scores))
+		for _, score := range scores {
+			scoresByProblem[score.ProblemID] = score
 		}
 
+		// Unscored problems (never attempted, retired, snoozed) sort last
+		sort.SliceStable(rows, func(i, j int) bool {
+			return scoresByProblem[rows[i].ID].Score > scoresByProblem[rows[j].ID].Score
+		})
+
+		start := min(int(params.Offset), len(rows))
+		end := min(start+int(params.Limit), len(rows))
+		rows = rows[start:end]
+	}
+
+	leechThreshold := s.scoringService.GetLeechThreshold(ctx)
+
+	problemIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		problemIDs = append(problemIDs, row.ID)
+	}
+	patternsByProblem := s.getPatternsForProblems(ctx, problemIDs)
+	companiesByProblem := s.getCompaniesForProblems(ctx, problemIDs)
+
+	problems := make([]ProblemWithStats, 0, len(rows))
+	for _, row := range rows {
+		patterns := patternsByProblem[row.ID]
+
 		problem := ProblemWithStats{
 			ID:         row.ID.String(),
 			Title:      row.Title,
@@ -256,6 +816,14 @@ func (s *problemService) SearchProblemsForUser(ctx context.Context, userID uuid.
 			Difficulty: pgtypeTextToStr(row.Difficulty, "medium"),
 			CreatedAt:  row.CreatedAt.Time.Format(time.RFC3339),
 			Patterns:   convertPatternsFromRepo(patterns),
+			Companies:  companiesOrEmpty(companiesByProblem[row.ID]),
+			IsLeech:    scoring.IsLeech(row.ConsecutiveFailures, leechThreshold),
+			IsStarred:  row.IsStarred,
+		}
+
+		if score, ok := scoresByProblem[row.ID]; ok {
+			problem.Score = &score.Score
+			problem.Reason = &score.Reason
 		}
 
 		// Add stats if they exist
@@ -269,6 +837,8 @@ func (s *problemService) SearchProblemsForUser(ctx context.Context, userID uuid.
 				LastAttemptAt: pgtypeTimestamptzToPtr(row.LastAttemptAt),
 				TotalAttempts: row.TotalAttempts.Int32,
 				LastOutcome:   pgtypeTextToPtr(row.LastOutcome),
+				Notes:         pgtypeTextToPtr(row.Notes),
+				SnoozedUntil:  activeSnooze(row.SnoozedUntil),
 				UpdatedAt:     row.UpdatedAt.Time.Format(time.RFC3339),
 			}
 		}
@@ -292,9 +862,15 @@ func (s *problemService) SearchProblemsForUser(ctx context.Context, userID uuid.
 	}, nil
 }
 
-func (s *problemService) GetUrgentProblems(ctx context.Context, userID uuid.UUID, limit int32) ([]UrgentProblem, error) {
-	// Get all scored problems using the scoring service
-	scores, err := s.scoringService.ComputeScoresForUser(ctx, userID)
+func (s *problemService) GetUrgentProblems(ctx context.Context, userID uuid.UUID, limit int32, force bool) ([]UrgentProblem, error) {
+	// Get all scored problems using the scoring service (force bypasses the score cache)
+	var scores []scoring.ProblemScore
+	var err error
+	if force {
+		scores, err = s.scoringService.RecomputeScoresForUser(ctx, userID)
+	} else {
+		scores, err = s.scoringService.ComputeScoresForUser(ctx, userID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute scores: %w", err)
 	}
@@ -351,9 +927,576 @@ func (s *problemService) GetUrgentProblems(ctx context.Context, userID uuid.UUID
 	return problems, nil
 }
 
+func (s *problemService) GetLeechProblems(ctx context.Context, userID uuid.UUID) (*LeechListResponse, error) {
+	threshold := s.scoringService.GetLeechThreshold(ctx)
+
+	rows, err := s.repo.GetLeechProblems(ctx, repo.GetLeechProblemsParams{
+		UserID:    userID,
+		Threshold: pgtype.Int4{Int32: int32(threshold), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leech problems: %w", err)
+	}
+
+	problems := make([]LeechProblem, 0, len(rows))
+	for _, row := range rows {
+		problems = append(problems, LeechProblem{
+			ID:                  row.ProblemID.String(),
+			Title:               row.Title,
+			Difficulty:          pgtypeTextToStr(row.Difficulty, "medium"),
+			Source:              pgtypeTextToPtr(row.Source),
+			URL:                 pgtypeTextToPtr(row.Url),
+			ConsecutiveFailures: row.ConsecutiveFailures.Int32,
+			TotalAttempts:       row.TotalAttempts.Int32,
+			Confidence:          row.Confidence.Int32,
+			LastAttemptAt:       pgtypeTimestamptzToPtr(row.LastAttemptAt),
+			NextReviewAt:        pgtypeTimestamptzToPtr(row.NextReviewAt),
+		})
+	}
+
+	return &LeechListResponse{
+		Threshold: threshold,
+		Problems:  problems,
+	}, nil
+}
+
+// GetDueProblems lists problems by next_review_at without running the scorer.
+// window is one of "overdue" (due before now), "today" (due before end of day)
+// or "week" (due within the next 7 days); overdue items are always included.
+// Days end at midnight in loc.
+func (s *problemService) GetDueProblems(ctx context.Context, userID uuid.UUID, window string, loc *time.Location, limit, offset int32) (*PaginatedDueProblems, error) {
+	now := time.Now().In(loc)
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	var dueBefore time.Time
+	switch window {
+	case "overdue":
+		dueBefore = now
+	case "today":
+		dueBefore = endOfToday
+	case "week":
+		dueBefore = endOfToday.AddDate(0, 0, 6)
+	default:
+		return nil, fmt.Errorf("invalid window: %s", window)
+	}
+	cutoff := pgtype.Timestamptz{Time: dueBefore, Valid: true}
+
+	total, err := s.repo.CountDueProblems(ctx, repo.CountDueProblemsParams{
+		UserID:    userID,
+		DueBefore: cutoff,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count due problems: %w", err)
+	}
+
+	rows, err := s.repo.GetDueProblems(ctx, repo.GetDueProblemsParams{
+		UserID:    userID,
+		DueBefore: cutoff,
+		LimitVal:  limit,
+		OffsetVal: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due problems: %w", err)
+	}
+
+	problems := make([]DueProblem, 0, len(rows))
+	for _, row := range rows {
+		daysOverdue := 0
+		if row.NextReviewAt.Time.Before(now) {
+			daysOverdue = int(now.Sub(row.NextReviewAt.Time).Hours() / 24)
+		}
+
+		problems = append(problems, DueProblem{
+			ID:           row.ProblemID.String(),
+			Title:        row.Title,
+			Difficulty:   pgtypeTextToStr(row.Difficulty, "medium"),
+			Source:       pgtypeTextToPtr(row.Source),
+			URL:          pgtypeTextToPtr(row.Url),
+			Status:       pgtypeTextToStr(row.Status, "unsolved"),
+			Confidence:   row.Confidence.Int32,
+			DueAt:        row.NextReviewAt.Time.Format(time.RFC3339),
+			DaysOverdue:  daysOverdue,
+			IntervalDays: row.IntervalDays.Int32,
+		})
+	}
+
+	page := offset/limit + 1
+	totalPages := (int32(total) + limit - 1) / limit
+
+	return &PaginatedDueProblems{
+		Window:     window,
+		Data:       problems,
+		Total:      total,
+		Page:       page,
+		PageSize:   limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+var (
+	breakdownDifficulties = []string{"easy", "medium", "hard"}
+	breakdownStatuses     = []string{"unsolved", "solved", "mastered", "abandoned"}
+)
+
+// GetProblemBreakdown counts the user's problems by difficulty and status,
+// overall and per source
+func (s *problemService) GetProblemBreakdown(ctx context.Context, userID uuid.UUID) (*ProblemBreakdown, error) {
+	rows, err := s.repo.GetProblemBreakdown(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get problem breakdown: %w", err)
+	}
+
+	breakdown := &ProblemBreakdown{
+		Matrix:           newBreakdownMatrix(),
+		DifficultyTotals: make(map[string]int64, len(breakdownDifficulties)),
+		StatusTotals:     make(map[string]int64, len(breakdownStatuses)),
+		Sources:          []SourceBreakdown{},
+	}
+	for _, difficulty := range breakdownDifficulties {
+		breakdown.DifficultyTotals[difficulty] = 0
+	}
+	for _, status := range breakdownStatuses {
+		breakdown.StatusTotals[status] = 0
+	}
+
+	// Rows are ordered by source, so each source's rows are contiguous
+	var current *SourceBreakdown
+	for _, row := range rows {
+		if current == nil || current.Source != row.Source {
+			breakdown.Sources = append(breakdown.Sources, SourceBreakdown{
+				Source: row.Source,
+				Matrix: newBreakdownMatrix(),
+			})
+			current = &breakdown.Sources[len(breakdown.Sources)-1]
+		}
+
+		if _, ok := breakdown.Matrix[row.Difficulty]; !ok {
+			breakdown.Matrix[row.Difficulty] = map[string]int64{}
+		}
+		if _, ok := current.Matrix[row.Difficulty]; !ok {
+			current.Matrix[row.Difficulty] = map[string]int64{}
+		}
+
+		breakdown.Matrix[row.Difficulty][row.Status] += row.Count
+		current.Matrix[row.Difficulty][row.Status] += row.Count
+		breakdown.DifficultyTotals[row.Difficulty] += row.Count
+		breakdown.StatusTotals[row.Status] += row.Count
+		breakdown.Total += row.Count
+		current.Total += row.Count
+	}
+
+	return breakdown, nil
+}
+
+func newBreakdownMatrix() BreakdownMatrix {
+	matrix := make(BreakdownMatrix, len(breakdownDifficulties))
+	for _, difficulty := range breakdownDifficulties {
+		matrix[difficulty] = make(map[string]int64, len(breakdownStatuses))
+		for _, status := range breakdownStatuses {
+			matrix[difficulty][status] = 0
+		}
+	}
+	return matrix
+}
+
+// GetRandomProblem picks one problem uniformly from those matching the search
+// filters. A seed makes the pick reproducible for the same matching set.
+func (s *problemService) GetRandomProblem(ctx context.Context, userID uuid.UUID, params SearchProblemsParams, seed *int64) (*ProblemWithStats, error) {
+	count, err := s.repo.CountProblemsForUser(ctx, repo.CountProblemsForUserParams{
+		UserID:        userID,
+		SearchQuery:   params.Query,
+		Difficulty:    params.Difficulty,
+		Source:        params.Source,
+		Status:        params.Status,
+		NotesContains: params.NotesContains,
+		PatternIds:    params.PatternIDs,
+		CompanyID:     pgtypeUUIDFromPtr(params.CompanyID),
+		StarredOnly:   params.StarredOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count problems: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrProblemNotFound
+	}
+
+	var offset int64
+	if seed != nil {
+		offset = rand.New(rand.NewSource(*seed)).Int63n(count)
+	} else {
+		offset = rand.Int63n(count)
+	}
+
+	// Skip to the chosen row instead of loading the whole matching set
+	rows, err := s.repo.SearchProblemsForUser(ctx, repo.SearchProblemsForUserParams{
+		UserID:        userID,
+		SearchQuery:   params.Query,
+		Difficulty:    params.Difficulty,
+		Source:        params.Source,
+		Status:        params.Status,
+		NotesContains: params.NotesContains,
+		PatternIds:    params.PatternIDs,
+		CompanyID:     pgtypeUUIDFromPtr(params.CompanyID),
+		StarredOnly:   params.StarredOnly,
+		LimitVal:      1,
+		OffsetVal:     int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pick random problem: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrProblemNotFound
+	}
+
+	return s.GetProblem(ctx, userID, rows[0].ID)
+}
+
+// GetRelatedProblems ranks problems sharing patterns with the given one by how
+// many patterns they share, breaking ties by urgency score
+func (s *problemService) GetRelatedProblems(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, limit int) ([]RelatedProblem, error) {
+	if _, err := s.repo.GetProblem(ctx, problemID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProblemNotFound
+		}
+		return nil, fmt.Errorf("failed to get problem: %w", err)
+	}
+
+	rows, err := s.repo.GetRelatedProblems(ctx, repo.GetRelatedProblemsParams{
+		UserID:    userID,
+		ProblemID: problemID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get related problems: %w", err)
+	}
+
+	scores, err := s.scoringService.ComputeScoresForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute scores: %w", err)
+	}
+	scoreByProblem := make(map[uuid.UUID]float64, len(scores))
+	for _, score := range scores {
+		scoreByProblem[score.ProblemID] = score.Score
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].SharedCount != rows[j].SharedCount {
+			return rows[i].SharedCount > rows[j].SharedCount
+		}
+		return scoreByProblem[rows[i].ID] > scoreByProblem[rows[j].ID]
+	})
+
+	related := make([]RelatedProblem, 0, min(limit, len(rows)))
+	for i := 0; i < len(rows) && i < limit; i++ {
+		row := rows[i]
+		related = append(related, RelatedProblem{
+			ID:                 row.ID.String(),
+			Title:              row.Title,
+			Difficulty:         pgtypeTextToStr(row.Difficulty, "medium"),
+			SharedPatterns:     row.SharedPatterns,
+			SharedPatternCount: row.SharedCount,
+			Confidence:         pgInt4ToPtr(row.Confidence),
+			Score:              scoreByProblem[row.ID],
+		})
+	}
+
+	return related, nil
+}
+
+// PreviewProblemURL builds a pre-filled problem from a LeetCode or Codeforces URL.
+// Upstream failures degrade to a title guessed from the URL rather than an error.
+func (s *problemService) PreviewProblemURL(ctx context.Context, rawURL string) (*URLPreview, error) {
+	parsed, err := ParseProblemURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSpace(rawURL)
+	preview := &URLPreview{
+		Platform: parsed.Platform,
+		Slug:     parsed.Slug,
+		Problem: CreateProblemBody{
+			Title:      titleFromSlug(parsed.Slug),
+			Source:     strPtr(platformSources[parsed.Platform]),
+			URL:        &url,
+			Difficulty: "medium",
+			PatternIDs: []string{},
+		},
+		UnmatchedTags: []string{},
+	}
+
+	metadata, err := s.fetcher.Fetch(ctx, parsed)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to fetch metadata", "url", rawURL, "error", err)
+		return preview, nil
+	}
+
+	preview.Fetched = true
+	preview.Problem.Title = metadata.Title
+	if metadata.Difficulty == "easy" || metadata.Difficulty == "medium" || metadata.Difficulty == "hard" {
+		preview.Problem.Difficulty = metadata.Difficulty
+	}
+
+	// Match topic tags to existing patterns by title
+	patterns, err := s.repo.ListPatterns(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to list patterns", "error", err)
+		preview.UnmatchedTags = metadata.Tags
+		return preview, nil
+	}
+	patternsByTitle := make(map[string]string, len(patterns))
+	for _, pattern := range patterns {
+		patternsByTitle[strings.ToLower(pattern.Title)] = pattern.ID.String()
+	}
+	for _, tag := range metadata.Tags {
+		if id, ok := patternsByTitle[strings.ToLower(tag)]; ok {
+			preview.Problem.PatternIDs = append(preview.Problem.PatternIDs, id)
+		} else {
+			preview.UnmatchedTags = append(preview.UnmatchedTags, tag)
+		}
+	}
+
+	return preview, nil
+}
+
+var platformSources = map[string]string{
+	PlatformLeetCode:   "LeetCode",
+	PlatformCodeforces: "Codeforces",
+}
+
+func (s *problemService) SetProblemStarred(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, starred bool) error {
+	if err := s.ensureProblemExists(ctx, problemID); err != nil {
+		return err
+	}
+
+	if starred {
+		return s.repo.StarProblem(ctx, repo.StarProblemParams{UserID: userID, ProblemID: problemID})
+	}
+	return s.repo.UnstarProblem(ctx, repo.UnstarProblemParams{UserID: userID, ProblemID: problemID})
+}
+
+// ensureProblemExists returns ErrProblemNotFound for an unknown problem, so
+// per-user upserts report a 404 rather than a foreign key violation
+func (s *problemService) ensureProblemExists(ctx context.Context, problemID uuid.UUID) error {
+	if _, err := s.repo.GetProblem(ctx, problemID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProblemNotFound
+		}
+		return fmt.Errorf("failed to get problem: %w", err)
+	}
+	return nil
+}
+
+// UpdateProblemNotes sets (or clears, with nil/empty) the user's note for a problem
+func (s *problemService) UpdateProblemNotes(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, notes *string) (*Stats, error) {
+	if notes != nil && *notes == "" {
+		notes = nil
+	}
+
+	if err := s.ensureProblemExists(ctx, problemID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.SetUserProblemNotes(ctx, repo.SetUserProblemNotesParams{
+		UserID:    userID,
+		ProblemID: problemID,
+		Notes:     pgtypeText(notes),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notes: %w", err)
+	}
+
+	return toStats(stats), nil
+}
+
+// UpdateProblemStatus marks a problem as mastered or abandoned, or with "active"
+// returns it to the solved/unsolved status its attempt history implies
+func (s *problemService) UpdateProblemStatus(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, status string) (*Stats, error) {
+	if err := s.ensureProblemExists(ctx, problemID); err != nil {
+		return nil, err
+	}
+
+	if status == StatusActive {
+		attempts, err := s.repo.ListAttemptsForProblem(ctx, repo.ListAttemptsForProblemParams{
+			UserID:    userID,
+			ProblemID: problemID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attempts: %w", err)
+		}
+
+		status = "unsolved"
+		for _, attempt := range attempts {
+			if attempt.Outcome.Valid && attempt.Outcome.String == "passed" {
+				status = "solved"
+				break
+			}
+		}
+	}
+
+	stats, err := s.repo.SetUserProblemStatus(ctx, repo.SetUserProblemStatusParams{
+		UserID:    userID,
+		ProblemID: problemID,
+		Status:    pgtypeText(&status),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update status: %w", err)
+	}
+
+	// Mastered and abandoned problems drop out of scoring, so the cached score is stale
+	if err := s.repo.DeleteProblemScore(ctx, repo.DeleteProblemScoreParams{
+		UserID:    userID,
+		ProblemID: problemID,
+	}); err != nil {
+		logging.FromContext(ctx).Warn("Failed to invalidate score", "problem_id", problemID, "error", err)
+	}
+
+	return toStats(stats), nil
+}
+
+// SnoozeProblem hides a problem from reviews for days days by pushing its due
+// date out. The SM-2 interval and ease are left alone, so once the snooze ends
+// the problem picks up where it was.
+func (s *problemService) SnoozeProblem(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, days int) (*Stats, error) {
+	if err := s.ensureProblemExists(ctx, problemID); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.repo.SnoozeUserProblem(ctx, repo.SnoozeUserProblemParams{
+		UserID:       userID,
+		ProblemID:    problemID,
+		SnoozedUntil: pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, days), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to snooze problem: %w", err)
+	}
+
+	return toStats(stats), nil
+}
+
+// CancelSnooze ends an active snooze and restores the due date it replaced
+func (s *problemService) CancelSnooze(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) (*Stats, error) {
+	stats, err := s.repo.CancelUserProblemSnooze(ctx, repo.CancelUserProblemSnoozeParams{
+		UserID:    userID,
+		ProblemID: problemID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotSnoozed
+		}
+		return nil, fmt.Errorf("failed to cancel snooze: %w", err)
+	}
+
+	return toStats(stats), nil
+}
+
+func toStats(stats repo.UserProblemStat) *Stats {
+	return &Stats{
+		ID:            stats.ID.String(),
+		UserID:        stats.UserID.String(),
+		ProblemID:     stats.ProblemID.String(),
+		Status:        pgtypeTextToStr(stats.Status, "unsolved"),
+		Confidence:    stats.Confidence.Int32,
+		AvgConfidence: stats.AvgConfidence.Int32,
+		LastAttemptAt: pgtypeTimestamptzToPtr(stats.LastAttemptAt),
+		TotalAttempts: stats.TotalAttempts.Int32,
+		LastOutcome:   pgtypeTextToPtr(stats.LastOutcome),
+		Notes:         pgtypeTextToPtr(stats.Notes),
+		SnoozedUntil:  activeSnooze(stats.SnoozedUntil),
+		UpdatedAt:     stats.UpdatedAt.Time.Format(time.RFC3339),
+	}
+}
+
+// activeSnooze formats snoozedUntil, or returns nil once the snooze has passed
+func activeSnooze(snoozedUntil pgtype.Timestamptz) *string {
+	if !snoozedUntil.Valid || !snoozedUntil.Time.After(time.Now()) {
+		return nil
+	}
+	return pgtypeTimestamptzToPtr(snoozedUntil)
+}
+
+func (s *problemService) ExplainProblemScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ScoreExplanation, error) {
+	explanation, err := s.scoringService.ExplainScore(ctx, userID, problemID, emphasis)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrProblemNotFound
+		}
+		return nil, fmt.Errorf("failed to explain score: %w", err)
+	}
+
+	f := explanation.Score.Features
+	w := explanation.Weights
+	terms := []struct {
+		key    string
+		name   string
+		value  float64
+		weight float64
+	}{
+		{"f_conf", "Confidence", f.FConf, w.WConf},
+		{"f_days", "Days until/since due", f.FDays, w.WDays},
+		{"f_attempts", "Attempt count", f.FAttempts, w.WAttempts},
+		{"f_time", "Solve time", f.FTime, w.WTime},
+		{"f_difficulty", "Difficulty", f.FDifficulty, w.WDifficulty},
+		{"f_failed", "Recent failure", f.FFailed, w.WFailed},
+		{"f_pattern", "Pattern weakness", f.FPattern, w.WPattern},
+	}
+
+	features := make([]FeatureContribution, 0, len(terms))
+	for _, t := range terms {
+		features = append(features, FeatureContribution{
+			Key:          t.key,
+			Name:         t.name,
+			Value:        t.value,
+			Weight:       t.weight,
+			Contribution: t.value * t.weight,
+		})
+	}
+
+	stats := explanation.Stats
+	inputs := ScoreInputs{
+		Confidence:     pgInt4ToPtr(stats.Confidence),
+		AvgConfidence:  pgInt4ToPtr(stats.AvgConfidence),
+		TotalAttempts:  stats.TotalAttempts.Int32,
+		AvgTimeSeconds: pgInt4ToPtr(stats.AvgTimeSeconds),
+		LastOutcome:    pgtypeTextToPtr(stats.LastOutcome),
+		LastAttemptAt:  pgtypeTimestamptzToPtr(stats.LastAttemptAt),
+		NextReviewAt:   pgtypeTimestamptzToPtr(stats.NextReviewAt),
+		IntervalDays:   pgInt4ToPtr(stats.IntervalDays),
+		ReviewCount:    stats.ReviewCount.Int32,
+	}
+	if stats.NextReviewAt.Valid {
+		daysOverdue := time.Since(stats.NextReviewAt.Time).Hours() / 24
+		inputs.DaysOverdue = &daysOverdue
+	}
+	if stats.EaseFactor.Valid {
+		inputs.EaseFactor = &stats.EaseFactor.Float32
+	}
+
+	return &ScoreExplanation{
+		ProblemID: problemID.String(),
+		Score:     explanation.Score.Score,
+		Reason:    explanation.Score.Reason,
+		Emphasis:  explanation.Emphasis,
+		Features:  features,
+		Weights: EffectiveWeights{
+			WConf:       w.WConf,
+			WDays:       w.WDays,
+			WAttempts:   w.WAttempts,
+			WTime:       w.WTime,
+			WDifficulty: w.WDifficulty,
+			WFailed:     w.WFailed,
+			WPattern:    w.WPattern,
+		},
+		Inputs: inputs,
+	}, nil
+}
+
 func (s *problemService) LinkProblemToPatterns(ctx context.Context, problemID uuid.UUID, patternIDs []uuid.UUID) error {
+	return linkProblemToPatterns(ctx, s.repo, problemID, patternIDs)
+}
+
+func linkProblemToPatterns(ctx context.Context, q repo.Querier, problemID uuid.UUID, patternIDs []uuid.UUID) error {
 	for _, patternID := range patternIDs {
-		if err := s.repo.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{
+		if err := q.LinkProblemToPattern(ctx, repo.LinkProblemToPatternParams{
 			ProblemID: problemID,
 			PatternID: patternID,
 		}); err != nil {
@@ -364,6 +1507,18 @@ func (s *problemService) LinkProblemToPatterns(ctx context.Context, problemID uu
 }
 
 // Helper functions
+func linkProblemToCompanies(ctx context.Context, q repo.Querier, problemID uuid.UUID, companyIDs []uuid.UUID) error {
+	for _, companyID := range companyIDs {
+		if err := q.LinkProblemToCompany(ctx, repo.LinkProblemToCompanyParams{
+			ProblemID: problemID,
+			CompanyID: companyID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func pgtypeText(s *string) pgtype.Text {
 	if s == nil {
 		return pgtype.Text{}
@@ -385,6 +1540,21 @@ func pgtypeTextToStr(t pgtype.Text, defaultVal string) string {
 	return t.String
 }
 
+func pgtypeUUIDToPtr(u pgtype.UUID) *string {
+	if !u.Valid {
+		return nil
+	}
+	s := uuid.UUID(u.Bytes).String()
+	return &s
+}
+
+func pgtypeUUIDFromPtr(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
+
 func pgtypeTimestamptzToPtr(t pgtype.Timestamptz) *string {
 	if !t.Valid {
 		return nil
@@ -393,6 +1563,13 @@ func pgtypeTimestamptzToPtr(t pgtype.Timestamptz) *string {
 	return &s
 }
 
+func pgInt4ToPtr(i pgtype.Int4) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int32
+}
+
 func strPtr(s string) *string {
 	return &s
 }
@@ -409,6 +1586,29 @@ func parseUUIDs(strs []string) ([]uuid.UUID, error) {
 	return uuids, nil
 }
 
+// getPatternsForProblems loads pattern links for a page of problems in one query
+func (s *problemService) getPatternsForProblems(ctx context.Context, problemIDs []uuid.UUID) map[uuid.UUID][]repo.Pattern {
+	patternsByProblem := make(map[uuid.UUID][]repo.Pattern, len(problemIDs))
+	if len(problemIDs) == 0 {
+		return patternsByProblem
+	}
+
+	rows, err := s.repo.GetPatternsForProblems(ctx, problemIDs)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get patterns for problems", "error", err)
+		return patternsByProblem
+	}
+
+	for _, row := range rows {
+		patternsByProblem[row.ProblemID] = append(patternsByProblem[row.ProblemID], repo.Pattern{
+			ID:          row.ID,
+			Title:       row.Title,
+			Description: row.Description,
+		})
+	}
+	return patternsByProblem
+}
+
 func convertPatternsFromRepo(rows []repo.Pattern) []Pattern {
 	patterns := make([]Pattern, 0, len(rows))
 	for _, row := range rows {
@@ -420,3 +1620,47 @@ func convertPatternsFromRepo(rows []repo.Pattern) []Pattern {
 	}
 	return patterns
 }
+
+func (s *problemService) getCompaniesForProblem(ctx context.Context, problemID uuid.UUID) []Company {
+	rows, err := s.repo.GetCompaniesForProblem(ctx, problemID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get companies for problem", "problem_id", problemID, "error", err)
+		return []Company{}
+	}
+
+	companies := make([]Company, 0, len(rows))
+	for _, row := range rows {
+		companies = append(companies, Company{ID: row.ID.String(), Name: row.Name})
+	}
+	return companies
+}
+
+// getCompaniesForProblems loads company tags for a page of problems in one query
+func (s *problemService) getCompaniesForProblems(ctx context.Context, problemIDs []uuid.UUID) map[uuid.UUID][]Company {
+	companiesByProblem := make(map[uuid.UUID][]Company, len(problemIDs))
+	if len(problemIDs) == 0 {
+		return companiesByProblem
+	}
+
+	rows, err := s.repo.GetCompaniesForProblems(ctx, problemIDs)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to get companies for problems", "error", err)
+		return companiesByProblem
+	}
+
+	for _, row := range rows {
+		companiesByProblem[row.ProblemID] = append(companiesByProblem[row.ProblemID], Company{
+			ID:   row.ID.String(),
+			Name: row.Name,
+		})
+	}
+	return companiesByProblem
+}
+
+// companiesOrEmpty keeps untagged problems' companies an empty list in JSON
+func companiesOrEmpty(companies []Company) []Company {
+	if companies == nil {
+		return []Company{}
+	}
+	return companies
+}
@@ -1,11 +1,17 @@
 package problems
 
+import "github.com/google/uuid"
+
 type CreateProblemBody struct {
 	Title      string   `json:"title"      validate:"required"`
 	Source     *string  `json:"source"     validate:"omitempty"`
 	URL        *string  `json:"url"        validate:"omitempty,url"`
 	Difficulty string   `json:"difficulty" validate:"required,oneof=easy medium hard"`
 	PatternIDs []string `json:"pattern_ids" validate:"omitempty,dive,uuid"`
+	CompanyIDs []string `json:"company_ids" validate:"omitempty,dive,uuid"`
+
+	// AllowDuplicate skips the check for an existing problem with the same URL
+	AllowDuplicate bool `json:"allow_duplicate"`
 }
 
 type UpdateProblemBody struct {
@@ -14,6 +20,59 @@ type UpdateProblemBody struct {
 	URL        *string  `json:"url"        validate:"omitempty,url"`
 	Difficulty string   `json:"difficulty" validate:"required,oneof=easy medium hard"`
 	PatternIDs []string `json:"pattern_ids" validate:"omitempty,dive,uuid"`
+	// CompanyIDs replaces the company tags; leaving it out keeps them as they are
+	CompanyIDs []string `json:"company_ids" validate:"omitempty,dive,uuid"`
+}
+
+// MaxBulkProblems caps how many problem IDs a single bulk request may touch
+const MaxBulkProblems = 500
+
+type BulkDeleteProblemsBody struct {
+	ProblemIDs []string `json:"problem_ids" validate:"required,min=1,max=500,dive,uuid"`
+}
+
+type BulkDeleteResult struct {
+	Deleted         int      `json:"deleted"`
+	NotFound        []string `json:"not_found"`
+	SessionsUpdated int      `json:"sessions_updated"`
+}
+
+type BulkUpdateProblemsBody struct {
+	ProblemIDs       []string `json:"problem_ids"        validate:"required,min=1,max=500,dive,uuid"`
+	Difficulty       *string  `json:"difficulty"         validate:"omitempty,oneof=easy medium hard"`
+	Source           *string  `json:"source"             validate:"omitempty"`
+	AddPatternIDs    []string `json:"add_pattern_ids"    validate:"omitempty,dive,uuid"`
+	RemovePatternIDs []string `json:"remove_pattern_ids" validate:"omitempty,dive,uuid"`
+	DryRun           bool     `json:"dry_run"`
+}
+
+type BulkLinkPatternBody struct {
+	PatternID  string   `json:"pattern_id"  validate:"required,uuid"`
+	ProblemIDs []string `json:"problem_ids" validate:"required,min=1,max=500,dive,uuid"`
+}
+
+type BulkUpdateResult struct {
+	DryRun    bool                   `json:"dry_run"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+	Results   []BulkUpdateItemResult `json:"results"`
+}
+
+type BulkUpdateItemResult struct {
+	ProblemID string          `json:"problem_id"`
+	Success   bool            `json:"success"`
+	Error     *string         `json:"error,omitempty"`
+	Changes   *ProblemChanges `json:"changes,omitempty"`
+}
+
+// ProblemChanges describes what a bulk update did (or would do, on dry run)
+type ProblemChanges struct {
+	DifficultyFrom    *string  `json:"difficulty_from,omitempty"`
+	DifficultyTo      *string  `json:"difficulty_to,omitempty"`
+	SourceFrom        *string  `json:"source_from,omitempty"`
+	SourceTo          *string  `json:"source_to,omitempty"`
+	AddedPatternIDs   []string `json:"added_pattern_ids"`
+	RemovedPatternIDs []string `json:"removed_pattern_ids"`
 }
 
 type ProblemWithStats struct {
@@ -25,8 +84,24 @@ type ProblemWithStats struct {
 	CreatedAt  string    `json:"created_at"`
 	Stats      *Stats    `json:"stats"`
 	Patterns   []Pattern `json:"patterns"`
+	Companies  []Company `json:"companies"`
 	Score      *float64  `json:"score,omitempty"`
 	Reason     *string   `json:"reason,omitempty"`
+	IsLeech    bool      `json:"is_leech"`
+	IsStarred  bool      `json:"is_starred"`
+
+	// Only populated on the single-problem detail endpoint
+	RecentAttempts []RecentAttempt `json:"recent_attempts,omitempty"`
+}
+
+type RecentAttempt struct {
+	ID              string  `json:"id"`
+	SessionID       *string `json:"session_id"`
+	Outcome         *string `json:"outcome"`
+	ConfidenceScore *int32  `json:"confidence_score"`
+	DurationSeconds *int32  `json:"duration_seconds"`
+	Notes           *string `json:"notes"`
+	PerformedAt     *string `json:"performed_at"`
 }
 
 type Stats struct {
@@ -39,15 +114,81 @@ type Stats struct {
 	LastAttemptAt *string `json:"last_attempt_at"`
 	TotalAttempts int32   `json:"total_attempts"`
 	LastOutcome   *string `json:"last_outcome"`
+	Notes         *string `json:"notes"`
+	SnoozedUntil  *string `json:"snoozed_until"` // Only while the snooze is active
 	UpdatedAt     string  `json:"updated_at"`
 }
 
+// Statuses a user can set directly; "active" clears a manual status
+const (
+	StatusMastered  = "mastered"
+	StatusAbandoned = "abandoned"
+	StatusActive    = "active"
+)
+
+type UpdateStatusBody struct {
+	Status string `json:"status" validate:"required,oneof=mastered abandoned active"`
+}
+
+// BreakdownMatrix maps difficulty -> status -> count, with every cell present
+type BreakdownMatrix map[string]map[string]int64
+
+type ProblemBreakdown struct {
+	Total            int64             `json:"total"`
+	Matrix           BreakdownMatrix   `json:"matrix"`
+	DifficultyTotals map[string]int64  `json:"difficulty_totals"`
+	StatusTotals     map[string]int64  `json:"status_totals"`
+	Sources          []SourceBreakdown `json:"sources"`
+}
+
+type SourceBreakdown struct {
+	Source string          `json:"source"`
+	Total  int64           `json:"total"`
+	Matrix BreakdownMatrix `json:"matrix"`
+}
+
+type RelatedProblem struct {
+	ID                 string   `json:"id"`
+	Title              string   `json:"title"`
+	Difficulty         string   `json:"difficulty"`
+	SharedPatterns     []string `json:"shared_patterns"`
+	SharedPatternCount int64    `json:"shared_pattern_count"`
+	Confidence         *int32   `json:"confidence"`
+	Score              float64  `json:"score"`
+}
+
+type PreviewURLBody struct {
+	URL string `json:"url" validate:"required,url"`
+}
+
+// URLPreview is a pre-filled problem for the client to confirm before creating it
+type URLPreview struct {
+	Platform      string            `json:"platform"`
+	Slug          string            `json:"slug"`
+	Fetched       bool              `json:"fetched"` // false when only the URL could be parsed
+	Problem       CreateProblemBody `json:"problem"`
+	UnmatchedTags []string          `json:"unmatched_tags"`
+}
+
+type SnoozeBody struct {
+	Days int `json:"days" validate:"required,min=1,max=30"`
+}
+
+type UpdateNotesBody struct {
+	Notes *string `json:"notes" validate:"omitempty,max=10000"`
+}
+
 type Pattern struct {
 	ID          string  `json:"id"`
 	Title       string  `json:"title"`
 	Description *string `json:"description"`
 }
 
+type Company struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 type UrgentProblem struct {
 	ID            string  `json:"id"`
 	Title         string  `json:"title"`
@@ -60,12 +201,131 @@ type UrgentProblem struct {
 	CreatedAt     string  `json:"created_at"`
 }
 
+// ScoreExplanation is the full breakdown behind a problem's urgency score
+type ScoreExplanation struct {
+	ProblemID string                `json:"problem_id"`
+	Score     float64               `json:"score"`
+	Reason    string                `json:"reason"`
+	Emphasis  string                `json:"emphasis"`
+	Features  []FeatureContribution `json:"features"`
+	Weights   EffectiveWeights      `json:"weights"`
+	Inputs    ScoreInputs           `json:"inputs"`
+}
+
+// FeatureContribution is a single term of the scoring formula: weight * value
+type FeatureContribution struct {
+	Key          string  `json:"key"`
+	Name         string  `json:"name"`
+	Value        float64 `json:"value"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// EffectiveWeights are the weights actually used, after emphasis and renormalization
+type EffectiveWeights struct {
+	WConf       float64 `json:"w_conf"`
+	WDays       float64 `json:"w_days"`
+	WAttempts   float64 `json:"w_attempts"`
+	WTime       float64 `json:"w_time"`
+	WDifficulty float64 `json:"w_difficulty"`
+	WFailed     float64 `json:"w_failed"`
+	WPattern    float64 `json:"w_pattern"`
+}
+
+// ScoreInputs are the raw stats the features were derived from
+type ScoreInputs struct {
+	Confidence     *int32   `json:"confidence"`
+	AvgConfidence  *int32   `json:"avg_confidence"`
+	TotalAttempts  int32    `json:"total_attempts"`
+	AvgTimeSeconds *int32   `json:"avg_time_seconds"`
+	LastOutcome    *string  `json:"last_outcome"`
+	LastAttemptAt  *string  `json:"last_attempt_at"`
+	NextReviewAt   *string  `json:"next_review_at"`
+	DaysOverdue    *float64 `json:"days_overdue"`
+	IntervalDays   *int32   `json:"interval_days"`
+	EaseFactor     *float32 `json:"ease_factor"`
+	ReviewCount    int32    `json:"review_count"`
+}
+
+// LeechProblem is a problem that has been failed repeatedly in a row
+type LeechProblem struct {
+	ID                  string  `json:"id"`
+	Title               string  `json:"title"`
+	Difficulty          string  `json:"difficulty"`
+	Source              *string `json:"source"`
+	URL                 *string `json:"url"`
+	ConsecutiveFailures int32   `json:"consecutive_failures"`
+	TotalAttempts       int32   `json:"total_attempts"`
+	Confidence          int32   `json:"confidence"`
+	LastAttemptAt       *string `json:"last_attempt_at"`
+	NextReviewAt        *string `json:"next_review_at"`
+}
+
+type LeechListResponse struct {
+	Threshold int            `json:"threshold"`
+	Problems  []LeechProblem `json:"problems"`
+}
+
+// DueProblem is a problem whose SM-2 review date falls inside the requested window
+type DueProblem struct {
+	ID           string  `json:"id"`
+	Title        string  `json:"title"`
+	Difficulty   string  `json:"difficulty"`
+	Source       *string `json:"source"`
+	URL          *string `json:"url"`
+	Status       string  `json:"status"`
+	Confidence   int32   `json:"confidence"`
+	DueAt        string  `json:"due_at"`
+	DaysOverdue  int     `json:"days_overdue"`
+	IntervalDays int32   `json:"interval_days"`
+}
+
+type PaginatedDueProblems struct {
+	Window     string       `json:"window"`
+	Data       []DueProblem `json:"data"`
+	Total      int64        `json:"total"`
+	Page       int32        `json:"page"`
+	PageSize   int32        `json:"page_size"`
+	TotalPages int32        `json:"total_pages"`
+}
+
+// AnkiDeck is one page of an Anki export
+type AnkiDeck struct {
+	Window   string
+	Cards    []AnkiCard
+	Total    int64
+	Page     int32
+	PageSize int32
+}
+
+// AnkiCard is a note in Anki's basic Front/Back note type; fields are HTML
+type AnkiCard struct {
+	Front string
+	Back  string
+	Tags  []string
+}
+
+// Sort orders accepted by problem search; empty keeps the default (newest first)
+const (
+	SortScoreDesc      = "score_desc"
+	SortLastAttemptAsc = "last_attempt_asc"
+	SortConfidenceAsc  = "confidence_asc"
+	SortCreatedDesc    = "created_desc"
+	SortTitleAsc       = "title_asc"
+)
+
 type SearchProblemsParams struct {
-	Query      string
-	Difficulty string
-	Status     string
-	Limit      int32
-	Offset     int32
+	Query         string
+	Difficulty    string
+	Source        string
+	Status        string
+	NotesContains string
+	PatternIDs    []uuid.UUID // matches problems linked to any of these
+	CompanyID     *uuid.UUID  // matches problems tagged with this company
+	StarredOnly   bool
+	SortBy        string
+	Limit         int32
+	Offset        int32
 }
 
 type PaginatedProblems struct {
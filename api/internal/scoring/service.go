@@ -2,13 +2,19 @@ package scoring
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
 )
 
 // ScoringWeights holds the configurable weights for the scoring formula
@@ -32,22 +38,60 @@ type ProblemScore struct {
 
 // FeatureBreakdown shows individual feature contributions
 type FeatureBreakdown struct {
-	FConf       float64
-	FDays       float64
-	FAttempts   float64
-	FTime       float64
-	FDifficulty float64
-	FFailed     float64
-	FPattern    float64
+	FConf       float64 `json:"f_conf"`
+	FDays       float64 `json:"f_days"`
+	FAttempts   float64 `json:"f_attempts"`
+	FTime       float64 `json:"f_time"`
+	FDifficulty float64 `json:"f_difficulty"`
+	FFailed     float64 `json:"f_failed"`
+	FPattern    float64 `json:"f_pattern"`
 }
 
+// ScoreExplanation bundles a score with the effective weights (after emphasis)
+// and the raw stats that produced it
+type ScoreExplanation struct {
+	Score    ProblemScore
+	Weights  ScoringWeights
+	Emphasis string
+	Stats    repo.UserProblemStat
+}
+
+// SolveTiming carries how long an attempt took relative to what is expected for
+// its difficulty. A zero value disables the time adjustment.
+type SolveTiming struct {
+	DurationSeconds int
+	ExpectedSeconds int
+	SlowMultiplier  float64
+	FastMultiplier  float64
+}
+
+// Defaults for the sr_slow_multiplier / sr_fast_multiplier settings
+const (
+	DefaultSlowMultiplier = 1.5
+	DefaultFastMultiplier = 0.5
+)
+
+// DefaultLeechThreshold is used when the leech_threshold setting is missing or invalid
+const DefaultLeechThreshold = 4
+
+// scoreCacheTTL bounds how long a cached row is trusted even if the underlying
+// stats have not changed, since f_days and f_failed drift with the clock.
+const scoreCacheTTL = 6 * time.Hour
+
 type Service interface {
 	GetWeights(ctx context.Context) (*ScoringWeights, error)
+	GetLeechThreshold(ctx context.Context) int
 	ComputeScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) (*ProblemScore, error)
 	ComputeScoreWithEmphasis(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ProblemScore, error)
+	ExplainScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ScoreExplanation, error)
 	ComputeScoresForUser(ctx context.Context, userID uuid.UUID) ([]ProblemScore, error)
 	ComputeScoresForUserWithEmphasis(ctx context.Context, userID uuid.UUID, emphasis string) ([]ProblemScore, error)
-	CalculateNextReview(outcome string, confidence int, currentInterval int, easeFactor float64, reviewCount int) (int, float64, time.Time)
+	RecomputeScoresForUser(ctx context.Context, userID uuid.UUID) ([]ProblemScore, error)
+	ComputeScoresForUserWithWeights(ctx context.Context, userID uuid.UUID, weights ScoringWeights) ([]ProblemScore, error)
+	RefreshScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) error
+	InvalidateProblemScores(ctx context.Context, problemID uuid.UUID) error
+	GetSolveTimeThresholds(ctx context.Context) (slow float64, fast float64)
+	CalculateNextReview(outcome string, confidence int, currentInterval int, easeFactor float64, reviewCount int, timing SolveTiming) (int, float64, time.Time)
 }
 
 type scoringService struct {
@@ -77,7 +121,11 @@ func (s *scoringService) GetWeights(ctx context.Context) (*ScoringWeights, error
 	}
 
 	for _, row := range rows {
-		val := parseFloat(row.Value)
+		val, err := parseWeight(row.Key, row.Value)
+		if err != nil {
+			// Keep the default for this key rather than silently zeroing it
+			continue
+		}
 		switch row.Key {
 		case "w_conf":
 			weights.WConf = val
@@ -99,6 +147,64 @@ func (s *scoringService) GetWeights(ctx context.Context) (*ScoringWeights, error
 	return weights, nil
 }
 
+// GetLeechThreshold returns the number of consecutive failures after which a
+// problem is treated as a leech
+func (s *scoringService) GetLeechThreshold(ctx context.Context) int {
+	value, err := s.repo.GetLeechThreshold(ctx)
+	if err != nil {
+		return DefaultLeechThreshold
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 1 {
+		return DefaultLeechThreshold
+	}
+	return threshold
+}
+
+// GetSolveTimeThresholds returns the slow and fast multipliers used to nudge the
+// SM-2 quality rating based on solve time
+func (s *scoringService) GetSolveTimeThresholds(ctx context.Context) (float64, float64) {
+	slow, fast := DefaultSlowMultiplier, DefaultFastMultiplier
+
+	rows, err := s.repo.GetSolveTimeSettings(ctx)
+	if err != nil {
+		return slow, fast
+	}
+
+	for _, row := range rows {
+		val, err := strconv.ParseFloat(row.Value, 64)
+		if err != nil || val <= 0 {
+			continue
+		}
+		switch row.Key {
+		case "sr_slow_multiplier":
+			slow = val
+		case "sr_fast_multiplier":
+			fast = val
+		}
+	}
+
+	return slow, fast
+}
+
+// ExpectedSolveSeconds is the expected solve time for a difficulty, matching
+// the session planner's estimates
+func ExpectedSolveSeconds(difficulty string) int {
+	switch difficulty {
+	case "easy":
+		return 15 * 60
+	case "hard":
+		return 35 * 60
+	default:
+		return 25 * 60
+	}
+}
+
+// IsLeech reports whether a consecutive failure count has crossed the threshold
+func IsLeech(consecutiveFailures pgtype.Int4, threshold int) bool {
+	return consecutiveFailures.Valid && int(consecutiveFailures.Int32) >= threshold
+}
+
 // ApplyEmphasis modifies weights based on scoring emphasis and renormalizes
 func (s *scoringService) applyEmphasis(weights *ScoringWeights, emphasis string) *ScoringWeights {
 	// Copy weights to avoid modifying original
@@ -147,6 +253,16 @@ func (s *scoringService) ComputeScore(ctx context.Context, userID uuid.UUID, pro
 }
 
 func (s *scoringService) ComputeScoreWithEmphasis(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ProblemScore, error) {
+	explanation, err := s.ExplainScore(ctx, userID, problemID, emphasis)
+	if err != nil {
+		return nil, err
+	}
+	return &explanation.Score, nil
+}
+
+// ExplainScore computes a problem's score and keeps the inputs around so callers
+// can show how each feature contributed
+func (s *scoringService) ExplainScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, emphasis string) (*ScoreExplanation, error) {
 	// Get weights
 	weights, err := s.GetWeights(ctx)
 	if err != nil {
@@ -184,22 +300,21 @@ func (s *scoringService) ComputeScoreWithEmphasis(ctx context.Context, userID uu
 	features := s.computeFeatures(stats, problem, patterns, patternStatsMap)
 
 	// Compute final score
-	score := weights.WConf*features.FConf +
-		weights.WDays*features.FDays +
-		weights.WAttempts*features.FAttempts +
-		weights.WTime*features.FTime +
-		weights.WDifficulty*features.FDifficulty +
-		weights.WFailed*features.FFailed +
-		weights.WPattern*features.FPattern
+	score := weightedScore(weights, features)
 
 	// Build reason string
 	reason := s.buildReason(features, weights, stats)
 
-	return &ProblemScore{
-		ProblemID: problemID,
-		Score:     score,
-		Features:  features,
-		Reason:    reason,
+	return &ScoreExplanation{
+		Score: ProblemScore{
+			ProblemID: problemID,
+			Score:     score,
+			Features:  features,
+			Reason:    reason,
+		},
+		Weights:  *weights,
+		Emphasis: emphasis,
+		Stats:    stats,
 	}, nil
 }
 
@@ -208,53 +323,93 @@ func (s *scoringService) ComputeScoresForUser(ctx context.Context, userID uuid.U
 }
 
 func (s *scoringService) ComputeScoresForUserWithEmphasis(ctx context.Context, userID uuid.UUID, emphasis string) ([]ProblemScore, error) {
-	// Get all user problem stats
-	statsList, err := s.repo.ListUserProblemStats(ctx, userID)
+	// Get weights once for all problems
+	baseWeights, err := s.GetWeights(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list user problem stats: %w", err)
+		return nil, err
 	}
+	return s.computeScoresForUser(ctx, userID, s.applyEmphasis(baseWeights, emphasis), baseWeights, true)
+}
 
-	// Get weights once for all problems
+// RecomputeScoresForUser ignores any cached rows and rescores every problem,
+// rewriting the cache as it goes
+func (s *scoringService) RecomputeScoresForUser(ctx context.Context, userID uuid.UUID) ([]ProblemScore, error) {
 	weights, err := s.GetWeights(ctx)
 	if err != nil {
 		return nil, err
 	}
-	weights = s.applyEmphasis(weights, emphasis)
+	return s.computeScoresForUser(ctx, userID, weights, weights, false)
+}
 
-	// Get all pattern stats for user upfront (fix N+1 query)
-	patternStatsMap := s.getPatternStatsMap(ctx, userID)
+// ComputeScoresForUserWithWeights scores every problem with caller-supplied
+// weights. Nothing is written to the cache, so it is safe for previews.
+func (s *scoringService) ComputeScoresForUserWithWeights(ctx context.Context, userID uuid.UUID, weights ScoringWeights) ([]ProblemScore, error) {
+	return s.computeScoresForUser(ctx, userID, &weights, nil, true)
+}
+
+// computeScoresForUser scores all of a user's problems with the given weights.
+// Freshly computed rows are written to the cache (scored with cacheWeights)
+// unless cacheWeights is nil.
+func (s *scoringService) computeScoresForUser(ctx context.Context, userID uuid.UUID, weights *ScoringWeights, cacheWeights *ScoringWeights, useCache bool) ([]ProblemScore, error) {
+	// Get all user problem stats
+	statsList, err := s.repo.ListUserProblemStats(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user problem stats: %w", err)
+	}
+
+	// Cached features are weight independent, so they can be reused for any emphasis
+	cachedFeatures := make(map[uuid.UUID]repo.ProblemScore)
+	if useCache {
+		cachedFeatures = s.getCachedScoresMap(ctx, userID)
+	}
+
+	// Pattern stats are only needed when a problem has to be rescored
+	var patternStatsMap map[uuid.UUID]repo.UserPatternStat
 
 	scores := make([]ProblemScore, 0, len(statsList))
 	for _, stats := range statsList {
-		// Skip abandoned problems
-		if stats.Status.Valid && stats.Status.String == "abandoned" {
+		// Skip problems the user has retired
+		if stats.Status.Valid && (stats.Status.String == "abandoned" || stats.Status.String == "mastered") {
 			continue
 		}
-
-		// Get problem details
-		problem, err := s.repo.GetProblem(ctx, stats.ProblemID)
-		if err != nil {
-			fmt.Printf("Warning: failed to get problem %s: %v\n", stats.ProblemID, err)
+		// and problems snoozed for now, which keeps them out of urgent lists
+		// and generated sessions until the snooze ends
+		if stats.SnoozedUntil.Valid && stats.SnoozedUntil.Time.After(time.Now()) {
 			continue
 		}
 
-		// Get patterns for this problem
-		patterns, err := s.repo.GetPatternsForProblem(ctx, stats.ProblemID)
-		if err != nil {
-			patterns = []repo.Pattern{}
-		}
+		features, ok := cachedFeatureBreakdown(cachedFeatures[stats.ProblemID], stats)
+		if !ok {
+			// Get problem details
+			problem, err := s.repo.GetProblem(ctx, stats.ProblemID)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Failed to get problem", "problem_id", stats.ProblemID, "error", err)
+				continue
+			}
+
+			// Get patterns for this problem
+			patterns, err := s.repo.GetPatternsForProblem(ctx, stats.ProblemID)
+			if err != nil {
+				patterns = []repo.Pattern{}
+			}
+
+			// Get all pattern stats for user upfront (fix N+1 query)
+			if patternStatsMap == nil {
+				patternStatsMap = s.getPatternStatsMap(ctx, userID)
+			}
 
-		// Compute features using cached pattern stats
-		features := s.computeFeatures(stats, problem, patterns, patternStatsMap)
+			// Compute features using cached pattern stats
+			features = s.computeFeatures(stats, problem, patterns, patternStatsMap)
+
+			if cacheWeights != nil {
+				if err := s.storeScore(ctx, userID, stats.ProblemID, weightedScore(cacheWeights, features), features); err != nil {
+					logging.FromContext(ctx).Warn("Failed to cache score", "problem_id", stats.ProblemID, "error", err)
+				}
+			}
+		}
 
 		// Compute final score
-		score := weights.WConf*features.FConf +
-			weights.WDays*features.FDays +
-			weights.WAttempts*features.FAttempts +
-			weights.WTime*features.FTime +
-			weights.WDifficulty*features.FDifficulty +
-			weights.WFailed*features.FFailed +
-			weights.WPattern*features.FPattern
+		score := weightedScore(weights, features)
 
 		// Build reason string
 		reason := s.buildReason(features, weights, stats)
@@ -270,6 +425,84 @@ func (s *scoringService) ComputeScoresForUserWithEmphasis(ctx context.Context, u
 	return scores, nil
 }
 
+// RefreshScore recomputes a single problem's score and writes it to the cache.
+// Called after anything that changes the problem's stats.
+func (s *scoringService) RefreshScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID) error {
+	score, err := s.ComputeScore(ctx, userID, problemID)
+	if err != nil {
+		return err
+	}
+	return s.storeScore(ctx, userID, problemID, score.Score, score.Features)
+}
+
+// InvalidateProblemScores drops cached scores for a problem across all users,
+// used when problem metadata (difficulty, patterns) changes
+func (s *scoringService) InvalidateProblemScores(ctx context.Context, problemID uuid.UUID) error {
+	if err := s.repo.DeleteProblemScoresForProblem(ctx, problemID); err != nil {
+		return fmt.Errorf("failed to invalidate problem scores: %w", err)
+	}
+	return nil
+}
+
+func (s *scoringService) storeScore(ctx context.Context, userID uuid.UUID, problemID uuid.UUID, score float64, features FeatureBreakdown) error {
+	featuresJSON, err := json.Marshal(features)
+	if err != nil {
+		return fmt.Errorf("failed to marshal features: %w", err)
+	}
+
+	return s.repo.UpsertProblemScore(ctx, repo.UpsertProblemScoreParams{
+		UserID:       userID,
+		ProblemID:    problemID,
+		Score:        score,
+		FeaturesJson: string(featuresJSON),
+	})
+}
+
+// getCachedScoresMap fetches all cached score rows for a user keyed by problem
+func (s *scoringService) getCachedScoresMap(ctx context.Context, userID uuid.UUID) map[uuid.UUID]repo.ProblemScore {
+	rows, err := s.repo.ListProblemScoresForUser(ctx, userID)
+	if err != nil {
+		return make(map[uuid.UUID]repo.ProblemScore)
+	}
+
+	cache := make(map[uuid.UUID]repo.ProblemScore, len(rows))
+	for _, row := range rows {
+		cache[row.ProblemID] = row
+	}
+	return cache
+}
+
+// cachedFeatureBreakdown returns the cached features if the row is still fresh:
+// computed after the last stats write and within scoreCacheTTL
+func cachedFeatureBreakdown(row repo.ProblemScore, stats repo.UserProblemStat) (FeatureBreakdown, bool) {
+	if row.FeaturesJson == "" {
+		return FeatureBreakdown{}, false
+	}
+	if time.Since(row.ComputedAt) > scoreCacheTTL {
+		return FeatureBreakdown{}, false
+	}
+	if stats.UpdatedAt.Valid && stats.UpdatedAt.Time.After(row.ComputedAt) {
+		return FeatureBreakdown{}, false
+	}
+
+	var features FeatureBreakdown
+	if err := json.Unmarshal([]byte(row.FeaturesJson), &features); err != nil {
+		return FeatureBreakdown{}, false
+	}
+	return features, true
+}
+
+// weightedScore combines feature values with their weights into the final score
+func weightedScore(weights *ScoringWeights, features FeatureBreakdown) float64 {
+	return weights.WConf*features.FConf +
+		weights.WDays*features.FDays +
+		weights.WAttempts*features.FAttempts +
+		weights.WTime*features.FTime +
+		weights.WDifficulty*features.FDifficulty +
+		weights.WFailed*features.FFailed +
+		weights.WPattern*features.FPattern
+}
+
 // getPatternStatsMap fetches all pattern stats for a user and returns a map
 // This fixes the N+1 query problem when computing scores for many problems
 func (s *scoringService) getPatternStatsMap(ctx context.Context, userID uuid.UUID) map[uuid.UUID]repo.UserPatternStat {
@@ -439,7 +672,7 @@ func (s *scoringService) calculatePatternWeakness(patterns []repo.Pattern, patte
 
 // CalculateNextReview implements SM-2 algorithm for spaced repetition scheduling
 // Returns: new interval (days), new ease factor, next review date
-func (s *scoringService) CalculateNextReview(outcome string, confidence int, currentInterval int, easeFactor float64, reviewCount int) (int, float64, time.Time) {
+func (s *scoringService) CalculateNextReview(outcome string, confidence int, currentInterval int, easeFactor float64, reviewCount int, timing SolveTiming) (int, float64, time.Time) {
 	// Map confidence (0-100) to SM-2 quality rating (0-5)
 	// confidence >= 80 -> quality 5 (perfect)
 	// confidence >= 60 -> quality 4 (correct with hesitation)
@@ -463,6 +696,7 @@ func (s *scoringService) CalculateNextReview(outcome string, confidence int, cur
 		default:
 			quality = 1
 		}
+		quality = adjustQualityForTime(quality, timing)
 	}
 
 	var newInterval int
@@ -495,6 +729,29 @@ func (s *scoringService) CalculateNextReview(outcome string, confidence int, cur
 	return newInterval, newEaseFactor, nextReview
 }
 
+// adjustQualityForTime moves the quality rating one level down for a slow solve
+// and one level up for a fast one. The adjustment never crosses the pass/fail
+// boundary (quality 3) in either direction, and failures are never adjusted.
+func adjustQualityForTime(quality float64, timing SolveTiming) float64 {
+	if timing.DurationSeconds <= 0 || timing.ExpectedSeconds <= 0 {
+		return quality
+	}
+
+	ratio := float64(timing.DurationSeconds) / float64(timing.ExpectedSeconds)
+	adjusted := quality
+	switch {
+	case timing.SlowMultiplier > 0 && ratio > timing.SlowMultiplier:
+		adjusted = quality - 1
+	case timing.FastMultiplier > 0 && ratio < timing.FastMultiplier:
+		adjusted = quality + 1
+	}
+
+	if quality >= 3 {
+		return math.Min(5, math.Max(3, adjusted))
+	}
+	return math.Min(2, math.Max(1, adjusted))
+}
+
 func (s *scoringService) buildReason(features FeatureBreakdown, weights *ScoringWeights, stats repo.UserProblemStat) string {
 	// Find top 3 contributing features
 	type contribution struct {
@@ -582,8 +839,14 @@ func (s *scoringService) buildReason(features FeatureBreakdown, weights *Scoring
 	return reason
 }
 
-func parseFloat(s string) float64 {
-	var f float64
-	fmt.Sscanf(s, "%f", &f)
-	return f
+// parseWeight strictly parses a stored weight, logging anything malformed.
+// Weights are saved within 0-1, so a value outside it is as corrupt as one
+// that isn't a number.
+func parseWeight(key, value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || f < 0 || f > 1 {
+		slog.Warn("Ignoring malformed scoring weight setting", "key", key, "value", value)
+		return 0, fmt.Errorf("invalid value %q for %s", value, key)
+	}
+	return f, nil
 }
@@ -0,0 +1,136 @@
+package scoring
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// scoringRepo serves a user's stats rows and the problems they refer to
+type scoringRepo struct {
+	*testutil.Querier
+	stats    []repo.UserProblemStat
+	problems map[uuid.UUID]repo.Problem
+}
+
+func (f *scoringRepo) ListUserProblemStats(ctx context.Context, userID uuid.UUID) ([]repo.UserProblemStat, error) {
+	return f.stats, nil
+}
+
+func (f *scoringRepo) GetProblem(ctx context.Context, id uuid.UUID) (repo.Problem, error) {
+	return f.problems[id], nil
+}
+
+func (f *scoringRepo) GetPatternsForProblem(ctx context.Context, problemID uuid.UUID) ([]repo.Pattern, error) {
+	return nil, nil
+}
+
+// ListProblemScoresForUser reports an empty cache, so every row is scored fresh
+func (f *scoringRepo) ListProblemScoresForUser(ctx context.Context, userID uuid.UUID) ([]repo.ProblemScore, error) {
+	return nil, nil
+}
+
+func (f *scoringRepo) ListUserPatternStats(ctx context.Context, userID uuid.UUID) ([]repo.UserPatternStat, error) {
+	return nil, nil
+}
+
+// snoozed returns stats as SnoozeUserProblem leaves them: due no earlier than
+// until, with the previous due date kept for a cancel
+func snoozed(stats repo.UserProblemStat, until time.Time) repo.UserProblemStat {
+	stats.SnoozedFromReviewAt = stats.NextReviewAt
+	stats.SnoozedUntil = testutil.Timestamp(until)
+	if stats.NextReviewAt.Time.Before(until) {
+		stats.NextReviewAt = testutil.Timestamp(until)
+	}
+	return stats
+}
+
+func TestComputeScoresSkipsActiveSnoozes(t *testing.T) {
+	now := time.Now()
+	userID := uuid.New()
+
+	tests := []struct {
+		name   string
+		stats  func(repo.UserProblemStat) repo.UserProblemStat
+		scored bool
+	}{
+		{
+			name:   "not snoozed",
+			stats:  func(s repo.UserProblemStat) repo.UserProblemStat { return s },
+			scored: true,
+		},
+		{
+			name:   "snoozed until tomorrow",
+			stats:  func(s repo.UserProblemStat) repo.UserProblemStat { return snoozed(s, now.AddDate(0, 0, 1)) },
+			scored: false,
+		},
+		{
+			name:   "snooze ended yesterday",
+			stats:  func(s repo.UserProblemStat) repo.UserProblemStat { return snoozed(s, now.AddDate(0, 0, -1)) },
+			scored: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := testutil.Problem("Two Sum", "easy")
+			stats := testutil.ProblemStats(userID, problem.ID, 60, 10)
+			f := &scoringRepo{
+				Querier:  testutil.NewQuerier(),
+				stats:    []repo.UserProblemStat{tt.stats(stats)},
+				problems: map[uuid.UUID]repo.Problem{problem.ID: problem},
+			}
+
+			scores, err := NewService(f).ComputeScoresForUserWithWeights(context.Background(), userID, ScoringWeights{WConf: 0.5, WDays: 0.5})
+			if err != nil {
+				t.Fatalf("ComputeScoresForUserWithWeights: %v", err)
+			}
+			if got := len(scores) == 1; got != tt.scored {
+				t.Errorf("scored = %v, want %v", got, tt.scored)
+			}
+		})
+	}
+}
+
+// The due date a snooze writes is what calculateDaysUrgency reads, so a
+// snoozed problem stops being urgent and cancelling restores its urgency.
+// Urgency is measured from the wall clock, so comparisons allow for the time
+// between calls.
+func TestDaysUrgencyAcrossSnooze(t *testing.T) {
+	now := time.Now()
+	s := &scoringService{}
+
+	overdue := testutil.ProblemStats(uuid.New(), uuid.New(), 60, 10)
+	overdue.NextReviewAt = testutil.Timestamp(now.AddDate(0, 0, -3))
+	before := s.calculateDaysUrgency(overdue)
+	if before <= 0.5 {
+		t.Fatalf("urgency of a problem 3 days overdue = %.3f, want above 0.5", before)
+	}
+
+	during := s.calculateDaysUrgency(snoozed(overdue, now.AddDate(0, 0, 7)))
+	if during >= 0.5 {
+		t.Errorf("urgency while snoozed for a week = %.3f, want below 0.5", during)
+	}
+
+	// CancelUserProblemSnooze moves snoozed_from_review_at back into next_review_at
+	cancelled := snoozed(overdue, now.AddDate(0, 0, 7))
+	cancelled.NextReviewAt = cancelled.SnoozedFromReviewAt
+	cancelled.SnoozedUntil = pgtype.Timestamptz{}
+	cancelled.SnoozedFromReviewAt = pgtype.Timestamptz{}
+	if after := s.calculateDaysUrgency(cancelled); math.Abs(after-before) > 1e-6 {
+		t.Errorf("urgency after cancelling = %.3f, want the pre-snooze %.3f", after, before)
+	}
+
+	// A snooze doesn't pull a later due date forward
+	later := testutil.ProblemStats(uuid.New(), uuid.New(), 60, 10)
+	later.NextReviewAt = testutil.Timestamp(now.AddDate(0, 0, 20))
+	want := s.calculateDaysUrgency(later)
+	if got := s.calculateDaysUrgency(snoozed(later, now.AddDate(0, 0, 7))); math.Abs(got-want) > 1e-6 {
+		t.Errorf("urgency when snoozed short of the due date = %.3f, want %.3f", got, want)
+	}
+}
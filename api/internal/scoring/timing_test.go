@@ -0,0 +1,79 @@
+package scoring
+
+import "testing"
+
+func TestAdjustQualityForTime(t *testing.T) {
+	timing := func(duration, expected int) SolveTiming {
+		return SolveTiming{
+			DurationSeconds: duration,
+			ExpectedSeconds: expected,
+			SlowMultiplier:  DefaultSlowMultiplier,
+			FastMultiplier:  DefaultFastMultiplier,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		quality float64
+		timing  SolveTiming
+		want    float64
+	}{
+		{"exactly the expected time", 4, timing(600, 600), 4},
+		{"at the slow threshold", 4, timing(900, 600), 4},
+		{"past the slow threshold", 4, timing(901, 600), 3},
+		{"at the fast threshold", 4, timing(300, 600), 4},
+		{"under the fast threshold", 4, timing(299, 600), 5},
+		{"no expected time", 4, timing(60, 0), 4},
+		{"negative expected time", 4, timing(60, -1), 4},
+		{"no duration", 4, timing(0, 600), 4},
+		{"no multipliers", 4, SolveTiming{DurationSeconds: 6000, ExpectedSeconds: 600}, 4},
+		{"slow never fails a pass", 3, timing(6000, 600), 3},
+		{"fast never passes a failure", 2, timing(60, 600), 2},
+		{"fast caps at 5", 5, timing(60, 600), 5},
+		{"slow floors a failure at 1", 1, timing(6000, 600), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adjustQualityForTime(tt.quality, tt.timing); got != tt.want {
+				t.Errorf("adjustQualityForTime(%v, %+v) = %v, want %v", tt.quality, tt.timing, got, tt.want)
+			}
+		})
+	}
+}
+
+// A pass's solve time only moves the ease factor, so its effect on intervals
+// shows from the following review on
+func TestCalculateNextReviewFastVersusSlowPass(t *testing.T) {
+	s := &scoringService{}
+	timing := func(duration int) SolveTiming {
+		return SolveTiming{
+			DurationSeconds: duration,
+			ExpectedSeconds: 25 * 60,
+			SlowMultiplier:  DefaultSlowMultiplier,
+			FastMultiplier:  DefaultFastMultiplier,
+		}
+	}
+
+	// Two reviews in, on a 6 day interval; the pass is rated quality 4
+	review := func(duration int) (int, int) {
+		interval, ease, _ := s.CalculateNextReview("passed", 70, 6, 2.5, 2, timing(duration))
+		next, _, _ := s.CalculateNextReview("passed", 70, interval, ease, 3, timing(25*60))
+		return interval, next
+	}
+
+	fastInterval, fastNext := review(10 * 60)
+	onTimeInterval, onTimeNext := review(25 * 60)
+	slowInterval, slowNext := review(60 * 60)
+
+	if fastInterval != onTimeInterval || slowInterval != onTimeInterval {
+		t.Errorf("intervals = fast %d, on time %d, slow %d; want all equal", fastInterval, onTimeInterval, slowInterval)
+	}
+	if !(fastNext > onTimeNext && onTimeNext > slowNext) {
+		t.Errorf("following intervals = fast %d, on time %d, slow %d; want fast > on time > slow", fastNext, onTimeNext, slowNext)
+	}
+
+	// A slow pass is still a pass
+	if interval, _, _ := s.CalculateNextReview("passed", 40, 6, 2.5, 2, timing(60*60)); interval <= 1 {
+		t.Errorf("interval after a slow pass = %d, want more than 1", interval)
+	}
+}
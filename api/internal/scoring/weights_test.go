@@ -0,0 +1,82 @@
+package scoring
+
+import (
+	"context"
+	"testing"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+func TestParseWeight(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"0.25", 0.25, false},
+		{" 0.5 ", 0.5, false},
+		{"0", 0, false},
+		{"1", 1, false},
+		{"", 0, true},
+		{"   ", 0, true},
+		{"abc", 0, true},
+		{"0.3x", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+		{"-Inf", 0, true},
+		{"-0.1", 0, true},
+		{"1.5", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseWeight("w_conf", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWeight(%q) err = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseWeight(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// weightsRepo serves stored weight settings
+type weightsRepo struct {
+	*testutil.Querier
+	rows []repo.GetScoringWeightsRow
+}
+
+func (f *weightsRepo) GetScoringWeights(ctx context.Context) ([]repo.GetScoringWeightsRow, error) {
+	return f.rows, nil
+}
+
+func TestGetWeightsKeepsDefaultsForMalformedRows(t *testing.T) {
+	f := &weightsRepo{
+		Querier: testutil.NewQuerier(),
+		rows: []repo.GetScoringWeightsRow{
+			{Key: "w_conf", Value: "garbage"},
+			{Key: "w_days", Value: "-0.2"},
+			{Key: "w_attempts", Value: "NaN"},
+			{Key: "w_time", Value: ""},
+			{Key: "w_pattern", Value: "0.4"},
+		},
+	}
+
+	weights, err := NewService(f).GetWeights(context.Background())
+	if err != nil {
+		t.Fatalf("GetWeights: %v", err)
+	}
+	want := ScoringWeights{
+		WConf:       0.30,
+		WDays:       0.20,
+		WAttempts:   0.10,
+		WTime:       0.05,
+		WDifficulty: 0.15,
+		WFailed:     0.10,
+		WPattern:    0.4,
+	}
+	if *weights != want {
+		t.Errorf("GetWeights = %+v, want %+v", *weights, want)
+	}
+}
@@ -2,13 +2,13 @@ package sessions
 
 import (
 	"errors"
-	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -26,22 +26,22 @@ func (h *handler) CreateSession(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body CreateSessionBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	session, err := h.service.CreateSession(r.Context(), userID, body)
 	if err != nil {
-		slog.Error("Failed to create session", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to create session", "error", err)
 		utils.InternalServerError(w, "Failed to create session")
 		return
 	}
@@ -51,7 +51,7 @@ func (h *handler) CreateSession(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) GetSession(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -66,8 +66,12 @@ func (h *handler) GetSession(w http.ResponseWriter, r *http.Request) {
 
 	session, err := h.service.GetSession(r.Context(), userID, sessionID)
 	if err != nil {
-		slog.Error("Failed to get session", "error", err)
-		utils.NotFound(w, "Session not found")
+		if errors.Is(err, ErrSessionNotFound) {
+			utils.NotFound(w, "Session not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to get session", "error", err)
+		utils.InternalServerError(w, "Failed to get session")
 		return
 	}
 
@@ -76,7 +80,7 @@ func (h *handler) GetSession(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) ListSessionsForUser(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -112,7 +116,7 @@ func (h *handler) ListSessionsForUser(w http.ResponseWriter, r *http.Request) {
 
 	sessions, err := h.service.ListSessionsForUser(r.Context(), userID, int32(limit), int32(offset))
 	if err != nil {
-		slog.Error("Failed to list sessions", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to list sessions", "error", err)
 		utils.InternalServerError(w, "Failed to list sessions")
 		return
 	}
@@ -148,7 +152,7 @@ func (h *handler) searchSessionsForUser(w http.ResponseWriter, r *http.Request,
 
 	result, err := h.service.SearchSessionsForUser(r.Context(), userID, params)
 	if err != nil {
-		slog.Error("Failed to search sessions", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to search sessions", "error", err)
 		utils.InternalServerError(w, "Failed to search sessions")
 		return
 	}
@@ -160,16 +164,16 @@ func (h *handler) GenerateSession(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body GenerateSessionBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
@@ -178,7 +182,7 @@ func (h *handler) GenerateSession(w http.ResponseWriter, r *http.Request) {
 		// Check if it's a session generation error with user-friendly message
 		var genErr *SessionGenerationError
 		if errors.As(err, &genErr) {
-			slog.Warn("Session generation constraint not met", "error", genErr.Message, "constraint", genErr.Constraint)
+			logging.FromContext(r.Context()).Warn("Session generation constraint not met", "error", genErr.Message, "constraint", genErr.Constraint)
 			utils.BadRequest(w, genErr.Message, map[string]interface{}{
 				"constraint":      genErr.Constraint,
 				"required_count":  genErr.RequiredCount,
@@ -187,7 +191,7 @@ func (h *handler) GenerateSession(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		slog.Error("Failed to generate session", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to generate session", "error", err)
 		utils.InternalServerError(w, "Failed to generate session")
 		return
 	}
@@ -217,9 +221,9 @@ func (h *handler) GenerateCustomSession(w http.ResponseWriter, r *http.Request)
 	defer r.Body.Close()
 
 	var body GenerateCustomSessionBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
@@ -229,7 +233,7 @@ func (h *handler) GenerateCustomSession(w http.ResponseWriter, r *http.Request)
 
 func (h *handler) CompleteSession(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -244,7 +248,11 @@ func (h *handler) CompleteSession(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.CompleteSession(r.Context(), userID, sessionID)
 	if err != nil {
-		slog.Error("Failed to complete session", "error", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			utils.NotFound(w, "Session not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to complete session", "error", err)
 		utils.InternalServerError(w, "Failed to complete session")
 		return
 	}
@@ -256,7 +264,7 @@ func (h *handler) CompleteSession(w http.ResponseWriter, r *http.Request) {
 
 func (h *handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -271,7 +279,11 @@ func (h *handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.DeleteSession(r.Context(), userID, sessionID)
 	if err != nil {
-		slog.Error("Failed to delete session", "error", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			utils.NotFound(w, "Session not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete session", "error", err)
 		utils.InternalServerError(w, "Failed to delete session")
 		return
 	}
@@ -284,7 +296,7 @@ func (h *handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 func (h *handler) UpdateSessionTimer(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -298,15 +310,19 @@ func (h *handler) UpdateSessionTimer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body UpdateSessionTimerBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	err = h.service.UpdateSessionTimer(r.Context(), userID, sessionID, body)
 	if err != nil {
-		slog.Error("Failed to update timer", "error", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			utils.NotFound(w, "Session not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to update timer", "error", err)
 		utils.InternalServerError(w, "Failed to update timer")
 		return
 	}
@@ -319,7 +335,7 @@ func (h *handler) UpdateSessionTimer(w http.ResponseWriter, r *http.Request) {
 func (h *handler) ReorderSession(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -333,16 +349,23 @@ func (h *handler) ReorderSession(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body ReorderSessionBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, "Invalid request body", nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	err = h.service.ReorderSession(r.Context(), userID, sessionID, body)
 	if err != nil {
-		slog.Error("Failed to reorder session", "error", err)
-		utils.BadRequest(w, err.Error(), nil)
+		switch {
+		case errors.Is(err, ErrSessionNotFound):
+			utils.NotFound(w, "Session not found")
+		case errors.Is(err, ErrInvalidOrder):
+			utils.BadRequest(w, err.Error(), nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to reorder session", "error", err)
+			utils.InternalServerError(w, "Failed to reorder session")
+		}
 		return
 	}
 
@@ -0,0 +1,72 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// serveID runs one handler method for a request to /{id}, as an authenticated
+// user
+func serveID(h http.HandlerFunc, method, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/"+id, nil)
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx)
+	req = req.WithContext(auth.WithUser(ctx, uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+// lookupRepo fails the session lookup with err
+type lookupRepo struct {
+	*testutil.Querier
+	err error
+}
+
+func (f *lookupRepo) GetSession(ctx context.Context, arg repo.GetSessionParams) (repo.RevisionSession, error) {
+	return repo.RevisionSession{}, f.err
+}
+
+// A missing row is a 404; any other repo failure stays a 500
+func TestGetSessionNotFoundVersusFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		repoErr error
+		status  int
+		code    string
+	}{
+		{"missing", uuid.NewString(), pgx.ErrNoRows, http.StatusNotFound, utils.ErrCodeNotFound},
+		{"database failure", uuid.NewString(), errors.New("connection refused"), http.StatusInternalServerError, utils.ErrCodeInternalServer},
+		{"malformed id", "42", nil, http.StatusBadRequest, utils.ErrCodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &lookupRepo{Querier: testutil.NewQuerier(), err: tt.repoErr}
+			s := NewService(f, nil, metrics.Noop{}, events.Noop{}, webhooks.Noop{})
+			rec := serveID(NewHandler(s).GetSession, http.MethodGet, tt.id)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if code := testutil.ErrorResponse(t, rec).Code; code != tt.code {
+				t.Errorf("error code = %q, want %q", code, tt.code)
+			}
+		})
+	}
+}
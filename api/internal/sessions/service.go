@@ -5,20 +5,33 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/metrics"
 	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/utils"
+	"github.com/vasujain275/reforge/internal/webhooks"
 )
 
 // Custom errors
 var (
 	ErrInsufficientProblems = errors.New("insufficient problems to generate session")
 	ErrConstraintNotMet     = errors.New("session constraints not met")
+	ErrSessionNotFound      = fmt.Errorf("session %w", utils.ErrNotFound)
+	ErrInvalidOrder         = errors.New("new order must contain exactly the session's problems")
 )
 
+// DefaultStarredBoost is the score multiplier for starred problems when the
+// starred_boost system setting is missing or invalid
+const DefaultStarredBoost = 1.5
+
 // SessionGenerationError provides detailed information about why session generation failed
 type SessionGenerationError struct {
 	Message        string
@@ -56,12 +69,18 @@ type Service interface {
 type sessionService struct {
 	repo           repo.Querier
 	scoringService scoring.Service
+	metrics        metrics.Recorder
+	events         events.Publisher // Timer changes, for the user's other open clients
+	webhooks       webhooks.Notifier
 }
 
-func NewService(repo repo.Querier, scoringService scoring.Service) Service {
+func NewService(repo repo.Querier, scoringService scoring.Service, recorder metrics.Recorder, publisher events.Publisher, notifier webhooks.Notifier) Service {
 	return &sessionService{
 		repo:           repo,
 		scoringService: scoringService,
+		metrics:        recorder,
+		events:         publisher,
+		webhooks:       notifier,
 	}
 }
 
@@ -113,6 +132,9 @@ func (s *sessionService) GetSession(ctx context.Context, userID uuid.UUID, sessi
 		UserID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -124,6 +146,8 @@ func (s *sessionService) GetSession(ctx context.Context, userID uuid.UUID, sessi
 		}
 	}
 
+	leechThreshold := s.scoringService.GetLeechThreshold(ctx)
+
 	// Fetch problems for the session with attempt data
 	problems := make([]SessionProblem, 0)
 	for _, problemIDStr := range problemIDStrs {
@@ -198,6 +222,8 @@ func (s *sessionService) GetSession(ctx context.Context, userID uuid.UUID, sessi
 			CreatedAt:     problem.CreatedAt.Time.Format(time.RFC3339),
 			Completed:     completed,
 			Outcome:       outcome,
+			IsLeech:       scoring.IsLeech(stats.ConsecutiveFailures, leechThreshold),
+			HasNotes:      stats.Notes.Valid && stats.Notes.String != "",
 		})
 	}
 
@@ -322,12 +348,25 @@ func (s *sessionService) GenerateSession(ctx context.Context, userID uuid.UUID,
 		durationMin = *body.DurationMin
 	}
 
+	template.PatternID = body.PatternID
+	template.CompanyID = body.CompanyID
+	if template.CompanyMode == "specific" && template.CompanyID == nil {
+		return nil, &SessionGenerationError{
+			Message:    "This template needs a company. Pick one with company_id.",
+			Constraint: "company_id",
+		}
+	}
+
 	// Get all scored problems using the scoring service
 	scores, err := s.scoringService.ComputeScoresForUser(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute scores: %w", err)
 	}
 
+	if body.PreferStarred {
+		s.boostStarredScores(ctx, userID, scores)
+	}
+
 	// Sort by score descending (higher score = more urgent)
 	for i := 0; i < len(scores)-1; i++ {
 		for j := 0; j < len(scores)-i-1; j++ {
@@ -342,6 +381,7 @@ func (s *sessionService) GenerateSession(ctx context.Context, userID uuid.UUID,
 	if err != nil {
 		return nil, fmt.Errorf("failed to build session: %w", err)
 	}
+	s.metrics.SessionGenerated(body.TemplateKey)
 
 	return &GenerateSessionResponse{
 		TemplateKey:        &body.TemplateKey,
@@ -365,6 +405,23 @@ func (s *sessionService) buildSessionWithConstraints(
 	// Step 1: Build all candidates with full metadata (no filtering yet)
 	allCandidates := s.buildAllCandidates(ctx, userID, scores)
 
+	// The company is never relaxed, not even by the final fallback
+	if template.CompanyID != nil {
+		var err error
+		allCandidates, err = s.restrictToCompany(ctx, allCandidates, *template.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if len(allCandidates) == 0 {
+			return nil, &SessionGenerationError{
+				Message:        "None of this company's problems are in your practice history yet. Attempt a few of them first.",
+				RequiredCount:  1,
+				AvailableCount: 0,
+				Constraint:     "company_id",
+			}
+		}
+	}
+
 	if len(allCandidates) == 0 {
 		return nil, &SessionGenerationError{
 			Message:        "No problems available. Add some problems to your library first.",
@@ -430,6 +487,7 @@ func (s *sessionService) buildSessionWithConstraints(
 // buildAllCandidates creates candidate structs for all scored problems without filtering
 func (s *sessionService) buildAllCandidates(ctx context.Context, userID uuid.UUID, scores []scoring.ProblemScore) []candidateProblem {
 	candidates := make([]candidateProblem, 0, len(scores))
+	leechThreshold := s.scoringService.GetLeechThreshold(ctx)
 
 	for _, score := range scores {
 		problem, err := s.repo.GetProblem(ctx, score.ProblemID)
@@ -467,6 +525,7 @@ func (s *sessionService) buildAllCandidates(ctx context.Context, userID uuid.UUI
 			difficulty:    difficulty,
 			estimatedMin:  estimatedMin,
 			daysSinceLast: daysSinceLast,
+			isLeech:       scoring.IsLeech(stats.ConsecutiveFailures, leechThreshold),
 		})
 	}
 
@@ -490,6 +549,11 @@ func (s *sessionService) filterCandidates(
 			continue
 		}
 
+		// Leech exclusion is never relaxed either - they must be re-added manually
+		if template.ExcludeLeeches && candidate.isLeech {
+			continue
+		}
+
 		confidence := int(candidate.stats.Confidence.Int32)
 
 		// Confidence filters (relaxed at level 1+)
@@ -769,11 +833,39 @@ func (s *sessionService) candidateToSessionProblem(candidate candidateProblem) S
 		CreatedAt:     candidate.problem.CreatedAt.Time.Format(time.RFC3339),
 		Completed:     false,
 		Outcome:       nil,
+		IsLeech:       candidate.isLeech,
+		HasNotes:      candidate.stats.Notes.Valid && candidate.stats.Notes.String != "",
 		Priority:      priority,
 		DaysUntilDue:  daysUntilDue,
 	}
 }
 
+// boostStarredScores multiplies the scores of the user's starred problems in place
+func (s *sessionService) boostStarredScores(ctx context.Context, userID uuid.UUID, scores []scoring.ProblemScore) {
+	starredIDs, err := s.repo.ListStarredProblemIDs(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to list starred problems", "error", err)
+		return
+	}
+	starred := make(map[uuid.UUID]bool, len(starredIDs))
+	for _, id := range starredIDs {
+		starred[id] = true
+	}
+
+	boost := DefaultStarredBoost
+	if value, err := s.repo.GetStarredBoost(ctx); err == nil {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+			boost = parsed
+		}
+	}
+
+	for i := range scores {
+		if starred[scores[i].ProblemID] {
+			scores[i].Score *= boost
+		}
+	}
+}
+
 // calculatePriority determines problem priority based on spaced repetition data
 // Returns priority status and days until due (negative = overdue)
 func (s *sessionService) calculatePriority(stats repo.UserProblemStat) (string, *int) {
@@ -842,6 +934,7 @@ type candidateProblem struct {
 	difficulty    string
 	estimatedMin  int
 	daysSinceLast *int
+	isLeech       bool
 }
 
 // applyPatternModeFilter filters candidates based on template pattern mode
@@ -912,6 +1005,30 @@ func (s *sessionService) applyPatternModeFilter(
 	}
 }
 
+// restrictToCompany keeps the candidates tagged with the company
+func (s *sessionService) restrictToCompany(ctx context.Context, candidates []candidateProblem, companyID string) ([]candidateProblem, error) {
+	companyUUID, err := uuid.Parse(companyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid company_id: %w", err)
+	}
+	problemIDs, err := s.repo.ListProblemIDsForCompany(ctx, companyUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list company problems: %w", err)
+	}
+
+	tagged := make(map[uuid.UUID]bool, len(problemIDs))
+	for _, id := range problemIDs {
+		tagged[id] = true
+	}
+	filtered := make([]candidateProblem, 0, len(tagged))
+	for _, candidate := range candidates {
+		if tagged[candidate.problem.ID] {
+			filtered = append(filtered, candidate)
+		}
+	}
+	return filtered, nil
+}
+
 // getWeakestPatterns returns the N weakest patterns for a user
 func (s *sessionService) getWeakestPatterns(ctx context.Context, userID uuid.UUID, count int) ([]uuid.UUID, error) {
 	// Get all pattern stats for user
@@ -966,11 +1083,14 @@ func (s *sessionService) applyProgressionMode(candidates []candidateProblem) []c
 
 func (s *sessionService) CompleteSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
 	// Verify session belongs to user
-	_, err := s.repo.GetSession(ctx, repo.GetSessionParams{
+	session, err := s.repo.GetSession(ctx, repo.GetSessionParams{
 		ID:     sessionID,
 		UserID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -985,17 +1105,33 @@ func (s *sessionService) CompleteSession(ctx context.Context, userID uuid.UUID,
 		return fmt.Errorf("failed to update session: %w", err)
 	}
 
+	s.events.Publish(userID, events.Event{Type: events.SessionCompleted, Data: TimerEvent{
+		SessionID:  sessionID.String(),
+		TimerState: "idle",
+		Completed:  true,
+		UpdatedAt:  completedAt.Time.Format(time.RFC3339),
+	}})
+	s.webhooks.Notify(ctx, userID, webhooks.EventSessionCompleted, CompletedEvent{
+		SessionID:          sessionID.String(),
+		TemplateKey:        pgTextToPtr(session.TemplateKey),
+		PlannedDurationMin: pgInt4ToInt64(session.PlannedDurationMin, 0),
+		ElapsedTimeSeconds: pgInt4ToInt64(session.ElapsedTimeSeconds, 0),
+		CompletedAt:        completedAt.Time.Format(time.RFC3339),
+	})
 	return nil
 }
 
 func (s *sessionService) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
-	err := s.repo.DeleteSession(ctx, repo.DeleteSessionParams{
+	deleted, err := s.repo.DeleteSession(ctx, repo.DeleteSessionParams{
 		ID:     sessionID,
 		UserID: userID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
 
 	return nil
 }
@@ -1007,6 +1143,9 @@ func (s *sessionService) UpdateSessionTimer(ctx context.Context, userID uuid.UUI
 		UserID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -1023,6 +1162,12 @@ func (s *sessionService) UpdateSessionTimer(ctx context.Context, userID uuid.UUI
 		return fmt.Errorf("failed to update timer: %w", err)
 	}
 
+	s.events.Publish(userID, events.Event{Type: events.SessionTimer, Data: TimerEvent{
+		SessionID:          sessionID.String(),
+		ElapsedTimeSeconds: body.ElapsedTimeSeconds,
+		TimerState:         body.TimerState,
+		UpdatedAt:          now.Time.Format(time.RFC3339),
+	}})
 	return nil
 }
 
@@ -1094,6 +1239,9 @@ func (s *sessionService) ReorderSession(ctx context.Context, userID uuid.UUID, s
 		UserID: userID,
 	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrSessionNotFound
+		}
 		return fmt.Errorf("failed to get session: %w", err)
 	}
 
@@ -1107,7 +1255,7 @@ func (s *sessionService) ReorderSession(ctx context.Context, userID uuid.UUID, s
 
 	// Validate that the new order contains the same problem IDs
 	if len(body.ProblemIDs) != len(currentProblemIDs) {
-		return fmt.Errorf("problem count mismatch: expected %d, got %d", len(currentProblemIDs), len(body.ProblemIDs))
+		return fmt.Errorf("%w: expected %d problems, got %d", ErrInvalidOrder, len(currentProblemIDs), len(body.ProblemIDs))
 	}
 
 	// Create a map to verify all IDs exist in current session
@@ -1118,7 +1266,7 @@ func (s *sessionService) ReorderSession(ctx context.Context, userID uuid.UUID, s
 
 	for _, id := range body.ProblemIDs {
 		if !currentIDMap[id] {
-			return fmt.Errorf("problem ID %s not found in session", id)
+			return fmt.Errorf("%w: problem ID %s is not in the session", ErrInvalidOrder, id)
 		}
 	}
 
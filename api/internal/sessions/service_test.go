@@ -0,0 +1,372 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/events"
+	"github.com/vasujain275/reforge/internal/metrics"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/webhooks"
+)
+
+// libraryRepo serves a fixed problem library with per-problem stats, patterns
+// and company tags
+type libraryRepo struct {
+	*testutil.Querier
+	problems  map[uuid.UUID]repo.Problem
+	stats     map[uuid.UUID]repo.UserProblemStat
+	patterns  map[uuid.UUID][]repo.Pattern
+	companies map[uuid.UUID][]uuid.UUID
+}
+
+func (f *libraryRepo) GetProblem(ctx context.Context, id uuid.UUID) (repo.Problem, error) {
+	f.Record("GetProblem", id)
+	problem, ok := f.problems[id]
+	if !ok {
+		return repo.Problem{}, errors.New("no rows")
+	}
+	return problem, nil
+}
+
+func (f *libraryRepo) GetUserProblemStats(ctx context.Context, arg repo.GetUserProblemStatsParams) (repo.UserProblemStat, error) {
+	f.Record("GetUserProblemStats", arg)
+	stats, ok := f.stats[arg.ProblemID]
+	if !ok {
+		return repo.UserProblemStat{}, errors.New("no rows")
+	}
+	return stats, nil
+}
+
+func (f *libraryRepo) GetPatternsForProblem(ctx context.Context, problemID uuid.UUID) ([]repo.Pattern, error) {
+	f.Record("GetPatternsForProblem", problemID)
+	return f.patterns[problemID], nil
+}
+
+func (f *libraryRepo) ListProblemIDsForCompany(ctx context.Context, companyID uuid.UUID) ([]uuid.UUID, error) {
+	f.Record("ListProblemIDsForCompany", companyID)
+	return f.companies[companyID], nil
+}
+
+// fixedScores returns the scores it was built with, in that order
+type fixedScores struct {
+	scoring.Service
+	scores []scoring.ProblemScore
+}
+
+func (f fixedScores) ComputeScoresForUser(ctx context.Context, userID uuid.UUID) ([]scoring.ProblemScore, error) {
+	return append([]scoring.ProblemScore(nil), f.scores...), nil
+}
+
+func (f fixedScores) GetLeechThreshold(ctx context.Context) int {
+	return 3
+}
+
+// libraryProblem describes one problem in a test library
+type libraryProblem struct {
+	title      string
+	difficulty string
+	score      float64
+	confidence int32
+	failures   int32
+	patterns   []repo.Pattern
+	company    uuid.UUID
+}
+
+func newLibrary(userID uuid.UUID, problems []libraryProblem) (*libraryRepo, fixedScores) {
+	library := &libraryRepo{
+		Querier:   testutil.NewQuerier(),
+		problems:  map[uuid.UUID]repo.Problem{},
+		stats:     map[uuid.UUID]repo.UserProblemStat{},
+		patterns:  map[uuid.UUID][]repo.Pattern{},
+		companies: map[uuid.UUID][]uuid.UUID{},
+	}
+	var scores fixedScores
+	for _, p := range problems {
+		problem := testutil.Problem(p.title, p.difficulty)
+		// Stats are stamped against the wall clock, which buildAllCandidates
+		// measures days since the last attempt from
+		stats := testutil.ProblemStats(userID, problem.ID, p.confidence, 0)
+		stats.LastAttemptAt = testutil.Timestamp(time.Now().AddDate(0, 0, -7))
+		stats.ConsecutiveFailures = pgtype.Int4{Int32: p.failures, Valid: true}
+
+		library.problems[problem.ID] = problem
+		library.stats[problem.ID] = stats
+		library.patterns[problem.ID] = p.patterns
+		if p.company != uuid.Nil {
+			library.companies[p.company] = append(library.companies[p.company], problem.ID)
+		}
+		scores.scores = append(scores.scores, scoring.ProblemScore{ProblemID: problem.ID, Score: p.score})
+	}
+	return library, scores
+}
+
+func newTestService(library *libraryRepo, scores fixedScores) *sessionService {
+	return NewService(library, scores, metrics.Noop{}, events.Noop{}, webhooks.Noop{}).(*sessionService)
+}
+
+func pattern(title string) repo.Pattern {
+	return repo.Pattern{ID: uuid.New(), Title: title}
+}
+
+func titles(problems []SessionProblem) []string {
+	out := make([]string, len(problems))
+	for i, p := range problems {
+		out[i] = p.Title
+	}
+	return out
+}
+
+func equalTitles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildSessionWithConstraints(t *testing.T) {
+	arrays, graphs := pattern("Arrays"), pattern("Graphs")
+	acme := uuid.New()
+
+	tests := []struct {
+		name     string
+		problems []libraryProblem
+		template TemplateConfig
+		duration int64
+		want     []string
+	}{
+		{
+			name: "fills the time budget in score order",
+			problems: []libraryProblem{
+				{title: "a", difficulty: "medium", score: 0.9},
+				{title: "b", difficulty: "medium", score: 0.8},
+				{title: "c", difficulty: "medium", score: 0.7},
+			},
+			template: TemplateConfig{PatternMode: "all"},
+			duration: 50,
+			want:     []string{"a", "b"},
+		},
+		{
+			name: "skips a problem that doesn't fit for a smaller one that does",
+			problems: []libraryProblem{
+				{title: "medium", difficulty: "medium", score: 0.9},
+				{title: "hard", difficulty: "hard", score: 0.8},
+				{title: "easy", difficulty: "easy", score: 0.7},
+			},
+			template: TemplateConfig{PatternMode: "all"},
+			duration: 40,
+			want:     []string{"medium", "easy"},
+		},
+		{
+			name: "never relaxes the difficulty cap",
+			problems: []libraryProblem{
+				{title: "hard", difficulty: "hard", score: 0.9},
+				{title: "easy", difficulty: "easy", score: 0.5},
+			},
+			template: TemplateConfig{PatternMode: "all", MaxDifficulty: "medium"},
+			duration: 60,
+			want:     []string{"easy"},
+		},
+		{
+			name: "leaves leeches out when the template excludes them",
+			problems: []libraryProblem{
+				{title: "leech", difficulty: "easy", score: 0.9, failures: 3},
+				{title: "fine", difficulty: "easy", score: 0.5, failures: 2},
+			},
+			template: TemplateConfig{PatternMode: "all", ExcludeLeeches: true},
+			duration: 60,
+			want:     []string{"fine"},
+		},
+		{
+			name: "keeps leeches when the template allows them",
+			problems: []libraryProblem{
+				{title: "leech", difficulty: "easy", score: 0.9, failures: 3},
+				{title: "fine", difficulty: "easy", score: 0.5},
+			},
+			template: TemplateConfig{PatternMode: "all"},
+			duration: 60,
+			want:     []string{"leech", "fine"},
+		},
+		{
+			name: "relaxes the confidence range before coming up empty",
+			problems: []libraryProblem{
+				{title: "confident", difficulty: "easy", score: 0.9, confidence: 90},
+			},
+			template: TemplateConfig{PatternMode: "all", MaxConfidence: ptr(50)},
+			duration: 30,
+			want:     []string{"confident"},
+		},
+		{
+			name: "applies the confidence range when something matches",
+			problems: []libraryProblem{
+				{title: "confident", difficulty: "easy", score: 0.9, confidence: 90},
+				{title: "shaky", difficulty: "easy", score: 0.5, confidence: 40},
+			},
+			template: TemplateConfig{PatternMode: "all", MaxConfidence: ptr(50)},
+			duration: 30,
+			want:     []string{"shaky"},
+		},
+		{
+			name: "caps problems per pattern",
+			problems: []libraryProblem{
+				{title: "a1", difficulty: "easy", score: 0.9, patterns: []repo.Pattern{arrays}},
+				{title: "a2", difficulty: "easy", score: 0.8, patterns: []repo.Pattern{arrays}},
+				{title: "g1", difficulty: "easy", score: 0.7, patterns: []repo.Pattern{graphs}},
+			},
+			template: TemplateConfig{PatternMode: "all", MaxSamePattern: 1},
+			duration: 60,
+			want:     []string{"a1", "g1"},
+		},
+		{
+			name: "overflows the budget by up to half to reach the minimum",
+			problems: []libraryProblem{
+				{title: "a", difficulty: "medium", score: 0.9},
+				{title: "b", difficulty: "medium", score: 0.8},
+				{title: "c", difficulty: "medium", score: 0.7},
+			},
+			template: TemplateConfig{PatternMode: "all", MinProblems: 3},
+			duration: 40,
+			want:     []string{"a", "b"},
+		},
+		{
+			name: "reaches the minimum when the overflow allows it",
+			problems: []libraryProblem{
+				{title: "a", difficulty: "easy", score: 0.9},
+				{title: "b", difficulty: "easy", score: 0.8},
+				{title: "c", difficulty: "easy", score: 0.7},
+			},
+			template: TemplateConfig{PatternMode: "all", MinProblems: 3},
+			duration: 40,
+			want:     []string{"a", "b", "c"},
+		},
+		{
+			name: "includes one problem even when none fit the budget",
+			problems: []libraryProblem{
+				{title: "hard", difficulty: "hard", score: 0.9},
+			},
+			template: TemplateConfig{PatternMode: "all"},
+			duration: 10,
+			want:     []string{"hard"},
+		},
+		{
+			name: "keeps only the company's problems",
+			problems: []libraryProblem{
+				{title: "other", difficulty: "easy", score: 0.9},
+				{title: "acme", difficulty: "easy", score: 0.5, company: acme},
+			},
+			template: TemplateConfig{PatternMode: "all", CompanyID: ptr(acme.String())},
+			duration: 60,
+			want:     []string{"acme"},
+		},
+		{
+			name: "orders easy to hard in progression mode",
+			problems: []libraryProblem{
+				{title: "hard", difficulty: "hard", score: 0.9},
+				{title: "medium", difficulty: "medium", score: 0.8},
+				{title: "easy", difficulty: "easy", score: 0.7},
+			},
+			template: TemplateConfig{PatternMode: "all", ProgressionMode: true},
+			duration: 90,
+			want:     []string{"easy", "medium", "hard"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			library, scores := newLibrary(userID, tt.problems)
+			s := newTestService(library, scores)
+
+			got, err := s.buildSessionWithConstraints(context.Background(), userID, scores.scores, tt.template, tt.duration)
+			if err != nil {
+				t.Fatalf("buildSessionWithConstraints: %v", err)
+			}
+			if !equalTitles(titles(got), tt.want) {
+				t.Errorf("got %v, want %v", titles(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSessionWithConstraintsErrors(t *testing.T) {
+	acme := uuid.New()
+
+	tests := []struct {
+		name       string
+		problems   []libraryProblem
+		template   TemplateConfig
+		constraint string
+	}{
+		{
+			name:       "empty library",
+			template:   TemplateConfig{PatternMode: "all"},
+			constraint: "minimum_problems",
+		},
+		{
+			name: "company with no practiced problems",
+			problems: []libraryProblem{
+				{title: "other", difficulty: "easy", score: 0.9},
+			},
+			template:   TemplateConfig{PatternMode: "all", CompanyID: ptr(acme.String())},
+			constraint: "company_id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			library, scores := newLibrary(userID, tt.problems)
+			s := newTestService(library, scores)
+
+			_, err := s.buildSessionWithConstraints(context.Background(), userID, scores.scores, tt.template, 60)
+			var genErr *SessionGenerationError
+			if !errors.As(err, &genErr) {
+				t.Fatalf("err = %v, want a SessionGenerationError", err)
+			}
+			if genErr.Constraint != tt.constraint {
+				t.Errorf("constraint = %q, want %q", genErr.Constraint, tt.constraint)
+			}
+		})
+	}
+}
+
+func TestGenerateSessionSortsByScore(t *testing.T) {
+	userID := uuid.New()
+	library, scores := newLibrary(userID, []libraryProblem{
+		{title: "low", difficulty: "easy", score: 0.2},
+		{title: "high", difficulty: "easy", score: 0.9},
+		{title: "mid", difficulty: "easy", score: 0.5},
+	})
+	s := newTestService(library, scores)
+
+	got, err := s.GenerateSession(context.Background(), userID, GenerateSessionBody{
+		TemplateKey: "daily_mixed_grind",
+		DurationMin: ptr(int64(30)),
+	})
+	if err != nil {
+		t.Fatalf("GenerateSession: %v", err)
+	}
+	if want := []string{"high", "mid", "low"}; !equalTitles(titles(got.Problems), want) {
+		t.Errorf("got %v, want %v", titles(got.Problems), want)
+	}
+}
+
+func TestGenerateSessionRequiresCompany(t *testing.T) {
+	library, scores := newLibrary(uuid.New(), nil)
+	s := newTestService(library, scores)
+
+	_, err := s.GenerateSession(context.Background(), uuid.New(), GenerateSessionBody{TemplateKey: "company_focus"})
+	var genErr *SessionGenerationError
+	if !errors.As(err, &genErr) || genErr.Constraint != "company_id" {
+		t.Fatalf("err = %v, want a company_id SessionGenerationError", err)
+	}
+}
@@ -3,7 +3,7 @@ package sessions
 // ptr is a helper function to get pointer to a value
 func ptr[T any](v T) *T { return &v }
 
-// AllTemplates defines the 10 smart preset templates for interview preparation
+// AllTemplates defines the 11 smart preset templates for interview preparation
 // These replace the original 7 basic templates with more intelligent logic
 var AllTemplates = map[string]TemplateConfig{
 	// ========================================================================
@@ -26,6 +26,7 @@ var AllTemplates = map[string]TemplateConfig{
 		ScoringEmphasis:      "standard",
 		MinConfidence:        ptr(70), // Focus on problems with conf >= 70
 		MinDaysSinceLast:     ptr(7),  // Spaced repetition: 7-14 day window
+		ExcludeLeeches:       true,    // Failed-in-a-row problems need a conscious re-add
 	},
 
 	"weakness_crusher": {
@@ -44,6 +45,7 @@ var AllTemplates = map[string]TemplateConfig{
 		PatternCount:         2,            // Pick from 2 weakest patterns
 		ScoringEmphasis:      "confidence", // 2x weight on low confidence
 		MaxConfidence:        ptr(65),      // Only problems with conf < 65
+		ExcludeLeeches:       true,
 	},
 
 	"daily_mixed_grind": {
@@ -61,6 +63,7 @@ var AllTemplates = map[string]TemplateConfig{
 		PatternMode:          "all",
 		ScoringEmphasis:      "standard",
 		AdaptiveDifficulty:   true, // Adjusts based on recent session outcomes
+		ExcludeLeeches:       true,
 	},
 
 	// ========================================================================
@@ -182,6 +185,27 @@ var AllTemplates = map[string]TemplateConfig{
 		ScoringEmphasis:      "standard",
 		MinConfidence:        ptr(60), // Only attempt if somewhat competent
 	},
+
+	// ========================================================================
+	// CATEGORY D: COMPANY TEMPLATES (1)
+	// ========================================================================
+
+	"company_focus": {
+		Key:                  "company_focus",
+		DisplayName:          "Company Focus",
+		Description:          "Prep for a specific company. Its tagged problems with the usual difficulty mix.",
+		Category:             "company",
+		Icon:                 "🏢",
+		DurationMin:          60,
+		DifficultyDist:       &DifficultyDistribution{10, 60, 30}, // Same mix as the daily grind
+		MinQuickWins:         1,
+		MaxSamePattern:       2,
+		MinProblems:          3,
+		MinDifferentPatterns: 2,
+		PatternMode:          "all",
+		CompanyMode:          "specific", // User selects company
+		ScoringEmphasis:      "standard",
+	},
 }
 
 // GetTemplate retrieves a template by key
@@ -16,7 +16,11 @@ type CreateSessionBody struct {
 type GenerateSessionBody struct {
 	TemplateKey string  `json:"template_key" validate:"required"`
 	DurationMin *int64  `json:"duration_min" validate:"omitempty,gte=1"`
-	PatternID   *string `json:"pattern_id" validate:"omitempty"` // For pattern-specific templates
+	PatternID   *string `json:"pattern_id" validate:"omitempty"`      // For pattern-specific templates
+	CompanyID   *string `json:"company_id" validate:"omitempty,uuid"` // Only problems tagged with this company; required by company templates
+
+	// PreferStarred boosts starred problems' scores during selection
+	PreferStarred bool `json:"prefer_starred"`
 }
 
 type GenerateCustomSessionBody struct {
@@ -39,7 +43,7 @@ type SessionResponse struct {
 }
 
 type UpdateSessionTimerBody struct {
-	ElapsedTimeSeconds int64  `json:"elapsed_time_seconds" validate:"required,gte=0"`
+	ElapsedTimeSeconds int64  `json:"elapsed_time_seconds" validate:"gte=0"`
 	TimerState         string `json:"timer_state" validate:"required,oneof=idle running paused"`
 }
 
@@ -61,6 +65,8 @@ type SessionProblem struct {
 	CreatedAt     string  `json:"created_at"`
 	Completed     bool    `json:"completed"`
 	Outcome       *string `json:"outcome"` // "passed" or "failed"
+	IsLeech       bool    `json:"is_leech"`
+	HasNotes      bool    `json:"has_notes"`
 
 	// Spaced repetition priority indicators
 	Priority     string `json:"priority"`       // "overdue", "due_soon", "on_track", "new"
@@ -114,7 +120,7 @@ type TemplateConfig struct {
 	Key         string `json:"key"`
 	DisplayName string `json:"display_name"`
 	Description string `json:"description"`
-	Category    string `json:"category"` // "daily", "pattern", "weekend"
+	Category    string `json:"category"` // "daily", "pattern", "weekend", "company"
 	Icon        string `json:"icon"`     // Emoji for UI
 	DurationMin int64  `json:"duration_min"`
 
@@ -133,6 +139,10 @@ type TemplateConfig struct {
 	PatternCount int     `json:"pattern_count"`        // For "weakest" mode
 	PatternID    *string `json:"pattern_id,omitempty"` // For "specific" mode (user-provided) - now string UUID
 
+	// Company focus
+	CompanyMode string  `json:"company_mode,omitempty"` // "" (any company) or "specific"
+	CompanyID   *string `json:"company_id,omitempty"`   // User-provided; required for "specific" mode
+
 	// Scoring adjustments
 	ScoringEmphasis string `json:"scoring_emphasis"` // "standard", "confidence", "time", "failure"
 
@@ -144,6 +154,7 @@ type TemplateConfig struct {
 	// Smart features
 	AdaptiveDifficulty bool `json:"adaptive_difficulty"` // Adjust based on recent performance
 	ProgressionMode    bool `json:"progression_mode"`    // Easy → Medium → Hard ordering
+	ExcludeLeeches     bool `json:"exclude_leeches"`     // Leave repeatedly failed problems out
 }
 
 // ============================================================================
@@ -211,3 +222,21 @@ type PaginatedSessions struct {
 	PageSize   int32             `json:"page_size"`
 	TotalPages int32             `json:"total_pages"`
 }
+
+// CompletedEvent is the payload of the session.completed webhook
+type CompletedEvent struct {
+	SessionID          string  `json:"session_id"`
+	TemplateKey        *string `json:"template_key"`
+	PlannedDurationMin int64   `json:"planned_duration_min"`
+	ElapsedTimeSeconds int64   `json:"elapsed_time_seconds"`
+	CompletedAt        string  `json:"completed_at"`
+}
+
+// TimerEvent is the payload of session.* events on the timer stream
+type TimerEvent struct {
+	SessionID          string `json:"session_id"`
+	ElapsedTimeSeconds int64  `json:"elapsed_time_seconds"`
+	TimerState         string `json:"timer_state"`
+	Completed          bool   `json:"completed"`
+	UpdatedAt          string `json:"updated_at"`
+}
@@ -1,8 +1,11 @@
 package settings
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
+	"github.com/vasujain275/reforge/internal/auth"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -22,6 +25,7 @@ func (h *Handler) GetScoringWeights(w http.ResponseWriter, r *http.Request) {
 		utils.InternalServerError(w, err.Error())
 		return
 	}
+	hideHealthUnlessAdmin(r, weights)
 
 	utils.Write(w, http.StatusOK, weights)
 }
@@ -33,16 +37,87 @@ func (h *Handler) GetDefaultWeights(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) UpdateScoringWeights(w http.ResponseWriter, r *http.Request) {
 	var body UpdateScoringWeightsBody
-	if err := utils.Read(r, &body); err != nil {
-		utils.BadRequest(w, err.Error(), nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	weights, err := h.service.UpdateScoringWeights(r.Context(), body)
 	if err != nil {
+		if errors.Is(err, ErrInvalidWeight) {
+			utils.BadRequest(w, err.Error(), nil)
+			return
+		}
 		utils.InternalServerError(w, err.Error())
 		return
 	}
+	hideHealthUnlessAdmin(r, weights)
 
 	utils.Write(w, http.StatusOK, weights)
 }
+
+func (h *Handler) ListPresets(w http.ResponseWriter, r *http.Request) {
+	utils.Write(w, http.StatusOK, h.service.ListPresets())
+}
+
+func (h *Handler) ApplyPreset(w http.ResponseWriter, r *http.Request) {
+	var body ApplyPresetBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	weights, err := h.service.ApplyPreset(r.Context(), body.Preset)
+	if err != nil {
+		if errors.Is(err, ErrPresetNotFound) {
+			utils.NotFound(w, err.Error())
+			return
+		}
+		utils.InternalServerError(w, err.Error())
+		return
+	}
+	hideHealthUnlessAdmin(r, weights)
+
+	utils.Write(w, http.StatusOK, weights)
+}
+
+// hideHealthUnlessAdmin strips the settings health report for non-admin callers
+func hideHealthUnlessAdmin(r *http.Request, weights *ScoringWeightsResponse) {
+	if auth.RoleFromContext(r.Context()) != "admin" {
+		weights.Health = nil
+	}
+}
+
+func (h *Handler) PreviewScoringWeights(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body UpdateScoringWeightsBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
+			limit = parsedLimit
+		}
+	}
+
+	preview, err := h.service.PreviewScoringWeights(r.Context(), userID, body, limit)
+	if err != nil {
+		if errors.Is(err, ErrInvalidWeight) {
+			utils.BadRequest(w, err.Error(), nil)
+			return
+		}
+		utils.InternalServerError(w, err.Error())
+		return
+	}
+	hideHealthUnlessAdmin(r, &preview.CurrentWeights)
+
+	utils.Write(w, http.StatusOK, preview)
+}
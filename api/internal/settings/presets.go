@@ -0,0 +1,83 @@
+package settings
+
+import "math"
+
+// WeightPreset is a named, hand-tuned set of scoring weights
+type WeightPreset struct {
+	Key         string                 `json:"key"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Weights     ScoringWeightsResponse `json:"weights"`
+}
+
+// CustomPresetKey is reported when the stored weights don't match any preset
+const CustomPresetKey = "custom"
+
+// AllPresets lists the built-in presets in display order. Each set sums to 1.0.
+var AllPresets = []WeightPreset{
+	{
+		Key:         "balanced",
+		Name:        "Balanced",
+		Description: "The default mix. Confidence first, then due dates and difficulty.",
+		Weights: ScoringWeightsResponse{
+			WConf: 0.30, WDays: 0.20, WAttempts: 0.10, WTime: 0.05,
+			WDifficulty: 0.15, WFailed: 0.10, WPattern: 0.10,
+		},
+	},
+	{
+		Key:         "interview-cram",
+		Name:        "Interview Cram",
+		Description: "Hammer recent failures and anything overdue before an interview.",
+		Weights: ScoringWeightsResponse{
+			WConf: 0.20, WDays: 0.25, WAttempts: 0.05, WTime: 0.05,
+			WDifficulty: 0.10, WFailed: 0.25, WPattern: 0.10,
+		},
+	},
+	{
+		Key:         "maintenance",
+		Name:        "Maintenance",
+		Description: "Keep a solved library fresh by following the review schedule closely.",
+		Weights: ScoringWeightsResponse{
+			WConf: 0.20, WDays: 0.40, WAttempts: 0.05, WTime: 0.05,
+			WDifficulty: 0.10, WFailed: 0.10, WPattern: 0.10,
+		},
+	},
+	{
+		Key:         "new-material",
+		Name:        "New Material",
+		Description: "Favor problems with few attempts to build familiarity with new topics.",
+		Weights: ScoringWeightsResponse{
+			WConf: 0.20, WDays: 0.10, WAttempts: 0.35, WTime: 0.05,
+			WDifficulty: 0.10, WFailed: 0.10, WPattern: 0.10,
+		},
+	},
+}
+
+// GetPreset looks up a preset by key
+func GetPreset(key string) (WeightPreset, bool) {
+	for _, preset := range AllPresets {
+		if preset.Key == key {
+			return preset, true
+		}
+	}
+	return WeightPreset{}, false
+}
+
+// matchPreset returns the key of the preset equal to the given weights, or
+// CustomPresetKey. Weights are stored with two decimals, so compare loosely.
+func matchPreset(w ScoringWeightsResponse) string {
+	const eps = 0.005
+	for _, preset := range AllPresets {
+		p := preset.Weights
+		if math.Abs(p.WConf-w.WConf) < eps &&
+			math.Abs(p.WDays-w.WDays) < eps &&
+			math.Abs(p.WAttempts-w.WAttempts) < eps &&
+			math.Abs(p.WTime-w.WTime) < eps &&
+			math.Abs(p.WDifficulty-w.WDifficulty) < eps &&
+			math.Abs(p.WFailed-w.WFailed) < eps &&
+			math.Abs(p.WPattern-w.WPattern) < eps {
+			return preset.Key
+		}
+	}
+	return CustomPresetKey
+}
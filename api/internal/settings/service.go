@@ -2,26 +2,45 @@ package settings
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/scoring"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrInvalidWeight  = errors.New("scoring weights must be between 0 and 1")
+	ErrPresetNotFound = fmt.Errorf("scoring weight preset %w", utils.ErrNotFound)
 )
 
 type Service interface {
 	GetScoringWeights(ctx context.Context) (*ScoringWeightsResponse, error)
 	GetDefaultWeights() *ScoringWeightsResponse
 	UpdateScoringWeights(ctx context.Context, body UpdateScoringWeightsBody) (*ScoringWeightsResponse, error)
+	PreviewScoringWeights(ctx context.Context, userID uuid.UUID, body UpdateScoringWeightsBody, limit int) (*WeightsPreviewResponse, error)
+	ListPresets() []WeightPreset
+	ApplyPreset(ctx context.Context, key string) (*ScoringWeightsResponse, error)
 }
 
 type settingsService struct {
 	repo           repo.Querier
+	scoringService scoring.Service
 	defaultWeights *ScoringWeightsResponse
 }
 
-func NewService(repo repo.Querier, defaultWeights *ScoringWeightsResponse) Service {
+func NewService(repo repo.Querier, scoringService scoring.Service, defaultWeights *ScoringWeightsResponse) Service {
 	return &settingsService{
 		repo:           repo,
+		scoringService: scoringService,
 		defaultWeights: defaultWeights,
 	}
 }
@@ -47,9 +66,16 @@ func (s *settingsService) GetScoringWeights(ctx context.Context) (*ScoringWeight
 		WPattern:    s.defaultWeights.WPattern,
 	}
 
-	// Override with stored values
+	// Override with stored values, keeping the default for anything malformed
 	for _, row := range rows {
-		val := parseFloat(row.Value)
+		val, err := parseWeight(row.Key, row.Value)
+		if err != nil {
+			if weights.Health == nil {
+				weights.Health = &SettingsHealth{InvalidKeys: []string{}}
+			}
+			weights.Health.InvalidKeys = append(weights.Health.InvalidKeys, row.Key)
+			continue
+		}
 		switch row.Key {
 		case "w_conf":
 			weights.WConf = val
@@ -68,10 +94,16 @@ func (s *settingsService) GetScoringWeights(ctx context.Context) (*ScoringWeight
 		}
 	}
 
+	weights.ActivePreset = matchPreset(*weights)
+
 	return weights, nil
 }
 
 func (s *settingsService) UpdateScoringWeights(ctx context.Context, body UpdateScoringWeightsBody) (*ScoringWeightsResponse, error) {
+	if err := validateWeights(body); err != nil {
+		return nil, err
+	}
+
 	// Weight descriptions for clarity
 	descriptions := map[string]string{
 		"w_conf":       "Confidence weight for scoring algorithm",
@@ -109,12 +141,157 @@ func (s *settingsService) UpdateScoringWeights(ctx context.Context, body UpdateS
 		}
 	}
 
+	// Weights are global, so every cached score was computed with the old values
+	if err := s.repo.DeleteAllProblemScores(ctx); err != nil {
+		return nil, fmt.Errorf("failed to invalidate cached scores: %w", err)
+	}
+
 	// Return updated weights
 	return s.GetScoringWeights(ctx)
 }
 
-func parseFloat(s string) float64 {
-	var f float64
-	fmt.Sscanf(s, "%f", &f)
-	return f
+func (s *settingsService) ListPresets() []WeightPreset {
+	return AllPresets
+}
+
+func (s *settingsService) ApplyPreset(ctx context.Context, key string) (*ScoringWeightsResponse, error) {
+	preset, ok := GetPreset(key)
+	if !ok {
+		return nil, ErrPresetNotFound
+	}
+
+	w := preset.Weights
+	return s.UpdateScoringWeights(ctx, UpdateScoringWeightsBody{
+		WConf:       w.WConf,
+		WDays:       w.WDays,
+		WAttempts:   w.WAttempts,
+		WTime:       w.WTime,
+		WDifficulty: w.WDifficulty,
+		WFailed:     w.WFailed,
+		WPattern:    w.WPattern,
+	})
+}
+
+func (s *settingsService) PreviewScoringWeights(ctx context.Context, userID uuid.UUID, body UpdateScoringWeightsBody, limit int) (*WeightsPreviewResponse, error) {
+	if err := validateWeights(body); err != nil {
+		return nil, err
+	}
+
+	current, err := s.GetScoringWeights(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currentScores, err := s.scoringService.ComputeScoresForUserWithWeights(ctx, userID, toScoringWeights(*current))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute current scores: %w", err)
+	}
+
+	proposed := ScoringWeightsResponse{
+		WConf:       body.WConf,
+		WDays:       body.WDays,
+		WAttempts:   body.WAttempts,
+		WTime:       body.WTime,
+		WDifficulty: body.WDifficulty,
+		WFailed:     body.WFailed,
+		WPattern:    body.WPattern,
+	}
+	proposedScores, err := s.scoringService.ComputeScoresForUserWithWeights(ctx, userID, toScoringWeights(proposed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute proposed scores: %w", err)
+	}
+
+	currentRanked := rankScores(currentScores)
+	proposedRanked := rankScores(proposedScores)
+
+	return &WeightsPreviewResponse{
+		CurrentWeights:  *current,
+		ProposedWeights: proposed,
+		Current:         s.buildPreviewList(ctx, currentRanked, proposedRanked, limit, false),
+		Proposed:        s.buildPreviewList(ctx, proposedRanked, currentRanked, limit, true),
+	}, nil
+}
+
+// rankScores sorts scores descending and returns them with a 1-based rank lookup
+func rankScores(scores []scoring.ProblemScore) rankedScores {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+
+	ranks := make(map[uuid.UUID]int, len(scores))
+	for i, score := range scores {
+		ranks[score.ProblemID] = i + 1
+	}
+	return rankedScores{scores: scores, ranks: ranks}
+}
+
+type rankedScores struct {
+	scores []scoring.ProblemScore
+	ranks  map[uuid.UUID]int
+}
+
+func (s *settingsService) buildPreviewList(ctx context.Context, list, other rankedScores, limit int, isProposed bool) []PreviewProblem {
+	problems := make([]PreviewProblem, 0, limit)
+	for i := 0; i < len(list.scores) && i < limit; i++ {
+		score := list.scores[i]
+		rank := i + 1
+
+		entry := PreviewProblem{
+			Rank:      rank,
+			ProblemID: score.ProblemID.String(),
+			Score:     score.Score,
+			Reason:    score.Reason,
+		}
+
+		if problem, err := s.repo.GetProblem(ctx, score.ProblemID); err == nil {
+			entry.Title = problem.Title
+			entry.Difficulty = problem.Difficulty.String
+		}
+
+		if otherRank, ok := other.ranks[score.ProblemID]; ok {
+			entry.OtherRank = &otherRank
+			// Delta is always expressed as movement under the proposed weights
+			delta := rank - otherRank
+			if isProposed {
+				delta = otherRank - rank
+			}
+			entry.RankDelta = &delta
+		}
+
+		problems = append(problems, entry)
+	}
+	return problems
+}
+
+func validateWeights(body UpdateScoringWeightsBody) error {
+	for _, w := range []float64{body.WConf, body.WDays, body.WAttempts, body.WTime, body.WDifficulty, body.WFailed, body.WPattern} {
+		if w < 0 || w > 1 {
+			return ErrInvalidWeight
+		}
+	}
+	return nil
+}
+
+func toScoringWeights(w ScoringWeightsResponse) scoring.ScoringWeights {
+	return scoring.ScoringWeights{
+		WConf:       w.WConf,
+		WDays:       w.WDays,
+		WAttempts:   w.WAttempts,
+		WTime:       w.WTime,
+		WDifficulty: w.WDifficulty,
+		WFailed:     w.WFailed,
+		WPattern:    w.WPattern,
+	}
+}
+
+// parseWeight strictly parses a stored weight, logging anything malformed.
+// Weights are saved within 0-1, so a value outside it is as corrupt as one
+// that isn't a number.
+func parseWeight(key, value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || f < 0 || f > 1 {
+		slog.Warn("Ignoring malformed scoring weight setting", "key", key, "value", value)
+		return 0, fmt.Errorf("invalid value %q for %s", value, key)
+	}
+	return f, nil
 }
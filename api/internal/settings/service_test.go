@@ -0,0 +1,91 @@
+package settings
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/testutil"
+)
+
+// weightsRepo serves stored weight settings
+type weightsRepo struct {
+	*testutil.Querier
+	rows []repo.GetScoringWeightsRow
+}
+
+func (f *weightsRepo) GetScoringWeights(ctx context.Context) ([]repo.GetScoringWeightsRow, error) {
+	return f.rows, nil
+}
+
+func TestGetScoringWeightsReportsMalformedRows(t *testing.T) {
+	defaults := &ScoringWeightsResponse{
+		WConf:       0.30,
+		WDays:       0.20,
+		WAttempts:   0.10,
+		WTime:       0.05,
+		WDifficulty: 0.15,
+		WFailed:     0.10,
+		WPattern:    0.10,
+	}
+
+	tests := []struct {
+		name    string
+		rows    []repo.GetScoringWeightsRow
+		invalid []string
+		wConf   float64
+	}{
+		{
+			name:  "all valid",
+			rows:  []repo.GetScoringWeightsRow{{Key: "w_conf", Value: "0.5"}},
+			wConf: 0.5,
+		},
+		{
+			name:    "empty",
+			rows:    []repo.GetScoringWeightsRow{{Key: "w_conf", Value: ""}},
+			invalid: []string{"w_conf"},
+			wConf:   0.30,
+		},
+		{
+			name:    "not a number",
+			rows:    []repo.GetScoringWeightsRow{{Key: "w_conf", Value: "0.5abc"}},
+			invalid: []string{"w_conf"},
+			wConf:   0.30,
+		},
+		{
+			name: "NaN and infinity",
+			rows: []repo.GetScoringWeightsRow{
+				{Key: "w_conf", Value: "NaN"},
+				{Key: "w_days", Value: "+Inf"},
+			},
+			invalid: []string{"w_conf", "w_days"},
+			wConf:   0.30,
+		},
+		{
+			name:    "negative",
+			rows:    []repo.GetScoringWeightsRow{{Key: "w_conf", Value: "-0.3"}},
+			invalid: []string{"w_conf"},
+			wConf:   0.30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &weightsRepo{Querier: testutil.NewQuerier(), rows: tt.rows}
+			weights, err := NewService(f, nil, defaults).GetScoringWeights(context.Background())
+			if err != nil {
+				t.Fatalf("GetScoringWeights: %v", err)
+			}
+			if weights.WConf != tt.wConf {
+				t.Errorf("w_conf = %v, want %v", weights.WConf, tt.wConf)
+			}
+			var invalid []string
+			if weights.Health != nil {
+				invalid = weights.Health.InvalidKeys
+			}
+			if !slices.Equal(invalid, tt.invalid) {
+				t.Errorf("invalid keys = %v, want %v", invalid, tt.invalid)
+			}
+		})
+	}
+}
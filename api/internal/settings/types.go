@@ -8,14 +8,50 @@ type ScoringWeightsResponse struct {
 	WDifficulty float64 `json:"w_difficulty"`
 	WFailed     float64 `json:"w_failed"`
 	WPattern    float64 `json:"w_pattern"`
+
+	// ActivePreset is the matching preset key, or "custom"
+	ActivePreset string `json:"active_preset,omitempty"`
+
+	// Health is only shown to admins and lists stored keys that failed to parse
+	Health *SettingsHealth `json:"health,omitempty"`
+}
+
+type SettingsHealth struct {
+	InvalidKeys []string `json:"invalid_keys"`
 }
 
 type UpdateScoringWeightsBody struct {
-	WConf       float64 `json:"w_conf"       validate:"required,gte=0,lte=1"`
-	WDays       float64 `json:"w_days"       validate:"required,gte=0,lte=1"`
-	WAttempts   float64 `json:"w_attempts"   validate:"required,gte=0,lte=1"`
-	WTime       float64 `json:"w_time"       validate:"required,gte=0,lte=1"`
-	WDifficulty float64 `json:"w_difficulty" validate:"required,gte=0,lte=1"`
-	WFailed     float64 `json:"w_failed"     validate:"required,gte=0,lte=1"`
-	WPattern    float64 `json:"w_pattern"    validate:"required,gte=0,lte=1"`
+	WConf       float64 `json:"w_conf"       validate:"gte=0,lte=1"`
+	WDays       float64 `json:"w_days"       validate:"gte=0,lte=1"`
+	WAttempts   float64 `json:"w_attempts"   validate:"gte=0,lte=1"`
+	WTime       float64 `json:"w_time"       validate:"gte=0,lte=1"`
+	WDifficulty float64 `json:"w_difficulty" validate:"gte=0,lte=1"`
+	WFailed     float64 `json:"w_failed"     validate:"gte=0,lte=1"`
+	WPattern    float64 `json:"w_pattern"    validate:"gte=0,lte=1"`
+}
+
+type ApplyPresetBody struct {
+	Preset string `json:"preset" validate:"required"`
+}
+
+// WeightsPreviewResponse compares the top-N urgent problems under the current
+// weights against a proposed set, without saving anything
+type WeightsPreviewResponse struct {
+	CurrentWeights  ScoringWeightsResponse `json:"current_weights"`
+	ProposedWeights ScoringWeightsResponse `json:"proposed_weights"`
+	Current         []PreviewProblem       `json:"current"`
+	Proposed        []PreviewProblem       `json:"proposed"`
+}
+
+type PreviewProblem struct {
+	Rank       int     `json:"rank"`
+	ProblemID  string  `json:"problem_id"`
+	Title      string  `json:"title"`
+	Difficulty string  `json:"difficulty"`
+	Score      float64 `json:"score"`
+	Reason     string  `json:"reason"`
+	// OtherRank is this problem's rank under the other weight set (nil if not ranked)
+	OtherRank *int `json:"other_rank"`
+	// RankDelta is positive when the proposed weights move the problem up
+	RankDelta *int `json:"rank_delta"`
 }
@@ -0,0 +1,157 @@
+package testutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	"github.com/vasujain275/reforge/internal/adapters/postgres/migrations"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// TestDatabaseEnv names the Postgres connection string integration tests run
+// against. Tests that need a database skip when it isn't set.
+const TestDatabaseEnv = "TEST_DATABASE_URL"
+
+// DB is a migrated database for one test, isolated in its own schema
+type DB struct {
+	Pool       *pgxpool.Pool
+	Queries    *repo.Queries
+	Transactor postgres.Transactor
+}
+
+// NewDB creates a schema on the TEST_DATABASE_URL server, applies every
+// embedded migration to it and drops it when the test ends. The test is
+// skipped when no database is configured.
+func NewDB(t testing.TB) *DB {
+	t.Helper()
+
+	dsn := os.Getenv(TestDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", TestDatabaseEnv)
+	}
+	ctx := context.Background()
+
+	admin, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	schema := "test_" + randomSuffix(t)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("failed to create schema %s: %v", schema, err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+		admin.Close(ctx)
+	})
+
+	// Every connection, the migration one included, resolves tables in the
+	// test's schema
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", TestDatabaseEnv, err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+
+	migrationDB := stdlib.OpenDB(*cfg.ConnConfig.Copy())
+	defer migrationDB.Close()
+	provider, err := goose.NewProvider(goose.DialectPostgres, migrationDB, migrations.EmbeddedMigrations)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := provider.Up(ctx); err != nil {
+		t.Fatalf("failed to apply migrations: %v", err)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("failed to open test pool: %v", err)
+	}
+	// Registered after the schema cleanup, so it runs first
+	t.Cleanup(pool.Close)
+
+	return &DB{
+		Pool:       pool,
+		Queries:    repo.New(pool),
+		Transactor: postgres.NewTransactor(pool),
+	}
+}
+
+// CreateUser inserts an active regular user
+func (db *DB) CreateUser(t testing.TB, email string) repo.CreateUserRow {
+	t.Helper()
+	user, err := db.Queries.CreateUser(context.Background(), repo.CreateUserParams{
+		Email:        email,
+		PasswordHash: "not-a-real-hash",
+		Name:         "Test User",
+		Role:         pgtype.Text{String: "user", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", email, err)
+	}
+	return user
+}
+
+// CreateProblem inserts a problem with the given difficulty
+func (db *DB) CreateProblem(t testing.TB, title, difficulty string) repo.Problem {
+	t.Helper()
+	problem, err := db.Queries.CreateProblem(context.Background(), repo.CreateProblemParams{
+		Title:      title,
+		Source:     pgtype.Text{String: "LeetCode", Valid: true},
+		Difficulty: pgtype.Text{String: difficulty, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create problem %s: %v", title, err)
+	}
+	return problem
+}
+
+func randomSuffix(t testing.TB) string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("failed to generate schema name: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// WrapTransactor runs fn in Inner's transaction with its Querier passed
+// through Wrap, so a test can make one query fail against a real database and
+// check what was rolled back
+type WrapTransactor struct {
+	Inner postgres.Transactor
+	Wrap  func(repo.Querier) repo.Querier
+}
+
+var _ postgres.Transactor = WrapTransactor{}
+
+func (t WrapTransactor) WithTx(ctx context.Context, fn func(ctx context.Context, q repo.Querier) error) error {
+	return t.Inner.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		return fn(ctx, t.Wrap(q))
+	})
+}
+
+// Count returns the number of rows in table matching where, e.g.
+// db.Count(t, "attempts", "user_id = $1", userID)
+func (db *DB) Count(t testing.TB, table, where string, args ...any) int {
+	t.Helper()
+	var n int
+	query := "SELECT COUNT(*) FROM " + table
+	if where != "" {
+		query += " WHERE " + where
+	}
+	if err := db.Pool.QueryRow(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+// Package testutil holds fixtures and fakes for exercising services without a
+// database. Builders return fully valid rows; tests adjust the fields they care
+// about on the returned value.
+package testutil
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Now is the reference time fixtures are stamped relative to, so results that
+// depend on elapsed days don't drift with the wall clock
+var Now = time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+// User returns an active regular user
+func User(email string) repo.User {
+	return repo.User{
+		ID:        uuid.New(),
+		Email:     email,
+		Name:      "Test User",
+		Role:      pgtype.Text{String: "user", Valid: true},
+		IsActive:  pgtype.Bool{Bool: true, Valid: true},
+		CreatedAt: Timestamp(Now.AddDate(0, -1, 0)),
+	}
+}
+
+// Problem returns a problem with the given difficulty
+func Problem(title, difficulty string) repo.Problem {
+	return repo.Problem{
+		ID:         uuid.New(),
+		Title:      title,
+		Source:     pgtype.Text{String: "LeetCode", Valid: true},
+		Difficulty: pgtype.Text{String: difficulty, Valid: true},
+		CreatedAt:  Timestamp(Now.AddDate(0, -1, 0)),
+	}
+}
+
+// ProblemStats returns stats for a solved problem last attempted daysAgo days
+// before Now, with SM-2 state at its defaults after one review
+func ProblemStats(userID, problemID uuid.UUID, confidence int32, daysAgo int) repo.UserProblemStat {
+	lastAttempt := Now.AddDate(0, 0, -daysAgo)
+	return repo.UserProblemStat{
+		ID:                  uuid.New(),
+		UserID:              userID,
+		ProblemID:           problemID,
+		Status:              pgtype.Text{String: "solved", Valid: true},
+		Confidence:          pgtype.Int4{Int32: confidence, Valid: true},
+		AvgConfidence:       pgtype.Int4{Int32: confidence, Valid: true},
+		LastAttemptAt:       Timestamp(lastAttempt),
+		TotalAttempts:       pgtype.Int4{Int32: 1, Valid: true},
+		LastOutcome:         pgtype.Text{String: "passed", Valid: true},
+		NextReviewAt:        Timestamp(lastAttempt.AddDate(0, 0, 1)),
+		IntervalDays:        pgtype.Int4{Int32: 1, Valid: true},
+		EaseFactor:          pgtype.Float4{Float32: 2.5, Valid: true},
+		ReviewCount:         pgtype.Int4{Int32: 1, Valid: true},
+		ConsecutiveFailures: pgtype.Int4{Int32: 0, Valid: true},
+		UpdatedAt:           Timestamp(lastAttempt),
+	}
+}
+
+// Attempts returns completed attempts with the given outcomes, listed oldest
+// first and performed a day apart ending at Now. The result is ordered newest
+// first, the way ListAttemptsForProblem returns them.
+func Attempts(userID, problemID uuid.UUID, outcomes ...string) []repo.Attempt {
+	attempts := make([]repo.Attempt, len(outcomes))
+	for i, outcome := range outcomes {
+		confidence := int32(80)
+		if outcome == "failed" {
+			confidence = 30
+		}
+		attempts[len(outcomes)-1-i] = repo.Attempt{
+			ID:              uuid.New(),
+			UserID:          userID,
+			ProblemID:       problemID,
+			ConfidenceScore: pgtype.Int4{Int32: confidence, Valid: true},
+			DurationSeconds: pgtype.Int4{Int32: 20 * 60, Valid: true},
+			Outcome:         pgtype.Text{String: outcome, Valid: true},
+			PerformedAt:     Timestamp(Now.AddDate(0, 0, i-len(outcomes)+1)),
+			Status:          pgtype.Text{String: "completed", Valid: true},
+		}
+	}
+	return attempts
+}
+
+// Timestamp wraps t as a non-null timestamptz
+func Timestamp(t time.Time) pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
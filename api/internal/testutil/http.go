@@ -0,0 +1,39 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// ErrorResponse decodes the standard error envelope from a recorded response.
+// Details keep their JSON shape; decode them further with DecodeDetails.
+func ErrorResponse(t testing.TB, rec *httptest.ResponseRecorder) utils.Error {
+	t.Helper()
+	var body struct {
+		Success bool            `json:"success"`
+		Error   *utils.Error    `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if body.Success || body.Error == nil {
+		t.Fatalf("response is not an error envelope: success=%v data=%s", body.Success, body.Data)
+	}
+	return *body.Error
+}
+
+// DecodeDetails converts an error's details into v, e.g. []utils.FieldError
+func DecodeDetails(t testing.TB, details any, v any) {
+	t.Helper()
+	raw, err := json.Marshal(details)
+	if err != nil {
+		t.Fatalf("failed to encode error details: %v", err)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatalf("failed to decode error details %s: %v", raw, err)
+	}
+}
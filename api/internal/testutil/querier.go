@@ -0,0 +1,83 @@
+package testutil
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Querier is the base for hand-rolled repo.Querier fakes. It embeds the
+// interface, so a fake only defines the queries the code under test runs:
+//
+//	type statsRepo struct {
+//		*testutil.Querier
+//		attempts []repo.Attempt
+//	}
+//
+//	func (f *statsRepo) ListAttemptsForProblem(ctx context.Context, arg repo.ListAttemptsForProblemParams) ([]repo.Attempt, error) {
+//		f.Record("ListAttemptsForProblem", arg)
+//		return f.attempts, nil
+//	}
+//
+// Calling a query the fake doesn't define panics on the nil embedded
+// interface, which names the missing method in the stack trace.
+type Querier struct {
+	repo.Querier
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ repo.Querier = (*Querier)(nil)
+
+// Call is one recorded query and its argument
+type Call struct {
+	Method string
+	Arg    any
+}
+
+// NewQuerier returns an empty fake base
+func NewQuerier() *Querier {
+	return &Querier{}
+}
+
+// Record notes that method ran with arg; safe for concurrent use
+func (q *Querier) Record(method string, arg any) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.calls = append(q.calls, Call{Method: method, Arg: arg})
+}
+
+// Calls returns every recorded call in order
+func (q *Querier) Calls() []Call {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]Call(nil), q.calls...)
+}
+
+// CallsTo returns the arguments of every recorded call to method, in order
+func (q *Querier) CallsTo(method string) []any {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var args []any
+	for _, c := range q.calls {
+		if c.Method == method {
+			args = append(args, c.Arg)
+		}
+	}
+	return args
+}
+
+// Transactor is a postgres.Transactor that runs fn directly against Q, for
+// services that group their writes in a transaction
+type Transactor struct {
+	Q repo.Querier
+}
+
+var _ postgres.Transactor = Transactor{}
+
+func (t Transactor) WithTx(ctx context.Context, fn func(ctx context.Context, q repo.Querier) error) error {
+	return fn(ctx, t.Q)
+}
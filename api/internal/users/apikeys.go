@@ -0,0 +1,99 @@
+package users
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/security"
+)
+
+// APIKeyPrefix marks a bearer token as a personal API key
+const APIKeyPrefix = "rk_"
+
+// apiKeyDisplayLength is how much of the key is kept in clear for listing
+const apiKeyDisplayLength = len(APIKeyPrefix) + 8
+
+// CreateAPIKey generates a key for the user. The raw key is returned once;
+// only its hash is stored.
+func (s *userService) CreateAPIKey(ctx context.Context, userID uuid.UUID, body CreateAPIKeyBody) (CreateAPIKeyResponse, error) {
+	var expiresAt pgtype.Timestamptz
+	if body.ExpiresInDays != nil {
+		if *body.ExpiresInDays < 1 {
+			return CreateAPIKeyResponse{}, ErrInvalidAPIKeyTTL
+		}
+		expiresAt = pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, *body.ExpiresInDays), Valid: true}
+	}
+
+	token, err := security.GenerateSecureToken(32)
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+	rawKey := APIKeyPrefix + strings.TrimRight(token, "=")
+
+	key, err := s.repo.CreateAPIKey(ctx, repo.CreateAPIKeyParams{
+		UserID:    userID,
+		Name:      strings.TrimSpace(body.Name),
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   security.HashToken(rawKey),
+		ReadOnly:  body.ReadOnly,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+
+	return CreateAPIKeyResponse{
+		APIKeyResponse: APIKeyResponse{
+			ID:         key.ID.String(),
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			ReadOnly:   key.ReadOnly,
+			ExpiresAt:  timestampToPtr(key.ExpiresAt),
+			LastUsedAt: timestampToPtr(key.LastUsedAt),
+			CreatedAt:  key.CreatedAt.Format(time.RFC3339),
+		},
+		Key: rawKey,
+	}, nil
+}
+
+// ListAPIKeys returns the user's keys without any secret material
+func (s *userService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]APIKeyResponse, error) {
+	rows, err := s.repo.ListUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]APIKeyResponse, len(rows))
+	for i, row := range rows {
+		keys[i] = APIKeyResponse{
+			ID:         row.ID.String(),
+			Name:       row.Name,
+			Prefix:     row.Prefix,
+			ReadOnly:   row.ReadOnly,
+			ExpiresAt:  timestampToPtr(row.ExpiresAt),
+			LastUsedAt: timestampToPtr(row.LastUsedAt),
+			CreatedAt:  row.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey deletes one of the user's keys
+func (s *userService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	deleted, err := s.repo.DeleteUserAPIKey(ctx, repo.DeleteUserAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
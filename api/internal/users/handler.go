@@ -1,12 +1,14 @@
 package users
 
 import (
-	"log/slog"
+	"fmt"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/vasujain275/reforge/internal/admin"
 	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
 	"github.com/vasujain275/reforge/internal/utils"
 )
 
@@ -27,68 +29,49 @@ func (h *handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	var body CreateUserBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, err.Error(), nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
 	// Check signup settings
 	settings, err := h.adminService.GetSignupSettings(r.Context())
 	if err != nil {
-		slog.Error("Failed to get signup settings", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to get signup settings", "error", err)
 		utils.InternalServerError(w, "Failed to check signup settings")
 		return
 	}
 
-	// Check if signup is enabled
-	if !settings.SignupEnabled {
+	// With invite codes enabled a valid code is always required, and it is the
+	// only way in while public signup is disabled
+	if !settings.SignupEnabled && !settings.InviteCodesEnabled {
 		utils.Forbidden(w, "Registration is currently disabled")
 		return
 	}
 
-	// Check if invite codes are required
-	var inviteCodeToUse string
-	if settings.InviteCodesEnabled {
-		if body.InviteCode == nil || *body.InviteCode == "" {
+	user, err := h.service.CreateUser(r.Context(), body, settings.InviteCodesEnabled)
+	if err != nil {
+		if err == ErrInviteCodeRequired {
 			utils.BadRequest(w, "Invite code is required", nil)
 			return
 		}
-
-		// Validate invite code (but don't consume it yet)
-		if err := h.adminService.ValidateInviteCode(r.Context(), *body.InviteCode); err != nil {
+		if err == ErrInvalidInviteCode {
 			utils.BadRequest(w, "Invalid or expired invite code", nil)
 			return
 		}
-
-		// Store the code to use after successful user creation
-		inviteCodeToUse = *body.InviteCode
-	}
-
-	// Create the user first
-	user, err := h.service.CreateUser(r.Context(), body)
-	if err != nil {
-		slog.Error("Failed to create user", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to create user", "error", err)
 		utils.InternalServerError(w, "Failed to create user")
 		return
 	}
 
-	// Only consume the invite code after successful user creation
-	if inviteCodeToUse != "" {
-		if err := h.adminService.UseInviteCode(r.Context(), inviteCodeToUse); err != nil {
-			// User was created but invite code consumption failed
-			// Log the error but don't fail the request - user is already created
-			slog.Error("Failed to consume invite code after user creation", "error", err, "user_id", user.ID)
-		}
-	}
-
 	utils.WriteSuccess(w, http.StatusCreated, user)
 }
 
 func (h *handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 
 	// Get ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
@@ -104,80 +87,281 @@ func (h *handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, user)
 }
 
+// UpdateProfile - PUT /api/v1/users/me
+func (h *handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body UpdateProfileBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), userID, body)
+	if err != nil {
+		switch err {
+		case ErrNameRequired:
+			utils.BadRequest(w, "Name cannot be empty", nil)
+		case ErrInvalidEmail:
+			utils.BadRequest(w, "Invalid email address", nil)
+		case ErrEmailTaken:
+			utils.Conflict(w, "Email is already in use", nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to update profile", "error", err)
+			utils.InternalServerError(w, "Failed to update profile")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, user)
+}
+
 func (h *handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body ChangePasswordBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, err.Error(), nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
-	err := h.service.ChangePassword(r.Context(), userID, body.OldPassword, body.NewPassword)
+	// The session making the change stays signed in; all others are revoked
+	currentToken := ""
+	if cookie, err := r.Cookie(auth.RefreshTokenCookie); err == nil {
+		currentToken = cookie.Value
+	}
+
+	user, err := h.service.ChangePassword(r.Context(), userID, body.OldPassword, body.NewPassword, currentToken)
 	if err != nil {
 		if err == ErrInvalidPassword {
 			utils.Unauthorized(w, "Current password is incorrect")
 			return
 		}
-		slog.Error("Failed to change password", "error", err)
+		if err == ErrPasswordTooShort {
+			utils.BadRequest(w, fmt.Sprintf("Password must be at least %d characters", MinPasswordLength), nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to change password", "error", err)
 		utils.InternalServerError(w, "Failed to change password")
 		return
 	}
 
-	utils.WriteSuccess(w, http.StatusOK, map[string]string{
-		"message": "Password changed successfully",
-	})
+	utils.WriteSuccess(w, http.StatusOK, user)
 }
 
 func (h *handler) DeleteOwnAccount(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Get user ID from context
-	userID, ok := r.Context().Value(auth.UserKey).(uuid.UUID)
+	userID, ok := auth.UserIDFromContext(r.Context())
 	if !ok {
 		utils.InternalServerError(w, "User ID is missing from context")
 		return
 	}
 
 	var body DeleteAccountBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, err.Error(), nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
-	err := h.service.DeleteOwnAccount(r.Context(), userID, body.Password)
+	email, err := h.service.VerifyPassword(r.Context(), userID, body.Password)
 	if err != nil {
 		if err == ErrInvalidPassword {
 			utils.Unauthorized(w, "Password is incorrect")
 			return
 		}
-		slog.Error("Failed to delete account", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to verify password", "error", err)
 		utils.InternalServerError(w, "Failed to delete account")
 		return
 	}
 
-	utils.WriteSuccess(w, http.StatusOK, map[string]string{
+	// Same purge as the admin endpoint, so all practice data goes with the account
+	manifest, err := h.adminService.PurgeUser(r.Context(), userID, userID, email)
+	if err != nil {
+		if err == admin.ErrLastAdmin {
+			utils.BadRequest(w, "Cannot delete the last admin", nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete account", "error", err)
+		utils.InternalServerError(w, "Failed to delete account")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{
 		"message": "Account deleted successfully",
+		"deleted": manifest.Deleted,
 	})
 }
 
-// ResetPassword - POST /api/v1/users/reset-password (Public - no auth required)
+// CreateAPIKey - POST /api/v1/users/me/api-keys
+func (h *handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body CreateAPIKeyBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(r.Context(), userID, body)
+	if err != nil {
+		if err == ErrInvalidAPIKeyTTL {
+			utils.BadRequest(w, "expires_in_days must be at least 1", nil)
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to create API key", "error", err)
+		utils.InternalServerError(w, "Failed to create API key")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, key)
+}
+
+// ListAPIKeys - GET /api/v1/users/me/api-keys
+func (h *handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list API keys", "error", err)
+		utils.InternalServerError(w, "Failed to list API keys")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"api_keys": keys})
+}
+
+// RevokeAPIKey - DELETE /api/v1/users/me/api-keys/:id
+func (h *handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid API key ID format", nil)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		if err == ErrAPIKeyNotFound {
+			utils.NotFound(w, "API key not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to revoke API key", "error", err)
+		utils.InternalServerError(w, "Failed to revoke API key")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
+
+// ListSessions - GET /api/v1/users/me/sessions
+func (h *handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	currentToken := ""
+	if cookie, err := r.Cookie(auth.RefreshTokenCookie); err == nil {
+		currentToken = cookie.Value
+	}
+
+	sessions, err := h.service.ListSessions(r.Context(), userID, currentToken)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list sessions", "error", err)
+		utils.InternalServerError(w, "Failed to list sessions")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"sessions": sessions})
+}
+
+// RevokeSession - DELETE /api/v1/users/me/sessions/:id
+func (h *handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid session ID format", nil)
+		return
+	}
+
+	if err := h.service.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		if err == ErrSessionNotFound {
+			utils.NotFound(w, "Session not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to revoke session", "error", err)
+		utils.InternalServerError(w, "Failed to revoke session")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// RevokeAllSessions - POST /api/v1/users/me/sessions/revoke-all
+func (h *handler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	if err := h.service.RevokeAllSessions(r.Context(), userID); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to revoke sessions", "error", err)
+		utils.InternalServerError(w, "Failed to revoke sessions")
+		return
+	}
+
+	auth.ClearTokenCookies(w)
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Signed out on all devices"})
+}
+
+// ResetPassword - POST /api/v1/auth/reset-password (Public - no auth required)
 func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	var body ResetPasswordBody
-	if err := utils.Read(r, &body); err != nil {
-		slog.Error("Failed to parse request body", "error", err)
-		utils.BadRequest(w, err.Error(), nil)
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
 		return
 	}
 
@@ -187,11 +371,11 @@ func (h *handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 			utils.BadRequest(w, "Invalid or expired reset link", nil)
 			return
 		}
-		if err == ErrResetTokenUsed {
-			utils.BadRequest(w, "This reset link has already been used", nil)
+		if err == ErrPasswordTooShort {
+			utils.BadRequest(w, fmt.Sprintf("Password must be at least %d characters", MinPasswordLength), nil)
 			return
 		}
-		slog.Error("Failed to reset password", "error", err)
+		logging.FromContext(r.Context()).Error("Failed to reset password", "error", err)
 		utils.InternalServerError(w, "Failed to reset password")
 		return
 	}
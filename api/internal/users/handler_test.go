@@ -0,0 +1,39 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/testutil"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+// Password rule violations name the field and rule but never echo the value
+func TestChangePasswordValidationHidesPasswords(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/me/password",
+		strings.NewReader(`{"old_password": "", "new_password": "hunter2"}`))
+	req = req.WithContext(auth.WithUser(req.Context(), uuid.New(), "user"))
+	rec := httptest.NewRecorder()
+	NewHandler(nil, nil).ChangePassword(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if strings.Contains(rec.Body.String(), "hunter2") {
+		t.Errorf("response echoes the password: %s", rec.Body)
+	}
+	var fields []utils.FieldError
+	testutil.DecodeDetails(t, testutil.ErrorResponse(t, rec).Details, &fields)
+	want := []utils.FieldError{
+		{Field: "old_password", Rule: "required"},
+		{Field: "new_password", Rule: "min", Param: "8"},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("details = %+v, want %+v", fields, want)
+	}
+}
@@ -2,33 +2,59 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
 	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
 	"github.com/vasujain275/reforge/internal/security"
 )
 
 type Service interface {
-	CreateUser(ctx context.Context, body CreateUserBody) (UserResponse, error)
+	CreateUser(ctx context.Context, body CreateUserBody, inviteRequired bool) (UserResponse, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (UserResponse, error)
-	ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword string) error
-	DeleteOwnAccount(ctx context.Context, userID uuid.UUID, password string) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, body UpdateProfileBody) (UserResponse, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword, currentToken string) (UserResponse, error)
+	VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (string, error)
 	ResetPasswordWithToken(ctx context.Context, token, newPassword string) error
+
+	// API Keys
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, body CreateAPIKeyBody) (CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+
+	// Sessions
+	ListSessions(ctx context.Context, userID uuid.UUID, currentToken string) ([]SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
 }
 
 type userService struct {
 	repo repo.Querier
+	tx   postgres.Transactor
 }
 
-func NewService(repo repo.Querier) Service {
+func NewService(repo repo.Querier, tx postgres.Transactor) Service {
 	return &userService{
 		repo: repo,
+		tx:   tx,
 	}
 }
 
-func (s *userService) CreateUser(ctx context.Context, body CreateUserBody) (UserResponse, error) {
+// CreateUser registers a user. When inviteRequired is set the body's invite
+// code is consumed in the same transaction as the insert, so a failed signup
+// doesn't use up the code and concurrent signups can't exceed max_uses.
+func (s *userService) CreateUser(ctx context.Context, body CreateUserBody, inviteRequired bool) (UserResponse, error) {
+	if inviteRequired && (body.InviteCode == nil || *body.InviteCode == "") {
+		return UserResponse{}, ErrInviteCodeRequired
+	}
 
 	passwordHash, err := security.HashPassword(body.Password)
 
@@ -36,14 +62,44 @@ func (s *userService) CreateUser(ctx context.Context, body CreateUserBody) (User
 		return UserResponse{}, err
 	}
 
-	params := repo.CreateUserParams{
-		Email:        body.Email,
-		Name:         body.Name,
-		PasswordHash: passwordHash,
-		Role:         pgtype.Text{String: "user", Valid: true}, // Default role
-	}
-
-	user, err := s.repo.CreateUser(ctx, params)
+	var user repo.CreateUserRow
+	err = s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		var (
+			inviteCodeID uuid.UUID
+			err          error
+		)
+		if inviteRequired {
+			inviteCodeID, err = q.ConsumeInviteCode(ctx, *body.InviteCode)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrInvalidInviteCode
+				}
+				return fmt.Errorf("failed to consume invite code: %w", err)
+			}
+		}
+
+		params := repo.CreateUserParams{
+			Email:        body.Email,
+			Name:         body.Name,
+			PasswordHash: passwordHash,
+			Role:         pgtype.Text{String: "user", Valid: true}, // Default role
+		}
+
+		user, err = q.CreateUser(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		if inviteRequired {
+			if err := q.RecordInviteCodeUse(ctx, repo.RecordInviteCodeUseParams{
+				CodeID: inviteCodeID,
+				UserID: user.ID,
+			}); err != nil {
+				return fmt.Errorf("failed to record invite code: %w", err)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return UserResponse{}, err
 	}
@@ -58,7 +114,9 @@ func (s *userService) GetUserByID(ctx context.Context, userID uuid.UUID) (UserRe
 		return UserResponse{}, err
 	}
 
-	return ToUserResponse(user.ID, user.Email, user.Name, user.Role, user.IsActive, user.CreatedAt), nil
+	resp := ToUserResponse(user.ID, user.Email, user.Name, user.Role, user.IsActive, user.CreatedAt)
+	resp.LastLoginAt = timestampToPtr(user.LastLoginAt)
+	return resp, nil
 }
 
 // ToUserResponse converts DB row to UserResponse (exported for use by auth package)
@@ -89,80 +147,145 @@ func ToUserResponse(id uuid.UUID, email, name string, role pgtype.Text, isActive
 	}
 }
 
-func (s *userService) ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword string) error {
+// UpdateProfile changes the user's name and/or email
+func (s *userService) UpdateProfile(ctx context.Context, userID uuid.UUID, body UpdateProfileBody) (UserResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return UserResponse{}, err
+	}
+
+	name, email := user.Name, user.Email
+	if body.Name != nil {
+		name = strings.TrimSpace(*body.Name)
+		if name == "" {
+			return UserResponse{}, ErrNameRequired
+		}
+	}
+	if body.Email != nil {
+		addr, err := mail.ParseAddress(strings.TrimSpace(*body.Email))
+		if err != nil || addr.Name != "" {
+			return UserResponse{}, ErrInvalidEmail
+		}
+		email = addr.Address
+
+		if email != user.Email {
+			if existing, err := s.repo.GetUserByEmail(ctx, email); err == nil && existing.ID != userID {
+				return UserResponse{}, ErrEmailTaken
+			} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return UserResponse{}, err
+			}
+		}
+	}
+
+	updated, err := s.repo.UpdateUserProfile(ctx, repo.UpdateUserProfileParams{
+		Name:  name,
+		Email: email,
+		ID:    userID,
+	})
+	if err != nil {
+		// Another account may have taken the email since the check above
+		// (23505 is unique_violation)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return UserResponse{}, ErrEmailTaken
+		}
+		return UserResponse{}, err
+	}
+
+	return ToUserResponse(updated.ID, updated.Email, updated.Name, updated.Role, updated.IsActive, updated.CreatedAt), nil
+}
+
+// ChangePassword verifies the current password, sets the new one and signs
+// out every other session. currentToken is the caller's raw refresh token;
+// its session is kept.
+func (s *userService) ChangePassword(ctx context.Context, userID uuid.UUID, oldPassword, newPassword, currentToken string) (UserResponse, error) {
 	// Fetch user with password hash to verify old password
 	user, err := s.repo.GetUserByIDWithPassword(ctx, userID)
 	if err != nil {
-		return err
+		return UserResponse{}, err
 	}
 
 	// Verify old password
 	if !security.CheckPasswordHash(oldPassword, user.PasswordHash) {
-		return ErrInvalidPassword
+		return UserResponse{}, ErrInvalidPassword
+	}
+
+	if len(newPassword) < MinPasswordLength {
+		return UserResponse{}, ErrPasswordTooShort
 	}
 
 	newHash, err := security.HashPassword(newPassword)
 	if err != nil {
-		return err
+		return UserResponse{}, err
 	}
 
-	return s.repo.UpdateUserPassword(ctx, repo.UpdateUserPasswordParams{
+	if err := s.repo.UpdateUserPassword(ctx, repo.UpdateUserPasswordParams{
 		PasswordHash: newHash,
 		ID:           userID,
-	})
+	}); err != nil {
+		return UserResponse{}, err
+	}
+
+	if err := s.repo.DeleteOtherUserSessions(ctx, repo.DeleteOtherUserSessionsParams{
+		UserID:    userID,
+		TokenHash: security.HashToken(currentToken),
+	}); err != nil {
+		return UserResponse{}, err
+	}
+
+	return ToUserResponse(user.ID, user.Email, user.Name, user.Role, user.IsActive, user.CreatedAt), nil
 }
 
-func (s *userService) DeleteOwnAccount(ctx context.Context, userID uuid.UUID, password string) error {
-	// Verify password before deletion
+// VerifyPassword checks a user's password and returns their email, which
+// account deletion passes on as the purge confirmation
+func (s *userService) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) (string, error) {
 	user, err := s.repo.GetUserByIDWithPassword(ctx, userID)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Verify password
 	if !security.CheckPasswordHash(password, user.PasswordHash) {
-		return ErrInvalidPassword
+		return "", ErrInvalidPassword
 	}
 
-	return s.repo.DeleteUser(ctx, userID)
+	return user.Email, nil
 }
 
-// ResetPasswordWithToken validates the token and resets the user's password
+// ResetPasswordWithToken consumes a reset token, sets the new password and
+// revokes every refresh token the user holds. Unknown, expired and used tokens
+// all return ErrInvalidResetToken so callers can't tell them apart.
 func (s *userService) ResetPasswordWithToken(ctx context.Context, token, newPassword string) error {
-	// Hash the incoming token to look up in database
-	tokenHash := security.HashToken(token)
-
-	// Find the token record
-	resetToken, err := s.repo.GetPasswordResetToken(ctx, tokenHash)
-	if err != nil {
-		return ErrInvalidResetToken
+	if len(newPassword) < MinPasswordLength {
+		return ErrPasswordTooShort
 	}
 
-	// Check if token was already used
-	if resetToken.UsedAt.Valid {
-		return ErrResetTokenUsed
+	// Hash the new password before touching the token so a hashing failure
+	// doesn't burn a valid link
+	newHash, err := security.HashPassword(newPassword)
+	if err != nil {
+		return err
 	}
 
-	// Check expiration (ExpiresAt is now time.Time directly)
-	if time.Now().After(resetToken.ExpiresAt) {
-		return ErrInvalidResetToken
-	}
+	// Hash the incoming token to look up in database
+	tokenHash := security.HashToken(token)
 
-	// Hash the new password
-	newHash, err := security.HashPassword(newPassword)
+	userID, err := s.repo.ConsumePasswordResetToken(ctx, tokenHash)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidResetToken
+		}
 		return err
 	}
 
 	// Update the user's password
 	err = s.repo.UpdateUserPassword(ctx, repo.UpdateUserPasswordParams{
 		PasswordHash: newHash,
-		ID:           resetToken.UserID,
+		ID:           userID,
 	})
 	if err != nil {
 		return err
 	}
 
-	// Mark the token as used
-	return s.repo.MarkPasswordResetTokenUsed(ctx, resetToken.ID)
+	// Sessions started with the old password must not survive the reset
+	return s.repo.RevokeUserRefreshTokens(ctx, userID)
 }
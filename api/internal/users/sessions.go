@@ -0,0 +1,76 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/security"
+)
+
+// ListSessions returns the user's signed-in devices. currentToken is the raw
+// refresh token from the request cookie, used to mark the caller's session.
+func (s *userService) ListSessions(ctx context.Context, userID uuid.UUID, currentToken string) ([]SessionResponse, error) {
+	rows, err := s.repo.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := ""
+	if currentToken != "" {
+		currentHash = security.HashToken(currentToken)
+	}
+
+	sessions := make([]SessionResponse, len(rows))
+	for i, row := range rows {
+		startedAt := row.StartedAt.Format(time.RFC3339)
+		sessions[i] = SessionResponse{
+			ID:         row.FamilyID.String(),
+			CreatedAt:  &startedAt,
+			LastUsedAt: timestampToPtr(row.LastUsedAt),
+			ExpiresAt:  row.ExpiresAt.Format(time.RFC3339),
+			UserAgent:  textToPtr(row.UserAgent),
+			IPAddress:  textToPtr(row.IpAddress),
+			Current:    currentHash != "" && row.TokenHash == currentHash,
+		}
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out by deleting its token family
+func (s *userService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	deleted, err := s.repo.DeleteUserSession(ctx, repo.DeleteUserSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions signs the user out on every device, including this one
+func (s *userService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.RevokeUserRefreshTokens(ctx, userID)
+}
+
+func timestampToPtr(ts pgtype.Timestamptz) *string {
+	if !ts.Valid {
+		return nil
+	}
+	s := ts.Time.Format(time.RFC3339)
+	return &s
+}
+
+func textToPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
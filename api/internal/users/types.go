@@ -1,16 +1,31 @@
 package users
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vasujain275/reforge/internal/utils"
+)
 
 var (
 	ErrInvalidPassword    = errors.New("invalid password")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrUserNotFound       = fmt.Errorf("user %w", utils.ErrNotFound)
 	ErrSignupDisabled     = errors.New("registration is currently disabled")
 	ErrInviteCodeRequired = errors.New("invite code is required")
+	ErrInvalidInviteCode  = errors.New("invite code is invalid or expired")
 	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
-	ErrResetTokenUsed     = errors.New("reset token has already been used")
+	ErrPasswordTooShort   = errors.New("password is too short")
+	ErrSessionNotFound    = fmt.Errorf("session %w", utils.ErrNotFound)
+	ErrEmailTaken         = errors.New("email is already in use")
+	ErrInvalidEmail       = errors.New("email is invalid")
+	ErrNameRequired       = errors.New("name is required")
+	ErrAPIKeyNotFound     = fmt.Errorf("api key %w", utils.ErrNotFound)
+	ErrInvalidAPIKeyTTL   = errors.New("api key expiry must be positive")
 )
 
+// MinPasswordLength is the shortest password accepted on reset or change
+const MinPasswordLength = 8
+
 // Request types
 type CreateUserBody struct {
 	Name       string  `json:"name" validate:"required"`
@@ -24,6 +39,18 @@ type ChangePasswordBody struct {
 	NewPassword string `json:"new_password" validate:"required,min=8"`
 }
 
+// UpdateProfileBody changes the name and/or email; omitted fields are kept
+type UpdateProfileBody struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email" validate:"omitempty,email"`
+}
+
+type CreateAPIKeyBody struct {
+	Name          string `json:"name"`
+	ExpiresInDays *int   `json:"expires_in_days"` // nil = never expires
+	ReadOnly      bool   `json:"read_only"`
+}
+
 type DeleteAccountBody struct {
 	Password string `json:"password" validate:"required"`
 }
@@ -34,6 +61,35 @@ type ResetPasswordBody struct {
 }
 
 // Response types
+
+type APIKeyResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	ReadOnly   bool    `json:"read_only"`
+	ExpiresAt  *string `json:"expires_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse carries the raw key, which is only ever shown here
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// SessionResponse is one signed-in device. ID is the refresh token family, so
+// it stays the same across token rotations.
+type SessionResponse struct {
+	ID         string  `json:"id"`
+	CreatedAt  *string `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	ExpiresAt  string  `json:"expires_at"`
+	UserAgent  *string `json:"user_agent"`
+	IPAddress  *string `json:"ip_address"`
+	Current    bool    `json:"current"`
+}
+
 type UserResponse struct {
 	ID        string `json:"id"`
 	Email     string `json:"email"`
@@ -41,4 +97,7 @@ type UserResponse struct {
 	Role      string `json:"role"` // Always "user" or "admin", never null in response
 	IsActive  bool   `json:"is_active"`
 	CreatedAt string `json:"created_at"`
+
+	// LastLoginAt is only filled in for the caller's own profile
+	LastLoginAt *string `json:"last_login_at,omitempty"`
 }
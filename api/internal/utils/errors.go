@@ -0,0 +1,9 @@
+package utils
+
+import "errors"
+
+// ErrNotFound is the shared sentinel for a lookup that matched nothing.
+// Packages wrap it in their own errors, e.g.
+// fmt.Errorf("session %w", utils.ErrNotFound), so handlers can answer 404
+// without knowing every package's sentinel.
+var ErrNotFound = errors.New("not found")
@@ -2,9 +2,17 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 )
 
+// errTrailingData is returned by Read when the body holds more than one JSON value
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+// errEmptyBody is returned by Read when the body has no JSON value at all
+var errEmptyBody = errors.New("request body is empty")
+
 func Write(w http.ResponseWriter, status int, data any) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -12,9 +20,25 @@ func Write(w http.ResponseWriter, status int, data any) error {
 	return err
 }
 
+// Read decodes a single JSON value from the request body into data, rejecting
+// unknown fields and anything after the value
 func Read(r *http.Request, data any) error {
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 
-	return decoder.Decode(data)
+	if err := decoder.Decode(data); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errEmptyBody
+		}
+		return err
+	}
+
+	// A second Decode must hit EOF; a MaxBytesError here still means too large
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		if IsBodyTooLarge(err) {
+			return err
+		}
+		return errTrailingData
+	}
+	return nil
 }
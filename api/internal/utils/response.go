@@ -58,7 +58,9 @@ const (
 	ErrCodeForbidden          = "FORBIDDEN"
 	ErrCodeNotFound           = "NOT_FOUND"
 	ErrCodeConflict           = "CONFLICT"
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
 	ErrCodeValidation         = "VALIDATION_ERROR"
+	ErrCodeTooManyRequests    = "TOO_MANY_REQUESTS"
 	ErrCodeInternalServer     = "INTERNAL_SERVER_ERROR"
 	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
 )
@@ -90,11 +92,21 @@ func Conflict(w http.ResponseWriter, message string, details any) {
 	WriteError(w, http.StatusConflict, ErrCodeConflict, message, details)
 }
 
+// PayloadTooLarge writes a 413 Payload Too Large error response
+func PayloadTooLarge(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, message, nil)
+}
+
 // ValidationError writes a 422 Validation Error response
 func ValidationError(w http.ResponseWriter, message string, details any) {
 	WriteError(w, http.StatusUnprocessableEntity, ErrCodeValidation, message, details)
 }
 
+// TooManyRequests writes a 429 Too Many Requests error response
+func TooManyRequests(w http.ResponseWriter, message string) {
+	WriteError(w, http.StatusTooManyRequests, ErrCodeTooManyRequests, message, nil)
+}
+
 // InternalServerError writes a 500 Internal Server Error response
 func InternalServerError(w http.ResponseWriter, message string) {
 	WriteError(w, http.StatusInternalServerError, ErrCodeInternalServer, message, nil)
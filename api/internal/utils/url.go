@@ -0,0 +1,26 @@
+package utils
+
+import "strings"
+
+// NormalizeProblemURL reduces a problem URL to a comparable form: the fragment
+// and query string are dropped, the result is lowercased, the http(s) scheme
+// and a leading "www." are removed and trailing slashes are stripped. The
+// FindProblemByNormalizedURL and FindProblemsByNormalizedURLs queries apply
+// the same steps in SQL, so they must be kept in sync.
+func NormalizeProblemURL(raw string) string {
+	u := strings.TrimSpace(raw)
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+	if i := strings.Index(u, "?"); i >= 0 {
+		u = u[:i]
+	}
+	u = strings.ToLower(u)
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		u = rest
+	} else {
+		u = strings.TrimPrefix(u, "http://")
+	}
+	u = strings.TrimPrefix(u, "www.")
+	return strings.TrimRight(u, "/")
+}
@@ -0,0 +1,48 @@
+package utils
+
+import "testing"
+
+func TestNormalizeProblemURL(t *testing.T) {
+	const want = "leetcode.com/problems/two-sum"
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"canonical", "https://leetcode.com/problems/two-sum"},
+		{"trailing slash", "https://leetcode.com/problems/two-sum/"},
+		{"several trailing slashes", "https://leetcode.com/problems/two-sum//"},
+		{"http", "http://leetcode.com/problems/two-sum"},
+		{"www", "https://www.leetcode.com/problems/two-sum"},
+		{"http and www", "http://www.leetcode.com/problems/two-sum/"},
+		{"no scheme", "leetcode.com/problems/two-sum"},
+		{"query string", "https://leetcode.com/problems/two-sum/?envType=study-plan"},
+		{"fragment", "https://leetcode.com/problems/two-sum/#description"},
+		{"query and fragment", "https://leetcode.com/problems/two-sum?tab=1#top"},
+		{"mixed-case host", "https://LeetCode.COM/problems/two-sum"},
+		{"upper-case scheme", "HTTPS://leetcode.com/problems/two-sum"},
+		{"surrounding space", "  https://leetcode.com/problems/two-sum/  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeProblemURL(tt.url); got != want {
+				t.Errorf("NormalizeProblemURL(%q) = %q, want %q", tt.url, got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeProblemURLKeepsDistinctProblems(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"https://leetcode.com/problems/two-sum", "https://leetcode.com/problems/three-sum"},
+		{"https://leetcode.com/problems/two-sum", "https://neetcode.io/problems/two-sum"},
+		{"https://leetcode.com/problems/two-sum", "ftp://leetcode.com/problems/two-sum"},
+	}
+	for _, tt := range tests {
+		if NormalizeProblemURL(tt.a) == NormalizeProblemURL(tt.b) {
+			t.Errorf("%q and %q normalize to the same URL", tt.a, tt.b)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is shared by every handler; validator caches struct metadata, so a
+// single instance is both cheaper and safe for concurrent use
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Report fields by their json name so details match what the client sent
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// FieldError describes a single failed validation rule
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// ValidationErrors is returned by ReadAndValidate when the body decoded but
+// broke one or more `validate` rules
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fmt.Sprintf("%s failed %s", fe.Field, fe.Rule)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// ReadAndValidate decodes the request body into data and runs its `validate`
+// tags. Rule violations come back as ValidationErrors; anything else is a
+// malformed body.
+func ReadAndValidate(r *http.Request, data any) error {
+	if err := Read(r, data); err != nil {
+		return err
+	}
+
+	err := validate.Struct(data)
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	fields := make(ValidationErrors, len(verrs))
+	for i, fe := range verrs {
+		fields[i] = FieldError{
+			Field: fieldPath(fe.Namespace()),
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+			Value: fieldValue(fe),
+		}
+	}
+	return fields
+}
+
+// InvalidBody writes the response for an error from ReadAndValidate: 422 with
+// field details when validation failed, 413 when the body went over its size
+// limit, 400 when the JSON itself was bad
+func InvalidBody(w http.ResponseWriter, err error) {
+	var fields ValidationErrors
+	if errors.As(err, &fields) {
+		ValidationError(w, "Request validation failed", fields)
+		return
+	}
+	if IsBodyTooLarge(err) {
+		PayloadTooLarge(w, "Request body is too large")
+		return
+	}
+	slog.Debug("Failed to decode request body", "error", err)
+	BadRequest(w, "Invalid request body", err.Error())
+}
+
+// IsBodyTooLarge reports whether err came from reading past a body size limit
+func IsBodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// fieldPath drops the leading struct name, so "CreateAttemptBody.config.min"
+// becomes "config.min"
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}
+
+// fieldValue echoes the offending value back, except for secrets
+func fieldValue(fe validator.FieldError) any {
+	if strings.Contains(strings.ToLower(fe.Field()), "password") {
+		return nil
+	}
+	return fe.Value()
+}
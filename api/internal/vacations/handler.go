@@ -0,0 +1,103 @@
+package vacations
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+// CreateVacation - POST /api/v1/users/me/vacation
+func (h *handler) CreateVacation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body CreateVacationBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	vacation, err := h.service.CreateVacation(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidDates), errors.Is(err, ErrTooLong), errors.Is(err, ErrTooFarBack):
+			utils.BadRequest(w, err.Error(), nil)
+		case errors.Is(err, ErrOverlap):
+			utils.Conflict(w, err.Error(), nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to create vacation", "error", err)
+			utils.InternalServerError(w, "Failed to create vacation")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, vacation)
+}
+
+// ListVacations - GET /api/v1/users/me/vacation
+func (h *handler) ListVacations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	vacations, err := h.service.ListVacations(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list vacations", "error", err)
+		utils.InternalServerError(w, "Failed to list vacations")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"vacations": vacations})
+}
+
+// CancelVacation - DELETE /api/v1/users/me/vacation/:id
+// Undoes the schedule shift of a vacation that has started.
+func (h *handler) CancelVacation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	vacationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid vacation ID format", nil)
+		return
+	}
+
+	vacation, err := h.service.CancelVacation(r.Context(), userID, vacationID)
+	if err != nil {
+		if errors.Is(err, ErrVacationNotFound) {
+			utils.NotFound(w, "Vacation not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to cancel vacation", "error", err)
+		utils.InternalServerError(w, "Failed to cancel vacation")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, vacation)
+}
@@ -0,0 +1,267 @@
+// Package vacations pauses a user's review schedule while they are away. When
+// a vacation starts, or right away when it is recorded after the fact, every
+// due date is pushed out by its length, so nothing piles up as overdue.
+package vacations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vasujain275/reforge/internal/adapters/postgres"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/preferences"
+)
+
+// shiftBatchSize caps how many started vacations one ApplyStartedVacations run shifts
+const shiftBatchSize = 100
+
+type Service interface {
+	CreateVacation(ctx context.Context, userID uuid.UUID, body CreateVacationBody) (*VacationResponse, error)
+	ListVacations(ctx context.Context, userID uuid.UUID) ([]VacationResponse, error)
+	CancelVacation(ctx context.Context, userID, vacationID uuid.UUID) (*VacationResponse, error)
+	ApplyStartedVacations(ctx context.Context) (int, error)
+}
+
+type vacationService struct {
+	repo        repo.Querier
+	tx          postgres.Transactor // The overlap check and insert, and each shift, run atomically
+	preferences preferences.Service // Timezone the vacation dates are in
+}
+
+func NewService(repo repo.Querier, tx postgres.Transactor, preferencesService preferences.Service) Service {
+	return &vacationService{
+		repo:        repo,
+		tx:          tx,
+		preferences: preferencesService,
+	}
+}
+
+// CreateVacation records a vacation. One that has already started is applied
+// straight away; a future one is applied by ApplyStartedVacations once it starts.
+func (s *vacationService) CreateVacation(ctx context.Context, userID uuid.UUID, body CreateVacationBody) (*VacationResponse, error) {
+	locale, err := s.preferences.GetLocale(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := locale.Location
+
+	start, err := time.ParseInLocation(dateLayout, body.StartDate, loc)
+	if err != nil {
+		return nil, ErrInvalidDates
+	}
+	end, err := time.ParseInLocation(dateLayout, body.EndDate, loc)
+	if err != nil || end.Before(start) {
+		return nil, ErrInvalidDates
+	}
+
+	// Calendar days, counted on dates so DST can't make one 23 hours
+	shiftDays := int32(dayNumber(end)-dayNumber(start)) + 1
+	if shiftDays > maxVacationDays {
+		return nil, ErrTooLong
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if start.Before(today.AddDate(0, 0, -maxRetroactiveDays)) {
+		return nil, ErrTooFarBack
+	}
+
+	var vacation repo.UserVacation
+	err = s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		if err := q.LockUserVacations(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock vacations: %w", err)
+		}
+
+		overlapping, err := q.CountOverlappingVacations(ctx, repo.CountOverlappingVacationsParams{
+			UserID:    userID,
+			StartDate: pgtype.Date{Time: start, Valid: true},
+			EndDate:   pgtype.Date{Time: end, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check for overlapping vacations: %w", err)
+		}
+		if overlapping > 0 {
+			return ErrOverlap
+		}
+
+		vacation, err = q.CreateVacation(ctx, repo.CreateVacationParams{
+			UserID:    userID,
+			StartDate: pgtype.Date{Time: start, Valid: true},
+			EndDate:   pgtype.Date{Time: end, Valid: true},
+			StartsAt:  start,
+			ShiftDays: shiftDays,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create vacation: %w", err)
+		}
+
+		if !start.After(now) {
+			vacation, err = s.applyShift(ctx, q, vacation.ID)
+			if err != nil {
+				return err
+			}
+		}
+		return bumpDataVersion(ctx, q, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toVacationResponse(vacation), nil
+}
+
+func (s *vacationService) ListVacations(ctx context.Context, userID uuid.UUID) ([]VacationResponse, error) {
+	rows, err := s.repo.ListUserVacations(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vacations: %w", err)
+	}
+
+	vacations := make([]VacationResponse, len(rows))
+	for i, row := range rows {
+		vacations[i] = *toVacationResponse(row)
+	}
+	return vacations, nil
+}
+
+// CancelVacation ends a vacation and undoes its shift. Due dates that changed
+// since, because the problem was practised, are left as they are.
+func (s *vacationService) CancelVacation(ctx context.Context, userID, vacationID uuid.UUID) (*VacationResponse, error) {
+	var vacation repo.UserVacation
+	err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+		if err := q.LockUserVacations(ctx, userID); err != nil {
+			return fmt.Errorf("failed to lock vacations: %w", err)
+		}
+
+		var err error
+		vacation, err = q.CancelVacation(ctx, repo.CancelVacationParams{
+			ID:     vacationID,
+			UserID: userID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrVacationNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to cancel vacation: %w", err)
+		}
+
+		if vacation.ShiftedAt.Valid {
+			restored, err := q.UndoVacationShift(ctx, vacation.ID)
+			if err != nil {
+				return fmt.Errorf("failed to undo schedule shift: %w", err)
+			}
+			logging.FromContext(ctx).Info("Undid vacation schedule shift",
+				"vacation_id", vacation.ID,
+				"shifted", vacation.ShiftedCount,
+				"restored", restored,
+			)
+		}
+		return bumpDataVersion(ctx, q, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toVacationResponse(vacation), nil
+}
+
+// ApplyStartedVacations shifts the schedules of vacations that have started
+// since the last run and returns how many it shifted
+func (s *vacationService) ApplyStartedVacations(ctx context.Context) (int, error) {
+	pending, err := s.repo.ListPendingVacationShifts(ctx, shiftBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list started vacations: %w", err)
+	}
+
+	applied := 0
+	for _, vacation := range pending {
+		err := s.tx.WithTx(ctx, func(ctx context.Context, q repo.Querier) error {
+			if err := q.LockUserVacations(ctx, vacation.UserID); err != nil {
+				return fmt.Errorf("failed to lock vacations: %w", err)
+			}
+			if _, err := s.applyShift(ctx, q, vacation.ID); err != nil {
+				return err
+			}
+			return bumpDataVersion(ctx, q, vacation.UserID)
+		})
+		if errors.Is(err, ErrVacationNotFound) {
+			continue // Cancelled or applied in the meantime
+		}
+		if err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// applyShift moves the user's due dates by the vacation length, at most once
+// per vacation
+func (s *vacationService) applyShift(ctx context.Context, q repo.Querier, vacationID uuid.UUID) (repo.UserVacation, error) {
+	vacation, err := q.ClaimVacationShift(ctx, vacationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.UserVacation{}, ErrVacationNotFound
+	}
+	if err != nil {
+		return repo.UserVacation{}, fmt.Errorf("failed to claim vacation shift: %w", err)
+	}
+
+	shifted, err := q.ShiftUserReviews(ctx, vacationID)
+	if err != nil {
+		return repo.UserVacation{}, fmt.Errorf("failed to shift review schedule: %w", err)
+	}
+	if err := q.SetVacationShiftedCount(ctx, repo.SetVacationShiftedCountParams{
+		ShiftedCount: int32(shifted),
+		ID:           vacationID,
+	}); err != nil {
+		return repo.UserVacation{}, fmt.Errorf("failed to record schedule shift: %w", err)
+	}
+	vacation.ShiftedCount = int32(shifted)
+
+	logging.FromContext(ctx).Info("Shifted review schedule for vacation",
+		"vacation_id", vacationID,
+		"days", vacation.ShiftDays,
+		"shifted", shifted,
+	)
+	return vacation, nil
+}
+
+// bumpDataVersion marks the user's data as changed, so list ETags built from
+// it stop matching. The background shift runs outside any request, so this
+// can't be left to the HTTP middleware.
+func bumpDataVersion(ctx context.Context, q repo.Querier, userID uuid.UUID) error {
+	if err := q.BumpDataVersion(ctx, userID.String()); err != nil {
+		return fmt.Errorf("failed to bump data version: %w", err)
+	}
+	return nil
+}
+
+func toVacationResponse(vacation repo.UserVacation) *VacationResponse {
+	return &VacationResponse{
+		ID:           vacation.ID.String(),
+		StartDate:    vacation.StartDate.Time.Format(dateLayout),
+		EndDate:      vacation.EndDate.Time.Format(dateLayout),
+		ShiftDays:    vacation.ShiftDays,
+		ShiftedAt:    pgTimestamptzToPtr(vacation.ShiftedAt),
+		ShiftedCount: vacation.ShiftedCount,
+		CancelledAt:  pgTimestamptzToPtr(vacation.CancelledAt),
+		CreatedAt:    vacation.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func pgTimestamptzToPtr(t pgtype.Timestamptz) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.Time.Format(time.RFC3339)
+	return &s
+}
+
+// dayNumber is the number of days from the Unix epoch to t's calendar date
+func dayNumber(t time.Time) int64 {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
@@ -0,0 +1,45 @@
+package vacations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrVacationNotFound = fmt.Errorf("vacation %w", utils.ErrNotFound)
+	ErrInvalidDates     = errors.New("start_date and end_date must be YYYY-MM-DD with end_date on or after start_date")
+	ErrTooLong          = fmt.Errorf("a vacation can last at most %d days", maxVacationDays)
+	ErrTooFarBack       = fmt.Errorf("a vacation can start at most %d days ago", maxRetroactiveDays)
+	ErrOverlap          = errors.New("vacation overlaps an existing one")
+)
+
+const (
+	maxVacationDays = 90
+
+	// maxRetroactiveDays bounds how far back a vacation recorded on return can start
+	maxRetroactiveDays = 90
+
+	dateLayout = "2006-01-02"
+)
+
+// Request types
+
+type CreateVacationBody struct {
+	StartDate string `json:"start_date" validate:"required"` // YYYY-MM-DD, in the user's timezone
+	EndDate   string `json:"end_date"   validate:"required"` // Inclusive
+}
+
+// Response types
+
+type VacationResponse struct {
+	ID           string  `json:"id"`
+	StartDate    string  `json:"start_date"`
+	EndDate      string  `json:"end_date"`
+	ShiftDays    int32   `json:"shift_days"`
+	ShiftedAt    *string `json:"shifted_at"`    // NULL until the vacation has started
+	ShiftedCount int32   `json:"shifted_count"` // Due dates moved
+	CancelledAt  *string `json:"cancelled_at"`
+	CreatedAt    string  `json:"created_at"`
+}
@@ -0,0 +1,186 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Reforge-Event"
+	HeaderDelivery  = "X-Reforge-Delivery"
+	HeaderSignature = "X-Reforge-Signature" // "sha256=" + hex HMAC-SHA256 of the body, keyed with the secret
+)
+
+const (
+	// maxDeliveryAttempts is how many times a delivery is tried before it is
+	// marked failed
+	maxDeliveryAttempts = 6
+
+	// Retries back off exponentially from retryBaseDelay up to retryMaxDelay
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+
+	// deliveryLease is how long a claimed delivery is hidden from other
+	// workers; it only matters if the process dies mid-send
+	deliveryLease = 5 * time.Minute
+
+	deliveryBatchSize = 50
+	deliveryTimeout   = 10 * time.Second
+
+	// deliveryRetention is how long finished deliveries stay in the history
+	deliveryRetention = 30 * 24 * time.Hour
+)
+
+// Dispatcher sends queued deliveries. It is driven by a background job rather
+// than running its own loop.
+type Dispatcher struct {
+	repo   repo.Querier
+	client *http.Client
+}
+
+func NewDispatcher(repo repo.Querier) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: newDeliveryClient(),
+	}
+}
+
+// DeliverDue sends every delivery that is due, one batch at a time, and prunes
+// old history. Failed sends are recorded on the delivery and rescheduled; only
+// database errors are returned.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	for {
+		batch, err := d.repo.ClaimDueWebhookDeliveries(ctx, repo.ClaimDueWebhookDeliveriesParams{
+			Lease:     pgtype.Interval{Microseconds: deliveryLease.Microseconds(), Valid: true},
+			BatchSize: deliveryBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to claim webhook deliveries: %w", err)
+		}
+
+		for _, delivery := range batch {
+			if err := d.deliver(ctx, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < deliveryBatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+
+	pruned, err := d.repo.DeleteOldWebhookDeliveries(ctx, time.Now().Add(-deliveryRetention))
+	if err != nil {
+		return fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+	if pruned > 0 {
+		slog.Info("Pruned old webhook deliveries", "count", pruned)
+	}
+	return nil
+}
+
+// deliver makes one attempt at a delivery and records how it went
+func (d *Dispatcher) deliver(ctx context.Context, delivery repo.ClaimDueWebhookDeliveriesRow) error {
+	statusCode, sendErr := d.send(ctx, delivery)
+	if ctx.Err() != nil {
+		// Shutting down; the lease runs out and the delivery is retried
+		return nil
+	}
+
+	attempts := delivery.AttemptCount + 1
+	params := repo.RecordWebhookDeliveryAttemptParams{
+		ID:            delivery.ID,
+		Status:        StatusSucceeded,
+		NextAttemptAt: time.Now(),
+	}
+	if statusCode != 0 {
+		params.LastStatusCode = pgtype.Int4{Int32: int32(statusCode), Valid: true}
+	}
+	if sendErr != nil {
+		params.LastError = pgtype.Text{String: sendErr.Error(), Valid: true}
+		if attempts >= maxDeliveryAttempts {
+			params.Status = StatusFailed
+		} else {
+			params.Status = StatusPending
+			params.NextAttemptAt = time.Now().Add(retryDelay(attempts))
+		}
+		slog.Warn("Webhook delivery failed",
+			"delivery_id", delivery.ID,
+			"webhook_id", delivery.WebhookID,
+			"attempt", attempts,
+			"status", params.Status,
+			"error", sendErr,
+		)
+	}
+
+	if err := d.repo.RecordWebhookDeliveryAttempt(ctx, params); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// send POSTs the signed envelope and returns the response status, or 0 when no
+// response came back. Any non-2xx response is an error.
+func (d *Dispatcher) send(ctx context.Context, delivery repo.ClaimDueWebhookDeliveriesRow) (int, error) {
+	body, err := json.Marshal(envelope{
+		ID:        delivery.ID.String(),
+		Event:     delivery.EventType,
+		CreatedAt: delivery.CreatedAt.Format(time.RFC3339),
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Reforge-Webhooks")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Only the status is recorded, never the body: the error is shown in the
+	// delivery history, which would otherwise echo whatever the URL returns
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for body, which receivers recompute
+// with the shared secret to check a delivery came from this instance
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay is the wait after the given number of failed attempts
+func retryDelay(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < attempts && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMaxDelay)
+}
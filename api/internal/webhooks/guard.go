@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Webhook URLs are user supplied and fetched by the server, so they must not
+// reach the host itself or the network it sits on (metadata endpoints, the
+// database, other internal services). The URL is checked when a webhook is
+// created, and every connection is checked again at dial time, after DNS
+// resolution, so a hostname that later resolves somewhere internal is still
+// refused.
+
+// isPublicAddr reports whether addr is a unicast address outside the loopback,
+// private, link-local and unspecified ranges
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() &&
+		addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!addr.IsUnspecified()
+}
+
+// checkWebhookURL validates a webhook target: an absolute http(s) URL whose
+// host resolves only to public addresses
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	host := parsed.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !isPublicAddr(addr) {
+			return ErrDisallowedURL
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return ErrDisallowedURL
+	}
+	for _, addr := range addrs {
+		if !isPublicAddr(addr) {
+			return ErrDisallowedURL
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs after name
+// resolution, on the address actually being dialed.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook dial to %q: %w", address, ErrDisallowedURL)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("webhook dial to %s: %w", addrPort.Addr(), ErrDisallowedURL)
+	}
+	return nil
+}
+
+// newDeliveryClient returns the HTTP client deliveries are sent with. It only
+// dials public addresses, ignores proxy settings (a proxy would dial on our
+// behalf, past the check) and doesn't follow redirects, which could lead
+// anywhere.
+func newDeliveryClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: deliveryTimeout,
+		Control: dialControl,
+	}
+	return &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   deliveryTimeout,
+			ResponseHeaderTimeout: deliveryTimeout,
+			IdleConnTimeout:       90 * time.Second,
+			MaxIdleConns:          10,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://93.184.216.34/hook", nil},
+		{"ftp://93.184.216.34/hook", ErrInvalidURL},
+		{"/relative/path", ErrInvalidURL},
+		{"http://", ErrInvalidURL},
+		{"http://127.0.0.1:5432", ErrDisallowedURL},
+		{"http://[::1]/", ErrDisallowedURL},
+		{"http://169.254.169.254/latest/meta-data/", ErrDisallowedURL},
+		{"http://10.0.0.5/internal", ErrDisallowedURL},
+		{"http://localhost:5432", ErrDisallowedURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := checkWebhookURL(context.Background(), tt.url); !errors.Is(err, tt.want) {
+				t.Errorf("checkWebhookURL(%q) = %v, want %v", tt.url, err, tt.want)
+			}
+		})
+	}
+}
+
+func testDelivery(url string) repo.ClaimDueWebhookDeliveriesRow {
+	return repo.ClaimDueWebhookDeliveriesRow{
+		ID:        uuid.New(),
+		WebhookID: uuid.New(),
+		EventType: EventPing,
+		Payload:   []byte(`{}`),
+		CreatedAt: time.Now(),
+		Url:       url,
+		Secret:    "secret",
+	}
+}
+
+func TestSendRefusesLoopback(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{client: newDeliveryClient()}
+	status, err := d.send(context.Background(), testDelivery(srv.URL))
+	if !errors.Is(err, ErrDisallowedURL) {
+		t.Fatalf("send to %s: err = %v, want ErrDisallowedURL", srv.URL, err)
+	}
+	if status != 0 || hit {
+		t.Errorf("send reached the loopback server (status %d)", status)
+	}
+}
+
+// The remaining tests swap in a transport without the dial check so they can
+// use a local server, keeping the rest of the delivery client
+func localDispatcher() *Dispatcher {
+	client := newDeliveryClient()
+	client.Transport = http.DefaultTransport
+	return &Dispatcher{client: client}
+}
+
+func TestSendDoesNotFollowRedirects(t *testing.T) {
+	followed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	status, err := localDispatcher().send(context.Background(), testDelivery(srv.URL+"/hook"))
+	if err == nil || status != http.StatusFound {
+		t.Fatalf("send = (%d, %v), want a 302 error", status, err)
+	}
+	if followed {
+		t.Error("send followed the redirect")
+	}
+}
+
+func TestSendKeepsOnlyTheStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("db_password=hunter2"))
+	}))
+	defer srv.Close()
+
+	status, err := localDispatcher().send(context.Background(), testDelivery(srv.URL))
+	if status != http.StatusInternalServerError || err == nil {
+		t.Fatalf("send = (%d, %v), want a 500 error", status, err)
+	}
+	if strings.Contains(err.Error(), "hunter2") {
+		t.Errorf("error %q includes the response body", err)
+	}
+}
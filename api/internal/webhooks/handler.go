@@ -0,0 +1,154 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/vasujain275/reforge/internal/auth"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *handler {
+	return &handler{
+		service: service,
+	}
+}
+
+// CreateWebhook - POST /api/v1/users/me/webhooks
+func (h *handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	var body CreateWebhookBody
+	if err := utils.ReadAndValidate(r, &body); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to parse request body", "error", err)
+		utils.InvalidBody(w, err)
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), userID, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidURL), errors.Is(err, ErrDisallowedURL), errors.Is(err, ErrInvalidEventType):
+			utils.BadRequest(w, err.Error(), nil)
+		case errors.Is(err, ErrTooManyWebhooks):
+			utils.Conflict(w, err.Error(), nil)
+		default:
+			logging.FromContext(r.Context()).Error("Failed to create webhook", "error", err)
+			utils.InternalServerError(w, "Failed to create webhook")
+		}
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, webhook)
+}
+
+// ListWebhooks - GET /api/v1/users/me/webhooks
+func (h *handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return
+	}
+
+	webhooks, err := h.service.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to list webhooks", "error", err)
+		utils.InternalServerError(w, "Failed to list webhooks")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"webhooks": webhooks})
+}
+
+// DeleteWebhook - DELETE /api/v1/users/me/webhooks/:id
+func (h *handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := h.webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), userID, webhookID); err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			utils.NotFound(w, "Webhook not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to delete webhook", "error", err)
+		utils.InternalServerError(w, "Failed to delete webhook")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Webhook deleted"})
+}
+
+// ListDeliveries - GET /api/v1/users/me/webhooks/:id/deliveries
+func (h *handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := h.webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), userID, webhookID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			utils.NotFound(w, "Webhook not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to list webhook deliveries", "error", err)
+		utils.InternalServerError(w, "Failed to list webhook deliveries")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, map[string]any{"deliveries": deliveries})
+}
+
+// TestWebhook - POST /api/v1/users/me/webhooks/:id/test
+// The ping is queued like any event; its outcome shows up in the deliveries.
+func (h *handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, webhookID, ok := h.webhookParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.TestWebhook(r.Context(), userID, webhookID)
+	if err != nil {
+		if errors.Is(err, ErrWebhookNotFound) {
+			utils.NotFound(w, "Webhook not found")
+			return
+		}
+		logging.FromContext(r.Context()).Error("Failed to test webhook", "error", err)
+		utils.InternalServerError(w, "Failed to test webhook")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusAccepted, delivery)
+}
+
+// webhookParams reads the user and webhook IDs, writing the error response
+// when either is missing or malformed
+func (h *handler) webhookParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.InternalServerError(w, "User ID is missing from context")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.BadRequest(w, "Invalid webhook ID format", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, webhookID, true
+}
@@ -0,0 +1,267 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	repo "github.com/vasujain275/reforge/internal/adapters/postgres/sqlc"
+	"github.com/vasujain275/reforge/internal/logging"
+	"github.com/vasujain275/reforge/internal/security"
+)
+
+const (
+	// maxWebhooksPerUser bounds the fan-out of a single event
+	maxWebhooksPerUser = 10
+
+	// deliveryHistoryLimit is how many deliveries the history endpoint returns
+	deliveryHistoryLimit = 50
+)
+
+// Notifier queues webhook deliveries for practice events. Services call it
+// after their write has committed; delivery happens later in the background.
+type Notifier interface {
+	Notify(ctx context.Context, userID uuid.UUID, eventType string, data any)
+}
+
+// Noop drops every event, for callers outside the HTTP API
+type Noop struct{}
+
+func (Noop) Notify(context.Context, uuid.UUID, string, any) {}
+
+type Service interface {
+	Notifier
+
+	CreateWebhook(ctx context.Context, userID uuid.UUID, body CreateWebhookBody) (*CreateWebhookResponse, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error
+	ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID) ([]DeliveryResponse, error)
+	TestWebhook(ctx context.Context, userID, webhookID uuid.UUID) (*DeliveryResponse, error)
+}
+
+type webhookService struct {
+	repo repo.Querier
+}
+
+func NewService(repo repo.Querier) Service {
+	return &webhookService{
+		repo: repo,
+	}
+}
+
+// Notify queues the event for every matching webhook of the user. Failing to
+// queue is logged rather than returned: the write that caused the event has
+// already succeeded.
+func (s *webhookService) Notify(ctx context.Context, userID uuid.UUID, eventType string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to encode webhook payload", "event", eventType, "error", err)
+		return
+	}
+
+	_, err = s.repo.EnqueueWebhookEvent(context.WithoutCancel(ctx), repo.EnqueueWebhookEventParams{
+		UserID:    userID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to queue webhook deliveries", "event", eventType, "error", err)
+	}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, userID uuid.UUID, body CreateWebhookBody) (*CreateWebhookResponse, error) {
+	if err := checkWebhookURL(ctx, body.URL); err != nil {
+		return nil, err
+	}
+
+	eventTypes := make([]string, 0, len(body.EventTypes))
+	for _, eventType := range body.EventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			return nil, ErrInvalidEventType
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	count, err := s.repo.CountUserWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret := body.Secret
+	if secret == "" {
+		secret, err = security.GenerateSecureToken(32)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	webhook, err := s.repo.CreateWebhook(ctx, repo.CreateWebhookParams{
+		UserID:     userID,
+		Url:        body.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return &CreateWebhookResponse{
+		WebhookResponse: toWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	}, nil
+}
+
+// ListWebhooks returns the user's webhooks without their secrets
+func (s *webhookService) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]WebhookResponse, error) {
+	rows, err := s.repo.ListUserWebhooks(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	webhooks := make([]WebhookResponse, len(rows))
+	for i, row := range rows {
+		webhooks[i] = toWebhookResponse(row)
+	}
+	return webhooks, nil
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+	deleted, err := s.repo.DeleteUserWebhook(ctx, repo.DeleteUserWebhookParams{
+		ID:     webhookID,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if deleted == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the webhook's most recent deliveries, newest first
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID) ([]DeliveryResponse, error) {
+	if _, err := s.getWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.ListWebhookDeliveries(ctx, repo.ListWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     deliveryHistoryLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries := make([]DeliveryResponse, len(rows))
+	for i, row := range rows {
+		deliveries[i] = DeliveryResponse{
+			ID:             row.ID.String(),
+			EventType:      row.EventType,
+			Status:         row.Status,
+			AttemptCount:   row.AttemptCount,
+			LastStatusCode: pgInt4ToPtr(row.LastStatusCode),
+			LastError:      pgTextToPtr(row.LastError),
+			CreatedAt:      row.CreatedAt.Format(time.RFC3339),
+			DeliveredAt:    pgTimestamptzToPtr(row.DeliveredAt),
+		}
+		if row.Status == StatusPending {
+			next := row.NextAttemptAt.Format(time.RFC3339)
+			deliveries[i].NextAttemptAt = &next
+		}
+	}
+	return deliveries, nil
+}
+
+// TestWebhook queues a ping to the webhook, whatever it subscribes to. It goes
+// through the same queue as real events and shows up in the history.
+func (s *webhookService) TestWebhook(ctx context.Context, userID, webhookID uuid.UUID) (*DeliveryResponse, error) {
+	webhook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"webhook_id": webhook.ID.String(),
+		"message":    "Test delivery from Reforge",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.EnqueueWebhookDelivery(ctx, repo.EnqueueWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		EventType: EventPing,
+		Payload:   payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue test delivery: %w", err)
+	}
+
+	next := delivery.NextAttemptAt.Format(time.RFC3339)
+	return &DeliveryResponse{
+		ID:            delivery.ID.String(),
+		EventType:     delivery.EventType,
+		Status:        delivery.Status,
+		AttemptCount:  delivery.AttemptCount,
+		NextAttemptAt: &next,
+		CreatedAt:     delivery.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *webhookService) getWebhook(ctx context.Context, userID, webhookID uuid.UUID) (repo.Webhook, error) {
+	webhook, err := s.repo.GetUserWebhook(ctx, repo.GetUserWebhookParams{
+		ID:     webhookID,
+		UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repo.Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		return repo.Webhook{}, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func toWebhookResponse(webhook repo.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:         webhook.ID.String(),
+		URL:        webhook.Url,
+		EventTypes: webhook.EventTypes,
+		IsActive:   webhook.IsActive,
+		CreatedAt:  webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func pgInt4ToPtr(i pgtype.Int4) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int32
+}
+
+func pgTextToPtr(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func pgTimestamptzToPtr(t pgtype.Timestamptz) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := t.Time.Format(time.RFC3339)
+	return &s
+}
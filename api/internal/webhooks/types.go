@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/vasujain275/reforge/internal/utils"
+)
+
+var (
+	ErrWebhookNotFound  = fmt.Errorf("webhook %w", utils.ErrNotFound)
+	ErrInvalidEventType = errors.New("event types must be among: attempt.completed, session.completed, problem.created")
+	ErrInvalidURL       = errors.New("url must be an absolute http or https URL")
+	ErrDisallowedURL    = errors.New("url must resolve to a public address")
+	ErrTooManyWebhooks  = fmt.Errorf("a user can have at most %d webhooks", maxWebhooksPerUser)
+)
+
+// Event types a webhook can subscribe to
+const (
+	EventAttemptCompleted = "attempt.completed"
+	EventSessionCompleted = "session.completed"
+	EventProblemCreated   = "problem.created"
+
+	// EventPing is only sent by the test-fire endpoint
+	EventPing = "ping"
+)
+
+// EventTypes lists the subscribable event types
+var EventTypes = []string{EventAttemptCompleted, EventSessionCompleted, EventProblemCreated}
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed" // Retries exhausted
+)
+
+// Request types
+
+type CreateWebhookBody struct {
+	URL        string   `json:"url"         validate:"required,url,max=2048"`
+	Secret     string   `json:"secret"      validate:"omitempty,min=16,max=256"` // Generated when empty
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,required"`
+}
+
+// Response types
+
+type WebhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	IsActive   bool     `json:"is_active"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateWebhookResponse is the only time the signing secret is returned
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type DeliveryResponse struct {
+	ID             string  `json:"id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	AttemptCount   int32   `json:"attempt_count"`
+	NextAttemptAt  *string `json:"next_attempt_at"` // Only while pending
+	LastStatusCode *int32  `json:"last_status_code"`
+	LastError      *string `json:"last_error"`
+	CreatedAt      string  `json:"created_at"`
+	DeliveredAt    *string `json:"delivered_at"`
+}
+
+// envelope is the JSON body POSTed to the webhook URL
+type envelope struct {
+	ID        string          `json:"id"` // Delivery ID, the same across retries
+	Event     string          `json:"event"`
+	CreatedAt string          `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -0,0 +1,6 @@
+package sampledatasets
+
+import "embed"
+
+//go:embed *.csv
+var EmbeddedDatasets embed.FS
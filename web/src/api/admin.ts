@@ -21,11 +21,12 @@ export interface UserListResponse {
 export interface InviteCode {
     id: string;
     code: string;
-    created_by_admin_id: string;
+    created_by_admin_id: string | null; // null once the creating admin is purged
     created_at: string;
     expires_at: string | null;
     max_uses: number;
     current_uses: number;
+    users_registered: number;
 }
 
 export interface SignupSettings {
@@ -94,13 +95,14 @@ export const adminApi = {
         return response.data.data.invite_codes || [];
     },
 
-    // Create invite code
+    // Create one or more invite codes
     createInviteCode: async (params?: {
         expires_in?: number;  // Hours until expiration
         max_uses?: number;
-    }): Promise<InviteCode> => {
+        count?: number;       // Codes to generate (default 1)
+    }): Promise<InviteCode[]> => {
         const response = await api.post(`/admin/invites`, params || {});
-        return response.data.data;
+        return response.data.data.invite_codes || [];
     },
 
     // Delete invite code
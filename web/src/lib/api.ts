@@ -6,6 +6,40 @@ export const api = axios.create({
     withCredentials: true, // Important for cookies
 });
 
+const CSRF_COOKIE = "csrf_token";
+const SAFE_METHODS = ["get", "head", "options"];
+
+const readCookie = (name: string) =>
+    document.cookie
+        .split("; ")
+        .find((part) => part.startsWith(`${name}=`))
+        ?.slice(name.length + 1);
+
+// Echo the CSRF cookie on state-changing requests (double-submit)
+api.interceptors.request.use((config) => {
+    const method = (config.method ?? "get").toLowerCase();
+    if (!SAFE_METHODS.includes(method)) {
+        const token = readCookie(CSRF_COOKIE);
+        if (token) {
+            config.headers.set("X-CSRF-Token", decodeURIComponent(token));
+        }
+    }
+    return config;
+});
+
+// Refresh tokens are single-use, so concurrent 401s must share one refresh
+// call; a second call with the same cookie would be treated as token reuse
+let refreshPromise: Promise<unknown> | null = null;
+
+const refreshSession = () => {
+    if (!refreshPromise) {
+        refreshPromise = api.post("/auth/refresh").finally(() => {
+            refreshPromise = null;
+        });
+    }
+    return refreshPromise;
+};
+
 // Response interceptor for handling token refresh
 api.interceptors.response.use(
     (response) => response,
@@ -23,7 +57,7 @@ api.interceptors.response.use(
 
             try {
                 // Attempt to refresh token
-                await api.post("/auth/refresh");
+                await refreshSession();
 
                 // Retry original request
                 return api(originalRequest);
@@ -33,6 +67,18 @@ api.interceptors.response.use(
             }
         }
 
+        // Sessions that started before CSRF tokens existed have no cookie yet;
+        // a refresh issues one
+        if (
+            error.response?.status === 403 &&
+            error.response?.data?.error?.message === "CSRF token missing or invalid" &&
+            !originalRequest._retry
+        ) {
+            originalRequest._retry = true;
+            await refreshSession();
+            return api(originalRequest);
+        }
+
         return Promise.reject(error);
     }
 );
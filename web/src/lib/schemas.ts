@@ -3,6 +3,7 @@ import { z } from "zod";
 export const loginSchema = z.object({
     email: z.string().email("Invalid email address"),
     password: z.string().min(1, "Password is required"),
+    remember_me: z.boolean().optional(),
 });
 
 export const registerSchema = z.object({
@@ -82,7 +82,7 @@ export default function ImportPage() {
             No Datasets Available
           </h3>
           <p className="text-xs text-muted-foreground font-mono mt-1">
-            No bundled datasets found. Check DATASET_PATH configuration.
+            No bundled datasets found. Check the server logs for dataset errors.
           </p>
         </div>
       </div>
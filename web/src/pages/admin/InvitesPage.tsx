@@ -80,10 +80,10 @@ export default function InvitesPage() {
         params.max_uses = parseInt(maxUses);
       }
 
-      const newInvite = await adminApi.createInviteCode(params);
+      const newInvites = await adminApi.createInviteCode(params);
       
       // Copy code to clipboard
-      await navigator.clipboard.writeText(newInvite.code);
+      await navigator.clipboard.writeText(newInvites.map((invite) => invite.code).join("\n"));
       
       toast.success("Invite code created and copied to clipboard!", {
         duration: 5000,
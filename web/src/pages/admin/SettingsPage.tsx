@@ -148,7 +148,9 @@ export default function AdminSettingsPage() {
               </p>
               {!settings.signup_enabled && (
                 <p className="text-xs text-primary font-mono mt-2">
-                  Only admins can create accounts via password reset links
+                  {settings.invite_codes_enabled
+                    ? "Only users with a valid invite code can register"
+                    : "Only admins can create accounts via password reset links"}
                 </p>
               )}
             </div>
@@ -183,17 +185,12 @@ export default function AdminSettingsPage() {
                   ? "Users must provide a valid invite code to register"
                   : "Users can register without an invite code"}
               </p>
-              {!settings.signup_enabled && (
-                <p className="text-xs text-muted-foreground font-mono mt-2 italic">
-                  This setting has no effect while public signup is disabled
-                </p>
-              )}
             </div>
             <Switch
               id="invites-toggle"
               checked={settings.invite_codes_enabled}
               onCheckedChange={handleToggleInvites}
-              disabled={updating === 'invites' || !settings.signup_enabled}
+              disabled={updating === 'invites'}
               className="mt-1"
             />
           </div>
@@ -1,4 +1,5 @@
 import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { COPY } from "@/lib/copy";
@@ -15,6 +16,7 @@ export default function LoginPage() {
   const navigate = useNavigate();
   const [email, setEmail] = useState("");
   const [password, setPassword] = useState("");
+  const [rememberMe, setRememberMe] = useState(false);
   const [error, setError] = useState("");
   const [isSubmitting, setIsSubmitting] = useState(false);
   const [isSuccess, setIsSuccess] = useState(false);
@@ -25,7 +27,7 @@ export default function LoginPage() {
     setError("");
 
     // Zod Validation
-    const result = loginSchema.safeParse({ email, password });
+    const result = loginSchema.safeParse({ email, password, remember_me: rememberMe });
     if (!result.success) {
       setError(result.error.issues[0].message);
       setIsSubmitting(false);
@@ -258,6 +260,18 @@ export default function LoginPage() {
                 />
               </div>
 
+              <div className="flex items-center gap-2">
+                <Checkbox
+                  id="remember-me"
+                  checked={rememberMe}
+                  onCheckedChange={(checked) => setRememberMe(checked === true)}
+                  disabled={isSubmitting}
+                />
+                <Label htmlFor="remember-me" className="text-sm font-normal text-muted-foreground">
+                  {COPY.auth.rememberMe}
+                </Label>
+              </div>
+
               <Button
                 type="submit"
                 disabled={isSubmitting}
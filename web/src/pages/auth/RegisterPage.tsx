@@ -87,7 +87,7 @@ export default function RegisterPage() {
     );
   }
 
-  if (!signupEnabled) {
+  if (!signupEnabled && !inviteCodesRequired) {
     return (
       <div className="min-h-[calc(100vh-4rem)] bg-background relative overflow-hidden flex items-center justify-center p-6">
         {/* Subtle Grid Background */}
@@ -51,7 +51,7 @@ export default function ResetPasswordPage() {
     }
 
     try {
-      await api.post("/users/reset-password", {
+      await api.post("/auth/reset-password", {
         token,
         new_password: password,
       });
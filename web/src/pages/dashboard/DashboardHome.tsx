@@ -77,7 +77,9 @@ export default function DashboardHome() {
     setError(null);
     try {
       const [statsRes, urgentRes] = await Promise.all([
-        api.get("/dashboard/stats"),
+        api.get("/dashboard/stats", {
+          params: { tz: Intl.DateTimeFormat().resolvedOptions().timeZone },
+        }),
         api.get("/problems/urgent"),
       ]);
       setStats(statsRes.data.data);
@@ -236,7 +238,9 @@ export default function DashboardHome() {
                   {stats?.current_streak ?? 0} <span className="text-base font-normal text-muted-foreground">{COPY.dashboard.stats.days}</span>
                 </div>
                 <p className="text-xs text-muted-foreground mt-1">
-                  Keep it going!
+                  {stats?.at_risk
+                    ? "Practice today to keep your streak"
+                    : `Longest: ${stats?.longest_streak ?? 0} ${COPY.dashboard.stats.days}`}
                 </p>
               </CardContent>
             </Card>
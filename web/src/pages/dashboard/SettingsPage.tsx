@@ -11,11 +11,14 @@ import { Label } from "@/components/ui/label";
 import { Separator } from "@/components/ui/separator";
 import { Slider } from "@/components/ui/slider";
 import { api } from "@/lib/api";
+import { useAuthStore } from "@/store/authStore";
 import type { ScoringWeights } from "@/types";
 import { Loader2, Save, Sliders, Settings, RotateCcw } from "lucide-react";
 import { useEffect, useState } from "react";
 
 export default function SettingsPage() {
+  // Weights are instance-wide, so only admins can change them
+  const isAdmin = useAuthStore((state) => state.user?.role === "admin");
   const [loading, setLoading] = useState(true);
   const [isSaving, setIsSaving] = useState(false);
   const [isResetting, setIsResetting] = useState(false);
@@ -148,7 +151,9 @@ export default function SettingsPage() {
               </CardTitle>
             </div>
             <CardDescription>
-              Adjust the 7-feature deterministic scoring algorithm
+              {isAdmin
+                ? "Adjust the 7-feature deterministic scoring algorithm"
+                : "The 7-feature deterministic scoring algorithm, set by your admin"}
             </CardDescription>
           </CardHeader>
           <CardContent className="space-y-6">
@@ -168,6 +173,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -188,6 +194,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -208,6 +215,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -228,6 +236,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -248,6 +257,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -268,6 +278,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -288,6 +299,7 @@ export default function SettingsPage() {
                 min={0}
                 max={1}
                 step={0.01}
+                disabled={!isAdmin}
                 className="w-full"
               />
             </div>
@@ -326,7 +338,7 @@ export default function SettingsPage() {
           <Button
             variant="outline"
             onClick={handleReset}
-            disabled={isResetting || !defaultWeights}
+            disabled={!isAdmin || isResetting || !defaultWeights}
             className="rounded-md border-orange-400/50 text-orange-400 hover:bg-orange-400/10"
           >
             {isResetting ? (
@@ -351,7 +363,7 @@ export default function SettingsPage() {
             </Button>
             <Button
               onClick={handleSave}
-              disabled={isSaving}
+              disabled={!isAdmin || isSaving}
               className="rounded-md"
             >
               {isSaving ? (
@@ -249,6 +249,9 @@ export interface DashboardStats {
   mastered_problems: number;
   avg_confidence: number;
   current_streak: number;
+  longest_streak: number;
+  last_active_date: string | null;
+  at_risk: boolean;
   problems_due?: number;
   weakest_pattern?: {
     name: string;